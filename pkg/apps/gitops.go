@@ -9,6 +9,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"k8s.io/helm/pkg/proto/hapi/chart"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/environments"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // GitOpsOptions is the options used for Git Operations for apps
@@ -200,6 +202,14 @@ func (o *GitOpsOptions) GetApps(appNames map[string]bool, expandFn func([]string
 		return nil, errors.Wrapf(err, "failed to checkout %s to dir %s", o.DevEnv.Spec.Source.Ref, dir)
 	}
 
+	states, err := helmfile.LoadHelmfiles(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load the environment's helmfiles")
+	}
+	if len(states) > 0 {
+		return getHelmfileApps(states, appNames, expandFn), nil
+	}
+
 	envDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
 	if err != nil {
 		return nil, err
@@ -255,3 +265,36 @@ func (o *GitOpsOptions) GetApps(appNames map[string]bool, expandFn func([]string
 	}
 	return &appsList, nil
 }
+
+// getHelmfileApps returns the apps released by the helmfiles of an environment, using the App resources where they
+// exist and otherwise the chart and version of the release
+func getHelmfileApps(states []*helmfile.HelmState, appNames map[string]bool, expandFn func([]string) (*v1.AppList, error)) *v1.AppList {
+	appsList := v1.AppList{}
+	for _, state := range states {
+		for i := range state.Releases {
+			release := &state.Releases[i]
+			name := release.ChartName()
+			if appNames[name] != true && appNames[release.Name] != true && len(appNames) != 0 {
+				continue
+			}
+			resourcesInCRD, _ := expandFn([]string{name})
+			if resourcesInCRD != nil && len(resourcesInCRD.Items) != 0 {
+				appsList.Items = append(appsList.Items, resourcesInCRD.Items...)
+				continue
+			}
+			appsList.Items = append(appsList.Items, v1.App{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: release.Name,
+					Labels: map[string]string{
+						helm.LabelAppName:    name,
+						helm.LabelAppVersion: release.Version,
+					},
+					Annotations: map[string]string{
+						helm.AnnotationAppRepository: state.RepositoryURL(release),
+					},
+				},
+			})
+		}
+	}
+	return &appsList
+}
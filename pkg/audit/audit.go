@@ -0,0 +1,318 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/jenkins-x/jx/v2/pkg/errorutil"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OutcomeSucceeded the outcome of a command which completed successfully
+	OutcomeSucceeded = "Succeeded"
+
+	// OutcomeFailed the outcome of a command which failed
+	OutcomeFailed = "Failed"
+
+	// EventReason the reason of the Kubernetes Events recording jx commands
+	EventReason = "JXCommand"
+
+	// EventReasonFailed the reason of the Kubernetes Events recording failed jx commands
+	EventReasonFailed = "JXCommandFailed"
+
+	// AnnotationEntry the annotation on an Event which contains the audit entry as JSON
+	AnnotationEntry = "jenkins.io/audit-entry"
+
+	// FileExtension the extension of the audit entries stored in a bucket
+	FileExtension = ".yaml"
+
+	defaultTimeout = time.Second * 20
+)
+
+// bucketPrefix the folder in the bucket the audit entries are stored in
+var bucketPrefix = path.Join("jenkins-x", "audit") + "/"
+
+// Entry records who ran which jx command against which namespace or environment and its outcome
+type Entry struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	GitUser     string    `json:"gitUser,omitempty"`
+	KubeUser    string    `json:"kubeUser,omitempty"`
+	Command     string    `json:"command"`
+	Args        []string  `json:"args,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Who returns the identity of the user who ran the command
+func (e *Entry) Who() string {
+	if e.GitUser != "" {
+		return e.GitUser
+	}
+	if e.KubeUser != "" {
+		return e.KubeUser
+	}
+	return "unknown"
+}
+
+// Message returns a human readable description of the entry
+func (e *Entry) Message() string {
+	text := strings.TrimSpace(e.Command + " " + strings.Join(e.Args, " "))
+	message := fmt.Sprintf("%s ran '%s': %s", e.Who(), text, e.Outcome)
+	if e.Error != "" {
+		message += ": " + e.Error
+	}
+	return message
+}
+
+// Filter filters the audit entries when querying the audit log
+type Filter struct {
+	Since       time.Time
+	User        string
+	Command     string
+	Namespace   string
+	Environment string
+	Outcome     string
+}
+
+// Matches returns true if the entry matches the filter
+func (f *Filter) Matches(e *Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.User != "" && !strings.Contains(e.GitUser, f.User) && !strings.Contains(e.KubeUser, f.User) {
+		return false
+	}
+	if f.Command != "" && !strings.Contains(e.Command, f.Command) {
+		return false
+	}
+	if f.Namespace != "" && e.Namespace != f.Namespace {
+		return false
+	}
+	if f.Environment != "" && e.Environment != f.Environment {
+		return false
+	}
+	if f.Outcome != "" && !strings.EqualFold(e.Outcome, f.Outcome) {
+		return false
+	}
+	return true
+}
+
+// Recorder records audit entries as Kubernetes Events in the team namespace and, if a bucket URL is configured,
+// as append only objects in the storage bucket of the team so that they outlive the Events
+type Recorder struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+	BucketURL  string
+	Masker     *kube.LogMasker
+}
+
+// Record masks any secrets in the entry then stores it
+func (r *Recorder) Record(entry *Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == "" {
+		suffix, err := util.RandStringBytesMaskImprSrc(5)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate the audit entry ID")
+		}
+		entry.ID = strings.ToLower(fmt.Sprintf("%s-%s", entry.Time.UTC().Format("20060102-150405"), suffix))
+	}
+	if r.Masker != nil {
+		for i, arg := range entry.Args {
+			entry.Args[i] = r.Masker.MaskLog(arg)
+		}
+		entry.Error = r.Masker.MaskLog(entry.Error)
+	}
+
+	var errs []error
+	if r.KubeClient != nil {
+		err := r.createEvent(entry)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to create the audit Event in namespace %s", r.Namespace))
+		}
+	}
+	if r.BucketURL != "" {
+		err := r.writeBucket(entry)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errorutil.CombineErrors(errs...)
+}
+
+// writeBucket stores the entry in the storage bucket
+func (r *Recorder) writeBucket(entry *Entry) error {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the audit entry to YAML")
+	}
+	err = buckets.WriteBucket(r.BucketURL, EntryKey(entry), bytes.NewReader(data), defaultTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store the audit entry in bucket %s", r.BucketURL)
+	}
+	return nil
+}
+
+// EntryKey returns the key in the storage bucket of the given entry. Entries are grouped by day so that
+// querying a recent time range does not have to list the whole audit log
+func EntryKey(entry *Entry) string {
+	return dayPrefix(entry.Time) + entry.ID + FileExtension
+}
+
+func dayPrefix(t time.Time) string {
+	return bucketPrefix + t.UTC().Format("2006/01/02") + "/"
+}
+
+// createEvent creates an Event on the Environment the command targeted, defaulting to the dev Environment
+func (r *Recorder) createEvent(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the audit entry to JSON")
+	}
+	envName := entry.Environment
+	if envName == "" || strings.Contains(envName, ",") {
+		envName = kube.LabelValueDevEnvironment
+	}
+	eventType := corev1.EventTypeNormal
+	reason := EventReason
+	if entry.Outcome == OutcomeFailed {
+		eventType = corev1.EventTypeWarning
+		reason = EventReasonFailed
+	}
+	t := metav1.NewTime(entry.Time)
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-audit-" + entry.ID,
+			Namespace: r.Namespace,
+			Labels: map[string]string{
+				kube.LabelKind: kube.ValueKindAudit,
+			},
+			Annotations: map[string]string{
+				AnnotationEntry: string(data),
+			},
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "jenkins.io/v1",
+			Kind:       "Environment",
+			Name:       envName,
+			Namespace:  r.Namespace,
+		},
+		Reason:         reason,
+		Message:        entry.Message(),
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "jx"},
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+	}
+	_, err = r.KubeClient.CoreV1().Events(r.Namespace).Create(event)
+	return err
+}
+
+// LoadEntries loads the audit entries matching the filter from the storage bucket if there is one and from the
+// Events in the team namespace, sorted by time
+func LoadEntries(kubeClient kubernetes.Interface, ns string, bucketURL string, filter *Filter) ([]*Entry, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+	entries := map[string]*Entry{}
+	if bucketURL != "" {
+		err := loadBucketEntries(bucketURL, filter, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if kubeClient != nil {
+		err := loadEventEntries(kubeClient, ns, filter, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var answer []*Entry
+	for _, e := range entries {
+		answer = append(answer, e)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		if answer[i].Time.Equal(answer[j].Time) {
+			return answer[i].ID < answer[j].ID
+		}
+		return answer[i].Time.Before(answer[j].Time)
+	})
+	return answer, nil
+}
+
+func loadBucketEntries(bucketURL string, filter *Filter, entries map[string]*Entry) error {
+	prefixes := []string{bucketPrefix}
+	if !filter.Since.IsZero() {
+		prefixes = nil
+		now := time.Now().UTC()
+		for day := filter.Since.UTC(); !day.After(now.AddDate(0, 0, 1)); day = day.AddDate(0, 0, 1) {
+			prefixes = append(prefixes, dayPrefix(day))
+		}
+	}
+	for _, prefix := range prefixes {
+		objects, err := buckets.ListBucket(bucketURL, prefix, defaultTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the audit entries in bucket %s", bucketURL)
+		}
+		for _, o := range objects {
+			if o.IsDir || !strings.HasSuffix(o.Key, FileExtension) {
+				continue
+			}
+			data, err := buckets.ReadBucket(bucketURL, o.Key, defaultTimeout)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read audit entry %s in bucket %s", o.Key, bucketURL)
+			}
+			entry := &Entry{}
+			err = yaml.Unmarshal(data, entry)
+			if err != nil {
+				return errors.Wrapf(err, "failed to unmarshal audit entry %s in bucket %s", o.Key, bucketURL)
+			}
+			if filter.Matches(entry) {
+				entries[entry.ID] = entry
+			}
+		}
+	}
+	return nil
+}
+
+func loadEventEntries(kubeClient kubernetes.Interface, ns string, filter *Filter, entries map[string]*Entry) error {
+	list, err := kubeClient.CoreV1().Events(ns).List(metav1.ListOptions{
+		LabelSelector: kube.LabelKind + "=" + kube.ValueKindAudit,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the audit Events in namespace %s", ns)
+	}
+	for _, event := range list.Items {
+		data := event.Annotations[AnnotationEntry]
+		if data == "" {
+			continue
+		}
+		entry := &Entry{}
+		err = json.Unmarshal([]byte(data), entry)
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the audit entry of Event %s", event.Name)
+		}
+		if entries[entry.ID] == nil && filter.Matches(entry) {
+			entries[entry.ID] = entry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// +build unit
+
+package audit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/audit"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRecordAndLoadEntries(t *testing.T) {
+	t.Parallel()
+
+	ns := "jx"
+	dir, err := ioutil.TempDir("", "test-audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	bucketURL := "file://" + dir
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-pipeline-git-github", Namespace: ns},
+		Data:       map[string][]byte{"password": []byte("mysecrettoken")},
+	})
+	masker, err := kube.NewLogMasker(kubeClient, ns)
+	require.NoError(t, err)
+	masker.LoadWord("mypassword")
+
+	recorder := &audit.Recorder{
+		KubeClient: kubeClient,
+		Namespace:  ns,
+		BucketURL:  bucketURL,
+		Masker:     masker,
+	}
+	now := time.Now()
+	promote := &audit.Entry{
+		Time:        now.Add(-time.Hour),
+		GitUser:     "James Strachan <james@example.com>",
+		Command:     "jx promote",
+		Args:        []string{"myapp", "--env=production", "--version=1.2.3"},
+		Namespace:   "jx-production",
+		Environment: "production",
+		Outcome:     audit.OutcomeSucceeded,
+	}
+	token := &audit.Entry{
+		Time:     now.Add(-48 * time.Hour),
+		KubeUser: "admin",
+		Command:  "jx create token addon",
+		Args:     []string{"anchore", "--api-token=mysecrettoken", "--password=mypassword"},
+		Outcome:  audit.OutcomeFailed,
+		Error:    "failed to validate mysecrettoken",
+	}
+	for _, e := range []*audit.Entry{promote, token} {
+		err = recorder.Record(e)
+		require.NoError(t, err)
+		require.NotEmpty(t, e.ID)
+	}
+	assert.Equal(t, []string{"anchore", "--api-token=*************", "--password=**********"}, token.Args)
+	assert.Equal(t, "failed to validate *************", token.Error)
+
+	_, err = os.Stat(filepath.Join(dir, audit.EntryKey(promote)))
+	assert.NoError(t, err, "the entry should be stored in the bucket")
+
+	events, err := kubeClient.CoreV1().Events(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 2)
+	for _, event := range events.Items {
+		switch event.Reason {
+		case audit.EventReason:
+			assert.Equal(t, "production", event.InvolvedObject.Name)
+			assert.Equal(t, corev1.EventTypeNormal, event.Type)
+		case audit.EventReasonFailed:
+			assert.Equal(t, kube.LabelValueDevEnvironment, event.InvolvedObject.Name)
+			assert.Equal(t, corev1.EventTypeWarning, event.Type)
+			assert.NotContains(t, event.Message, "mysecrettoken")
+		default:
+			assert.Fail(t, "unexpected event reason %s", event.Reason)
+		}
+	}
+
+	entries, err := audit.LoadEntries(kubeClient, ns, bucketURL, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, token.ID, entries[0].ID)
+	assert.Equal(t, promote.ID, entries[1].ID)
+
+	entries, err = audit.LoadEntries(nil, ns, bucketURL, &audit.Filter{Since: now.Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "jx promote", entries[0].Command)
+
+	entries, err = audit.LoadEntries(kubeClient, ns, "", &audit.Filter{User: "admin", Outcome: "failed"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "admin", entries[0].Who())
+}
+
+func TestRecordCreatesEventWhenBucketFails(t *testing.T) {
+	t.Parallel()
+
+	ns := "jx"
+	kubeClient := fake.NewSimpleClientset()
+	recorder := &audit.Recorder{
+		KubeClient: kubeClient,
+		Namespace:  ns,
+		BucketURL:  "unknown://bucket",
+	}
+	err := recorder.Record(&audit.Entry{
+		Command: "jx promote",
+		Outcome: audit.OutcomeSucceeded,
+	})
+	assert.Error(t, err)
+
+	events, err := kubeClient.CoreV1().Events(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, events.Items, 1, "the Event should be recorded even if the entry cannot be stored in the bucket")
+}
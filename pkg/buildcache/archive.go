@@ -0,0 +1,125 @@
+package buildcache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// ResolvePaths returns the absolute paths of the cache paths which are relative to the given directory
+func ResolvePaths(dir string, paths []string) []string {
+	var answer []string
+	for _, p := range paths {
+		if strings.HasPrefix(p, "~/") {
+			home, err := os.UserHomeDir()
+			if err == nil {
+				p = filepath.Join(home, strings.TrimPrefix(p, "~/"))
+			}
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		answer = append(answer, filepath.Clean(p))
+	}
+	return answer
+}
+
+// Archive writes a gzipped tar of the absolute paths to the writer. Paths which do not exist are ignored.
+// The entries are stored relative to the root directory so that they can be extracted back into the same place
+func Archive(w io.Writer, root string, paths []string) (int, error) {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	count := 0
+	for _, p := range paths {
+		_, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to check if %s exists", p)
+		}
+		err = filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() && !info.IsDir() {
+				// lets skip symlinks, sockets and the like
+				return nil
+			}
+			name, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(name, "..") {
+				return fmt.Errorf("cache path %s is not inside %s", file, root)
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(name)
+			err = tw.WriteHeader(header)
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			count++
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to archive %s", p)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return count, err
+	}
+	return count, gw.Close()
+}
+
+// Extract extracts a gzipped tar created by Archive into the root directory returning the number of files extracted
+func Extract(r io.Reader, root string) (int, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read the gzipped cache archive")
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	count := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, errors.Wrap(err, "failed to read the cache archive")
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return count, fmt.Errorf("invalid file name %s in the cache archive", header.Name)
+		}
+		err = util.UnTarFile(header, filepath.Join(root, name), tr)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to extract %s from the cache archive", header.Name)
+		}
+		if !header.FileInfo().IsDir() {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,166 @@
+// +build unit
+
+package buildcache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-buildcache-key-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), []byte("<project/>"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	key1, err := buildcache.RenderKey(`maven-{{ checksum "pom.xml" }}`, dir)
+	require.NoError(t, err)
+	assert.Regexp(t, "^maven-[0-9a-f]{64}$", key1)
+
+	key2, err := buildcache.RenderKey(`maven-{{ checksum "*.xml" }}`, dir)
+	require.NoError(t, err)
+	assert.Equal(t, key1, key2, "glob patterns should give the same checksum")
+
+	err = ioutil.WriteFile(filepath.Join(dir, "pom.xml"), []byte("<project><version>2</version></project>"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+	key3, err := buildcache.RenderKey(`maven-{{ checksum "pom.xml" }}`, dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, key1, key3, "the key should change when the file changes")
+
+	key, err := buildcache.RenderKey("node modules/v1", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "node-modules-v1", key)
+
+	_, err = buildcache.RenderKey(`{{ checksum "package-lock.json" }}`, dir)
+	assert.Error(t, err, "should fail when no file matches")
+
+	_, err = buildcache.RenderKey(`/`, dir)
+	assert.Error(t, err, "should fail for an empty key")
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-buildcache-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	stores := map[string]buildcache.Store{
+		"dir":    buildcache.NewDirStore(filepath.Join(tmpDir, "volume")),
+		"bucket": buildcache.NewBucketStore("file://" + filepath.Join(tmpDir, "bucket")),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			source := filepath.Join(tmpDir, name, "source")
+			repo := filepath.Join(source, "home", ".m2", "repository")
+			require.NoError(t, os.MkdirAll(filepath.Join(repo, "org", "example"), util.DefaultWritePermissions))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(repo, "org", "example", "example-1.0.jar"), []byte("jar"), util.DefaultFileWritePermissions))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(repo, "org", "example", "example-1.0.pom"), []byte("pom"), util.DefaultFileWritePermissions))
+
+			key := buildcache.EntryKey("myorg", "myrepo", "maven-abc")
+			assert.Equal(t, "myorg/myrepo/maven-abc.tar.gz", key)
+
+			result, err := buildcache.Restore(store, key, source)
+			require.NoError(t, err)
+			assert.False(t, result.Hit, "should miss an empty cache")
+
+			result, err = buildcache.Save(store, key, source, []string{repo})
+			require.NoError(t, err)
+			assert.False(t, result.Hit)
+			assert.False(t, result.Skipped)
+			assert.Equal(t, 2, result.Files)
+
+			result, err = buildcache.Save(store, key, source, []string{repo})
+			require.NoError(t, err)
+			assert.True(t, result.Hit, "should not overwrite an existing entry")
+
+			result, err = buildcache.Save(store, buildcache.EntryKey("myorg", "myrepo", "empty"), source, []string{filepath.Join(source, "missing")})
+			require.NoError(t, err)
+			assert.True(t, result.Skipped, "should skip saving when there are no files")
+
+			target := filepath.Join(tmpDir, name, "target")
+			result, err = buildcache.Restore(store, key, target)
+			require.NoError(t, err)
+			assert.True(t, result.Hit)
+			assert.Equal(t, 2, result.Files)
+			data, err := ioutil.ReadFile(filepath.Join(target, "home", ".m2", "repository", "org", "example", "example-1.0.jar"))
+			require.NoError(t, err)
+			assert.Equal(t, "jar", string(data))
+
+			entries, err := store.List()
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, key, entries[0].Key)
+
+			evicted, err := buildcache.Evict(store, time.Now().Add(-time.Hour), 0, false)
+			require.NoError(t, err)
+			assert.Empty(t, evicted, "should keep recent entries")
+
+			evicted, err = buildcache.Evict(store, time.Now().Add(time.Hour), 0, true)
+			require.NoError(t, err)
+			assert.Len(t, evicted, 1)
+			exists, err := store.Exists(key)
+			require.NoError(t, err)
+			assert.True(t, exists, "a dry run should not delete entries")
+
+			evicted, err = buildcache.Evict(store, time.Now().Add(time.Hour), 0, false)
+			require.NoError(t, err)
+			assert.Len(t, evicted, 1)
+			exists, err = store.Exists(key)
+			require.NoError(t, err)
+			assert.False(t, exists, "the entry should have been evicted")
+		})
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-buildcache-lru-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	stores := map[string]buildcache.Store{
+		"dir":    buildcache.NewDirStore(filepath.Join(tmpDir, "volume")),
+		"bucket": buildcache.NewBucketStore("file://" + filepath.Join(tmpDir, "bucket")),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			source := filepath.Join(tmpDir, name, "source")
+			require.NoError(t, os.MkdirAll(source, util.DefaultWritePermissions))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(source, "dep.jar"), []byte("jar"), util.DefaultFileWritePermissions))
+
+			oldKey := buildcache.EntryKey("myorg", "myrepo", "old")
+			newKey := buildcache.EntryKey("myorg", "myrepo", "new")
+			for _, key := range []string{oldKey, newKey} {
+				_, err := buildcache.Save(store, key, source, []string{filepath.Join(source, "dep.jar")})
+				require.NoError(t, err)
+			}
+			before := time.Now().Add(time.Second)
+			time.Sleep(2 * time.Second)
+
+			result, err := buildcache.Restore(store, oldKey, filepath.Join(tmpDir, name, "target"))
+			require.NoError(t, err)
+			require.True(t, result.Hit)
+
+			evicted, err := buildcache.Evict(store, before, 0, true)
+			require.NoError(t, err)
+			require.Len(t, evicted, 1, "the restored entry should be kept")
+			assert.Equal(t, newKey, evicted[0].Key)
+
+			evicted, err = buildcache.Evict(store, time.Time{}, evicted[0].Size, false)
+			require.NoError(t, err)
+			require.Len(t, evicted, 1, "the least recently used entry should be evicted to fit the maximum size")
+			assert.Equal(t, newKey, evicted[0].Key)
+			exists, err := store.Exists(oldKey)
+			require.NoError(t, err)
+			assert.True(t, exists)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package buildcache
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Result the result of restoring or saving a cache entry
+type Result struct {
+	Key      string
+	Hit      bool
+	Skipped  bool
+	Files    int
+	Size     int
+	Duration time.Duration
+}
+
+// Restore restores the cache entry with the key into the root directory.
+// If there is no entry a miss is returned
+func Restore(store Store, key string, root string) (*Result, error) {
+	start := time.Now()
+	result := &Result{
+		Key: key,
+	}
+	reader, err := store.Load(key)
+	if err != nil {
+		return result, errors.Wrapf(err, "failed to load cache entry %s", key)
+	}
+	if reader == nil {
+		return result, nil
+	}
+	defer reader.Close() //nolint:errcheck
+	result.Hit = true
+	counter := &countingReader{reader: reader}
+	result.Files, err = Extract(counter, root)
+	result.Size = counter.count
+	result.Duration = time.Since(start)
+	if err != nil {
+		return result, err
+	}
+	err = store.Touch(key)
+	if err != nil {
+		log.Logger().Warnf("failed to record the use of cache entry %s: %s", key, err.Error())
+	}
+	return result, nil
+}
+
+// Save archives the paths into the cache entry with the key.
+//
+// Entries are immutable so that a cache key always restores the same content; if there is
+// already an entry for the key the save is skipped
+func Save(store Store, key string, root string, paths []string) (*Result, error) {
+	start := time.Now()
+	result := &Result{
+		Key: key,
+	}
+	exists, err := store.Exists(key)
+	if err != nil {
+		return result, errors.Wrapf(err, "failed to check if cache entry %s exists", key)
+	}
+	if exists {
+		result.Hit = true
+		result.Skipped = true
+		return result, nil
+	}
+	// the archive is written to a temporary file rather than memory as caches such as a maven repository can be large
+	tmpFile, err := ioutil.TempFile("", "jx-cache-*"+FileExtension)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to create a temporary file for the cache entry")
+	}
+	defer os.Remove(tmpFile.Name()) //nolint:errcheck
+	defer tmpFile.Close()           //nolint:errcheck
+	result.Files, err = Archive(tmpFile, root, paths)
+	if err != nil {
+		return result, err
+	}
+	if result.Files == 0 {
+		result.Skipped = true
+		return result, nil
+	}
+	size, err := tmpFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return result, errors.Wrapf(err, "failed to find the size of %s", tmpFile.Name())
+	}
+	result.Size = int(size)
+	_, err = tmpFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return result, errors.Wrapf(err, "failed to rewind %s", tmpFile.Name())
+	}
+	err = store.Save(key, tmpFile)
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// countingReader counts the bytes read so that the size of a restored entry can be reported
+type countingReader struct {
+	reader io.Reader
+	count  int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += n
+	return n, err
+}
+
+// Evict deletes the least recently used entries. Entries which have not been saved or restored since the given time
+// are always deleted. If maxSize is positive, the least recently used of the remaining entries are then deleted until
+// the total size of the entries is at most maxSize
+func Evict(store Store, before time.Time, maxSize int64, dryRun bool) ([]*Entry, error) {
+	entries, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed().Before(entries[j].LastUsed())
+	})
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	var answer []*Entry
+	for _, e := range entries {
+		if !e.LastUsed().Before(before) && (maxSize <= 0 || total <= maxSize) {
+			continue
+		}
+		if !dryRun {
+			err = store.Delete(e.Key)
+			if err != nil {
+				return answer, err
+			}
+		}
+		total -= e.Size
+		answer = append(answer, e)
+	}
+	return answer, nil
+}
@@ -0,0 +1,91 @@
+package buildcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var invalidKeyCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// RenderKey evaluates the cache key template in the given directory.
+//
+// The template can use the following functions:
+//
+// * checksum - the SHA-256 checksum of one or more files or glob patterns, e.g. {{ checksum "pom.xml" }}
+// * env - the value of an environment variable, e.g. {{ env "BRANCH_NAME" }}
+// * arch - the architecture the build is running on
+func RenderKey(keyTemplate string, dir string) (string, error) {
+	funcMap := template.FuncMap{
+		"checksum": func(patterns ...string) (string, error) {
+			return Checksum(dir, patterns...)
+		},
+		"env":  os.Getenv,
+		"arch": func() string { return runtime.GOARCH },
+	}
+	tmpl, err := template.New("key").Funcs(funcMap).Option("missingkey=error").Parse(keyTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse cache key template %s", keyTemplate)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate cache key template %s", keyTemplate)
+	}
+	key := strings.Trim(invalidKeyCharacters.ReplaceAllString(buf.String(), "-"), "-")
+	if key == "" {
+		return "", fmt.Errorf("cache key template %s evaluated to an empty key", keyTemplate)
+	}
+	return key, nil
+}
+
+// Checksum returns the SHA-256 checksum of the contents of the files matching the patterns in the directory.
+// It is an error if no file matches
+func Checksum(dir string, patterns ...string) (string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid checksum pattern %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files match %s in %s", strings.Join(patterns, ", "), dir)
+	}
+	sort.Strings(files)
+	hash := sha256.New()
+	for _, file := range files {
+		err := checksumFile(hash, file)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func checksumFile(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", file)
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", file)
+	}
+	return nil
+}
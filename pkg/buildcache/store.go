@@ -0,0 +1,278 @@
+package buildcache
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FileExtension the extension of the cache entries
+	FileExtension = ".tar.gz"
+
+	// DefaultTimeout the default timeout when reading and writing cache entries
+	DefaultTimeout = time.Minute * 5
+
+	// accessedSuffix the suffix of the objects in a bucket which record when an entry was last restored
+	accessedSuffix = ".accessed"
+)
+
+// Entry a cache entry in a store
+type Entry struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+	// Accessed the last time the entry was saved or restored
+	Accessed time.Time
+}
+
+// LastUsed returns the last time the entry was saved or restored
+func (e *Entry) LastUsed() time.Time {
+	if e.Accessed.After(e.Modified) {
+		return e.Accessed
+	}
+	return e.Modified
+}
+
+// Store stores the cache entries
+type Store interface {
+	// Load opens the entry for the key returning nil if there is no entry. The caller must close the reader
+	Load(key string) (io.ReadCloser, error)
+
+	// Exists returns true if there is an entry for the key
+	Exists(key string) (bool, error)
+
+	// Save saves the entry for the key reading its content from the reader
+	Save(key string, reader io.Reader) error
+
+	// List lists all the entries in the store
+	List() ([]*Entry, error)
+
+	// Delete deletes the entry for the key
+	Delete(key string) error
+
+	// Touch records that the entry for the key has just been used
+	Touch(key string) error
+}
+
+// EntryKey returns the key of the cache entry of a repository in a store
+func EntryKey(owner string, repository string, key string) string {
+	return path.Join(owner, repository, key+FileExtension)
+}
+
+// BucketStore stores the cache entries in a cloud storage bucket
+type BucketStore struct {
+	BucketURL string
+	Timeout   time.Duration
+}
+
+// NewBucketStore creates a store for the given cloud storage bucket URL
+func NewBucketStore(bucketURL string) *BucketStore {
+	return &BucketStore{
+		BucketURL: bucketURL,
+		Timeout:   DefaultTimeout,
+	}
+}
+
+// bucketPrefix the folder in the bucket the entries are stored in
+var bucketPrefix = path.Join("jenkins-x", "caches") + "/"
+
+// Load opens the entry for the key returning nil if there is no entry
+func (s *BucketStore) Load(key string) (io.ReadCloser, error) {
+	return buckets.OpenBucket(s.BucketURL, bucketPrefix+key, s.Timeout)
+}
+
+// Exists returns true if there is an entry for the key
+func (s *BucketStore) Exists(key string) (bool, error) {
+	return s.exists(key)
+}
+
+func (s *BucketStore) exists(key string) (bool, error) {
+	objects, err := buckets.ListBucket(s.BucketURL, bucketPrefix+key, s.Timeout)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range objects {
+		if o.Key == bucketPrefix+key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Save saves the entry for the key reading its content from the reader
+func (s *BucketStore) Save(key string, reader io.Reader) error {
+	return buckets.WriteBucket(s.BucketURL, bucketPrefix+key, reader, s.Timeout)
+}
+
+// List lists all the entries in the store
+func (s *BucketStore) List() ([]*Entry, error) {
+	objects, err := buckets.ListBucket(s.BucketURL, bucketPrefix, s.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	var answer []*Entry
+	accessed := map[string]time.Time{}
+	for _, o := range objects {
+		if o.IsDir {
+			continue
+		}
+		key := strings.TrimPrefix(o.Key, bucketPrefix)
+		if strings.HasSuffix(key, FileExtension+accessedSuffix) {
+			accessed[strings.TrimSuffix(key, accessedSuffix)] = o.ModTime
+			continue
+		}
+		if !strings.HasSuffix(key, FileExtension) {
+			continue
+		}
+		answer = append(answer, &Entry{
+			Key:      key,
+			Size:     o.Size,
+			Modified: o.ModTime,
+		})
+	}
+	for _, e := range answer {
+		e.Accessed = accessed[e.Key]
+	}
+	return answer, nil
+}
+
+// Delete deletes the entry for the key
+func (s *BucketStore) Delete(key string) error {
+	err := buckets.DeleteBucket(s.BucketURL, bucketPrefix+key, s.Timeout)
+	if err != nil {
+		return err
+	}
+	exists, err := s.exists(key + accessedSuffix)
+	if err != nil || !exists {
+		return err
+	}
+	return buckets.DeleteBucket(s.BucketURL, bucketPrefix+key+accessedSuffix, s.Timeout)
+}
+
+// Touch records that the entry for the key has just been used. As objects in a bucket cannot be touched a small
+// object next to the entry records the last access
+func (s *BucketStore) Touch(key string) error {
+	data := time.Now().UTC().Format(time.RFC3339)
+	return buckets.WriteBucket(s.BucketURL, bucketPrefix+key+accessedSuffix, strings.NewReader(data), s.Timeout)
+}
+
+// DirStore stores the cache entries in a directory such as a PersistentVolumeClaim shared between builds
+type DirStore struct {
+	Dir string
+}
+
+// NewDirStore creates a store for the given directory
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{
+		Dir: dir,
+	}
+}
+
+// Load opens the entry for the key returning nil if there is no entry
+func (s *DirStore) Load(key string) (io.ReadCloser, error) {
+	file := filepath.Join(s.Dir, filepath.FromSlash(key))
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read cache file %s", file)
+	}
+	return f, nil
+}
+
+// Exists returns true if there is an entry for the key
+func (s *DirStore) Exists(key string) (bool, error) {
+	return util.FileExists(filepath.Join(s.Dir, filepath.FromSlash(key)))
+}
+
+// Save saves the entry for the key reading its content from the reader.
+// The entry is written to a temporary file first so that concurrent builds never see a partial entry
+func (s *DirStore) Save(key string, reader io.Reader) error {
+	file := filepath.Join(s.Dir, filepath.FromSlash(key))
+	dir := filepath.Dir(file)
+	err := os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+	tmpFile, err := ioutil.TempFile(dir, ".cache-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a temporary file in %s", dir)
+	}
+	_, err = io.Copy(tmpFile, reader)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name()) //nolint:errcheck
+		return errors.Wrapf(err, "failed to write cache file %s", tmpFile.Name())
+	}
+	err = os.Rename(tmpFile.Name(), file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename %s to %s", tmpFile.Name(), file)
+	}
+	return nil
+}
+
+// List lists all the entries in the store
+func (s *DirStore) List() ([]*Entry, error) {
+	var answer []*Entry
+	exists, err := util.DirExists(s.Dir)
+	if err != nil || !exists {
+		return answer, err
+	}
+	err = filepath.Walk(s.Dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(file, FileExtension) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Dir, file)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, &Entry{
+			Key:      filepath.ToSlash(rel),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			Accessed: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to list the cache entries in %s", s.Dir)
+	}
+	return answer, nil
+}
+
+// Delete deletes the entry for the key
+func (s *DirStore) Delete(key string) error {
+	file := filepath.Join(s.Dir, filepath.FromSlash(key))
+	err := os.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to delete cache file %s", file)
+	}
+	return nil
+}
+
+// Touch records that the entry for the key has just been used by updating the modification time of its file
+func (s *DirStore) Touch(key string) error {
+	file := filepath.Join(s.Dir, filepath.FromSlash(key))
+	now := time.Now()
+	err := os.Chtimes(file, now, now)
+	if err != nil {
+		return errors.Wrapf(err, "failed to touch cache file %s", file)
+	}
+	return nil
+}
@@ -2,13 +2,44 @@ package buildpacks
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/jenkins-x/draft-repo/pkg/draft/pack"
 	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 )
 
 // CopyBuildPack copies the build pack from the source dir to the destination dir
 func CopyBuildPack(dest, src string) error {
+	// the kustomize layout of a pack is not a chart so lets load the pack without it and copy it separately
+	kustomizeDir := filepath.Join(src, kustomize.ProjectDir)
+	hasKustomize, err := util.DirExists(kustomizeDir)
+	if err != nil {
+		return err
+	}
+	if hasKustomize {
+		tmpDir, err := ioutil.TempDir("", "buildpack-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create temporary directory for the build pack")
+		}
+		defer os.RemoveAll(tmpDir)
+
+		packDir := filepath.Join(tmpDir, filepath.Base(src))
+		err = util.CopyDir(src, packDir, true)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy the build pack %s", src)
+		}
+		err = os.RemoveAll(filepath.Join(packDir, kustomize.ProjectDir))
+		if err != nil {
+			return err
+		}
+		src = packDir
+	}
+
 	// first do some validation that we are copying from a valid pack directory
 	p, err := pack.FromDir(src)
 	if err != nil {
@@ -19,5 +50,13 @@ func CopyBuildPack(dest, src string) error {
 	for _, file := range []string{jenkinsfile.PipelineConfigFileName, jenkinsfile.PipelineTemplateFileName} {
 		delete(p.Files, file)
 	}
-	return p.SaveDir(dest)
+	err = p.SaveDir(dest)
+	if err != nil || !hasKustomize {
+		return err
+	}
+	err = util.CopyDirPreserve(kustomizeDir, filepath.Join(dest, kustomize.ProjectDir))
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy the kustomize layout of the build pack %s", kustomizeDir)
+	}
+	return nil
 }
@@ -0,0 +1,96 @@
+package buildstats
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RollupFileName the file name used to store the rollup in the storage bucket
+	RollupFileName = "rollup.yaml"
+
+	// DefaultRollupMaxAge the default maximum age of summaries kept in the rollup
+	DefaultRollupMaxAge = time.Hour * 24 * 90
+
+	defaultTimeout = time.Second * 20
+)
+
+// Rollup stores the summaries of the activities of a namespace after they have been garbage collected
+type Rollup struct {
+	Namespace string             `json:"namespace,omitempty"`
+	Summaries []*ActivitySummary `json:"summaries,omitempty"`
+}
+
+// RollupKey returns the key in the storage bucket of the rollup for the given namespace
+func RollupKey(ns string) string {
+	return path.Join("jenkins-x", "pipelinestats", ns, RollupFileName)
+}
+
+// Add adds the summaries to the rollup replacing any existing summary of the same pipeline build
+// and removing summaries which completed before the given time
+func (r *Rollup) Add(summaries []*ActivitySummary, removeBefore time.Time) {
+	m := map[string]*ActivitySummary{}
+	for _, s := range r.Summaries {
+		m[s.Key()] = s
+	}
+	for _, s := range summaries {
+		m[s.Key()] = s
+	}
+	answer := []*ActivitySummary{}
+	for _, s := range m {
+		if s.Completed.Before(removeBefore) {
+			continue
+		}
+		answer = append(answer, s)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Completed.Before(answer[j].Completed)
+	})
+	r.Summaries = answer
+}
+
+// Merge returns the summaries of the rollup combined with the given summaries of live activities
+func (r *Rollup) Merge(summaries []*ActivitySummary) []*ActivitySummary {
+	merged := &Rollup{
+		Summaries: append([]*ActivitySummary{}, r.Summaries...),
+	}
+	merged.Add(summaries, time.Time{})
+	return merged.Summaries
+}
+
+// LoadRollup loads the rollup for the namespace from the given bucket URL.
+// If there is no rollup stored yet an empty rollup is returned
+func LoadRollup(bucketURL string, ns string) (*Rollup, error) {
+	rollup := &Rollup{
+		Namespace: ns,
+	}
+	key := RollupKey(ns)
+	data, err := buckets.ReadBucket(bucketURL, key, defaultTimeout)
+	if err != nil {
+		return rollup, err
+	}
+	if len(data) == 0 {
+		return rollup, nil
+	}
+	err = yaml.Unmarshal(data, rollup)
+	if err != nil {
+		return rollup, errors.Wrapf(err, "failed to unmarshal pipeline rollup %s in bucket %s", key, bucketURL)
+	}
+	return rollup, nil
+}
+
+// SaveRollup stores the rollup in the given bucket URL
+func SaveRollup(bucketURL string, rollup *Rollup) error {
+	data, err := yaml.Marshal(rollup)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal pipeline rollup to YAML")
+	}
+	key := RollupKey(rollup.Namespace)
+	return buckets.WriteBucket(bucketURL, key, bytes.NewReader(data), defaultTimeout)
+}
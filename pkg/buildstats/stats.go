@@ -0,0 +1,198 @@
+package buildstats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+)
+
+// DurationStats the duration statistics of a pipeline, stage or step
+type DurationStats struct {
+	Name        string        `json:"name"`
+	Count       int           `json:"count"`
+	Succeeded   int           `json:"succeeded"`
+	P50         time.Duration `json:"p50"`
+	P90         time.Duration `json:"p90"`
+	QueueP50    time.Duration `json:"queueP50,omitempty"`
+	QueueP90    time.Duration `json:"queueP90,omitempty"`
+	SuccessRate float64       `json:"successRate"`
+}
+
+// Trend the change in the median duration of a pipeline, stage or step between the first and second half of a time window
+type Trend struct {
+	Name     string        `json:"name"`
+	Before   time.Duration `json:"before"`
+	After    time.Duration `json:"after"`
+	Growth   time.Duration `json:"growth"`
+	GrowthPC float64       `json:"growthPercent"`
+}
+
+// Report the statistics of the pipelines, stages and steps over a time window
+type Report struct {
+	Since     time.Time        `json:"since"`
+	Until     time.Time        `json:"until"`
+	Pipelines []*DurationStats `json:"pipelines,omitempty"`
+	Stages    []*DurationStats `json:"stages,omitempty"`
+	Steps     []*DurationStats `json:"steps,omitempty"`
+	Trends    []*Trend         `json:"trends,omitempty"`
+}
+
+type sample struct {
+	duration  time.Duration
+	queue     time.Duration
+	succeeded bool
+	completed time.Time
+}
+
+type samples map[string][]sample
+
+func (s samples) add(name string, value sample) {
+	s[name] = append(s[name], value)
+}
+
+// Calculate calculates the statistics for the summaries which completed within the time window
+func Calculate(summaries []*ActivitySummary, since time.Time, until time.Time) *Report {
+	pipelines := samples{}
+	stages := samples{}
+	steps := samples{}
+	for _, s := range summaries {
+		if s.Completed.Before(since) || s.Completed.After(until) {
+			continue
+		}
+		pipelines.add(s.Pipeline, sample{
+			duration:  s.RunTime(),
+			queue:     s.QueueTime(),
+			succeeded: s.Succeeded(),
+			completed: s.Completed,
+		})
+		for _, stage := range s.Stages {
+			stageName := s.Pipeline + " / " + stage.Name
+			stages.add(stageName, sample{
+				duration:  seconds(stage.DurationSeconds),
+				succeeded: stage.Status == string(v1.ActivityStatusTypeSucceeded),
+				completed: s.Completed,
+			})
+			for _, step := range stage.Steps {
+				steps.add(stageName+" / "+step.Name, sample{
+					duration:  seconds(step.DurationSeconds),
+					succeeded: step.Status == string(v1.ActivityStatusTypeSucceeded),
+					completed: s.Completed,
+				})
+			}
+		}
+	}
+	midpoint := since.Add(until.Sub(since) / 2)
+	return &Report{
+		Since:     since,
+		Until:     until,
+		Pipelines: durationStats(pipelines),
+		Stages:    durationStats(stages),
+		Steps:     durationStats(steps),
+		Trends:    trends(steps, midpoint),
+	}
+}
+
+// SlowestGrowing returns up to the given number of trends which have grown the most
+func (r *Report) SlowestGrowing(count int) []*Trend {
+	var answer []*Trend
+	for _, t := range r.Trends {
+		if t.Growth <= 0 {
+			continue
+		}
+		answer = append(answer, t)
+		if count > 0 && len(answer) >= count {
+			break
+		}
+	}
+	return answer
+}
+
+func durationStats(values samples) []*DurationStats {
+	var answer []*DurationStats
+	for name, list := range values {
+		stats := &DurationStats{
+			Name:  name,
+			Count: len(list),
+		}
+		var durations, queues []time.Duration
+		for _, s := range list {
+			if s.succeeded {
+				stats.Succeeded++
+			}
+			durations = append(durations, s.duration)
+			queues = append(queues, s.queue)
+		}
+		stats.P50 = Percentile(durations, 50)
+		stats.P90 = Percentile(durations, 90)
+		stats.QueueP50 = Percentile(queues, 50)
+		stats.QueueP90 = Percentile(queues, 90)
+		if stats.Count > 0 {
+			stats.SuccessRate = float64(stats.Succeeded) * 100 / float64(stats.Count)
+		}
+		answer = append(answer, stats)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+func trends(values samples, midpoint time.Time) []*Trend {
+	var answer []*Trend
+	for name, list := range values {
+		var before, after []time.Duration
+		for _, s := range list {
+			if s.completed.Before(midpoint) {
+				before = append(before, s.duration)
+			} else {
+				after = append(after, s.duration)
+			}
+		}
+		if len(before) == 0 || len(after) == 0 {
+			continue
+		}
+		t := &Trend{
+			Name:   name,
+			Before: Percentile(before, 50),
+			After:  Percentile(after, 50),
+		}
+		t.Growth = t.After - t.Before
+		if t.Before > 0 {
+			t.GrowthPC = float64(t.Growth) * 100 / float64(t.Before)
+		}
+		answer = append(answer, t)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		if answer[i].Growth != answer[j].Growth {
+			return answer[i].Growth > answer[j].Growth
+		}
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+// Percentile returns the given percentile of the durations using the nearest rank method
+func Percentile(durations []time.Duration, percentile float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,157 @@
+// +build unit
+
+package buildstats_test
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/buildstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarizeActivity(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	a := createActivity("1", created, time.Minute, 10*time.Minute, 8*time.Minute, v1.ActivityStatusTypeSucceeded)
+
+	s := buildstats.SummarizeActivity(a)
+	require.NotNil(t, s)
+	assert.Equal(t, "myorg/myrepo/master", s.Pipeline)
+	assert.Equal(t, "myrepo", s.Repository)
+	assert.Equal(t, time.Minute, s.QueueTime())
+	assert.Equal(t, 10*time.Minute, s.RunTime())
+	require.Len(t, s.Stages, 1)
+	require.Len(t, s.Stages[0].Steps, 1)
+	assert.Equal(t, "build", s.Stages[0].Steps[0].Name)
+	assert.Equal(t, float64(480), s.Stages[0].Steps[0].DurationSeconds)
+
+	a.Spec.CompletedTimestamp = nil
+	assert.Nil(t, buildstats.SummarizeActivity(a), "running activities should not be summarized")
+}
+
+func TestCalculate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	since := now.Add(-10 * 24 * time.Hour)
+	var activities []v1.PipelineActivity
+	for i := 0; i < 10; i++ {
+		status := v1.ActivityStatusTypeSucceeded
+		if i == 3 {
+			status = v1.ActivityStatusTypeFailed
+		}
+		created := since.Add(time.Duration(i) * 24 * time.Hour)
+		stepDuration := time.Duration(i+1) * time.Minute
+		activities = append(activities, *createActivity(string(rune('a'+i)), created, 30*time.Second, 20*time.Minute, stepDuration, status))
+	}
+	// this one is outside of the time window
+	activities = append(activities, *createActivity("old", since.Add(-48*time.Hour), 0, time.Hour, time.Hour, v1.ActivityStatusTypeSucceeded))
+
+	report := buildstats.Calculate(buildstats.SummarizeActivities(activities), since, now)
+	require.Len(t, report.Pipelines, 1)
+	p := report.Pipelines[0]
+	assert.Equal(t, 10, p.Count)
+	assert.Equal(t, 9, p.Succeeded)
+	assert.Equal(t, float64(90), p.SuccessRate)
+	assert.Equal(t, 20*time.Minute, p.P50)
+	assert.Equal(t, 30*time.Second, p.QueueP50)
+
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, "myorg/myrepo/master / from-build-pack / build", report.Steps[0].Name)
+	assert.Equal(t, 5*time.Minute, report.Steps[0].P50)
+	assert.Equal(t, 9*time.Minute, report.Steps[0].P90)
+
+	trends := report.SlowestGrowing(5)
+	require.Len(t, trends, 1)
+	assert.Equal(t, 3*time.Minute, trends[0].Before)
+	assert.Equal(t, 8*time.Minute, trends[0].After)
+	assert.Equal(t, 5*time.Minute, trends[0].Growth)
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	durations := []time.Duration{5, 1, 4, 2, 3}
+	assert.Equal(t, time.Duration(3), buildstats.Percentile(durations, 50))
+	assert.Equal(t, time.Duration(5), buildstats.Percentile(durations, 90))
+	assert.Equal(t, time.Duration(1), buildstats.Percentile(durations, 0))
+	assert.Equal(t, time.Duration(0), buildstats.Percentile(nil, 50))
+}
+
+func TestRollup(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pipeline-rollup")
+	require.NoError(t, err)
+	bucketURL := "file://" + dir
+
+	now := time.Now()
+	rollup, err := buildstats.LoadRollup(bucketURL, "jx")
+	require.NoError(t, err)
+	assert.Empty(t, rollup.Summaries)
+
+	old := buildstats.SummarizeActivity(createActivity("old", now.Add(-100*24*time.Hour), 0, time.Minute, time.Minute, v1.ActivityStatusTypeSucceeded))
+	recent := buildstats.SummarizeActivity(createActivity("recent", now.Add(-time.Hour), 0, time.Minute, time.Minute, v1.ActivityStatusTypeSucceeded))
+	rollup.Add([]*buildstats.ActivitySummary{old, recent}, now.Add(-buildstats.DefaultRollupMaxAge))
+	require.Len(t, rollup.Summaries, 1, "the old summary should have been removed")
+
+	err = buildstats.SaveRollup(bucketURL, rollup)
+	require.NoError(t, err)
+
+	loaded, err := buildstats.LoadRollup(bucketURL, "jx")
+	require.NoError(t, err)
+	require.Len(t, loaded.Summaries, 1)
+	assert.Equal(t, "recent", loaded.Summaries[0].Build)
+
+	live := buildstats.SummarizeActivity(createActivity("live", now, 0, time.Minute, time.Minute, v1.ActivityStatusTypeSucceeded))
+	merged := loaded.Merge([]*buildstats.ActivitySummary{live, recent})
+	assert.Len(t, merged, 2)
+}
+
+func createActivity(build string, created time.Time, queue time.Duration, duration time.Duration, stepDuration time.Duration, status v1.ActivityStatusType) *v1.PipelineActivity {
+	started := created.Add(queue)
+	completed := started.Add(duration)
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-myrepo-master-" + build,
+			CreationTimestamp: metav1.Time{Time: created},
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "myorg/myrepo/master",
+			Build:              build,
+			GitOwner:           "myorg",
+			GitRepository:      "myrepo",
+			GitBranch:          "master",
+			Status:             status,
+			StartedTimestamp:   &metav1.Time{Time: started},
+			CompletedTimestamp: &metav1.Time{Time: completed},
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Name:               "from-build-pack",
+							Status:             status,
+							StartedTimestamp:   &metav1.Time{Time: started},
+							CompletedTimestamp: &metav1.Time{Time: completed},
+						},
+						Steps: []v1.CoreActivityStep{
+							{
+								Name:               "build",
+								Status:             status,
+								StartedTimestamp:   &metav1.Time{Time: started},
+								CompletedTimestamp: &metav1.Time{Time: started.Add(stepDuration)},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package buildstats
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ActivitySummary is a compact summary of a completed PipelineActivity which is small enough to be kept
+// for a long time after the PipelineActivity itself has been garbage collected
+type ActivitySummary struct {
+	Name       string          `json:"name"`
+	Pipeline   string          `json:"pipeline"`
+	Owner      string          `json:"owner,omitempty"`
+	Repository string          `json:"repository,omitempty"`
+	Branch     string          `json:"branch,omitempty"`
+	Context    string          `json:"context,omitempty"`
+	Build      string          `json:"build,omitempty"`
+	Status     string          `json:"status,omitempty"`
+	Created    time.Time       `json:"created"`
+	Started    time.Time       `json:"started"`
+	Completed  time.Time       `json:"completed"`
+	Stages     []*StageSummary `json:"stages,omitempty"`
+}
+
+// StageSummary is a compact summary of a stage of a pipeline
+type StageSummary struct {
+	Name            string         `json:"name"`
+	Status          string         `json:"status,omitempty"`
+	DurationSeconds float64        `json:"duration"`
+	Steps           []*StepSummary `json:"steps,omitempty"`
+}
+
+// StepSummary is a compact summary of a step of a stage
+type StepSummary struct {
+	Name            string  `json:"name"`
+	Status          string  `json:"status,omitempty"`
+	DurationSeconds float64 `json:"duration"`
+}
+
+// Key returns the unique key of the summary
+func (s *ActivitySummary) Key() string {
+	return s.Pipeline + "#" + s.Build + "#" + s.Context
+}
+
+// QueueTime returns the time between the activity being created and the pipeline starting
+func (s *ActivitySummary) QueueTime() time.Duration {
+	if s.Created.IsZero() || s.Started.IsZero() || s.Started.Before(s.Created) {
+		return 0
+	}
+	return s.Started.Sub(s.Created)
+}
+
+// RunTime returns the time between the pipeline starting and completing
+func (s *ActivitySummary) RunTime() time.Duration {
+	if s.Started.IsZero() || s.Completed.IsZero() {
+		return 0
+	}
+	return s.Completed.Sub(s.Started)
+}
+
+// Succeeded returns true if the pipeline succeeded
+func (s *ActivitySummary) Succeeded() bool {
+	return s.Status == string(v1.ActivityStatusTypeSucceeded)
+}
+
+// SummarizeActivity creates a summary of the given activity or returns nil if the activity has not completed yet
+func SummarizeActivity(a *v1.PipelineActivity) *ActivitySummary {
+	spec := &a.Spec
+	if spec.CompletedTimestamp == nil || spec.StartedTimestamp == nil {
+		return nil
+	}
+	summary := &ActivitySummary{
+		Name:       a.Name,
+		Pipeline:   spec.Pipeline,
+		Owner:      a.RepositoryOwner(),
+		Repository: a.RepositoryName(),
+		Branch:     a.BranchName(),
+		Context:    spec.Context,
+		Build:      spec.Build,
+		Status:     string(spec.Status),
+		Created:    a.CreationTimestamp.Time,
+		Started:    spec.StartedTimestamp.Time,
+		Completed:  spec.CompletedTimestamp.Time,
+	}
+	for _, step := range spec.Steps {
+		stage := step.Stage
+		if step.Kind != v1.ActivityStepKindTypeStage || stage == nil {
+			continue
+		}
+		stageSummary := &StageSummary{
+			Name:            stage.Name,
+			Status:          string(stage.Status),
+			DurationSeconds: durationSeconds(stage.StartedTimestamp, stage.CompletedTimestamp),
+		}
+		for _, s := range stage.Steps {
+			stageSummary.Steps = append(stageSummary.Steps, &StepSummary{
+				Name:            s.Name,
+				Status:          string(s.Status),
+				DurationSeconds: durationSeconds(s.StartedTimestamp, s.CompletedTimestamp),
+			})
+		}
+		summary.Stages = append(summary.Stages, stageSummary)
+	}
+	return summary
+}
+
+// SummarizeActivities summarizes all of the completed activities
+func SummarizeActivities(activities []v1.PipelineActivity) []*ActivitySummary {
+	var answer []*ActivitySummary
+	for i := range activities {
+		s := SummarizeActivity(&activities[i])
+		if s != nil {
+			answer = append(answer, s)
+		}
+	}
+	return answer
+}
+
+func durationSeconds(started *metav1.Time, completed *metav1.Time) float64 {
+	if started == nil || completed == nil || completed.Before(started) {
+		return 0
+	}
+	return completed.Sub(started.Time).Seconds()
+}
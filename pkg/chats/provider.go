@@ -10,6 +10,9 @@ import (
 // ChatProvider represents an integration interface to chat
 type ChatProvider interface {
 	GetChannelMetrics(name string) (*ChannelMetrics, error)
+
+	// PostMessage posts the text as a message to the given channel
+	PostMessage(channel string, text string) error
 }
 
 // ChannelMetrics metrics for a channel
@@ -8,6 +8,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/auth"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
 )
 
 type SlackChatProvider struct {
@@ -62,3 +63,17 @@ func (c *SlackChatProvider) GetChannelMetrics(name string) (*ChannelMetrics, err
 	metrics.URL = util.UrlJoin(c.Server.URL, "messages", info.ID)
 	return metrics, nil
 }
+
+// PostMessage posts the text as a message to the given channel
+func (c *SlackChatProvider) PostMessage(channel string, text string) error {
+	if !strings.HasPrefix(channel, "#") {
+		channel = "#" + channel
+	}
+	params := slack.NewPostMessageParameters()
+	params.AsUser = true
+	_, _, err := c.SlackClient.PostMessage(channel, text, params)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post message to Slack channel %s", channel)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package aks
 import (
 	b64 "encoding/base64"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
@@ -233,6 +234,91 @@ func (az *AzureRunner) getACRCredential(azureRegistrySubscription string, resour
 	return string(dockerConfigStr), err
 }
 
+// Cluster an AKS cluster
+type Cluster struct {
+	Name     string            `json:"name"`
+	Group    string            `json:"group"`
+	Location string            `json:"location"`
+	Status   string            `json:"status"`
+	Tags     map[string]string `json:"tags"`
+	ETag     string            `json:"etag"`
+}
+
+// ListClusters returns the AKS clusters in the resource group or in all resource groups if none is specified
+func (az *AzureRunner) ListClusters(resourceGroup string) ([]Cluster, error) {
+	args := []string{"aks", "list", "--query", "[].{name:name,group:resourceGroup,location:location,status:provisioningState,tags:tags,etag:etag}"}
+	if resourceGroup != "" {
+		args = append(args, "-g", resourceGroup)
+	}
+	output, err := az.azureCLI(args...)
+	if err != nil {
+		return nil, err
+	}
+	clusters := []Cluster{}
+	err = json.Unmarshal([]byte(output), &clusters)
+	if err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// UpdateClusterTags replaces the tags of the AKS cluster
+func (az *AzureRunner) UpdateClusterTags(resourceGroup string, name string, tags map[string]string) error {
+	args := append([]string{"aks", "update", "-g", resourceGroup, "-n", name, "--tags"}, tagArgs(tags)...)
+	_, err := az.azureCLI(args...)
+	return err
+}
+
+// UpdateClusterTagsIfMatch replaces the tags of the AKS cluster if it still has the given etag. Returns the new etag of
+// the cluster and false if the cluster has been changed since the etag was read
+func (az *AzureRunner) UpdateClusterTagsIfMatch(resourceGroup string, name string, tags map[string]string, etag string) (string, bool, error) {
+	if etag == "" {
+		return "", false, fmt.Errorf("no etag of AKS cluster %s to update its tags conditionally", name)
+	}
+	args := append([]string{"aks", "update", "-g", resourceGroup, "-n", name, "--if-match", etag, "--tags"}, tagArgs(tags)...)
+	output, err := az.azureCLI(args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "PreconditionFailed") {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	// the etag is left empty if the output cannot be parsed so that the cluster is read again before its next update
+	updated := Cluster{}
+	_ = json.Unmarshal([]byte(output), &updated)
+	return updated.ETag, true, nil
+}
+
+// CreateCluster creates an AKS cluster with the given tags
+func (az *AzureRunner) CreateCluster(resourceGroup string, name string, tags map[string]string) error {
+	args := []string{"aks", "create", "-g", resourceGroup, "-n", name, "--generate-ssh-keys"}
+	if len(tags) > 0 {
+		args = append(append(args, "--tags"), tagArgs(tags)...)
+	}
+	_, err := az.azureCLI(args...)
+	return err
+}
+
+// DeleteCluster deletes the AKS cluster
+func (az *AzureRunner) DeleteCluster(resourceGroup string, name string) error {
+	_, err := az.azureCLI("aks", "delete", "-g", resourceGroup, "-n", name, "--yes")
+	return err
+}
+
+// ConnectToCluster adds the credentials of the AKS cluster to the kube config and makes it the current context
+func (az *AzureRunner) ConnectToCluster(resourceGroup string, name string) error {
+	_, err := az.azureCLI("aks", "get-credentials", "-g", resourceGroup, "-n", name, "--overwrite-existing")
+	return err
+}
+
+// tagArgs returns the arguments for the tags, or an empty tag to remove all tags
+func tagArgs(tags map[string]string) []string {
+	if len(tags) == 0 {
+		return []string{""}
+	}
+	return util.MapToKeyValues(tags)
+}
+
 func formatLoginServer(name string) string {
 	return name + ".azurecr.io"
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
@@ -108,6 +107,25 @@ func ReadBucketURL(u *url.URL, timeout time.Duration) (io.ReadCloser, error) {
 	return data, nil
 }
 
+// ReadBucket reads the data for the key in a bucket URL of the form 's3://bucketName' with the given timeout.
+// If the key does not exist yet then nil data is returned without an error
+func ReadBucket(bucketURL string, key string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	bucket, err := blob.Open(ctx, bucketURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bucket %s", bucketURL)
+	}
+	data, err := bucket.ReadAll(ctx, key)
+	if err != nil {
+		if blob.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read key %s in bucket %s", key, bucketURL)
+	}
+	return data, nil
+}
+
 // WriteBucketURL writes the data to a bucket URL of the for 's3://bucketName/foo/bar/whatnot.txt?param=123'
 // with the given timeout
 func WriteBucketURL(u *url.URL, data io.Reader, timeout time.Duration) error {
@@ -116,24 +134,105 @@ func WriteBucketURL(u *url.URL, data io.Reader, timeout time.Duration) error {
 }
 
 // WriteBucket writes the data to a bucket URL and key of the for 's3://bucketName' and key 'foo/bar/whatnot.txt'
-// with the given timeout
+// with the given timeout. The data is streamed from the reader rather than being loaded into memory
 func WriteBucket(bucketURL string, key string, reader io.Reader, timeout time.Duration) (err error) {
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 	bucket, err := blob.Open(ctx, bucketURL)
 	if err != nil {
 		return errors.Wrapf(err, "failed to open bucket %s", bucketURL)
 	}
-	data, err := ioutil.ReadAll(reader)
+	w, err := bucket.NewWriter(ctx, key, nil)
 	if err != nil {
+		return errors.Wrapf(err, "failed to write key %s in bucket %s", key, bucketURL)
+	}
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		// cancelling the context before closing the writer aborts the write
+		cancel()
+		w.Close() //nolint:errcheck
 		return errors.Wrapf(err, "failed to read data for key %s in bucket %s", key, bucketURL)
 	}
-	err = bucket.WriteAll(ctx, key, data, nil)
+	err = w.Close()
 	if err != nil {
 		return errors.Wrapf(err, "failed to write key %s in bucket %s", key, bucketURL)
 	}
 	return nil
 }
 
+// OpenBucket opens a reader of the data for the key in a bucket URL of the form 's3://bucketName' with the given
+// timeout so that large objects do not have to be loaded into memory. If the key does not exist yet then a nil
+// reader is returned without an error. The reader must be closed by the caller
+func OpenBucket(bucketURL string, key string, timeout time.Duration) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	bucket, err := blob.Open(ctx, bucketURL)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrapf(err, "failed to open bucket %s", bucketURL)
+	}
+	reader, err := bucket.NewReader(ctx, key, nil)
+	if err != nil {
+		cancel()
+		if blob.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read key %s in bucket %s", key, bucketURL)
+	}
+	return &cancelOnClose{ReadCloser: reader, cancel: cancel}, nil
+}
+
+// cancelOnClose cancels the context of a reader when it is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the reader and cancels its context
+func (r *cancelOnClose) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// ListBucket lists the objects in a bucket URL of the form 's3://bucketName' whose keys start with the given prefix
+func ListBucket(bucketURL string, prefix string, timeout time.Duration) ([]*blob.ListObject, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	bucket, err := blob.Open(ctx, bucketURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bucket %s", bucketURL)
+	}
+	var answer []*blob.ListObject
+	iter := bucket.List(&blob.ListOptions{
+		Prefix: prefix,
+	})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to list prefix %s in bucket %s", prefix, bucketURL)
+		}
+		answer = append(answer, obj)
+	}
+	return answer, nil
+}
+
+// DeleteBucket deletes the key in a bucket URL of the form 's3://bucketName'
+func DeleteBucket(bucketURL string, key string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	bucket, err := blob.Open(ctx, bucketURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open bucket %s", bucketURL)
+	}
+	err = bucket.Delete(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete key %s in bucket %s", key, bucketURL)
+	}
+	return nil
+}
+
 // SplitBucketURL splits the full bucket URL into the URL to open the bucket and the file name to refer to
 // within the bucket
 func SplitBucketURL(u *url.URL) (string, string) {
@@ -3,8 +3,12 @@
 package buckets_test
 
 import (
+	"io/ioutil"
 	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +21,28 @@ func TestSplitBucketURL(t *testing.T) {
 
 }
 
+func TestWriteAndOpenBucket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-buckets-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	bucketURL := "file://" + dir
+
+	reader, err := buckets.OpenBucket(bucketURL, "missing.txt", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, reader, "should not open a missing key")
+
+	err = buckets.WriteBucket(bucketURL, "folder/cheese.txt", strings.NewReader("edam"), time.Minute)
+	require.NoError(t, err)
+
+	reader, err = buckets.OpenBucket(bucketURL, "folder/cheese.txt", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, reader)
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "edam", string(data))
+}
+
 func assertSplitBucketURL(t *testing.T, inputURL string, expectedBucketURL string, expectedKey string) {
 	u, err := url.Parse(inputURL)
 	require.NoError(t, err, "failed to parse URL %s", inputURL)
@@ -18,6 +18,9 @@ const (
 	ICP        = "icp"
 	JX_INFRA   = "jx-infra"
 	ALIBABA    = "alibaba"
+
+	// KIND local kind clusters which are only used for testing so are not a Kubernetes provider for installation
+	KIND = "kind"
 )
 
 // KubernetesProviders list of all available Kubernetes providers
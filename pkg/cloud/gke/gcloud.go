@@ -2,10 +2,12 @@ package gke
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -18,6 +20,8 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/googleapi"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -59,10 +63,11 @@ type GCloud struct {
 
 // Cluster struct to represent a cluster on gcloud
 type Cluster struct {
-	Name           string            `json:"name,omitempty"`
-	ResourceLabels map[string]string `json:"resourceLabels,omitempty"`
-	Status         string            `json:"status,omitempty"`
-	Location       string            `json:"location,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	ResourceLabels   map[string]string `json:"resourceLabels,omitempty"`
+	LabelFingerprint string            `json:"labelFingerprint,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	Location         string            `json:"location,omitempty"`
 }
 
 type recordSet struct {
@@ -912,6 +917,66 @@ func (g *GCloud) UpdateGkeClusterLabels(region string, projectID string, cluster
 	return err
 }
 
+// SetGkeClusterLabels replaces the labels of a gke cluster if they still have the given fingerprint, which is the
+// labelFingerprint of the cluster when its labels were read. Returns false if the labels have changed since then
+func (g *GCloud) SetGkeClusterLabels(location string, projectID string, clusterName string, labels map[string]string, fingerprint string) (bool, error) {
+	ctx := context.Background()
+	service, err := container.NewService(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "creating the GKE client")
+	}
+	name := fmt.Sprintf("projects/%s/locations/%s/clusters/%s", projectID, location, clusterName)
+	request := &container.SetLabelsRequest{
+		ResourceLabels:   labels,
+		LabelFingerprint: fingerprint,
+	}
+	_, err = service.Projects.Locations.Clusters.SetResourceLabels(name, request).Context(ctx).Do()
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && (apiErr.Code == http.StatusConflict || apiErr.Code == http.StatusPreconditionFailed || strings.Contains(apiErr.Message, "fingerprint")) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "setting the labels of cluster %s", name)
+	}
+	return true, nil
+}
+
+// CreateGkeCluster creates a gke cluster in a GKE project with the given labels
+func (g *GCloud) CreateGkeCluster(region string, projectID string, clusterName string, labels []string) error {
+	args := []string{"container", "clusters", "create", clusterName, "--quiet"}
+	if len(labels) > 0 {
+		args = append(args, "--labels="+strings.Join(labels, ","))
+	}
+	if region != "" {
+		args = append(args, "--region="+region)
+	}
+	if projectID != "" {
+		args = append(args, "--project="+projectID)
+	}
+	cmd := util.Command{
+		Name: "gcloud",
+		Args: args,
+	}
+	_, err := cmd.RunWithoutRetry()
+	return err
+}
+
+// DeleteGkeCluster deletes a gke cluster from a GKE project
+func (g *GCloud) DeleteGkeCluster(region string, projectID string, clusterName string) error {
+	args := []string{"container", "clusters", "delete", clusterName, "--quiet"}
+	if region != "" {
+		args = append(args, "--region="+region)
+	}
+	if projectID != "" {
+		args = append(args, "--project="+projectID)
+	}
+	cmd := util.Command{
+		Name: "gcloud",
+		Args: args,
+	}
+	_, err := cmd.RunWithoutRetry()
+	return err
+}
+
 // DeleteServiceAccountKey deletes a service account key
 func (g *GCloud) DeleteServiceAccountKey(serviceAccount string, projectID string, key string) error {
 	account := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", serviceAccount, projectID)
@@ -22,6 +22,8 @@ type GClouder interface {
 	ListClusters(region string, projectID string) ([]Cluster, error)
 	LoadGkeCluster(region string, projectID string, clusterName string) (*Cluster, error)
 	UpdateGkeClusterLabels(region string, projectID string, clusterName string, labels []string) error
+	CreateGkeCluster(region string, projectID string, clusterName string, labels []string) error
+	DeleteGkeCluster(region string, projectID string, clusterName string) error
 	DeleteServiceAccountKey(serviceAccount string, projectID string, key string) error
 	CleanupServiceAccountKeys(serviceAccount string, projectID string) error
 	DeleteServiceAccount(serviceAccount string, projectID string, roles []string) error
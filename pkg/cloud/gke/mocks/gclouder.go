@@ -209,6 +209,21 @@ func (mock *MockGClouder) CreateGCPServiceAccount(_param0 kubernetes.Interface,
 	return ret0, ret1
 }
 
+func (mock *MockGClouder) CreateGkeCluster(_param0 string, _param1 string, _param2 string, _param3 []string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGClouder().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("CreateGkeCluster", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockGClouder) CreateKmsKey(_param0 string, _param1 string, _param2 string) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGClouder().")
@@ -299,6 +314,21 @@ func (mock *MockGClouder) DeleteBucket(_param0 string) error {
 	return ret0
 }
 
+func (mock *MockGClouder) DeleteGkeCluster(_param0 string, _param1 string, _param2 string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockGClouder().")
+	}
+	params := []pegomock.Param{_param0, _param1, _param2}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteGkeCluster", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockGClouder) DeleteServiceAccount(_param0 string, _param1 string, _param2 []string) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockGClouder().")
@@ -1026,6 +1056,45 @@ func (c *MockGClouder_CreateGCPServiceAccount_OngoingVerification) GetAllCapture
 	return
 }
 
+func (verifier *VerifierMockGClouder) CreateGkeCluster(_param0 string, _param1 string, _param2 string, _param3 []string) *MockGClouder_CreateGkeCluster_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2, _param3}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CreateGkeCluster", params, verifier.timeout)
+	return &MockGClouder_CreateGkeCluster_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGClouder_CreateGkeCluster_OngoingVerification struct {
+	mock              *MockGClouder
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGClouder_CreateGkeCluster_OngoingVerification) GetCapturedArguments() (string, string, string, []string) {
+	_param0, _param1, _param2, _param3 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1], _param3[len(_param3)-1]
+}
+
+func (c *MockGClouder_CreateGkeCluster_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 [][]string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+		_param3 = make([][]string, len(c.methodInvocations))
+		for u, param := range params[3] {
+			_param3[u] = param.([]string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGClouder) CreateKmsKey(_param0 string, _param1 string, _param2 string) *MockGClouder_CreateKmsKey_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CreateKmsKey", params, verifier.timeout)
@@ -1212,6 +1281,41 @@ func (c *MockGClouder_DeleteBucket_OngoingVerification) GetAllCapturedArguments(
 	return
 }
 
+func (verifier *VerifierMockGClouder) DeleteGkeCluster(_param0 string, _param1 string, _param2 string) *MockGClouder_DeleteGkeCluster_OngoingVerification {
+	params := []pegomock.Param{_param0, _param1, _param2}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteGkeCluster", params, verifier.timeout)
+	return &MockGClouder_DeleteGkeCluster_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockGClouder_DeleteGkeCluster_OngoingVerification struct {
+	mock              *MockGClouder
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockGClouder_DeleteGkeCluster_OngoingVerification) GetCapturedArguments() (string, string, string) {
+	_param0, _param1, _param2 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1], _param1[len(_param1)-1], _param2[len(_param2)-1]
+}
+
+func (c *MockGClouder_DeleteGkeCluster_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]string, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.(string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockGClouder) DeleteServiceAccount(_param0 string, _param1 string, _param2 []string) *MockGClouder_DeleteServiceAccount_OngoingVerification {
 	params := []pegomock.Param{_param0, _param1, _param2}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteServiceAccount", params, verifier.timeout)
@@ -0,0 +1,123 @@
+package aks
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/aks"
+	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+type azure struct {
+	resourceGroup string
+	az            *aks.AzureRunner
+}
+
+// NewAKS create a new client for working with AKS clusters in the given resource group
+func NewAKS(resourceGroup string) (cluster.Client, error) {
+	return NewAKSWithRunner(resourceGroup, aks.NewAzureRunner())
+}
+
+// NewAKSWithRunner create a new client for working with AKS clusters in the given resource group using the given
+// Azure CLI runner
+func NewAKSWithRunner(resourceGroup string, az *aks.AzureRunner) (cluster.Client, error) {
+	if resourceGroup == "" {
+		return nil, fmt.Errorf("no resource group specified for the AKS cluster client")
+	}
+	return &azure{
+		resourceGroup: resourceGroup,
+		az:            az,
+	}, nil
+}
+
+// NewAKSFromEnv create a new client for working with AKS clusters using environment variables to define the resource group
+func NewAKSFromEnv() (cluster.Client, error) {
+	resourceGroup := os.Getenv(cluster.EnvAKSResourceGroup)
+	if resourceGroup == "" {
+		return nil, util.MissingEnv(cluster.EnvAKSResourceGroup)
+	}
+	return NewAKS(resourceGroup)
+}
+
+// List lists the clusters
+func (c *azure) List() ([]*cluster.Cluster, error) {
+	items, err := c.az.ListClusters(c.resourceGroup)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list clusters in resource group %s", c.resourceGroup)
+	}
+	var answer []*cluster.Cluster
+	for _, item := range items {
+		answer = append(answer, &cluster.Cluster{
+			Name:             item.Name,
+			Labels:           item.Tags,
+			Status:           item.Status,
+			Location:         item.Location,
+			LabelFingerprint: item.ETag,
+		})
+	}
+	return answer, nil
+}
+
+// ListFilter lists the clusters with a filter
+func (c *azure) ListFilter(labels map[string]string) ([]*cluster.Cluster, error) {
+	return cluster.ListFilter(c, labels)
+}
+
+// Connect connects to a cluster
+func (c *azure) Connect(cluster *cluster.Cluster) error {
+	return c.az.ConnectToCluster(c.resourceGroup, cluster.Name)
+}
+
+// String return the string representation
+func (c *azure) String() string {
+	return fmt.Sprintf("AKS resource group: %s", c.resourceGroup)
+}
+
+// Get looks up a cluster by name
+func (c *azure) Get(name string) (*cluster.Cluster, error) {
+	return cluster.GetCluster(c, name)
+}
+
+// Create creates a new cluster in the resource group
+func (c *azure) Create(name string, labels map[string]string) (*cluster.Cluster, error) {
+	err := c.az.CreateCluster(c.resourceGroup, name, labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create cluster %s in resource group %s", name, c.resourceGroup)
+	}
+	return c.Get(name)
+}
+
+// Delete deletes the cluster from the resource group
+func (c *azure) Delete(cluster *cluster.Cluster) error {
+	err := c.az.DeleteCluster(c.resourceGroup, cluster.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete cluster %s in resource group %s", cluster.Name, c.resourceGroup)
+	}
+	return nil
+}
+
+// SetClusterLabels replaces the tags of the given cluster with the labels
+func (c *azure) SetClusterLabels(cluster *cluster.Cluster, labels map[string]string) error {
+	err := c.az.UpdateClusterTags(c.resourceGroup, cluster.Name, labels)
+	if err != nil {
+		return errors.Wrapf(err, "failed to tag cluster %s in resource group %s", cluster.Name, c.resourceGroup)
+	}
+	cluster.Labels = labels
+	return nil
+}
+
+// SetClusterLabelsIfUnchanged replaces the tags of the given cluster with the labels if the cluster still has the
+// etag it had when it was read
+func (c *azure) SetClusterLabelsIfUnchanged(cluster *cluster.Cluster, labels map[string]string) (bool, error) {
+	etag, updated, err := c.az.UpdateClusterTagsIfMatch(c.resourceGroup, cluster.Name, labels, cluster.LabelFingerprint)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to tag cluster %s in resource group %s", cluster.Name, c.resourceGroup)
+	}
+	if updated {
+		cluster.Labels = labels
+		cluster.LabelFingerprint = etag
+	}
+	return updated, nil
+}
@@ -0,0 +1,142 @@
+// +build unit
+
+package aks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/aks"
+	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	clusteraks "github.com/jenkins-x/jx/v2/pkg/cluster/aks"
+	mocks "github.com/jenkins-x/jx/v2/pkg/util/mocks"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listOutput = `[
+	{
+		"group": "e2e",
+		"location": "westeurope",
+		"name": "pool-abc",
+		"status": "Succeeded",
+		"tags": {"pool": "e2e", "lease-id": "1234"},
+		"etag": "1"
+	},
+	{
+		"group": "e2e",
+		"location": "westeurope",
+		"name": "other",
+		"status": "Creating",
+		"tags": null
+	}
+]`
+
+func newClient(t *testing.T) (cluster.Client, *mocks.MockCommander) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn(listOutput, nil)
+	client, err := clusteraks.NewAKSWithRunner("e2e", aks.NewAzureRunnerWithCommander(runner))
+	require.NoError(t, err)
+	return client, runner
+}
+
+func TestAKSList(t *testing.T) {
+	client, runner := newClient(t)
+
+	clusters, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "pool-abc", clusters[0].Name)
+	assert.Equal(t, "Succeeded", clusters[0].Status)
+	assert.Equal(t, "westeurope", clusters[0].Location)
+	assert.Equal(t, map[string]string{"pool": "e2e", "lease-id": "1234"}, clusters[0].Labels)
+	assert.Equal(t, "1", clusters[0].LabelFingerprint)
+
+	args := runner.VerifyWasCalled(AtLeast(1)).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"aks", "list", "--query", "[].{name:name,group:resourceGroup,location:location,status:provisioningState,tags:tags,etag:etag}", "-g", "e2e"}, args)
+
+	filtered, err := client.ListFilter(map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "pool-abc", filtered[0].Name)
+}
+
+func TestAKSSetClusterLabels(t *testing.T) {
+	client, runner := newClient(t)
+
+	c := &cluster.Cluster{Name: "pool-abc"}
+	err := client.SetClusterLabels(c, map[string]string{"pool": "e2e", "lease-owner": "pr-1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pool": "e2e", "lease-owner": "pr-1"}, c.Labels)
+
+	args := runner.VerifyWasCalled(Once()).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"aks", "update", "-g", "e2e", "-n", "pool-abc", "--tags", "lease-owner=pr-1", "pool=e2e"}, args)
+
+	err = client.SetClusterLabels(c, map[string]string{})
+	require.NoError(t, err)
+	args = runner.VerifyWasCalled(Times(2)).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"aks", "update", "-g", "e2e", "-n", "pool-abc", "--tags", ""}, args)
+}
+
+func TestAKSSetClusterLabelsIfUnchanged(t *testing.T) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn(`{"name": "pool-abc", "etag": "2"}`, nil)
+	client, err := clusteraks.NewAKSWithRunner("e2e", aks.NewAzureRunnerWithCommander(runner))
+	require.NoError(t, err)
+	conditional, ok := client.(cluster.ConditionalLabelsClient)
+	require.True(t, ok, "the AKS client should update the labels of clusters conditionally")
+
+	c := &cluster.Cluster{Name: "pool-abc", LabelFingerprint: "1"}
+	updated, err := conditional.SetClusterLabelsIfUnchanged(c, map[string]string{"pool": "e2e", "lease-id": "1234"})
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, map[string]string{"pool": "e2e", "lease-id": "1234"}, c.Labels)
+	assert.Equal(t, "2", c.LabelFingerprint)
+
+	args := runner.VerifyWasCalled(Once()).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"aks", "update", "-g", "e2e", "-n", "pool-abc", "--if-match", "1", "--tags", "lease-id=1234", "pool=e2e"}, args)
+}
+
+func TestAKSSetClusterLabelsIfUnchangedWhenChanged(t *testing.T) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn("", errors.New("(PreconditionFailed) Operation is not allowed because the etag does not match"))
+	client, err := clusteraks.NewAKSWithRunner("e2e", aks.NewAzureRunnerWithCommander(runner))
+	require.NoError(t, err)
+	conditional := client.(cluster.ConditionalLabelsClient)
+
+	c := &cluster.Cluster{Name: "pool-abc", Labels: map[string]string{"pool": "e2e"}, LabelFingerprint: "1"}
+	updated, err := conditional.SetClusterLabelsIfUnchanged(c, map[string]string{"pool": "e2e", "lease-id": "1234"})
+	require.NoError(t, err)
+	assert.False(t, updated, "the cluster changed since it was read")
+	assert.Equal(t, map[string]string{"pool": "e2e"}, c.Labels)
+	assert.Equal(t, "1", c.LabelFingerprint)
+
+	_, err = conditional.SetClusterLabelsIfUnchanged(&cluster.Cluster{Name: "pool-abc"}, map[string]string{})
+	assert.Error(t, err, "a cluster without an etag cannot be updated conditionally")
+}
+
+func TestAKSCreateAndDelete(t *testing.T) {
+	client, runner := newClient(t)
+
+	c, err := client.Create("pool-abc", map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	require.NotNil(t, c)
+	assert.Equal(t, "pool-abc", c.Name)
+
+	allArgs := runner.VerifyWasCalled(Times(2)).SetArgs(AnyStringSlice()).GetAllCapturedArguments()
+	assert.Equal(t, []string{"aks", "create", "-g", "e2e", "-n", "pool-abc", "--generate-ssh-keys", "--tags", "pool=e2e"}, allArgs[0])
+
+	err = client.Delete(c)
+	require.NoError(t, err)
+	args := runner.VerifyWasCalled(Times(3)).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"aks", "delete", "-g", "e2e", "-n", "pool-abc", "--yes"}, args)
+}
+
+func TestNewAKSRequiresResourceGroup(t *testing.T) {
+	_, err := clusteraks.NewAKSWithRunner("", aks.NewAzureRunner())
+	assert.Error(t, err)
+}
@@ -7,4 +7,7 @@ const (
 
 	// EnvGKERegion the environment variable for the GKE region
 	EnvGKERegion = "GKE_REGION"
+
+	// EnvAKSResourceGroup the environment variable for the AKS resource group
+	EnvAKSResourceGroup = "AKS_RESOURCE_GROUP"
 )
@@ -60,6 +60,12 @@ func (a awsClusterClient) Get(clusterName string) (*cluster.Cluster, error) {
 	return describedCluster, err
 }
 
+// Create creates a new EKS cluster, which is not supported yet. So a pool of EKS clusters cannot grow, its clusters
+// have to be created with eksctl and tagged with the labels of the pool
+func (a awsClusterClient) Create(name string, tags map[string]string) (*cluster.Cluster, error) {
+	return nil, fmt.Errorf("creating EKS cluster %s is not supported by the EKS cluster client so pools of EKS clusters cannot grow", name)
+}
+
 // Delete should delete the given cluster using eksctl and delete any created EBS volumes to avoid extra charges
 func (a awsClusterClient) Delete(cluster *cluster.Cluster) error {
 	log.Logger().Infof("Attempting to delete cluster %s", cluster.Name)
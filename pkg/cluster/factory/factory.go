@@ -6,8 +6,10 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cloud"
 	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/aks"
 	"github.com/jenkins-x/jx/v2/pkg/cluster/eks"
 	"github.com/jenkins-x/jx/v2/pkg/cluster/gke"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/kind"
 )
 
 // NewClientFromEnv uses environment variables to detect which kind of cluster we are running inside
@@ -15,6 +17,9 @@ func NewClientFromEnv() (cluster.Client, error) {
 	if os.Getenv(cluster.EnvGKEProject) != "" && os.Getenv(cluster.EnvGKERegion) != "" {
 		return gke.NewGKEFromEnv()
 	}
+	if os.Getenv(cluster.EnvAKSResourceGroup) != "" {
+		return aks.NewAKSFromEnv()
+	}
 	// lets try discover the current project
 	return nil, fmt.Errorf("could not detect the cluter.Client from the environment variables")
 }
@@ -28,6 +33,10 @@ func NewClientForProvider(provider string) (cluster.Client, error) {
 		fallthrough
 	case cloud.EKS:
 		return eks.NewAWSClusterClient()
+	case cloud.AKS:
+		return aks.NewAKSFromEnv()
+	case cloud.KIND:
+		return kind.NewKind()
 	default:
 		return nil, fmt.Errorf("no cluster client found for provier %s", provider)
 	}
@@ -1,6 +1,8 @@
 package fake
 
 import (
+	"strconv"
+
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/cluster"
 	"github.com/jenkins-x/jx/v2/pkg/util"
@@ -9,10 +11,13 @@ import (
 // Client a fake implementation of the cluster client
 type Client struct {
 	Clusters []*cluster.Cluster
+
+	labelUpdates int
 }
 
-// verify we implement the interface
+// verify we implement the interfaces
 var _ cluster.Client = &Client{}
+var _ cluster.ConditionalLabelsClient = &Client{}
 
 // NewClient create a new fake client for testing
 func NewClient(clusters []*cluster.Cluster) *Client {
@@ -21,9 +26,15 @@ func NewClient(clusters []*cluster.Cluster) *Client {
 	}
 }
 
-// List lists the clusters
+// List lists copies of the clusters like a real client would
 func (c *Client) List() ([]*cluster.Cluster, error) {
-	return c.Clusters, nil
+	var answer []*cluster.Cluster
+	for _, v := range c.Clusters {
+		copy := *v
+		copy.Labels = util.MergeMaps(map[string]string{}, v.Labels)
+		answer = append(answer, &copy)
+	}
+	return answer, nil
 }
 
 // ListFilter lists the clusters with a filter
@@ -47,6 +58,17 @@ func (c *Client) Get(name string) (*cluster.Cluster, error) {
 	return cluster.GetCluster(c, name)
 }
 
+// Create adds a new cluster to the clusters list
+func (c *Client) Create(name string, labels map[string]string) (*cluster.Cluster, error) {
+	answer := &cluster.Cluster{
+		Name:   name,
+		Labels: labels,
+		Status: "RUNNING",
+	}
+	c.Clusters = append(c.Clusters, answer)
+	return c.Get(name)
+}
+
 // Delete should delete the cluster from the clusters list
 func (c *Client) Delete(cluster *cluster.Cluster) error {
 	for i, v := range c.Clusters {
@@ -60,5 +82,23 @@ func (c *Client) Delete(cluster *cluster.Cluster) error {
 // SetClusterLabels labels the given cluster
 func (c *Client) SetClusterLabels(cluster *cluster.Cluster, labels map[string]string) error {
 	cluster.Labels = labels
+	c.labelUpdates++
+	cluster.LabelFingerprint = strconv.Itoa(c.labelUpdates)
+	for _, v := range c.Clusters {
+		if v.Name == cluster.Name {
+			v.Labels = util.MergeMaps(map[string]string{}, labels)
+			v.LabelFingerprint = cluster.LabelFingerprint
+		}
+	}
 	return nil
 }
+
+// SetClusterLabelsIfUnchanged labels the given cluster if its labels have not changed since it was listed
+func (c *Client) SetClusterLabelsIfUnchanged(cluster *cluster.Cluster, labels map[string]string) (bool, error) {
+	for _, v := range c.Clusters {
+		if v.Name == cluster.Name && v.LabelFingerprint != cluster.LabelFingerprint {
+			return false, nil
+		}
+	}
+	return true, c.SetClusterLabels(cluster, labels)
+}
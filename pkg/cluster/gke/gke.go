@@ -16,6 +16,9 @@ type gcloud struct {
 	gcloud  gcp.GCloud
 }
 
+// verify we can lease the clusters of a pool
+var _ cluster.ConditionalLabelsClient = &gcloud{}
+
 // NewGKE create a new client for working with GKE clusters using the given region and project
 func NewGKE(project string, region string) (cluster.Client, error) {
 	return &gcloud{
@@ -47,10 +50,11 @@ func (c *gcloud) List() ([]*cluster.Cluster, error) {
 
 	for _, item := range items {
 		answer = append(answer, &cluster.Cluster{
-			Name:     item.Name,
-			Labels:   item.ResourceLabels,
-			Status:   item.Status,
-			Location: item.Location,
+			Name:             item.Name,
+			Labels:           item.ResourceLabels,
+			Status:           item.Status,
+			Location:         item.Location,
+			LabelFingerprint: item.LabelFingerprint,
 		})
 	}
 	return answer, nil
@@ -76,9 +80,18 @@ func (c *gcloud) Get(name string) (*cluster.Cluster, error) {
 	return cluster.GetCluster(c, name)
 }
 
+// Create creates a new cluster in GKE
+func (c *gcloud) Create(name string, labelMap map[string]string) (*cluster.Cluster, error) {
+	err := c.gcloud.CreateGkeCluster(c.region, c.project, name, util.MapToKeyValues(labelMap))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create cluster %s in region %s project %s", name, c.region, c.project)
+	}
+	return c.Get(name)
+}
+
 // Delete should delete the cluster from GKE
 func (c *gcloud) Delete(cluster *cluster.Cluster) error {
-	return fmt.Errorf("not implemented")
+	return c.gcloud.DeleteGkeCluster(util.FirstNotEmptyString(cluster.Location, c.region), c.project, cluster.Name)
 }
 
 // SetClusterLabels labels the given cluster
@@ -86,3 +99,8 @@ func (c *gcloud) SetClusterLabels(cluster *cluster.Cluster, labelMap map[string]
 	labels := util.MapToKeyValues(labelMap)
 	return c.gcloud.UpdateGkeClusterLabels(cluster.Location, c.project, cluster.Name, labels)
 }
+
+// SetClusterLabelsIfUnchanged replaces the labels of the cluster if they have not changed since the cluster was read
+func (c *gcloud) SetClusterLabelsIfUnchanged(cluster *cluster.Cluster, labelMap map[string]string) (bool, error) {
+	return c.gcloud.SetGkeClusterLabels(util.FirstNotEmptyString(cluster.Location, c.region), c.project, cluster.Name, labelMap, cluster.LabelFingerprint)
+}
@@ -6,6 +6,9 @@ type Cluster struct {
 	Labels   map[string]string
 	Status   string
 	Location string
+	// LabelFingerprint identifies the labels of the cluster when it was read so that they are only updated if they
+	// have not been changed since
+	LabelFingerprint string
 }
 
 // Client represents a kubernetes cluster provider
@@ -28,5 +31,16 @@ type Client interface {
 	// Get looks up a given cluster by name returning nil if its not found
 	Get(name string) (*Cluster, error)
 
+	// Create creates a new cluster with the given name and labels
+	Create(name string, labels map[string]string) (*Cluster, error)
+
 	Delete(cluster *Cluster) error
 }
+
+// ConditionalLabelsClient is implemented by clients which can update the labels of a cluster only if they have not
+// been changed since the cluster was read, so that concurrent updates of the labels cannot both succeed
+type ConditionalLabelsClient interface {
+	// SetClusterLabelsIfUnchanged replaces the labels of the cluster if they still have the LabelFingerprint of the
+	// cluster. Returns false if the labels have been changed since the cluster was read
+	SetClusterLabelsIfUnchanged(cluster *Cluster, labels map[string]string) (bool, error)
+}
@@ -0,0 +1,226 @@
+package kind
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// LabelsConfigMapName the name of the ConfigMap in each kind cluster which stores the labels of the cluster
+	LabelsConfigMapName = "jx-cluster-labels"
+
+	// LabelsConfigMapNamespace the namespace of the ConfigMap which stores the labels of the cluster
+	LabelsConfigMapNamespace = "kube-system"
+
+	// StatusRunning the status of kind clusters as they only exist while they are running
+	StatusRunning = "RUNNING"
+)
+
+// KubeClientFn creates a kubernetes client for the kind cluster with the given name
+type KubeClientFn func(name string) (kubernetes.Interface, error)
+
+type kind struct {
+	runner     util.Commander
+	kubeClient KubeClientFn
+}
+
+// NewKind create a new client for working with local kind clusters
+func NewKind() (cluster.Client, error) {
+	return NewKindWithRunner(&util.Command{}, KubeClientForCluster)
+}
+
+// NewKindWithRunner create a new client for working with local kind clusters using the given command runner and
+// function to create kubernetes clients for the clusters which store their labels
+func NewKindWithRunner(runner util.Commander, kubeClient KubeClientFn) (cluster.Client, error) {
+	return &kind{
+		runner:     runner,
+		kubeClient: kubeClient,
+	}, nil
+}
+
+// KubeClientForCluster creates a kubernetes client for the kind cluster using its context in the kube config
+func KubeClientForCluster(name string) (kubernetes.Interface, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: "kind-" + name},
+	).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the kube config context of kind cluster %s", name)
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// List lists the clusters
+func (c *kind) List() ([]*cluster.Cluster, error) {
+	output, err := c.kind("get", "clusters")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list kind clusters")
+	}
+	var answer []*cluster.Cluster
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "No kind clusters") {
+			continue
+		}
+		labels, fingerprint, err := c.loadLabels(name)
+		if err != nil {
+			log.Logger().Warnf("failed to load the labels of kind cluster %s: %s", name, err)
+		}
+		answer = append(answer, &cluster.Cluster{
+			Name:             name,
+			Labels:           labels,
+			Status:           StatusRunning,
+			LabelFingerprint: fingerprint,
+		})
+	}
+	return answer, nil
+}
+
+// ListFilter lists the clusters with a filter
+func (c *kind) ListFilter(labels map[string]string) ([]*cluster.Cluster, error) {
+	return cluster.ListFilter(c, labels)
+}
+
+// Connect connects to a cluster
+func (c *kind) Connect(cluster *cluster.Cluster) error {
+	_, err := c.kind("export", "kubeconfig", "--name", cluster.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect to kind cluster %s", cluster.Name)
+	}
+	return nil
+}
+
+// String return the string representation
+func (c *kind) String() string {
+	return "kind clusters"
+}
+
+// Get looks up a cluster by name
+func (c *kind) Get(name string) (*cluster.Cluster, error) {
+	return cluster.GetCluster(c, name)
+}
+
+// Create creates a new kind cluster
+func (c *kind) Create(name string, labels map[string]string) (*cluster.Cluster, error) {
+	_, err := c.kind("create", "cluster", "--name", name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create kind cluster %s", name)
+	}
+	answer := &cluster.Cluster{
+		Name:   name,
+		Status: StatusRunning,
+	}
+	if len(labels) > 0 {
+		err = c.SetClusterLabels(answer, labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return answer, nil
+}
+
+// Delete deletes the kind cluster
+func (c *kind) Delete(cluster *cluster.Cluster) error {
+	_, err := c.kind("delete", "cluster", "--name", cluster.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete kind cluster %s", cluster.Name)
+	}
+	return nil
+}
+
+// SetClusterLabels replaces the labels stored in the ConfigMap of the given cluster
+func (c *kind) SetClusterLabels(cluster *cluster.Cluster, labels map[string]string) error {
+	kubeClient, err := c.kubeClient(cluster.Name)
+	if err != nil {
+		return err
+	}
+	configMaps := kubeClient.CoreV1().ConfigMaps(LabelsConfigMapNamespace)
+	cm, err := configMaps.Get(LabelsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get ConfigMap %s of kind cluster %s", LabelsConfigMapName, cluster.Name)
+		}
+		cm = newLabelsConfigMap(labels)
+		cm, err = configMaps.Create(cm)
+	} else {
+		cm.Data = util.MergeMaps(map[string]string{}, labels)
+		cm, err = configMaps.Update(cm)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the labels of kind cluster %s", cluster.Name)
+	}
+	cluster.Labels = labels
+	cluster.LabelFingerprint = cm.ResourceVersion
+	return nil
+}
+
+// SetClusterLabelsIfUnchanged replaces the labels stored in the ConfigMap of the given cluster if the ConfigMap still
+// has the resource version it had when the cluster was read
+func (c *kind) SetClusterLabelsIfUnchanged(cluster *cluster.Cluster, labels map[string]string) (bool, error) {
+	kubeClient, err := c.kubeClient(cluster.Name)
+	if err != nil {
+		return false, err
+	}
+	configMaps := kubeClient.CoreV1().ConfigMaps(LabelsConfigMapNamespace)
+	cm := newLabelsConfigMap(labels)
+	if cluster.LabelFingerprint == "" {
+		cm, err = configMaps.Create(cm)
+	} else {
+		cm.ResourceVersion = cluster.LabelFingerprint
+		cm, err = configMaps.Update(cm)
+	}
+	if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to save the labels of kind cluster %s", cluster.Name)
+	}
+	cluster.Labels = labels
+	cluster.LabelFingerprint = cm.ResourceVersion
+	return true, nil
+}
+
+func newLabelsConfigMap(labels map[string]string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LabelsConfigMapName,
+			Namespace: LabelsConfigMapNamespace,
+		},
+		Data: util.MergeMaps(map[string]string{}, labels),
+	}
+}
+
+// loadLabels returns the labels of the cluster and the resource version of the ConfigMap which stores them, which is
+// empty if there is no ConfigMap
+func (c *kind) loadLabels(name string) (map[string]string, string, error) {
+	kubeClient, err := c.kubeClient(name)
+	if err != nil {
+		return nil, "", err
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps(LabelsConfigMapNamespace).Get(LabelsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return map[string]string{}, "", nil
+		}
+		return nil, "", err
+	}
+	if cm.Data == nil {
+		return map[string]string{}, cm.ResourceVersion, nil
+	}
+	return cm.Data, cm.ResourceVersion, nil
+}
+
+func (c *kind) kind(args ...string) (string, error) {
+	c.runner.SetName("kind")
+	c.runner.SetArgs(args)
+	return c.runner.RunWithoutRetry()
+}
@@ -0,0 +1,183 @@
+// +build unit
+
+package kind_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/kind"
+	mocks "github.com/jenkins-x/jx/v2/pkg/util/mocks"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newClient(t *testing.T, output string, kubeClients map[string]kubernetes.Interface) (cluster.Client, *mocks.MockCommander) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn(output, nil)
+	kubeClientFn := func(name string) (kubernetes.Interface, error) {
+		kubeClient, ok := kubeClients[name]
+		if !ok {
+			kubeClient = fake.NewSimpleClientset()
+			kubeClients[name] = kubeClient
+		}
+		return kubeClient, nil
+	}
+	client, err := kind.NewKindWithRunner(runner, kubeClientFn)
+	require.NoError(t, err)
+	return client, runner
+}
+
+func TestKindList(t *testing.T) {
+	kubeClients := map[string]kubernetes.Interface{
+		"pool-abc": fake.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      kind.LabelsConfigMapName,
+				Namespace: kind.LabelsConfigMapNamespace,
+			},
+			Data: map[string]string{"pool": "e2e"},
+		}),
+	}
+	client, runner := newClient(t, "pool-abc\nother\n", kubeClients)
+
+	clusters, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "pool-abc", clusters[0].Name)
+	assert.Equal(t, kind.StatusRunning, clusters[0].Status)
+	assert.Equal(t, map[string]string{"pool": "e2e"}, clusters[0].Labels)
+	assert.Equal(t, "other", clusters[1].Name)
+	assert.Empty(t, clusters[1].Labels)
+
+	args := runner.VerifyWasCalled(Once()).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"get", "clusters"}, args)
+
+	filtered, err := client.ListFilter(map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "pool-abc", filtered[0].Name)
+}
+
+func TestKindListNoClusters(t *testing.T) {
+	client, _ := newClient(t, "No kind clusters found.\n", map[string]kubernetes.Interface{})
+
+	clusters, err := client.List()
+	require.NoError(t, err)
+	assert.Empty(t, clusters)
+}
+
+func TestKindSetClusterLabels(t *testing.T) {
+	kubeClients := map[string]kubernetes.Interface{}
+	client, _ := newClient(t, "", kubeClients)
+
+	c := &cluster.Cluster{Name: "pool-abc"}
+	err := client.SetClusterLabels(c, map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pool": "e2e"}, c.Labels)
+
+	err = client.SetClusterLabels(c, map[string]string{"pool": "e2e", "lease-id": "1234"})
+	require.NoError(t, err)
+
+	cm, err := kubeClients["pool-abc"].CoreV1().ConfigMaps(kind.LabelsConfigMapNamespace).Get(kind.LabelsConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pool": "e2e", "lease-id": "1234"}, cm.Data)
+}
+
+// withResourceVersions makes the fake client version the ConfigMaps and reject updates of stale ConfigMaps like the
+// API server does
+func withResourceVersions(kubeClient *fake.Clientset) *fake.Clientset {
+	version := 0
+	kubeClient.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		version++
+		action.(k8stesting.CreateAction).GetObject().(*v1.ConfigMap).ResourceVersion = strconv.Itoa(version)
+		return false, nil, nil
+	})
+	kubeClient.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap)
+		current, err := kubeClient.Tracker().Get(v1.SchemeGroupVersion.WithResource("configmaps"), cm.Namespace, cm.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		if current.(*v1.ConfigMap).ResourceVersion != cm.ResourceVersion {
+			return true, nil, apierrors.NewConflict(v1.Resource("configmaps"), cm.Name, errors.New("the object has been modified"))
+		}
+		version++
+		cm.ResourceVersion = strconv.Itoa(version)
+		return false, nil, nil
+	})
+	return kubeClient
+}
+
+func TestKindSetClusterLabelsIfUnchanged(t *testing.T) {
+	kubeClients := map[string]kubernetes.Interface{
+		"pool-abc": withResourceVersions(fake.NewSimpleClientset()),
+	}
+	client, _ := newClient(t, "pool-abc\n", kubeClients)
+	conditional, ok := client.(cluster.ConditionalLabelsClient)
+	require.True(t, ok, "the kind client should update the labels of clusters conditionally")
+
+	clusters, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	c := clusters[0]
+	assert.Empty(t, c.LabelFingerprint)
+
+	updated, err := conditional.SetClusterLabelsIfUnchanged(c, map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.NotEmpty(t, c.LabelFingerprint)
+
+	// lets simulate another pipeline leasing the cluster after it was read
+	clusters, err = client.List()
+	require.NoError(t, err)
+	other := clusters[0]
+	assert.Equal(t, c.LabelFingerprint, other.LabelFingerprint)
+	updated, err = conditional.SetClusterLabelsIfUnchanged(other, map[string]string{"pool": "e2e", "lease-id": "1"})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	updated, err = conditional.SetClusterLabelsIfUnchanged(c, map[string]string{"pool": "e2e", "lease-id": "2"})
+	require.NoError(t, err)
+	assert.False(t, updated, "the labels changed since the cluster was read")
+
+	updated, err = conditional.SetClusterLabelsIfUnchanged(&cluster.Cluster{Name: "pool-abc"}, map[string]string{"pool": "e2e", "lease-id": "3"})
+	require.NoError(t, err)
+	assert.False(t, updated, "the labels were created since the cluster was read")
+
+	cm, err := kubeClients["pool-abc"].CoreV1().ConfigMaps(kind.LabelsConfigMapNamespace).Get(kind.LabelsConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pool": "e2e", "lease-id": "1"}, cm.Data)
+}
+
+func TestKindCreateAndDelete(t *testing.T) {
+	kubeClients := map[string]kubernetes.Interface{}
+	client, runner := newClient(t, "", kubeClients)
+
+	c, err := client.Create("pool-abc", map[string]string{"pool": "e2e"})
+	require.NoError(t, err)
+	assert.Equal(t, "pool-abc", c.Name)
+	assert.Equal(t, map[string]string{"pool": "e2e"}, c.Labels)
+
+	args := runner.VerifyWasCalled(Once()).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"create", "cluster", "--name", "pool-abc"}, args)
+
+	cm, err := kubeClients["pool-abc"].CoreV1().ConfigMaps(kind.LabelsConfigMapNamespace).Get(kind.LabelsConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"pool": "e2e"}, cm.Data)
+
+	err = client.Delete(c)
+	require.NoError(t, err)
+	args = runner.VerifyWasCalled(Times(2)).SetArgs(AnyStringSlice()).GetCapturedArguments()
+	assert.Equal(t, []string{"delete", "cluster", "--name", "pool-abc"}, args)
+}
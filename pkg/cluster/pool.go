@@ -0,0 +1,393 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LabelPool the label of the clusters in a pool for the name of the pool
+	LabelPool = "pool"
+
+	// LabelLeaseID the label of a leased cluster for the unique id of the lease
+	LabelLeaseID = "lease-id"
+
+	// LabelLeaseOwner the label of a leased cluster for the owner of the lease, such as the name of a build
+	LabelLeaseOwner = "lease-owner"
+
+	// LabelLeaseHeartbeat the label of a leased cluster for the unix time of the last heartbeat of the lease
+	LabelLeaseHeartbeat = "lease-heartbeat"
+
+	// LabelLeaseTTL the label of a leased cluster for the number of seconds the lease lasts after a heartbeat
+	LabelLeaseTTL = "lease-ttl"
+)
+
+// maxLabelUpdateAttempts the number of times the labels of a leased cluster are read and updated conditionally before
+// giving up as they keep changing
+const maxLabelUpdateAttempts = 3
+
+// LeaseLabels the labels which make up a lease on a cluster
+var LeaseLabels = []string{LabelLeaseID, LabelLeaseOwner, LabelLeaseHeartbeat, LabelLeaseTTL}
+
+// Lease a time limited lease on a cluster of a pool which is kept alive by heartbeats
+type Lease struct {
+	Cluster   *Cluster
+	ID        string
+	Owner     string
+	Heartbeat time.Time
+	TTL       time.Duration
+}
+
+// Expires returns the time the lease expires unless there is another heartbeat
+func (l *Lease) Expires() time.Time {
+	return l.Heartbeat.Add(l.TTL)
+}
+
+// IsExpired returns true if the lease has expired at the given time
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !now.Before(l.Expires())
+}
+
+// LeaseOf returns the lease on the cluster from its labels or nil if the cluster is not leased
+func LeaseOf(cluster *Cluster) *Lease {
+	if cluster.Labels == nil || cluster.Labels[LabelLeaseID] == "" {
+		return nil
+	}
+	answer := &Lease{
+		Cluster: cluster,
+		ID:      cluster.Labels[LabelLeaseID],
+		Owner:   cluster.Labels[LabelLeaseOwner],
+	}
+	heartbeat, err := strconv.ParseInt(cluster.Labels[LabelLeaseHeartbeat], 10, 64)
+	if err == nil {
+		answer.Heartbeat = time.Unix(heartbeat, 0)
+	}
+	ttl, err := strconv.ParseInt(cluster.Labels[LabelLeaseTTL], 10, 64)
+	if err == nil {
+		answer.TTL = time.Duration(ttl) * time.Second
+	}
+	return answer
+}
+
+// Pool manages a pool of clusters, such as for running e2e tests, which are leased for a limited time. A lease is
+// kept alive by heartbeats so that the clusters of builds which die are reclaimed when their lease expires
+type Pool struct {
+	Client Client
+	Name   string
+
+	// MinSize the minimum number of clusters in the pool
+	MinSize int
+	// MaxSize the maximum number of clusters in the pool. Zero means the pool does not grow beyond the minimum size
+	MaxSize int
+	// Labels the additional labels used to filter the clusters of the pool and to label new clusters
+	Labels map[string]string
+	// Now returns the current time, defaults to time.Now
+	Now func() time.Time
+}
+
+// NewPool creates a new pool of clusters with the given name
+func NewPool(client Client, name string) *Pool {
+	return &Pool{
+		Client: client,
+		Name:   name,
+	}
+}
+
+// Clusters returns the clusters in the pool
+func (p *Pool) Clusters() ([]*Cluster, error) {
+	clusters, err := p.Client.ListFilter(p.filterLabels())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the clusters of pool %s", p.Name)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
+
+// Leases returns the leases on the clusters of the pool
+func (p *Pool) Leases() ([]*Lease, error) {
+	clusters, err := p.Clusters()
+	if err != nil {
+		return nil, err
+	}
+	var answer []*Lease
+	for _, c := range clusters {
+		if lease := LeaseOf(c); lease != nil {
+			answer = append(answer, lease)
+		}
+	}
+	return answer, nil
+}
+
+// Acquire leases a free cluster of the pool to the owner for the given time to live, reclaiming any expired leases
+// first. If there is no free cluster and the pool is smaller than its maximum size a new cluster is created.
+// Returns nil if no cluster is available
+func (p *Pool) Acquire(owner string, ttl time.Duration) (*Lease, error) {
+	_, err := p.ReclaimExpired()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := p.Clusters()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		if LeaseOf(c) != nil {
+			continue
+		}
+		lease, err := p.lease(c, owner, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if lease != nil {
+			return lease, nil
+		}
+	}
+	if len(clusters) >= p.maxSize() {
+		return nil, nil
+	}
+
+	id, err := NewLabelValue()
+	if err != nil {
+		return nil, err
+	}
+	labels := util.MergeMaps(map[string]string{}, p.filterLabels(), p.leaseLabels(id, owner, ttl))
+	c, err := p.Client.Create(p.newClusterName(), labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a cluster for pool %s", p.Name)
+	}
+	log.Logger().Infof("created cluster %s for pool %s leased to %s", util.ColorInfo(c.Name), util.ColorInfo(p.Name), util.ColorInfo(owner))
+	return LeaseOf(c), nil
+}
+
+// Heartbeat renews the lease so that it expires a time to live after now. The lease is only renewed if the labels of
+// its cluster have not changed since it was verified so that a lease which is reclaimed concurrently is not renewed
+func (p *Pool) Heartbeat(lease *Lease) error {
+	client, err := p.conditionalClient()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < maxLabelUpdateAttempts; i++ {
+		c, err := p.verifyLease(lease)
+		if err != nil {
+			return err
+		}
+		labels := util.MergeMaps(map[string]string{}, c.Labels)
+		labels[LabelLeaseHeartbeat] = strconv.FormatInt(p.now().Unix(), 10)
+		updated, err := client.SetClusterLabelsIfUnchanged(c, labels)
+		if err != nil {
+			return errors.Wrapf(err, "failed to renew the lease on cluster %s", c.Name)
+		}
+		if updated {
+			lease.Cluster = c
+			lease.Heartbeat = time.Unix(p.now().Unix(), 0)
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to renew the lease on cluster %s as its labels keep changing", lease.Cluster.Name)
+}
+
+// Release removes the lease from its cluster so the cluster is free to be leased again
+func (p *Pool) Release(lease *Lease) error {
+	client, err := p.conditionalClient()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < maxLabelUpdateAttempts; i++ {
+		c, err := p.verifyLease(lease)
+		if err != nil {
+			return err
+		}
+		updated, err := client.SetClusterLabelsIfUnchanged(c, withoutLeaseLabels(c.Labels))
+		if err != nil {
+			return errors.Wrapf(err, "failed to release the lease on cluster %s", c.Name)
+		}
+		if updated {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to release the lease on cluster %s as its labels keep changing", lease.Cluster.Name)
+}
+
+// ReclaimExpired removes the expired leases from the clusters of the pool if the labels of their clusters have not
+// changed since they were listed, so that a lease which is renewed or reclaimed concurrently is kept. Returns the
+// reclaimed clusters
+func (p *Pool) ReclaimExpired() ([]*Cluster, error) {
+	client, err := p.conditionalClient()
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := p.Clusters()
+	if err != nil {
+		return nil, err
+	}
+	now := p.now()
+	var answer []*Cluster
+	for _, c := range clusters {
+		lease := LeaseOf(c)
+		if lease == nil || !lease.IsExpired(now) {
+			continue
+		}
+		updated, err := client.SetClusterLabelsIfUnchanged(c, withoutLeaseLabels(c.Labels))
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to reclaim cluster %s", c.Name)
+		}
+		if !updated {
+			log.Logger().Infof("did not reclaim cluster %s as its lease changed since it was listed", util.ColorInfo(c.Name))
+			continue
+		}
+		log.Logger().Infof("reclaimed cluster %s from %s as its lease expired at %s", util.ColorInfo(c.Name), util.ColorInfo(lease.Owner), lease.Expires().Format(time.RFC3339))
+		answer = append(answer, c)
+	}
+	return answer, nil
+}
+
+// Reconcile reclaims expired leases then creates clusters until the pool has its minimum size and deletes free
+// clusters until the pool has at most its maximum size. Returns the names of the created and deleted clusters
+func (p *Pool) Reconcile() ([]string, []string, error) {
+	_, err := p.ReclaimExpired()
+	if err != nil {
+		return nil, nil, err
+	}
+	clusters, err := p.Clusters()
+	if err != nil {
+		return nil, nil, err
+	}
+	var created, deleted []string
+	for i := len(clusters); i < p.MinSize; i++ {
+		c, err := p.Client.Create(p.newClusterName(), util.MergeMaps(map[string]string{}, p.filterLabels()))
+		if err != nil {
+			return created, deleted, errors.Wrapf(err, "failed to create a cluster for pool %s", p.Name)
+		}
+		log.Logger().Infof("created cluster %s for pool %s", util.ColorInfo(c.Name), util.ColorInfo(p.Name))
+		created = append(created, c.Name)
+	}
+	excess := len(clusters) - p.maxSize()
+	for _, c := range clusters {
+		if excess <= 0 {
+			break
+		}
+		if LeaseOf(c) != nil {
+			continue
+		}
+		err = p.Client.Delete(c)
+		if err != nil {
+			return created, deleted, errors.Wrapf(err, "failed to delete cluster %s of pool %s", c.Name, p.Name)
+		}
+		log.Logger().Infof("deleted cluster %s as pool %s is larger than its maximum size %d", util.ColorInfo(c.Name), util.ColorInfo(p.Name), p.maxSize())
+		deleted = append(deleted, c.Name)
+		excess--
+	}
+	return created, deleted, nil
+}
+
+// lease labels the free cluster with a new lease if its labels have not changed since it was listed so that only one
+// of any concurrent leases succeeds. Returns nil if another process leased the cluster first
+func (p *Pool) lease(c *Cluster, owner string, ttl time.Duration) (*Lease, error) {
+	client, err := p.conditionalClient()
+	if err != nil {
+		return nil, err
+	}
+	id, err := NewLabelValue()
+	if err != nil {
+		return nil, err
+	}
+	leaseLabels := p.leaseLabels(id, owner, ttl)
+	updated, err := client.SetClusterLabelsIfUnchanged(c, util.MergeMaps(map[string]string{}, c.Labels, leaseLabels))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to label cluster %s with lease %#v", c.Name, leaseLabels)
+	}
+	if !updated {
+		log.Logger().Infof("could not lease cluster %s as it is no longer free", c.Name)
+		return nil, nil
+	}
+	copy, err := p.Client.Get(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	if copy == nil {
+		return nil, fmt.Errorf("cluster %s no longer exists", c.Name)
+	}
+	return LeaseOf(copy), nil
+}
+
+// conditionalClient returns the client of the pool as it must update the labels of clusters conditionally so that
+// concurrent changes of the leases cannot both succeed
+func (p *Pool) conditionalClient() (ConditionalLabelsClient, error) {
+	client, ok := p.Client.(ConditionalLabelsClient)
+	if !ok {
+		return nil, fmt.Errorf("cannot manage the leases of pool %s as %s cannot update the labels of a cluster conditionally", p.Name, p.Client.String())
+	}
+	return client, nil
+}
+
+// withoutLeaseLabels returns a copy of the labels without the labels of a lease
+func withoutLeaseLabels(labels map[string]string) map[string]string {
+	answer := util.MergeMaps(map[string]string{}, labels)
+	for _, label := range LeaseLabels {
+		delete(answer, label)
+	}
+	return answer
+}
+
+// verifyLease returns the current state of the cluster of the lease if the lease is still held
+func (p *Pool) verifyLease(lease *Lease) (*Cluster, error) {
+	c, err := p.Client.Get(lease.Cluster.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get cluster %s", lease.Cluster.Name)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("cluster %s no longer exists", lease.Cluster.Name)
+	}
+	current := LeaseOf(c)
+	if current == nil || current.ID != lease.ID {
+		return nil, fmt.Errorf("the lease %s on cluster %s is no longer held as the lease expired and was reclaimed", lease.ID, c.Name)
+	}
+	return c, nil
+}
+
+func (p *Pool) leaseLabels(id string, owner string, ttl time.Duration) map[string]string {
+	return map[string]string{
+		LabelLeaseID:        id,
+		LabelLeaseOwner:     naming.ToValidNameTruncated(owner, 63),
+		LabelLeaseHeartbeat: strconv.FormatInt(p.now().Unix(), 10),
+		LabelLeaseTTL:       strconv.FormatInt(int64(ttl/time.Second), 10),
+	}
+}
+
+func (p *Pool) filterLabels() map[string]string {
+	return util.MergeMaps(map[string]string{}, p.Labels, map[string]string{LabelPool: p.Name})
+}
+
+func (p *Pool) newClusterName() string {
+	id, err := NewLabelValue()
+	if err != nil {
+		id = strconv.FormatInt(p.now().UnixNano(), 36)
+	}
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return naming.ToValidNameTruncated(p.Name, 31) + "-" + id
+}
+
+func (p *Pool) maxSize() int {
+	if p.MaxSize < p.MinSize {
+		return p.MinSize
+	}
+	return p.MaxSize
+}
+
+func (p *Pool) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
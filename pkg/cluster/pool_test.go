@@ -0,0 +1,237 @@
+// +build unit
+
+package cluster_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/fake"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(clusters ...*cluster.Cluster) (*cluster.Pool, *fake.Client, *time.Time) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := fake.NewClient(clusters)
+	pool := cluster.NewPool(client, "e2e")
+	pool.MinSize = 1
+	pool.MaxSize = 2
+	pool.Now = func() time.Time {
+		return now
+	}
+	return pool, client, &now
+}
+
+func poolCluster(name string, pool string) *cluster.Cluster {
+	return &cluster.Cluster{
+		Name:   name,
+		Labels: map[string]string{cluster.LabelPool: pool},
+	}
+}
+
+func TestPoolAcquireHeartbeatRelease(t *testing.T) {
+	t.Parallel()
+
+	pool, _, now := newTestPool(poolCluster("other", "another-pool"), poolCluster("e2e-1", "e2e"))
+
+	lease, err := pool.Acquire("PR-123", 10*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, "e2e-1", lease.Cluster.Name)
+	assert.Equal(t, "pr-123", lease.Owner)
+	assert.True(t, now.Add(10*time.Minute).Equal(lease.Expires()), "lease expires at %s", lease.Expires())
+
+	*now = now.Add(8 * time.Minute)
+	require.NoError(t, pool.Heartbeat(lease))
+	assert.True(t, now.Add(10*time.Minute).Equal(lease.Expires()), "lease expires at %s", lease.Expires())
+
+	leases, err := pool.Leases()
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, lease.ID, leases[0].ID)
+
+	require.NoError(t, pool.Release(lease))
+	leases, err = pool.Leases()
+	require.NoError(t, err)
+	assert.Empty(t, leases)
+	assert.Error(t, pool.Heartbeat(lease), "a released lease should not be renewed")
+}
+
+func TestPoolAcquireCreatesClusterUpToMaxSize(t *testing.T) {
+	t.Parallel()
+
+	pool, client, _ := newTestPool(poolCluster("e2e-1", "e2e"))
+
+	first, err := pool.Acquire("build-1", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, "e2e-1", first.Cluster.Name)
+
+	second, err := pool.Acquire("build-2", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.NotEqual(t, "e2e-1", second.Cluster.Name)
+	assert.Equal(t, "e2e", second.Cluster.Labels[cluster.LabelPool])
+	assert.Len(t, client.Clusters, 2)
+
+	third, err := pool.Acquire("build-3", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, third, "the pool is at its maximum size")
+}
+
+func TestPoolReclaimsExpiredLeases(t *testing.T) {
+	t.Parallel()
+
+	pool, _, now := newTestPool(poolCluster("e2e-1", "e2e"))
+	pool.MaxSize = 1
+
+	dead, err := pool.Acquire("dead-build", 5*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, dead)
+
+	lease, err := pool.Acquire("build-2", 5*time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, lease, "the cluster is still leased")
+
+	*now = now.Add(6 * time.Minute)
+	lease, err = pool.Acquire("build-2", 5*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	assert.Equal(t, "e2e-1", lease.Cluster.Name)
+	assert.Equal(t, "build-2", lease.Owner)
+
+	assert.Error(t, pool.Heartbeat(dead), "the reclaimed lease should no longer be held")
+}
+
+func TestPoolReconcile(t *testing.T) {
+	t.Parallel()
+
+	pool, client, _ := newTestPool()
+	pool.MinSize = 2
+	pool.MaxSize = 3
+
+	created, deleted, err := pool.Reconcile()
+	require.NoError(t, err)
+	assert.Len(t, created, 2)
+	assert.Empty(t, deleted)
+
+	client.Clusters = append(client.Clusters, poolCluster("e2e-a", "e2e"), poolCluster("e2e-b", "e2e"))
+	lease, err := pool.Acquire("build-1", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	created, deleted, err = pool.Reconcile()
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	assert.Len(t, deleted, 1)
+	assert.NotContains(t, deleted, lease.Cluster.Name, "leased clusters should not be deleted")
+
+	clusters, err := pool.Clusters()
+	require.NoError(t, err)
+	assert.Len(t, clusters, 3)
+}
+
+// racingClient leases the cluster to another process after it has been listed but before it is labelled
+type racingClient struct {
+	*fake.Client
+	raced bool
+}
+
+func (c *racingClient) SetClusterLabelsIfUnchanged(cl *cluster.Cluster, labels map[string]string) (bool, error) {
+	if !c.raced {
+		c.raced = true
+		other, err := c.Client.Get(cl.Name)
+		if err != nil {
+			return false, err
+		}
+		otherLabels := map[string]string{cluster.LabelLeaseID: "other", cluster.LabelLeaseOwner: "other-build"}
+		_, err = c.Client.SetClusterLabelsIfUnchanged(other, util.MergeMaps(otherLabels, other.Labels))
+		if err != nil {
+			return false, err
+		}
+	}
+	return c.Client.SetClusterLabelsIfUnchanged(cl, labels)
+}
+
+func TestPoolAcquireConcurrentLease(t *testing.T) {
+	t.Parallel()
+
+	pool, client, _ := newTestPool(poolCluster("e2e-1", "e2e"))
+	pool.MaxSize = 1
+	pool.Client = &racingClient{Client: client}
+
+	lease, err := pool.Acquire("build-1", time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, lease, "the cluster was leased by another process first")
+
+	leases, err := pool.Leases()
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "other", leases[0].ID)
+}
+
+// heartbeatRacingClient renews the lease on the cluster after it has been listed but before its labels are updated
+type heartbeatRacingClient struct {
+	*fake.Client
+	heartbeat string
+	raced     bool
+}
+
+func (c *heartbeatRacingClient) SetClusterLabelsIfUnchanged(cl *cluster.Cluster, labels map[string]string) (bool, error) {
+	if !c.raced {
+		c.raced = true
+		other, err := c.Client.Get(cl.Name)
+		if err != nil {
+			return false, err
+		}
+		otherLabels := util.MergeMaps(map[string]string{}, other.Labels, map[string]string{cluster.LabelLeaseHeartbeat: c.heartbeat})
+		_, err = c.Client.SetClusterLabelsIfUnchanged(other, otherLabels)
+		if err != nil {
+			return false, err
+		}
+	}
+	return c.Client.SetClusterLabelsIfUnchanged(cl, labels)
+}
+
+func TestPoolReclaimExpiredKeepsRenewedLease(t *testing.T) {
+	t.Parallel()
+
+	pool, client, now := newTestPool(poolCluster("e2e-1", "e2e"))
+	lease, err := pool.Acquire("build-1", 5*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	*now = now.Add(6 * time.Minute)
+	pool.Client = &heartbeatRacingClient{Client: client, heartbeat: strconv.FormatInt(now.Unix(), 10)}
+	reclaimed, err := pool.ReclaimExpired()
+	require.NoError(t, err)
+	assert.Empty(t, reclaimed, "the lease was renewed after the clusters were listed")
+
+	leases, err := pool.Leases()
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, lease.ID, leases[0].ID)
+}
+
+func TestPoolHeartbeatRetriesWhenLabelsChange(t *testing.T) {
+	t.Parallel()
+
+	pool, client, now := newTestPool(poolCluster("e2e-1", "e2e"))
+	lease, err := pool.Acquire("build-1", 10*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+
+	*now = now.Add(5 * time.Minute)
+	pool.Client = &heartbeatRacingClient{Client: client, heartbeat: "0"}
+	require.NoError(t, pool.Heartbeat(lease))
+	assert.True(t, now.Add(10*time.Minute).Equal(lease.Expires()), "lease expires at %s", lease.Expires())
+
+	leases, err := pool.Leases()
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.True(t, now.Equal(leases[0].Heartbeat), "the heartbeat should not be overwritten by the concurrent change")
+}
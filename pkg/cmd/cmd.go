@@ -42,6 +42,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/controller"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/create"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/deletecmd"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/devpod"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/edit"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/gc"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/get"
@@ -69,6 +70,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/util"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/clients"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/version"
@@ -76,6 +78,10 @@ import (
 	"gopkg.in/AlecAivazis/survey.v1/terminal"
 )
 
+// unauditedCommands the read only commands and the pipeline steps, which are recorded by the activities of their
+// pipelines, that are excluded from the audit log
+var unauditedCommands = []string{"completion", "diagnose", "docs", "get", "options", "prompt", "status", "step", "version"}
+
 // NewJXCommand creates the `jx` command and its nested children.
 // args used to determine binary plugin to run can be overridden (does not affect compiled in commands).
 func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWriter,
@@ -83,16 +89,22 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 
 	configureViper()
 	rootCommand := &cobra.Command{
-		Use:              "jx",
-		Short:            "jx is a command line tool for working with Jenkins X",
-		PersistentPreRun: setLoggingLevel,
-		Run:              runHelp,
+		Use:   "jx",
+		Short: "jx is a command line tool for working with Jenkins X",
+		Run:   runHelp,
 	}
 
 	features.Init()
 
 	commonOpts := opts.NewCommonOptionsWithTerm(f, in, out, err)
 	commonOpts.AddBaseFlags(rootCommand)
+	rootCommand.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		setLoggingLevel(cmd, args)
+		auditFailures(commonOpts, cmd, args)
+	}
+	rootCommand.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		commonOpts.Audit(cmd, args, nil)
+	}
 
 	addCommands := add.NewCmdAdd(commonOpts)
 	createCommands := create.NewCmdCreate(commonOpts)
@@ -170,6 +182,7 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 		{
 			Message: "Working with Applications:",
 			Commands: []*cobra.Command{
+				devpod.NewCmdDevPod(commonOpts),
 				NewCmdLogs(commonOpts),
 				NewCmdOpen(commonOpts),
 				rsh.NewCmdRsh(commonOpts),
@@ -237,6 +250,12 @@ func NewJXCommand(f clients.Factory, in terminal.FileReader, out terminal.FileWr
 	rootCommand.AddCommand(NewCmdOptions(out))
 	rootCommand.AddCommand(NewCmdDiagnose(commonOpts))
 
+	for _, c := range rootCommand.Commands() {
+		if util.StringArrayIndex(unauditedCommands, c.Name()) >= 0 {
+			opts.DisableAudit(c)
+		}
+	}
+
 	// Mark the deprecated commands
 	deprecation.DeprecateCommands(rootCommand)
 
@@ -311,6 +330,13 @@ func fullPath(command *cobra.Command) string {
 	return name
 }
 
+// auditFailures records the command in the audit log when it fails, before the behavior on a fatal error
+func auditFailures(commonOpts *opts.CommonOptions, cmd *cobra.Command, args []string) {
+	helper.BeforeFatal(func(err error) {
+		commonOpts.Audit(cmd, args, err)
+	})
+}
+
 func setLoggingLevel(cmd *cobra.Command, args []string) {
 	verbose, err := strconv.ParseBool(cmd.Flag(opts.OptionVerbose).Value.String())
 	if err != nil {
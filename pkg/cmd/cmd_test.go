@@ -86,6 +86,30 @@ func TestLoggingSetup(t *testing.T) {
 	}
 }
 
+func TestCommandFlagsDoNotCollide(t *testing.T) {
+	rootCmd := NewJXCommand(fake.NewFakeFactory(), os.Stdin, os.Stdout, os.Stderr, nil)
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		// merging the persistent flags of the parents panics if a shorthand is defined twice
+		assert.NotPanics(t, func() {
+			cmd.InheritedFlags()
+		}, "the flags of '%s' should not redefine the flags of its parents", cmd.CommandPath())
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(rootCmd)
+
+	rootCmd.SetArgs([]string{"get", "usage", "--help"})
+	_ = log.CaptureOutput(func() {
+		assert.NotPanics(t, func() {
+			err := rootCmd.Execute()
+			assert.NoError(t, err)
+		})
+	})
+}
+
 func TestFindPluginBinary(t *testing.T) {
 	pluginsDir := filepath.Join("test_data", "binary_plugins_dir")
 
@@ -36,6 +36,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/builds"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/spf13/cobra"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -62,6 +63,8 @@ type ControllerBuildOptions struct {
 	FailIfNoGitProvider bool
 	JobURLBase          string
 
+	MaxInfrastructureRetries int
+
 	EnvironmentCache *kube.EnvironmentNamespaceCache
 
 	DryRun bool
@@ -97,6 +100,7 @@ func NewCmdControllerBuild(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to watch or defaults to the current namespace")
 	cmd.Flags().BoolVarP(&options.InitGitCredentials, "git-credentials", "", false, "If enable then lets run the 'jx step git credentials' step to initialise git credentials")
 	cmd.Flags().BoolVarP(&options.FailIfNoGitProvider, "fail-on-git-provider-error", "", false, "If enable then lets terminate quickly if we cannot create a git provider")
+	cmd.Flags().IntVarP(&options.MaxInfrastructureRetries, "max-infrastructure-retries", "", 2, "The maximum number of times a pipeline which failed due to the infrastructure, such as an evicted or OOMKilled pod, is retried automatically. Use 0 to disable retries")
 
 	// optional git reporting flags
 	cmd.Flags().StringVarP(&options.TargetURLTemplate, "target-url-template", "", "", "The Go template for generating the target URL of pipeline logs/views if git reporting is enabled. If unspecified, a default will be used based on `--job-url-base`.")
@@ -287,6 +291,7 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 				key := o.createPromoteStepActivityKeyFromRun(pri)
 				if key != nil {
 					name := ""
+					var activity *v1.PipelineActivity
 					err := util.Retry(time.Second*20, func() error {
 						a, created, err := key.GetOrCreate(jxClient, ns)
 						if err != nil {
@@ -306,10 +311,13 @@ func (o *ControllerBuildOptions) onPipelinePod(obj interface{}, kubeClient kuber
 								return err
 							}
 						}
+						activity = a
 						return nil
 					})
 					if err != nil {
 						log.Logger().Warnf("Failed to update PipelineActivities %s: %s", name, err)
+					} else {
+						o.retryInfrastructureFailure(kubeClient, tektonClient, jxClient, ns, activity, pr)
 					}
 				}
 			} else {
@@ -607,6 +615,68 @@ func (o *ControllerBuildOptions) updatePipelineActivity(kubeClient kubernetes.In
 	return originYaml != newYaml
 }
 
+// retryInfrastructureFailure retries the PipelineRun of the PipelineActivity if the pipeline failed due to the
+// infrastructure and it has not been retried the maximum number of times yet. The retry is claimed by annotating the
+// PipelineActivity with a conditional update so that only one of the events of the failed pods retries it
+func (o *ControllerBuildOptions) retryInfrastructureFailure(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, pr *pipelineapi.PipelineRun) {
+	if o.DryRun || o.MaxInfrastructureRetries <= 0 || !shouldRetryInfrastructureFailure(activity) {
+		return
+	}
+	attempt := tekton.RetryAttempt(pr)
+	if attempt >= o.MaxInfrastructureRetries {
+		log.Logger().Debugf("not retrying PipelineRun %s as it has been retried %d times", pr.Name, attempt)
+		return
+	}
+	retryName := tekton.RetryPipelineRunName(pr)
+	claimed, err := claimRetry(jxClient, ns, activity.Name, retryName)
+	if err != nil {
+		log.Logger().Warnf("Failed to claim the retry of PipelineRun %s: %s", pr.Name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	retry, err := tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, ns, pr, "")
+	if err != nil {
+		log.Logger().Warnf("Failed to retry PipelineRun %s after an infrastructure failure: %s", pr.Name, err)
+		return
+	}
+	log.Logger().Infof("retrying PipelineRun %s as %s after an infrastructure failure (attempt %d of %d)", pr.Name, retry.Name, attempt+1, o.MaxInfrastructureRetries)
+}
+
+// shouldRetryInfrastructureFailure returns true if the PipelineActivity failed due to the infrastructure and has not
+// been retried yet
+func shouldRetryInfrastructureFailure(activity *v1.PipelineActivity) bool {
+	return activity != nil && activity.Spec.Status == v1.ActivityStatusTypeFailed &&
+		activity.Annotations[tekton.AnnotationFailureClass] == string(tekton.FailureClassInfrastructure) &&
+		activity.Annotations[tekton.AnnotationRetriedBy] == ""
+}
+
+// claimRetry annotates the PipelineActivity with the name of the PipelineRun which retries it. The update fails
+// with a conflict if the PipelineActivity was modified since it was read, in which case it is read again to check
+// whether it has been claimed in the meantime. Returns false if it has already been retried
+func claimRetry(jxClient versioned.Interface, ns string, name string, retryName string) (bool, error) {
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	for i := 0; i < 5; i++ {
+		activity, err := activities.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if !shouldRetryInfrastructureFailure(activity) {
+			return false, nil
+		}
+		activity.Annotations[tekton.AnnotationRetriedBy] = retryName
+		_, err = activities.Update(activity)
+		if err == nil {
+			return true, nil
+		}
+		if !k8sErrors.IsConflict(err) {
+			return false, err
+		}
+	}
+	return false, fmt.Errorf("too many conflicts updating PipelineActivity %s", name)
+}
+
 func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kubernetes.Interface, ns string, activity *v1.PipelineActivity, pri *tekton.PipelineRunInfo, pod *corev1.Pod) bool {
 	originYaml := toYamlString(activity)
 	for _, stage := range pri.Stages {
@@ -661,6 +731,12 @@ func (o *ControllerBuildOptions) updatePipelineActivityForRun(kubeClient kuberne
 	if allStagesCompleted || (!running && failed) {
 		if failed {
 			spec.Status = v1.ActivityStatusTypeFailed
+			if class := tekton.ClassifyActivityFailure(activity); class != tekton.FailureClassNone {
+				if activity.Annotations == nil {
+					activity.Annotations = map[string]string{}
+				}
+				activity.Annotations[tekton.AnnotationFailureClass] = string(class)
+			}
 			// Mark any Pending stages as not executed
 			for i := range spec.Steps {
 				step := &spec.Steps[i]
@@ -791,6 +867,10 @@ func updateForStage(si *tekton.StageInfo, a *v1.PipelineActivity) {
 					}
 				} else {
 					step.Status = v1.ActivityStatusTypeFailed
+					if class, reason := tekton.ClassifyStepFailure(pod, container); class != tekton.FailureClassNone {
+						log.Logger().Debugf("step %s of stage %s failed due to the %s: %s", step.Name, stage.Name, class, reason)
+						tekton.SetStepFailureClass(a, stage.Name, step.Name, class)
+					}
 				}
 			} else {
 				if running != nil && isStepRunning(i, stageSteps) {
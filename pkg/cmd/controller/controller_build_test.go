@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
@@ -18,11 +19,13 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/tekton"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/tekton_helpers_test"
 	"github.com/stretchr/testify/assert"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 )
 
 func TestDigitSuffix(t *testing.T) {
@@ -354,3 +357,41 @@ func parseTime(t *testing.T, timeString string) *time.Time {
 	}
 	return nil
 }
+
+func TestRetryInfrastructureFailureOnlyOnce(t *testing.T) {
+	ns := "jx"
+	name := "myorg-myapp-master-1"
+	pr := &pipelineapi.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: pipelineapi.PipelineRunSpec{
+			PipelineRef: &pipelineapi.PipelineRef{Name: name},
+		},
+	}
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Annotations: map[string]string{
+				tekton.AnnotationFailureClass: string(tekton.FailureClassInfrastructure),
+			},
+		},
+		Spec: v1.PipelineActivitySpec{Status: v1.ActivityStatusTypeFailed},
+	}
+	tektonClient := tektonfake.NewSimpleClientset(pr)
+	jxClient := jxfake.NewSimpleClientset(activity)
+	kubeClient := kubefake.NewSimpleClientset()
+	o := &ControllerBuildOptions{MaxInfrastructureRetries: 2}
+
+	// lets simulate the events of several failed pods of the same PipelineRun
+	for i := 0; i < 3; i++ {
+		o.retryInfrastructureFailure(kubeClient, tektonClient, jxClient, ns, activity, pr)
+	}
+
+	runs, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, runs.Items, 2, "the PipelineRun should be retried once")
+
+	updated, err := jxClient.JenkinsV1().PipelineActivities(ns).Get(name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, name+"-retry-1", updated.Annotations[tekton.AnnotationRetriedBy])
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// ControllerDevPodOptions the options for the DevPod controller
+type ControllerDevPodOptions struct {
+	ControllerOptions
+
+	Namespace    string
+	IdleTimeout  time.Duration
+	CPUThreshold string
+	Interval     time.Duration
+}
+
+var (
+	controllerDevPodLong = templates.LongDesc(`
+		Runs the DevPod controller which automatically suspends idle DevPods.
+
+		A DevPod is active while the CPU usage of its pod from the metrics.k8s.io API is at least the --cpu-threshold.
+		DevPods which have not been active for the idle timeout are suspended via the same mechanism as 'jx devpod suspend'
+		so their workspace is kept. The idle timeout of a DevPod can be overridden by the idleTimeout of its DevPod template.
+
+		DevPods which do not have a persistent workspace are never suspended.
+`)
+
+	controllerDevPodExample = templates.Examples(`
+		# suspend DevPods which have been idle for 2 hours
+		jx controller devpod
+
+		# suspend DevPods which have been idle for 30 minutes
+		jx controller devpod --idle-timeout 30m
+	`)
+)
+
+// NewCmdControllerDevPod creates the command
+func NewCmdControllerDevPod(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerDevPodOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "devpod",
+		Short:   "Runs the DevPod controller which suspends idle DevPods",
+		Long:    controllerDevPodLong,
+		Example: controllerDevPodExample,
+		Aliases: []string{"devpods"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the DevPods. Defaults to the dev namespace")
+	cmd.Flags().DurationVarP(&options.IdleTimeout, "idle-timeout", "", 2*time.Hour, "The duration a DevPod can be idle before it is suspended. Zero disables suspending DevPods without an idleTimeout in their DevPod template")
+	cmd.Flags().StringVarP(&options.CPUThreshold, "cpu-threshold", "", "50m", "The CPU usage above which a DevPod is active")
+	cmd.Flags().DurationVarP(&options.Interval, "interval", "", time.Minute, "The interval between checks of the DevPods")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerDevPodOptions) Run() error {
+	// Always run in batch mode as a controller is never run interactively
+	o.BatchMode = true
+
+	kubeClient, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	metricsClient, err := o.GetFactory().CreateMetricsClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the metrics client")
+	}
+	ns := o.Namespace
+	if ns == "" {
+		ns = devNs
+	}
+	threshold, err := resource.ParseQuantity(o.CPUThreshold)
+	if err != nil {
+		return util.InvalidOptionError("cpu-threshold", o.CPUThreshold, err)
+	}
+
+	log.Logger().Infof("Watching for idle DevPods in namespace %s", util.ColorInfo(ns))
+	for {
+		_, err = o.SuspendIdleDevPods(kubeClient, metricsClient, ns, threshold, time.Now())
+		if err != nil {
+			log.Logger().Warnf("failed to check the DevPods in namespace %s: %s", ns, err)
+		}
+		time.Sleep(o.Interval)
+	}
+}
+
+// SuspendIdleDevPods records which DevPods are active and suspends the DevPods which have been idle for longer than
+// their idle timeout. Returns the names of the suspended DevPods
+func (o *ControllerDevPodOptions) SuspendIdleDevPods(kubeClient kubernetes.Interface, metricsClient metricsclient.Interface, ns string, threshold resource.Quantity, now time.Time) ([]string, error) {
+	names, pods, err := kube.GetDevPodNames(kubeClient, ns, "")
+	if err != nil {
+		return nil, err
+	}
+	var suspended []string
+	for _, name := range names {
+		pod := pods[name]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		cpu, _, err := kube.PodMetricsUsage(metricsClient, ns, kube.LabelDevPodName+"="+name)
+		if err != nil {
+			log.Logger().Warnf("could not determine if DevPod %s is idle: %s", name, err)
+			continue
+		}
+		if int64(cpu*1000) >= threshold.MilliValue() {
+			_, err = kube.MarkDevPodActive(kubeClient, ns, pod, now)
+			if err != nil {
+				log.Logger().Warnf("%s", err)
+			}
+			continue
+		}
+		if !kube.IsDevPodIdle(pod, now, o.IdleTimeout) {
+			continue
+		}
+		if len(kube.DevPodWorkspaceClaims(pod)) == 0 {
+			log.Logger().Debugf("not suspending idle DevPod %s as it has no persistent workspace", name)
+			continue
+		}
+		_, err = kube.SuspendDevPod(kubeClient, ns, name)
+		if err != nil {
+			return suspended, errors.Wrapf(err, "failed to suspend idle DevPod %s", name)
+		}
+		log.Logger().Infof("Suspended DevPod %s as it has been idle since %s", util.ColorInfo(name), kube.DevPodLastActive(pod).Format(time.RFC3339))
+		suspended = append(suspended, name)
+	}
+	return suspended, nil
+}
@@ -0,0 +1,99 @@
+// +build unit
+
+package controller_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/controller"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestSuspendIdleDevPods(t *testing.T) {
+	ns := "jx"
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	devPod := func(name string, persist bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{kube.LabelDevPodName: name, kube.LabelDevPodUsername: "jstrachan"},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "devpod", Image: "maven"}},
+			},
+			Status: corev1.PodStatus{
+				Phase:     corev1.PodRunning,
+				StartTime: &metav1.Time{Time: now.Add(-3 * time.Hour)},
+			},
+		}
+		if persist {
+			pod.Spec.Volumes = []corev1.Volume{
+				{
+					Name: "ws-volume",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name + "-pvc"},
+					},
+				},
+			}
+		}
+		return pod
+	}
+	kubeClient := fake.NewSimpleClientset(
+		devPod("busy", true),
+		devPod("idle", true),
+		devPod("idle-ephemeral", false),
+	)
+
+	usage := map[string]string{
+		"busy":           "500m",
+		"idle":           "5m",
+		"idle-ephemeral": "5m",
+	}
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		selector := action.(k8stesting.ListAction).GetListRestrictions().Labels
+		list := &metricsv1beta1.PodMetricsList{}
+		for name, cpu := range usage {
+			podLabels := labels.Set{kube.LabelDevPodName: name}
+			if !selector.Matches(podLabels) {
+				continue
+			}
+			list.Items = append(list.Items, metricsv1beta1.PodMetrics{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: podLabels},
+				Containers: []metricsv1beta1.ContainerMetrics{
+					{Name: "devpod", Usage: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse("100Mi")}},
+				},
+			})
+		}
+		return true, list, nil
+	})
+
+	o := &controller.ControllerDevPodOptions{IdleTimeout: 2 * time.Hour}
+	suspended, err := o.SuspendIdleDevPods(kubeClient, metricsClient, ns, resource.MustParse("50m"), now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"idle"}, suspended)
+
+	busy, err := kubeClient.CoreV1().Pods(ns).Get("busy", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, now.Format(time.RFC3339), busy.Annotations[kube.AnnotationDevPodLastActive])
+
+	_, err = kubeClient.CoreV1().Pods(ns).Get("idle-ephemeral", metav1.GetOptions{})
+	assert.NoError(t, err, "a DevPod without a persistent workspace should not be suspended")
+
+	names, _, err := kube.GetSuspendedDevPods(kubeClient, ns, "jstrachan")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"idle"}, names)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/git/credentials"
@@ -21,6 +22,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -111,7 +113,7 @@ func (o *ControllerTeamOptions) Run() error {
 
 	stop := make(chan struct{})
 
-	_, teamController := cache.NewInformer(
+	teams, teamController := cache.NewInformer(
 		&cache.ListWatch{
 			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
 				return jxClient.JenkinsV1().Teams(adminNs).List(lo)
@@ -137,6 +139,62 @@ func (o *ControllerTeamOptions) Run() error {
 
 	go teamController.Run(stop)
 
+	// lets reconcile the policies of the teams as soon as they change
+	_, policyController := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().ConfigMaps(adminNs).List(lo)
+			},
+			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().ConfigMaps(adminNs).Watch(lo)
+			},
+		},
+		&corev1.ConfigMap{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onTeamPolicyChange(obj, teams, client, jxClient, adminNs)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onTeamPolicyChange(newObj, teams, client, jxClient, adminNs)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				o.onTeamPolicyChange(obj, teams, client, jxClient, adminNs)
+			},
+		},
+	)
+
+	go policyController.Run(stop)
+
+	// lets enforce the policies of the teams on their builds as they start
+	_, buildPodController := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+				lo.LabelSelector = v1.LabelBuild
+				return client.CoreV1().Pods(metav1.NamespaceAll).List(lo)
+			},
+			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+				lo.LabelSelector = v1.LabelBuild
+				return client.CoreV1().Pods(metav1.NamespaceAll).Watch(lo)
+			},
+		},
+		&corev1.Pod{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				o.onBuildPodChange(obj, teams, client, adminNs)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				o.onBuildPodChange(newObj, teams, client, adminNs)
+			},
+		},
+	)
+
+	go buildPodController.Run(stop)
+
 	// Wait forever
 	select {}
 }
@@ -317,6 +375,8 @@ func (o *ControllerTeamOptions) onTeamChange(obj interface{}, kubeClient kuberne
 			log.Logger().Errorf("Unable set admin namespace on team %s to %s - %s", util.ColorInfo(teamNs), util.ColorInfo(adminNs), err)
 			return
 		}
+		o.reconcileTeamPolicy(kubeClient, jxClient, adminNs, teamNs)
+
 		err = oc.ModifyTeam(adminNs, team.Name, func(team *v1.Team) error {
 			team.Status.ProvisionStatus = v1.TeamProvisionStatusComplete
 			team.Status.Message = "Installation complete"
@@ -332,8 +392,110 @@ func (o *ControllerTeamOptions) onTeamChange(obj interface{}, kubeClient kuberne
 			log.Logger().Errorf("Unable set admin namespace on team %s to %s - %s", util.ColorInfo(teamNs), util.ColorInfo(adminNs), err)
 			return
 		}
+		o.reconcileTeamPolicy(kubeClient, jxClient, adminNs, teamNs)
+	}
+
+}
+
+// onTeamPolicyChange reconciles the policies of the provisioned teams which use the policy in the ConfigMap
+func (o *ControllerTeamOptions) onTeamPolicyChange(obj interface{}, teams cache.Store, kubeClient kubernetes.Interface, jxClient versioned.Interface, adminNs string) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return
+	}
+	var teamNames []string
+	switch {
+	case cm.Name == kube.DefaultTeamPolicyConfigMap:
+		for _, key := range teams.ListKeys() {
+			_, name, err := cache.SplitMetaNamespaceKey(key)
+			if err == nil {
+				teamNames = append(teamNames, name)
+			}
+		}
+	case strings.HasPrefix(cm.Name, kube.TeamPolicyConfigMapPrefix):
+		teamNames = []string{strings.TrimPrefix(cm.Name, kube.TeamPolicyConfigMapPrefix)}
+	default:
+		return
+	}
+	for _, name := range teamNames {
+		if isTeamProvisioned(teams, adminNs, name) {
+			o.reconcileTeamPolicy(kubeClient, jxClient, adminNs, name)
+		}
 	}
+}
 
+// onBuildPodChange enforces the policy of the team on the build pod
+func (o *ControllerTeamOptions) onBuildPodChange(obj interface{}, teams cache.Store, kubeClient kubernetes.Interface, adminNs string) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	teamNs := pod.Namespace
+	if !isTeamProvisioned(teams, adminNs, teamNs) {
+		return
+	}
+	policy, err := kube.LoadTeamPolicy(kubeClient, adminNs, teamNs)
+	if err != nil {
+		log.Logger().Errorf("Failed to load the policy of team %s: %s", util.ColorInfo(teamNs), err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+	violations, err := kube.EnforceTeamPolicyPod(kubeClient, pod, policy)
+	logDeletedBuildPods(teamNs, violations)
+	if err != nil {
+		log.Logger().Errorf("Failed to enforce the policy of team %s: %s", util.ColorInfo(teamNs), err)
+	}
+}
+
+// reconcileTeamPolicy applies the policy of the team to its namespace, enforces it on the running builds of the team
+// and reports any other violations of it
+func (o *ControllerTeamOptions) reconcileTeamPolicy(kubeClient kubernetes.Interface, jxClient versioned.Interface, adminNs string, teamNs string) {
+	policy, err := kube.LoadTeamPolicy(kubeClient, adminNs, teamNs)
+	if err != nil {
+		log.Logger().Errorf("Failed to load the policy of team %s: %s", util.ColorInfo(teamNs), err)
+		return
+	}
+	if policy == nil {
+		// lets remove any quotas of a policy which has been deleted
+		policy = &kube.TeamPolicy{}
+	}
+	err = kube.ApplyTeamPolicy(kubeClient, teamNs, policy)
+	if err != nil {
+		log.Logger().Errorf("Failed to apply the policy of team %s: %s", util.ColorInfo(teamNs), err)
+		return
+	}
+	violations, err := kube.EnforceTeamPolicyPods(kubeClient, teamNs, policy)
+	logDeletedBuildPods(teamNs, violations)
+	if err != nil {
+		log.Logger().Errorf("Failed to enforce the policy of team %s: %s", util.ColorInfo(teamNs), err)
+		return
+	}
+	violations, err = kube.CheckTeamPolicy(kubeClient, jxClient, teamNs, policy)
+	if err != nil {
+		log.Logger().Errorf("Failed to check the policy of team %s: %s", util.ColorInfo(teamNs), err)
+		return
+	}
+	for _, v := range violations {
+		log.Logger().Warnf("Team %s violates its policy: %s", util.ColorInfo(teamNs), v)
+	}
+}
+
+func logDeletedBuildPods(teamNs string, violations []kube.TeamPolicyViolation) {
+	for _, v := range violations {
+		log.Logger().Warnf("Deleted a build pod of team %s as it violates the team policy: %s", util.ColorInfo(teamNs), v)
+	}
+}
+
+// isTeamProvisioned returns true if the team in the store of the team informer has been provisioned
+func isTeamProvisioned(teams cache.Store, adminNs string, name string) bool {
+	obj, exists, err := teams.GetByKey(adminNs + "/" + name)
+	if err != nil || !exists {
+		return false
+	}
+	team, ok := obj.(*v1.Team)
+	return ok && team.Status.ProvisionStatus == v1.TeamProvisionStatusComplete
 }
 
 // LoadProwOAuthConfig returns the OAuth Token for Prow
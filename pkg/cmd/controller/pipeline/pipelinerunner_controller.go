@@ -295,6 +295,8 @@ func (c *controller) buildStepCreateTaskOption(prowJobSpec prowapi.ProwJobSpec,
 	createTaskOption.Revision = revision
 	createTaskOption.ServiceAccount = c.serviceAccount
 	createTaskOption.SemanticRelease = c.semanticRelease
+	// lets not block the webhook while the pipeline is queued
+	createTaskOption.QueueInBackground = true
 	// turn map into string array with = separator to match type of custom labels which are CLI flags
 	for key, value := range pipelineRun.Labels {
 		createTaskOption.CustomLabels = append(createTaskOption.CustomLabels, fmt.Sprintf("%s=%s", key, value))
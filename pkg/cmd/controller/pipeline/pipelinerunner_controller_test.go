@@ -19,6 +19,7 @@ import (
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -238,6 +239,15 @@ var _ = Describe("Pipeline Runner", func() {
 			Expect(url).Should(BeEmpty())
 		})
 	})
+
+	Describe("when creating the pipeline without the meta pipeline", func() {
+		It("queues the pipeline in the background so that the webhook does not block", func() {
+			testController := controller{ns: "jx"}
+			prowJobSpec := prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Context: "pr-build"}
+			option := testController.buildStepCreateTaskOption(prowJobSpec, "1", "https://github.com/jenkins-x/jx.git", "abc", "PR-1", PipelineRunRequest{}, nil)
+			Expect(option.QueueInBackground).Should(BeTrue())
+		})
+	})
 })
 
 // getFreePort asks the kernel for a free open port that is ready to use.
@@ -68,6 +68,9 @@ var (
 
 		# creates a new Maven DevPod 
 		jx create devpod -l maven
+
+		# creates a new DevPod from the DevPod template of the team called backend
+		jx create devpod --template backend
 	`)
 )
 
@@ -84,6 +87,7 @@ type CreateDevPodOptions struct {
 	opts.CommonDevPodOptions
 
 	Label           string
+	Template        string
 	Suffix          string
 	WorkingDir      string
 	RequestCpu      string
@@ -203,6 +207,7 @@ func NewCmdCreateDevPod(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&options.Label, opts.OptionLabel, "l", "", "The label of the pod template to use")
+	cmd.Flags().StringVarP(&options.Template, "template", "", "", "The name of the DevPod template of the team to use")
 	cmd.Flags().StringVarP(&options.Suffix, "suffix", "s", "", "The suffix to append the pod name")
 	cmd.Flags().StringVarP(&options.WorkingDir, "working-dir", "w", "", "The working directory of the DevPod")
 	cmd.Flags().StringVarP(&options.RequestCpu, optionRequestCPU, "c", "1", "The request CPU of the DevPod")
@@ -307,6 +312,24 @@ func (o *CreateDevPodOptions) Run() error {
 	podResources := client.CoreV1().Pods(ns)
 	var exposeServicePorts []int
 	var gitLabels devPodLabels
+	var devPodTemplate *kube.DevPodTemplate
+
+	if o.Template != "" {
+		devPodTemplates, err := kube.LoadDevPodTemplates(client, ns)
+		if err != nil {
+			return errors.Wrapf(err, "loading the DevPod templates from namespace %q", ns)
+		}
+		devPodTemplate = devPodTemplates[o.Template]
+		if devPodTemplate == nil {
+			return util.InvalidOption("template", o.Template, util.SortedMapKeys(devPodTemplatesByName(devPodTemplates)))
+		}
+		if label == "" {
+			label = devPodTemplate.PodTemplate
+		}
+		if devPodTemplate.Persist && !o.Sync {
+			o.Persist = true
+		}
+	}
 
 	if importURL != "" && o.Reuse {
 		gitInfo, err := gits.ParseGitURL(importURL)
@@ -604,7 +627,7 @@ func (o *CreateDevPodOptions) Run() error {
 			}
 		}
 
-		if o.RequestCpu != "" {
+		if o.RequestCpu != "" && o.useRequestFlag(optionRequestCPU, corev1.ResourceCPU, devPodTemplate) {
 			q, err := resource.ParseQuantity(o.RequestCpu)
 			if err != nil {
 				return util.InvalidOptionError(optionRequestCPU, o.RequestCpu, err)
@@ -612,7 +635,7 @@ func (o *CreateDevPodOptions) Run() error {
 			container1.Resources.Requests[corev1.ResourceCPU] = q
 		}
 
-		if o.RequestMemory != "" {
+		if o.RequestMemory != "" && o.useRequestFlag(optionRequestMemory, corev1.ResourceMemory, devPodTemplate) {
 			q, err := resource.ParseQuantity(o.RequestMemory)
 			if err != nil {
 				return util.InvalidOptionError(optionRequestMemory, o.RequestMemory, err)
@@ -697,6 +720,13 @@ func (o *CreateDevPodOptions) Run() error {
 			}
 		}
 
+		if devPodTemplate != nil {
+			err = devPodTemplate.ApplyTo(pod)
+			if err != nil {
+				return errors.Wrapf(err, "applying the DevPod template %s", devPodTemplate.Name)
+			}
+		}
+
 		if o.Reuse {
 			matchLabels := map[string]string{
 				kube.LabelPodTemplate:    label,
@@ -933,12 +963,11 @@ func (o *CreateDevPodOptions) Run() error {
 			CommonOptions: o.CommonOptions,
 			Namespace:     ns,
 			Pod:           pod.Name,
-			Daemon:        true,
 			Dir:           dir,
 		}
-		err = syncOptions.CreateKsync(client, ns, pod.Name, dir, workingDir, userName)
+		err = syncOptions.StartSync(client, ns, pod.Name, dir, workingDir)
 		if err != nil {
-			return errors.Wrap(err, "creating ksync")
+			return errors.Wrap(err, "starting the sync")
 		}
 	}
 
@@ -1021,6 +1050,10 @@ func (o *CreateDevPodOptions) Run() error {
 		// First configure git credentials
 		rshExec = append(rshExec, setupWorkspaceCommand, "jx step git credentials", "git config --global credential.helper store")
 
+		if create && devPodTemplate != nil && devPodTemplate.DotfilesRepo != "" {
+			rshExec = append(rshExec, fmt.Sprintf("if ! [ -d ~/.dotfiles ]; then git clone %s ~/.dotfiles && if [ -x ~/.dotfiles/install.sh ]; then ~/.dotfiles/install.sh; fi; fi", devPodTemplate.DotfilesRepo))
+		}
+
 		// We only honor --import if --sync is not specified
 		if o.Import {
 			if importURL != "" {
@@ -1054,6 +1087,26 @@ func (o *CreateDevPodOptions) Run() error {
 	return options.Run()
 }
 
+// useRequestFlag returns true if the request flag should be used for the resource rather than the request of the
+// DevPod template. Flags only override the request of the template if they are specified explicitly
+func (o *CreateDevPodOptions) useRequestFlag(flag string, name corev1.ResourceName, devPodTemplate *kube.DevPodTemplate) bool {
+	if devPodTemplate == nil || devPodTemplate.Resources == nil {
+		return true
+	}
+	if _, ok := devPodTemplate.Resources.Requests[name]; !ok {
+		return true
+	}
+	return o.Cmd != nil && o.Cmd.Flags().Changed(flag)
+}
+
+func devPodTemplatesByName(devPodTemplates map[string]*kube.DevPodTemplate) map[string]string {
+	answer := map[string]string{}
+	for k := range devPodTemplates {
+		answer[k] = k
+	}
+	return answer
+}
+
 func (o *CreateDevPodOptions) isAutoExposeSecure(client kubernetes.Interface, ns string) bool {
 	ingressConfig, err := kube.GetIngressConfig(client, ns)
 	tlsEnabled := false
@@ -2,6 +2,7 @@ package create
 
 import (
 	"fmt"
+	"io/ioutil"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/create/options"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
@@ -25,6 +27,9 @@ var (
 		# Create a new pending Team which can then be provisioned
 		jx create team myname
 "
+
+		# Create a new Team with a policy limiting its resources
+		jx create team myname --policy team-policy.yaml
 	`)
 )
 
@@ -32,8 +37,9 @@ var (
 type CreateTeamOptions struct {
 	options.CreateOptions
 
-	Name    string
-	Members []string
+	Name       string
+	Members    []string
+	PolicyFile string
 }
 
 // NewCmdCreateTeam creates a command object for the "create" command
@@ -60,6 +66,7 @@ func NewCmdCreateTeam(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Name, optionName, "n", "", "The name of the new Team. Should be all lower case and no special characters other than '-'")
 	cmd.Flags().StringArrayVarP(&options.Members, "member", "m", []string{}, "The usernames of the members to add to the Team")
+	cmd.Flags().StringVarP(&options.PolicyFile, "policy", "", "", "The YAML file of the policy of the Team such as its resource quota and allowed image registries")
 
 	return cmd
 }
@@ -105,6 +112,18 @@ func (o *CreateTeamOptions) Run() error {
 		return fmt.Errorf("The Team %s already exists!", name)
 	}
 
+	var policy *kube.TeamPolicy
+	if o.PolicyFile != "" {
+		data, err := ioutil.ReadFile(o.PolicyFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the policy file %s", o.PolicyFile)
+		}
+		policy, err = kube.ParseTeamPolicy(data)
+		if err != nil {
+			return errors.Wrapf(err, "invalid policy file %s", o.PolicyFile)
+		}
+	}
+
 	// TODO configure other properties?
 	team := kube.CreateTeam(ns, name, o.Members)
 	_, err = jxClient.JenkinsV1().Teams(ns).Create(team)
@@ -112,5 +131,13 @@ func (o *CreateTeamOptions) Run() error {
 		return fmt.Errorf("Failed to create Team %s: %s", name, err)
 	}
 	log.Logger().Infof("Created Team: %s", util.ColorInfo(name))
+
+	if policy != nil {
+		err = kube.SaveTeamPolicy(kubeClient, ns, name, policy)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("Saved the policy of Team %s", util.ColorInfo(name))
+	}
 	return nil
 }
@@ -40,7 +40,6 @@ var (
 		"helm",
 		"tiller",
 		"helm3",
-		"ksync",
 		"oc",
 		"aws",
 		"eksctl",
@@ -28,8 +28,10 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
 	"github.com/jenkins-x/jx/v2/pkg/jenkins"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -405,6 +407,16 @@ func (o *DeleteApplicationOptions) deleteApplicationFromEnvironment(env *v1.Envi
 		requirements.RemoveApplication(applicationName)
 		return nil
 	}
+	modifyHelmfileFn := func(states []*helmfile.HelmState, dir string, info *gits.PullRequestDetails) error {
+		for _, state := range states {
+			state.RemoveRelease(applicationName)
+		}
+		return nil
+	}
+	modifyKustomizationFn := func(dir string, info *gits.PullRequestDetails) error {
+		_, err := kustomize.RemoveEnvironmentApp(dir, applicationName)
+		return err
+	}
 	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
 	if err != nil {
 		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
@@ -420,9 +432,11 @@ func (o *DeleteApplicationOptions) deleteApplicationFromEnvironment(env *v1.Envi
 		Message:    "The command `jx delete application` was run by " + username + " and it generated this Pull Request",
 	}
 	options := environments.EnvironmentPullRequestOptions{
-		Gitter:        o.Git(),
-		ModifyChartFn: modifyChartFn,
-		GitProvider:   gitProvider,
+		Gitter:                o.Git(),
+		ModifyChartFn:         modifyChartFn,
+		ModifyHelmfileFn:      modifyHelmfileFn,
+		ModifyKustomizationFn: modifyKustomizationFn,
+		GitProvider:           gitProvider,
 	}
 	info, err := options.Create(env, envDir, &details, nil, "", o.AutoMerge)
 	if err != nil {
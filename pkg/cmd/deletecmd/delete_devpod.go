@@ -88,6 +88,11 @@ func (o *DeleteDevPodOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "getting the pod names")
 	}
+	suspendedNames, suspended, err := kube.GetSuspendedDevPods(client, ns, userName)
+	if err != nil {
+		return errors.Wrap(err, "getting the suspended DevPods")
+	}
+	names = append(names, suspendedNames...)
 
 	info := util.ColorInfo
 	if len(names) == 0 {
@@ -113,7 +118,12 @@ func (o *DeleteDevPodOptions) Run() error {
 			return util.InvalidOption(opts.OptionLabel, name, names)
 		}
 		log.Logger().Debugf("About to delete Devpod %s", name)
-		err = client.CoreV1().Pods(ns).Delete(name, &metav1.DeleteOptions{})
+		if cm, ok := suspended[name]; ok {
+			// the workspace and services of a suspended DevPod are owned by its ConfigMap
+			err = client.CoreV1().ConfigMaps(ns).Delete(cm.Name, &metav1.DeleteOptions{})
+		} else {
+			err = client.CoreV1().Pods(ns).Delete(name, &metav1.DeleteOptions{})
+		}
 		if err != nil {
 			return errors.Wrapf(err, "deleting the devpod %q", name)
 		}
@@ -0,0 +1,103 @@
+package devpod
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DevPodOptions contains the command line options
+type DevPodOptions struct {
+	*opts.CommonOptions
+	opts.CommonDevPodOptions
+}
+
+var (
+	devPodLong = templates.LongDesc(`
+		Manages the lifecycle of DevPods such as suspending a DevPod while keeping its workspace.
+
+		For more documentation see: [https://jenkins-x.io/developing/devpods/](https://jenkins-x.io/developing/devpods/)
+
+`)
+
+	devPodExample = templates.Examples(`
+		# suspend a DevPod
+		jx devpod suspend myuser-maven
+
+		# resume a suspended DevPod
+		jx devpod resume myuser-maven
+
+		# snapshot the workspace of a DevPod into the team storage bucket
+		jx devpod snapshot myuser-maven
+	`)
+)
+
+// NewCmdDevPod creates the command object
+func NewCmdDevPod(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DevPodOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "devpod ACTION [flags]",
+		Short:   "Suspends, resumes or snapshots DevPods",
+		Long:    devPodLong,
+		Example: devPodExample,
+		Aliases: []string{"devpods"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.AddCommand(NewCmdDevPodResume(commonOpts))
+	cmd.AddCommand(NewCmdDevPodSnapshot(commonOpts))
+	cmd.AddCommand(NewCmdDevPodSuspend(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *DevPodOptions) Run() error {
+	return o.Cmd.Help()
+}
+
+// devPodClient returns the kubernetes client, the dev namespace of the DevPods and the name of the current user
+func (o *DevPodOptions) devPodClient() (kubernetes.Interface, string, string, error) {
+	client, curNs, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "getting the kubernetes client")
+	}
+	ns, _, err := kube.GetDevNamespace(client, curNs)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "getting the dev namespace")
+	}
+	userName, err := o.GetUsername(o.CommonDevPodOptions.Username)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "getting the current user")
+	}
+	return client, ns, userName, nil
+}
+
+// pickDevPod returns the DevPod name argument or picks one of the names
+func (o *DevPodOptions) pickDevPod(names []string, kind string, userName string) (string, error) {
+	if len(o.Args) > 0 {
+		name := o.Args[0]
+		if util.StringArrayIndex(names, name) < 0 {
+			return "", util.InvalidArg(name, names)
+		}
+		return name, nil
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("there are no %s for user %s", kind, util.ColorInfo(userName))
+	}
+	return util.PickName(names, "Pick DevPod:", "", o.GetIOFileHandles())
+}
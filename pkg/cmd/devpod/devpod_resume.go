@@ -0,0 +1,90 @@
+package devpod
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// DevPodResumeOptions contains the command line options
+type DevPodResumeOptions struct {
+	DevPodOptions
+
+	NoWait bool
+}
+
+var (
+	devPodResumeLong = templates.LongDesc(`
+		Resumes a suspended DevPod by creating its pod again using the PersistentVolumeClaim of its workspace.
+`)
+
+	devPodResumeExample = templates.Examples(`
+		# resume a DevPod by picking one from the list of suspended DevPods
+		jx devpod resume
+
+		# resume a specific DevPod
+		jx devpod resume myuser-maven
+	`)
+)
+
+// NewCmdDevPodResume creates the command object
+func NewCmdDevPodResume(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DevPodResumeOptions{
+		DevPodOptions: DevPodOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "resume [devpod]",
+		Short:   "Resumes a suspended DevPod",
+		Long:    devPodResumeLong,
+		Example: devPodResumeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.NoWait, "no-wait", "", false, "Do not wait for the DevPod to be ready")
+	options.AddCommonDevPodFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *DevPodResumeOptions) Run() error {
+	client, ns, userName, err := o.devPodClient()
+	if err != nil {
+		return err
+	}
+	names, _, err := kube.GetSuspendedDevPods(client, ns, userName)
+	if err != nil {
+		return err
+	}
+	name, err := o.pickDevPod(names, "suspended DevPods", userName)
+	if err != nil {
+		return err
+	}
+	_, err = kube.ResumeDevPod(client, ns, name)
+	if err != nil {
+		return err
+	}
+	if !o.NoWait {
+		log.Logger().Infof("Resumed DevPod %s - waiting for it to be ready...", util.ColorInfo(name))
+		err = kube.WaitForPodNameToBeReady(client, ns, name, time.Hour)
+		if err != nil {
+			return errors.Wrapf(err, "waiting for DevPod %s to be ready", name)
+		}
+	}
+	log.Logger().Infof("DevPod %s is resumed. You can open a shell into it via: %s", util.ColorInfo(name), util.ColorInfo("jx rsh -d"))
+	return nil
+}
@@ -0,0 +1,138 @@
+package devpod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cloud/factory"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const devPodContainerName = "devpod"
+
+// DevPodSnapshotOptions contains the command line options
+type DevPodSnapshotOptions struct {
+	DevPodOptions
+
+	Dir       string
+	BucketURL string
+}
+
+var (
+	devPodSnapshotLong = templates.LongDesc(`
+		Snapshots the workspace of a DevPod by archiving it into a tarball in the storage bucket of the team for the
+		'devpods' classification.
+
+		You can configure the bucket via 'jx edit storage -c devpods'.
+`)
+
+	devPodSnapshotExample = templates.Examples(`
+		# snapshot the workspace of a DevPod by picking one from the list
+		jx devpod snapshot
+
+		# snapshot the workspace of a specific DevPod
+		jx devpod snapshot myuser-maven
+	`)
+)
+
+// NewCmdDevPodSnapshot creates the command object
+func NewCmdDevPodSnapshot(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DevPodSnapshotOptions{
+		DevPodOptions: DevPodOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "snapshot [devpod]",
+		Short:   "Snapshots the workspace of a DevPod into the team storage bucket",
+		Long:    devPodSnapshotLong,
+		Example: devPodSnapshotExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "/workspace", "The workspace directory in the DevPod to snapshot")
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The bucket URL to store the snapshot in. Defaults to the storage location of the team for the devpods classification")
+	options.AddCommonDevPodFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *DevPodSnapshotOptions) Run() error {
+	client, ns, userName, err := o.devPodClient()
+	if err != nil {
+		return err
+	}
+	names, _, err := kube.GetDevPodNames(client, ns, userName)
+	if err != nil {
+		return errors.Wrap(err, "getting the DevPod names")
+	}
+	name, err := o.pickDevPod(names, "running DevPods", userName)
+	if err != nil {
+		return err
+	}
+
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to load the team settings")
+	}
+	location := settings.StorageLocationOrDefault(kube.ClassificationDevPods)
+	if o.BucketURL != "" {
+		location.BucketURL = o.BucketURL
+	}
+	if location.BucketURL == "" {
+		return fmt.Errorf("there is no cloud storage bucket for the %s classification. Use 'jx edit storage' to configure one or specify --bucket-url", kube.ClassificationDevPods)
+	}
+
+	f, err := ioutil.TempFile("", name+"-*.tar.gz")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary file for the snapshot")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	log.Logger().Infof("Archiving %s of DevPod %s", util.ColorInfo(o.Dir), util.ColorInfo(name))
+	cmd := util.Command{
+		Name: "kubectl",
+		Args: []string{"exec", "-n", ns, "-c", devPodContainerName, name, "--", "tar", "czf", "-", "-C", o.Dir, "."},
+		Out:  f,
+	}
+	_, err = cmd.RunWithoutRetry()
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive the workspace of DevPod %s", name)
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the snapshot %s", f.Name())
+	}
+
+	provider, err := factory.NewBucketProviderFromTeamSettingsConfigurationOrDefault(o.GetFactory(), location)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the bucket provider")
+	}
+	u, err := provider.UploadFileToBucket(f, SnapshotKey(userName, name, time.Now()), location.BucketURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload the snapshot of DevPod %s to %s", name, location.BucketURL)
+	}
+	log.Logger().Infof("Saved the snapshot of DevPod %s to %s", util.ColorInfo(name), util.ColorInfo(u))
+	return nil
+}
+
+// SnapshotKey returns the key in the bucket of a snapshot of the DevPod of the user taken at the given time
+func SnapshotKey(userName string, name string, t time.Time) string {
+	return fmt.Sprintf("devpods/%s/%s/%s.tar.gz", userName, name, t.UTC().Format("20060102-150405"))
+}
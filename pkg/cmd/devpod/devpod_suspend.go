@@ -0,0 +1,81 @@
+package devpod
+
+import (
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// DevPodSuspendOptions contains the command line options
+type DevPodSuspendOptions struct {
+	DevPodOptions
+}
+
+var (
+	devPodSuspendLong = templates.LongDesc(`
+		Suspends a DevPod by deleting its pod while keeping the PersistentVolumeClaim of its workspace, so that the
+		DevPod no longer uses any CPU or memory of the cluster until it is resumed via 'jx devpod resume'.
+
+		Only DevPods created with --persist can be suspended.
+`)
+
+	devPodSuspendExample = templates.Examples(`
+		# suspend a DevPod by picking one from the list
+		jx devpod suspend
+
+		# suspend a specific DevPod
+		jx devpod suspend myuser-maven
+	`)
+)
+
+// NewCmdDevPodSuspend creates the command object
+func NewCmdDevPodSuspend(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DevPodSuspendOptions{
+		DevPodOptions: DevPodOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "suspend [devpod]",
+		Short:   "Suspends a DevPod keeping its workspace",
+		Long:    devPodSuspendLong,
+		Example: devPodSuspendExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddCommonDevPodFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *DevPodSuspendOptions) Run() error {
+	client, ns, userName, err := o.devPodClient()
+	if err != nil {
+		return err
+	}
+	names, _, err := kube.GetDevPodNames(client, ns, userName)
+	if err != nil {
+		return errors.Wrap(err, "getting the DevPod names")
+	}
+	name, err := o.pickDevPod(names, "running DevPods", userName)
+	if err != nil {
+		return err
+	}
+	_, err = kube.SuspendDevPod(client, ns, name)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Suspended DevPod %s. You can resume it via: %s", util.ColorInfo(name), util.ColorInfo("jx devpod resume "+name))
+	return nil
+}
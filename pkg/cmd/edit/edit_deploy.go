@@ -2,11 +2,13 @@ package edit
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/spf13/cobra"
 
@@ -29,6 +31,9 @@ var (
         # to switch to normal kubernetes deployments
 		jx edit deploy default
 
+        # to switch to kustomize base and overlays which generates a 'kustomize' directory
+		jx edit deploy kustomize
+
         # to switch to use canary deployments (requires flagger and its dependencies)
 		jx edit deploy --canary
 
@@ -45,7 +50,7 @@ var (
 		jx edit deploy --team knative --canary=true --hpa=false
 	`)
 
-	deployKinds = []string{opts.DeployKindKnative, opts.DeployKindDefault}
+	deployKinds = []string{opts.DeployKindKnative, opts.DeployKindDefault, opts.DeployKindKustomize}
 
 	knativeDeployKey = "knativeDeploy:"
 	deployCanaryKey  = "canary:"
@@ -137,6 +142,22 @@ func (o *EditDeployKindOptions) Run() error {
 		return o.ModifyDevEnvironment(callback)
 	}
 
+	kustomizeProject, err := kustomize.IsKustomizeProject(o.Dir)
+	if err != nil {
+		return err
+	}
+	if kustomizeProject {
+		name, err := o.pickDeployKind(opts.DeployKindKustomize)
+		if err != nil {
+			return err
+		}
+		if name != opts.DeployKindKustomize {
+			return fmt.Errorf("the project is deployed via kustomize so cannot be switched to the %s deploy kind", name)
+		}
+		return nil
+	}
+
+	useKustomize := false
 	fn := func(text string) (string, error) {
 		defaultName, currentDeployOptions := o.FindDefaultDeployKindInValuesYaml(text)
 		name := ""
@@ -145,6 +166,10 @@ func (o *EditDeployKindOptions) Run() error {
 		if err != nil {
 			return name, err
 		}
+		if name == opts.DeployKindKustomize {
+			useKustomize = true
+			return text, nil
+		}
 		canary, err := o.pickProgressiveDelivery(currentDeployOptions.Canary)
 		if err != nil {
 			return name, err
@@ -155,7 +180,17 @@ func (o *EditDeployKindOptions) Run() error {
 		}
 		return o.setDeployKindInValuesYaml(text, name, canary, hpa)
 	}
-	return o.ModifyHelmValuesFile(o.Dir, fn)
+	err = o.ModifyHelmValuesFile(o.Dir, fn)
+	if err != nil || !useKustomize {
+		return err
+	}
+
+	// lets use the name of the chart as the name of the app
+	valuesFile, err := o.FindChartValuesYaml(o.Dir)
+	if err != nil {
+		return err
+	}
+	return o.GenerateKustomizeLayout(o.Dir, filepath.Base(filepath.Dir(valuesFile)))
 }
 
 // FindDefaultDeployKindInValuesYaml finds the deployment values for the given values.yaml text
@@ -255,7 +290,7 @@ func (o *EditDeployKindOptions) pickDeployKind(defaultName string) (string, erro
 	if util.StringArrayIndex(deployKinds, defaultName) < 0 {
 		defaultName = opts.DeployKindDefault
 	}
-	name, err := util.PickNameWithDefault(deployKinds, "Pick the deployment kind: ", defaultName, "lets you switch between knative serve based deployments and default kubernetes deployments", o.GetIOFileHandles())
+	name, err := util.PickNameWithDefault(deployKinds, "Pick the deployment kind: ", defaultName, "lets you switch between knative serve based deployments, default kubernetes deployments and kustomize overlays", o.GetIOFileHandles())
 	if err != nil {
 		return name, err
 	}
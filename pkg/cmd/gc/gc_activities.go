@@ -7,7 +7,9 @@ import (
 
 	gojenkins "github.com/jenkins-x/golang-jenkins"
 	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/buildstats"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/pkg/errors"
 	prowjobv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 
@@ -36,6 +38,9 @@ type GCActivitiesOptions struct {
 	PullRequestAgeLimit     time.Duration
 	PipelineRunAgeLimit     time.Duration
 	ProwJobAgeLimit         time.Duration
+	Rollup                  bool
+	RollupBucketURL         string
+	RollupMaxAge            time.Duration
 	jclient                 gojenkins.JenkinsClient
 }
 
@@ -107,6 +112,9 @@ func NewCmdGCActivities(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().DurationVarP(&options.ReleaseAgeLimit, "release-age", "r", time.Hour*24*30, "Maximum age to keep PipelineActivities for Releases")
 	cmd.Flags().DurationVarP(&options.PipelineRunAgeLimit, "pipelinerun-age", "", time.Hour*12, "Maximum age to keep completed PipelineRuns for all pipelines")
 	cmd.Flags().DurationVarP(&options.ProwJobAgeLimit, "prowjob-age", "", time.Hour*24*7, "Maximum age to keep completed ProwJobs for all pipelines")
+	cmd.Flags().BoolVarP(&options.Rollup, "rollup", "", false, "Stores a compact summary of the deleted PipelineActivities in the team's storage bucket so they can be used by 'jx get pipeline stats'")
+	cmd.Flags().StringVarP(&options.RollupBucketURL, "rollup-bucket-url", "", "", "The cloud storage bucket URL to store the summaries in. Defaults to the team's storage location for the 'reports' classifier")
+	cmd.Flags().DurationVarP(&options.RollupMaxAge, "rollup-age", "", buildstats.DefaultRollupMaxAge, "Maximum age to keep the summaries of PipelineActivities for")
 	return cmd
 }
 
@@ -170,7 +178,8 @@ func (o *GCActivitiesOptions) Run() error {
 		return !completedActivities[i].Spec.CompletedTimestamp.Before(completedActivities[j].Spec.CompletedTimestamp)
 	})
 
-	//
+	// the activities to delete once their summaries are stored
+	var deletions []*v1.PipelineActivity
 	for _, a := range completedActivities {
 		activity := a
 		branchName := a.BranchName()
@@ -178,21 +187,14 @@ func (o *GCActivitiesOptions) Run() error {
 		maxAge, revisionHistory := o.ageAndHistoryLimits(isPR, isBatch)
 		// lets remove activities that are too old
 		if activity.Spec.CompletedTimestamp != nil && activity.Spec.CompletedTimestamp.Add(maxAge).Before(now) {
-
-			err = o.deleteActivity(activityInterface, &activity)
-			if err != nil {
-				return err
-			}
+			deletions = append(deletions, &activity)
 			continue
 		}
 
 		repoBranchAndContext := activity.RepositoryOwner() + "/" + activity.RepositoryName() + "/" + activity.BranchName() + "/" + activity.Spec.Context
 		c := counters.AddBuild(repoBranchAndContext, isPR)
 		if c > revisionHistory && a.Spec.CompletedTimestamp != nil {
-			err = o.deleteActivity(activityInterface, &activity)
-			if err != nil {
-				return err
-			}
+			deletions = append(deletions, &activity)
 			continue
 		}
 
@@ -206,14 +208,23 @@ func (o *GCActivitiesOptions) Run() error {
 				}
 			}
 			if !matched {
-				err = o.deleteActivity(activityInterface, &activity)
-				if err != nil {
-					return err
-				}
+				deletions = append(deletions, &activity)
 			}
 		}
 	}
 
+	// lets store the summaries before deleting the activities so that they are kept if the deletion fails
+	err = o.saveRollup(currentNs, deletions)
+	if err != nil {
+		log.Logger().Warnf("failed to store the summaries of the PipelineActivities to delete: %s", err.Error())
+	}
+	for _, activity := range deletions {
+		err = o.deleteActivity(activityInterface, activity)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Clean up completed PipelineRuns
 	err = o.gcPipelineRuns(currentNs)
 	if err != nil {
@@ -241,6 +252,46 @@ func (o *GCActivitiesOptions) deleteActivity(activityInterface jv1.PipelineActiv
 	return activityInterface.Delete(a.Name, metav1.NewDeleteOptions(0))
 }
 
+// saveRollup stores the summaries of the activities to delete in the team's storage bucket
+func (o *GCActivitiesOptions) saveRollup(ns string, activities []*v1.PipelineActivity) error {
+	if !o.Rollup || o.DryRun {
+		return nil
+	}
+	var summaries []*buildstats.ActivitySummary
+	for _, a := range activities {
+		summary := buildstats.SummarizeActivity(a)
+		if summary != nil {
+			summaries = append(summaries, summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return nil
+	}
+	bucketURL := o.RollupBucketURL
+	if bucketURL == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return err
+		}
+		bucketURL = settings.StorageLocationOrDefault(kube.ClassificationReports).BucketURL
+	}
+	if bucketURL == "" {
+		log.Logger().Debugf("no storage bucket configured so not storing the summaries of %d deleted PipelineActivities", len(summaries))
+		return nil
+	}
+	rollup, err := buildstats.LoadRollup(bucketURL, ns)
+	if err != nil {
+		return err
+	}
+	rollup.Add(summaries, time.Now().Add(-o.RollupMaxAge))
+	err = buildstats.SaveRollup(bucketURL, rollup)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("stored the summaries of %d deleted PipelineActivities in %s", len(summaries), util.ColorInfo(bucketURL))
+	return nil
+}
+
 func (o *GCActivitiesOptions) gcPipelineRuns(ns string) error {
 	tektonClient, _, err := o.TektonClient()
 	if err != nil {
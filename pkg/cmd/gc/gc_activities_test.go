@@ -3,11 +3,14 @@
 package gc
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	jenkinsv1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
 	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/buildstats"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/clients/fake"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/testhelpers"
 	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
@@ -297,3 +300,129 @@ func TestGCPipelineActivitiesWithBatchAndPRBuilds(t *testing.T) {
 	assert.NotNil(t, job2, "ProwJob job2 has no completion time and so shouldn't have been deleted")
 	assert.NotNil(t, job3, "ProwJob job3 completed less than 7 days ago and so shouldn't have been deleted")
 }
+
+func TestGCPipelineActivitiesStoresRollup(t *testing.T) {
+	t.Parallel()
+
+	commonOpts := opts.NewCommonOptionsWithFactory(fake.NewFakeFactory())
+	options := &commonOpts
+	testhelpers.ConfigureTestOptions(options, options.Git(), options.Helm())
+
+	dir, err := ioutil.TempDir("", "gc-rollup")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	o := &GCActivitiesOptions{
+		CommonOptions:           options,
+		PullRequestAgeLimit:     time.Hour * 48,
+		ReleaseAgeLimit:         time.Hour * 24 * 30,
+		ReleaseHistoryLimit:     5,
+		PullRequestHistoryLimit: 2,
+		PipelineRunAgeLimit:     time.Hour * 2,
+		ProwJobAgeLimit:         time.Hour * 24 * 7,
+		Rollup:                  true,
+		RollupBucketURL:         "file://" + dir,
+		RollupMaxAge:            buildstats.DefaultRollupMaxAge,
+	}
+
+	jxClient, ns, err := options.JXClientAndDevNamespace()
+	assert.NoError(t, err)
+
+	err = options.ModifyDevEnvironment(func(env *v1.Environment) error {
+		env.Spec.TeamSettings.PromotionEngine = jenkinsv1.PromotionEngineProw
+		return nil
+	})
+	assert.NoError(t, err)
+
+	nowMinusThreeDays := time.Now().AddDate(0, 0, -3)
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).Create(&v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "old-pr",
+			Labels: map[string]string{
+				v1.LabelBranch: "PR-1",
+			},
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "org/project/PR-1",
+			Build:              "1",
+			Status:             v1.ActivityStatusTypeSucceeded,
+			StartedTimestamp:   &metav1.Time{Time: nowMinusThreeDays.Add(-time.Minute)},
+			CompletedTimestamp: &metav1.Time{Time: nowMinusThreeDays},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = o.Run()
+	assert.NoError(t, err)
+
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, activities.Items, "the old activity should have been garbage collected")
+
+	rollup, err := buildstats.LoadRollup(o.RollupBucketURL, ns)
+	assert.NoError(t, err)
+	if assert.Len(t, rollup.Summaries, 1) {
+		assert.Equal(t, "org/project/PR-1", rollup.Summaries[0].Pipeline)
+		assert.Equal(t, time.Minute, rollup.Summaries[0].RunTime().Round(time.Second))
+	}
+}
+
+func TestGCPipelineActivitiesDeletesActivitiesIfRollupFails(t *testing.T) {
+	t.Parallel()
+
+	commonOpts := opts.NewCommonOptionsWithFactory(fake.NewFakeFactory())
+	options := &commonOpts
+	testhelpers.ConfigureTestOptions(options, options.Git(), options.Helm())
+
+	// lets use a file as the bucket so that the rollup cannot be stored
+	file, err := ioutil.TempFile("", "gc-rollup")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	o := &GCActivitiesOptions{
+		CommonOptions:           options,
+		PullRequestAgeLimit:     time.Hour * 48,
+		ReleaseAgeLimit:         time.Hour * 24 * 30,
+		ReleaseHistoryLimit:     5,
+		PullRequestHistoryLimit: 2,
+		PipelineRunAgeLimit:     time.Hour * 2,
+		ProwJobAgeLimit:         time.Hour * 24 * 7,
+		Rollup:                  true,
+		RollupBucketURL:         "file://" + file.Name(),
+		RollupMaxAge:            buildstats.DefaultRollupMaxAge,
+	}
+
+	jxClient, ns, err := options.JXClientAndDevNamespace()
+	assert.NoError(t, err)
+
+	err = options.ModifyDevEnvironment(func(env *v1.Environment) error {
+		env.Spec.TeamSettings.PromotionEngine = jenkinsv1.PromotionEngineProw
+		return nil
+	})
+	assert.NoError(t, err)
+
+	nowMinusThreeDays := time.Now().AddDate(0, 0, -3)
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).Create(&v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "old-pr",
+			Labels: map[string]string{
+				v1.LabelBranch: "PR-1",
+			},
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "org/project/PR-1",
+			Build:              "1",
+			Status:             v1.ActivityStatusTypeSucceeded,
+			StartedTimestamp:   &metav1.Time{Time: nowMinusThreeDays.Add(-time.Minute)},
+			CompletedTimestamp: &metav1.Time{Time: nowMinusThreeDays},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = o.Run()
+	assert.NoError(t, err, "should not fail when the rollup cannot be stored")
+
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, activities.Items, "the activity should be deleted even though its summary was not stored")
+}
@@ -0,0 +1,130 @@
+package gc
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// GCCachesOptions containers the CLI options
+type GCCachesOptions struct {
+	*opts.CommonOptions
+
+	Age       time.Duration
+	MaxSize   string
+	BucketURL string
+	Dir       string
+	DryRun    bool
+}
+
+var (
+	gcCachesLong = templates.LongDesc(`
+		Garbage collect the least recently used build caches.
+
+		Build caches which have not been saved or restored for the --age are removed. As cache keys usually contain the
+		checksum of the dependency files, old cache entries are no longer used once the dependencies change.
+		If --max-size is specified, the least recently used of the remaining build caches are then removed until their
+		total size is below it.
+`)
+
+	gcCachesExample = templates.Examples(`
+		# garbage collect the build caches in the team's storage location older than the default age
+		jx gc caches
+
+		# list the build caches not used for a week without removing them
+		jx gc caches --age 168h --dry-run
+
+		# keep the total size of the build caches below 50 gigabytes
+		jx gc caches --max-size 50Gi
+
+		# garbage collect the build caches in a shared volume
+		jx gc caches --dir /build-cache
+`)
+)
+
+// NewCmdGCCaches creates the command object
+func NewCmdGCCaches(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GCCachesOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "caches",
+		Short:   "garbage collection for build caches",
+		Aliases: []string{"cache"},
+		Long:    gcCachesLong,
+		Example: gcCachesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().DurationVarP(&options.Age, "age", "a", time.Hour*24*30, "The minimum time since a build cache was last used to garbage collect it. Any more recently used caches will be kept")
+	cmd.Flags().StringVarP(&options.MaxSize, "max-size", "", "", "The maximum total size of the build caches such as '50Gi'. The least recently used caches are removed until they fit")
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL of the build caches. Defaults to the team's storage location for the 'caches' classifier")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of a shared volume containing the build caches")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Only list the build caches which would be removed")
+	return cmd
+}
+
+// Run implements this command
+func (o *GCCachesOptions) Run() error {
+	var maxSize int64
+	if o.MaxSize != "" {
+		quantity, err := resource.ParseQuantity(o.MaxSize)
+		if err != nil {
+			return util.InvalidOptionError("max-size", o.MaxSize, err)
+		}
+		maxSize = quantity.Value()
+	}
+
+	var store buildcache.Store
+	location := o.Dir
+	if o.Dir != "" {
+		store = buildcache.NewDirStore(o.Dir)
+	} else {
+		if o.BucketURL == "" {
+			settings, err := o.TeamSettings()
+			if err != nil {
+				return errors.Wrap(err, "failed to load the team settings")
+			}
+			o.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationCaches).BucketURL
+		}
+		if o.BucketURL == "" {
+			return util.MissingOption("bucket-url")
+		}
+		store = buildcache.NewBucketStore(o.BucketURL)
+		location = o.BucketURL
+	}
+
+	entries, err := buildcache.Evict(store, time.Now().Add(-o.Age), maxSize, o.DryRun)
+	if err != nil {
+		return errors.Wrapf(err, "failed to garbage collect the build caches in %s", location)
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+		if o.DryRun {
+			log.Logger().Infof("would remove build cache %s last used %s", util.ColorInfo(e.Key), e.LastUsed().Format(time.RFC3339))
+		} else {
+			log.Logger().Debugf("removed build cache %s", e.Key)
+		}
+	}
+	if o.DryRun {
+		log.Logger().Infof("%d build caches using %d bytes would be removed from %s", len(entries), size, util.ColorInfo(location))
+		return nil
+	}
+	log.Logger().Infof("removed %d build caches using %d bytes from %s", len(entries), size, util.ColorInfo(location))
+	return nil
+}
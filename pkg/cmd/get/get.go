@@ -75,16 +75,19 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.AddCommand(NewCmdGetActivity(commonOpts))
 	cmd.AddCommand(NewCmdGetAddon(commonOpts))
+	cmd.AddCommand(NewCmdGetAudit(commonOpts))
 	cmd.AddCommand(NewCmdGetApps(commonOpts))
 	cmd.AddCommand(NewCmdGetApplications(commonOpts))
 	cmd.AddCommand(NewCmdGetBranchPattern(commonOpts))
 	cmd.AddCommand(NewCmdGetBuild(commonOpts))
 	cmd.AddCommand(NewCmdGetBuildPack(commonOpts))
 	cmd.AddCommand(NewCmdGetChat(commonOpts))
+	cmd.AddCommand(NewCmdGetCluster(commonOpts))
 	cmd.AddCommand(NewCmdGetConfig(commonOpts))
 	cmd.AddCommand(NewCmdGetCRDCount(commonOpts))
 	cmd.AddCommand(NewCmdGetCVE(commonOpts))
 	cmd.AddCommand(NewCmdGetDevPod(commonOpts))
+	cmd.AddCommand(NewCmdGetDORA(commonOpts))
 	cmd.AddCommand(NewCmdGetEnv(commonOpts))
 	cmd.AddCommand(NewCmdGetGit(commonOpts))
 	cmd.AddCommand(NewCmdGetHelmBin(commonOpts))
@@ -100,9 +103,11 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetStorage(commonOpts))
 	cmd.AddCommand(NewCmdGetTeam(commonOpts))
 	cmd.AddCommand(NewCmdGetTeamRole(commonOpts))
+	cmd.AddCommand(NewCmdGetTests(commonOpts))
 	cmd.AddCommand(NewCmdGetToken(commonOpts))
 	cmd.AddCommand(NewCmdGetTracker(commonOpts))
 	cmd.AddCommand(NewCmdGetURL(commonOpts))
+	cmd.AddCommand(NewCmdGetUsage(commonOpts))
 	cmd.AddCommand(NewCmdGetUser(commonOpts))
 	cmd.AddCommand(vault.NewCmdGetVault(commonOpts))
 	cmd.AddCommand(config.NewCmdGetVaultConfig(commonOpts))
@@ -0,0 +1,140 @@
+package get
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/audit"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GetAuditOptions containers the CLI options
+type GetAuditOptions struct {
+	GetOptions
+
+	Since       time.Duration
+	User        string
+	Command     string
+	Namespace   string
+	Environment string
+	Outcome     string
+	BucketURL   string
+	NoBucket    bool
+}
+
+var (
+	getAuditLong = templates.LongDesc(`
+		Display the audit log of the jx commands run against the team such as 'jx promote', 'jx delete env',
+		'jx edit userrole', 'jx create token' and 'jx boot'. Every command is audited apart from read only commands
+		such as 'jx get' and the pipeline steps of 'jx step'.
+
+		Each entry records who ran the command, its arguments with the values of password, token and secret flags
+		masked, the namespace or environment it changed and its outcome. Entries are recorded as Kubernetes Events in
+		the dev namespace and in the storage bucket of the team for the 'audit' classification. As Events expire,
+		configure a bucket via 'jx edit storage -c audit' to keep the full history.
+`)
+
+	getAuditExample = templates.Examples(`
+		# List the commands run in the last day
+		jx get audit
+
+		# List the promotions to production in the last week
+		jx get audit --command promote --env production --since 168h
+
+		# List the failed commands of a user
+		jx get audit --user jstrachan --outcome failed
+	`)
+)
+
+// NewCmdGetAudit creates the new command for: jx get audit
+func NewCmdGetAudit(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetAuditOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Short:   "Display the audit log of the jx commands which changed the team",
+		Aliases: []string{"audits"},
+		Long:    getAuditLong,
+		Example: getAuditExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", 24*time.Hour, "Only display the commands run within this duration. Zero displays the whole audit log")
+	cmd.Flags().StringVarP(&options.User, "user", "u", "", "Only display the commands run by a git or kubernetes user containing this text")
+	cmd.Flags().StringVarP(&options.Command, "command", "c", "", "Only display the commands containing this text such as 'promote'")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "Only display the commands which changed this namespace")
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "Only display the commands which changed this environment")
+	cmd.Flags().StringVarP(&options.Outcome, "outcome", "", "", "Only display the commands with this outcome: "+strings.Join([]string{audit.OutcomeSucceeded, audit.OutcomeFailed}, ", "))
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL of the audit log. Defaults to the team's storage location for the 'audit' classifier")
+	cmd.Flags().BoolVarP(&options.NoBucket, "no-bucket", "", false, "Only display the audit log recorded as Kubernetes Events")
+
+	options.AddGetFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetAuditOptions) Run() error {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	if o.BucketURL == "" && !o.NoBucket {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to load the team settings")
+		}
+		o.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationAudit).BucketURL
+	}
+	if o.NoBucket {
+		o.BucketURL = ""
+	}
+
+	filter := &audit.Filter{
+		User:        o.User,
+		Command:     o.Command,
+		Namespace:   o.Namespace,
+		Environment: o.Environment,
+		Outcome:     o.Outcome,
+	}
+	if o.Since > 0 {
+		filter.Since = time.Now().Add(-o.Since)
+	}
+	entries, err := audit.LoadEntries(kubeClient, ns, o.BucketURL, filter)
+	if err != nil {
+		return err
+	}
+	if o.Output != "" {
+		return o.renderResult(entries, o.Output)
+	}
+	if len(entries) == 0 {
+		log.Logger().Infof("No audit log entries found")
+		return nil
+	}
+
+	table := o.CreateTable()
+	table.AddRow("TIME", "USER", "COMMAND", "NAMESPACE", "ENVIRONMENT", "OUTCOME")
+	for _, e := range entries {
+		outcome := e.Outcome
+		if e.Outcome == audit.OutcomeFailed {
+			outcome = util.ColorError(outcome)
+		}
+		command := strings.TrimSpace(e.Command + " " + strings.Join(e.Args, " "))
+		table.AddRow(e.Time.Local().Format(time.RFC3339), e.Who(), command, e.Namespace, e.Environment, outcome)
+	}
+	table.Render()
+	return nil
+}
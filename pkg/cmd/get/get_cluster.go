@@ -0,0 +1,40 @@
+package get
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/spf13/cobra"
+)
+
+// GetClusterOptions the command line options
+type GetClusterOptions struct {
+	GetOptions
+}
+
+// NewCmdGetCluster creates the command
+func NewCmdGetCluster(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetClusterOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Short:   "Display information about clusters",
+		Aliases: []string{"clusters"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdGetClusterPool(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *GetClusterOptions) Run() error {
+	return o.Cmd.Help()
+}
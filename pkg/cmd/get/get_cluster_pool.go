@@ -0,0 +1,98 @@
+package get
+
+import (
+	"time"
+
+	clusters "github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// GetClusterPoolOptions the command line options
+type GetClusterPoolOptions struct {
+	GetOptions
+	ClusterOptions opts.ClusterOptions
+
+	Pool    string
+	Filters []string
+}
+
+var (
+	getClusterPoolLong = templates.LongDesc(`
+		Display the clusters of a pool and who holds the leases on them.
+`)
+
+	getClusterPoolExample = templates.Examples(`
+		# List the clusters of the e2e pool
+		jx get cluster pool
+
+		# List the clusters of another pool
+		jx get cluster pool -p bdd
+	`)
+)
+
+// NewCmdGetClusterPool creates the command
+func NewCmdGetClusterPool(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetClusterPoolOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "pool",
+		Short:   "Display the clusters of a pool and who holds the leases on them",
+		Long:    getClusterPoolLong,
+		Example: getClusterPoolExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.ClusterOptions.AddClusterFlags(cmd)
+	cmd.Flags().StringVarP(&options.Pool, "pool", "p", "e2e", "The name of the pool of clusters")
+	cmd.Flags().StringArrayVarP(&options.Filters, "filter", "f", nil, "The labels of the form 'key=value' to filter the clusters of the pool")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetClusterPoolOptions) Run() error {
+	client, err := o.ClusterOptions.CreateClient(true)
+	if err != nil {
+		return err
+	}
+	pool := clusters.NewPool(client, o.Pool)
+	pool.Labels = util.KeyValuesToMap(o.Filters)
+	list, err := pool.Clusters()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	table := o.CreateTable()
+	table.AddRow("NAME", "STATUS", "OWNER", "LEASE", "HEARTBEAT", "EXPIRES")
+	for _, c := range list {
+		lease := clusters.LeaseOf(c)
+		if lease == nil {
+			table.AddRow(c.Name, c.Status, "", "free", "", "")
+			continue
+		}
+		state := "leased"
+		if lease.IsExpired(now) {
+			state = util.ColorWarning("expired")
+		}
+		heartbeat := now.Sub(lease.Heartbeat).Round(time.Second).String() + " ago"
+		expires := lease.Expires().Sub(now).Round(time.Second).String()
+		if lease.IsExpired(now) {
+			expires = ""
+		}
+		table.AddRow(c.Name, c.Status, lease.Owner, state, heartbeat, expires)
+	}
+	table.Render()
+	return nil
+}
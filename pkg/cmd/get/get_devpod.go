@@ -111,6 +111,16 @@ func (o *GetDevPodOptions) Run() error {
 		}
 	}
 
+	suspendedNames, suspended, err := kube.GetSuspendedDevPods(client, ns, userName)
+	if err != nil {
+		return errors.Wrap(err, "getting the suspended DevPods")
+	}
+	for _, k := range suspendedNames {
+		cm := suspended[k]
+		d := time.Now().Sub(cm.CreationTimestamp.Time).Round(time.Second)
+		table.AddRow(k, cm.Labels[kube.LabelPodTemplate], d.String(), "Suspended")
+	}
+
 	table.Render()
 	return nil
 }
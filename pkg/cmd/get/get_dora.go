@@ -0,0 +1,282 @@
+package get
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/dora"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/reports"
+	"github.com/jenkins-x/jx/v2/pkg/table"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetDORAOptions the command line options
+type GetDORAOptions struct {
+	GetOptions
+
+	Since        time.Duration
+	Environment  string
+	HotfixWindow time.Duration
+	HistoryFile  string
+	ReportDate   string
+
+	gitProviders map[string]gits.GitProvider
+	gitClones    map[string]string
+}
+
+var (
+	getDORALong = templates.LongDesc(`
+		Display the DORA metrics of each application and the whole team.
+
+		The metrics are calculated from the promote steps of the PipelineActivity resources and the Release resources
+		created by 'jx step changelog':
+
+		* deployment frequency - the number of promotions into the environment per day
+		* lead time for changes - the median time from the changes being committed to their promotion into the environment
+		* change failure rate - the percentage of promotions which failed or were followed by a rollback or hotfix
+		* time to restore - the median time from a failed promotion to the next successful one
+`)
+
+	getDORAExample = templates.Examples(`
+		# display the DORA metrics for the production environment over the last 30 days
+		jx get dora
+
+		# display the DORA metrics for the staging environment over the last week as JSON
+		jx get dora --env staging --since 168h -o json
+
+		# publish the team metrics into the project history used by 'jx step blog'
+		jx get dora --history-file ../jx-blog/data/projectHistory.yml
+	`)
+)
+
+// NewCmdGetDORA creates the command
+func NewCmdGetDORA(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetDORAOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "dora [flags]",
+		Short:   "Display the DORA metrics of the applications and the team",
+		Long:    getDORALong,
+		Example: getDORAExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", time.Hour*24*30, "The time window to calculate the metrics for")
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "production", "The environment to calculate the metrics for")
+	cmd.Flags().DurationVarP(&options.HotfixWindow, "hotfix-window", "", time.Hour*24, "A promotion is counted as failed if a hotfix of the application is promoted within this time")
+	cmd.Flags().StringVarP(&options.HistoryFile, "history-file", "", "", "If specified the team metrics are published into the given project history file used by 'jx step blog'")
+	cmd.Flags().StringVarP(&options.ReportDate, "report-date", "", "", "The date of the project history report to publish the metrics into. Defaults to today. Should be a format: "+util.DateFormat)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetDORAOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+	releases, err := kube.GetOrderedReleases(jxClient, ns, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to list Releases in namespace %s", ns)
+	}
+
+	deployments := dora.FindDeployments(activities.Items, o.Environment)
+	defer o.removeGitClones()
+	dora.ApplyReleases(deployments, releases, o.commitTime)
+	dora.ClassifyFailures(deployments, o.HotfixWindow)
+
+	now := time.Now()
+	report := dora.Calculate(ns, o.Environment, deployments, now.Add(-o.Since), now)
+
+	if o.HistoryFile != "" {
+		err = o.publishHistory(report)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.Output != "" {
+		return o.renderResult(report, o.Output)
+	}
+	if len(report.Apps) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+
+	table := o.CreateTable()
+	table.AddRow("APP", "DEPLOYMENTS", "PER DAY", "LEAD TIME", "FAILURES", "FAILURE RATE", "TIME TO RESTORE")
+	for _, m := range report.Apps {
+		addDORARow(table, m.Name, m)
+	}
+	addDORARow(table, "TOTAL", report.Total)
+	table.Render()
+	return nil
+}
+
+func addDORARow(t table.Table, name string, m *dora.Metrics) {
+	t.AddRow(name, strconv.Itoa(m.Deployments), fmt.Sprintf("%.2f", m.DeploymentFrequency), formatStatsDuration(m.LeadTime),
+		strconv.Itoa(m.Failures), fmt.Sprintf("%.0f%%", m.ChangeFailureRate), formatStatsDuration(m.TimeToRestore))
+}
+
+// publishHistory stores the team metrics in the project history report of the report date
+func (o *GetDORAOptions) publishHistory(report *dora.Report) error {
+	reportDate := o.ReportDate
+	if reportDate == "" {
+		reportDate = util.FormatDate(time.Now())
+	}
+	historyService, history, err := reports.NewProjectHistoryService(o.HistoryFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the project history file %s", o.HistoryFile)
+	}
+	total := report.Total
+	history.UpdateDORAMetrics(reportDate, reports.DORAMetrics{
+		Deployments:          total.Deployments,
+		DeploymentsPerDay:    total.DeploymentFrequency,
+		LeadTimeMinutes:      total.LeadTime.Minutes(),
+		ChangeFailureRate:    total.ChangeFailureRate,
+		TimeToRestoreMinutes: total.TimeToRestore.Minutes(),
+	})
+	err = historyService.SaveHistory()
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the project history file %s", o.HistoryFile)
+	}
+	log.Logger().Infof("Published the DORA metrics for %s into %s", util.ColorInfo(reportDate), util.ColorInfo(o.HistoryFile))
+	return nil
+}
+
+// commitTime returns the time of the commit queried from the git provider of the repository of the deployment,
+// falling back to the git log of a clone of the repository when the provider does not return the commit time
+func (o *GetDORAOptions) commitTime(d *dora.Deployment, sha string) *time.Time {
+	if d.GitURL == "" {
+		return nil
+	}
+	committedAt, err := o.providerCommitTime(d, sha)
+	if err != nil {
+		log.Logger().Debugf("failed to find commit %s of %s using the git provider: %s", sha, d.GitURL, err.Error())
+	}
+	if committedAt != nil {
+		return committedAt
+	}
+	committedAt, err = o.gitLogCommitTime(d.GitURL, sha)
+	if err != nil {
+		log.Logger().Warnf("failed to find commit %s of %s: %s", sha, d.GitURL, err.Error())
+		return nil
+	}
+	return committedAt
+}
+
+// providerCommitTime returns the time of the commit from the git provider which is nil if the provider does not
+// return commit times
+func (o *GetDORAOptions) providerCommitTime(d *dora.Deployment, sha string) (*time.Time, error) {
+	provider, err := o.gitProvider(d.GitURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the git provider for %s", d.GitURL)
+	}
+	commits, err := provider.ListCommits(d.Owner, d.App, &gits.ListCommitsArguments{
+		SHA:     sha,
+		PerPage: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		if c.SHA == sha {
+			return c.CommittedAt, nil
+		}
+	}
+	return nil, nil
+}
+
+// gitLogCommitTime returns the committer time of the commit from a bare clone of the repository reusing the clones
+// of earlier deployments
+func (o *GetDORAOptions) gitLogCommitTime(gitURL string, sha string) (*time.Time, error) {
+	if o.gitClones == nil {
+		o.gitClones = map[string]string{}
+	}
+	dir := o.gitClones[gitURL]
+	if dir == "" {
+		var err error
+		dir, err = ioutil.TempDir("", "jx-get-dora-")
+		if err != nil {
+			return nil, errors.Wrap(err, "creating a temporary directory")
+		}
+		o.gitClones[gitURL] = dir
+		err = o.Git().CloneBare(dir, gitURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "git",
+		Args: []string{"log", "-1", "--format=%ct", sha},
+	}
+	out, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return nil, errors.Wrapf(err, "running git log for commit %s", sha)
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the commit time %q", out)
+	}
+	committedAt := time.Unix(seconds, 0).UTC()
+	return &committedAt, nil
+}
+
+// removeGitClones removes the clones of the repositories used to find commit times
+func (o *GetDORAOptions) removeGitClones() {
+	for _, dir := range o.gitClones {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			log.Logger().Warnf("failed to remove %s: %s", dir, err.Error())
+		}
+	}
+	o.gitClones = nil
+}
+
+// gitProvider returns the git provider for the git URL reusing the providers of earlier deployments
+func (o *GetDORAOptions) gitProvider(gitURL string) (gits.GitProvider, error) {
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		return nil, err
+	}
+	if o.gitProviders == nil {
+		o.gitProviders = map[string]gits.GitProvider{}
+	}
+	provider := o.gitProviders[gitInfo.Host]
+	if provider == nil {
+		provider, err = o.GitProviderForURL(gitURL, "git provider")
+		if err != nil {
+			return nil, err
+		}
+		o.gitProviders[gitInfo.Host] = provider
+	}
+	return provider, nil
+}
@@ -0,0 +1,69 @@
+// +build unit
+
+package get
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/dora"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitTimeFallsBackToGitLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-get-dora-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	committedAt := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	env := map[string]string{
+		"GIT_AUTHOR_NAME":     "test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_COMMITTER_DATE":  committedAt.Format(time.RFC3339),
+	}
+	for _, args := range [][]string{
+		{"init"},
+		{"commit", "--allow-empty", "-m", "initial commit"},
+	} {
+		cmd := util.Command{Dir: dir, Name: "git", Args: args, Env: env}
+		_, err = cmd.RunWithoutRetry()
+		require.NoError(t, err)
+	}
+	cmd := util.Command{Dir: dir, Name: "git", Args: []string{"rev-parse", "HEAD"}}
+	sha, err := cmd.RunWithoutRetry()
+	require.NoError(t, err)
+
+	// a provider which cannot list the commits like the GitLab and Gitea providers
+	gitInfo, err := gits.ParseGitURL(dir)
+	require.NoError(t, err)
+	commonOpts := opts.NewCommonOptionsWithFactory(nil)
+	commonOpts.SetGit(gits.NewGitCLI())
+	o := &GetDORAOptions{
+		GetOptions: GetOptions{
+			CommonOptions: &commonOpts,
+		},
+		gitProviders: map[string]gits.GitProvider{
+			gitInfo.Host: gits.NewFakeProvider(),
+		},
+	}
+	defer o.removeGitClones()
+
+	d := &dora.Deployment{
+		App:    "myapp",
+		Owner:  "myorg",
+		GitURL: dir,
+	}
+	actual := o.commitTime(d, sha)
+	require.NotNil(t, actual, "no commit time found")
+	assert.True(t, committedAt.Equal(*actual), "expected %s but was %s", committedAt, actual)
+
+	assert.Nil(t, o.commitTime(d, "0000000000000000000000000000000000000000"))
+}
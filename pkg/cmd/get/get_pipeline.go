@@ -44,6 +44,9 @@ var (
 
 		# Lists all the pipelines in a custom Jenkins App
 		jx get pipeline -m
+
+		# Display the duration statistics of the pipelines
+		jx get pipeline stats
 	`)
 )
 
@@ -74,6 +77,7 @@ func NewCmdGetPipeline(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.JenkinsSelector.UseCustomJenkins, "custom", "m", false, "List the pipelines in custom Jenkins App instead of the default execution engine in Jenkins X")
 	cmd.Flags().StringVarP(&options.JenkinsSelector.CustomJenkinsName, "name", "n", "", "The name of the custom Jenkins App if you don't wish to list the pipelines in the default execution engine in Jenkins X")
 
+	cmd.AddCommand(NewCmdGetPipelineStats(commonOpts))
 	return cmd
 }
 
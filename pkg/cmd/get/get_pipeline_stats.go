@@ -0,0 +1,200 @@
+package get
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildstats"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/reports"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetPipelineStatsOptions the command line options
+type GetPipelineStatsOptions struct {
+	GetOptions
+
+	Since     time.Duration
+	Filter    string
+	Kind      string
+	Trends    int
+	Rollup    bool
+	BucketURL string
+}
+
+var (
+	getPipelineStatsLong = templates.LongDesc(`
+		Display the duration statistics of pipelines, stages and steps over a time window.
+
+		The statistics are calculated from the current PipelineActivity resources along with the summaries of the
+		PipelineActivity resources which have been removed by 'jx gc activities --rollup'
+`)
+
+	getPipelineStatsExample = templates.Examples(`
+		# display the p50/p90 durations, queue times and success rate of each pipeline over the last week
+		jx get pipeline stats
+
+		# display the statistics of the stages of a pipeline over the last 30 days
+		jx get pipeline stats --kind stage --since 720h --filter myorg/myrepo/master
+
+		# display the statistics of the steps along with the 10 steps which have slowed down the most
+		jx get pipeline stats --kind step --trends 10
+	`)
+
+	statsKinds = []string{"pipeline", "stage", "step"}
+)
+
+// NewCmdGetPipelineStats creates the command
+func NewCmdGetPipelineStats(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetPipelineStatsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "stats [flags]",
+		Short:   "Display the duration statistics of pipelines, stages and steps",
+		Long:    getPipelineStatsLong,
+		Example: getPipelineStatsExample,
+		Aliases: []string{"stat", "statistics"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", time.Hour*24*7, "The time window to calculate the statistics for")
+	cmd.Flags().StringVarP(&options.Filter, "filter", "f", "", "Filters the pipelines, stages or steps which contain the given text")
+	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "pipeline", fmt.Sprintf("The kind of statistics to display. Possible values: %s", strings.Join(statsKinds, ", ")))
+	cmd.Flags().IntVarP(&options.Trends, "trends", "t", 5, "The number of steps which have slowed down the most to display")
+	cmd.Flags().BoolVarP(&options.Rollup, "rollup", "", true, "Include the summaries of garbage collected PipelineActivities stored in the team's storage bucket")
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL of the summaries. Defaults to the team's storage location for the 'reports' classifier")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetPipelineStatsOptions) Run() error {
+	if util.StringArrayIndex(statsKinds, o.Kind) < 0 {
+		return util.InvalidOption("kind", o.Kind, statsKinds)
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+	summaries := buildstats.SummarizeActivities(activities.Items)
+
+	if o.Rollup {
+		rollup, err := o.loadRollup(ns)
+		if err != nil {
+			log.Logger().Warnf("failed to load the summaries of garbage collected PipelineActivities: %s", err.Error())
+		} else if rollup != nil {
+			summaries = rollup.Merge(summaries)
+		}
+	}
+
+	now := time.Now()
+	report := buildstats.Calculate(summaries, now.Add(-o.Since), now)
+	report.Pipelines = o.filterStats(report.Pipelines)
+	report.Stages = o.filterStats(report.Stages)
+	report.Steps = o.filterStats(report.Steps)
+	report.Trends = o.filterTrends(report.Trends)
+
+	if o.Output != "" {
+		return o.renderResult(report, o.Output)
+	}
+
+	var stats []*buildstats.DurationStats
+	switch o.Kind {
+	case "stage":
+		stats = report.Stages
+	case "step":
+		stats = report.Steps
+	default:
+		stats = report.Pipelines
+	}
+	if len(stats) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+
+	table := o.CreateTable()
+	table.AddRow(strings.ToUpper(o.Kind), "BUILDS", "SUCCESS", "P50", "P90", "QUEUE P50", "QUEUE P90")
+	for _, s := range stats {
+		table.AddRow(s.Name, strconv.Itoa(s.Count), fmt.Sprintf("%.0f%%", s.SuccessRate),
+			formatStatsDuration(s.P50), formatStatsDuration(s.P90), formatStatsDuration(s.QueueP50), formatStatsDuration(s.QueueP90))
+	}
+	table.Render()
+
+	trends := report.SlowestGrowing(o.Trends)
+	if o.Trends <= 0 || len(trends) == 0 {
+		return nil
+	}
+	log.Logger().Infof("\nSteps which have slowed down the most:\n")
+	barReport := reports.NewTableBarReport(o.CreateTable(), "STEP", "P50 GROWTH")
+	for _, t := range trends {
+		barReport.AddText(t.Name, fmt.Sprintf("+%s (%.0f%%) %s -> %s", formatStatsDuration(t.Growth), t.GrowthPC, formatStatsDuration(t.Before), formatStatsDuration(t.After)))
+	}
+	return barReport.Render()
+}
+
+func (o *GetPipelineStatsOptions) loadRollup(ns string) (*buildstats.Rollup, error) {
+	bucketURL := o.BucketURL
+	if bucketURL == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return nil, err
+		}
+		bucketURL = settings.StorageLocationOrDefault(kube.ClassificationReports).BucketURL
+	}
+	if bucketURL == "" {
+		return nil, nil
+	}
+	return buildstats.LoadRollup(bucketURL, ns)
+}
+
+func (o *GetPipelineStatsOptions) filterStats(stats []*buildstats.DurationStats) []*buildstats.DurationStats {
+	if o.Filter == "" {
+		return stats
+	}
+	var answer []*buildstats.DurationStats
+	for _, s := range stats {
+		if strings.Contains(s.Name, o.Filter) {
+			answer = append(answer, s)
+		}
+	}
+	return answer
+}
+
+func (o *GetPipelineStatsOptions) filterTrends(trends []*buildstats.Trend) []*buildstats.Trend {
+	if o.Filter == "" {
+		return trends
+	}
+	var answer []*buildstats.Trend
+	for _, t := range trends {
+		if strings.Contains(t.Name, o.Filter) {
+			answer = append(answer, t)
+		}
+	}
+	return answer
+}
+
+func formatStatsDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
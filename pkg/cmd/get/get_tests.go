@@ -0,0 +1,150 @@
+package get
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/junit"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GetTestsOptions containers the CLI options
+type GetTestsOptions struct {
+	GetOptions
+
+	Owner      string
+	Repository string
+	BucketURL  string
+	Flaky      bool
+	Window     int
+}
+
+var (
+	getTestsLong = templates.LongDesc(`
+		Display the recent test results of a repository recorded via 'jx step report junit --record-history'
+`)
+
+	getTestsExample = templates.Examples(`
+		# List the results of the tests in the last build of the current repository
+		jx get tests
+
+		# List the flaky tests of a repository
+		jx get tests --flaky --owner myorg --repo myrepo
+	`)
+)
+
+// NewCmdGetTests creates the new command for: jx get tests
+func NewCmdGetTests(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetTestsOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "tests",
+		Short:   "Display the recent test results or the flaky tests of a repository",
+		Aliases: []string{"test"},
+		Long:    getTestsLong,
+		Example: getTestsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Owner, "owner", "", "", "The git owner of the repository. Defaults to the current git repository")
+	cmd.Flags().StringVarP(&options.Repository, "repo", "r", "", "The git repository name. Defaults to the current git repository")
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL of the test history. Defaults to the team's storage location for the 'tests' classifier")
+	cmd.Flags().BoolVarP(&options.Flaky, "flaky", "", false, "Only display the flaky tests")
+	cmd.Flags().IntVarP(&options.Window, "window", "w", junit.DefaultFlakyWindow, "The number of recent builds to look at")
+
+	options.AddGetFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetTestsOptions) Run() error {
+	if o.Owner == "" || o.Repository == "" {
+		gitInfo, err := o.FindGitInfo("")
+		if err != nil {
+			return errors.Wrap(err, "failed to find the git repository. Try specifying --owner and --repo")
+		}
+		if o.Owner == "" {
+			o.Owner = gitInfo.Organisation
+		}
+		if o.Repository == "" {
+			o.Repository = gitInfo.Name
+		}
+	}
+	if o.BucketURL == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to load the team settings")
+		}
+		o.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationTests).BucketURL
+	}
+	if o.BucketURL == "" {
+		return util.MissingOption("bucket-url")
+	}
+
+	history, err := junit.LoadHistory(o.BucketURL, o.Owner, o.Repository)
+	if err != nil {
+		return err
+	}
+	if len(history.Runs) == 0 {
+		log.Logger().Infof("No test history found for %s/%s", util.ColorInfo(o.Owner), util.ColorInfo(o.Repository))
+		return nil
+	}
+
+	if o.Flaky {
+		flakes := history.FlakyTests(o.Window)
+		if o.Output != "" {
+			return o.renderResult(flakes, o.Output)
+		}
+		if len(flakes) == 0 {
+			log.Logger().Infof("No flaky tests found in the last %d builds of %s/%s", o.Window, o.Owner, o.Repository)
+			return nil
+		}
+		table := o.CreateTable()
+		table.AddRow("TEST", "PASSED", "FAILED", "FLIPS", "SAME COMMIT", "LAST FAILURE")
+		for _, f := range flakes {
+			lastFailure := ""
+			if f.LastFailedBuild != "" {
+				lastFailure = fmt.Sprintf("%s #%s", f.LastFailedRef, f.LastFailedBuild)
+			}
+			table.AddRow(f.Name, strconv.Itoa(f.Passes), strconv.Itoa(f.Failures), strconv.Itoa(f.Flips), fmt.Sprintf("%t", f.SameCommit), lastFailure)
+		}
+		table.Render()
+		return nil
+	}
+
+	last := history.Runs[len(history.Runs)-1]
+	if o.Output != "" {
+		return o.renderResult(last, o.Output)
+	}
+	names := []string{}
+	for name := range last.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	table := o.CreateTable()
+	table.AddRow("TEST", "STATUS")
+	for _, name := range names {
+		status := string(last.Results[name])
+		if last.Results[name] == junit.TestStatusFailed {
+			status = util.ColorError(status)
+		}
+		table.AddRow(name, status)
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,298 @@
+package get
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/usage"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetUsageOptions the command line options
+type GetUsageOptions struct {
+	GetOptions
+
+	Since         time.Duration
+	Branches      bool
+	AllTeams      bool
+	DefaultCPU    string
+	DefaultMemory string
+	PriceSheet    string
+	PostChat      bool
+	ChatURL       string
+	Channel       string
+	Top           int
+}
+
+var (
+	getUsageLong = templates.LongDesc(`
+		Display the CPU and memory consumed by the pipeline builds and preview environments of each repository.
+
+		The usage of a build is the CPU and memory requested by the Tasks generated for each stage of the pipeline
+		multiplied by the running time of the stage recorded in the PipelineActivity. If the Task of a stage has
+		been garbage collected the default requests are used instead.
+
+		The usage of a preview environment is the CPU and memory requested by the pods in its namespace multiplied
+		by the time they have been running.
+
+		If a price sheet YAML file is specified the cost of the usage is calculated. e.g.
+
+			currency: USD
+			cpuCoreHour: 0.033
+			memoryGBHour: 0.0045
+			previewDiscount: 70
+`)
+
+	getUsageExample = templates.Examples(`
+		# display the usage of each repository over the last week
+		jx get usage
+
+		# display the usage of each branch of each repository of all teams over the last 30 days
+		jx get usage --branches --all-teams --since 720h
+
+		# display the cost of the usage
+		jx get usage --price-sheet prices.yaml
+
+		# post a weekly summary of the usage to a chat channel, e.g. from a weekly CronJob
+		jx get usage --price-sheet prices.yaml --post-chat --chat-url https://myorg.slack.com --channel "#jenkins-x"
+	`)
+)
+
+// NewCmdGetUsage creates the command
+func NewCmdGetUsage(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetUsageOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "usage [flags]",
+		Short:   "Display the CPU and memory used by pipeline builds and preview environments",
+		Long:    getUsageLong,
+		Example: getUsageExample,
+		Aliases: []string{"cost", "costs"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.AddGetFlags(cmd)
+
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", time.Hour*24*7, "The time window to calculate the usage for")
+	cmd.Flags().BoolVarP(&options.Branches, "branches", "", false, "Display the usage of each branch rather than each repository")
+	cmd.Flags().BoolVarP(&options.AllTeams, "all-teams", "", false, "Display the usage of all teams rather than the current team")
+	cmd.Flags().StringVarP(&options.DefaultCPU, "default-cpu", "", "1", "The CPU requests of a stage whose Task has been removed")
+	cmd.Flags().StringVarP(&options.DefaultMemory, "default-memory", "", "1Gi", "The memory requests of a stage whose Task has been removed")
+	cmd.Flags().StringVarP(&options.PriceSheet, "price-sheet", "p", "", "The YAML file of the prices used to calculate the cost of the usage")
+	cmd.Flags().BoolVarP(&options.PostChat, "post-chat", "", false, "Post a summary of the usage to the chat channel")
+	cmd.Flags().StringVarP(&options.ChatURL, "chat-url", "", "", "The URL of the chat server to post the summary to")
+	cmd.Flags().StringVarP(&options.Channel, "channel", "", "", "The chat channel to post the summary to")
+	cmd.Flags().IntVarP(&options.Top, "top", "", 10, "The number of repositories to include in the chat summary")
+	return cmd
+}
+
+// Run implements this command
+func (o *GetUsageOptions) Run() error {
+	if o.PostChat {
+		if o.ChatURL == "" {
+			return util.MissingOption("chat-url")
+		}
+		if o.Channel == "" {
+			return util.MissingOption("channel")
+		}
+	}
+	defaultRequests, err := o.defaultRequests()
+	if err != nil {
+		return err
+	}
+	var sheet *usage.PriceSheet
+	if o.PriceSheet != "" {
+		sheet, err = usage.LoadPriceSheet(o.PriceSheet)
+		if err != nil {
+			return err
+		}
+	}
+
+	kubeClient, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	teams := []string{devNs}
+	if o.AllTeams {
+		_, teams, err = kube.GetTeams(kubeClient)
+		if err != nil {
+			return errors.Wrap(err, "failed to find the teams")
+		}
+	}
+
+	now := time.Now()
+	since := now.Add(-o.Since)
+	var usages []*usage.Usage
+	for _, team := range teams {
+		teamUsages, err := o.teamUsage(team, defaultRequests, since, now)
+		if err != nil {
+			return err
+		}
+		usages = append(usages, teamUsages...)
+	}
+	if sheet != nil {
+		sheet.Apply(usages)
+	}
+	if !o.Branches {
+		usages = usage.Summarize(usages)
+	}
+
+	if o.PostChat {
+		err = o.postSummary(devNs, usages, sheet, since, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.Output != "" {
+		return o.renderResult(usages, o.Output)
+	}
+	if len(usages) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+
+	table := o.CreateTable()
+	titles := []string{"TEAM", "KIND", "REPOSITORY"}
+	if o.Branches {
+		titles = append(titles, "BRANCH")
+	}
+	titles = append(titles, "RUNS", "CPU CORE HOURS", "MEMORY GB HOURS")
+	if sheet != nil {
+		titles = append(titles, "COST")
+	}
+	table.AddRow(titles...)
+	missing := 0
+	for _, u := range usages {
+		table.AddRow(o.usageRow(u, sheet)...)
+		missing += u.MissingRequest
+	}
+	table.AddRow(o.usageRow(usage.Total("", usages), sheet)...)
+	table.Render()
+
+	if missing > 0 {
+		log.Logger().Warnf("the default requests were used for %d stages whose Tasks have been removed", missing)
+	}
+	return nil
+}
+
+func (o *GetUsageOptions) usageRow(u *usage.Usage, sheet *usage.PriceSheet) []string {
+	repository := u.Repository
+	if u.Owner != "" {
+		repository = u.Owner + "/" + u.Repository
+	}
+	row := []string{u.Team, u.Kind, repository}
+	if o.Branches {
+		row = append(row, u.Branch)
+	}
+	row = append(row, strconv.Itoa(u.Runs), fmt.Sprintf("%.2f", u.CPUCoreHours), fmt.Sprintf("%.2f", u.MemoryGBHours))
+	if sheet != nil {
+		row = append(row, sheet.FormatCost(u.Cost))
+	}
+	return row
+}
+
+// teamUsage returns the usage of the builds and previews in the dev namespace of the team
+func (o *GetUsageOptions) teamUsage(ns string, defaultRequests usage.Requests, since time.Time, until time.Time) ([]*usage.Usage, error) {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	tektonClient, _, err := o.TektonClient()
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+	tasks, err := tektonClient.TektonV1alpha1().Tasks(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list Tasks in namespace %s", ns)
+	}
+	answer := usage.BuildUsage(ns, activities.Items, usage.NewTaskIndex(tasks.Items), defaultRequests, since, until)
+
+	envs, err := jxClient.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list Environments in namespace %s", ns)
+	}
+	var previews []v1.Environment
+	podsByNamespace := map[string][]corev1.Pod{}
+	for _, env := range envs.Items {
+		if env.Spec.Kind != v1.EnvironmentKindTypePreview || env.Spec.Namespace == "" {
+			continue
+		}
+		previews = append(previews, env)
+		pods, err := kubeClient.CoreV1().Pods(env.Spec.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			log.Logger().Warnf("failed to list pods in preview namespace %s: %s", env.Spec.Namespace, err.Error())
+			continue
+		}
+		podsByNamespace[env.Spec.Namespace] = pods.Items
+	}
+	answer = append(answer, usage.PreviewUsage(ns, previews, podsByNamespace, since, until)...)
+	return answer, nil
+}
+
+func (o *GetUsageOptions) defaultRequests() (usage.Requests, error) {
+	answer := usage.Requests{}
+	cpu, err := resource.ParseQuantity(o.DefaultCPU)
+	if err != nil {
+		return answer, util.InvalidOptionError("default-cpu", o.DefaultCPU, err)
+	}
+	memory, err := resource.ParseQuantity(o.DefaultMemory)
+	if err != nil {
+		return answer, util.InvalidOptionError("default-memory", o.DefaultMemory, err)
+	}
+	container := &corev1.Container{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    cpu,
+				corev1.ResourceMemory: memory,
+			},
+		},
+	}
+	return usage.ContainerRequests(container), nil
+}
+
+func (o *GetUsageOptions) postSummary(team string, usages []*usage.Usage, sheet *usage.PriceSheet, since time.Time, until time.Time) error {
+	provider, err := o.CreateChatProvider(&config.ChatConfig{
+		URL: o.ChatURL,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the chat provider for %s", o.ChatURL)
+	}
+	text := usage.SummaryText(team, usages, sheet, since, until, o.Top)
+	err = provider.PostMessage(o.Channel, text)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Posted the usage summary to %s", util.ColorInfo(o.Channel))
+	return nil
+}
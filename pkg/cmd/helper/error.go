@@ -17,6 +17,8 @@ const (
 
 var fatalErrHandler = Fatal
 
+var beforeFatal func(error)
+
 // BehaviorOnFatal allows you to override the default behavior when a fatal
 // error occurs, which is to call os.Exit(code). You can pass 'panic' as a function
 // here if you prefer the panic() over os.Exit(1).
@@ -29,6 +31,13 @@ func DefaultBehaviorOnFatal() {
 	fatalErrHandler = Fatal
 }
 
+// BeforeFatal registers a function which is invoked with the error before the behavior on a fatal error, e.g. to
+// record the failure of a command. It replaces any previously registered function but not the behavior on a fatal
+// error. Pass nil to remove it.
+func BeforeFatal(f func(error)) {
+	beforeFatal = f
+}
+
 // Fatal prints the message (if provided) and then exits. If V(2) or greater,
 // glog.Logger().Fatal is invoked for extended information.
 func Fatal(msg string, code int) {
@@ -52,6 +61,9 @@ var ErrExit = fmt.Errorf("exit")
 // This method is generic to the command in use and may be used by non-Kubectl
 // commands.
 func CheckErr(err error) {
+	if err != nil && beforeFatal != nil {
+		beforeFatal(err)
+	}
 	checkErr(err, fatalErrHandler)
 }
 
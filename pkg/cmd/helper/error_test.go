@@ -0,0 +1,32 @@
+// +build unit
+
+package helper_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeforeFatalKeepsBehaviorOnFatal(t *testing.T) {
+	var fatalMsg string
+	var fatalErr error
+	helper.BehaviorOnFatal(func(msg string, code int) {
+		fatalMsg = msg
+	})
+	defer helper.DefaultBehaviorOnFatal()
+	helper.BeforeFatal(func(err error) {
+		fatalErr = err
+	})
+	defer helper.BeforeFatal(nil)
+
+	helper.CheckErr(nil)
+	assert.Nil(t, fatalErr)
+	assert.Empty(t, fatalMsg)
+
+	helper.CheckErr(errors.New("boom"))
+	assert.EqualError(t, fatalErr, "boom")
+	assert.Equal(t, "error: boom", fatalMsg, "the behavior on fatal errors should still be invoked")
+}
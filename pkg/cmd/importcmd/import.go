@@ -125,7 +125,7 @@ var (
 		jx import --github --org myname --all --filter foo 
 		`)
 
-	deployKinds = []string{opts.DeployKindKnative, opts.DeployKindDefault}
+	deployKinds = []string{opts.DeployKindKnative, opts.DeployKindDefault, opts.DeployKindKustomize}
 
 	removeSourceRepositoryAnnotations = []string{"kubectl.kubernetes.io/last-applied-configuration", "jenkins.io/chart"}
 )
@@ -547,7 +547,6 @@ func (options *ImportOptions) DraftCreate() error {
 		return err
 	}
 
-	err = options.modifyDeployKind()
 	err = options.modifyDeployKind()
 	if err != nil {
 		return err
@@ -1738,6 +1737,9 @@ func (options *ImportOptions) modifyDeployKind() error {
 	if deployKind == "" {
 		return nil
 	}
+	if deployKind == opts.DeployKindKustomize {
+		return options.generateKustomizeLayout()
+	}
 	dopts := options.DeployOptions
 
 	copy := *options.CommonOptions
@@ -1756,6 +1758,26 @@ func (options *ImportOptions) modifyDeployKind() error {
 	return nil
 }
 
+// generateKustomizeLayout generates the kustomize base and overlays of the project instead of the charts generated
+// by the build pack, unless the build pack provides its own kustomize layout
+func (options *ImportOptions) generateKustomizeLayout() error {
+	dir := options.Dir
+	app := options.AppName
+	if app == "" {
+		app = naming.ToValidName(filepath.Base(dir))
+	}
+	err := options.GenerateKustomizeLayout(dir, app)
+	if err != nil {
+		return err
+	}
+	chartsDir := filepath.Join(dir, "charts")
+	err = os.RemoveAll(chartsDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove the charts directory %s", chartsDir)
+	}
+	return nil
+}
+
 func isValidPreviewNamespace(ns interface{}, envs *v1.EnvironmentList) error {
 	for _, env := range envs.Items {
 		if ns == env.Spec.Namespace && env.Spec.Kind.IsPermanent() {
@@ -2,41 +2,57 @@ package cmd
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 
-	"time"
-
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 )
 
 type MetricsOptions struct {
 	*opts.CommonOptions
 
-	Namespace string
-	Filter    string
-	Duration  string
-	Selector  string
-	Metric    string
+	Namespace           string
+	Filter              string
+	Duration            string
+	Step                string
+	Headroom            int
+	Width               int
+	PrometheusNamespace string
+	PrometheusService   string
+
+	// Selector and Metric are deprecated and ignored
+	Selector string
+	Metric   string
 }
 
 var (
 	MetricsLong = templates.LongDesc(`
-		Gets the metrics of the newest pod for a Deployment.
+		Gets the CPU, memory and restart metrics of the pods of a Deployment, compares them to the resource requests
+		and limits of the pods and suggests right sized requests.
+
+		The metrics over the --duration are queried from the Prometheus addon, which must be installed to use --duration.
+		Otherwise the current metrics are queried from the metrics.k8s.io API of metrics-server.
 
 `)
 
 	MetricsExample = templates.Examples(`
-		# displays metrics of the latest pod in deployment myapp
+		# displays the current metrics of the pods in deployment myapp
 		jx metrics myapp
 
-		# Tails the metrics of the container foo in the latest pod in deployment myapp
-		jx metrics myapp -c foo
+		# displays the metrics of the pods in deployment myapp over the last 6 hours
+		jx metrics myapp -d 6h
+
+		# pick the deployment to display the metrics of over the last day in 30 minute steps
+		jx metrics -d 24h --step 30m
 `)
 )
 
@@ -46,7 +62,7 @@ func NewCmdMetrics(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	cmd := &cobra.Command{
 		Use:     "metrics [deployment]",
-		Short:   "Gets the metrics of the latest pod for a deployment",
+		Short:   "Gets the metrics of the pods of a deployment and suggests right sized requests",
 		Long:    MetricsLong,
 		Example: MetricsExample,
 		Aliases: []string{"metrics"},
@@ -60,9 +76,16 @@ func NewCmdMetrics(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "the namespace to look for the Deployment. Defaults to the current namespace")
 	cmd.Flags().StringVarP(&options.Filter, "filter", "f", "", "Filters the available deployments if no deployment argument is provided")
-	cmd.Flags().StringVarP(&options.Duration, "duration", "d", "", "The duration to query (e.g. 1.5h, 20s, 5m")
+	cmd.Flags().StringVarP(&options.Duration, "duration", "d", "", "The duration to query (e.g. 1.5h, 20s, 5m). If not specified the current metrics are displayed")
+	cmd.Flags().StringVarP(&options.Step, "step", "", "", "The interval between samples. Defaults to a sixtieth of the duration")
+	cmd.Flags().IntVarP(&options.Headroom, "headroom", "", 20, "The percentage of headroom to add to the observed usage when suggesting requests")
+	cmd.Flags().IntVarP(&options.Width, "width", "w", 30, "The maximum width of the sparklines")
+	cmd.Flags().StringVarP(&options.PrometheusNamespace, "prometheus-namespace", "", kube.DefaultNamespace, "The namespace of the Prometheus addon")
+	cmd.Flags().StringVarP(&options.PrometheusService, "prometheus-service", "", "prometheus-server", "The name of the Prometheus server service")
 	cmd.Flags().StringVarP(&options.Selector, "selector", "s", "", "The pod selector to use to query for pods")
 	cmd.Flags().StringVarP(&options.Metric, "metric", "m", "", "The heapster metric name")
+	_ = cmd.Flags().MarkDeprecated("selector", "it is ignored as the metrics of all the pods of the Deployment are displayed. This will be removed in a future release")
+	_ = cmd.Flags().MarkDeprecated("metric", "it is ignored as the CPU, memory and restart metrics are displayed. This will be removed in a future release")
 	return cmd
 }
 
@@ -73,80 +96,122 @@ func (o *MetricsOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	metricsClient, err := o.GetFactory().CreateMetricsClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the metrics client")
+	}
 
 	ns := o.Namespace
 	if ns == "" {
 		ns = curNs
 	}
-	pod := ""
-	selector := o.Selector
-	if selector == "" {
-		names, err := kube.GetDeploymentNames(client, ns, o.Filter)
-		if err != nil {
-			return err
-		}
-		name := ""
-		if len(args) == 0 {
-			if len(names) == 0 {
-				return fmt.Errorf("There are no Deployments running")
-			}
-			n, err := util.PickName(names, "Pick Deployment:", "", o.GetIOFileHandles())
-			if err != nil {
-				return err
-			}
-			name = n
-		} else {
-			name = args[0]
-			if util.StringArrayIndex(names, name) < 0 {
-				return util.InvalidArg(name, names)
-			}
+	names, err := kube.GetDeploymentNames(client, ns, o.Filter)
+	if err != nil {
+		return err
+	}
+	name := ""
+	if len(args) == 0 {
+		if len(names) == 0 {
+			return fmt.Errorf("There are no Deployments running")
 		}
-
-		p, err := o.WaitForReadyPodForDeployment(client, ns, name, names, false)
+		n, err := util.PickName(names, "Pick Deployment:", "", o.GetIOFileHandles())
 		if err != nil {
 			return err
 		}
-
-		pod = p
-		if pod == "" {
-			return fmt.Errorf("No pod found for namespace %s with name %s", ns, name)
+		name = n
+	} else {
+		name = args[0]
+		if util.StringArrayIndex(names, name) < 0 {
+			return util.InvalidArg(name, names)
 		}
 	}
 
-	if o.Duration != "" || o.Metric != "" {
-		start := ""
-		end := ""
-		if o.Duration != "" && pod != "" {
-			d, err := time.ParseDuration(o.Duration)
-			if err != nil {
-				return fmt.Errorf("Failed to parse duration %s due to %s", o.Duration, err)
-			}
-			e := time.Now()
-			s := e.Add(-d)
-			start = s.Format(time.RFC3339)
-			end = e.Format(time.RFC3339)
-		}
-
-		heapster := kube.HeapterConfig{
-			KubeClient: client,
+	var duration, step time.Duration
+	if o.Duration != "" {
+		duration, err = time.ParseDuration(o.Duration)
+		if err != nil {
+			return util.InvalidOptionError("duration", o.Duration, err)
 		}
-		data, err := heapster.GetPodMetrics(ns, pod, selector, o.Metric, start, end)
+		step = duration / 60
+	}
+	if o.Step != "" {
+		step, err = time.ParseDuration(o.Step)
 		if err != nil {
-			return err
+			return util.InvalidOptionError("step", o.Step, err)
 		}
-		log.Logger().Infof("%s", string(data))
-		return nil
 	}
 
-	if selector != "" {
-		args = []string{"top", "pod", "--selector", selector, "--namespace", ns}
-	} else {
-		args = []string{"top", "pod", pod, "--namespace", ns}
+	prometheus := &kube.PrometheusConfig{
+		KubeClient: client,
+		Namespace:  o.PrometheusNamespace,
+		Service:    o.PrometheusService,
 	}
-
-	err = o.RunCommand("kubectl", args...)
+	metrics, err := kube.GetDeploymentMetrics(client, metricsClient, prometheus, ns, name, duration, step)
 	if err != nil {
 		return err
 	}
+	o.renderMetrics(metrics)
 	return nil
 }
+
+func (o *MetricsOptions) renderMetrics(metrics *kube.DeploymentMetrics) {
+	suggested := metrics.RecommendedRequests(o.Headroom)
+
+	table := o.CreateTable()
+	table.AddRow("METRIC", "USAGE", "MIN", "AVG", "MAX", "REQUEST", "LIMIT", "SUGGESTED")
+	table.AddRow("CPU", util.Sparkline(metrics.CPU.Values, o.Width),
+		formatCPU(metrics.CPU.Min()), formatCPU(metrics.CPU.Average()), formatCPU(metrics.CPU.Max()),
+		formatQuantity(metrics.Requests, v1.ResourceCPU), formatQuantity(metrics.Limits, v1.ResourceCPU), formatQuantity(suggested, v1.ResourceCPU))
+	table.AddRow("Memory", util.Sparkline(metrics.Memory.Values, o.Width),
+		formatMemory(metrics.Memory.Min()), formatMemory(metrics.Memory.Average()), formatMemory(metrics.Memory.Max()),
+		formatQuantity(metrics.Requests, v1.ResourceMemory), formatQuantity(metrics.Limits, v1.ResourceMemory), formatQuantity(suggested, v1.ResourceMemory))
+	table.AddRow("Restarts", util.Sparkline(metrics.Restarts.Values, o.Width),
+		fmt.Sprintf("%d", int64(metrics.Restarts.Min())), fmt.Sprintf("%.1f", metrics.Restarts.Average()), fmt.Sprintf("%d", int64(metrics.Restarts.Max())),
+		"", "", "")
+	table.Render()
+
+	o.suggest("CPU", v1.ResourceCPU, metrics.CPU.Max(), metrics, suggested)
+	o.suggest("memory", v1.ResourceMemory, metrics.Memory.Max(), metrics, suggested)
+	if metrics.Restarts.Max() > metrics.Restarts.Min() {
+		log.Logger().Warnf("The pods of Deployment %s restarted %d times", metrics.Name, int64(metrics.Restarts.Max()-metrics.Restarts.Min()))
+	}
+}
+
+// suggest logs how the request of the resource compares to the suggested request and warns if the maximum usage is
+// close to the limit. The maximum usage is in cores for CPU and bytes for memory
+func (o *MetricsOptions) suggest(label string, name v1.ResourceName, max float64, metrics *kube.DeploymentMetrics, suggested v1.ResourceList) {
+	suggestion, ok := suggested[name]
+	if !ok {
+		return
+	}
+	request, ok := metrics.Requests[name]
+	switch {
+	case !ok:
+		log.Logger().Warnf("Deployment %s has no %s request, suggest a request of %s", metrics.Name, label, util.ColorInfo(suggestion.String()))
+	case suggestion.Cmp(request) > 0:
+		log.Logger().Warnf("Deployment %s is under-provisioned on %s, suggest increasing the request from %s to %s", metrics.Name, label, request.String(), util.ColorInfo(suggestion.String()))
+	case suggestion.MilliValue()*2 < request.MilliValue():
+		log.Logger().Infof("Deployment %s is over-provisioned on %s, suggest reducing the request from %s to %s", metrics.Name, label, request.String(), util.ColorInfo(suggestion.String()))
+	}
+	limit, ok := metrics.Limits[name]
+	if ok && limit.MilliValue() > 0 && max*1000 >= float64(limit.MilliValue())*0.9 {
+		percent := int64(math.Round(max * 1000 * 100 / float64(limit.MilliValue())))
+		log.Logger().Warnf("Deployment %s used %s of its %s limit of %s", metrics.Name, util.ColorWarning(fmt.Sprintf("%d%%", percent)), label, limit.String())
+	}
+}
+
+func formatCPU(cores float64) string {
+	return fmt.Sprintf("%dm", int64(math.Round(cores*1000)))
+}
+
+func formatMemory(bytes float64) string {
+	return fmt.Sprintf("%dMi", int64(math.Round(bytes/(1024*1024))))
+}
+
+func formatQuantity(list v1.ResourceList, name v1.ResourceName) string {
+	q, ok := list[name]
+	if !ok {
+		return ""
+	}
+	return q.String()
+}
@@ -0,0 +1,179 @@
+package opts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/audit"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AnnotationAudit the annotation of a command which is set to "false" to exclude the command and its sub
+	// commands from the audit log
+	AnnotationAudit = "jenkins.io/audit"
+)
+
+// secretFlagNames the words in the names of flags whose values are masked in the audit log
+var secretFlagNames = []string{"password", "token", "secret"}
+
+// DisableAudit excludes the commands and their sub commands, such as read only commands, from the audit log
+func DisableAudit(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = map[string]string{}
+		}
+		cmd.Annotations[AnnotationAudit] = "false"
+	}
+}
+
+// IsAudited returns true if running the command is recorded in the audit log. Every command is audited unless
+// it or one of its parents disables the audit log or it only displays help
+func IsAudited(cmd *cobra.Command) bool {
+	if cmd == nil || cmd.HasSubCommands() || cmd.Name() == "help" {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[AnnotationAudit] == "false" {
+			return false
+		}
+	}
+	return true
+}
+
+// Audit records who ran the command, its arguments and its outcome in the audit log of the team if the command
+// is audited. It is called for every command by the root command once the command has completed.
+// A failure to record the entry is only logged so that it never changes the outcome of the command
+func (o *CommonOptions) Audit(cmd *cobra.Command, args []string, cmdErr error) {
+	if !IsAudited(cmd) {
+		return
+	}
+	kubeClient, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		log.Logger().Debugf("not recording the audit log entry as there is no connection to the cluster: %s", err)
+		return
+	}
+	err = o.recordAudit(kubeClient, devNs, cmd, args, cmdErr)
+	if err != nil {
+		log.Logger().Warnf("failed to record the audit log entry: %s", err)
+	}
+}
+
+func (o *CommonOptions) recordAudit(kubeClient kubernetes.Interface, devNs string, cmd *cobra.Command, args []string, cmdErr error) error {
+	namespace, environment := auditTarget(cmd, args)
+	if namespace == "" {
+		namespace = devNs
+	}
+	entry := &audit.Entry{
+		GitUser:     o.auditGitUser(),
+		KubeUser:    o.auditKubeUser(),
+		Command:     cmd.CommandPath(),
+		Namespace:   namespace,
+		Environment: environment,
+		Outcome:     audit.OutcomeSucceeded,
+	}
+	if cmdErr != nil {
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = cmdErr.Error()
+	}
+	var secretWords []string
+	entry.Args, secretWords = auditArgs(cmd, args)
+
+	// lets mask the values of the flags which hold secrets rather than loading every secret of the team
+	masker := &kube.LogMasker{}
+	for _, word := range secretWords {
+		masker.LoadWord(word)
+	}
+
+	recorder := &audit.Recorder{
+		KubeClient: kubeClient,
+		Namespace:  devNs,
+		Masker:     masker,
+	}
+	settings, err := o.TeamSettings()
+	if err != nil {
+		log.Logger().Debugf("not storing the audit log entry in a bucket as the team settings could not be loaded: %s", err)
+	} else {
+		recorder.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationAudit).BucketURL
+	}
+	return recorder.Record(entry)
+}
+
+// auditTarget returns the namespace and environment the command changed from its flags. Commands on environments
+// target the environments in their arguments
+func auditTarget(cmd *cobra.Command, args []string) (string, string) {
+	namespace := stringFlagValue(cmd, OptionNamespace)
+	environment := stringFlagValue(cmd, OptionEnvironment)
+	if environment == "" && cmd.Name() == "environment" {
+		environment = strings.Join(args, ",")
+	}
+	return namespace, environment
+}
+
+func stringFlagValue(cmd *cobra.Command, name string) string {
+	f := cmd.Flags().Lookup(name)
+	if f == nil || f.Value.Type() != "string" {
+		return ""
+	}
+	return f.Value.String()
+}
+
+func isSecretFlag(name string) bool {
+	for _, secret := range secretFlagNames {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditArgs returns the arguments and the flags set on the command along with the values of the secret flags
+// which are to be masked
+func auditArgs(cmd *cobra.Command, args []string) ([]string, []string) {
+	answer := append([]string{}, args...)
+	var secretWords []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		value := f.Value.String()
+		if isSecretFlag(f.Name) {
+			secretWords = append(secretWords, value)
+		}
+		answer = append(answer, fmt.Sprintf("--%s=%s", f.Name, value))
+	})
+	return answer, secretWords
+}
+
+// auditGitUser returns the git identity of the current user
+func (o *CommonOptions) auditGitUser() string {
+	name, err := o.Git().Username("")
+	if err != nil {
+		log.Logger().Debugf("failed to find the git user name: %s", err)
+	}
+	email, err := o.Git().Email("")
+	if err != nil {
+		log.Logger().Debugf("failed to find the git user email: %s", err)
+	}
+	name = strings.TrimSpace(name)
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return name
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s <%s>", name, email))
+}
+
+// auditKubeUser returns the user of the current kubernetes context
+func (o *CommonOptions) auditKubeUser() string {
+	config, _, err := o.Kube().LoadConfig()
+	if err != nil {
+		log.Logger().Debugf("failed to load the kube config: %s", err)
+		return ""
+	}
+	context := kube.CurrentContext(config)
+	if context == nil {
+		return ""
+	}
+	return context.AuthInfo
+}
@@ -0,0 +1,92 @@
+// +build unit
+
+package opts
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/audit"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubemocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func newAuditTestCommands() (*cobra.Command, *cobra.Command, *cobra.Command, *cobra.Command) {
+	run := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "jx", Run: run}
+	get := &cobra.Command{Use: "get", Run: run}
+	getApps := &cobra.Command{Use: "apps", Run: run}
+	get.AddCommand(getApps)
+	boot := &cobra.Command{Use: "boot", Run: run}
+	promote := &cobra.Command{Use: "promote", Run: run}
+	promote.Flags().StringP(OptionEnvironment, "e", "", "")
+	promote.Flags().StringP(OptionNamespace, "n", "", "")
+	promote.Flags().StringP("api-token", "", "", "")
+	promote.Flags().BoolP("batch-mode", "b", false, "")
+	root.AddCommand(get, boot, promote)
+	DisableAudit(get)
+	return root, getApps, boot, promote
+}
+
+func TestIsAudited(t *testing.T) {
+	t.Parallel()
+
+	root, getApps, boot, promote := newAuditTestCommands()
+	assert.False(t, IsAudited(root), "commands with sub commands only display help")
+	assert.False(t, IsAudited(getApps), "commands of groups which disable the audit log should not be audited")
+	assert.True(t, IsAudited(boot))
+	assert.True(t, IsAudited(promote))
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	ns := "jx"
+	kubeClient := kubemocks.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-pipeline-git-github", Namespace: ns},
+		Data:       map[string][]byte{"password": []byte("mysecrettoken")},
+	})
+	git := gits.NewGitFake().(*gits.GitFake)
+	git.GitUser.Name = "James Strachan"
+	o := &CommonOptions{}
+	o.SetDevNamespace(ns)
+	o.SetKubeClient(kubeClient)
+	o.SetGit(git)
+	o.ModifyDevEnvironmentFn = func(callback func(env *v1.Environment) error) error {
+		return fmt.Errorf("no dev environment")
+	}
+	_, getApps, boot, promote := newAuditTestCommands()
+
+	o.Audit(getApps, nil, nil)
+	o.Audit(boot, nil, nil)
+
+	err := promote.ParseFlags([]string{"-b", "--env", "production", "-n", "jx-production", "--api-token", "mytoken"})
+	require.NoError(t, err)
+	o.Audit(promote, []string{"myapp"}, fmt.Errorf("failed to push with mytoken"))
+	for _, action := range kubeClient.Actions() {
+		assert.False(t, action.Matches("list", "secrets"), "the secrets of the team should not be loaded to mask them")
+	}
+
+	events, err := kubeClient.CoreV1().Events(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 2, "the get command should not be audited")
+
+	entries, err := audit.LoadEntries(kubeClient, ns, "", &audit.Filter{Command: "promote"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "James Strachan", entry.GitUser)
+	assert.Equal(t, "jx promote", entry.Command)
+	assert.Equal(t, "jx-production", entry.Namespace)
+	assert.Equal(t, "production", entry.Environment)
+	assert.Equal(t, audit.OutcomeFailed, entry.Outcome)
+	assert.Contains(t, entry.Args, "myapp")
+	assert.Contains(t, entry.Args, "--api-token=*******")
+	assert.Equal(t, "failed to push with *******", entry.Error)
+}
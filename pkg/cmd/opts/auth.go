@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/jenkins-x/jx/v2/pkg/auth"
+	"github.com/jenkins-x/jx/v2/pkg/chats"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 )
 
@@ -56,6 +57,26 @@ func (o *CommonOptions) CreateChatAuthConfigService(kind string) (auth.ConfigSer
 	return o.factory.CreateChatAuthConfigService(namespace, kind)
 }
 
+// CreateChatProvider creates a new chat provider from the given configuration
+func (o *CommonOptions) CreateChatProvider(chatConfig *config.ChatConfig) (chats.ChatProvider, error) {
+	u := chatConfig.URL
+	if u == "" {
+		return nil, nil
+	}
+	authConfigSvc, err := o.CreateChatAuthConfigService("")
+	if err != nil {
+		return nil, err
+	}
+	config := authConfigSvc.Config()
+
+	server := config.GetOrCreateServer(u)
+	userAuth, err := config.PickServerUserAuth(server, "user to access the chat service at "+u, o.BatchMode, "", o.GetIOFileHandles())
+	if err != nil {
+		return nil, err
+	}
+	return chats.CreateChatProvider(server.Kind, server, userAuth, o.BatchMode)
+}
+
 // PickPipelineUserAuth returns the user auth for pipeline user
 func (o *CommonOptions) PickPipelineUserAuth(config *auth.AuthConfig, server *auth.AuthServer) (*auth.UserAuth, error) {
 	userName := config.PipeLineUsername
@@ -1,11 +1,16 @@
 package opts
 
 import (
+	"os"
 	"sort"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cloud"
 	gcp "github.com/jenkins-x/jx/v2/pkg/cloud/gke"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/aks"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/factory"
 	"github.com/jenkins-x/jx/v2/pkg/cluster/fake"
+	"github.com/jenkins-x/jx/v2/pkg/cluster/kind"
 
 	"github.com/jenkins-x/jx/v2/pkg/cluster"
 	"github.com/jenkins-x/jx/v2/pkg/cluster/gke"
@@ -16,8 +21,10 @@ import (
 
 // ClusterOptions used to determine which kind of cluster to query
 type ClusterOptions struct {
-	GKE  GKEClusterOptions
-	Fake bool
+	Provider string
+	GKE      GKEClusterOptions
+	AKS      AKSClusterOptions
+	Fake     bool
 }
 
 // GKEClusterOptions GKE specific configurations
@@ -26,6 +33,11 @@ type GKEClusterOptions struct {
 	Region  string
 }
 
+// AKSClusterOptions AKS specific configurations
+type AKSClusterOptions struct {
+	ResourceGroup string
+}
+
 // CreateClient creates a new cluster client from the CLI options
 func (o *ClusterOptions) CreateClient(requireProject bool) (cluster.Client, error) {
 	if o.Fake {
@@ -42,6 +54,20 @@ func (o *ClusterOptions) CreateClient(requireProject bool) (cluster.Client, erro
 		}
 		return fake.NewClient(clusters), nil
 	}
+	switch o.Provider {
+	case "", cloud.GKE:
+		// the GKE client is created from the GKE options below
+	case cloud.AKS:
+		resourceGroup := util.FirstNotEmptyString(o.AKS.ResourceGroup, os.Getenv(cluster.EnvAKSResourceGroup))
+		if resourceGroup == "" {
+			return nil, util.MissingOption("aks-resource-group")
+		}
+		return aks.NewAKS(resourceGroup)
+	case cloud.KIND:
+		return kind.NewKind()
+	default:
+		return factory.NewClientForProvider(o.Provider)
+	}
 	if o.GKE.Project != "" || o.GKE.Region != "" {
 		if o.GKE.Project == "" {
 			return nil, util.MissingOption("gke-project")
@@ -75,6 +101,8 @@ func (o *ClusterOptions) CreateClient(requireProject bool) (cluster.Client, erro
 func (o *ClusterOptions) AddClusterFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.GKE.Project, "gke-project", "", "", "The GKE project name")
 	cmd.Flags().StringVarP(&o.GKE.Region, "gke-region", "", "", "The GKE project name")
+	cmd.Flags().StringVarP(&o.Provider, "provider", "", "", "The kind of clusters to use: gke, eks, aks or kind. Defaults to gke")
+	cmd.Flags().StringVarP(&o.AKS.ResourceGroup, "aks-resource-group", "", "", "The AKS resource group of the clusters. Defaults to the $"+cluster.EnvAKSResourceGroup+" environment variable")
 	cmd.Flags().BoolVarP(&o.Fake, "fake", "", false, "Use the fake clusters client")
 }
 
@@ -26,6 +26,9 @@ work
 	// DeployKindDefault for default kubernetes Deployment + Service deployment kinds
 	DeployKindDefault = "default"
 
+	// DeployKindKustomize for kustomize base and overlay based deployments
+	DeployKindKustomize = "kustomize"
+
 	// OptionKind to specify the kind of something (such as the kind of a deployment)
 	OptionKind = "kind"
 
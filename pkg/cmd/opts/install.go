@@ -13,8 +13,6 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/brew"
 
-	"github.com/jenkins-x/jx/v2/pkg/ksync"
-
 	"github.com/jenkins-x/jx/v2/pkg/cloud/amazon"
 
 	"github.com/jenkins-x/jx/v2/pkg/cloud/iks"
@@ -101,8 +99,6 @@ func (o *CommonOptions) DoInstallMissingDependencies(install []string) error {
 			err = o.InstallTiller()
 		case "helm3":
 			err = o.InstallHelm3()
-		case "ksync":
-			_, err = ksync.InstallKSync()
 		case "oc":
 			err = openshift.InstallOc()
 		case "oci":
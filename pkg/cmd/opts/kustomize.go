@@ -1,12 +1,22 @@
 package opts
 
 import (
+	"io/ioutil"
+	"os"
+
 	"github.com/blang/semver"
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 	"github.com/pkg/errors"
 )
 
+// defaultKustomizeEnvironments the environments to generate overlays for if the environments of the team cannot be found
+var defaultKustomizeEnvironments = []string{"staging", "production"}
+
 // EnsureKustomize ensures kustomize is installed
 func (o *CommonOptions) EnsureKustomize() error {
 	version, err := o.Kustomize().Version()
@@ -73,3 +83,60 @@ func isInstalledKustomizeVersionSupported(version string, stableVersion *version
 
 	return false, errors.Wrapf(err, "unsupported version of Kustomize installed. Install kustomize version above %s or below %s ", lowerLimit, upperLimit)
 }
+
+// GenerateKustomizeLayout generates the kustomize base and overlays of the app in the project dir with an overlay for
+// each permanent environment of the team
+func (o *CommonOptions) GenerateKustomizeLayout(dir string, app string) error {
+	environments := []string{}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err == nil {
+		var envs []*v1.Environment
+		envs, err = kube.GetPermanentEnvironments(jxClient, ns)
+		for _, env := range envs {
+			environments = append(environments, env.Name)
+		}
+	}
+	if err != nil || len(environments) == 0 {
+		log.Logger().Debugf("using the default environments for the kustomize overlays as the team environments could not be found: %v", err)
+		environments = defaultKustomizeEnvironments
+	}
+
+	err = kustomize.GenerateLayout(dir, kustomize.LayoutOptions{
+		AppName:      app,
+		Environments: environments,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate the kustomize layout in %s", dir)
+	}
+	log.Logger().Infof("generated the kustomize base and overlays for %s", util.ColorInfo(app))
+	return nil
+}
+
+// ApplyKustomizeOverlay builds the resources of the kustomize overlay in the given dir and applies them to the namespace
+func (o *CommonOptions) ApplyKustomizeOverlay(dir string, ns string) error {
+	err := o.EnsureKustomize()
+	if err != nil {
+		return err
+	}
+	resources, err := o.Kustomize().Build(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build the kustomize overlay %s", dir)
+	}
+
+	tmpFile, err := ioutil.TempFile("", "kustomize-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file for the kustomize resources")
+	}
+	tmpFileName := tmpFile.Name()
+	defer os.Remove(tmpFileName)
+	err = ioutil.WriteFile(tmpFileName, []byte(resources), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the kustomize resources to %s", tmpFileName)
+	}
+
+	err = o.RunCommandVerbose("kubectl", "apply", "-f", tmpFileName, "-n", ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply the kustomize overlay %s", dir)
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/cenkalti/backoff"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
 
 	"github.com/jenkins-x/jx/v2/pkg/kserving"
 	"github.com/jenkins-x/jx/v2/pkg/users"
@@ -36,6 +37,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/spf13/cobra"
 	batchv1 "k8s.io/api/batch/v1"
@@ -49,6 +51,9 @@ var (
 	previewLong = templates.LongDesc(`
 		Creates or updates a Preview Environment for the given Pull Request or Branch.
 
+		If the preview directory contains a helmfile.yaml the preview is deployed via 'helmfile sync' rather than by
+		installing the preview chart. The preview values are passed to the helmfile as state values.
+
 		For more documentation on Preview Environments see: [https://jenkins-x.io/about/features/#preview-environments](https://jenkins-x.io/about/features/#preview-environments)
 
 `)
@@ -524,7 +529,25 @@ func (o *PreviewOptions) Run() error {
 		helmOptions.ValueFiles = append(helmOptions.ValueFiles, defaultValuesFileName)
 	}
 
-	err = o.InstallChartWithOptions(helmOptions)
+	helmfileName, err := helmfile.FindHelmfile(dir)
+	if err != nil {
+		return err
+	}
+	projectDir := o.Dir
+	if projectDir == "" {
+		projectDir = dir
+	}
+	kustomizeProject, err := kustomize.IsKustomizeProject(projectDir)
+	if err != nil {
+		return err
+	}
+	if kustomizeProject {
+		err = o.applyKustomizeOverlay(projectDir, values)
+	} else if helmfileName != "" {
+		err = o.syncHelmfile(helmfileName, helmOptions)
+	} else {
+		err = o.InstallChartWithOptions(helmOptions)
+	}
 	if err != nil {
 		return err
 	}
@@ -656,6 +679,47 @@ func (o *PreviewOptions) Run() error {
 	return o.RunPostPreviewSteps(kubeClient, o.Namespace, url, pipeline, build, o.Application)
 }
 
+// syncHelmfile deploys the preview from a helmfile rather than a chart. The preview values are passed to the helmfile
+// as state values so that its releases can use them via templates such as {{ .Values.preview.image.tag }}
+func (o *PreviewOptions) syncHelmfile(fileName string, helmOptions helm.InstallChartOptions) error {
+	args := []string{"--file", fileName, "--namespace", helmOptions.Ns}
+	for _, valuesFile := range helmOptions.ValueFiles {
+		args = append(args, "--state-values-file", valuesFile)
+	}
+	for _, value := range append(helmOptions.SetValues, helmOptions.SetStrings...) {
+		args = append(args, "--state-values-set", value)
+	}
+	args = append(args, "sync")
+
+	log.Logger().Infof("Deploying the preview from %s", util.ColorInfo(fileName))
+	err := o.RunCommandVerbose("helmfile", args...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to sync helmfile %s", fileName)
+	}
+	return nil
+}
+
+// applyKustomizeOverlay deploys the preview from the preview overlay of a project which uses kustomize rather than a
+// chart. The image of the preview and the namespace are set in the overlay before it is applied
+func (o *PreviewOptions) applyKustomizeOverlay(projectDir string, values *config.PreviewValuesConfig) error {
+	overlayDir := kustomize.OverlayDir(projectDir, kustomize.PreviewOverlay)
+	overlay, err := kustomize.LoadKustomization(overlayDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load the preview overlay of %s", projectDir)
+	}
+	if values.Preview != nil && values.Preview.Image != nil {
+		overlay.SetImage(o.Application, values.Preview.Image.Repository, values.Preview.Image.Tag)
+	}
+	overlay.Namespace = o.Namespace
+	err = kustomize.SaveKustomization(overlayDir, overlay)
+	if err != nil {
+		return err
+	}
+
+	log.Logger().Infof("Deploying the preview from the kustomize overlay %s", util.ColorInfo(overlayDir))
+	return o.ApplyKustomizeOverlay(overlayDir, o.Namespace)
+}
+
 // findPreviewURL finds the preview URL
 func (o *PreviewOptions) findPreviewURL(kubeClient kubernetes.Interface, kserveClient kserve.Interface) (string, []string, error) {
 	app := naming.ToValidName(o.Application)
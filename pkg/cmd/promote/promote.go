@@ -28,9 +28,12 @@ import (
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -568,6 +571,36 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 		requirements.SetAppVersion(app, version, o.HelmRepositoryURL, o.Alias)
 		return nil
 	}
+	modifyHelmfileFn := func(states []*helmfile.HelmState, dir string, details *gits.PullRequestDetails) error {
+		var err error
+		if version == "" {
+			version, err = o.findLatestVersion(app)
+			if err != nil {
+				return err
+			}
+		}
+		releaseName := app
+		if o.Alias != "" {
+			releaseName = o.Alias
+		}
+		state := helmfile.ReleaseHelmfile(states, releaseName)
+		state.SetReleaseVersion(app, version, o.HelmRepositoryURL, kube.LocalHelmRepoName, o.Alias, env.Spec.Namespace)
+		return nil
+	}
+	modifyKustomizationFn := func(dir string, details *gits.PullRequestDetails) error {
+		if version == "" {
+			return fmt.Errorf("a version must be specified to promote %s to the environment %s which uses kustomize", app, env.Name)
+		}
+		gitInfo := o.GitInfo
+		if gitInfo == nil && !o.IgnoreLocalFiles {
+			gitInfo, _ = o.Git().Info("")
+		}
+		sourceURL := ""
+		if gitInfo != nil {
+			sourceURL = gitInfo.HttpsURL()
+		}
+		return kustomize.SetEnvironmentAppVersion(dir, env.Name, app, o.kustomizeImageName(app, gitInfo), version, sourceURL)
+	}
 	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
 	if err != nil {
 		return errors.Wrapf(err, "creating git provider for %s", env.Spec.Source.URL)
@@ -579,9 +612,11 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 	}
 
 	options := environments.EnvironmentPullRequestOptions{
-		Gitter:        o.Git(),
-		ModifyChartFn: modifyChartFn,
-		GitProvider:   gitProvider,
+		Gitter:                o.Git(),
+		ModifyChartFn:         modifyChartFn,
+		ModifyHelmfileFn:      modifyHelmfileFn,
+		ModifyKustomizationFn: modifyKustomizationFn,
+		GitProvider:           gitProvider,
 	}
 	filter := &gits.PullRequestFilter{}
 	if releaseInfo.PullRequestInfo != nil && releaseInfo.PullRequestInfo.PullRequest != nil {
@@ -884,6 +919,29 @@ func (o *PromoteOptions) waitForGitOpsPullRequest(ns string, env *v1.Environment
 	return nil
 }
 
+// kustomizeImageName returns the full name of the image of the app which replaces the image named after the app
+// in the kustomize base of the app
+func (o *PromoteOptions) kustomizeImageName(app string, gitInfo *gits.GitRepository) string {
+	var projectConfig *config.ProjectConfig
+	if !o.IgnoreLocalFiles {
+		var err error
+		projectConfig, _, err = config.LoadProjectConfig("")
+		if err != nil {
+			log.Logger().Debugf("failed to load the project configuration: %s", err)
+		}
+	}
+	answer := app
+	org := o.GetDockerRegistryOrg(projectConfig, gitInfo)
+	if org != "" {
+		answer = org + "/" + answer
+	}
+	registry := o.GetDockerRegistry(projectConfig)
+	if registry != "" {
+		answer = registry + "/" + answer
+	}
+	return answer
+}
+
 func (o *PromoteOptions) findLatestVersion(app string) (string, error) {
 	charts, err := o.Helm().SearchCharts(app, true)
 	if err != nil {
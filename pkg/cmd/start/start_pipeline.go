@@ -24,6 +24,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	prowjobv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 )
 
@@ -50,6 +51,8 @@ type StartPipelineOptions struct {
 	Context      string
 	CustomLabels []string
 	CustomEnvs   []string
+
+	RetryFailedFrom string
 }
 
 var (
@@ -67,6 +70,9 @@ var (
 
 		# Select the pipeline to start and tail the log
 		jx start pipeline -t
+
+		# Retry the last pipeline of the master branch from its 'deploy' stage without running the earlier stages again
+		jx start pipeline myorg/myapp/master --retry-failed-from deploy
 	`)
 )
 
@@ -97,6 +103,7 @@ func NewCmdStartPipeline(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVar(&options.ServiceAccount, "service-account", tekton.DefaultPipelineSA, "The Kubernetes ServiceAccount to use to run the meta pipeline")
 	cmd.Flags().StringArrayVarP(&options.CustomLabels, "label", "l", nil, "List of custom labels to be applied to the generated PipelineRun (can be use multiple times)")
 	cmd.Flags().StringArrayVarP(&options.CustomEnvs, "env", "e", nil, "List of custom environment variables to be applied to the generated PipelineRun that are created (can be use multiple times)")
+	cmd.Flags().StringVarP(&options.RetryFailedFrom, "retry-failed-from", "", "", "Retries the last PipelineRun of the pipeline from the given stage. The earlier stages are not run again: the first stage run starts from their outputs, which requires Tekton to store the outputs of stages in a bucket configured in the config-artifact-bucket ConfigMap")
 
 	options.JenkinsSelector.AddFlags(cmd)
 
@@ -168,7 +175,12 @@ func (o *StartPipelineOptions) Run() error {
 		args = []string{name}
 	}
 	for _, a := range args {
-		if devEnv.Spec.IsLighthouse() {
+		if o.RetryFailedFrom != "" {
+			err = o.retryPipelineRun(a)
+			if err != nil {
+				return err
+			}
+		} else if devEnv.Spec.IsLighthouse() {
 			err = o.createMetaPipeline(a)
 			if err != nil {
 				return err
@@ -266,6 +278,58 @@ func (o *StartPipelineOptions) createMetaPipeline(jobName string) error {
 	return nil
 }
 
+// retryPipelineRun retries the last build PipelineRun of the job from the RetryFailedFrom stage
+func (o *StartPipelineOptions) retryPipelineRun(jobName string) error {
+	parts := strings.Split(jobName, "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("job name [%s] does not match org/repo/branch format", jobName)
+	}
+	branch := parts[2]
+	if o.Branch != "" {
+		branch = o.Branch
+	}
+	tektonClient, ns, err := o.TektonClient()
+	if err != nil {
+		return errors.Wrap(err, "could not create tekton client")
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create JX client")
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the kube client")
+	}
+
+	selector := fmt.Sprintf("%s=%s,%s=%s,%s=%s,%s=%s", tekton.LabelOwner, parts[0], tekton.LabelRepo, parts[1], tekton.LabelBranch, branch, tekton.LabelType, tekton.BuildPipeline.String())
+	if o.Context != "" {
+		selector += fmt.Sprintf(",%s=%s", tekton.LabelContext, o.Context)
+	}
+	prList, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PipelineRuns in namespace %s", ns)
+	}
+	if len(prList.Items) == 0 {
+		return fmt.Errorf("no PipelineRuns found for %s", jobName)
+	}
+	latest := &prList.Items[0]
+	for i := range prList.Items {
+		pr := &prList.Items[i]
+		if latest.CreationTimestamp.Before(&pr.CreationTimestamp) {
+			latest = pr
+		}
+	}
+
+	retry, err := tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, ns, latest, o.RetryFailedFrom)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retry PipelineRun %s from stage %s", latest.Name, o.RetryFailedFrom)
+	}
+	log.Logger().Infof("retrying PipelineRun %s from stage %s as %s", util.ColorInfo(latest.Name), util.ColorInfo(o.RetryFailedFrom), util.ColorInfo(retry.Name))
+	return nil
+}
+
 func (o *StartPipelineOptions) createProwJob(jobname string) error {
 	parts := strings.Split(jobname, "/")
 	if len(parts) != 3 {
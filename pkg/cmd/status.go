@@ -72,7 +72,12 @@ func (o *StatusOptions) Run() error {
 	/*
 	 * get status for all pods in all namespaces
 	 */
-	clusterStatus, err := kube.GetClusterStatus(client, "", o.Verbose)
+	metricsClient, err := o.GetFactory().CreateMetricsClient()
+	if err != nil {
+		log.Logger().Debugf("Unable to create the metrics client: %s", err)
+		metricsClient = nil
+	}
+	clusterStatus, err := kube.GetClusterStatus(client, metricsClient, "", o.Verbose)
 	if err != nil {
 		log.Logger().Errorf("Failed to get cluster status %s", err.Error())
 		return err
@@ -9,6 +9,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/bdd"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/boot"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/buildpack"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/cache"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/cluster"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/create"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/e2e"
@@ -22,6 +23,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/report"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/restore"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/scheduler"
+	stepsync "github.com/jenkins-x/jx/v2/pkg/cmd/step/sync"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/syntax"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/update"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step/verify"
@@ -51,6 +53,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(bdd.NewCmdStepBDD(commonOpts))
 	cmd.AddCommand(e2e.NewCmdStepE2E(commonOpts))
 	cmd.AddCommand(step.NewCmdStepBlog(commonOpts))
+	cmd.AddCommand(cache.NewCmdStepCache(commonOpts))
 	cmd.AddCommand(step.NewCmdStepChangelog(commonOpts))
 	cmd.AddCommand(cluster.NewCmdStepCluster(commonOpts))
 	cmd.AddCommand(step.NewCmdStepCredential(commonOpts))
@@ -70,6 +73,7 @@ func NewCmdStep(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(step.NewCmdStepRelease(commonOpts))
 	cmd.AddCommand(step.NewCmdStepReplicate(commonOpts))
 	cmd.AddCommand(step.NewCmdStepSplitMonorepo(commonOpts))
+	cmd.AddCommand(stepsync.NewCmdStepSync(commonOpts))
 	cmd.AddCommand(syntax.NewCmdStepSyntax(commonOpts))
 	cmd.AddCommand(step.NewCmdStepTag(commonOpts))
 	cmd.AddCommand(step.NewCmdStepValidate(commonOpts))
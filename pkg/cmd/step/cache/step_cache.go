@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepCacheOptions contains the command line flags common to the cache steps
+type StepCacheOptions struct {
+	step.StepOptions
+
+	Key        string
+	KeyFile    string
+	Paths      []string
+	Dir        string
+	BucketURL  string
+	Owner      string
+	Repository string
+	Root       string
+}
+
+// NewCmdStepCache Creates a new Command object
+func NewCmdStepCache(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCacheOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "cache [restore|save]",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepCacheRestore(commonOpts))
+	cmd.AddCommand(NewCmdStepCacheSave(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepCacheOptions) Run() error {
+	return o.Cmd.Help()
+}
+
+// AddCacheFlags adds the common cache flags
+func (o *StepCacheOptions) AddCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Key, "key", "k", "", "The template of the cache key evaluated in the current directory. e.g. 'maven-{{ checksum \"pom.xml\" }}'")
+	cmd.Flags().StringVarP(&o.KeyFile, "key-file", "", "", "The file the rendered key is written to if it does not exist, or read from if it does, so that the key is only rendered once per build")
+	cmd.Flags().StringArrayVarP(&o.Paths, "path", "p", nil, "The paths to cache. Relative paths are relative to the current directory")
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", "", "The directory of a shared volume to store the cache in. If not specified the team's storage location is used")
+	cmd.Flags().StringVarP(&o.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL to store the cache in. Defaults to the team's storage location for the 'caches' classifier")
+	cmd.Flags().StringVarP(&o.Owner, "owner", "", "", "The git owner of the repository being built. Defaults to the $REPO_OWNER environment variable")
+	cmd.Flags().StringVarP(&o.Repository, "repo", "r", "", "The git repository being built. Defaults to the $REPO_NAME environment variable")
+	cmd.Flags().StringVarP(&o.Root, "root", "", "/", "The root directory the cache entries are stored relative to")
+}
+
+// CacheStore returns the store of the cache and the key of the entry to restore or save.
+// If there is a key file the key rendered by an earlier step is used so that the cache is saved with the same key
+// it was restored with, even if the build changed the files the key is a checksum of
+func (o *StepCacheOptions) CacheStore() (buildcache.Store, string, error) {
+	if o.Key == "" {
+		return nil, "", util.MissingOption("key")
+	}
+	if len(o.Paths) == 0 {
+		return nil, "", util.MissingOption("path")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := o.renderKey(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if o.Owner == "" {
+		o.Owner = os.Getenv("REPO_OWNER")
+	}
+	if o.Repository == "" {
+		o.Repository = os.Getenv("REPO_NAME")
+	}
+	if o.Owner == "" || o.Repository == "" {
+		gitInfo, err := o.FindGitInfo(dir)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to find the git repository. Try specifying --owner and --repo")
+		}
+		if o.Owner == "" {
+			o.Owner = gitInfo.Organisation
+		}
+		if o.Repository == "" {
+			o.Repository = gitInfo.Name
+		}
+	}
+	entryKey := buildcache.EntryKey(o.Owner, o.Repository, key)
+
+	if o.Dir != "" {
+		return buildcache.NewDirStore(o.Dir), entryKey, nil
+	}
+	if o.BucketURL == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return nil, entryKey, errors.Wrap(err, "failed to load the team settings")
+		}
+		o.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationCaches).BucketURL
+	}
+	if o.BucketURL == "" {
+		return nil, entryKey, nil
+	}
+	return buildcache.NewBucketStore(o.BucketURL), entryKey, nil
+}
+
+// renderKey renders the key in the directory unless it has already been rendered into the key file
+func (o *StepCacheOptions) renderKey(dir string) (string, error) {
+	if o.KeyFile != "" {
+		data, err := ioutil.ReadFile(o.KeyFile)
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", errors.Wrapf(err, "failed to read the cache key file %s", o.KeyFile)
+		}
+	}
+	key, err := buildcache.RenderKey(o.Key, dir)
+	if err != nil {
+		return "", err
+	}
+	if o.KeyFile != "" {
+		err = ioutil.WriteFile(o.KeyFile, []byte(key), util.DefaultFileWritePermissions)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to write the cache key file %s", o.KeyFile)
+		}
+	}
+	return key, nil
+}
+
+// ResolvedPaths returns the absolute paths to cache
+func (o *StepCacheOptions) ResolvedPaths() ([]string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return buildcache.ResolvePaths(dir, o.Paths), nil
+}
+
+// warnNoStorage logs that the cache is not used as there is nowhere to store it
+func warnNoStorage() {
+	log.Logger().Warnf("not using the cache as there is no cloud storage bucket for the %s classifier. Use 'jx edit storage' to configure one", kube.ClassificationCaches)
+}
+
+func formatBytes(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
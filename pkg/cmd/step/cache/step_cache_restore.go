@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// StepCacheRestoreOptions contains the command line flags
+type StepCacheRestoreOptions struct {
+	StepCacheOptions
+}
+
+var (
+	stepCacheRestoreLong = templates.LongDesc(`
+		Restores the build cache for the key if there is one.
+
+		This step is added before the steps of a stage which has a 'cache' in its options. A failure to restore the cache
+		is logged but does not fail the pipeline.
+`)
+
+	stepCacheRestoreExample = templates.Examples(`
+		# restore the local maven repository for the current pom.xml
+		jx step cache restore --key 'maven-{{ checksum "pom.xml" }}' --path /root/.mvnrepository
+`)
+)
+
+// NewCmdStepCacheRestore Creates a new Command object
+func NewCmdStepCacheRestore(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCacheRestoreOptions{
+		StepCacheOptions: StepCacheOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "restore",
+		Short:   "Restores the build cache for a key",
+		Long:    stepCacheRestoreLong,
+		Example: stepCacheRestoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.AddCacheFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepCacheRestoreOptions) Run() error {
+	store, key, err := o.CacheStore()
+	if err != nil {
+		log.Logger().Warnf("failed to restore the cache: %s", err.Error())
+		return nil
+	}
+	if store == nil {
+		warnNoStorage()
+		return nil
+	}
+	result, err := buildcache.Restore(store, key, o.Root)
+	if err != nil {
+		log.Logger().Warnf("failed to restore the cache %s: %s", key, err.Error())
+		return nil
+	}
+	if !result.Hit {
+		log.Logger().Infof("cache miss for %s", util.ColorInfo(key))
+		return nil
+	}
+	log.Logger().Infof("cache hit for %s: restored %d files (%s) in %s", util.ColorInfo(key), result.Files, util.ColorInfo(formatBytes(result.Size)), result.Duration.Round(100*time.Millisecond))
+	return nil
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// StepCacheSaveOptions contains the command line flags
+type StepCacheSaveOptions struct {
+	StepCacheOptions
+}
+
+var (
+	stepCacheSaveLong = templates.LongDesc(`
+		Saves the build cache for the key unless there is already a cache for the key.
+
+		This step is added after the steps of a stage which has a 'cache' in its options. A failure to save the cache
+		is logged but does not fail the pipeline.
+`)
+
+	stepCacheSaveExample = templates.Examples(`
+		# save the local maven repository for the current pom.xml
+		jx step cache save --key 'maven-{{ checksum "pom.xml" }}' --path /root/.mvnrepository
+`)
+)
+
+// NewCmdStepCacheSave Creates a new Command object
+func NewCmdStepCacheSave(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCacheSaveOptions{
+		StepCacheOptions: StepCacheOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "save",
+		Short:   "Saves the build cache for a key",
+		Long:    stepCacheSaveLong,
+		Example: stepCacheSaveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.AddCacheFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *StepCacheSaveOptions) Run() error {
+	store, key, err := o.CacheStore()
+	if err != nil {
+		log.Logger().Warnf("failed to save the cache: %s", err.Error())
+		return nil
+	}
+	if store == nil {
+		warnNoStorage()
+		return nil
+	}
+	paths, err := o.ResolvedPaths()
+	if err != nil {
+		log.Logger().Warnf("failed to save the cache %s: %s", key, err.Error())
+		return nil
+	}
+	result, err := buildcache.Save(store, key, o.Root, paths)
+	if err != nil {
+		log.Logger().Warnf("failed to save the cache %s: %s", key, err.Error())
+		return nil
+	}
+	if result.Hit {
+		log.Logger().Infof("cache %s already exists so not saving it", util.ColorInfo(key))
+		return nil
+	}
+	if result.Skipped {
+		log.Logger().Infof("no files found to save in the cache %s", util.ColorInfo(key))
+		return nil
+	}
+	log.Logger().Infof("saved %d files (%s) to the cache %s in %s", result.Files, util.ColorInfo(formatBytes(result.Size)), util.ColorInfo(key), result.Duration.Round(100*time.Millisecond))
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const defaultPoolName = "e2e"
+
 // StepClusterOptions contains the command line flags and other helper objects
 type StepClusterOptions struct {
 	step.StepOptions
@@ -34,9 +36,19 @@ func NewCmdStepCluster(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepClusterLabel(commonOpts))
 	cmd.AddCommand(NewCmdStepClusterLock(commonOpts))
 	cmd.AddCommand(NewCmdStepClusterUnlock(commonOpts))
+	cmd.AddCommand(NewCmdStepClusterLease(commonOpts))
+	cmd.AddCommand(NewCmdStepClusterHeartbeat(commonOpts))
+	cmd.AddCommand(NewCmdStepClusterRelease(commonOpts))
+	cmd.AddCommand(NewCmdStepClusterReconcile(commonOpts))
 	return cmd
 }
 
+// addPoolFlags adds the flags for the pool of clusters
+func (o *StepClusterOptions) addPoolFlags(cmd *cobra.Command, pool *string, filters *[]string) {
+	cmd.Flags().StringVarP(pool, "pool", "p", defaultPoolName, "The name of the pool of clusters")
+	cmd.Flags().StringArrayVarP(filters, "filter", "f", nil, "The labels of the form 'key=value' to filter the clusters of the pool and to label new clusters")
+}
+
 // Run implements this command
 func (o *StepClusterOptions) Run() error {
 	return o.Cmd.Help()
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	clusters "github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepClusterHeartbeatLong = templates.LongDesc(`
+		Renews the lease on a cluster of a pool so that it is not reclaimed.
+
+		If an interval is specified the lease is renewed every interval until the command is stopped.
+`)
+	stepClusterHeartbeatExample = templates.Examples(`
+		# renew the lease on a cluster every 5 minutes in the background
+		jx step cluster heartbeat -n mycluster --lease-id $LEASE_ID --interval 5m &
+`)
+)
+
+// StepClusterHeartbeatOptions contains the command line flags and other helper objects
+type StepClusterHeartbeatOptions struct {
+	StepClusterOptions
+	Pool        string
+	Filters     []string
+	ClusterName string
+	LeaseID     string
+	Interval    time.Duration
+}
+
+// NewCmdStepClusterHeartbeat Creates a new Command object
+func NewCmdStepClusterHeartbeat(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepClusterHeartbeatOptions{
+		StepClusterOptions: StepClusterOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "heartbeat",
+		Short:   "Renews the lease on a cluster of a pool so that it is not reclaimed",
+		Long:    stepClusterHeartbeatLong,
+		Example: stepClusterHeartbeatExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.ClusterOptions.AddClusterFlags(cmd)
+	options.addPoolFlags(cmd, &options.Pool, &options.Filters)
+
+	cmd.Flags().StringVarP(&options.ClusterName, "name", "n", "", "The name of the leased cluster")
+	cmd.Flags().StringVarP(&options.LeaseID, "lease-id", "", "", "The id of the lease")
+	cmd.Flags().DurationVarP(&options.Interval, "interval", "i", 0, "The interval to renew the lease at until the command is stopped")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepClusterHeartbeatOptions) Run() error {
+	if o.ClusterName == "" {
+		return util.MissingOption("name")
+	}
+	if o.LeaseID == "" {
+		return util.MissingOption("lease-id")
+	}
+	client, err := o.ClusterOptions.CreateClient(true)
+	if err != nil {
+		return err
+	}
+	pool := clusters.NewPool(client, o.Pool)
+	pool.Labels = util.KeyValuesToMap(o.Filters)
+	lease := &clusters.Lease{
+		Cluster: &clusters.Cluster{Name: o.ClusterName},
+		ID:      o.LeaseID,
+	}
+	for {
+		err = pool.Heartbeat(lease)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("renewed the lease on cluster %s", util.ColorInfo(o.ClusterName))
+		if o.Interval <= 0 {
+			return nil
+		}
+		time.Sleep(o.Interval)
+	}
+}
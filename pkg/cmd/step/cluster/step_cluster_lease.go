@@ -0,0 +1,114 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	clusters "github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepClusterLeaseLong = templates.LongDesc(`
+		Leases a cluster from a pool of clusters and joins it.
+
+		The lease expires after the time to live unless it is renewed via 'jx step cluster heartbeat', so the cluster
+		is reclaimed automatically if the build using it dies. If there is no free cluster and the pool is smaller than
+		its maximum size a new cluster is created, except for EKS clusters which cannot be created by this command.
+`)
+	stepClusterLeaseExample = templates.Examples(`
+		# lease a cluster from the e2e pool for 30 minutes
+		jx step cluster lease --owner $BUILD_ID --ttl 30m
+`)
+)
+
+// StepClusterLeaseOptions contains the command line flags and other helper objects
+type StepClusterLeaseOptions struct {
+	StepClusterOptions
+	Pool    string
+	Filters []string
+	Owner   string
+	TTL     time.Duration
+	MaxSize int
+	Wait    time.Duration
+}
+
+// NewCmdStepClusterLease Creates a new Command object
+func NewCmdStepClusterLease(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepClusterLeaseOptions{
+		StepClusterOptions: StepClusterOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "lease",
+		Short:   "Leases a cluster from a pool of clusters and joins it",
+		Long:    stepClusterLeaseLong,
+		Example: stepClusterLeaseExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.ClusterOptions.AddClusterFlags(cmd)
+	options.addPoolFlags(cmd, &options.Pool, &options.Filters)
+
+	cmd.Flags().StringVarP(&options.Owner, "owner", "o", "", "The owner of the lease, such as the name of the build")
+	cmd.Flags().DurationVarP(&options.TTL, "ttl", "", 30*time.Minute, "How long the lease lasts unless it is renewed by a heartbeat")
+	cmd.Flags().IntVarP(&options.MaxSize, "max", "", 0, "The maximum number of clusters in the pool. If there is no free cluster and the pool is smaller a new cluster is created")
+	cmd.Flags().DurationVarP(&options.Wait, "wait", "w", 0, "How long to wait for a cluster to become free")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepClusterLeaseOptions) Run() error {
+	if o.Owner == "" {
+		return util.MissingOption("owner")
+	}
+	client, err := o.ClusterOptions.CreateClient(true)
+	if err != nil {
+		return err
+	}
+	pool := clusters.NewPool(client, o.Pool)
+	pool.MaxSize = o.MaxSize
+	pool.Labels = util.KeyValuesToMap(o.Filters)
+
+	var lease *clusters.Lease
+	acquire := func() error {
+		lease, err = pool.Acquire(o.Owner, o.TTL)
+		if err != nil {
+			return err
+		}
+		if lease == nil {
+			return fmt.Errorf("there is no free cluster in pool %s", o.Pool)
+		}
+		return nil
+	}
+	if o.Wait > 0 {
+		err = util.Retry(o.Wait, acquire)
+	} else {
+		err = acquire()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to lease a cluster from pool %s", o.Pool)
+	}
+
+	log.Logger().Infof("leased cluster %s to %s until %s", util.ColorInfo(lease.Cluster.Name), util.ColorInfo(lease.Owner), lease.Expires().Format(time.RFC3339))
+	log.Logger().Infof("to renew the lease run: %s", util.ColorInfo(fmt.Sprintf("jx step cluster heartbeat -p %s -n %s --lease-id %s", o.Pool, lease.Cluster.Name, lease.ID)))
+	log.Logger().Infof("to release the cluster again run: %s", util.ColorInfo(fmt.Sprintf("jx step cluster release -p %s -n %s --lease-id %s", o.Pool, lease.Cluster.Name, lease.ID)))
+
+	return o.verifyClusterConnect(client, lease.Cluster)
+}
@@ -94,7 +94,7 @@ func (o *StepClusterLockOptions) Run() error {
 	return o.verifyClusterConnect(client, cluster)
 }
 
-func (o *StepClusterLockOptions) verifyClusterConnect(client clusters.Client, cluster *clusters.Cluster) error {
+func (o *StepClusterOptions) verifyClusterConnect(client clusters.Client, cluster *clusters.Cluster) error {
 	name := cluster.Name
 
 	currentContext, err := o.currentKubeContext()
@@ -122,7 +122,7 @@ func (o *StepClusterLockOptions) verifyClusterConnect(client clusters.Client, cl
 	return nil
 }
 
-func (o *StepClusterLockOptions) currentKubeContext() (string, error) {
+func (o *StepClusterOptions) currentKubeContext() (string, error) {
 	config, _, err := o.Kube().LoadConfig()
 	if err != nil {
 		return "", err
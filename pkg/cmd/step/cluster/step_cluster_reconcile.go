@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	clusters "github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepClusterReconcileLong = templates.LongDesc(`
+		Reclaims the clusters of a pool whose leases have expired then creates or deletes free clusters so the pool has
+		between its minimum and maximum number of clusters.
+
+		EKS clusters cannot be created by this command so pools of EKS clusters cannot grow.
+`)
+	stepClusterReconcileExample = templates.Examples(`
+		# keep between 2 and 5 clusters in the e2e pool
+		jx step cluster reconcile --min 2 --max 5
+`)
+)
+
+// StepClusterReconcileOptions contains the command line flags and other helper objects
+type StepClusterReconcileOptions struct {
+	StepClusterOptions
+	Pool    string
+	Filters []string
+	MinSize int
+	MaxSize int
+}
+
+// NewCmdStepClusterReconcile Creates a new Command object
+func NewCmdStepClusterReconcile(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepClusterReconcileOptions{
+		StepClusterOptions: StepClusterOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "reconcile",
+		Short:   "Reclaims expired leases and keeps the pool of clusters between its minimum and maximum size",
+		Long:    stepClusterReconcileLong,
+		Example: stepClusterReconcileExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.ClusterOptions.AddClusterFlags(cmd)
+	options.addPoolFlags(cmd, &options.Pool, &options.Filters)
+
+	cmd.Flags().IntVarP(&options.MinSize, "min", "", 0, "The minimum number of clusters in the pool")
+	cmd.Flags().IntVarP(&options.MaxSize, "max", "", 0, "The maximum number of clusters in the pool. Defaults to the minimum")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepClusterReconcileOptions) Run() error {
+	client, err := o.ClusterOptions.CreateClient(true)
+	if err != nil {
+		return err
+	}
+	pool := clusters.NewPool(client, o.Pool)
+	pool.MinSize = o.MinSize
+	pool.MaxSize = o.MaxSize
+	pool.Labels = util.KeyValuesToMap(o.Filters)
+
+	created, deleted, err := pool.Reconcile()
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 && len(deleted) == 0 {
+		log.Logger().Infof("pool %s is within its minimum and maximum size", util.ColorInfo(o.Pool))
+		return nil
+	}
+	if len(created) > 0 {
+		log.Logger().Infof("created clusters %s in pool %s", util.ColorInfo(strings.Join(created, ", ")), util.ColorInfo(o.Pool))
+	}
+	if len(deleted) > 0 {
+		log.Logger().Infof("deleted clusters %s from pool %s", util.ColorInfo(strings.Join(deleted, ", ")), util.ColorInfo(o.Pool))
+	}
+	return nil
+}
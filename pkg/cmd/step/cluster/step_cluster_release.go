@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	clusters "github.com/jenkins-x/jx/v2/pkg/cluster"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stepClusterReleaseLong    = templates.LongDesc(`Releases the lease on a cluster so it is free to be leased from the pool again.`)
+	stepClusterReleaseExample = templates.Examples(`
+		# release a leased cluster
+		jx step cluster release -n mycluster --lease-id $LEASE_ID
+`)
+)
+
+// StepClusterReleaseOptions contains the command line flags and other helper objects
+type StepClusterReleaseOptions struct {
+	StepClusterOptions
+	Pool        string
+	Filters     []string
+	ClusterName string
+	LeaseID     string
+}
+
+// NewCmdStepClusterRelease Creates a new Command object
+func NewCmdStepClusterRelease(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepClusterReleaseOptions{
+		StepClusterOptions: StepClusterOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "release",
+		Short:   "Releases the lease on a cluster so it is free to be leased from the pool again",
+		Long:    stepClusterReleaseLong,
+		Example: stepClusterReleaseExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	options.ClusterOptions.AddClusterFlags(cmd)
+	options.addPoolFlags(cmd, &options.Pool, &options.Filters)
+
+	cmd.Flags().StringVarP(&options.ClusterName, "name", "n", "", "The name of the leased cluster")
+	cmd.Flags().StringVarP(&options.LeaseID, "lease-id", "", "", "The id of the lease")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepClusterReleaseOptions) Run() error {
+	if o.ClusterName == "" {
+		return util.MissingOption("name")
+	}
+	if o.LeaseID == "" {
+		return util.MissingOption("lease-id")
+	}
+	client, err := o.ClusterOptions.CreateClient(true)
+	if err != nil {
+		return err
+	}
+	pool := clusters.NewPool(client, o.Pool)
+	pool.Labels = util.KeyValuesToMap(o.Filters)
+	err = pool.Release(&clusters.Lease{
+		Cluster: &clusters.Cluster{Name: o.ClusterName},
+		ID:      o.LeaseID,
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("released cluster %s", util.ColorInfo(o.ClusterName))
+	return nil
+}
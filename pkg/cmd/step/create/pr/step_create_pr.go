@@ -20,18 +20,19 @@ import (
 //StepCreatePrOptions are the common options for all PR creation steps
 type StepCreatePrOptions struct {
 	step.StepCreateOptions
-	Results       *gits.PullRequestInfo
-	BranchName    string
-	GitURLs       []string
-	Base          string
-	Fork          bool
-	SrcGitURL     string
-	Component     string
-	Version       string
-	DryRun        bool
-	SkipCommit    bool
-	SkipAutoMerge bool
-	Labels        []string
+	Results         *gits.PullRequestInfo
+	BranchName      string
+	GitURLs         []string
+	Base            string
+	Fork            bool
+	SrcGitURL       string
+	Component       string
+	Version         string
+	DryRun          bool
+	SkipCommit      bool
+	SkipAutoMerge   bool
+	Labels          []string
+	BatchConfigFile string
 }
 
 // NewCmdStepCreatePr Steps a command object for the "step" command
@@ -83,6 +84,7 @@ func AddStepCreatePrFlags(cmd *cobra.Command, o *StepCreatePrOptions) {
 	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "Perform a dry run, the change will be generated and committed, but not pushed or have a PR created")
 	cmd.Flags().BoolVarP(&o.SkipAutoMerge, "skip-auto-merge", "", false, "Disable auto merge of the PR if status checks pass")
 	cmd.Flags().StringArrayVarP(&o.Labels, "labels", "", []string{}, "Labels to add to the created PR")
+	cmd.Flags().StringVarP(&o.BatchConfigFile, "batch-config", "", "", "The YAML file which groups the updates into batch PRs. Updates of a group are added to the open PR of the group on each repository rather than creating a PR per update")
 }
 
 // ValidateOptions validates the common options for all PR creation steps
@@ -125,8 +127,10 @@ func (o *StepCreatePrOptions) CreatePullRequest(kind string, update operations.C
 	if o.DryRun {
 		log.Logger().Infof("--dry-run specified. Change will be created and committed to local git repo, but not pushed. No pull request will be created or updated. A fork will still be created.")
 	}
-	op := o.createPullRequestOperation()
-	var err error
+	op, err := o.createPullRequestOperation()
+	if err != nil {
+		return err
+	}
 	o.Results, err = op.CreatePullRequest(kind, update)
 	if err != nil {
 		return errors.Wrap(err, "unable to create pull request")
@@ -134,7 +138,7 @@ func (o *StepCreatePrOptions) CreatePullRequest(kind string, update operations.C
 	return nil
 }
 
-func (o *StepCreatePrOptions) createPullRequestOperation() operations.PullRequestOperation {
+func (o *StepCreatePrOptions) createPullRequestOperation() (operations.PullRequestOperation, error) {
 	op := operations.PullRequestOperation{
 		CommonOptions: o.CommonOptions,
 		GitURLs:       o.GitURLs,
@@ -153,5 +157,11 @@ func (o *StepCreatePrOptions) createPullRequestOperation() operations.PullReques
 		op.AuthorName = authorName
 		op.AuthorEmail = authorEmail
 	}
-	return op
+	if o.BatchConfigFile != "" {
+		op.BatchConfig, err = operations.LoadBatchConfig(o.BatchConfigFile)
+		if err != nil {
+			return op, errors.Wrapf(err, "loading batch configuration %s", o.BatchConfigFile)
+		}
+	}
+	return op, nil
 }
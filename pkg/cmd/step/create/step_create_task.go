@@ -1,6 +1,7 @@
 package create
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -39,6 +40,7 @@ import (
 	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
 )
@@ -71,6 +73,8 @@ var (
 
 	lastPipelineRun = time.Now()
 
+	concurrencyPollInterval = 10 * time.Second
+
 	createTaskOutDir  string
 	createTaskNoApply bool
 )
@@ -121,6 +125,10 @@ type StepCreateTaskOptions struct {
 	AdditionalEnvVars   map[string]string
 	PodTemplates        map[string]*corev1.Pod
 	UseBranchAsRevision bool
+	// QueueInBackground waits for the capacity of the concurrency group and the team in a goroutine rather than
+	// blocking Run, e.g. when invoked by a webhook handler. A pipeline queued in the background is not started if the
+	// process exits before it leaves the queue
+	QueueInBackground bool
 
 	GitInfo                *gits.GitRepository
 	BuildNumber            string
@@ -329,6 +337,14 @@ func (o *StepCreateTaskOptions) Run() error {
 		return err
 	}
 
+	concurrencyGroup, err := o.concurrencyGroup(effectiveProjectConfig)
+	if err != nil {
+		return err
+	}
+	if concurrencyGroup != "" {
+		o.labels[tekton.LabelConcurrencyGroup] = tekton.ConcurrencyGroupLabelValue(concurrencyGroup)
+	}
+
 	log.Logger().Debug("Setting build version")
 	err = o.setBuildVersion(effectiveProjectConfig)
 	if err != nil {
@@ -371,15 +387,64 @@ func (o *StepCreateTaskOptions) Run() error {
 		if o.DisableConcurrent {
 			o.waitForPreviousPipeline(tektonClient, ns, 10*time.Minute)
 		}
-		log.Logger().Infof("Applying changes ")
-		err := tekton.ApplyPipeline(jxClient, kubeClient, tektonClient, tektonCRDs, ns, activityKey)
-		if err != nil {
-			return errors.Wrapf(err, "failed to apply Tekton CRDs")
+		maxBuilds := o.maxConcurrentBuilds(kubeClient, ns)
+		concurrency := effectiveProjectConfig.Concurrency
+		if o.QueueInBackground && (maxBuilds > 0 || (concurrencyGroup != "" && !concurrency.CancelInProgress)) {
+			log.Logger().Infof("Queueing the pipeline %s in the background", tektonCRDs.Name())
+			go func() {
+				err := o.applyPipeline(kubeClient, jxClient, tektonClient, ns, concurrency, concurrencyGroup, maxBuilds, tektonCRDs, activityKey)
+				if err != nil {
+					log.Logger().Errorf("Failed to start the queued pipeline %s: %s", tektonCRDs.Name(), err)
+				}
+			}()
+			return nil
 		}
-		tektonCRDs.AddLabels(o.labels)
+		return o.applyPipeline(kubeClient, jxClient, tektonClient, ns, concurrency, concurrencyGroup, maxBuilds, tektonCRDs, activityKey)
+	}
+	return nil
+}
 
-		log.Logger().Debugf(" for %s", tektonCRDs.PipelineRun().Name)
+// applyPipeline waits until the concurrency group of the pipeline and the team have capacity for it, if they limit
+// their concurrent pipelines, and then applies its Tekton CRDs
+func (o *StepCreateTaskOptions) applyPipeline(kubeClient kubeclient.Interface, jxClient jxclient.Interface, tektonClient tektonclient.Interface, ns string, concurrency *config.ConcurrencyConfig, concurrencyGroup string, maxBuilds int, tektonCRDs *tekton.CRDWrapper, activityKey *kube.PromoteStepActivityKey) error {
+	if concurrencyGroup != "" {
+		release, err := o.enforceConcurrencyGroup(kubeClient, jxClient, tektonClient, ns, concurrency, concurrencyGroup, activityKey)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err := release()
+			if err != nil {
+				log.Logger().Warnf("failed to release the slot of concurrency group %s: %s", concurrencyGroup, err)
+			}
+		}()
+	}
+	if maxBuilds > 0 {
+		timeout, err := concurrency.GetQueueTimeout()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		release, err := tekton.WaitForMaxConcurrentBuilds(ctx, kubeClient, jxClient, tektonClient, ns, activityKey, maxBuilds, concurrencyPollInterval)
+		if err != nil {
+			return errors.Wrapf(err, "failed to wait for the maximum concurrent builds of team %s", ns)
+		}
+		defer func() {
+			err := release()
+			if err != nil {
+				log.Logger().Warnf("failed to release the slot of the maximum concurrent builds: %s", err)
+			}
+		}()
 	}
+	log.Logger().Infof("Applying changes ")
+	err := tekton.ApplyPipeline(jxClient, kubeClient, tektonClient, tektonCRDs, ns, activityKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply Tekton CRDs")
+	}
+	tektonCRDs.AddLabels(o.labels)
+
+	log.Logger().Debugf(" for %s", tektonCRDs.PipelineRun().Name)
 	return nil
 }
 
@@ -465,6 +530,75 @@ restartWatch:
 	}
 }
 
+// concurrencyGroup returns the concurrency group of the pipeline or an empty string if the project does not limit
+// the concurrency of its pipelines
+func (o *StepCreateTaskOptions) concurrencyGroup(projectConfig *config.ProjectConfig) (string, error) {
+	if projectConfig == nil || projectConfig.Concurrency == nil {
+		return "", nil
+	}
+	values := config.ConcurrencyGroupValues{
+		Branch:  o.Branch,
+		Context: o.Context,
+		Kind:    o.PipelineKind,
+	}
+	if o.GitInfo != nil {
+		values.Owner = o.GitInfo.Organisation
+		values.Repository = o.GitInfo.Name
+	}
+	return projectConfig.Concurrency.GroupName(values)
+}
+
+// enforceConcurrencyGroup either cancels the pipelines of the concurrency group which are in progress or waits until
+// the group has capacity for another pipeline. Returns the function which releases the slot of the pipeline in the
+// group once its PipelineRun has been created
+func (o *StepCreateTaskOptions) enforceConcurrencyGroup(kubeClient kubeclient.Interface, jxClient jxclient.Interface, tektonClient tektonclient.Interface, ns string, concurrency *config.ConcurrencyConfig, group string, activityKey *kube.PromoteStepActivityKey) (func() error, error) {
+	if concurrency.CancelInProgress {
+		_, err := tekton.CancelConcurrencyGroupRuns(tektonClient, ns, group)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to cancel the pipelines of concurrency group %s", group)
+		}
+		return func() error {
+			return nil
+		}, nil
+	}
+	timeout, err := concurrency.GetQueueTimeout()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	release, err := tekton.WaitForConcurrencyGroup(ctx, kubeClient, jxClient, tektonClient, ns, activityKey, group, concurrency.GetMaxParallel(), concurrencyPollInterval)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to wait for concurrency group %s", group)
+	}
+	return release, nil
+}
+
+// maxConcurrentBuilds returns the maximum concurrent builds of the team policy or 0 if the team does not limit them
+func (o *StepCreateTaskOptions) maxConcurrentBuilds(kubeClient kubeclient.Interface, ns string) int {
+	policy, err := loadTeamPolicy(kubeClient, ns)
+	if err != nil {
+		log.Logger().Warnf("failed to load the policy of team %s so its maximum concurrent builds are not enforced: %s", ns, err)
+		return 0
+	}
+	if policy == nil {
+		return 0
+	}
+	return policy.MaxConcurrentBuilds
+}
+
+// loadTeamPolicy loads the policy of the team of the namespace from its admin namespace
+func loadTeamPolicy(kubeClient kubeclient.Interface, ns string) (*kube.TeamPolicy, error) {
+	adminNs, err := kube.GetAdminNamespace(kubeClient, ns)
+	if apierrors.IsNotFound(errors.Cause(err)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kube.LoadTeamPolicy(kubeClient, adminNs, ns)
+}
+
 func (o *StepCreateTaskOptions) createEffectiveProjectConfigFromOptions(tektonClient tektonclient.Interface, jxClient jxclient.Interface, kubeClient kubeclient.Interface, ns string, pipelineName string) (*config.ProjectConfig, error) {
 	if o.InterpretMode {
 		// lets allow this command to run in an empty cluster
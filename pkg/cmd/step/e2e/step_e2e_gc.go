@@ -26,6 +26,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// runningClusterStatuses the statuses of running clusters of the providers which use a cluster.Client
+var runningClusterStatuses = map[string]bool{
+	"ACTIVE":    true,
+	"Succeeded": true,
+	"RUNNING":   true,
+}
+
 // StepE2EGCOptions contains the command line flags
 type StepE2EGCOptions struct {
 	step.StepOptions
@@ -70,7 +77,7 @@ func NewCmdStepE2EGC(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Region, "region", "", "europe-west1-c", "GKE region to use. Default: europe-west1-c")
 	cmd.Flags().StringVarP(&options.ProjectID, "project-id", "p", "", "Google Project ID to delete cluster from")
 	cmd.Flags().IntVarP(&options.Duration, "duration", "d", 2, "How many hours old a cluster should be before it is deleted if it does not have a --delete tag")
-	cmd.Flags().StringArrayVarP(&options.Providers, "providers", "", []string{"gke"}, "The providers to run the cleanup for")
+	cmd.Flags().StringArrayVarP(&options.Providers, "providers", "", []string{"gke"}, "The providers to run the cleanup for: gke, eks, aks or kind")
 
 	return cmd
 }
@@ -86,6 +93,8 @@ func (o *StepE2EGCOptions) Run() error {
 			fallthrough
 		case cloud.EKS:
 			return o.eksGarbageCollection()
+		case cloud.AKS, cloud.KIND:
+			return o.clusterClientGarbageCollection(strings.ToLower(pr))
 		default:
 			return fmt.Errorf("provider %s doesn't have an E2E GC implementation defined", pr)
 		}
@@ -94,31 +103,36 @@ func (o *StepE2EGCOptions) Run() error {
 }
 
 func (o *StepE2EGCOptions) eksGarbageCollection() error {
-	eksClient, err := factory.NewClientForProvider(cloud.EKS)
+	return o.clusterClientGarbageCollection(cloud.EKS)
+}
+
+// clusterClientGarbageCollection removes the stale clusters of the provider using its cluster.Client
+func (o *StepE2EGCOptions) clusterClientGarbageCollection(provider string) error {
+	client, err := factory.NewClientForProvider(provider)
 	if err != nil {
-		return errors.Wrap(err, "could not obtain an EKS cluster client to ")
+		return errors.Wrapf(err, "could not obtain a %s cluster client", provider)
 	}
-	eksClusters, err := eksClient.List()
+	clusters, err := client.List()
 	if err != nil {
-		return errors.Wrap(err, "there was a problem obtaining every eksClient in the current account")
+		return errors.Wrapf(err, "there was a problem listing the %s clusters", provider)
 	}
 
-	for _, eksCluster := range eksClusters {
-		if eksCluster.Status == "ACTIVE" {
-			if !o.ShouldDeleteMarkedEKSCluster(eksCluster) {
-				if !o.ShouldDeleteOlderThanDurationEKS(eksCluster) {
-					if o.ShouldDeleteDueToNewerRunEKS(eksCluster, eksClusters) {
-						err = o.deleteEksCluster(eksCluster, eksClient)
+	for _, c := range clusters {
+		if runningClusterStatuses[c.Status] {
+			if !o.ShouldDeleteMarkedEKSCluster(c) {
+				if !o.ShouldDeleteOlderThanDurationEKS(c) {
+					if o.ShouldDeleteDueToNewerRunEKS(c, clusters) {
+						err = o.deleteClusterWithClient(c, client)
 					}
 				} else {
-					err = o.deleteEksCluster(eksCluster, eksClient)
+					err = o.deleteClusterWithClient(c, client)
 				}
 			} else {
-				err = o.deleteEksCluster(eksCluster, eksClient)
+				err = o.deleteClusterWithClient(c, client)
 			}
 		}
 		if err != nil {
-			log.Logger().Errorf("error deleting cluster %s: %s", eksCluster.Name, err.Error())
+			log.Logger().Errorf("error deleting cluster %s: %s", c.Name, err.Error())
 		}
 	}
 	return nil
@@ -316,10 +330,10 @@ func (o *StepE2EGCOptions) ShouldDeleteDueToNewerRun(cluster *gke.Cluster, clust
 	return false
 }
 
-func (o *StepE2EGCOptions) deleteEksCluster(cluster *cluster.Cluster, client cluster.Client) error {
+func (o *StepE2EGCOptions) deleteClusterWithClient(cluster *cluster.Cluster, client cluster.Client) error {
 	err := client.Delete(cluster)
 	if err != nil {
-		return errors.Wrapf(err, "error deleting EKS cluster %s", cluster.Name)
+		return errors.Wrapf(err, "error deleting cluster %s", cluster.Name)
 	}
 	return nil
 }
@@ -32,6 +32,7 @@ func NewCmdStepGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	cmd.AddCommand(NewCmdStepGetBuildNumber(commonOpts))
 	cmd.AddCommand(NewCmdStepGetVersionChangeSet(commonOpts))
+	cmd.AddCommand(NewCmdStepGetDependencyGraph(commonOpts))
 	cmd.AddCommand(NewCmdStepGetDependencyVersion(commonOpts))
 	return cmd
 }
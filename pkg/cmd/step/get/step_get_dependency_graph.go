@@ -0,0 +1,165 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/dependencymatrix"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getDependencyGraphLong = templates.LongDesc(`
+		Outputs the transitive dependency graph of the dependency matrix in the version stream or a local directory
+
+		The graph can be output in the graphviz DOT, mermaid or JSON formats. Dependencies which are reached with
+		different versions via different paths are highlighted.
+
+		With --impact the downstream repositories and paths affected by updating a dependency to a version are
+		listed instead
+`)
+
+	getDependencyGraphExample = templates.Examples(`
+		# output the dependency graph of the version stream as DOT
+		jx step get dependency-graph | dot -Tsvg > graph.svg
+
+		# output the dependency graph of a local directory containing a "dependency-matrix" subdirectory as mermaid
+		jx step get dependency-graph --dir=/some/directory --format mermaid
+
+		# list the repositories affected by updating jx
+		jx step get dependency-graph --impact github.com/jenkins-x/jx@2.1.0
+			`)
+)
+
+// StepGetDependencyGraphOptions contains the command line flags
+type StepGetDependencyGraphOptions struct {
+	step.StepOptions
+
+	Dir    string
+	Root   string
+	Format string
+	Impact string
+}
+
+// NewCmdStepGetDependencyGraph Creates a new Command object
+func NewCmdStepGetDependencyGraph(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepGetDependencyGraphOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "dependency-graph",
+		Short:   "Outputs the transitive dependency graph of the Jenkins X dependency matrix",
+		Long:    getDependencyGraphLong,
+		Example: getDependencyGraphExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "", "", "Directory to read dependency matrix from instead of using the version stream")
+	cmd.Flags().StringVarP(&options.Root, "root", "", "", "The name of the repository containing the dependency matrix. Defaults to the git repository of the directory")
+	cmd.Flags().StringVarP(&options.Format, "format", "", "dot", "The output format. Possible values: dot, mermaid, json")
+	cmd.Flags().StringVarP(&options.Impact, "impact", "", "", "Lists the repositories affected by updating a dependency of the form host/owner/repo@version")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepGetDependencyGraphOptions) Run() error {
+	formats := []string{"dot", "mermaid", "json"}
+	if util.StringArrayIndex(formats, o.Format) < 0 {
+		return util.InvalidOption("format", o.Format, formats)
+	}
+	if o.Dir == "" {
+		resolver, err := o.GetVersionResolver()
+		if err != nil {
+			return err
+		}
+		o.Dir = resolver.VersionsDir
+	}
+	if o.Root == "" {
+		o.Root = "root"
+		gitInfo, err := o.FindGitInfo(o.Dir)
+		if err == nil && gitInfo != nil {
+			o.Root = fmt.Sprintf("%s/%s/%s", gitInfo.Host, gitInfo.Organisation, gitInfo.Name)
+		}
+	}
+
+	matrix, err := dependencymatrix.LoadDependencyMatrix(o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load dependency matrix at %s", o.Dir)
+	}
+
+	if o.Impact != "" {
+		return o.outputImpact(matrix)
+	}
+
+	graph := matrix.Graph(o.Root)
+	for _, node := range graph.Inconsistent() {
+		log.Logger().Warnf("%s is reached with inconsistent versions %s", util.ColorWarning(node.ID), strings.Join(node.Versions, ", "))
+	}
+	switch o.Format {
+	case "json":
+		return o.outputJSON(graph)
+	case "mermaid":
+		fmt.Fprint(o.Out, graph.Mermaid())
+	default:
+		fmt.Fprint(o.Out, graph.DOT())
+	}
+	return nil
+}
+
+func (o *StepGetDependencyGraphOptions) outputImpact(matrix *dependencymatrix.DependencyMatrix) error {
+	ref, err := dependencymatrix.ParseDependencyRef(o.Impact)
+	if err != nil {
+		return err
+	}
+	impact, err := matrix.Impact(o.Root, ref)
+	if err != nil {
+		return err
+	}
+	if impact.Inconsistent() {
+		log.Logger().Warnf("%s is reached with inconsistent versions %s", util.ColorWarning(impact.Dependency), strings.Join(impact.Versions, ", "))
+	}
+	if o.Format == "json" {
+		return o.outputJSON(impact)
+	}
+
+	fmt.Fprintf(o.Out, "Updating %s to %s affects %d repositories:\n\n", impact.Dependency, impact.Version, len(impact.Repositories))
+	for _, r := range impact.Repositories {
+		fmt.Fprintf(o.Out, "  %s\n", r)
+	}
+	fmt.Fprintf(o.Out, "\nPaths:\n\n")
+	table := o.CreateTable()
+	table.AddRow("PATH", "CURRENT VERSION", "UPDATE")
+	for _, p := range impact.Paths {
+		update := ""
+		if p.Update {
+			update = util.ColorInfo(fmt.Sprintf("%s -> %s", p.CurrentVersion, impact.Version))
+		}
+		table.AddRow(strings.Join(p.Path, " -> "), p.CurrentVersion, update)
+	}
+	table.Render()
+	return nil
+}
+
+func (o *StepGetDependencyGraphOptions) outputJSON(value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling to JSON")
+	}
+	fmt.Fprintln(o.Out, string(data))
+	return nil
+}
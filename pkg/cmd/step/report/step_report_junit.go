@@ -5,13 +5,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/builds"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/junit"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/reportingtools"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
@@ -29,6 +35,9 @@ var (
 
 	# Select a single *.junit.xml file and create a report form it
 	jx step report --in-dir /randomdir --out-dir /outdir --target-report test.junit.xml --output-name resulting_report.html
+
+	# Record the results of every *.junit.xml file in the team's test history, detect flaky tests and comment on the Pull Request
+	jx step report junit --in-dir /randomdir --merge --record-history --comment-pr --skip-html
 `)
 )
 
@@ -41,45 +50,16 @@ type StepReportJUnitOptions struct {
 	TargetReport     string
 	SuiteName        string
 	OutputReportName string
+	RecordHistory    bool
+	CommentOnPR      bool
+	SkipHTML         bool
+	BucketURL        string
+	GitOwner         string
+	GitRepository    string
+	FlakyWindow      int
 	DeleteReportFn   func(reportName string) error
 }
 
-// TestSuites is the representation of the root of a *.junit.xml xml file
-type TestSuites struct {
-	XMLName    xml.Name    `xml:"testsuites"`
-	Text       string      `xml:",chardata"`
-	TestSuites []TestSuite `xml:"testsuite"`
-}
-
-// TestSuite is the representation of a <testsuite> of a *.junit.xml xml file
-type TestSuite struct {
-	XMLName  xml.Name   `xml:"testsuite"`
-	Text     string     `xml:",chardata"`
-	Name     string     `xml:"name,attr"`
-	Tests    string     `xml:"tests,attr"`
-	Failures string     `xml:"failures,attr"`
-	Errors   string     `xml:"errors,attr"`
-	Time     string     `xml:"time,attr"`
-	TestCase []TestCase `xml:"testcase"`
-}
-
-// TestCase is the representation of an individual test case within a TestSuite in a *.junit.xml xml file
-type TestCase struct {
-	XMLName   xml.Name `xml:"testcase"`
-	Text      string   `xml:",chardata"`
-	Name      string   `xml:"name,attr"`
-	Classname string   `xml:"classname,attr"`
-	Time      string   `xml:"time,attr"`
-	Failure   *Failure `xml:"failure,omitempty"`
-	SystemOut string   `xml:"system-out"`
-}
-
-// Failure is the representation of a Failure that can be present in a TestCase within a TestSuite in a *.junit.xml xml file
-type Failure struct {
-	Text string `xml:",chardata"`
-	Type string `xml:"type,attr"`
-}
-
 // NewCmdStepReportJUnit Creates a new Command object
 func NewCmdStepReportJUnit(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &StepReportJUnitOptions{
@@ -110,6 +90,13 @@ func NewCmdStepReportJUnit(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.TargetReport, "target-report", "t", "", "The name of a single report file to parse")
 	cmd.Flags().StringVarP(&options.SuiteName, "suite-name", "s", "", "The name of the tests suite to be shown in the HTML report")
 	cmd.Flags().BoolVarP(&options.MergeReports, "merge", "m", false, "Whether or not to merge the report files in the \"in-folder\" to parse them and show it as a single test run")
+	cmd.Flags().BoolVarP(&options.RecordHistory, "record-history", "", false, "Records the test results in the test history of the repository stored in the team's storage bucket and reports any flaky tests")
+	cmd.Flags().BoolVarP(&options.CommentOnPR, "comment-pr", "", false, "Adds a comment to the current Pull Request listing any flaky tests. Requires --record-history")
+	cmd.Flags().BoolVarP(&options.SkipHTML, "skip-html", "", false, "Skips generating the HTML report which requires xunit-viewer")
+	cmd.Flags().StringVarP(&options.BucketURL, "bucket-url", "", "", "The cloud storage bucket URL used to store the test history. Defaults to the team's storage location for the 'tests' classifier")
+	cmd.Flags().StringVarP(&options.GitOwner, "owner", "", "", "The git owner of the repository. Defaults to $REPO_OWNER or the current git repository")
+	cmd.Flags().StringVarP(&options.GitRepository, "repo", "", "", "The git repository name. Defaults to $REPO_NAME or the current git repository")
+	cmd.Flags().IntVarP(&options.FlakyWindow, "flaky-window", "", junit.DefaultFlakyWindow, "The number of recent builds to look at when detecting flaky tests")
 
 	return cmd
 }
@@ -124,17 +111,27 @@ func (o *StepReportJUnitOptions) Run() error {
 		o.DeleteReportFn = util.DeleteFile
 	}
 
+	// check $REPORTS_DIR is set, overridden by "in-folder"
+	if o.ReportsDir == "" {
+		o.ReportsDir = os.Getenv("REPORTS_DIR")
+	}
+
+	if o.RecordHistory {
+		err := o.recordTestHistory()
+		if err != nil {
+			log.Logger().Warnf("there was a problem recording the test history: %s", err.Error())
+		}
+	}
+	if o.SkipHTML {
+		return nil
+	}
+
 	//We want to finish gracefully, otherwise the pipeline would fail
 	err := o.XUnitClient.EnsureXUnitViewer(o.CommonOptions)
 	if err != nil {
 		return logErrorAndExitGracefully("there was a problem ensuring the presence of xunit-viewer", err)
 	}
 
-	// check $REPORTS_DIR is set, overridden by "in-folder"
-	if o.ReportsDir == "" {
-		o.ReportsDir = os.Getenv("REPORTS_DIR")
-	}
-
 	matchingReportFiles, err := o.obtainingMatchingReportFiles()
 	if err != nil {
 		return logErrorAndExitGracefully("there was a problem obtaining the matching report files", err)
@@ -208,44 +205,142 @@ func (o *StepReportJUnitOptions) prepareSingleFileForParsing(resultFileName stri
 func (o *StepReportJUnitOptions) mergeJUnitReportFiles(jUnitReportFiles []string, resultFileName string) error {
 	log.Logger().Infof(util.ColorInfo("Performing merge of *.junit.xml files in %s"), o.ReportsDir)
 
-	aggregatedTestSuites := TestSuites{}
-	for _, v := range jUnitReportFiles {
-		bytes, err := ioutil.ReadFile(v)
+	testSuites, err := junit.ParseFiles(jUnitReportFiles)
+	if err != nil {
+		return err
+	}
+	aggregatedTestSuites := junit.TestSuites{
+		TestSuites: testSuites,
+	}
+
+	suitesBytes, err := xml.Marshal(aggregatedTestSuites)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(resultFileName, suitesBytes, 0600)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recordTestHistory adds the test results to the test history of the repository and reports any flaky tests
+func (o *StepReportJUnitOptions) recordTestHistory() error {
+	var reportFiles []string
+	if o.MergeReports || o.TargetReport == "" {
+		var err error
+		reportFiles, err = o.obtainingMatchingReportFiles()
 		if err != nil {
 			return err
 		}
+	} else {
+		reportFiles = []string{filepath.Join(o.ReportsDir, o.TargetReport)}
+	}
+	suites, err := junit.ParseFiles(reportFiles)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the junit reports")
+	}
 
-		// trying to parse <testsuites></testsuites>
-		var testSuites TestSuites
-		err = xml.Unmarshal(bytes, &testSuites)
+	gitURL := ""
+	if o.GitOwner == "" {
+		o.GitOwner = os.Getenv("REPO_OWNER")
+	}
+	if o.GitRepository == "" {
+		o.GitRepository = os.Getenv("REPO_NAME")
+	}
+	if o.GitOwner == "" || o.GitRepository == "" {
+		gitInfo, err := o.FindGitInfo("")
 		if err != nil {
-			// If no <testsuites></testsuites>, trying to parse <testsuite></testsuite>
-			var testSuite TestSuite
-			err = xml.Unmarshal(bytes, &testSuite)
-			if err != nil {
-				return err
-			}
-			aggregatedTestSuites.TestSuites = append(aggregatedTestSuites.TestSuites, testSuite)
-		} else {
-			for _, testSuite := range testSuites.TestSuites {
-				aggregatedTestSuites.TestSuites = append(aggregatedTestSuites.TestSuites, testSuite)
-			}
+			return errors.Wrap(err, "failed to find the git repository")
 		}
+		o.GitOwner = gitInfo.Organisation
+		o.GitRepository = gitInfo.Name
+		gitURL = gitInfo.URL
 	}
 
-	suitesBytes, err := xml.Marshal(aggregatedTestSuites)
+	if o.BucketURL == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to load the team settings")
+		}
+		o.BucketURL = settings.StorageLocationOrDefault(kube.ClassificationTests).BucketURL
+	}
+	if o.BucketURL == "" {
+		return util.MissingOption("bucket-url")
+	}
+
+	commit := os.Getenv("PULL_PULL_SHA")
+	if commit == "" {
+		commit = os.Getenv("PULL_BASE_SHA")
+	}
+	if commit == "" {
+		commit, err = o.Git().GetLatestCommitSha("")
+		if err != nil {
+			log.Logger().Warnf("failed to find the latest git commit: %s", err.Error())
+		}
+	}
+
+	history, err := junit.LoadHistory(o.BucketURL, o.GitOwner, o.GitRepository)
 	if err != nil {
 		return err
 	}
-
-	err = ioutil.WriteFile(resultFileName, suitesBytes, 0600)
+	history.AddRun(&junit.TestRun{
+		Build:     builds.GetBuildNumber(),
+		Branch:    os.Getenv(util.EnvVarBranchName),
+		Commit:    commit,
+		Timestamp: time.Now(),
+		Results:   junit.Results(suites),
+	}, junit.DefaultMaxRuns)
+	err = junit.SaveHistory(o.BucketURL, history)
 	if err != nil {
 		return err
 	}
+	log.Logger().Infof("recorded the test history for %s/%s in %s", o.GitOwner, o.GitRepository, util.ColorInfo(o.BucketURL))
 
+	flakes := history.FlakyTests(o.FlakyWindow)
+	if len(flakes) == 0 {
+		return nil
+	}
+	for _, f := range flakes {
+		log.Logger().Warnf("flaky test %s passed %d and failed %d times", util.ColorWarning(f.Name), f.Passes, f.Failures)
+	}
+	if o.CommentOnPR {
+		return o.commentFlakyTests(gitURL, flakes)
+	}
 	return nil
 }
 
+func (o *StepReportJUnitOptions) commentFlakyTests(gitURL string, flakes []*junit.FlakyTest) error {
+	prText := os.Getenv("PULL_NUMBER")
+	if prText == "" {
+		log.Logger().Infof("no $PULL_NUMBER so not commenting on a Pull Request")
+		return nil
+	}
+	prNumber, err := strconv.Atoi(prText)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse Pull Request number %s", prText)
+	}
+	if gitURL == "" {
+		gitInfo, err := o.FindGitInfo("")
+		if err != nil {
+			return errors.Wrap(err, "failed to find the git repository")
+		}
+		gitURL = gitInfo.URL
+	}
+	provider, err := o.GitProviderForURL(gitURL, "user name to submit comment as")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the git provider for %s", gitURL)
+	}
+	pr := &gits.GitPullRequest{
+		Owner:  o.GitOwner,
+		Repo:   o.GitRepository,
+		Number: &prNumber,
+	}
+	return provider.AddPRComment(pr, junit.FlakyTestsMarkdown(flakes, o.FlakyWindow))
+}
+
 func logErrorAndExitGracefully(message string, err error) error {
 	log.Logger().Errorf("%s: %+v", message, err.Error())
 	return nil
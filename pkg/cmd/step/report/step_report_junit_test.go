@@ -15,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	log2 "github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/junit"
 	reportingtools_test "github.com/jenkins-x/jx/v2/pkg/reportingtools/mocks"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/petergtz/pegomock"
@@ -122,7 +123,7 @@ func TestReportWithMultipleFiles(t *testing.T) {
 	reportBytes, err := ioutil.ReadFile(targetFileName)
 	assert.NoError(t, err)
 
-	var testSuites TestSuites
+	var testSuites junit.TestSuites
 	err = xml.Unmarshal(reportBytes, &testSuites)
 	assert.NoError(t, err, "There shouldn't be an error Unmarshalling the resulting merged report")
 
@@ -178,6 +179,83 @@ func TestErrorNoMatchingFilesFound(t *testing.T) {
 	assert.Equal(t, "ERROR: there was a problem obtaining the matching report files: no report files to parse in test_data/junit/empty_dir, skipping\n", stripansi.Strip(output))
 }
 
+func TestRecordTestHistory(t *testing.T) {
+	mock := reportingtools_test.NewMockXUnitClient(pegomock.WithT(t))
+
+	dirName, err := ioutil.TempDir("", uuid.New().String())
+	defer os.RemoveAll(dirName)
+	assert.NoError(t, err, "there shouldn't be any problem creating a temp dir")
+
+	commitEnv := "PULL_PULL_SHA"
+	originalCommit := os.Getenv(commitEnv)
+	defer os.Setenv(commitEnv, originalCommit)
+	os.Setenv(commitEnv, "abc123")
+
+	o := StepReportJUnitOptions{
+		XUnitClient:   mock,
+		ReportsDir:    filepath.Join("test_data", "junit", "history_report"),
+		MergeReports:  true,
+		RecordHistory: true,
+		SkipHTML:      true,
+		BucketURL:     "file://" + dirName,
+		GitOwner:      "myorg",
+		GitRepository: "myrepo",
+		FlakyWindow:   junit.DefaultFlakyWindow,
+	}
+
+	err = o.Run()
+	assert.NoError(t, err)
+
+	mock.VerifyWasCalled(pegomock.Never()).EnsureXUnitViewer(AnyCommonOptions())
+
+	history, err := junit.LoadHistory(o.BucketURL, "myorg", "myrepo")
+	assert.NoError(t, err)
+	if assert.Len(t, history.Runs, 1) {
+		assert.Equal(t, "abc123", history.Runs[0].Commit)
+		assert.Equal(t, map[string]junit.TestStatus{
+			"import_applications.import spring-boot-http-gradle": junit.TestStatusPassed,
+			"import_applications.import golang-http":             junit.TestStatusFailed,
+		}, history.Runs[0].Results)
+	}
+}
+
+func TestReportWhenRecordingTestHistoryFails(t *testing.T) {
+	mock := reportingtools_test.NewMockXUnitClient(pegomock.WithT(t))
+
+	dirName, err := ioutil.TempDir("", uuid.New().String())
+	defer os.RemoveAll(dirName)
+	assert.NoError(t, err, "there shouldn't be any problem creating a temp dir")
+
+	commitEnv := "PULL_PULL_SHA"
+	originalCommit := os.Getenv(commitEnv)
+	defer os.Setenv(commitEnv, originalCommit)
+	os.Setenv(commitEnv, "abc123")
+
+	reportName := uuid.New().String() + ".html"
+	o := StepReportJUnitOptions{
+		XUnitClient:      mock,
+		ReportsDir:       filepath.Join("test_data", "junit", "single_report"),
+		TargetReport:     "import_applications.junit.xml",
+		DeleteReportFn:   func(reportName string) (err error) { return },
+		OutputReportName: reportName,
+		StepReportOptions: StepReportOptions{
+			OutputDir: dirName,
+		},
+		RecordHistory: true,
+		BucketURL:     "nosuchscheme://bucket",
+		GitOwner:      "myorg",
+		GitRepository: "myrepo",
+	}
+
+	output := log2.CaptureOutput(func() {
+		err = o.Run()
+		assert.NoError(t, err)
+	})
+
+	assert.Contains(t, stripansi.Strip(output), "WARNING: there was a problem recording the test history")
+	mock.VerifyWasCalledOnce().CreateHTMLReport(pegomock.EqString(filepath.Join(dirName, reportName)), pegomock.EqString(""), pegomock.AnyString())
+}
+
 func AnyCommonOptions() *opts.CommonOptions {
 	pegomock.RegisterMatcher(pegomock.NewAnyMatcher(reflect.TypeOf((**opts.CommonOptions)(nil)).Elem()))
 	return nil
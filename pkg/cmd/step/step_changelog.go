@@ -21,6 +21,7 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/semrel"
 
 	"github.com/pkg/errors"
 
@@ -68,6 +69,7 @@ type StepChangelogOptions struct {
 	NoReleaseInDev      bool
 	IncludeMergeCommits bool
 	FailIfFindCommits   bool
+	Component           string
 	State               StepChangelogState
 }
 
@@ -78,6 +80,8 @@ type StepChangelogState struct {
 	FoundIssueNames map[string]bool
 	LoggedIssueKind bool
 	Release         *v1.Release
+	// PreReleases the pre-releases of the version which are included in the changelog of a final release
+	PreReleases []*semrel.ComponentRelease
 }
 
 const (
@@ -188,6 +192,7 @@ func NewCmdStepChangelog(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.UpdateRelease, "update-release", "", true, "Should we update the release on the Git repository with the changelog")
 	cmd.Flags().BoolVarP(&options.NoReleaseInDev, "no-dev-release", "", false, "Disables the generation of Release CRDs in the development namespace to track releases being performed")
 	cmd.Flags().BoolVarP(&options.IncludeMergeCommits, "include-merge-commits", "", false, "Include merge commits when generating the changelog")
+	cmd.Flags().StringVarP(&options.Component, "component", "", "", "the path of a component of a monorepo to generate the changelog of. Only the commits changing the path or using the component name as their scope are included and the component tags such as svc-a/v1.4.0 are used to find the revisions")
 	cmd.Flags().BoolVarP(&options.FailIfFindCommits, "fail-if-no-commits", "", false, "Do we want to fail the build if we don't find any commits to generate the changelog")
 
 	cmd.Flags().StringVarP(&options.Header, "header", "", "", "The changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
@@ -219,6 +224,19 @@ func (o *StepChangelogOptions) Run() error {
 	if err != nil {
 		return errors.Wrapf(err, "error unshallowing git repo in %s", dir)
 	}
+	var component *semrel.Component
+	if o.Component != "" {
+		component = semrel.NewComponent(o.Component)
+		err = o.defaultComponentRevisions(dir, component)
+		if err != nil {
+			return err
+		}
+	} else if o.Version != "" && o.PreviousRevision == "" && o.PreviousDate == "" {
+		err = o.defaultPreReleaseRevisions(dir)
+		if err != nil {
+			return err
+		}
+	}
 	previousRev := o.PreviousRevision
 	if previousRev == "" {
 		previousDate := o.PreviousDate
@@ -255,6 +273,12 @@ func (o *StepChangelogOptions) Run() error {
 	}
 
 	templatesDir := o.TemplatesDir
+	if templatesDir == "" && component != nil {
+		templatesDir, err = findComponentTemplatesDir(filepath.Join(dir, component.Path))
+		if err != nil {
+			return err
+		}
+	}
 	if templatesDir == "" {
 		chartFile, err := o.FindHelmChart()
 		if err != nil {
@@ -325,6 +349,12 @@ func (o *StepChangelogOptions) Run() error {
 		}
 		log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
 	}
+	if commits != nil && component != nil {
+		commits, err = o.filterComponentCommits(dir, component, previousRev, currentRev, commits)
+		if err != nil {
+			return err
+		}
+	}
 	if commits != nil {
 		commits1 := *commits
 		if len(commits1) > 0 {
@@ -402,7 +432,7 @@ func (o *StepChangelogOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	markdown = header + markdown + footer
+	markdown = header + markdown + o.preReleasesMarkdown(gitInfo) + footer
 
 	log.Logger().Debugf("Generated release notes:\n\n%s\n", markdown)
 
@@ -435,10 +465,14 @@ func (o *StepChangelogOptions) Run() error {
 		if foundVTag && !foundTag {
 			tagName = vVersion
 		}
+		if component != nil {
+			tagName = component.Tag(version)
+		}
 		releaseInfo := &gits.GitRelease{
-			Name:    version,
-			TagName: tagName,
-			Body:    markdown,
+			Name:       version,
+			TagName:    tagName,
+			Body:       markdown,
+			PreRelease: isPreRelease(version),
 		}
 		url := releaseInfo.HTMLURL
 		if url == "" {
@@ -452,6 +486,12 @@ func (o *StepChangelogOptions) Run() error {
 			log.Logger().Warnf("Failed to update the release at %s: %s", url, err)
 			return nil
 		}
+		if releaseInfo.PreRelease {
+			err = gitProvider.UpdateReleaseStatus(gitInfo.Organisation, gitInfo.Name, tagName, releaseInfo)
+			if err != nil {
+				log.Logger().Warnf("Failed to mark the release at %s as a pre-release: %s", url, err)
+			}
+		}
 		release.Spec.ReleaseNotesURL = url
 		log.Logger().Infof("Updated the release information at %s", util.ColorInfo(url))
 
@@ -545,7 +585,9 @@ func (o *StepChangelogOptions) Run() error {
 		}
 	}
 	appName := ""
-	if gitInfo != nil {
+	if component != nil {
+		appName = component.Name
+	} else if gitInfo != nil {
 		appName = gitInfo.Name
 	}
 	if appName == "" {
@@ -619,6 +661,143 @@ func (o *StepChangelogOptions) Run() error {
 	return nil
 }
 
+// defaultComponentRevisions defaults the revisions of the changelog to the latest release of the component
+// or the release of the version and the release before it
+func (o *StepChangelogOptions) defaultComponentRevisions(dir string, component *semrel.Component) error {
+	if o.PreviousRevision != "" && o.CurrentRevision != "" {
+		return nil
+	}
+	releases, err := semrel.FindComponentReleases(dir, o.Git(), component)
+	if err != nil {
+		return err
+	}
+	idx := len(releases) - 1
+	if o.Version != "" {
+		tag := component.Tag(o.Version)
+		for i, r := range releases {
+			if r.Tag == tag {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	if o.CurrentRevision == "" {
+		o.CurrentRevision = releases[idx].SHA
+	}
+	if o.PreviousRevision == "" && o.PreviousDate == "" && idx > 0 {
+		o.PreviousRevision = releases[idx-1].SHA
+		if releases[idx].Version.Prerelease() == "" {
+			// lets include the changes of the pre-releases in the final release
+			previous := semrel.LatestFinalRelease(releases[:idx], nil)
+			o.PreviousRevision = ""
+			if previous != nil {
+				o.PreviousRevision = previous.SHA
+			}
+			o.State.PreReleases = semrel.FindPreReleases(releases, releases[idx].Version)
+		}
+	}
+	if o.PreviousRevision == "" && o.PreviousDate == "" {
+		// lets assume this is the first release of the component
+		o.PreviousRevision, err = o.Git().GetFirstCommitSha(dir)
+		if err != nil {
+			return errors.Wrap(err, "failed to find first commit as there is no previous release of the component")
+		}
+	}
+	return nil
+}
+
+// defaultPreReleaseRevisions defaults the previous revision of a final release which has pre-releases to the
+// previous final release so that the changes of all the pre-releases are included
+func (o *StepChangelogOptions) defaultPreReleaseRevisions(dir string) error {
+	version := semrel.NewComponent("").ParseTag(o.Version)
+	if version == nil || version.Prerelease() != "" {
+		return nil
+	}
+	releases, err := semrel.FindComponentReleases(dir, o.Git(), semrel.NewComponent(""))
+	if err != nil {
+		return err
+	}
+	preReleases := semrel.FindPreReleases(releases, version)
+	if len(preReleases) == 0 {
+		return nil
+	}
+	o.State.PreReleases = preReleases
+	previous := semrel.LatestFinalRelease(releases, version)
+	if previous != nil {
+		o.PreviousRevision = previous.SHA
+		return nil
+	}
+	o.PreviousRevision, err = o.Git().GetFirstCommitSha(dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to find first commit as there is no previous final release")
+	}
+	return nil
+}
+
+// preReleasesMarkdown returns the markdown listing the pre-releases included in a final release
+func (o *StepChangelogOptions) preReleasesMarkdown(gitInfo *gits.GitRepository) string {
+	if len(o.State.PreReleases) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	buffer.WriteString("\n### Pre-releases\n\nThis release includes the changes of the pre-releases:\n\n")
+	for _, r := range o.State.PreReleases {
+		buffer.WriteString(fmt.Sprintf("* [%s](%s)\n", r.Version.String(), util.UrlJoin(gitInfo.HttpsURL(), "releases/tag", r.Tag)))
+	}
+	return buffer.String()
+}
+
+// isPreRelease returns true if the version is a semantic version with a pre-release such as 1.3.0-rc.1
+func isPreRelease(version string) bool {
+	v := semrel.NewComponent("").ParseTag(version)
+	return v != nil && v.Prerelease() != ""
+}
+
+// filterComponentCommits removes the commits which do not change the component
+func (o *StepChangelogOptions) filterComponentCommits(dir string, component *semrel.Component, previousRev string, currentRev string, commits *[]object.Commit) (*[]object.Commit, error) {
+	componentCommits, err := semrel.ComponentCommits(dir, o.Git(), component, previousRev, currentRev)
+	if err != nil {
+		return nil, err
+	}
+	shas := map[string]bool{}
+	for _, c := range componentCommits {
+		shas[c.SHA] = true
+	}
+	answer := make([]object.Commit, 0)
+	for _, c := range *commits {
+		if shas[c.Hash.String()] {
+			answer = append(answer, c)
+		}
+	}
+	log.Logger().Infof("Found %d commits for component %s", len(answer), util.ColorInfo(component.Name))
+	return &answer, nil
+}
+
+// findComponentTemplatesDir returns the templates directory of the chart of a component or an empty string if there is no chart
+func findComponentTemplatesDir(componentDir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(componentDir, "charts", "*", "Chart.yaml"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find charts in %s", componentDir)
+	}
+	files = append([]string{filepath.Join(componentDir, "Chart.yaml")}, files...)
+	for _, file := range files {
+		if filepath.Base(filepath.Dir(file)) == "preview" {
+			continue
+		}
+		exists, err := util.FileExists(file)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return filepath.Join(filepath.Dir(file), "templates"), nil
+		}
+	}
+	return "", nil
+}
+
 func (o *StepChangelogOptions) addCommit(spec *v1.ReleaseSpec, commit *object.Commit, resolver *users.GitUserResolver) {
 	// TODO
 	url := ""
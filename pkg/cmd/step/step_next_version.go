@@ -1,12 +1,9 @@
 package step
 
 import (
-	"bufio"
-	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 
@@ -16,12 +13,14 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/jenkins-x/jx/v2/pkg/builds"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/config"
 
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionfile"
 
-	"encoding/json"
-
+	semver2 "github.com/Masterminds/semver"
 	"github.com/blang/semver"
 	version "github.com/hashicorp/go-version"
 	"github.com/jenkins-x/jx-logging/pkg/log"
@@ -32,9 +31,6 @@ import (
 
 const (
 	packagejson = "package.json"
-	chartyaml   = "Chart.yaml"
-	pomxml      = "pom.xml"
-	makefile    = "Makefile"
 )
 
 // StepNextVersionOptions contains the command line flags
@@ -46,20 +42,18 @@ type StepNextVersionOptions struct {
 	UseGitTagOnly   bool
 	NewVersion      string
 	SemanticRelease bool
+	Component       string
+	Channel         string
 	step.StepOptions
 }
 
-type Project struct {
-	Version string `xml:"version"`
-}
-
-type PackageJSON struct {
-	Version string `json:"version"`
-}
-
 var (
 	StepNextVersionLong = templates.LongDesc(`
 		This pipeline step command works out a semantic version, writes a file ./VERSION and optionally updates a file
+
+		The current version is read from the file given by --filename. If no file is specified the version file is
+		detected from the supported files: pom.xml, gradle.properties, build.gradle(.kts), package.json, Cargo.toml,
+		pyproject.toml, setup.cfg, *.csproj, Chart.yaml, Makefile and version.go
 `)
 
 	StepNextVersionExample = templates.Examples(`
@@ -67,9 +61,16 @@ var (
 		jx step next-version --filename package.json
 		jx step next-version --filename package.json --tag
 		jx step next-version --filename package.json --tag --version 1.2.3
+		jx step next-version --filename Cargo.toml
 
 		# lets use git to create a new version from a tag and tag git
         jx step next-version --use-git-tag-only --tag
+
+		# lets use the conventional commits of a component of a monorepo to create a tag such as svc-a/v1.4.0
+		jx step next-version --semantic-release --component services/svc-a --tag
+
+		# lets create a pre-release of the beta channel such as 1.4.0-beta.2
+		jx step next-version --semantic-release --channel beta --tag
               
 `)
 )
@@ -92,20 +93,51 @@ func NewCmdStepNextVersion(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
-	cmd.Flags().StringVarP(&options.Filename, "filename", "f", "", "Filename that contains version property to update, e.g. package.json")
+	cmd.Flags().StringVarP(&options.Filename, "filename", "f", "", "Filename that contains version property to update, e.g. package.json. If not specified the version file is detected and only used to work out the version")
 	cmd.Flags().StringVarP(&options.NewVersion, "version", "", "", "optional version to use rather than generating a new one")
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "the directory to look for files that contain a pom.xml or Makefile with the project version to bump")
 	cmd.Flags().StringVarP(&options.ChartsDir, "charts-dir", "", "", "the directory of the chart to update the version (in conjunction with --tag)")
 	cmd.Flags().BoolVarP(&options.Tag, "tag", "t", false, "tag and push new version")
-	cmd.Flags().BoolVarP(&options.UseGitTagOnly, "use-git-tag-only", "", false, "only use a git tag so work out new semantic version, else specify filename [pom.xml,package.json,Makefile,Chart.yaml]")
+	cmd.Flags().BoolVarP(&options.UseGitTagOnly, "use-git-tag-only", "", false, "only use a git tag so work out new semantic version, else the version in the --filename or detected version file is used")
 	cmd.Flags().BoolVarP(&options.SemanticRelease, "semantic-release", "", false, "use conventional commits to determine next version. Ignores the --use-git-tag-only and --version options See https://github.com/angular/angular.js/blob/master/DEVELOPERS.md#-git-commit-guidelines")
+	cmd.Flags().StringVarP(&options.Channel, "channel", "", "", "the pre-release channel such as beta or rc to create the next semantic release version for. It must be one of the release.channels of the jenkins-x.yml and defaults to the channel of the current branch")
+	cmd.Flags().StringVarP(&options.Component, "component", "", "", "the path of a component of a monorepo to version independently. Only the commits changing the path or using the component name as their scope are used and tags are prefixed with the component name, e.g. svc-a/v1.4.0")
 	return cmd
 }
 
 func (o *StepNextVersionOptions) Run() error {
 
-	var err error
-	if o.SemanticRelease {
+	channel, useChannels, err := o.releaseChannel()
+	if err != nil {
+		return err
+	}
+	if o.SemanticRelease && (o.Component != "" || useChannels) {
+		err := o.Git().FetchTags(o.Dir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		cur, err := o.Git().RevParse(o.Dir, "HEAD")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		component := semrel.NewComponent(o.Component)
+		var newVersion *semver2.Version
+		var latest *semrel.ComponentRelease
+		if useChannels {
+			newVersion, latest, err = semrel.GetNewChannelVersion(o.Dir, cur, o.Git(), component, channel)
+		} else {
+			newVersion, latest, err = semrel.GetNewComponentVersion(o.Dir, cur, o.Git(), component)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "getting new semantic release version for component %s", component.Name)
+		}
+		if latest != nil {
+			log.Logger().Infof("latest tag %s and rev %s", util.ColorInfo(latest.Tag), util.ColorInfo(latest.SHA))
+		} else if component.Name != "" {
+			log.Logger().Infof("no previous release of component %s", util.ColorInfo(component.Name))
+		}
+		o.NewVersion = newVersion.String()
+	} else if o.SemanticRelease {
 		err := o.Git().FetchTags(o.Dir)
 		if err != nil {
 			return errors.WithStack(err)
@@ -153,6 +185,7 @@ func (o *StepNextVersionOptions) Run() error {
 			Flags: StepTagFlags{
 				Version:   o.NewVersion,
 				ChartsDir: o.ChartsDir,
+				Component: o.Component,
 			},
 			StepOptions: o.StepOptions,
 		}
@@ -164,100 +197,75 @@ func (o *StepNextVersionOptions) Run() error {
 	return nil
 }
 
-// GetVersion gets the version from a source file
-func (o *StepNextVersionOptions) GetVersion() (string, error) {
-	if o.UseGitTagOnly {
-		return "", nil
+// releaseChannel returns the pre-release channel to version, if any, and whether release channels are used.
+// Release channels are only used for semantic releases of projects which configure them in the project configuration.
+// Unless the channel is specified the channel of the current branch is used
+func (o *StepNextVersionOptions) releaseChannel() (string, bool, error) {
+	if !o.SemanticRelease {
+		if o.Channel != "" {
+			return "", false, errors.New("the --channel option requires the --semantic-release option")
+		}
+		return "", false, nil
 	}
-	if o.Filename == "" {
-		// try and work out
-		return "", fmt.Errorf("no filename flag set to work out next semantic version.  choose pom.xml, Chart.yaml, package.json, Makefile or set the flag use-git-tag-only")
+	projectConfig, fileName, err := config.LoadProjectConfig(o.Dir)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "loading project configuration %s", fileName)
 	}
-
-	switch o.Filename {
-	case chartyaml:
-		chartFile := filepath.Join(o.Dir, chartyaml)
-		chart, err := ioutil.ReadFile(chartFile)
-		if err != nil {
-			return "", err
-		}
-
-		log.Logger().Debugf("Found Chart.yaml")
-		scanner := bufio.NewScanner(strings.NewReader(string(chart)))
-		for scanner.Scan() {
-			if strings.Contains(scanner.Text(), "version") {
-				parts := strings.Split(scanner.Text(), ":")
-
-				v := strings.TrimSpace(parts[1])
-				if v != "" {
-					log.Logger().Debugf("existing Chart version %v", v)
-					return v, nil
-				}
+	releaseConfig := projectConfig.Release
+	if o.Channel != "" {
+		names := releaseConfig.ChannelNames()
+		if util.StringArrayIndex(names, o.Channel) < 0 {
+			if len(names) == 0 {
+				return "", false, fmt.Errorf("no release channels are configured in %s so there is no channel %s", fileName, o.Channel)
 			}
+			return "", false, util.InvalidOption("channel", o.Channel, names)
 		}
-	case packagejson:
-		packageFile := filepath.Join(o.Dir, packagejson)
-		p, err := ioutil.ReadFile(packageFile)
-		if err != nil {
-			return "", err
-		}
-
-		log.Logger().Debugf("found %s", packagejson)
-
-		var jsPackage PackageJSON
-		err = json.Unmarshal(p, &jsPackage)
-		if err != nil {
-			return "", err
-		}
-
-		if jsPackage.Version != "" {
-			log.Logger().Debugf("existing version %s", jsPackage.Version)
-			return jsPackage.Version, nil
-		}
-
-	case pomxml:
-		pomFile := filepath.Join(o.Dir, pomxml)
-		p, err := ioutil.ReadFile(pomFile)
-		if err != nil {
-			return "", err
-		}
-
-		log.Logger().Debugf("found pom.xml")
-		var project Project
-		err = xml.Unmarshal(p, &project)
+		return o.Channel, true, nil
+	}
+	if releaseConfig == nil || len(releaseConfig.Channels) == 0 {
+		return "", false, nil
+	}
+	branch := builds.GetBranchName()
+	if branch == "" {
+		branch, err = o.Git().Branch(o.Dir)
 		if err != nil {
-			return "", err
-		}
-		if project.Version != "" {
-			log.Logger().Debugf("existing version %s", project.Version)
-			return project.Version, nil
+			return "", false, errors.Wrapf(err, "getting the current branch in %s", o.Dir)
 		}
+	}
+	channel := releaseConfig.ChannelForBranch(branch)
+	if channel != "" {
+		log.Logger().Infof("using release channel %s for branch %s", util.ColorInfo(channel), util.ColorInfo(branch))
+	}
+	return channel, true, nil
+}
 
-	case makefile:
-		makefile := filepath.Join(o.Dir, makefile)
-		m, err := ioutil.ReadFile(makefile)
+// GetVersion gets the version from a source file. If no filename is specified the version file is detected
+func (o *StepNextVersionOptions) GetVersion() (string, error) {
+	if o.UseGitTagOnly {
+		return "", nil
+	}
+	dir := o.versionDir()
+	filename := o.Filename
+	if filename == "" {
+		name, handler, err := versionfile.Detect(dir)
 		if err != nil {
-			return "", err
+			return "", errors.Wrap(err, "failed to detect the version file")
 		}
-
-		log.Logger().Debugf("found Makefile")
-		scanner := bufio.NewScanner(strings.NewReader(string(m)))
-		for scanner.Scan() {
-			if strings.HasPrefix(scanner.Text(), "VERSION") || strings.HasPrefix(scanner.Text(), "VERSION ") || strings.HasPrefix(scanner.Text(), "VERSION:") || strings.HasPrefix(scanner.Text(), "VERSION=") {
-				parts := strings.Split(scanner.Text(), "=")
-
-				v := strings.TrimSpace(parts[1])
-				if v != "" {
-					log.Logger().Debugf("existing Makefile version %s", v)
-					return v, nil
-				}
-			}
+		if handler == nil {
+			return "", fmt.Errorf("no filename flag set to work out next semantic version and no version file found.  choose one of %s or set the flag use-git-tag-only", strings.Join(versionfile.SupportedFiles(), ", "))
 		}
-	default:
+		log.Logger().Debugf("found %s version file %s", handler.Name(), name)
+		filename = name
+	}
+	if versionfile.FindHandler(filename) == nil {
 		return "", fmt.Errorf("no recognised file to obtain current version from")
 	}
-
-	return "", fmt.Errorf("cannot find version for file %s\n", o.Filename)
+	v, err := versionfile.GetVersion(dir, filename)
+	if err != nil {
+		return "", err
+	}
+	log.Logger().Debugf("existing version %s in %s", v, filename)
+	return v, nil
 }
 
 func (o *StepNextVersionOptions) getLatestTag() (string, error) {
@@ -279,8 +287,19 @@ func (o *StepNextVersionOptions) getLatestTag() (string, error) {
 
 	// build an array of all the tags
 	versionsRaw = make([]string, len(tags))
+	var component *semrel.Component
+	if o.Component != "" {
+		component = semrel.NewComponent(o.Component)
+	}
 	for i, tag := range tags {
 		log.Logger().Debugf("found tag %s", tag)
+		if component != nil {
+			// only use the tags of the component
+			if v := component.ParseTag(tag); v != nil {
+				versionsRaw[i] = v.String()
+			}
+			continue
+		}
 		tag = strings.TrimPrefix(tag, "v")
 		if tag != "" {
 			versionsRaw[i] = tag
@@ -368,38 +387,8 @@ func (o *StepNextVersionOptions) getNewVersionFromTagAndFile() (string, error) {
 
 // SetVersion Sets the version...
 func (o *StepNextVersionOptions) SetVersion() error {
-	var err error
-	var matchField string
-	var regex *regexp.Regexp
-	filename := filepath.Join(o.Dir, o.Filename)
-	b, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	switch o.Filename {
-	case packagejson:
-		regex = regexp.MustCompile(`[0-9][0-9]{0,2}.[0-9][0-9]{0,2}(.[0-9][0-9]{0,2})?(.[0-9][0-9]{0,2})?(-development)?`)
-		matchField = "\"version\": \""
-
-	case chartyaml:
-		regex = regexp.MustCompile(`[0-9][0-9]{0,2}.[0-9][0-9]{0,2}(.[0-9][0-9]{0,2})?(.[0-9][0-9]{0,2})?(-.*)?`)
-		matchField = "version: "
-
-	default:
-		return fmt.Errorf("unrecognised filename %s, supported files are %s %s", o.Filename, packagejson, chartyaml)
-	}
-
-	lines := strings.Split(string(b), "\n")
-
-	for i, line := range lines {
-		if strings.Contains(line, matchField) {
-			lines[i] = regex.ReplaceAllString(line, o.NewVersion)
-		} else {
-			lines[i] = line
-		}
-	}
-	output := strings.Join(lines, "\n")
-	err = ioutil.WriteFile(filename, []byte(output), 0600)
+	dir := o.versionDir()
+	err := versionfile.SetVersion(dir, o.Filename, o.NewVersion)
 	if err != nil {
 		return err
 	}
@@ -408,16 +397,28 @@ func (o *StepNextVersionOptions) SetVersion() error {
 		// lets not commit to git as we do that in the tag step
 		return nil
 	}
-	err = o.Git().Add(o.Dir, o.Filename)
+	err = o.Git().Add(dir, o.Filename)
 	if err != nil {
 		return err
 	}
 
-	err = o.Git().CommitDir(o.Dir, fmt.Sprintf("release %s", o.NewVersion))
+	message := fmt.Sprintf("release %s", o.NewVersion)
+	if o.Component != "" {
+		message = fmt.Sprintf("release %s %s", semrel.NewComponent(o.Component).Name, o.NewVersion)
+	}
+	err = o.Git().CommitDir(dir, message)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// versionDir returns the directory containing the version file which is the directory of the component if there is one
+func (o *StepNextVersionOptions) versionDir() string {
+	if o.Component != "" {
+		return filepath.Join(o.Dir, o.Component)
+	}
+	return o.Dir
+}
+
 // returns a string array containing the git owner and repo name for a given URL
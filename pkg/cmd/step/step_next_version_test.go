@@ -3,15 +3,62 @@
 package step_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	step2 "github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/step"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestNextVersionInvalidChannel(t *testing.T) {
+	t.Parallel()
+
+	channelsDir, err := ioutil.TempDir("", "test-next-version-channels-")
+	require.NoError(t, err)
+	defer os.RemoveAll(channelsDir)
+	projectConfig := "release:\n  channels:\n  - branch: develop\n    name: beta\n"
+	err = ioutil.WriteFile(filepath.Join(channelsDir, config.ProjectConfigFileName), []byte(projectConfig), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	noChannelsDir, err := ioutil.TempDir("", "test-next-version-no-channels-")
+	require.NoError(t, err)
+	defer os.RemoveAll(noChannelsDir)
+
+	tests := []struct {
+		name            string
+		dir             string
+		semanticRelease bool
+		expectedError   string
+	}{
+		{name: "without semantic release", dir: channelsDir, expectedError: "requires the --semantic-release option"},
+		{name: "without channels", dir: noChannelsDir, semanticRelease: true, expectedError: "no release channels are configured"},
+		{name: "unknown channel", dir: channelsDir, semanticRelease: true, expectedError: "Invalid option: --channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := step.StepNextVersionOptions{
+				StepOptions: step2.StepOptions{
+					CommonOptions: &opts.CommonOptions{},
+				},
+				Dir:             tt.dir,
+				SemanticRelease: tt.semanticRelease,
+				Channel:         "alpha",
+			}
+			err := o.Run()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
 func TestMakefile(t *testing.T) {
 	t.Parallel()
 	o := step.StepNextVersionOptions{
@@ -62,3 +109,19 @@ func TestChart(t *testing.T) {
 
 	assert.Equal(t, "0.0.1-SNAPSHOT", v, "error with GetVersion for a Chart.yaml")
 }
+
+func TestDetectVersionFile(t *testing.T) {
+	t.Parallel()
+	o := step.StepNextVersionOptions{
+		StepOptions: step2.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		Dir: "test_data/next_version/java",
+	}
+
+	v, err := o.GetVersion()
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1.0-SNAPSHOT", v, "error with GetVersion for a detected pom.xml")
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/semrel"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionfile"
 	"github.com/spf13/cobra"
 	"k8s.io/helm/pkg/chartutil"
 )
@@ -44,6 +46,8 @@ type StepTagFlags struct {
 	Dir                  string
 	ChartsDir            string
 	ChartValueRepository string
+	UpdateVersionFiles   []string
+	Component            string
 	NoApply              bool
 }
 
@@ -64,6 +68,15 @@ var (
 
 		jx step tag --version 1.0.0
 
+		# tag the version in the Cargo.toml
+		jx step tag --version-file Cargo.toml
+
+		# update the version in the gradle.properties before tagging
+		jx step tag --version 1.0.0 --update-version-file gradle.properties
+
+		# tag a release of a component of a monorepo as svc-a/v1.0.0
+		jx step tag --version 1.0.0 --component services/svc-a
+
 `)
 )
 
@@ -87,12 +100,14 @@ func NewCmdStepTag(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&options.Flags.Version, VERSION, "v", "", "version number for the tag [required]")
-	cmd.Flags().StringVarP(&options.Flags.VersionFile, "version-file", "", defaultVersionFile, "The file name used to load the version number from if no '--version' option is specified")
+	cmd.Flags().StringVarP(&options.Flags.VersionFile, "version-file", "", defaultVersionFile, "The file name used to load the version number from if no '--version' option is specified. This can be a plain text file or a version file such as pom.xml or Cargo.toml")
+	cmd.Flags().StringArrayVarP(&options.Flags.UpdateVersionFiles, "update-version-file", "", nil, "The version files such as package.json or Cargo.toml to update with the version before committing")
 
 	cmd.Flags().StringVarP(&options.Flags.ChartsDir, "charts-dir", "d", "", "the directory of the chart to update the version")
 	cmd.Flags().StringVarP(&options.Flags.Dir, "dir", "", "", "the directory which may contain a 'jenkins-x.yml'")
 	cmd.Flags().StringVarP(&options.Flags.ChartValueRepository, "charts-value-repository", "r", "", "the fully qualified image name without the version tag. e.g. 'dockerregistry/myorg/myapp'")
 
+	cmd.Flags().StringVarP(&options.Flags.Component, "component", "", "", "the path of a component of a monorepo to tag. The tag is prefixed with the component name, e.g. svc-a/v1.0.0, and its chart is looked for in the component path")
 	cmd.Flags().BoolVarP(&options.Flags.NoApply, "no-apply", "", false, "Do not push the tag to the server, this is used for example in dry runs")
 
 	return cmd
@@ -107,23 +122,37 @@ func (o *StepTagOptions) Run() error {
 		}
 		exists, err := util.FileExists(path)
 		if exists && err == nil {
-			data, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
+			if path != defaultVersionFile && versionfile.FindHandler(path) != nil {
+				o.Flags.Version, err = versionfile.GetVersion(filepath.Dir(path), filepath.Base(path))
+				if err != nil {
+					return err
+				}
+			} else {
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				o.Flags.Version = strings.TrimSpace(string(data))
 			}
-			o.Flags.Version = strings.TrimSpace(string(data))
 		}
 	}
 	if o.Flags.Version == "" {
 		return errors.New("No version flag")
 	}
 	log.Logger().Debug("looking for charts folder...")
+	baseDir := ""
+	var component *semrel.Component
+	if o.Flags.Component != "" {
+		component = semrel.NewComponent(o.Flags.Component)
+		baseDir = o.Flags.Component
+	}
 	chartsDir := o.Flags.ChartsDir
 	if chartsDir == "" {
-		exists, err := util.FileExists("Chart.yaml")
+		chartsDir = baseDir
+		exists, err := util.FileExists(filepath.Join(baseDir, "Chart.yaml"))
 		if !exists && err == nil {
 			// lets try find the charts/foo dir ignoring the charts/preview dir
-			chartsDir, err = o.findChartsDir()
+			chartsDir, err = o.findChartsDir(baseDir)
 			if err != nil {
 				return err
 			}
@@ -138,15 +167,27 @@ func (o *StepTagOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	for _, path := range o.Flags.UpdateVersionFiles {
+		log.Logger().Debugf("updating the version in %s", path)
+		err = versionfile.SetVersion(filepath.Dir(path), filepath.Base(path), o.Flags.Version)
+		if err != nil {
+			return err
+		}
+	}
 
 	tag := "v" + o.Flags.Version
+	message := fmt.Sprintf("release %s", o.Flags.Version)
+	if component != nil {
+		tag = component.Tag(o.Flags.Version)
+		message = fmt.Sprintf("release %s %s", component.Name, o.Flags.Version)
+	}
 	log.Logger().Debugf("performing git commit")
-	err = o.Git().AddCommit("", fmt.Sprintf("release %s", o.Flags.Version))
+	err = o.Git().AddCommit("", message)
 	if err != nil {
 		return err
 	}
 
-	err = o.Git().CreateTag("", tag, fmt.Sprintf("release %s", o.Flags.Version))
+	err = o.Git().CreateTag("", tag, message)
 	if err != nil {
 		return err
 	}
@@ -270,8 +311,8 @@ func (o *StepTagOptions) defaultChartValueRepository() string {
 }
 
 // lets try find the charts dir
-func (o *StepTagOptions) findChartsDir() (string, error) {
-	files, err := filepath.Glob("*/*/Chart.yaml")
+func (o *StepTagOptions) findChartsDir(baseDir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(baseDir, "*/*/Chart.yaml"))
 	if err != nil {
 		return "", fmt.Errorf("failed to find Chart.yaml file: %s", err)
 	}
@@ -0,0 +1,40 @@
+package sync
+
+import (
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/spf13/cobra"
+)
+
+// StepSyncOptions contains the command line flags
+type StepSyncOptions struct {
+	step.StepOptions
+}
+
+// NewCmdStepSync creates the command
+func NewCmdStepSync(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSyncOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "sync [kind]",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepSyncUsers(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSyncOptions) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,224 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sourceLDAP = "ldap"
+	sourceOIDC = "oidc"
+
+	optionSource = "source"
+)
+
+var userSources = []string{sourceLDAP, sourceOIDC}
+
+// StepSyncUsersOptions contains the command line flags
+type StepSyncUsersOptions struct {
+	step.StepOptions
+
+	Source     string
+	GroupRoles []string
+	DryRun     bool
+	NoDisable  bool
+
+	LDAP users.LDAPDirectory
+	OIDC users.OIDCDirectory
+}
+
+var (
+	stepSyncUsersLong = templates.LongDesc(`
+		Synchronises the Users of the team from an LDAP directory or OIDC provider.
+
+		A User is created or updated for each user of the directory and given the roles of the team which are mapped
+		to their groups via --group-role. Users which were synchronised before but have been removed from the directory
+		are disabled by removing their roles and labelling them with jenkins.io/user-disabled.
+
+		OIDC has no standard endpoint which lists users so --oidc-users-url is required and must return a JSON array of the
+		claims of all the users of the provider.
+`)
+
+	stepSyncUsersExample = templates.Examples(`
+		# show the changes which would be made by synchronising the users from an LDAP directory
+		jx step sync users --source ldap --ldap-url ldaps://ldap.example.com --ldap-bind-dn cn=jx,dc=example,dc=com \
+			--ldap-user-base-dn ou=people,dc=example,dc=com --group-role developers=committer --dry-run
+
+		# synchronise the users from an OIDC provider using the client credentials flow
+		jx step sync users --source oidc --oidc-issuer-url https://sso.example.com/auth/realms/dev \
+			--oidc-users-url https://sso.example.com/users --oidc-client-id jx --group-role admins=owner
+	`)
+)
+
+// NewCmdStepSyncUsers creates the command
+func NewCmdStepSyncUsers(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepSyncUsersOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "users",
+		Short:   "Synchronises the Users of the team from an LDAP directory or OIDC provider",
+		Long:    stepSyncUsersLong,
+		Example: stepSyncUsersExample,
+		Aliases: []string{"user"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Source, optionSource, "s", "", fmt.Sprintf("The source of the users. One of: %s", strings.Join(userSources, ", ")))
+	cmd.Flags().StringArrayVarP(&options.GroupRoles, "group-role", "g", nil, "Maps a group to a role of the team as group=role. If not specified the roles of the users are not changed")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Only shows the changes which would be made")
+	cmd.Flags().BoolVarP(&options.NoDisable, "no-disable", "", false, "Does not disable the users which have been removed from the directory")
+
+	cmd.Flags().StringVarP(&options.LDAP.URL, "ldap-url", "", "", "The URL of the LDAP server")
+	cmd.Flags().StringVarP(&options.LDAP.BindDN, "ldap-bind-dn", "", "", "The DN to bind to the LDAP server as")
+	cmd.Flags().StringVarP(&options.LDAP.Password, "ldap-password", "", "", "The password of the bind DN. Defaults to the $LDAP_PASSWORD environment variable")
+	cmd.Flags().StringVarP(&options.LDAP.UserBaseDN, "ldap-user-base-dn", "", "", "The base DN of the users")
+	cmd.Flags().StringVarP(&options.LDAP.UserFilter, "ldap-user-filter", "", "", "The filter of the users. Defaults to (objectClass=inetOrgPerson)")
+	cmd.Flags().StringVarP(&options.LDAP.LoginAttribute, "ldap-login-attribute", "", "", "The attribute of the login of a user. Defaults to uid")
+	cmd.Flags().StringVarP(&options.LDAP.GroupBaseDN, "ldap-group-base-dn", "", "", "The base DN of the groups. If not specified the groups are read from the memberOf attribute of the users")
+	cmd.Flags().StringVarP(&options.LDAP.GroupFilter, "ldap-group-filter", "", "", "The filter of the groups. Defaults to groupOfNames and groupOfUniqueNames")
+
+	cmd.Flags().StringVarP(&options.OIDC.IssuerURL, "oidc-issuer-url", "", "", "The issuer URL of the OIDC provider")
+	cmd.Flags().StringVarP(&options.OIDC.UsersURL, "oidc-users-url", "", "", "The URL which returns a JSON array of the claims of all the users of the OIDC provider")
+	cmd.Flags().StringVarP(&options.OIDC.Token, "oidc-token", "", "", "The access token. Defaults to the $OIDC_TOKEN environment variable")
+	cmd.Flags().StringVarP(&options.OIDC.ClientID, "oidc-client-id", "", "", "The client ID used to get an access token via the client credentials flow")
+	cmd.Flags().StringVarP(&options.OIDC.ClientSecret, "oidc-client-secret", "", "", "The client secret used to get an access token via the client credentials flow. Defaults to the $OIDC_CLIENT_SECRET environment variable")
+	cmd.Flags().StringArrayVarP(&options.OIDC.Scopes, "oidc-scope", "", nil, "The scopes requested via the client credentials flow")
+	cmd.Flags().StringVarP(&options.OIDC.LoginClaim, "oidc-login-claim", "", "", "The claim of the login of a user. Defaults to preferred_username")
+	cmd.Flags().StringVarP(&options.OIDC.GroupsClaim, "oidc-groups-claim", "", "", "The claim of the groups of a user. Defaults to groups")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepSyncUsersOptions) Run() error {
+	groupRoles, err := ParseGroupRoles(o.GroupRoles)
+	if err != nil {
+		return err
+	}
+	directory, err := o.directory()
+	if err != nil {
+		return err
+	}
+
+	err = o.RegisterUserCRD()
+	if err != nil {
+		return err
+	}
+	err = o.RegisterEnvironmentRoleBindingCRD()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	jxClient, teamNs, adminNs, err := o.JXClientDevAndAdminNamespace()
+	if err != nil {
+		return err
+	}
+
+	directoryUsers, err := directory.Users()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the users from %s", o.Source)
+	}
+	log.Logger().Infof("Found %d users in %s", len(directoryUsers), util.ColorInfo(o.Source))
+
+	userSync := &users.UserSync{
+		JXClient:      jxClient,
+		KubeClient:    kubeClient,
+		Namespace:     adminNs,
+		TeamNamespace: teamNs,
+		ProviderKey:   directory.ProviderKey(),
+		GroupRoles:    groupRoles,
+		DryRun:        o.DryRun,
+	}
+	changes, err := userSync.Sync(directoryUsers, directory.ListsAllUsers() && !o.NoDisable)
+	for _, change := range changes {
+		if o.DryRun {
+			log.Logger().Infof("would %s", change)
+		} else {
+			log.Logger().Infof("%s", change)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		log.Logger().Infof("The users are up to date")
+	}
+	return nil
+}
+
+func (o *StepSyncUsersOptions) directory() (users.Directory, error) {
+	switch o.Source {
+	case sourceLDAP:
+		if o.LDAP.URL == "" {
+			return nil, util.MissingOption("ldap-url")
+		}
+		if o.LDAP.UserBaseDN == "" {
+			return nil, util.MissingOption("ldap-user-base-dn")
+		}
+		if o.LDAP.Password == "" {
+			o.LDAP.Password = os.Getenv("LDAP_PASSWORD")
+		}
+		directory := users.NewLDAPDirectory(o.LDAP.URL, o.LDAP.BindDN, o.LDAP.Password, o.LDAP.UserBaseDN)
+		directory.UserFilter = o.LDAP.UserFilter
+		directory.LoginAttribute = o.LDAP.LoginAttribute
+		directory.GroupBaseDN = o.LDAP.GroupBaseDN
+		directory.GroupFilter = o.LDAP.GroupFilter
+		return directory, nil
+	case sourceOIDC:
+		if o.OIDC.IssuerURL == "" {
+			return nil, util.MissingOption("oidc-issuer-url")
+		}
+		if o.OIDC.UsersURL == "" {
+			return nil, util.MissingOption("oidc-users-url")
+		}
+		if o.OIDC.Token == "" {
+			o.OIDC.Token = os.Getenv("OIDC_TOKEN")
+		}
+		if o.OIDC.ClientSecret == "" {
+			o.OIDC.ClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+		}
+		if o.OIDC.Token == "" && o.OIDC.ClientID == "" {
+			return nil, fmt.Errorf("an access token or a client ID is required to read the users of the OIDC provider")
+		}
+		return &o.OIDC, nil
+	case "":
+		return nil, util.MissingOption(optionSource)
+	}
+	return nil, util.InvalidOption(optionSource, o.Source, userSources)
+}
+
+// ParseGroupRoles parses group=role values into the roles of each group
+func ParseGroupRoles(values []string) (map[string][]string, error) {
+	answer := map[string][]string{}
+	for _, value := range values {
+		idx := strings.Index(value, "=")
+		if idx <= 0 || idx == len(value)-1 {
+			return nil, fmt.Errorf("invalid --group-role %s: it should be of the form group=role", value)
+		}
+		group := value[:idx]
+		answer[group] = append(answer[group], value[idx+1:])
+	}
+	return answer, nil
+}
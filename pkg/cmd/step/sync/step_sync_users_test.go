@@ -0,0 +1,27 @@
+// +build unit
+
+package sync_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/step/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupRoles(t *testing.T) {
+	t.Parallel()
+
+	groupRoles, err := sync.ParseGroupRoles([]string{"developers=committer", "developers=viewer", "admins=owner"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"developers": {"committer", "viewer"},
+		"admins":     {"owner"},
+	}, groupRoles)
+
+	for _, value := range []string{"developers", "=committer", "developers="} {
+		_, err = sync.ParseGroupRoles([]string{value})
+		assert.Error(t, err, "group role %s should be invalid", value)
+	}
+}
@@ -41,7 +41,7 @@ func NewCmdStepSyntaxSchema(commonOpts *opts.CommonOptions) *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:     "schema",
-		Short:   "Output the JSON schema either for jenkins-x.yml files or for build packs' pipeline.yaml files",
+		Short:   "Output the JSON schema for jenkins-x.yml, build packs' pipeline.yaml or jx-requirements.yml files",
 		Example: "schema --pipeline",
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
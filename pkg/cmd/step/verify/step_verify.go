@@ -40,8 +40,10 @@ func NewCmdStepVerify(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepVerifyPod(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyPreInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyRequirements(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyTeamPolicy(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyURL(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyValues(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyVersionStream(commonOpts))
 
 	return cmd
 }
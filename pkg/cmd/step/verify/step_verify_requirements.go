@@ -2,8 +2,10 @@ package verify
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
 
@@ -15,14 +17,18 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/v2/pkg/config"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var (
 	verifyRequirementsLong = templates.LongDesc(`
-		Verifies all the helm requirements.yaml files have a version number populated from the Version Stream.
+		Verifies all the helm requirements.yaml files and the releases in helmfile.yaml files have a version number populated from the Version Stream.
 
+		The jx-requirements.yml file is also checked for keys which are not part of the schema and for an apiVersion
+		which needs migrating via 'jx upgrade boot'. These are reported as warnings unless --strict is specified.
 
 
 
@@ -34,6 +40,9 @@ var (
 		# verify packages and fail if any are not valid:
 		jx step verify packages
 
+		# fail if the jx-requirements.yml file contains unknown keys or needs migrating
+		jx step verify requirements --strict
+
 		# override the error if the 'jx' binary is out of range (e.g. for development)
         export JX_DISABLE_VERIFY_JX="true"
 		jx step verify packages
@@ -44,7 +53,8 @@ var (
 type StepVerifyRequirementsOptions struct {
 	step.StepOptions
 
-	Dir string
+	Dir    string
+	Strict bool
 }
 
 // NewCmdStepVerifyRequirements creates the `jx step verify pod` command
@@ -69,6 +79,7 @@ func NewCmdStepVerifyRequirements(commonOpts *opts.CommonOptions) *cobra.Command
 		},
 	}
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "the directory to recursively look for 'requirements.yaml' files")
+	cmd.Flags().BoolVarP(&options.Strict, "strict", "", false, "fail if the jx-requirements.yml file contains unknown keys or an apiVersion which needs migrating")
 
 	return cmd
 }
@@ -82,6 +93,10 @@ func (o *StepVerifyRequirementsOptions) Run() error {
 			return err
 		}
 	}
+	err := o.verifyRequirementsConfig()
+	if err != nil {
+		return err
+	}
 	requirements, _, err := config.LoadRequirementsConfig(o.Dir, config.DefaultFailOnValidationError)
 	if err != nil {
 		return errors.Wrapf(err, "failed to load boot requirements")
@@ -102,17 +117,66 @@ func (o *StepVerifyRequirementsOptions) Run() error {
 
 	err = filepath.Walk(o.Dir, func(path string, info os.FileInfo, err error) error {
 		name := info.Name()
-		if info.IsDir() || name != helm.RequirementsFileName {
+		if info.IsDir() {
 			return nil
 		}
-
-		log.Logger().Infof("found %s", path)
-		return o.verifyRequirementsYAML(resolver, repoPrefixes, path)
+		switch name {
+		case helm.RequirementsFileName:
+			log.Logger().Infof("found %s", path)
+			return o.verifyRequirementsYAML(resolver, repoPrefixes, path)
+		case helmfile.HelmfileFileName:
+			log.Logger().Infof("found %s", path)
+			return o.verifyHelmfile(resolver, repoPrefixes, path)
+		}
+		return nil
 	})
 
 	return err
 }
 
+// verifyRequirementsConfig reports the unknown keys in the jx-requirements.yml file with their line numbers so that
+// typos are easy to find, along with whether the file needs migrating to the current apiVersion
+func (o *StepVerifyRequirementsOptions) verifyRequirementsConfig() error {
+	fileName, err := config.FindRequirementsConfigFile(o.Dir)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+
+	var problems []string
+	unknownKeys, err := util.FindUnknownYamlKeys(&config.RequirementsConfig{}, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse file %s", fileName)
+	}
+	for _, key := range unknownKeys {
+		problems = append(problems, key.String())
+	}
+	_, migrations, err := config.MigrateRequirements(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate file %s", fileName)
+	}
+	for _, migration := range migrations {
+		problems = append(problems, fmt.Sprintf("requires migration to apiVersion %s: %s", config.RequirementsAPIVersion, migration))
+	}
+	if len(problems) == 0 {
+		log.Logger().Infof("file %s is valid", util.ColorInfo(fileName))
+		return nil
+	}
+	if len(migrations) > 0 {
+		log.Logger().Warnf("run %s to migrate file %s", util.ColorInfo("jx upgrade boot"), fileName)
+	}
+	if o.Strict {
+		return fmt.Errorf("invalid file %s:\n%s", fileName, strings.Join(problems, "\n"))
+	}
+	for _, problem := range problems {
+		log.Logger().Warnf("%s: %s", fileName, util.ColorWarning(problem))
+	}
+	return nil
+}
+
 func (o *StepVerifyRequirementsOptions) verifyRequirementsYAML(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string) error {
 	req, err := helm.LoadRequirementsFile(fileName)
 	if err != nil {
@@ -159,3 +223,51 @@ func (o *StepVerifyRequirementsOptions) verifyRequirementsYAML(resolver *version
 	}
 	return nil
 }
+
+// verifyHelmfile populates the versions of the releases of charts in the helmfile from the version stream
+func (o *StepVerifyRequirementsOptions) verifyHelmfile(resolver *versionstream.VersionResolver, prefixes *versionstream.RepositoryPrefixes, fileName string) error {
+	state, err := helmfile.LoadHelmfile(fileName)
+	if errors.Cause(err) == helmfile.ErrTemplatedHelmfile {
+		log.Logger().Warnf("skipping helmfile %s as it contains templates", fileName)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", fileName)
+	}
+
+	modified := false
+	for i := range state.Releases {
+		release := &state.Releases[i]
+		if release.Version != "" || release.RepositoryName() == "" {
+			continue
+		}
+		repo := state.RepositoryURL(release)
+		if repo == "" {
+			return fmt.Errorf("cannot find a version for release %s in file %s as there is no repository for chart %s", release.Name, fileName, release.Chart)
+		}
+		prefix := prefixes.PrefixForURL(repo)
+		if prefix == "" {
+			return fmt.Errorf("the helm repository %s does not have an associated prefix in in the 'charts/repositories.yml' file the version stream, so we cannot default the version in file %s", repo, fileName)
+		}
+		fullChartName := prefix + "/" + release.ChartName()
+		newVersion, err := resolver.StableVersionNumber(versionstream.KindChart, fullChartName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find version of chart %s in file %s", fullChartName, fileName)
+		}
+		if newVersion == "" {
+			return fmt.Errorf("failed to find a version for release %s in file %s in the current version stream - please either add an explicit version to this file or add chart %s to the version stream", release.Name, fileName, fullChartName)
+		}
+		release.Version = newVersion
+		modified = true
+		log.Logger().Debugf("adding version %s to release %s in file %s", newVersion, release.Name, fileName)
+	}
+
+	if modified {
+		err = helmfile.SaveHelmfile(fileName, state)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("adding release versions to file %s", fileName)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// +build unit
+
+package verify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRequirementsConfigStrict(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct {
+		name         string
+		requirements string
+		expectError  string
+	}{
+		{
+			name:         "no apiVersion",
+			requirements: "cluster:\n  environmentGitPublic: true\n  provider: gke\n",
+		},
+		{
+			name:         "current apiVersion",
+			requirements: "apiVersion: " + config.RequirementsAPIVersion + "\ncluster:\n  provider: gke\n",
+		},
+		{
+			name:         "unknown key",
+			requirements: "cluster:\n  provder: gke\n",
+			expectError:  "cluster.provder",
+		},
+		{
+			name:         "needs migration",
+			requirements: "cluster:\n  environmentGitPrivate: true\n",
+			expectError:  "requires migration to apiVersion " + config.RequirementsAPIVersion,
+		},
+	}
+
+	for _, testCase := range testCases {
+		tc := testCase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir, err := ioutil.TempDir("", "test-verify-requirements-strict-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			err = ioutil.WriteFile(filepath.Join(dir, config.RequirementsConfigFileName), []byte(tc.requirements), util.DefaultWritePermissions)
+			require.NoError(t, err)
+
+			commonOpts := opts.NewCommonOptionsWithFactory(nil)
+			options := &StepVerifyRequirementsOptions{
+				Dir: dir,
+			}
+			options.CommonOptions = &commonOpts
+
+			options.Strict = false
+			err = options.verifyRequirementsConfig()
+			assert.NoError(t, err, "problems should only be warnings without --strict")
+
+			options.Strict = true
+			err = options.verifyRequirementsConfig()
+			if tc.expectError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectError)
+		})
+	}
+}
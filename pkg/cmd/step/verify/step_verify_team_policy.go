@@ -0,0 +1,115 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepVerifyTeamPolicyOptions contains the command line flags
+type StepVerifyTeamPolicyOptions struct {
+	step.StepOptions
+
+	Namespace string
+	WarnOnly  bool
+}
+
+var (
+	stepVerifyTeamPolicyLong = templates.LongDesc(`
+		Verifies the team complies with its policy reporting any violations.
+
+		The policy of a team is stored in the ConfigMap jenkins-x-team-policy-<team> in the admin namespace, or the
+		jenkins-x-team-policy ConfigMap for teams without their own policy. It can limit the number of concurrent builds
+		and preview environments and the registries of the images and the labels of the build pods of the team.
+
+		Pipelines triggered once the maximum number of builds are running are queued until a build completes. The team
+		controller deletes the build pods with images from other registries or without the required labels.
+`)
+
+	stepVerifyTeamPolicyExample = templates.Examples(`
+		# fail the pipeline if the team violates its policy
+		jx step verify team-policy
+
+		# only report the violations of the team policy
+		jx step verify team-policy --warn-only
+	`)
+)
+
+// NewCmdStepVerifyTeamPolicy creates the command
+func NewCmdStepVerifyTeamPolicy(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyTeamPolicyOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "team-policy",
+		Short:   "Verifies the team complies with its policy",
+		Long:    stepVerifyTeamPolicyLong,
+		Example: stepVerifyTeamPolicyExample,
+		Aliases: []string{"policy"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the team. Defaults to the dev namespace")
+	cmd.Flags().BoolVarP(&options.WarnOnly, "warn-only", "", false, "Only report the violations rather than failing")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyTeamPolicyOptions) Run() error {
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the kube client")
+	}
+	jxClient, devNs, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return errors.Wrap(err, "failed to create the jx client")
+	}
+	teamNs := o.Namespace
+	if teamNs == "" {
+		teamNs = devNs
+	}
+	adminNs, err := kube.GetAdminNamespace(kubeClient, teamNs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the admin namespace of team %s", teamNs)
+	}
+	policy, err := kube.LoadTeamPolicy(kubeClient, adminNs, teamNs)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		log.Logger().Infof("Team %s does not have a policy", util.ColorInfo(teamNs))
+		return nil
+	}
+
+	violations, err := kube.CheckTeamPolicy(kubeClient, jxClient, teamNs, policy)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		log.Logger().Infof("Team %s complies with its policy", util.ColorInfo(teamNs))
+		return nil
+	}
+	for _, v := range violations {
+		log.Logger().Warnf("%s", v)
+	}
+	if o.WarnOnly {
+		return nil
+	}
+	return fmt.Errorf("team %s has %d violations of its policy", teamNs, len(violations))
+}
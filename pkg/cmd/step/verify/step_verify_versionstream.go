@@ -0,0 +1,256 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/create"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/cve"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream/audit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyVersionStreamLong = templates.LongDesc(`
+		Audits the version stream for stale and vulnerable versions
+
+		Each chart, docker image, git repository and package in the version stream is compared to the latest
+		available version. Charts are searched for using helm, docker images using the tags in their registry
+		and git repositories and packages using the releases of their git repository.
+
+		The report includes how far behind each version is and optionally the vulnerabilities of the docker images
+		found by the CVE provider.
+`)
+
+	verifyVersionStreamExample = templates.Examples(`
+		# audits the default version stream
+		jx step verify versionstream
+
+		# audits the charts and docker images in a local version stream and saves a JSON report
+		jx step verify versionstream --dir ../jenkins-x-versions --kind charts --kind docker --format json --output audit.json
+
+		# audits the version stream and opens an issue with the report
+		jx step verify versionstream --create-issue
+	`)
+)
+
+// StepVerifyVersionStreamOptions contains the command line flags
+type StepVerifyVersionStreamOptions struct {
+	step.StepOptions
+
+	Dir            string
+	VersionsRepo   string
+	VersionsGitRef string
+	Kinds          []string
+	Includes       []string
+	Excludes       []string
+	Format         string
+	OutputFile     string
+	CVE            bool
+	CreateIssue    bool
+	IssueRepo      string
+	IssueTitle     string
+	Timeout        time.Duration
+}
+
+// NewCmdStepVerifyVersionStream creates the `jx step verify versionstream` command
+func NewCmdStepVerifyVersionStream(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyVersionStreamOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "versionstream",
+		Aliases: []string{"version-stream", "versions"},
+		Short:   "Audits the version stream for stale and vulnerable versions",
+		Long:    verifyVersionStreamLong,
+		Example: verifyVersionStreamExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory of the version stream. If not specified the version stream is cloned")
+	cmd.Flags().StringVarP(&options.VersionsRepo, "versions-repo", "", config.DefaultVersionsURL, "The git URL of the version stream to clone if no directory is specified")
+	cmd.Flags().StringVarP(&options.VersionsGitRef, "versions-ref", "", "", "The git ref of the version stream to clone if no directory is specified")
+	cmd.Flags().StringArrayVarP(&options.Kinds, "kind", "k", []string{string(versionstream.KindChart), string(versionstream.KindDocker), string(versionstream.KindGit), string(versionstream.KindPackage)}, fmt.Sprintf("The kinds of versions to audit. Possible values: %s", strings.Join(versionstream.KindStrings, ", ")))
+	cmd.Flags().StringArrayVarP(&options.Includes, "filter", "f", nil, "The name patterns to include. If not specified all names are included")
+	cmd.Flags().StringArrayVarP(&options.Excludes, "excludes", "x", nil, "The name patterns to exclude")
+	cmd.Flags().StringVarP(&options.Format, "format", "", "markdown", "The format of the report. Possible values: markdown, json")
+	cmd.Flags().StringVarP(&options.OutputFile, "output", "o", "", "The file to write the report to. If not specified the report is written to the console")
+	cmd.Flags().BoolVarP(&options.CVE, "cve", "", false, "Checks the docker images for vulnerabilities using the CVE provider addon")
+	cmd.Flags().BoolVarP(&options.CreateIssue, "create-issue", "", false, "Creates an issue with the report if any versions are outdated or vulnerable, or comments on the open issue with the same title")
+	cmd.Flags().StringVarP(&options.IssueRepo, "issue-repo", "", config.DefaultVersionsURL, "The git URL of the repository to create the issue in")
+	cmd.Flags().StringVarP(&options.IssueTitle, "issue-title", "", "Version stream audit", "The title of the issue")
+	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "", time.Minute, "The timeout of each request to a docker registry")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyVersionStreamOptions) Run() error {
+	if o.Format != "markdown" && o.Format != "json" {
+		return util.InvalidOption("format", o.Format, []string{"markdown", "json"})
+	}
+	dir := o.Dir
+	if dir == "" {
+		resolver, err := o.CreateVersionResolver(o.VersionsRepo, o.VersionsGitRef)
+		if err != nil {
+			return errors.Wrapf(err, "cloning the version stream %s", o.VersionsRepo)
+		}
+		dir = resolver.VersionsDir
+	}
+
+	auditor, err := o.createAuditor(dir)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("auditing the version stream in %s", util.ColorInfo(dir))
+	report, err := auditor.Audit(dir)
+	if err != nil {
+		return err
+	}
+
+	var output string
+	if o.Format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshalling the report to JSON")
+		}
+		output = string(data)
+	} else {
+		output = report.Markdown()
+	}
+	if o.OutputFile != "" {
+		err = ioutil.WriteFile(o.OutputFile, []byte(output), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "writing the report to %s", o.OutputFile)
+		}
+		log.Logger().Infof("wrote the report to %s", util.ColorInfo(o.OutputFile))
+	} else {
+		fmt.Fprintln(o.Out, output)
+	}
+
+	outdated := len(report.Outdated())
+	vulnerable := len(report.Vulnerable())
+	log.Logger().Infof("audited %d versions: %s outdated and %s vulnerable", len(report.Results), util.ColorInfo(outdated), util.ColorInfo(vulnerable))
+	if o.CreateIssue && (outdated > 0 || vulnerable > 0) {
+		return o.createIssue(report)
+	}
+	return nil
+}
+
+func (o *StepVerifyVersionStreamOptions) createAuditor(dir string) (*audit.Auditor, error) {
+	auditor := &audit.Auditor{
+		Finders:  map[versionstream.VersionKind]audit.Finder{},
+		Includes: o.Includes,
+		Excludes: o.Excludes,
+	}
+	gitFinder := &audit.GitFinder{
+		CreateProvider: o.gitProvider,
+	}
+	for _, k := range o.Kinds {
+		if util.StringArrayIndex(versionstream.KindStrings, k) < 0 {
+			return nil, util.InvalidOption("kind", k, versionstream.KindStrings)
+		}
+		kind := versionstream.VersionKind(k)
+		switch kind {
+		case versionstream.KindChart:
+			auditor.Finders[kind] = &audit.ChartFinder{
+				Helmer:      o.Helm(),
+				VersionsDir: dir,
+			}
+		case versionstream.KindDocker:
+			auditor.Finders[kind] = &audit.DockerFinder{
+				Client: &http.Client{Timeout: o.Timeout},
+			}
+		case versionstream.KindGit, versionstream.KindPackage:
+			auditor.Finders[kind] = gitFinder
+		}
+	}
+	if o.CVE {
+		provider, err := o.cveProvider()
+		if err != nil {
+			return nil, err
+		}
+		auditor.CVEProvider = provider
+	}
+	return auditor, nil
+}
+
+func (o *StepVerifyVersionStreamOptions) gitProvider(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing git URL %s", gitURL)
+	}
+	provider, err := o.GitProviderForURL(gitURL, "auditing versions")
+	if err != nil {
+		return nil, nil, err
+	}
+	return provider, gitInfo, nil
+}
+
+func (o *StepVerifyVersionStreamOptions) cveProvider() (cve.CVEProvider, error) {
+	externalURL, err := o.EnsureAddonServiceAvailable(kube.AddonServices[create.DefaultAnchoreName])
+	if err != nil {
+		return nil, errors.Wrap(err, "no CVE provider running, try running `jx create addon anchore` in your teams dev environment")
+	}
+	server, auth, err := o.GetAddonAuthByKind(kube.ValueKindCVE, externalURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting anchore engine auth details")
+	}
+	provider, err := cve.NewAnchoreProvider(server, auth)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating anchore provider")
+	}
+	return provider, nil
+}
+
+func (o *StepVerifyVersionStreamOptions) createIssue(report *audit.Report) error {
+	provider, gitInfo, err := o.gitProvider(o.IssueRepo)
+	if err != nil {
+		return err
+	}
+	issues, err := provider.SearchIssues(gitInfo.Organisation, gitInfo.Name, "open")
+	if err != nil {
+		return errors.Wrapf(err, "searching for open issues in %s", o.IssueRepo)
+	}
+	for _, existing := range issues {
+		if existing.Title != o.IssueTitle || existing.Number == nil {
+			continue
+		}
+		err = provider.CreateIssueComment(gitInfo.Organisation, gitInfo.Name, *existing.Number, report.Markdown())
+		if err != nil {
+			return errors.Wrapf(err, "commenting on issue %d in %s", *existing.Number, o.IssueRepo)
+		}
+		log.Logger().Infof("updated issue %s", util.ColorInfo(existing.URL))
+		return nil
+	}
+	issue, err := provider.CreateIssue(gitInfo.Organisation, gitInfo.Name, &gits.GitIssue{
+		Title: o.IssueTitle,
+		Body:  report.Markdown(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating issue in %s", o.IssueRepo)
+	}
+	log.Logger().Infof("created issue %s", util.ColorInfo(issue.URL))
+	return nil
+}
@@ -0,0 +1,72 @@
+// +build unit
+
+package verify
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersionStreamIssueOptions(t *testing.T, issues ...*gits.GitIssue) (*StepVerifyVersionStreamOptions, *gits.FakeRepository) {
+	repo, err := gits.NewFakeRepository("jenkins-x", "jenkins-x-versions", nil, nil)
+	require.NoError(t, err)
+	repo.Issues = map[int]*gits.FakeIssue{}
+	for _, issue := range issues {
+		repo.Issues[*issue.Number] = &gits.FakeIssue{Issue: issue}
+	}
+	commonOpts := &opts.CommonOptions{}
+	commonOpts.SetFakeGitProvider(gits.NewFakeProvider(repo))
+	options := &StepVerifyVersionStreamOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+		IssueRepo:  "https://github.com/jenkins-x/jenkins-x-versions.git",
+		IssueTitle: "Version stream audit",
+	}
+	return options, repo
+}
+
+func TestCreateIssueCommentsOnOpenIssue(t *testing.T) {
+	t.Parallel()
+
+	number := 7
+	open := "open"
+	options, repo := newVersionStreamIssueOptions(t, &gits.GitIssue{
+		Number: &number,
+		Title:  "Version stream audit",
+		State:  &open,
+	})
+	report := &audit.Report{}
+
+	err := options.createIssue(report)
+	require.NoError(t, err)
+
+	assert.Len(t, repo.Issues, 1)
+	assert.Equal(t, report.Markdown(), repo.Issues[number].Comment)
+}
+
+func TestCreateIssueWithoutOpenIssue(t *testing.T) {
+	t.Parallel()
+
+	number := 7
+	closed := "closed"
+	options, repo := newVersionStreamIssueOptions(t, &gits.GitIssue{
+		Number: &number,
+		Title:  "Version stream audit",
+		State:  &closed,
+	})
+	report := &audit.Report{}
+
+	err := options.createIssue(report)
+	require.NoError(t, err)
+
+	assert.Len(t, repo.Issues, 2)
+	assert.Empty(t, repo.Issues[number].Comment)
+	assert.Equal(t, report.Markdown(), repo.Issues[1].Issue.Body)
+}
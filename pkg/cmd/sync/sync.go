@@ -2,49 +2,59 @@ package sync
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/jenkins-x/jx/v2/pkg/ksync"
-	"github.com/pkg/errors"
-
-	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
-
-	"github.com/spf13/cobra"
-	"k8s.io/client-go/kubernetes"
-
 	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/v2/pkg/filesync"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultContainer = "devpod"
+	defaultRemoteDir = "/workspace"
 )
 
 type SyncOptions struct {
 	*opts.CommonOptions
 
-	Daemon      bool
-	NoKsyncInit bool
-	SingleMode  bool
-
 	Container string
 	Namespace string
 	Pod       string
 	Dir       string
 	RemoteDir string
-	Reload    bool
-	WatchOnly bool
+	Interval  time.Duration
+	Conflict  string
+
+	// deprecated
+	Daemon      bool
+	NoKsyncInit bool
+	SingleMode  bool
+	WatchOnly   bool
 }
 
 var (
 	sync_long = templates.LongDesc(`
 		Synchronises your local files to a DevPod so you an build and test your code easily on the cloud
 
+		Files are synchronised in both directions via the Kubernetes exec API so no extra binaries or daemons are required.
+		The .git, .idea, .settings, .vscode, bin, build, target and node_modules directories are never synchronised
+		along with any files matching the .gitignore, .dockerignore and .stignore files of the directory.
+
+		Changes are batched until the local directory stops changing. On startup the local files win. After that a file
+		changed both locally and in the DevPod since the last sync is a conflict which is handled via the --conflict policy.
+		If the workspace of the DevPod is replaced, such as when the DevPod restarts, the local files win again.
+		Deleting more than a few files on either side has to be confirmed, otherwise the files are copied back.
+
 		For more documentation see: [https://jenkins-x.io/developing/devpods/](https://jenkins-x.io/developing/devpods/)
 
 `)
@@ -52,17 +62,10 @@ var (
 	sync_example = templates.Examples(`
 		# Starts synchronizing the current directory files to the users DevPod
 		jx sync 
-`)
 
-	defaultStignoreFile = `.git
-.idea
-.settings
-.vscode
-bin
-build
-target
-node_modules
-`
+		# Synchronise a directory with a specific DevPod overwriting conflicting changes in the DevPod
+		jx sync --dir ~/src/myapp --pod myuser-maven --conflict local
+`)
 )
 
 func NewCmdSync(commonOpts *opts.CommonOptions) *cobra.Command {
@@ -82,187 +85,143 @@ func NewCmdSync(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
-	/*	cmd.Flags().StringVarP(&options.Container, "container", "c", "", "The name of the container to log")
-		cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "the namespace to look for the Deployment. Defaults to the current namespace")
-		cmd.Flags().StringVarP(&options.Pod, "pod", "p", "", "the pod name to use")
-		cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory to watch. Defaults to the current directory")
-		cmd.Flags().StringVarP(&options.RemoteDir, "remote-dir", "r", "", "The remote directory in the DevPod to sync")
-		cmd.Flags().BoolVarP(&options.Reload, "reload", "", false, "Should we reload the remote container on file changes?")
-	*/
-	cmd.Flags().BoolVarP(&options.Daemon, "daemon", "", false, "Runs ksync in a background daemon")
-	cmd.Flags().BoolVarP(&options.NoKsyncInit, "no-init", "", false, "Disables the use of 'ksync init' to ensure we have initialised ksync")
-	cmd.Flags().BoolVarP(&options.SingleMode, "single-mode", "", false, "Terminates eagerly if `ksync watch` fails")
+	cmd.Flags().StringVarP(&options.Container, "container", "c", defaultContainer, "The name of the container of the DevPod to sync")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the DevPod. Defaults to the dev namespace")
+	cmd.Flags().StringVarP(&options.Pod, "pod", "p", "", "The name of the DevPod. Defaults to the DevPod of the current user for the directory")
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "The directory to sync. Defaults to the current directory")
+	cmd.Flags().StringVarP(&options.RemoteDir, "remote-dir", "r", "", "The directory in the DevPod to sync. Defaults to the working directory of the DevPod")
+	cmd.Flags().DurationVarP(&options.Interval, "interval", "", 2*time.Second, "The interval between checks for changes in the DevPod")
+	cmd.Flags().StringVarP(&options.Conflict, "conflict", "", string(filesync.ConflictSkip), fmt.Sprintf("How to handle files changed both locally and in the DevPod. One of: %s", filesync.ConflictPolicies))
 
 	// deprecated
+	cmd.Flags().BoolVarP(&options.Daemon, "daemon", "", false, "Deprecated this flag is now ignored!")
+	cmd.Flags().BoolVarP(&options.NoKsyncInit, "no-init", "", false, "Deprecated this flag is now ignored!")
+	cmd.Flags().BoolVarP(&options.SingleMode, "single-mode", "", false, "Deprecated this flag is now ignored!")
 	cmd.Flags().BoolVarP(&options.WatchOnly, "watch-only", "", false, "Deprecated this flag is now ignored!")
 	return cmd
 }
 
 func (o *SyncOptions) Run() error {
-
-	// ksync is installed to the jx/bin dir, so we can add it for the user
-	err := os.Setenv("PATH", util.PathWithBinary())
-	if err != nil {
-		return errors.Wrap(err, "failed to set PATH env variable")
-	}
-
-	client, err := o.KubeClient()
+	client, ns, err := o.KubeClientAndDevNamespace()
 	if err != nil {
 		return err
 	}
-	sha, err := ksync.InstallKSync()
-	if err != nil {
-		return err
+	if o.Namespace != "" {
+		ns = o.Namespace
 	}
-
-	if !o.NoKsyncInit {
-		flag, err := kube.IsDaemonSetExists(client, "ksync", "kube-system")
-		if !flag || err != nil {
-			log.Logger().Infof("Initialising ksync")
-			kcli, err := ksync.NewCLI()
-			if err != nil {
-				return err
-			}
-			// Deal with https://github.com/ksync/ksync/issues/218
-			_, err = kcli.Init("--upgrade", "--image", fmt.Sprintf("ksync/ksync:git-%s", sha))
-			if err != nil {
-				return err
-			}
+	dir := o.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
 		}
 	}
-
-	if o.SingleMode {
-		return o.KsyncWatch()
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the absolute path of %s", dir)
 	}
-	for {
-		err = o.KsyncWatch()
+
+	name := o.Pod
+	if name == "" {
+		name, err = o.findDevPod(client, ns, dir)
 		if err != nil {
-			log.Logger().Warnf("Failed on ksync watch: %s", err)
+			return err
 		}
 	}
-}
-
-func (o *SyncOptions) waitForKsyncWatchToFail() {
-	logged := false
-	for {
-		_, err := o.GetCommandOutput("", "ksync", "get")
+	remoteDir := o.RemoteDir
+	if remoteDir == "" {
+		pod, err := client.CoreV1().Pods(ns).Get(name, metav1.GetOptions{})
 		if err != nil {
-			// lets assume watch is no longer running
-			log.Logger().Infof("Looks like 'ksync watch' is not running: %s", err)
-			return
+			return errors.Wrapf(err, "failed to find DevPod %s", name)
 		}
-		if !logged {
-			logged = true
-			log.Logger().Infof("It looks like 'ksync watch' is already running so we don't need to run it yet...")
+		remoteDir = pod.Annotations[kube.AnnotationWorkingDir]
+		if remoteDir == "" {
+			remoteDir = defaultRemoteDir
 		}
-		time.Sleep(time.Second * 5)
 	}
-}
 
-func (o *SyncOptions) KsyncWatch() error {
-	o.waitForKsyncWatchToFail()
-
-	args := []string{"watch"}
-	if o.Daemon {
-		args = append(args, "--daemon")
-	}
-	cmd := exec.Command("ksync", args...)
-	cmd.Stdout = o.Out
-	cmd.Stderr = o.Out
-	err := cmd.Start()
+	syncer, err := o.createSyncer(client, ns, name, dir, remoteDir)
 	if err != nil {
 		return err
 	}
-
-	log.Logger().Infof("Started the ksync watch")
-	time.Sleep(1 * time.Second)
-
-	state := cmd.ProcessState
-	if state != nil && state.Exited() {
-		return fmt.Errorf("ksync watch terminated")
+	if !o.BatchMode {
+		syncer.ConfirmDelete = o.confirmDelete
 	}
-	return cmd.Wait()
+	log.Logger().Infof("Synchronizing directory %s to DevPod %s path %s", util.ColorInfo(dir), util.ColorInfo(name), util.ColorInfo(remoteDir))
+	syncer.Run(o.Interval, nil)
+	return nil
 }
 
-// CreateKsync removes the exiting ksync if it already exists then create a new ksync of the given name
-func (o *SyncOptions) CreateKsync(client kubernetes.Interface, ns string, name string, dir string, remoteDir string, username string) error {
-
-	// ksync is installed to the jx/bin dir, so we can add it for the user
-	err := os.Setenv("PATH", util.PathWithBinary())
+// confirmDelete asks the user to confirm deleting many files from one side of the sync
+func (o *SyncOptions) confirmDelete(paths []string, local bool) bool {
+	where := "the DevPod"
+	if local {
+		where = "your local directory"
+	}
+	message := fmt.Sprintf("Delete %d files from %s, such as %s?", len(paths), where, paths[0])
+	answer, err := util.Confirm(message, false, "The files were deleted on the other side of the sync. If you decline they are copied back instead", o.GetIOFileHandles())
 	if err != nil {
-		return errors.Wrap(err, "failed to set PATH env variable")
+		log.Logger().Warnf("%s", err)
+		return false
 	}
+	return answer
+}
 
+// StartSync synchronises the directory to the DevPod once then keeps synchronising it in the background.
+// As the background sync cannot prompt, deleting many files is never propagated by it
+func (o *SyncOptions) StartSync(client kubernetes.Interface, ns string, name string, dir string, remoteDir string) error {
 	info := util.ColorInfo
 	log.Logger().Infof("synchronizing directory %s to DevPod %s path %s", info(dir), info(name), info(remoteDir))
 
-	ignoreFile := filepath.Join(dir, ".stignore")
-	exists, err := util.FileExists(ignoreFile)
+	syncer, err := o.createSyncer(client, ns, name, dir, remoteDir)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		err = ioutil.WriteFile(ignoreFile, []byte(defaultStignoreFile), util.DefaultWritePermissions)
-		if err != nil {
-			return err
-		}
-	}
-	matchLabels := map[string]string{
-		kube.LabelDevPodUsername: username,
-	}
-	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: matchLabels})
+	_, err = syncer.SyncOnce()
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "failed to sync %s to DevPod %s", dir, name)
 	}
+	go syncer.Run(o.Interval, nil)
+	return nil
+}
 
-	_, pods, err := kube.GetPodsWithLabels(client, ns, selector.String())
+func (o *SyncOptions) createSyncer(client kubernetes.Interface, ns string, name string, dir string, remoteDir string) (*filesync.Syncer, error) {
+	config, err := o.GetFactory().CreateKubeConfig()
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "failed to load the kube config")
 	}
+	container := o.Container
+	if container == "" {
+		container = defaultContainer
+	}
+	if o.Interval <= 0 {
+		o.Interval = 2 * time.Second
+	}
+	executor := filesync.NewPodExecutor(client, config, ns, name, container)
+	return filesync.NewSyncer(dir, remoteDir, executor, filesync.ConflictPolicy(o.Conflict))
+}
 
-	// ignore the bad lines that come
-	ignoreNames := []string{"starting", "watching"}
-
-	deleteNames := []string{}
-	err = o.Retry(5, time.Second, func() error {
-		text, err := o.GetCommandOutput(dir, "ksync", "get")
-		if err == nil {
-			for i, line := range strings.Split(text, "\n") {
-				if i > 1 {
-					cols := strings.Split(strings.TrimSpace(line), " ")
-					if len(cols) > 2 {
-						n := strings.TrimSpace(cols[0])
-						if n == name || pods[n] == nil {
-							if util.StringArrayIndex(deleteNames, n) < 0 && util.StringArrayIndex(ignoreNames, n) < 0 {
-								deleteNames = append(deleteNames, n)
-							}
-						}
-					}
-				}
-			}
-		}
-		return err
-	})
+// findDevPod finds the DevPod of the current user which is synchronised with the directory, or the only DevPod of
+// the user, otherwise lets the user pick one
+func (o *SyncOptions) findDevPod(client kubernetes.Interface, ns string, dir string) (string, error) {
+	userName, err := o.GetUsername("")
 	if err != nil {
-		log.Logger().Warnf("Failed to get from ksync daemon: %s", err)
+		return "", err
 	}
-
-	reload := "--reload=false"
-	if o.Reload {
-		reload = "--reload=true"
+	names, pods, err := kube.GetDevPodNames(client, ns, userName)
+	if err != nil {
+		return "", errors.Wrap(err, "getting the DevPod names")
 	}
-
-	for _, n := range deleteNames {
-		// ignore results as we may not have a spec yet for this name
-		log.Logger().Infof("Removing old ksync %s", n)
-
-		err = o.RunCommand("ksync", "delete", n)
-		if err != nil {
-			return err
+	if len(names) == 0 {
+		return "", fmt.Errorf("there are no DevPods for user %s. Create one via 'jx create devpod --sync'", userName)
+	}
+	for _, name := range names {
+		if pods[name].Annotations[kube.AnnotationLocalDir] == dir {
+			return name, nil
 		}
 	}
-
-	time.Sleep(1 * time.Second)
-
-	return o.RunCommand("ksync", "create", "--name", name, "-l", "jenkins.io/devpod="+name, reload, "-n", ns, dir, remoteDir)
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	return util.PickName(names, "Pick DevPod to sync:", "", o.GetIOFileHandles())
 }
@@ -1,6 +1,7 @@
 package upgrade
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -21,6 +22,7 @@ import (
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/jenkins-x/jx/v2/pkg/yamlmerge"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -32,22 +34,38 @@ type UpgradeBootOptions struct {
 	UpgradeVersionStreamRef string
 	LatestRelease           bool
 	Labels                  []string
+	Interactive             bool
+
+	conflictReports []string
 }
 
 var (
 	upgradeBootLong = templates.LongDesc(`
 		This command creates a pr for upgrading a jx boot gitOps cluster, incorporating changes to the boot
         config and version stream ref
+
+		The jx-requirements.yml and env/values.yaml files are merged using their YAML structure so that changes
+		to different values are merged automatically. Any remaining conflicts keep the current value and are
+		listed in the pull request
 `)
 
 	upgradeBootExample = templates.Examples(`
 		# create pr for upgrading a jx boot gitOps cluster
 		jx upgrade boot
+
+		# create pr for upgrading a jx boot gitOps cluster choosing how to resolve any conflicts
+		jx upgrade boot --interactive
 `)
 
 	filesExcludedFromCherryPick = []string{
 		"OWNERS",
 	}
+
+	// filesMergedByStructure are merged using their YAML structure after the commits have been cherry-picked
+	filesMergedByStructure = []string{
+		config.RequirementsConfigFileName,
+		"env/values.yaml",
+	}
 )
 
 const (
@@ -77,6 +95,7 @@ func NewCmdUpgradeBoot(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.UpgradeVersionStreamRef, "upgrade-version-stream-ref", "", config.DefaultVersionsRef, "a version stream ref to use to upgrade to")
 	cmd.Flags().BoolVarP(&options.LatestRelease, "latest-release", "", false, "upgrade to latest release tag")
 	cmd.Flags().StringArrayVarP(&options.Labels, "labels", "", []string{}, "Labels to add to the generated upgrade PR")
+	cmd.Flags().BoolVarP(&options.Interactive, "interactive", "i", false, "Prompt to resolve any conflicts merging jx-requirements.yml and env/values.yaml")
 
 	return cmd
 }
@@ -113,6 +132,11 @@ func (o *UpgradeBootOptions) Run() error {
 		return errors.Wrap(err, "failed to checkout upgrade_branch")
 	}
 
+	err = o.migrateRequirements(requirementsFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate jx-requirements.yml")
+	}
+
 	bootConfigURL, err := o.determineBootConfigURL(reqsVersionStream.URL)
 	if err != nil {
 		return errors.Wrap(err, "failed to determine boot configuration URL")
@@ -170,6 +194,22 @@ func (o *UpgradeBootOptions) Run() error {
 	return nil
 }
 
+// migrateRequirements rewrites the requirements file to the current apiVersion committing any changes
+func (o *UpgradeBootOptions) migrateRequirements(requirementsFile string) error {
+	migrations, err := config.MigrateRequirementsFile(requirementsFile)
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	for _, migration := range migrations {
+		log.Logger().Infof("migrated %s: %s", requirementsFile, migration)
+	}
+	message := fmt.Sprintf("chore: migrate jx-requirements.yml to %s", config.RequirementsAPIVersion)
+	return o.Git().AddCommitFiles(o.Dir, message, []string{requirementsFile})
+}
+
 func (o UpgradeBootOptions) createCommitForRequirements(requirementsFileName string) error {
 	reqsChanged, err := o.Git().HasFileChanged(o.Dir, requirementsFileName)
 	if err != nil {
@@ -321,6 +361,12 @@ func (o *UpgradeBootOptions) updateBootConfig(versionStreamURL string, versionSt
 		return errors.Wrapf(err, "failed to fetch upgrade tag %s from %s", upgradeVersion, bootConfigURL)
 	}
 
+	// Keep our versions of the files which are merged by structure so they can be merged once the commits are cherry-picked
+	ours, err := o.readFilesMergedByStructure()
+	if err != nil {
+		return err
+	}
+
 	// Set up custom merge driver to ensure that specified files always use the local/dev env version in merges/cherry picks with conflicts
 	err = o.configureGitMergeExcludes()
 	if err != nil {
@@ -336,9 +382,107 @@ func (o *UpgradeBootOptions) updateBootConfig(versionStreamURL string, versionSt
 		return errors.Wrap(err, "failed to cherry pick upgrade commits")
 	}
 
+	err = o.mergeFilesByStructure(configCloneDir, currentSha, upgradeSha, ours)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the upgraded configuration")
+	}
 	return nil
 }
 
+func (o *UpgradeBootOptions) readFilesMergedByStructure() (map[string][]byte, error) {
+	answer := map[string][]byte{}
+	for _, file := range filesMergedByStructure {
+		path := filepath.Join(o.Dir, filepath.FromSlash(file))
+		exists, err := util.FileExists(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking if %s exists", path)
+		}
+		if !exists {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", path)
+		}
+		answer[file] = data
+	}
+	return answer, nil
+}
+
+// mergeFilesByStructure does a three-way merge of our versions of the files with the changes to the boot config between
+// the two commits, committing the result. The conflicts which are not resolved are added to the pull request
+func (o *UpgradeBootOptions) mergeFilesByStructure(cloneDir string, fromSha string, toSha string, ours map[string][]byte) error {
+	for _, file := range filesMergedByStructure {
+		ourData, ok := ours[file]
+		if !ok {
+			continue
+		}
+		base, err := o.Git().LoadFileFromBranch(cloneDir, fromSha, file)
+		if err != nil {
+			log.Logger().Debugf("not merging %s as it is not in the boot config at %s: %s", file, fromSha, err)
+			continue
+		}
+		theirs, err := o.Git().LoadFileFromBranch(cloneDir, toSha, file)
+		if err != nil {
+			log.Logger().Debugf("not merging %s as it is not in the boot config at %s: %s", file, toSha, err)
+			continue
+		}
+
+		merger := &yamlmerge.Merger{}
+		if o.Interactive && !o.BatchMode {
+			merger.Resolve = o.resolveConflict(file)
+		}
+		merged, conflicts, err := merger.Merge([]byte(base), ourData, []byte(theirs))
+		if err != nil {
+			return errors.Wrapf(err, "merging %s", file)
+		}
+		path := filepath.Join(o.Dir, filepath.FromSlash(file))
+		current, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "reading %s", path)
+		}
+		if err != nil || !bytes.Equal(current, merged) {
+			err = ioutil.WriteFile(path, merged, util.DefaultWritePermissions)
+			if err != nil {
+				return errors.Wrapf(err, "writing %s", path)
+			}
+			err = o.Git().AddCommitFiles(o.Dir, fmt.Sprintf("Merge %s", file), []string{file})
+			if err != nil {
+				return errors.Wrapf(err, "committing the merged %s", file)
+			}
+		}
+		if len(conflicts) > 0 {
+			log.Logger().Warnf("%d conflicts merging %s kept the current values and will be listed in the pull request", len(conflicts), util.ColorWarning(file))
+			o.conflictReports = append(o.conflictReports, yamlmerge.Markdown(file, conflicts))
+		}
+	}
+	return nil
+}
+
+func (o *UpgradeBootOptions) resolveConflict(file string) func(conflict yamlmerge.Conflict) (yamlmerge.Resolution, error) {
+	const (
+		keepCurrent     = "keep the current value"
+		useUpgrade      = "use the upgraded value"
+		leaveUnresolved = "decide in the pull request"
+	)
+	resolutions := map[string]yamlmerge.Resolution{
+		keepCurrent:     yamlmerge.Ours,
+		useUpgrade:      yamlmerge.Theirs,
+		leaveUnresolved: yamlmerge.Unresolved,
+	}
+	return func(conflict yamlmerge.Conflict) (yamlmerge.Resolution, error) {
+		log.Logger().Infof("\nconflict at %s in %s\n", util.ColorInfo(conflict.Path), util.ColorInfo(file))
+		log.Logger().Infof("base:\n%s\n", conflict.Base)
+		log.Logger().Infof("current:\n%s\n", conflict.Ours)
+		log.Logger().Infof("upgrade:\n%s\n", conflict.Theirs)
+		answer, err := util.PickNameWithDefault([]string{keepCurrent, useUpgrade, leaveUnresolved}, "How do you want to resolve the conflict?", keepCurrent, "", o.GetIOFileHandles())
+		if err != nil {
+			return yamlmerge.Unresolved, err
+		}
+		return resolutions[answer], nil
+	}
+}
+
 // Add a custom merge driver to .git/config that will always choose the current version when there's a change upstream
 // in selected files, and use that merge driver in .git/info/attributes for those selected files.
 // see https://stackoverflow.com/a/930495 for more details
@@ -359,7 +503,7 @@ func (o *UpgradeBootOptions) configureGitMergeExcludes() error {
 
 	// Write the existing .git/info/attributes content and marking the selected files as using our custom driver
 	gitAttrContent := existingGitAttr + "\n"
-	for _, excludedFile := range filesExcludedFromCherryPick {
+	for _, excludedFile := range append(filesExcludedFromCherryPick, filesMergedByStructure...) {
 		newGitAttrLine := fmt.Sprintf("%s merge=%s\n", excludedFile, keepDevEnvKey)
 		if !strings.Contains(gitAttrContent, newGitAttrLine) {
 			gitAttrContent += newGitAttrLine
@@ -502,6 +646,10 @@ func (o *UpgradeBootOptions) prDetailsAndFilter() (gits.PullRequestDetails, gits
 		Title:      "feat(config): upgrade configuration",
 		Message:    "Upgrade configuration",
 	}
+	if len(o.conflictReports) > 0 {
+		details.Message += "\n\n### Conflicts\n\nThese values were changed differently by the dev environment and the boot config upgrade. " +
+			"The current values have been kept, please review them before merging.\n\n" + strings.Join(o.conflictReports, "\n")
+	}
 
 	labels := []string{}
 	if len(o.Labels) > 0 {
@@ -287,3 +287,86 @@ func TestUpdateVersionStreamRefAlternative(t *testing.T) {
 
 	assert.Equal(t, "22222222", vs.Ref, "UpdateVersionStreamRef Ref")
 }
+
+// bootConfigGitFake returns the files of the boot config at the commits and records the files which are committed
+type bootConfigGitFake struct {
+	gits.GitFake
+	files     map[string]string
+	committed []string
+}
+
+func (g *bootConfigGitFake) AddCommitFiles(dir string, msg string, files []string) error {
+	g.committed = append(g.committed, files...)
+	return nil
+}
+
+func (g *bootConfigGitFake) LoadFileFromBranch(dir string, branch string, file string) (string, error) {
+	text, ok := g.files[branch+":"+file]
+	if !ok {
+		return "", fmt.Errorf("path '%s' does not exist in '%s'", file, branch)
+	}
+	return text, nil
+}
+
+func TestMergeFilesByStructure(t *testing.T) {
+	t.Parallel()
+
+	o := TestUpgradeBootOptions{}
+	o.setup(defaultBootRequirements, "", "", "")
+
+	tmpDir, err := ioutil.TempDir("", "upgrade-boot-merge-")
+	require.NoError(t, err)
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		require.NoError(t, err, "could not clean up temp dir")
+	}()
+	o.UpgradeBootOptions.Dir = tmpDir
+
+	ours := map[string][]byte{
+		"jx-requirements.yml": []byte("cluster:\n  provider: gke\n  zone: us-east1-b\nwebhook: prow\n"),
+		"env/values.yaml":     []byte("expose:\n  enabled: false\n"),
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "env"), util.DefaultWritePermissions))
+	gitter := &bootConfigGitFake{
+		files: map[string]string{
+			"1111:jx-requirements.yml": "cluster:\n  provider: gke\n  zone: europe-west1-b\nwebhook: prow\n",
+			"2222:jx-requirements.yml": "cluster:\n  provider: gke\n  zone: europe-west1-b\n  registry: gcr.io\nwebhook: lighthouse\n",
+			"2222:env/values.yaml":     "expose:\n  enabled: true\n",
+			"3333:jx-requirements.yml": "cluster:\n  provider: gke\n  zone: europe-west1-b\nwebhook: prow\n",
+		},
+	}
+	o.SetGit(gitter)
+
+	err = o.mergeFilesByStructure("clone", "1111", "2222", ours)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, "jx-requirements.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "cluster:\n  provider: gke\n  zone: us-east1-b\n  registry: gcr.io\nwebhook: lighthouse\n", string(data))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "env", "values.yaml"), "values.yaml is not in the base boot config")
+	assert.Empty(t, o.conflictReports)
+	assert.Equal(t, []string{"jx-requirements.yml"}, gitter.committed)
+
+	// lets check a file which they did not change is neither reformatted nor committed
+	gitter.committed = nil
+	unchanged := "cluster: {provider: gke, zone: us-east1-b}\nwebhook: prow # our webhook\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "jx-requirements.yml"), []byte(unchanged), util.DefaultWritePermissions))
+	err = o.mergeFilesByStructure("clone", "1111", "3333", map[string][]byte{"jx-requirements.yml": []byte(unchanged)})
+	require.NoError(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(tmpDir, "jx-requirements.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, unchanged, string(data))
+	assert.Empty(t, gitter.committed)
+
+	// lets make a conflicting change
+	ours["jx-requirements.yml"] = []byte("cluster:\n  provider: gke\n  zone: us-east1-b\nwebhook: jenkins\n")
+	err = o.mergeFilesByStructure("clone", "1111", "2222", ours)
+	require.NoError(t, err)
+	require.Len(t, o.conflictReports, 1)
+	assert.Contains(t, o.conflictReports[0], "| `webhook` | <code>prow</code> | <code>jenkins</code> | <code>lighthouse</code> |")
+
+	details, _, err := o.prDetailsAndFilter()
+	require.NoError(t, err)
+	assert.Contains(t, details.Message, "### Conflicts")
+	assert.Contains(t, details.Message, o.conflictReports[0])
+}
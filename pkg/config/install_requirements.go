@@ -455,22 +455,24 @@ func (t *ClusterConfig) UnmarshalJSON(data []byte) error {
 	_, gitPublicSet := raw["environmentGitPublic"]
 	private, gitPrivateSet := raw["environmentGitPrivate"]
 
-	if gitPrivateSet && gitPublicSet {
-		return errors.New("found settings for EnvironmentGitPublic as well as EnvironmentGitPrivate in ClusterConfig, only EnvironmentGitPublic should be used")
-	}
-
 	if gitPrivateSet {
-		log.Logger().Warn("EnvironmentGitPrivate specified in Cluster EnvironmentGitPrivate is deprecated use EnvironmentGitPublic instead.")
-		privateString := string(private)
-		if privateString == "true" {
-			t.EnvironmentGitPublic = false
-		} else {
-			t.EnvironmentGitPublic = true
+		t.EnvironmentGitPublic, err = environmentGitPublicFromPrivate(string(private), gitPublicSet)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// environmentGitPublicFromPrivate returns the EnvironmentGitPublic value for the deprecated EnvironmentGitPrivate value
+func environmentGitPublicFromPrivate(private string, gitPublicSet bool) (bool, error) {
+	if gitPublicSet {
+		return false, errors.New("found settings for EnvironmentGitPublic as well as EnvironmentGitPrivate in ClusterConfig, only EnvironmentGitPublic should be used")
+	}
+	log.Logger().Warn("EnvironmentGitPrivate specified in Cluster EnvironmentGitPrivate is deprecated use EnvironmentGitPublic instead.")
+	return private != "true", nil
+}
+
 // VersionStreamConfig contains version stream config
 type VersionStreamConfig struct {
 	// URL of the version stream to use
@@ -518,6 +520,8 @@ type RequirementsValues struct {
 // RequirementsConfig contains the logical installation requirements in the `jx-requirements.yml` file when
 // installing, configuring or upgrading Jenkins X via `jx boot`
 type RequirementsConfig struct {
+	// APIVersion the version of the shape of the requirements, files without a version are migrated when upgraded
+	APIVersion string `json:"apiVersion,omitempty"`
 	// AutoUpdate contains auto update config
 	AutoUpdate AutoUpdateConfig `json:"autoUpdate,omitempty"`
 	// BootConfigURL contains the url to which the dev environment is associated with
@@ -561,6 +565,7 @@ type RequirementsConfig struct {
 // NewRequirementsConfig creates a default configuration file
 func NewRequirementsConfig() *RequirementsConfig {
 	return &RequirementsConfig{
+		APIVersion:    RequirementsAPIVersion,
 		SecretStorage: SecretStorageTypeLocal,
 		Webhook:       WebhookTypeProw,
 	}
@@ -570,9 +575,19 @@ func NewRequirementsConfig() *RequirementsConfig {
 // if there is not a file called `jx-requirements.yml` in the given dir we will scan up the parent
 // directories looking for the requirements file as we often run 'jx' steps in sub directories.
 func LoadRequirementsConfig(dir string, failOnValidationErrors bool) (*RequirementsConfig, string, error) {
+	fileName, err := FindRequirementsConfigFile(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	config, err := LoadRequirementsConfigFile(fileName, failOnValidationErrors)
+	return config, fileName, err
+}
+
+// FindRequirementsConfigFile returns the `jx-requirements.yml` file in the given dir or the nearest parent directory
+func FindRequirementsConfigFile(dir string) (string, error) {
 	absolute, err := filepath.Abs(dir)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "creating absolute path")
+		return "", errors.Wrap(err, "creating absolute path")
 	}
 	for absolute != "" && absolute != "." && absolute != "/" {
 		fileName := filepath.Join(absolute, RequirementsConfigFileName)
@@ -580,17 +595,14 @@ func LoadRequirementsConfig(dir string, failOnValidationErrors bool) (*Requireme
 
 		exists, err := util.FileExists(fileName)
 		if err != nil {
-			return nil, "", err
+			return "", err
 		}
 
-		if !exists {
-			continue
+		if exists {
+			return fileName, nil
 		}
-
-		config, err := LoadRequirementsConfigFile(fileName, failOnValidationErrors)
-		return config, fileName, err
 	}
-	return nil, "", errors.New("jx-requirements.yml file not found")
+	return "", errors.New("jx-requirements.yml file not found")
 }
 
 // LoadRequirementsConfigFile loads a specific project YAML configuration file
@@ -606,6 +618,11 @@ func LoadRequirementsConfigFile(fileName string, failOnValidationErrors bool) (*
 		return nil, fmt.Errorf("failed to load file %s due to %s", fileName, err)
 	}
 
+	data, _, err = MigrateRequirements(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to migrate YAML file %s", fileName)
+	}
+
 	validationErrors, err := util.ValidateYaml(config, data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate YAML file %s due to %s", fileName, err)
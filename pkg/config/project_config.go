@@ -1,8 +1,11 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/jenkins-x/jx/v2/pkg/jenkinsfile"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
@@ -10,6 +13,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"io/ioutil"
+	"path"
 	"path/filepath"
 	"reflect"
 
@@ -20,6 +24,8 @@ import (
 const (
 	// ProjectConfigFileName is the name of the project configuration file
 	ProjectConfigFileName = "jenkins-x.yml"
+
+	defaultConcurrencyQueueTimeout = time.Hour
 )
 
 // +exported
@@ -41,6 +47,8 @@ type ProjectConfig struct {
 	NoReleasePrepare    bool                        `json:"noReleasePrepare,omitempty"`
 	DockerRegistryHost  string                      `json:"dockerRegistryHost,omitempty"`
 	DockerRegistryOwner string                      `json:"dockerRegistryOwner,omitempty"`
+	Release             *ReleaseConfig              `json:"release,omitempty"`
+	Concurrency         *ConcurrencyConfig          `json:"concurrency,omitempty"`
 }
 
 type PreviewEnvironmentConfig struct {
@@ -67,6 +75,110 @@ type ChatConfig struct {
 	UserChannel      string `json:"userChannel,omitempty"`
 }
 
+// ReleaseConfig contains the configuration of how the releases of a project are versioned
+type ReleaseConfig struct {
+	// Channels maps branches to pre-release channels. Branches which do not match a channel create final releases
+	Channels []ReleaseChannel `json:"channels,omitempty"`
+}
+
+// ReleaseChannel maps branches to a pre-release channel such as beta or rc
+type ReleaseChannel struct {
+	// Branch the name of the branches or a pattern such as release/*
+	Branch string `json:"branch"`
+	// Name the name of the channel used as the pre-release identifier of the versions, e.g. 1.3.0-rc.1
+	Name string `json:"name"`
+}
+
+// ChannelNames returns the names of the pre-release channels
+func (c *ReleaseConfig) ChannelNames() []string {
+	if c == nil {
+		return nil
+	}
+	var answer []string
+	for _, channel := range c.Channels {
+		if util.StringArrayIndex(answer, channel.Name) < 0 {
+			answer = append(answer, channel.Name)
+		}
+	}
+	return answer
+}
+
+// ChannelForBranch returns the pre-release channel of the branch or an empty string if the branch creates final releases
+func (c *ReleaseConfig) ChannelForBranch(branch string) string {
+	if c == nil {
+		return ""
+	}
+	for _, channel := range c.Channels {
+		if channel.Branch == branch {
+			return channel.Name
+		}
+		if matched, _ := path.Match(channel.Branch, branch); matched {
+			return channel.Name
+		}
+	}
+	return ""
+}
+
+// ConcurrencyConfig limits how many pipelines of the same concurrency group can run at the same time
+type ConcurrencyConfig struct {
+	// Group the template of the key of the concurrency group such as deploy-{{.Branch}}. The available fields are
+	// Owner, Repository, Branch, Context and Kind
+	Group string `json:"group"`
+	// MaxParallel the maximum number of pipelines of the group which can run at the same time. Defaults to 1
+	MaxParallel int `json:"maxParallel,omitempty"`
+	// CancelInProgress cancels the pipelines of the group which are in progress when a new pipeline starts rather
+	// than queueing the new pipeline until they are complete
+	CancelInProgress bool `json:"cancelInProgress,omitempty"`
+	// QueueTimeout the maximum duration a pipeline is queued for, such as 30m, before it fails. Defaults to 1h
+	QueueTimeout string `json:"queueTimeout,omitempty"`
+}
+
+// ConcurrencyGroupValues the values available to the template of a concurrency group
+type ConcurrencyGroupValues struct {
+	Owner      string
+	Repository string
+	Branch     string
+	Context    string
+	Kind       string
+}
+
+// GroupName returns the name of the concurrency group for the given values
+func (c *ConcurrencyConfig) GroupName(values ConcurrencyGroupValues) (string, error) {
+	if c == nil || c.Group == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("concurrency").Option("missingkey=error").Parse(c.Group)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the concurrency group %s", c.Group)
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, values)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate the concurrency group %s", c.Group)
+	}
+	return buffer.String(), nil
+}
+
+// GetMaxParallel returns the maximum number of pipelines of the group which can run at the same time
+func (c *ConcurrencyConfig) GetMaxParallel() int {
+	if c == nil || c.MaxParallel <= 0 {
+		return 1
+	}
+	return c.MaxParallel
+}
+
+// GetQueueTimeout returns the maximum duration a pipeline of the group is queued for
+func (c *ConcurrencyConfig) GetQueueTimeout() (time.Duration, error) {
+	if c == nil || c.QueueTimeout == "" {
+		return defaultConcurrencyQueueTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.QueueTimeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid concurrency queueTimeout %s", c.QueueTimeout)
+	}
+	return timeout, nil
+}
+
 type AddonConfig struct {
 	Name    string `json:"name,omitempty"`
 	Version string `json:"version,omitempty"`
@@ -4,6 +4,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/config"
@@ -104,3 +105,69 @@ func TestGetPipeline(t *testing.T) {
 	assert.Equal(t, err.Error(), "no pipeline defined for kind feature")
 	assert.Nil(t, featurePipeline)
 }
+
+func TestReleaseChannelForBranch(t *testing.T) {
+	t.Parallel()
+
+	data := `release:
+  channels:
+  - branch: develop
+    name: beta
+  - branch: release/*
+    name: rc
+`
+	projectConfig := &config.ProjectConfig{}
+	err := yaml.Unmarshal([]byte(data), projectConfig)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "beta", projectConfig.Release.ChannelForBranch("develop"))
+	assert.Equal(t, "rc", projectConfig.Release.ChannelForBranch("release/1.3"))
+	assert.Equal(t, "", projectConfig.Release.ChannelForBranch("master"))
+	assert.Equal(t, "", projectConfig.Release.ChannelForBranch("release/1.3/hotfix"))
+
+	assert.Equal(t, []string{"beta", "rc"}, projectConfig.Release.ChannelNames())
+
+	var noRelease *config.ReleaseConfig
+	assert.Equal(t, "", noRelease.ChannelForBranch("develop"))
+	assert.Empty(t, noRelease.ChannelNames())
+}
+
+func TestConcurrencyGroupName(t *testing.T) {
+	t.Parallel()
+
+	data := `concurrency:
+  group: deploy-{{.Branch}}
+  maxParallel: 2
+  cancelInProgress: true
+  queueTimeout: 30m
+`
+	projectConfig := &config.ProjectConfig{}
+	err := yaml.Unmarshal([]byte(data), projectConfig)
+	assert.NoError(t, err)
+
+	concurrency := projectConfig.Concurrency
+	assert.Equal(t, 2, concurrency.GetMaxParallel())
+	assert.True(t, concurrency.CancelInProgress)
+	timeout, err := concurrency.GetQueueTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, timeout)
+
+	group, err := concurrency.GroupName(config.ConcurrencyGroupValues{Owner: "myorg", Repository: "myapp", Branch: "master"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy-master", group)
+
+	_, err = (&config.ConcurrencyConfig{Group: "deploy-{{.Cheese}}"}).GroupName(config.ConcurrencyGroupValues{})
+	assert.Error(t, err)
+
+	var noConcurrency *config.ConcurrencyConfig
+	group, err = noConcurrency.GroupName(config.ConcurrencyGroupValues{Branch: "master"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", group)
+	assert.Equal(t, 1, noConcurrency.GetMaxParallel())
+	timeout, err = noConcurrency.GetQueueTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, timeout)
+
+	_, err = (&config.ConcurrencyConfig{QueueTimeout: "soon"}).GetQueueTimeout()
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RequirementsAPIVersion is the current apiVersion of the `jx-requirements.yml` file
+	RequirementsAPIVersion = "core.jenkins-x.io/v1"
+)
+
+// RequirementsMigration migrates a `jx-requirements.yml` file from one apiVersion to another
+type RequirementsMigration struct {
+	// From the apiVersion to migrate from, empty for files without an apiVersion
+	From string
+	// To the apiVersion to migrate to
+	To string
+	// Description describes the changes made by the migration
+	Description string
+	// Migrate changes the top level mapping of the file in place so that the order of the keys and the comments are
+	// kept, returning whether anything was changed
+	Migrate func(requirements *yaml.Node) (bool, error)
+}
+
+var requirementsMigrations = []RequirementsMigration{
+	{
+		From:        "",
+		To:          RequirementsAPIVersion,
+		Description: "replace cluster.environmentGitPrivate with cluster.environmentGitPublic",
+		Migrate:     migrateEnvironmentGitPrivate,
+	},
+}
+
+// RegisterRequirementsMigration registers a migration. There can only be one migration from an apiVersion
+func RegisterRequirementsMigration(migration RequirementsMigration) error {
+	for _, m := range requirementsMigrations {
+		if m.From == migration.From {
+			return fmt.Errorf("there is already a migration from apiVersion '%s' to '%s'", m.From, m.To)
+		}
+	}
+	requirementsMigrations = append(requirementsMigrations, migration)
+	return nil
+}
+
+// MigrateRequirements applies the migrations from the apiVersion of the YAML until it is the current apiVersion,
+// returning the migrated YAML and the descriptions of the migrations applied.
+//
+// A file without an apiVersion is treated as the current apiVersion unless something in it needs migrating. A file
+// with an unknown apiVersion, e.g. one written by a newer jx, is returned unchanged with a warning
+func MigrateRequirements(data []byte) ([]byte, []string, error) {
+	document := &yaml.Node{}
+	err := yaml.Unmarshal(data, document)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal the requirements")
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+	requirements := document.Content[0]
+
+	var applied []string
+	for {
+		version := ""
+		_, versionNode := lookupYamlKey(requirements, "apiVersion")
+		if versionNode != nil {
+			version = versionNode.Value
+		}
+		if version == RequirementsAPIVersion {
+			break
+		}
+		migration := findRequirementsMigration(version)
+		if migration == nil {
+			log.Logger().Warnf("unknown requirements apiVersion '%s', the current apiVersion is %s so you may need to upgrade jx", version, RequirementsAPIVersion)
+			return data, nil, nil
+		}
+		changed, err := migration.Migrate(requirements)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to migrate from apiVersion '%s' to '%s'", migration.From, migration.To)
+		}
+		if version == "" && !changed {
+			break
+		}
+		setYamlKey(requirements, "apiVersion", migration.To)
+		applied = append(applied, migration.Description)
+	}
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(document)
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal the migrated requirements")
+	}
+	return buffer.Bytes(), applied, nil
+}
+
+// MigrateRequirementsFile migrates the `jx-requirements.yml` file to the current apiVersion, returning the
+// descriptions of the migrations applied
+func MigrateRequirementsFile(fileName string) ([]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	data, applied, err := MigrateRequirements(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to migrate file %s", fileName)
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return applied, nil
+}
+
+func findRequirementsMigration(from string) *RequirementsMigration {
+	for i := range requirementsMigrations {
+		if requirementsMigrations[i].From == from {
+			return &requirementsMigrations[i]
+		}
+	}
+	return nil
+}
+
+// migrateEnvironmentGitPrivate renames cluster.environmentGitPrivate to cluster.environmentGitPublic in place
+// using the same rules as ClusterConfig.UnmarshalJSON
+func migrateEnvironmentGitPrivate(requirements *yaml.Node) (bool, error) {
+	_, cluster := lookupYamlKey(requirements, "cluster")
+	privateKey, private := lookupYamlKey(cluster, "environmentGitPrivate")
+	if private == nil {
+		return false, nil
+	}
+	publicKey, _ := lookupYamlKey(cluster, "environmentGitPublic")
+	public, err := environmentGitPublicFromPrivate(private.Value, publicKey != nil)
+	if err != nil {
+		return false, err
+	}
+	privateKey.Value = "environmentGitPublic"
+	private.SetString(fmt.Sprintf("%t", public))
+	private.Tag = "!!bool"
+	return true, nil
+}
+
+// lookupYamlKey returns the key and value nodes of a key in a mapping or nil if it is not present
+func lookupYamlKey(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// setYamlKey sets the string value of a key in a mapping, adding it as the first key if it is not present
+func setYamlKey(mapping *yaml.Node, key string, value string) {
+	_, valueNode := lookupYamlKey(mapping, key)
+	if valueNode != nil {
+		valueNode.SetString(value)
+		return
+	}
+	keyNode := &yaml.Node{}
+	keyNode.SetString(key)
+	valueNode = &yaml.Node{}
+	valueNode.SetString(value)
+	if len(mapping.Content) > 0 {
+		// keep the comment at the top of the file at the top
+		keyNode.HeadComment = mapping.Content[0].HeadComment
+		mapping.Content[0].HeadComment = ""
+	}
+	mapping.Content = append([]*yaml.Node{keyNode, valueNode}, mapping.Content...)
+}
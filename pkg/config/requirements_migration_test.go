@@ -0,0 +1,135 @@
+// +build unit
+
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateRequirements(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct {
+		file           string
+		expectedPublic bool
+		migrations     int
+		expectError    bool
+	}{
+		{"git_public_nil_git_private_true.yaml", false, 1, false},
+		{"git_public_nil_git_private_false.yaml", true, 1, false},
+		{"git_public_true_git_private_nil.yaml", true, 0, false},
+		{"git_public_false_git_private_nil.yaml", false, 0, false},
+		{"git_public_true_git_private_true.yaml", false, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.file, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join(testDataDir, testCase.file))
+			require.NoError(t, err)
+
+			migrated, migrations, err := config.MigrateRequirements(data)
+			if testCase.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, migrations, testCase.migrations)
+			assert.NotContains(t, string(migrated), "environmentGitPrivate")
+			if testCase.migrations > 0 {
+				assert.Contains(t, string(migrated), "apiVersion: "+config.RequirementsAPIVersion)
+			}
+
+			validationErrors, err := util.ValidateYaml(&config.RequirementsConfig{}, migrated)
+			require.NoError(t, err)
+			assert.Empty(t, validationErrors)
+
+			migrated, migrations, err = config.MigrateRequirements(migrated)
+			require.NoError(t, err)
+			assert.Empty(t, migrations, "the migrated file should be current")
+
+			requirements := config.NewRequirementsConfig()
+			err = yaml.Unmarshal(migrated, requirements)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedPublic, requirements.Cluster.EnvironmentGitPublic)
+		})
+	}
+}
+
+func TestMigrateRequirementsUnknownAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("apiVersion: core.jenkins-x.io/v99\ncluster:\n  environmentGitPrivate: true\n")
+	migrated, migrations, err := config.MigrateRequirements(data)
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+	assert.Equal(t, string(data), string(migrated))
+}
+
+func TestMigrateRequirementsWithoutAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("cluster:\n  environmentGitPublic: true\n  provider: gke\n")
+	migrated, migrations, err := config.MigrateRequirements(data)
+	require.NoError(t, err)
+	assert.Empty(t, migrations, "a file without an apiVersion which needs no changes is current")
+	assert.Equal(t, string(data), string(migrated))
+}
+
+func TestMigrateRequirementsKeepsOrderAndComments(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`# the boot requirements
+cluster:
+  # the cluster name
+  clusterName: mycluster
+  environmentGitPrivate: false # should be public
+  provider: gke
+autoUpdate:
+  enabled: false
+`)
+	expected := `# the boot requirements
+apiVersion: core.jenkins-x.io/v1
+cluster:
+  # the cluster name
+  clusterName: mycluster
+  environmentGitPublic: true # should be public
+  provider: gke
+autoUpdate:
+  enabled: false
+`
+	migrated, migrations, err := config.MigrateRequirements(data)
+	require.NoError(t, err)
+	assert.Len(t, migrations, 1)
+	assert.Equal(t, expected, string(migrated))
+}
+
+func TestMigrateRequirementsFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-migrate-requirements-")
+	require.NoError(t, err)
+	fileName := filepath.Join(dir, config.RequirementsConfigFileName)
+	err = ioutil.WriteFile(fileName, []byte("cluster:\n  environmentGitPrivate: false\n"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	migrations, err := config.MigrateRequirementsFile(fileName)
+	require.NoError(t, err)
+	assert.Len(t, migrations, 1)
+
+	requirements, err := config.LoadRequirementsConfigFile(fileName, true)
+	require.NoError(t, err)
+	assert.Equal(t, config.RequirementsAPIVersion, requirements.APIVersion)
+	assert.True(t, requirements.Cluster.EnvironmentGitPublic)
+
+	migrations, err = config.MigrateRequirementsFile(fileName)
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+}
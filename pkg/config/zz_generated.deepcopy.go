@@ -890,6 +890,11 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 		*out = new(jenkinsfile.PipelineConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Release != nil {
+		in, out := &in.Release, &out.Release
+		*out = new(ReleaseConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -919,6 +924,43 @@ func (in *ProwValuesConfig) DeepCopy() *ProwValuesConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseChannel) DeepCopyInto(out *ReleaseChannel) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseChannel.
+func (in *ReleaseChannel) DeepCopy() *ReleaseChannel {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseChannel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseConfig) DeepCopyInto(out *ReleaseConfig) {
+	*out = *in
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]ReleaseChannel, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseConfig.
+func (in *ReleaseConfig) DeepCopy() *ReleaseConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequirementsConfig) DeepCopyInto(out *RequirementsConfig) {
 	*out = *in
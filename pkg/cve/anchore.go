@@ -151,6 +151,44 @@ func (a AnchoreProvider) GetImageVulnerabilityTable(jxClient versioned.Interface
 
 }
 
+// GetImageVulnerabilities returns the vulnerabilities of the analysed images matching the image name and optional version
+func (a AnchoreProvider) GetImageVulnerabilities(query CVEQuery) ([]Vulnerability, error) {
+	imageIDs := []string{}
+	if query.ImageID != "" {
+		imageIDs = append(imageIDs, query.ImageID)
+	} else if query.ImageName != "" {
+		var images []Image
+		err := a.AnchoreGet(GetImages, &images)
+		if err != nil {
+			return nil, fmt.Errorf("error getting images %v", err)
+		}
+		for _, image := range images {
+			for _, d := range image.ImageDetails {
+				if d.Repo == query.ImageName || d.Registry+"/"+d.Repo == query.ImageName {
+					if query.Vesion != "" && query.Vesion != d.Tag {
+						continue
+					}
+					imageIDs = append(imageIDs, d.ImageId)
+				}
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("choose an image name, an optinal version or anchore image id to find vulnerabilities")
+	}
+
+	var answer []Vulnerability
+	for _, imageID := range imageIDs {
+		var vList VulnerabilityList
+		subPath := fmt.Sprintf(getVulnerabilitiesByImageID, imageID, vulnerabilityType)
+		err := a.AnchoreGet(subPath, &vList)
+		if err != nil {
+			return nil, fmt.Errorf("error getting vulnerabilities for image %s: %v", imageID, err)
+		}
+		answer = append(answer, vList.Vulnerabilities...)
+	}
+	return answer, nil
+}
+
 // AnchoreGet get command
 func (a AnchoreProvider) AnchoreGet(subPath string, rs result) error {
 
@@ -0,0 +1,295 @@
+package dependencymatrix
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Graph is the transitive dependency graph of a dependency matrix
+type Graph struct {
+	Root  string       `json:"root"`
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a repository in the dependency graph
+type GraphNode struct {
+	ID        string `json:"id"`
+	Host      string `json:"host,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	Component string `json:"component,omitempty"`
+	URL       string `json:"url,omitempty"`
+	// Versions are all the versions of the repository reached via the different paths
+	Versions []string `json:"versions,omitempty"`
+}
+
+// GraphEdge is a dependency from one repository on a version of another
+type GraphEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Version string `json:"version"`
+}
+
+// Graph returns the transitive dependency graph of the matrix. The root is the name of the repository which contains
+// the matrix, each source path of a dependency is an edge from the root to the first repository in the path and from
+// the last repository in the path to the dependency
+func (d *DependencyMatrix) Graph(root string) *Graph {
+	g := &Graph{
+		Root: root,
+	}
+	nodes := map[string]*GraphNode{}
+	edges := map[string]*GraphEdge{}
+	addNode := func(details *DependencyDetails, version string) string {
+		id := details.String()
+		node := nodes[id]
+		if node == nil {
+			node = &GraphNode{
+				ID:        id,
+				Host:      details.Host,
+				Owner:     details.Owner,
+				Repo:      details.Repo,
+				Component: details.Component,
+				URL:       details.URL,
+			}
+			nodes[id] = node
+		}
+		if version != "" && util.StringArrayIndex(node.Versions, version) < 0 {
+			node.Versions = append(node.Versions, version)
+		}
+		return id
+	}
+	addEdge := func(from string, to string, version string) {
+		key := fmt.Sprintf("%s->%s@%s", from, to, version)
+		if edges[key] == nil {
+			edges[key] = &GraphEdge{From: from, To: to, Version: version}
+		}
+	}
+	nodes[root] = &GraphNode{ID: root}
+
+	for _, dep := range d.Dependencies {
+		id := addNode(&dep.DependencyDetails, dep.Version)
+		if len(dep.Sources) == 0 {
+			addEdge(root, id, dep.Version)
+			continue
+		}
+		for _, source := range dep.Sources {
+			from := root
+			for _, e := range source.Path {
+				to := addNode(e, e.Version)
+				addEdge(from, to, e.Version)
+				from = to
+			}
+			addNode(&dep.DependencyDetails, source.Version)
+			addEdge(from, id, source.Version)
+		}
+	}
+
+	for _, node := range nodes {
+		sort.Strings(node.Versions)
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	for _, edge := range edges {
+		g.Edges = append(g.Edges, edge)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Version < b.Version
+	})
+	return g
+}
+
+// Inconsistent returns the nodes which are reached with more than one version
+func (g *Graph) Inconsistent() []*GraphNode {
+	var answer []*GraphNode
+	for _, node := range g.Nodes {
+		if len(node.Versions) > 1 {
+			answer = append(answer, node)
+		}
+	}
+	return answer
+}
+
+// DOT returns the graph in the graphviz DOT format. Nodes with inconsistent versions are coloured red
+func (g *Graph) DOT() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("digraph dependencies {\n")
+	buffer.WriteString("  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		attributes := fmt.Sprintf("label=%q", node.ID)
+		if len(node.Versions) > 1 {
+			attributes += ", color=red"
+		}
+		buffer.WriteString(fmt.Sprintf("  %q [%s];\n", node.ID, attributes))
+	}
+	for _, edge := range g.Edges {
+		buffer.WriteString(fmt.Sprintf("  %q -> %q [label=%q];\n", edge.From, edge.To, edge.Version))
+	}
+	buffer.WriteString("}\n")
+	return buffer.String()
+}
+
+// Mermaid returns the graph as a mermaid flowchart. Nodes with inconsistent versions use the inconsistent class
+func (g *Graph) Mermaid() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("graph LR\n")
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+		buffer.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", id, node.ID))
+	}
+	for _, edge := range g.Edges {
+		buffer.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", ids[edge.From], edge.Version, ids[edge.To]))
+	}
+	inconsistent := g.Inconsistent()
+	if len(inconsistent) > 0 {
+		buffer.WriteString("  classDef inconsistent stroke:#f00\n")
+		for _, node := range inconsistent {
+			buffer.WriteString(fmt.Sprintf("  class %s inconsistent\n", ids[node.ID]))
+		}
+	}
+	return buffer.String()
+}
+
+// Impact is the impact of updating a dependency to a version
+type Impact struct {
+	Dependency string `json:"dependency"`
+	Version    string `json:"version"`
+	// Repositories are all the downstream repositories which depend on the dependency directly or transitively
+	Repositories []string `json:"repositories"`
+	// Paths are the paths from the root to the dependency
+	Paths []*ImpactPath `json:"paths"`
+	// Versions are the versions of the dependency currently reached via the paths
+	Versions []string `json:"versions"`
+}
+
+// ImpactPath is a path from the root to a dependency and the version of the dependency currently used on that path
+type ImpactPath struct {
+	Path           []string `json:"path"`
+	CurrentVersion string   `json:"currentVersion"`
+	// Update is true if the current version differs from the version being analysed
+	Update bool `json:"update"`
+}
+
+// Inconsistent returns true if different versions of the dependency are reached via different paths
+func (i *Impact) Inconsistent() bool {
+	return len(i.Versions) > 1
+}
+
+// ParseDependencyRef parses a reference of the form host/owner/repo[:component][@version]
+func ParseDependencyRef(ref string) (*DependencyDetails, error) {
+	answer := &DependencyDetails{}
+	idx := strings.LastIndex(ref, "@")
+	if idx >= 0 {
+		answer.Version = ref[idx+1:]
+		ref = ref[:idx]
+	}
+	idx = strings.Index(ref, ":")
+	if idx >= 0 {
+		answer.Component = ref[idx+1:]
+		ref = ref[:idx]
+	}
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, errors.Errorf("invalid dependency %s, it should be of the form host/owner/repo@version", ref)
+	}
+	answer.Host = parts[0]
+	answer.Owner = parts[1]
+	answer.Repo = parts[2]
+	return answer, nil
+}
+
+// matches returns true if the details are the same repository as the reference, any component matches if the
+// reference has no component
+func (d *DependencyDetails) matches(ref *DependencyDetails) bool {
+	return d.Host == ref.Host && d.Owner == ref.Owner && d.Repo == ref.Repo && (ref.Component == "" || d.Component == ref.Component)
+}
+
+// Impact returns every downstream repository and path affected by updating the referenced dependency to its version
+func (d *DependencyMatrix) Impact(root string, ref *DependencyDetails) (*Impact, error) {
+	answer := &Impact{
+		Dependency: ref.String(),
+		Version:    ref.Version,
+	}
+	paths := map[string]*ImpactPath{}
+	addPath := func(path DependencyPath, current string) {
+		ids := []string{root}
+		for _, e := range path {
+			ids = append(ids, e.String())
+		}
+		key := strings.Join(ids, ";") + "@" + current
+		if paths[key] != nil {
+			return
+		}
+		paths[key] = &ImpactPath{
+			Path:           ids,
+			CurrentVersion: current,
+			Update:         ref.Version != "" && current != ref.Version,
+		}
+		if util.StringArrayIndex(answer.Versions, current) < 0 {
+			answer.Versions = append(answer.Versions, current)
+		}
+	}
+
+	found := false
+	for _, dep := range d.Dependencies {
+		if dep.matches(ref) {
+			found = true
+			if len(dep.Sources) == 0 {
+				addPath(nil, dep.Version)
+			}
+			for _, source := range dep.Sources {
+				addPath(source.Path, source.Version)
+			}
+		}
+		// the dependency may also be reached part way along the paths of other dependencies
+		for _, source := range dep.Sources {
+			for i, e := range source.Path {
+				if e.matches(ref) {
+					found = true
+					addPath(source.Path[:i], e.Version)
+					break
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("could not find the dependency %s in the dependency matrix", ref.String())
+	}
+
+	repositories := map[string]bool{}
+	for _, p := range paths {
+		for _, id := range p.Path {
+			repositories[id] = true
+		}
+		answer.Paths = append(answer.Paths, p)
+	}
+	for id := range repositories {
+		answer.Repositories = append(answer.Repositories, id)
+	}
+	sort.Strings(answer.Repositories)
+	sort.Strings(answer.Versions)
+	sort.Slice(answer.Paths, func(i, j int) bool {
+		a, b := strings.Join(answer.Paths[i].Path, ";"), strings.Join(answer.Paths[j].Path, ";")
+		if a != b {
+			return a < b
+		}
+		return answer.Paths[i].CurrentVersion < answer.Paths[j].CurrentVersion
+	})
+	return answer, nil
+}
@@ -0,0 +1,83 @@
+// +build unit
+
+package dependencymatrix_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/dependencymatrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	matrix, err := dependencymatrix.LoadDependencyMatrix(filepath.Join("testdata", "two_versions_two_paths_matrix_inconsistent"))
+	require.NoError(t, err)
+
+	g := matrix.Graph("root")
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []string{"fake.git/acme/roadrunner", "fake.git/acme/wiley", "fake.git/cheese/brie", "fake.git/cheese/camembert", "fake.git/cheese/cheddar", "root"}, ids)
+
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+" -> "+e.To+"@"+e.Version)
+	}
+	assert.Equal(t, []string{
+		"fake.git/acme/wiley -> fake.git/acme/roadrunner@0.0.1",
+		"fake.git/cheese/brie -> fake.git/acme/roadrunner@0.0.2",
+		"fake.git/cheese/brie -> fake.git/cheese/cheddar@0.0.1",
+		"fake.git/cheese/camembert -> fake.git/cheese/cheddar@0.0.1",
+		"root -> fake.git/acme/wiley@0.0.1",
+		"root -> fake.git/cheese/brie@0.0.2",
+		"root -> fake.git/cheese/camembert@0.0.1",
+	}, edges)
+
+	inconsistent := g.Inconsistent()
+	require.Len(t, inconsistent, 1)
+	assert.Equal(t, "fake.git/acme/roadrunner", inconsistent[0].ID)
+	assert.Equal(t, []string{"0.0.1", "0.0.2"}, inconsistent[0].Versions)
+
+	assert.Contains(t, g.DOT(), `"fake.git/cheese/brie" -> "fake.git/acme/roadrunner" [label="0.0.2"];`)
+	assert.Contains(t, g.DOT(), `"fake.git/acme/roadrunner" [label="fake.git/acme/roadrunner", color=red];`)
+	assert.Contains(t, g.Mermaid(), "  n2 -->|0.0.2| n0\n")
+	assert.Contains(t, g.Mermaid(), "  class n0 inconsistent\n")
+}
+
+func TestDependencyImpact(t *testing.T) {
+	matrix, err := dependencymatrix.LoadDependencyMatrix(filepath.Join("testdata", "two_versions_two_paths_matrix_inconsistent"))
+	require.NoError(t, err)
+
+	ref, err := dependencymatrix.ParseDependencyRef("fake.git/acme/roadrunner@0.0.2")
+	require.NoError(t, err)
+	impact, err := matrix.Impact("root", ref)
+	require.NoError(t, err)
+
+	assert.True(t, impact.Inconsistent())
+	assert.Equal(t, []string{"0.0.1", "0.0.2"}, impact.Versions)
+	assert.Equal(t, []string{"fake.git/acme/wiley", "fake.git/cheese/brie", "root"}, impact.Repositories)
+	require.Len(t, impact.Paths, 2)
+	assert.Equal(t, []string{"root", "fake.git/acme/wiley"}, impact.Paths[0].Path)
+	assert.Equal(t, "0.0.1", impact.Paths[0].CurrentVersion)
+	assert.True(t, impact.Paths[0].Update)
+	assert.False(t, impact.Paths[1].Update)
+
+	// a dependency which is only reached part way along the paths of other dependencies
+	ref, err = dependencymatrix.ParseDependencyRef("fake.git/cheese/brie")
+	require.NoError(t, err)
+	impact, err = matrix.Impact("root", ref)
+	require.NoError(t, err)
+	assert.False(t, impact.Inconsistent())
+	assert.Equal(t, []string{"root"}, impact.Repositories)
+
+	ref, err = dependencymatrix.ParseDependencyRef("fake.git/acme/coyote@1.0.0")
+	require.NoError(t, err)
+	_, err = matrix.Impact("root", ref)
+	assert.Error(t, err)
+
+	_, err = dependencymatrix.ParseDependencyRef("acme/roadrunner@1.0.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,297 @@
+package dora
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/buildstats"
+)
+
+const (
+	// FailureReasonPromotion the promotion itself failed
+	FailureReasonPromotion = "promotion-failed"
+
+	// FailureReasonRollback the deployment was followed by a rollback to an older version
+	FailureReasonRollback = "rollback"
+
+	// FailureReasonHotfix the deployment was followed by a hotfix
+	FailureReasonHotfix = "hotfix"
+)
+
+// Deployment represents the promotion of a version of an application into an environment
+type Deployment struct {
+	App           string        `json:"app"`
+	Owner         string        `json:"owner,omitempty"`
+	Version       string        `json:"version"`
+	Environment   string        `json:"environment"`
+	Activity      string        `json:"activity,omitempty"`
+	GitURL        string        `json:"gitUrl,omitempty"`
+	Commit        string        `json:"commit,omitempty"`
+	DeployedAt    time.Time     `json:"deployedAt"`
+	ChangedAt     time.Time     `json:"changedAt,omitempty"`
+	LeadTime      time.Duration `json:"leadTime,omitempty"`
+	Failed        bool          `json:"failed,omitempty"`
+	FailureReason string        `json:"failureReason,omitempty"`
+	TimeToRestore time.Duration `json:"timeToRestore,omitempty"`
+
+	promotionFailed bool
+	hotfix          bool
+}
+
+// CommitTimeFunc returns the time the given commit of the repository of the deployment was made or nil if it
+// is not known
+type CommitTimeFunc func(d *Deployment, sha string) *time.Time
+
+// FindDeployments finds the deployments into the given environment from the promote steps of the activities
+func FindDeployments(activities []v1.PipelineActivity, environment string) []*Deployment {
+	var answer []*Deployment
+	for i := range activities {
+		a := &activities[i]
+		for _, step := range a.Spec.Steps {
+			promote := step.Promote
+			if step.Kind != v1.ActivityStepKindTypePromote || promote == nil || promote.Environment != environment {
+				continue
+			}
+			deployedAt := promote.CompletedTimestamp
+			if deployedAt == nil {
+				continue
+			}
+			status := promote.Status
+			if status != v1.ActivityStatusTypeSucceeded && status != v1.ActivityStatusTypeFailed && status != v1.ActivityStatusTypeError {
+				continue
+			}
+			d := &Deployment{
+				App:             a.RepositoryName(),
+				Owner:           a.RepositoryOwner(),
+				Version:         a.Spec.Version,
+				Environment:     environment,
+				Activity:        a.Name,
+				GitURL:          a.Spec.GitURL,
+				Commit:          a.Spec.LastCommitSHA,
+				DeployedAt:      deployedAt.Time,
+				promotionFailed: status != v1.ActivityStatusTypeSucceeded,
+			}
+			if strings.Contains(strings.ToLower(a.BranchName()), "hotfix") {
+				d.hotfix = true
+			}
+			answer = append(answer, d)
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].DeployedAt.Before(answer[j].DeployedAt)
+	})
+	return answer
+}
+
+// ApplyReleases uses the releases generated by 'jx step changelog' to determine whether each deployment is a hotfix
+// and the time its changes were committed.
+//
+// The change time is the time of the oldest commit of the release or, if the release is not known, the time of the
+// commit the pipeline built. Deployments without a known commit time have no lead time
+func ApplyReleases(deployments []*Deployment, releases []v1.Release, commitTimeFn CommitTimeFunc) {
+	for _, d := range deployments {
+		release := findRelease(releases, d)
+		if release != nil && IsHotfix(release) {
+			d.hotfix = true
+		}
+		if commitTimeFn == nil {
+			continue
+		}
+		for _, sha := range changeCommits(release, d) {
+			t := commitTimeFn(d, sha)
+			if t != nil {
+				d.ChangedAt = *t
+				break
+			}
+		}
+	}
+}
+
+// changeCommits returns the commits to take the change time of the deployment from in order of preference
+func changeCommits(release *v1.Release, d *Deployment) []string {
+	var answer []string
+	if release != nil {
+		// the changelog lists the newest commits first
+		commits := release.Spec.Commits
+		for i := len(commits) - 1; i >= 0; i-- {
+			if commits[i].SHA != "" {
+				answer = append(answer, commits[i].SHA)
+				break
+			}
+		}
+	}
+	if d.Commit != "" {
+		answer = append(answer, d.Commit)
+	}
+	return answer
+}
+
+// IsHotfix returns true if the release looks like a hotfix from its commits and pull requests
+func IsHotfix(release *v1.Release) bool {
+	for _, c := range release.Spec.Commits {
+		if isHotfixText(c.Message) || isHotfixText(c.Branch) {
+			return true
+		}
+	}
+	for _, pr := range release.Spec.PullRequests {
+		if isHotfixText(pr.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHotfixText(text string) bool {
+	return strings.Contains(strings.ToLower(text), "hotfix")
+}
+
+func findRelease(releases []v1.Release, d *Deployment) *v1.Release {
+	version := strings.TrimPrefix(d.Version, "v")
+	for i := range releases {
+		r := &releases[i]
+		if strings.TrimPrefix(r.Spec.Version, "v") != version {
+			continue
+		}
+		if r.Spec.GitRepository == d.App || r.Spec.Name == d.App {
+			return r
+		}
+	}
+	return nil
+}
+
+// ClassifyFailures works out which deployments failed and how long it took to restore service.
+//
+// A deployment is a failure if its promotion failed, if the next deployment of the application was a rollback
+// to an older version or if the next deployment was a hotfix within the hotfix window
+func ClassifyFailures(deployments []*Deployment, hotfixWindow time.Duration) {
+	byApp := map[string][]*Deployment{}
+	for _, d := range deployments {
+		if !d.ChangedAt.IsZero() && d.DeployedAt.After(d.ChangedAt) {
+			d.LeadTime = d.DeployedAt.Sub(d.ChangedAt)
+		}
+		byApp[d.App] = append(byApp[d.App], d)
+	}
+	for _, list := range byApp {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].DeployedAt.Before(list[j].DeployedAt)
+		})
+		for i, d := range list {
+			if d.promotionFailed {
+				d.Failed = true
+				d.FailureReason = FailureReasonPromotion
+			} else if i+1 < len(list) {
+				next := list[i+1]
+				if isOlderVersion(next.Version, d.Version) {
+					d.Failed = true
+					d.FailureReason = FailureReasonRollback
+				} else if next.hotfix && next.DeployedAt.Sub(d.DeployedAt) <= hotfixWindow {
+					d.Failed = true
+					d.FailureReason = FailureReasonHotfix
+				}
+			}
+			if !d.Failed {
+				continue
+			}
+			for _, restore := range list[i+1:] {
+				if !restore.promotionFailed {
+					d.TimeToRestore = restore.DeployedAt.Sub(d.DeployedAt)
+					break
+				}
+			}
+		}
+	}
+}
+
+func isOlderVersion(version string, than string) bool {
+	a, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	b, err := semver.NewVersion(than)
+	if err != nil {
+		return false
+	}
+	return a.LessThan(b)
+}
+
+// Metrics the DORA metrics of an application or team
+type Metrics struct {
+	Name                string        `json:"name"`
+	Deployments         int           `json:"deployments"`
+	DeploymentFrequency float64       `json:"deploymentsPerDay"`
+	LeadTime            time.Duration `json:"leadTime"`
+	Failures            int           `json:"failures"`
+	ChangeFailureRate   float64       `json:"changeFailureRate"`
+	TimeToRestore       time.Duration `json:"timeToRestore"`
+}
+
+// Report the DORA metrics of each application and the whole team over a time window
+type Report struct {
+	Team        string        `json:"team,omitempty"`
+	Environment string        `json:"environment"`
+	Since       time.Time     `json:"since"`
+	Until       time.Time     `json:"until"`
+	Apps        []*Metrics    `json:"apps,omitempty"`
+	Total       *Metrics      `json:"total"`
+	Deployments []*Deployment `json:"deployments,omitempty"`
+}
+
+// Calculate calculates the DORA metrics of the deployments which happened within the time window
+func Calculate(team string, environment string, deployments []*Deployment, since time.Time, until time.Time) *Report {
+	report := &Report{
+		Team:        team,
+		Environment: environment,
+		Since:       since,
+		Until:       until,
+	}
+	byApp := map[string][]*Deployment{}
+	var all []*Deployment
+	for _, d := range deployments {
+		if d.DeployedAt.Before(since) || d.DeployedAt.After(until) {
+			continue
+		}
+		byApp[d.App] = append(byApp[d.App], d)
+		all = append(all, d)
+	}
+	days := until.Sub(since).Hours() / 24
+	for app, list := range byApp {
+		report.Apps = append(report.Apps, calculateMetrics(app, list, days))
+	}
+	sort.Slice(report.Apps, func(i, j int) bool {
+		return report.Apps[i].Name < report.Apps[j].Name
+	})
+	report.Total = calculateMetrics(team, all, days)
+	report.Deployments = all
+	return report
+}
+
+func calculateMetrics(name string, deployments []*Deployment, days float64) *Metrics {
+	m := &Metrics{
+		Name:        name,
+		Deployments: len(deployments),
+	}
+	var leadTimes, restoreTimes []time.Duration
+	for _, d := range deployments {
+		if d.LeadTime > 0 {
+			leadTimes = append(leadTimes, d.LeadTime)
+		}
+		if d.Failed {
+			m.Failures++
+			if d.TimeToRestore > 0 {
+				restoreTimes = append(restoreTimes, d.TimeToRestore)
+			}
+		}
+	}
+	if days > 0 {
+		m.DeploymentFrequency = float64(m.Deployments) / days
+	}
+	if m.Deployments > 0 {
+		m.ChangeFailureRate = float64(m.Failures) * 100 / float64(m.Deployments)
+	}
+	m.LeadTime = buildstats.Percentile(leadTimes, 50)
+	m.TimeToRestore = buildstats.Percentile(restoreTimes, 50)
+	return m
+}
@@ -0,0 +1,159 @@
+// +build unit
+
+package dora_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/dora"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDORAMetrics(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(10 * 24 * time.Hour)
+	day := func(d int, h int) time.Time {
+		return since.Add(time.Duration(d)*24*time.Hour + time.Duration(h)*time.Hour)
+	}
+
+	activities := []v1.PipelineActivity{
+		createActivity("myapp", "1", "1.0.0", "master", day(1, 0), day(1, 2), v1.ActivityStatusTypeSucceeded),
+		// 1.1.0 is rolled back by 1.0.0 two hours later
+		createActivity("myapp", "2", "1.1.0", "master", day(2, 0), day(2, 1), v1.ActivityStatusTypeSucceeded),
+		createActivity("myapp", "3", "1.0.0", "master", day(2, 1), day(2, 3), v1.ActivityStatusTypeSucceeded),
+		// 1.2.0 is followed by a hotfix within the window
+		createActivity("myapp", "4", "1.2.0", "master", day(4, 0), day(4, 1), v1.ActivityStatusTypeSucceeded),
+		createActivity("myapp", "5", "1.2.1", "hotfix-1", day(4, 2), day(4, 5), v1.ActivityStatusTypeSucceeded),
+		// the promotion of other fails and is restored by the next build
+		createActivity("other", "1", "0.0.1", "master", day(3, 0), day(3, 1), v1.ActivityStatusTypeFailed),
+		createActivity("other", "2", "0.0.2", "master", day(3, 1), day(3, 5), v1.ActivityStatusTypeSucceeded),
+		// outside of the time window
+		createActivity("other", "0", "0.0.0", "master", day(-5, 0), day(-5, 1), v1.ActivityStatusTypeSucceeded),
+	}
+	// staging promotions are ignored
+	staging := createActivity("other", "3", "0.0.3", "master", day(5, 0), day(5, 1), v1.ActivityStatusTypeSucceeded)
+	staging.Spec.Steps[0].Promote.Environment = "staging"
+	activities = append(activities, staging)
+
+	releases := []v1.Release{
+		{
+			Spec: v1.ReleaseSpec{
+				Name:          "myapp",
+				GitRepository: "myapp",
+				Version:       "v1.0.0",
+				Commits: []v1.CommitSummary{
+					{SHA: "myapp-1", Message: "feat: more"},
+					{SHA: "myapp-0", Message: "feat: something"},
+				},
+			},
+		},
+	}
+	commitTimes := map[string]time.Time{
+		"myapp-0": day(0, 0),
+		"myapp-1": day(0, 12),
+		"other-2": day(3, 0),
+	}
+	commitTimeFn := func(d *dora.Deployment, sha string) *time.Time {
+		assert.Equal(t, "https://github.com/myorg/"+d.App+".git", d.GitURL)
+		t, ok := commitTimes[sha]
+		if !ok {
+			return nil
+		}
+		return &t
+	}
+
+	deployments := dora.FindDeployments(activities, "production")
+	require.Len(t, deployments, 8)
+	dora.ApplyReleases(deployments, releases, commitTimeFn)
+	dora.ClassifyFailures(deployments, 24*time.Hour)
+
+	report := dora.Calculate("jx", "production", deployments, since, until)
+	require.Len(t, report.Apps, 2)
+	require.Len(t, report.Deployments, 7)
+
+	myapp := report.Apps[0]
+	assert.Equal(t, "myapp", myapp.Name)
+	assert.Equal(t, 5, myapp.Deployments)
+	assert.Equal(t, 0.5, myapp.DeploymentFrequency)
+	assert.Equal(t, 2, myapp.Failures)
+	assert.Equal(t, float64(40), myapp.ChangeFailureRate)
+	assert.Equal(t, 2*time.Hour, myapp.TimeToRestore)
+
+	first := report.Deployments[0]
+	assert.Equal(t, "1.0.0", first.Version)
+	assert.Equal(t, 26*time.Hour, first.LeadTime, "the lead time should start at the oldest commit of the release")
+
+	leadTimes := map[string]time.Duration{}
+	for _, d := range report.Deployments {
+		if d.LeadTime > 0 {
+			leadTimes[d.Activity] = d.LeadTime
+		}
+	}
+	assert.Equal(t, map[string]time.Duration{
+		"myorg-myapp-master-1": 26 * time.Hour,
+		"myorg-myapp-master-3": 51 * time.Hour,
+		"myorg-other-master-2": 5 * time.Hour,
+	}, leadTimes, "the lead time should start at the commit of the activity if the release is not known")
+
+	other := report.Apps[1]
+	assert.Equal(t, "other", other.Name)
+	assert.Equal(t, 2, other.Deployments)
+	assert.Equal(t, 1, other.Failures)
+	assert.Equal(t, 4*time.Hour, other.TimeToRestore)
+
+	reasons := map[string]string{}
+	for _, d := range report.Deployments {
+		if d.Failed {
+			reasons[d.App+"-"+d.Version] = d.FailureReason
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"myapp-1.1.0": dora.FailureReasonRollback,
+		"myapp-1.2.0": dora.FailureReasonHotfix,
+		"other-0.0.1": dora.FailureReasonPromotion,
+	}, reasons)
+
+	total := report.Total
+	assert.Equal(t, "jx", total.Name)
+	assert.Equal(t, 7, total.Deployments)
+	assert.Equal(t, 3, total.Failures)
+}
+
+func createActivity(app string, build string, version string, branch string, created time.Time, promoted time.Time, status v1.ActivityStatusType) v1.PipelineActivity {
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "myorg-" + app + "-" + branch + "-" + build,
+			CreationTimestamp: metav1.Time{Time: created},
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "myorg/" + app + "/" + branch,
+			Build:         build,
+			Version:       version,
+			GitURL:        "https://github.com/myorg/" + app + ".git",
+			GitOwner:      "myorg",
+			GitRepository: app,
+			GitBranch:     branch,
+			LastCommitSHA: app + "-" + build,
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Name:               "promote: production",
+							Status:             status,
+							StartedTimestamp:   &metav1.Time{Time: created},
+							CompletedTimestamp: &metav1.Time{Time: promoted},
+						},
+						Environment: "production",
+					},
+				},
+			},
+		},
+	}
+}
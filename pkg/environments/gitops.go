@@ -20,6 +20,8 @@ import (
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
 	"github.com/jenkins-x/jx/v2/pkg/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
@@ -35,14 +37,27 @@ type ValuesFiles struct {
 type ModifyChartFn func(requirements *helm.Requirements, metadata *chart.Metadata, existingValues map[string]interface{},
 	templates map[string]string, dir string, pullRequestDetails *gits.PullRequestDetails) error
 
+// ModifyHelmfileFn callback for modifying the helmfiles of an environment which uses helmfile rather than a
+// requirements.yaml, i.e. the top level helmfile and the helmfiles of each phase of the jx-apps.yml. The dir is the
+// root directory of the environment repository
+type ModifyHelmfileFn func(states []*helmfile.HelmState, dir string, pullRequestDetails *gits.PullRequestDetails) error
+
+// ModifyKustomizationFn callback for modifying an environment which uses kustomize rather than a requirements.yaml,
+// the dir is the root directory of the environment repository containing the kustomization.yaml
+type ModifyKustomizationFn func(dir string, pullRequestDetails *gits.PullRequestDetails) error
+
 // EnvironmentPullRequestOptions are options for creating a pull request against an environment.
 // The provide a Gitter client for performing git operations, a GitProvider client for talking to the git provider,
 // a callback ModifyChartFn which is where the changes you want to make are defined,
+// a callback ModifyHelmfileFn which is used instead for environments which use a helmfile
+// and a callback ModifyKustomizationFn which is used instead for environments which use kustomize.
 type EnvironmentPullRequestOptions struct {
-	Gitter        gits.Gitter
-	GitProvider   gits.GitProvider
-	ModifyChartFn ModifyChartFn
-	Labels        []string
+	Gitter                gits.Gitter
+	GitProvider           gits.GitProvider
+	ModifyChartFn         ModifyChartFn
+	ModifyHelmfileFn      ModifyHelmfileFn
+	ModifyKustomizationFn ModifyKustomizationFn
+	Labels                []string
 }
 
 // Create a pull request against the environment repository for env.
@@ -71,7 +86,27 @@ func (o *EnvironmentPullRequestOptions) Create(env *jenkinsv1.Environment, prDir
 			prDir)
 	}
 
-	err = ModifyChartFiles(dir, pullRequestDetails, o.ModifyChartFn, chartName)
+	helmfileName, err := helmfile.FindHelmfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	states, err := helmfile.LoadHelmfiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	kustomizationExists, err := util.FileExists(filepath.Join(dir, kustomize.KustomizationFileName))
+	if err != nil {
+		return nil, err
+	}
+	// operations without a callback for helmfile or kustomize environments modify the chart files of the environment
+	switch {
+	case kustomizationExists && o.ModifyKustomizationFn != nil:
+		err = o.ModifyKustomizationFn(dir, pullRequestDetails)
+	case (helmfileName != "" || len(states) > 0) && o.ModifyHelmfileFn != nil:
+		err = ModifyHelmfiles(dir, states, pullRequestDetails, o.ModifyHelmfileFn)
+	default:
+		err = ModifyChartFiles(dir, pullRequestDetails, o.ModifyChartFn, chartName)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +184,32 @@ func ModifyChartFiles(dir string, details *gits.PullRequestDetails, modifyFn Mod
 	return nil
 }
 
+// ModifyHelmfiles modifies the helmfiles of the environment in the given dir using the given modify function, saving
+// the helmfiles which are changed
+func ModifyHelmfiles(dir string, states []*helmfile.HelmState, details *gits.PullRequestDetails, modifyFn ModifyHelmfileFn) error {
+	if len(states) == 0 {
+		return fmt.Errorf("the helmfiles in %s contain templates so cannot be modified", dir)
+	}
+	err := modifyFn(states, dir, details)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		modified, err := state.Modified()
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal helmfile %s", state.FilePath)
+		}
+		if !modified {
+			continue
+		}
+		err = helmfile.SaveHelmfile(state.FilePath, state)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CreateUpgradeRequirementsFn creates the ModifyChartFn that upgrades the requirements of a chart.
 // Either all requirements may be upgraded, or the chartName,
 // alias and version can be specified. A username and password can be passed for a protected repository.
@@ -0,0 +1,70 @@
+package filesync
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Executor runs commands in a container, such as the container of a DevPod
+type Executor interface {
+	// Exec runs the command with the given standard input, which may be nil, writing its standard output to stdout
+	Exec(command []string, stdin io.Reader, stdout io.Writer) error
+}
+
+// PodExecutor runs commands in a container of a pod via the exec API of Kubernetes
+type PodExecutor struct {
+	KubeClient kubernetes.Interface
+	Config     *rest.Config
+	Namespace  string
+	Pod        string
+	Container  string
+}
+
+// NewPodExecutor creates an executor which runs commands in the container of the pod
+func NewPodExecutor(kubeClient kubernetes.Interface, config *rest.Config, ns string, pod string, container string) *PodExecutor {
+	return &PodExecutor{
+		KubeClient: kubeClient,
+		Config:     config,
+		Namespace:  ns,
+		Pod:        pod,
+		Container:  container,
+	}
+}
+
+// Exec runs the command in the container of the pod
+func (e *PodExecutor) Exec(command []string, stdin io.Reader, stdout io.Writer) error {
+	req := e.KubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(e.Pod).
+		Namespace(e.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: e.Container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(e.Config, "POST", req.URL())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the exec client for pod %s", e.Pod)
+	}
+	stderr := &bytes.Buffer{}
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to run '%s' in pod %s: %s", strings.Join(command, " "), e.Pod, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package fake
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/filesync"
+)
+
+// Executor a fake implementation of the filesync executor which runs the commands used by the syncer against a local
+// directory which plays the part of the file system of the container
+type Executor struct {
+	Root     string
+	Commands [][]string
+}
+
+// verify we implement the interface
+var _ filesync.Executor = &Executor{}
+
+// NewExecutor creates a new fake executor for testing using the given directory as the root of the container
+func NewExecutor(root string) *Executor {
+	return &Executor{
+		Root: root,
+	}
+}
+
+// Exec runs the command against the root directory
+func (e *Executor) Exec(command []string, stdin io.Reader, stdout io.Writer) error {
+	e.Commands = append(e.Commands, command)
+	if len(command) == 0 {
+		return fmt.Errorf("no command")
+	}
+	args := command[1:]
+	switch command[0] {
+	case "mkdir":
+		return os.MkdirAll(e.path(args[len(args)-1]), os.ModePerm)
+	case "find":
+		return e.find(args, stdout)
+	case "touch":
+		for _, arg := range args {
+			f, err := os.OpenFile(e.path(arg), os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			f.Close()
+		}
+		return nil
+	case "rm":
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			err := os.Remove(e.path(arg))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	case "tar":
+		return e.tar(args, stdin, stdout)
+	}
+	return fmt.Errorf("unsupported command: %s", strings.Join(command, " "))
+}
+
+func (e *Executor) path(containerPath string) string {
+	return filepath.Join(e.Root, filepath.FromSlash(containerPath))
+}
+
+func (e *Executor) find(args []string, stdout io.Writer) error {
+	dir := args[0]
+	prune := map[string]bool{}
+	for i, arg := range args {
+		if arg == "-name" && i+1 < len(args) {
+			prune[args[i+1]] = true
+		}
+	}
+	root := e.path(dir)
+	return filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if prune[info.Name()] && file != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%d %d %s/%s\n", info.Size(), info.ModTime().Unix(), strings.TrimSuffix(dir, "/"), filepath.ToSlash(rel))
+		return err
+	})
+}
+
+func (e *Executor) tar(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 4 || args[2] != "-C" {
+		return fmt.Errorf("unsupported tar arguments: %s", strings.Join(args, " "))
+	}
+	dir := e.path(args[3])
+	switch args[0] {
+	case "xzf":
+		_, err := buildcache.Extract(stdin, dir)
+		return err
+	case "czf":
+		var paths []string
+		for _, arg := range args[4:] {
+			if arg != "--" {
+				paths = append(paths, filepath.Join(dir, filepath.FromSlash(arg)))
+			}
+		}
+		_, err := buildcache.Archive(stdout, dir, paths)
+		return err
+	}
+	return fmt.Errorf("unsupported tar arguments: %s", strings.Join(args, " "))
+}
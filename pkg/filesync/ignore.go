@@ -0,0 +1,77 @@
+package filesync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gitignore "github.com/denormal/go-gitignore"
+	"github.com/pkg/errors"
+)
+
+// DefaultIgnoredNames the names of the files and directories which are never synchronised at any depth of the tree
+var DefaultIgnoredNames = []string{".git", ".idea", ".settings", ".vscode", "bin", "build", "target", "node_modules"}
+
+// IgnoreFiles the names of the files which contain the patterns of the files which are not synchronised
+var IgnoreFiles = []string{".gitignore", ".dockerignore", ".stignore"}
+
+// Ignorer decides which files of a directory tree are not synchronised
+type Ignorer struct {
+	names   map[string]bool
+	ignores []gitignore.GitIgnore
+}
+
+// NewIgnorer creates an Ignorer for the directory which ignores the files and directories with the given names and
+// the patterns of the .gitignore, .dockerignore and .stignore files of the directory
+func NewIgnorer(dir string, names []string) (*Ignorer, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the absolute path of %s", dir)
+	}
+	answer := &Ignorer{
+		names: map[string]bool{},
+	}
+	for _, n := range names {
+		answer.names[n] = true
+	}
+	for _, file := range IgnoreFiles {
+		_, err := os.Stat(filepath.Join(absDir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		ignore, err := gitignore.NewRepositoryWithFile(absDir, file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the %s files of %s", file, dir)
+		}
+		answer.ignores = append(answer.ignores, ignore)
+	}
+	return answer, nil
+}
+
+// Ignored returns true if the file or directory with the given slash separated path relative to the directory is
+// not synchronised
+func (i *Ignorer) Ignored(path string, isDir bool) bool {
+	for _, part := range strings.Split(path, "/") {
+		if i.names[part] {
+			return true
+		}
+	}
+	for _, ignore := range i.ignores {
+		match := ignore.Relative(filepath.FromSlash(path), isDir)
+		if match != nil && match.Ignore() {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the sorted names of the files and directories which are ignored at any depth of the tree
+func (i *Ignorer) Names() []string {
+	var answer []string
+	for n := range i.names {
+		answer = append(answer, n)
+	}
+	sort.Strings(answer)
+	return answer
+}
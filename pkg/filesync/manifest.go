@@ -0,0 +1,149 @@
+package filesync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileState the state of a file used to detect if it has changed
+type FileState struct {
+	Size int64
+	// ModTime the modification time in nanoseconds since the epoch
+	ModTime int64
+}
+
+// Equivalent returns true if the files on either side of the sync are the same. As copying a file only keeps its
+// modification time to the second the times are only compared in seconds
+func (s FileState) Equivalent(other FileState) bool {
+	return s.Size == other.Size && s.ModTime/int64(time.Second) == other.ModTime/int64(time.Second)
+}
+
+// Manifest the states of the files of a directory tree indexed by their slash separated path relative to the directory
+type Manifest map[string]FileState
+
+// Paths returns the sorted paths of the manifest
+func (m Manifest) Paths() []string {
+	var answer []string
+	for p := range m {
+		answer = append(answer, p)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// ScanDir returns the manifest of the regular files of the directory tree which are not ignored
+func ScanDir(dir string, ignorer *Ignorer) (Manifest, error) {
+	answer := Manifest{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// the file was removed while we were scanning
+				return nil
+			}
+			return err
+		}
+		if file == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ignorer != nil && ignorer.Ignored(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		answer[rel] = FileState{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan %s", dir)
+	}
+	return answer, nil
+}
+
+// RemoteManifestCommand returns the command which lists the size, modification time and path of the files of the
+// directory in a container, pruning the directories with the given names. It uses stat rather than the GNU only
+// -printf option of find so that it also works with the busybox find of Alpine based images
+func RemoteManifestCommand(dir string, pruneNames []string) []string {
+	answer := []string{"find", dir}
+	if len(pruneNames) > 0 {
+		answer = append(answer, "(")
+		for i, name := range pruneNames {
+			if i > 0 {
+				answer = append(answer, "-o")
+			}
+			answer = append(answer, "-name", name)
+		}
+		answer = append(answer, ")", "-prune", "-o")
+	}
+	return append(answer, "-type", "f", "-exec", "stat", "-c", "%s %Y %n", "{}", "+")
+}
+
+// ParseRemoteManifest parses the output of the RemoteManifestCommand for the directory into a Manifest
+func ParseRemoteManifest(output string, dir string, ignorer *Ignorer) (Manifest, error) {
+	answer := Manifest{}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid line in the remote manifest: %s", line)
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size in the remote manifest: %s", line)
+		}
+		modTime, err := parseModTime(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid modification time in the remote manifest: %s", line)
+		}
+		if !strings.HasPrefix(fields[2], prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(fields[2], prefix)
+		if ignorer != nil && ignorer.Ignored(rel, false) {
+			continue
+		}
+		answer[rel] = FileState{Size: size, ModTime: modTime}
+	}
+	return answer, nil
+}
+
+// parseModTime parses a modification time in seconds with an optional fraction into nanoseconds since the epoch
+func parseModTime(text string) (int64, error) {
+	parts := strings.SplitN(text, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	nanos := int64(0)
+	if len(parts) == 2 {
+		fraction := parts[1]
+		if len(fraction) > 9 {
+			fraction = fraction[:9]
+		}
+		fraction += strings.Repeat("0", 9-len(fraction))
+		nanos, err = strconv.ParseInt(fraction, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return seconds*int64(time.Second) + nanos, nil
+}
@@ -0,0 +1,526 @@
+package filesync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/buildcache"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// ConflictPolicy how to resolve a file which has changed both locally and in the container since the last sync
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves both versions of the file in place and reports the conflict
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictLocal overwrites the file in the container with the local version
+	ConflictLocal ConflictPolicy = "local"
+	// ConflictRemote overwrites the local file with the version in the container
+	ConflictRemote ConflictPolicy = "remote"
+)
+
+// ConflictPolicies the valid conflict policies
+var ConflictPolicies = []string{string(ConflictSkip), string(ConflictLocal), string(ConflictRemote)}
+
+const (
+	// maxBatchIntervals the maximum number of intervals to wait for the local tree to stop changing before syncing
+	// when polling the local directory
+	maxBatchIntervals = 10
+	// settleDelay how long the local tree has to stop changing before its changes are synchronised
+	settleDelay = 500 * time.Millisecond
+	// maxBatchDelay the maximum time to wait for the local tree to stop changing before synchronising anyway
+	maxBatchDelay = 5 * time.Second
+	// maxPathsPerCommand the maximum number of paths passed to a single remote command
+	maxPathsPerCommand = 100
+	// maxUnconfirmedDeletes the maximum number of files a sync deletes on one side without confirmation
+	maxUnconfirmedDeletes = 10
+	// markerPrefix the prefix of the name of the file in the container which identifies the sync session
+	markerPrefix = ".jx-sync-"
+)
+
+// Result the changes made by a sync
+type Result struct {
+	Pushed        []string
+	Pulled        []string
+	DeletedRemote []string
+	DeletedLocal  []string
+	Conflicts     []string
+}
+
+// Changes returns the number of files changed by the sync
+func (r *Result) Changes() int {
+	return len(r.Pushed) + len(r.Pulled) + len(r.DeletedRemote) + len(r.DeletedLocal)
+}
+
+// syncState the state of a file on both sides after the last sync. A nil state means the file did not exist
+type syncState struct {
+	Local  *FileState
+	Remote *FileState
+}
+
+// ConfirmDeleteFn confirms deleting more than a few files from the local directory, if local is true, or the container
+type ConfirmDeleteFn func(paths []string, local bool) bool
+
+// Syncer synchronises a local directory with a directory in a container in both directions
+type Syncer struct {
+	Dir       string
+	RemoteDir string
+	Executor  Executor
+	Ignorer   *Ignorer
+	Conflict  ConflictPolicy
+	// ConfirmDelete confirms a bulk delete. If it is nil, or declines, the files are restored from the other side instead
+	ConfirmDelete ConfirmDeleteFn
+
+	// baseline the state of the files after the last sync, nil if there has not been a sync
+	baseline    map[string]syncState
+	lastRemote  Manifest
+	marker      string
+	initialised bool
+}
+
+// NewSyncer creates a Syncer for the local and remote directories which ignores the default names and the patterns
+// of the .gitignore and .dockerignore files of the local directory
+func NewSyncer(dir string, remoteDir string, executor Executor, conflict ConflictPolicy) (*Syncer, error) {
+	if conflict == "" {
+		conflict = ConflictSkip
+	}
+	if util.StringArrayIndex(ConflictPolicies, string(conflict)) < 0 {
+		return nil, util.InvalidOption("conflict", string(conflict), ConflictPolicies)
+	}
+	ignorer, err := NewIgnorer(dir, DefaultIgnoredNames)
+	if err != nil {
+		return nil, err
+	}
+	session, err := util.RandStringBytesMaskImprSrc(10)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the sync session ID")
+	}
+	return &Syncer{
+		Dir:       dir,
+		RemoteDir: strings.TrimSuffix(remoteDir, "/"),
+		Executor:  executor,
+		Ignorer:   ignorer,
+		Conflict:  conflict,
+		marker:    markerPrefix + strings.ToLower(session),
+	}, nil
+}
+
+// SyncOnce synchronises the directories once.
+//
+// A file which has only changed on one side since the last sync is copied to, or deleted from, the other side.
+// A file which has changed on both sides is a conflict which is resolved via the ConflictPolicy. Skipped conflicts are
+// only reported once, after that the next change on either side wins.
+// On the first sync there is nothing to compare with so the local directory wins, apart from files which only exist
+// in the container which are copied to the local directory. The same happens if the directory in the container has
+// been replaced since the last sync, such as when the DevPod restarts with an empty workspace, so that the local files
+// are never deleted because they are missing in the container.
+// Deleting more than a few files on either side has to be confirmed via ConfirmDelete
+func (s *Syncer) SyncOnce() (*Result, error) {
+	if !s.initialised {
+		err := s.createRemoteDir()
+		if err != nil {
+			return nil, err
+		}
+		s.initialised = true
+	}
+	local, err := ScanDir(s.Dir, s.Ignorer)
+	if err != nil {
+		return nil, err
+	}
+	remote, marked, err := s.remoteManifest()
+	if err != nil {
+		// the directory may have been removed, such as by a restart of the DevPod, so lets create it again
+		if s.createRemoteDir() != nil {
+			return nil, err
+		}
+		remote, marked, err = s.remoteManifest()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.baseline != nil && !marked {
+		log.Logger().Warnf("The files in %s have been replaced since the last sync, such as by a restart of the DevPod, so synchronising from %s", s.RemoteDir, s.Dir)
+		s.baseline = nil
+	}
+	result := s.plan(local, remote)
+	s.confirmDeletes(result)
+
+	err = s.push(result.Pushed)
+	if err != nil {
+		return result, err
+	}
+	err = s.deleteRemote(result.DeletedRemote)
+	if err != nil {
+		return result, err
+	}
+	err = s.pull(result.Pulled)
+	if err != nil {
+		return result, err
+	}
+	err = s.deleteLocal(result.DeletedLocal)
+	if err != nil {
+		return result, err
+	}
+	if !marked {
+		err = s.Executor.Exec([]string{"touch", path.Join(s.RemoteDir, s.marker)}, nil, nil)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to mark %s as synchronised", s.RemoteDir)
+		}
+	}
+
+	if result.Changes() > 0 {
+		local, err = ScanDir(s.Dir, s.Ignorer)
+		if err != nil {
+			return result, err
+		}
+		remote, _, err = s.remoteManifest()
+		if err != nil {
+			return result, err
+		}
+	}
+	s.updateBaseline(local, remote)
+	return result, nil
+}
+
+// Run synchronises the directories until the stop channel is closed.
+// Local changes are detected by watching the local directory and are batched until the local tree stops changing so
+// that a burst of changes, such as a git checkout, is synchronised in one go. The container is checked for changes
+// every interval. If the local directory cannot be watched it is polled every interval instead
+func (s *Syncer) Run(interval time.Duration, stop <-chan struct{}) {
+	watcher, err := s.watch()
+	if err != nil {
+		log.Logger().Warnf("failed to watch %s for changes so polling it instead: %s", s.Dir, err)
+		s.poll(interval, stop)
+		return
+	}
+	defer watcher.Close()
+
+	s.syncAndLog()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var settled <-chan time.Time
+	var batchStart time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !s.localChange(watcher, event) {
+				continue
+			}
+			if settled == nil {
+				batchStart = time.Now()
+			}
+			if time.Since(batchStart) < maxBatchDelay {
+				settled = time.After(settleDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Logger().Warnf("failed to watch %s: %s", s.Dir, err)
+		case <-settled:
+			settled = nil
+			s.syncAndLog()
+		case <-ticker.C:
+			if settled != nil {
+				// the local changes are about to be synchronised anyway
+				continue
+			}
+			changed, err := s.remoteChanged()
+			if err != nil {
+				log.Logger().Warnf("failed to check %s for changes: %s", s.RemoteDir, err)
+				continue
+			}
+			if changed {
+				s.syncAndLog()
+			}
+		}
+	}
+}
+
+// poll synchronises the directories every interval until the stop channel is closed, batching local changes until
+// the local tree stops changing
+func (s *Syncer) poll(interval time.Duration, stop <-chan struct{}) {
+	var previous Manifest
+	waited := 0
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		local, err := ScanDir(s.Dir, s.Ignorer)
+		if err == nil && previous != nil && !reflect.DeepEqual(local, previous) && waited < maxBatchIntervals {
+			previous = local
+			waited++
+		} else {
+			previous = local
+			waited = 0
+			s.syncAndLog()
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *Syncer) syncAndLog() {
+	result, err := s.SyncOnce()
+	if err != nil {
+		log.Logger().Warnf("failed to sync %s: %s", s.Dir, err)
+		return
+	}
+	s.logResult(result)
+}
+
+// remoteChanged returns true if the files in the container have changed since the last sync
+func (s *Syncer) remoteChanged() (bool, error) {
+	if s.baseline == nil {
+		return true, nil
+	}
+	remote, marked, err := s.remoteManifest()
+	if err != nil {
+		return false, err
+	}
+	return !marked || !reflect.DeepEqual(remote, s.lastRemote), nil
+}
+
+// confirmDeletes restores the files from the other side instead of deleting more than a few files without confirmation
+func (s *Syncer) confirmDeletes(result *Result) {
+	if len(result.DeletedLocal) > maxUnconfirmedDeletes && (s.ConfirmDelete == nil || !s.ConfirmDelete(result.DeletedLocal, true)) {
+		log.Logger().Warnf("Not deleting %d files from %s which were deleted in the DevPod, copying them to the DevPod instead", len(result.DeletedLocal), s.Dir)
+		result.Pushed = append(result.Pushed, result.DeletedLocal...)
+		result.DeletedLocal = nil
+	}
+	if len(result.DeletedRemote) > maxUnconfirmedDeletes && (s.ConfirmDelete == nil || !s.ConfirmDelete(result.DeletedRemote, false)) {
+		log.Logger().Warnf("Not deleting %d files from %s which were deleted locally, copying them from the DevPod instead", len(result.DeletedRemote), s.RemoteDir)
+		result.Pulled = append(result.Pulled, result.DeletedRemote...)
+		result.DeletedRemote = nil
+	}
+}
+
+func (s *Syncer) plan(local Manifest, remote Manifest) *Result {
+	result := &Result{}
+	paths := map[string]bool{}
+	for p := range local {
+		paths[p] = true
+	}
+	for p := range remote {
+		paths[p] = true
+	}
+	var sorted []string
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	for _, p := range sorted {
+		l := stateOf(local, p)
+		r := stateOf(remote, p)
+		base, ok := s.baseline[p]
+		if !ok {
+			if l == nil {
+				result.Pulled = append(result.Pulled, p)
+				continue
+			}
+			// with no previous sync the local file wins unless it is the same as the remote one
+			base = syncState{Remote: r}
+			if r != nil && l.Equivalent(*r) {
+				continue
+			}
+		}
+		localChanged := !sameState(base.Local, l)
+		remoteChanged := !sameState(base.Remote, r)
+		switch {
+		case localChanged && remoteChanged:
+			if l == nil && r == nil || l != nil && r != nil && l.Equivalent(*r) {
+				continue
+			}
+			result.Conflicts = append(result.Conflicts, p)
+			switch s.Conflict {
+			case ConflictLocal:
+				result.addLocalChange(p, l)
+			case ConflictRemote:
+				result.addRemoteChange(p, r)
+			}
+		case localChanged:
+			result.addLocalChange(p, l)
+		case remoteChanged:
+			result.addRemoteChange(p, r)
+		}
+	}
+	return result
+}
+
+func (r *Result) addLocalChange(p string, l *FileState) {
+	if l == nil {
+		r.DeletedRemote = append(r.DeletedRemote, p)
+	} else {
+		r.Pushed = append(r.Pushed, p)
+	}
+}
+
+func (r *Result) addRemoteChange(p string, remote *FileState) {
+	if remote == nil {
+		r.DeletedLocal = append(r.DeletedLocal, p)
+	} else {
+		r.Pulled = append(r.Pulled, p)
+	}
+}
+
+func (s *Syncer) updateBaseline(local Manifest, remote Manifest) {
+	s.lastRemote = remote
+	s.baseline = map[string]syncState{}
+	for p := range local {
+		s.baseline[p] = syncState{Local: stateOf(local, p), Remote: stateOf(remote, p)}
+	}
+	for p := range remote {
+		s.baseline[p] = syncState{Local: stateOf(local, p), Remote: stateOf(remote, p)}
+	}
+}
+
+func (s *Syncer) createRemoteDir() error {
+	err := s.Executor.Exec([]string{"mkdir", "-p", s.RemoteDir}, nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the directory %s", s.RemoteDir)
+	}
+	return nil
+}
+
+// remoteManifest returns the manifest of the directory in the container and whether it contains the marker of this
+// sync session, which shows the directory has not been replaced since the last sync
+func (s *Syncer) remoteManifest() (Manifest, bool, error) {
+	var out bytes.Buffer
+	err := s.Executor.Exec(RemoteManifestCommand(s.RemoteDir, s.Ignorer.Names()), nil, &out)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to list the files of %s", s.RemoteDir)
+	}
+	manifest, err := ParseRemoteManifest(out.String(), s.RemoteDir, s.Ignorer)
+	if err != nil {
+		return nil, false, err
+	}
+	_, marked := manifest[s.marker]
+	for p := range manifest {
+		if strings.HasPrefix(p, markerPrefix) {
+			delete(manifest, p)
+		}
+	}
+	return manifest, marked, nil
+}
+
+func (s *Syncer) push(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	var files []string
+	for _, p := range paths {
+		files = append(files, filepath.Join(s.Dir, filepath.FromSlash(p)))
+	}
+	var buffer bytes.Buffer
+	_, err := buildcache.Archive(&buffer, s.Dir, files)
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive the changes of %s", s.Dir)
+	}
+	err = s.Executor.Exec([]string{"tar", "xzf", "-", "-C", s.RemoteDir}, &buffer, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy the changes to %s", s.RemoteDir)
+	}
+	return nil
+}
+
+func (s *Syncer) pull(paths []string) error {
+	for _, chunk := range chunkPaths(paths) {
+		var buffer bytes.Buffer
+		command := append([]string{"tar", "czf", "-", "-C", s.RemoteDir, "--"}, chunk...)
+		err := s.Executor.Exec(command, nil, &buffer)
+		if err != nil {
+			return errors.Wrapf(err, "failed to archive the changes of %s", s.RemoteDir)
+		}
+		_, err = buildcache.Extract(&buffer, s.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy the changes to %s", s.Dir)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) deleteRemote(paths []string) error {
+	for _, chunk := range chunkPaths(paths) {
+		command := []string{"rm", "-f", "--"}
+		for _, p := range chunk {
+			command = append(command, path.Join(s.RemoteDir, p))
+		}
+		err := s.Executor.Exec(command, nil, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete files from %s", s.RemoteDir)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) deleteLocal(paths []string) error {
+	for _, p := range paths {
+		err := os.Remove(filepath.Join(s.Dir, filepath.FromSlash(p)))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to delete %s", p)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) logResult(result *Result) {
+	if result.Changes() > 0 {
+		log.Logger().Infof("Synced %s: %d pushed, %d pulled, %d deleted remotely, %d deleted locally", util.ColorInfo(s.Dir),
+			len(result.Pushed), len(result.Pulled), len(result.DeletedRemote), len(result.DeletedLocal))
+	}
+	if len(result.Conflicts) > 0 {
+		action := fmt.Sprintf("keeping the %s version", s.Conflict)
+		if s.Conflict == ConflictSkip {
+			action = "leaving both versions in place"
+		}
+		log.Logger().Warnf("Files changed both locally and in the DevPod, %s: %s", action, strings.Join(result.Conflicts, ", "))
+	}
+}
+
+func stateOf(m Manifest, p string) *FileState {
+	state, ok := m[p]
+	if !ok {
+		return nil
+	}
+	return &state
+}
+
+func sameState(a *FileState, b *FileState) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func chunkPaths(paths []string) [][]string {
+	var answer [][]string
+	for len(paths) > maxPathsPerCommand {
+		answer = append(answer, paths[:maxPathsPerCommand])
+		paths = paths[maxPathsPerCommand:]
+	}
+	if len(paths) > 0 {
+		answer = append(answer, paths)
+	}
+	return answer
+}
@@ -0,0 +1,324 @@
+// +build unit
+
+package filesync_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/filesync"
+	"github.com/jenkins-x/jx/v2/pkg/filesync/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const remoteDir = "/workspace/app"
+
+type syncTest struct {
+	t        *testing.T
+	local    string
+	executor *fake.Executor
+	syncer   *filesync.Syncer
+	clock    time.Time
+}
+
+func newSyncTest(t *testing.T, conflict filesync.ConflictPolicy, localFiles map[string]string) *syncTest {
+	local, err := ioutil.TempDir("", "filesync-local-")
+	require.NoError(t, err)
+	root, err := ioutil.TempDir("", "filesync-remote-")
+	require.NoError(t, err)
+	test := &syncTest{
+		t:        t,
+		local:    local,
+		executor: fake.NewExecutor(root),
+		clock:    time.Now().Add(-time.Hour).Truncate(time.Second),
+	}
+	for name, content := range localFiles {
+		test.write(local, name, content)
+	}
+	test.syncer, err = filesync.NewSyncer(local, remoteDir, test.executor, conflict)
+	require.NoError(t, err)
+	return test
+}
+
+func (s *syncTest) cleanup() {
+	os.RemoveAll(s.local)
+	os.RemoveAll(s.executor.Root)
+}
+
+func (s *syncTest) remote() string {
+	return filepath.Join(s.executor.Root, filepath.FromSlash(remoteDir))
+}
+
+// write writes the file with a modification time a second later than the last one written, as the files on either
+// side of the sync are only compared by their modification times in seconds
+func (s *syncTest) write(dir string, name string, content string) {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(s.t, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+	require.NoError(s.t, ioutil.WriteFile(file, []byte(content), 0644))
+	s.clock = s.clock.Add(time.Second)
+	require.NoError(s.t, os.Chtimes(file, s.clock, s.clock))
+}
+
+func (s *syncTest) sync() *filesync.Result {
+	result, err := s.syncer.SyncOnce()
+	require.NoError(s.t, err)
+	return result
+}
+
+func (s *syncTest) assertFile(dir string, name string, expected string) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(s.t, err, "reading %s", name)
+	assert.Equal(s.t, expected, string(data), "content of %s", name)
+}
+
+func (s *syncTest) assertNoFile(dir string, name string) {
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+	assert.True(s.t, os.IsNotExist(err), "%s should not exist", name)
+}
+
+func TestSyncPushesAndPullsChanges(t *testing.T) {
+	t.Parallel()
+
+	test := newSyncTest(t, filesync.ConflictSkip, map[string]string{
+		"main.go":     "package main",
+		"pkg/util.go": "package pkg",
+	})
+	defer test.cleanup()
+
+	result := test.sync()
+	assert.Equal(t, []string{"main.go", "pkg/util.go"}, result.Pushed)
+	test.assertFile(test.remote(), "pkg/util.go", "package pkg")
+
+	result = test.sync()
+	assert.Equal(t, 0, result.Changes(), "nothing should change without any edits")
+
+	test.write(test.local, "main.go", "package main\n\nfunc main() {}")
+	test.write(test.remote(), "generated.go", "package main // generated")
+	result = test.sync()
+	assert.Equal(t, []string{"main.go"}, result.Pushed)
+	assert.Equal(t, []string{"generated.go"}, result.Pulled)
+	test.assertFile(test.remote(), "main.go", "package main\n\nfunc main() {}")
+	test.assertFile(test.local, "generated.go", "package main // generated")
+
+	require.NoError(t, os.Remove(filepath.Join(test.local, "pkg", "util.go")))
+	require.NoError(t, os.Remove(filepath.Join(test.remote(), "generated.go")))
+	result = test.sync()
+	assert.Equal(t, []string{"pkg/util.go"}, result.DeletedRemote)
+	assert.Equal(t, []string{"generated.go"}, result.DeletedLocal)
+	test.assertNoFile(test.remote(), "pkg/util.go")
+	test.assertNoFile(test.local, "generated.go")
+	assert.Empty(t, result.Conflicts)
+}
+
+func TestSyncHonoursIgnoreFiles(t *testing.T) {
+	t.Parallel()
+
+	test := newSyncTest(t, filesync.ConflictSkip, map[string]string{
+		".gitignore":         "*.log\n/dist/\n",
+		".dockerignore":      "secrets.txt\n",
+		".stignore":          "*.tmp\n",
+		"main.go":            "package main",
+		"cache.tmp":          "cache",
+		"app.log":            "log",
+		"dist/app":           "binary",
+		"secrets.txt":        "secret",
+		"node_modules/a.js":  "module",
+		"pkg/nested/app.log": "log",
+	})
+	defer test.cleanup()
+
+	result := test.sync()
+	assert.Equal(t, []string{".dockerignore", ".gitignore", ".stignore", "main.go"}, result.Pushed)
+	test.assertNoFile(test.remote(), "app.log")
+	test.assertNoFile(test.remote(), "cache.tmp")
+	test.assertNoFile(test.remote(), "dist/app")
+	test.assertNoFile(test.remote(), "secrets.txt")
+	test.assertNoFile(test.remote(), "node_modules/a.js")
+	test.assertNoFile(test.remote(), "pkg/nested/app.log")
+
+	test.write(test.remote(), "node_modules/b.js", "module")
+	test.write(test.remote(), "build.log", "log")
+	result = test.sync()
+	assert.Equal(t, 0, result.Changes(), "ignored files in the container should not be pulled")
+}
+
+func TestRemoteManifestCommand(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "filesync-manifest-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"main.go":               "package main",
+		"docs/my notes.md":      "notes",
+		"node_modules/a.js":     "module",
+		"pkg/node_modules/b.js": "module",
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "main.go"), modTime, modTime))
+
+	args := filesync.RemoteManifestCommand(dir, []string{"node_modules"})
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	require.NoError(t, err)
+	manifest, err := filesync.ParseRemoteManifest(string(out), dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs/my notes.md", "main.go"}, manifest.Paths())
+	assert.Equal(t, filesync.FileState{Size: 12, ModTime: modTime.UnixNano()}, manifest["main.go"])
+}
+
+func TestSyncConflicts(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		policy         filesync.ConflictPolicy
+		expectedLocal  string
+		expectedRemote string
+	}{
+		{policy: filesync.ConflictSkip, expectedLocal: "local", expectedRemote: "remote"},
+		{policy: filesync.ConflictLocal, expectedLocal: "local", expectedRemote: "local"},
+		{policy: filesync.ConflictRemote, expectedLocal: "remote", expectedRemote: "remote"},
+	} {
+		test := newSyncTest(t, tc.policy, map[string]string{"README.md": "original"})
+		test.sync()
+
+		test.write(test.local, "README.md", "local")
+		test.write(test.remote(), "README.md", "remote")
+		result := test.sync()
+		assert.Equal(t, []string{"README.md"}, result.Conflicts, "conflicts for policy %s", tc.policy)
+		test.assertFile(test.local, "README.md", tc.expectedLocal)
+		test.assertFile(test.remote(), "README.md", tc.expectedRemote)
+
+		result = test.sync()
+		assert.Empty(t, result.Conflicts, "a conflict should only be reported once for policy %s", tc.policy)
+		assert.Equal(t, 0, result.Changes())
+		test.cleanup()
+	}
+}
+
+func TestNewSyncerInvalidConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := filesync.NewSyncer(".", remoteDir, fake.NewExecutor("."), "newest")
+	assert.Error(t, err)
+}
+
+func TestSyncDetectsEditsWithinTheSameSecond(t *testing.T) {
+	t.Parallel()
+
+	test := newSyncTest(t, filesync.ConflictSkip, map[string]string{"main.go": "aaaa"})
+	defer test.cleanup()
+	test.sync()
+
+	file := filepath.Join(test.local, "main.go")
+	require.NoError(t, ioutil.WriteFile(file, []byte("bbbb"), 0644))
+	modTime := test.clock.Add(time.Millisecond)
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+
+	result := test.sync()
+	assert.Equal(t, []string{"main.go"}, result.Pushed)
+	test.assertFile(test.remote(), "main.go", "bbbb")
+}
+
+func TestSyncKeepsLocalFilesWhenTheRemoteDirectoryIsReplaced(t *testing.T) {
+	t.Parallel()
+
+	test := newSyncTest(t, filesync.ConflictSkip, map[string]string{
+		"main.go":     "package main",
+		"pkg/util.go": "package pkg",
+	})
+	defer test.cleanup()
+	test.sync()
+
+	// the DevPod restarts with an empty workspace
+	require.NoError(t, os.RemoveAll(test.executor.Root))
+	result := test.sync()
+	assert.Empty(t, result.DeletedLocal)
+	assert.Equal(t, []string{"main.go", "pkg/util.go"}, result.Pushed)
+	test.assertFile(test.local, "main.go", "package main")
+	test.assertFile(test.remote(), "pkg/util.go", "package pkg")
+
+	// the DevPod is recreated from a workspace which has different files
+	require.NoError(t, os.RemoveAll(test.executor.Root))
+	test.write(test.remote(), "main.go", "package old")
+	result = test.sync()
+	assert.Empty(t, result.DeletedLocal)
+	assert.Equal(t, []string{"main.go", "pkg/util.go"}, result.Pushed)
+	test.assertFile(test.local, "main.go", "package main")
+	test.assertFile(test.remote(), "main.go", "package main")
+}
+
+func TestSyncConfirmsBulkDeletes(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{}
+	for i := 0; i < 12; i++ {
+		files[fmt.Sprintf("file%02d.txt", i)] = "content"
+	}
+	test := newSyncTest(t, filesync.ConflictSkip, files)
+	defer test.cleanup()
+	test.sync()
+
+	for name := range files {
+		require.NoError(t, os.Remove(filepath.Join(test.remote(), name)))
+	}
+	result := test.sync()
+	assert.Empty(t, result.DeletedLocal, "a bulk delete should not be propagated without confirmation")
+	assert.Len(t, result.Pushed, 12)
+	test.assertFile(test.local, "file00.txt", "content")
+	test.assertFile(test.remote(), "file00.txt", "content")
+
+	var confirmed []string
+	test.syncer.ConfirmDelete = func(paths []string, local bool) bool {
+		assert.True(t, local)
+		confirmed = paths
+		return true
+	}
+	for name := range files {
+		require.NoError(t, os.Remove(filepath.Join(test.remote(), name)))
+	}
+	result = test.sync()
+	assert.Len(t, result.DeletedLocal, 12)
+	assert.Equal(t, result.DeletedLocal, confirmed)
+	test.assertNoFile(test.local, "file00.txt")
+}
+
+func TestRunSyncsLocalChanges(t *testing.T) {
+	t.Parallel()
+
+	test := newSyncTest(t, filesync.ConflictSkip, map[string]string{"main.go": "package main"})
+	defer test.cleanup()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		test.syncer.Run(time.Second, stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	waitForFile := func(name string, expected string) {
+		file := filepath.Join(test.remote(), filepath.FromSlash(name))
+		assert.Eventually(t, func() bool {
+			data, err := ioutil.ReadFile(file)
+			return err == nil && string(data) == expected
+		}, 10*time.Second, 50*time.Millisecond, "%s should be synchronised", name)
+	}
+	waitForFile("main.go", "package main")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(test.local, "pkg"), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(test.local, "pkg", "util.go"), []byte("package pkg"), 0644))
+	waitForFile("pkg/util.go", "package pkg")
+}
@@ -0,0 +1,73 @@
+package filesync
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// watch creates a watcher of the directories of the local tree which are not ignored
+func (s *Syncer) watch() (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the file watcher")
+	}
+	err = s.addWatches(watcher, s.Dir)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// addWatches watches the directory and its sub directories which are not ignored
+func (s *Syncer) addWatches(watcher *fsnotify.Watcher, dir string) error {
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if file != s.Dir {
+			rel, err := filepath.Rel(s.Dir, file)
+			if err != nil {
+				return err
+			}
+			if s.Ignorer.Ignored(filepath.ToSlash(rel), true) {
+				return filepath.SkipDir
+			}
+		}
+		return watcher.Add(file)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to watch %s", dir)
+	}
+	return nil
+}
+
+// localChange returns true if the event changed a file which is synchronised, watching any new directory
+func (s *Syncer) localChange(watcher *fsnotify.Watcher, event fsnotify.Event) bool {
+	rel, err := filepath.Rel(s.Dir, event.Name)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(event.Name)
+	isDir := err == nil && info.IsDir()
+	if s.Ignorer.Ignored(filepath.ToSlash(rel), isDir) {
+		return false
+	}
+	if isDir && event.Op&fsnotify.Create != 0 {
+		err = s.addWatches(watcher, event.Name)
+		if err != nil {
+			log.Logger().Warnf("%s", err)
+		}
+	}
+	return true
+}
@@ -663,6 +663,11 @@ func (b *BitbucketServerProvider) moveToNextPage(paginationOptions map[string]in
 }
 
 func convertBitBucketCommitToGitCommit(bCommit *bitbucket.Commit, repo *GitRepository) *GitCommit {
+	var committedAt *time.Time
+	if bCommit.CommitterTimestamp > 0 {
+		t := time.Unix(0, bCommit.CommitterTimestamp*int64(time.Millisecond))
+		committedAt = &t
+	}
 	return &GitCommit{
 		SHA:     bCommit.ID,
 		Message: bCommit.Message,
@@ -677,6 +682,7 @@ func convertBitBucketCommitToGitCommit(bCommit *bitbucket.Commit, repo *GitRepos
 			Name:  bCommit.Committer.DisplayName,
 			Email: bCommit.Committer.EmailAddress,
 		},
+		CommittedAt: committedAt,
 	}
 }
 
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	bitbucket "github.com/gfleury/go-bitbucket-v1"
 	"github.com/jenkins-x/jx/v2/pkg/auth"
@@ -290,6 +291,8 @@ func (suite *BitbucketServerProviderTestSuite) TestPullRequestCommits() {
 	suite.Require().NotEmpty(commits)
 	suite.Require().Equal(len(commits), 2)
 	suite.Require().Equal("Test User", commits[0].Author.Name)
+	suite.Require().NotNil(commits[0].CommittedAt)
+	suite.Require().True(time.Unix(1528202969, 0).Equal(*commits[0].CommittedAt))
 }
 
 func (suite *BitbucketServerProviderTestSuite) TestPullRequestLastCommitStatus() {
@@ -525,6 +525,11 @@ func (g *GitCLI) CommitIfChanges(dir string, message string) error {
 func (g *GitCLI) GetCommits(dir string, startSha string, endSha string) ([]GitCommit, error) {
 	return g.getCommits(dir, fmt.Sprintf("%s..%s", startSha, endSha))
 }
+
+// GetCommitsInPath returns the commits in a range which change files in the path, exclusive of startSha and inclusive of endSha
+func (g *GitCLI) GetCommitsInPath(dir string, startSha string, endSha string, path string) ([]GitCommit, error) {
+	return g.getCommits(dir, fmt.Sprintf("%s..%s", startSha, endSha), "--", path)
+}
 func (g *GitCLI) getCommits(dir string, args ...string) ([]GitCommit, error) {
 	// use a custom format to get commits, using %x1e to separate commits and %x1f to separate fields
 	args = append([]string{"log", "--format=%H%x1f%an%x1f%ae%x1f%cn%x1f%ce%x1f%s%n%b%x1e"}, args...)
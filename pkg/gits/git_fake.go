@@ -626,6 +626,11 @@ func (g *GitFake) GetCommits(dir string, startSha string, endSha string) ([]GitC
 	return nil, nil
 }
 
+// GetCommitsInPath returns the commits in a range which change files in the path, exclusive of startSha and inclusive of endSha
+func (g *GitFake) GetCommitsInPath(dir string, startSha string, endSha string, path string) ([]GitCommit, error) {
+	return nil, nil
+}
+
 // RevParse runs git rev-parse on rev
 func (g *GitFake) RevParse(dir string, rev string) (string, error) {
 	return "", nil
@@ -488,6 +488,11 @@ func (g *GitLocal) GetCommits(dir string, startSha string, endSha string) ([]Git
 	return g.GitCLI.GetCommits(dir, startSha, endSha)
 }
 
+// GetCommitsInPath returns the commits in a range which change files in the path, exclusive of startSha and inclusive of endSha
+func (g *GitLocal) GetCommitsInPath(dir string, startSha string, endSha string, path string) ([]GitCommit, error) {
+	return g.GitCLI.GetCommitsInPath(dir, startSha, endSha, path)
+}
+
 // RevParse runs git rev parse
 func (g *GitLocal) RevParse(dir string, rev string) (string, error) {
 	return g.GitCLI.RevParse(dir, rev)
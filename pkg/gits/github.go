@@ -282,7 +282,7 @@ func toGitHubRelease(org string, name string, release *github.RepositoryRelease)
 		}
 		assets = append(assets, toGitHubAsset(asset))
 	}
-	return &GitRelease{
+	answer := &GitRelease{
 		Name:          asText(release.Name),
 		TagName:       asText(release.TagName),
 		Body:          asText(release.Body),
@@ -291,6 +291,10 @@ func toGitHubRelease(org string, name string, release *github.RepositoryRelease)
 		DownloadCount: totalDownloadCount,
 		Assets:        &assets,
 	}
+	if release.PublishedAt != nil {
+		answer.PublishedAt = &release.PublishedAt.Time
+	}
+	return answer
 }
 
 func toGitHubAsset(asset *github.ReleaseAsset) GitReleaseAsset {
@@ -1458,11 +1462,16 @@ func (p *GitHubProvider) ListCommits(owner, repo string, opt *ListCommitsArgumen
 	for _, commit := range githubCommits {
 		if commit.Commit != nil {
 			author := extractRepositoryCommitAuthor(commit)
+			var committedAt *time.Time
+			if commit.Commit.Committer != nil {
+				committedAt = commit.Commit.Committer.Date
+			}
 			commits = append(commits, &GitCommit{
-				SHA:     asText(commit.SHA),
-				Message: asText(commit.Commit.Message),
-				URL:     asText(commit.Commit.URL),
-				Author:  author,
+				SHA:         asText(commit.SHA),
+				Message:     asText(commit.Commit.Message),
+				URL:         asText(commit.Commit.URL),
+				Author:      author,
+				CommittedAt: committedAt,
 			})
 		}
 	}
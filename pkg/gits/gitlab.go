@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -823,13 +824,77 @@ func (g *GitlabProvider) UserInfo(username string) *GitUser {
 	}
 }
 
+// UpdateRelease creates or updates the release of the tag
 func (g *GitlabProvider) UpdateRelease(owner string, repo string, tag string, releaseInfo *GitRelease) error {
-	return nil
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	name := releaseInfo.Name
+	if name == "" {
+		name = tag
+	}
+	if releaseInfo.PreRelease {
+		name = gitlabPreReleaseName(name, true)
+	}
+	release, resp, err := g.Client.Releases.GetRelease(pid, tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		log.Logger().Warnf("No release found for %s/%s and tag %s so creating a new release", owner, repo, tag)
+		_, _, err = g.Client.Releases.CreateRelease(pid, &gitlab.CreateReleaseOptions{
+			Name:        &name,
+			TagName:     &tag,
+			Description: &releaseInfo.Body,
+		})
+		return err
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "failed to get the release for %s/%s and tag %s", owner, repo, tag)
+	}
+	description := release.Description
+	if description == "" {
+		description = releaseInfo.Body
+	}
+	_, _, err = g.Client.Releases.UpdateRelease(pid, tag, &gitlab.UpdateReleaseOptions{
+		Name:        &name,
+		Description: &description,
+	})
+	return err
 }
 
-// UpdateReleaseStatus is not supported for this git provider
+// UpdateReleaseStatus updates the state (release/prerelease) of a release.
+// As GitLab releases have no pre-release flag the name of a pre-release is suffixed with (pre-release)
 func (g *GitlabProvider) UpdateReleaseStatus(owner string, repo string, tag string, releaseInfo *GitRelease) error {
-	return nil
+	pid, err := g.projectId(owner, g.Username, repo)
+	if err != nil {
+		return err
+	}
+	release, resp, err := g.Client.Releases.GetRelease(pid, tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		log.Logger().Warnf("No release found for %s/%s and tag %s", owner, repo, tag)
+		return nil
+	}
+	if err != nil {
+		return errors2.Wrapf(err, "failed to get the release for %s/%s and tag %s", owner, repo, tag)
+	}
+	name := gitlabPreReleaseName(release.Name, releaseInfo.PreRelease)
+	if name == release.Name {
+		return nil
+	}
+	_, _, err = g.Client.Releases.UpdateRelease(pid, tag, &gitlab.UpdateReleaseOptions{
+		Name:        &name,
+		Description: &release.Description,
+	})
+	return err
+}
+
+const gitlabPreReleaseSuffix = " (pre-release)"
+
+func gitlabPreReleaseName(name string, preRelease bool) string {
+	name = strings.TrimSuffix(name, gitlabPreReleaseSuffix)
+	if preRelease {
+		return name + gitlabPreReleaseSuffix
+	}
+	return name
 }
 
 // IssueURL returns the URL of the issue
@@ -236,6 +236,12 @@ func (suite *GitlabProviderSuite) TestUpdatePullRequest() {
 	suite.Require().Equal(pr.Owner, gitlabUserName)
 }
 
+func (suite *GitlabProviderSuite) TestUpdateReleaseStatusWithoutRelease() {
+	err := suite.provider.UpdateReleaseStatus(gitlabUserName, gitlabProjectName, "v9.9.9", &gits.GitRelease{PreRelease: true})
+
+	suite.Require().Nil(err, "a tag without a release has no status to update")
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestGitlabProviderSuite(t *testing.T) {
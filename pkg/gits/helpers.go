@@ -752,7 +752,7 @@ type PullRequestFilter struct {
 
 func (f *PullRequestFilter) String() string {
 	if f.Number != nil {
-		return fmt.Sprintf("Pull Request #%d", f.Number)
+		return fmt.Sprintf("Pull Request #%d", *f.Number)
 	}
 	return strings.Join(f.Labels, ", ")
 }
@@ -782,7 +782,7 @@ func FilterOpenPullRequests(provider GitProvider, owner string, repo string, fil
 				answer = append(answer, pr)
 			}
 		}
-		if filter.Number != nil && filter.Number == pr.Number {
+		if filter.Number != nil && pr.Number != nil && *filter.Number == *pr.Number {
 			answer = append(answer, pr)
 		}
 	}
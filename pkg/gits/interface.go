@@ -283,6 +283,7 @@ type Gitter interface {
 	GetLatestCommitSha(dir string) (string, error)
 	GetFirstCommitSha(dir string) (string, error)
 	GetCommits(dir string, start string, end string) ([]GitCommit, error)
+	GetCommitsInPath(dir string, start string, end string, path string) ([]GitCommit, error)
 	RevParse(dir string, rev string) (string, error)
 	GetCommitsNotOnAnyRemote(dir string, branch string) ([]GitCommit, error)
 	Describe(dir string, contains bool, commitish string, abbrev string, fallback bool) (string, string, error)
@@ -0,0 +1,371 @@
+package operations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// BatchLabelPrefix the prefix of the label which identifies the pull request of a batch group
+	BatchLabelPrefix = "batch/"
+
+	batchUpdateMarker = "<!-- batch-update: %s -->"
+)
+
+var batchUpdatePattern = regexp.MustCompile(`^<!-- batch-update: (.+) -->$`)
+
+// BatchConfig configures how dependency updates are grouped into batch pull requests so that a repository
+// gets a single pull request for a group of updates rather than one per dependency
+type BatchConfig struct {
+	// Groups the groups of updates. The first group which matches an update is used
+	Groups []BatchGroup `json:"groups,omitempty"`
+}
+
+// BatchGroup a group of dependency updates which are made on a single branch and pull request per repository
+type BatchGroup struct {
+	// Name the name of the group which is used for the branch and label of the pull request
+	Name string `json:"name"`
+	// Dependencies the patterns of the source repositories of the updates, e.g. jenkins-x/* or github.com/jenkins-x/jx.
+	// If there are no patterns all the dependencies are included
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Repositories the patterns of the repositories to update, e.g. jenkins-x/*.
+	// If there are no patterns all the repositories are included
+	Repositories []string `json:"repositories,omitempty"`
+	// Labels the additional labels of the pull request
+	Labels []string `json:"labels,omitempty"`
+}
+
+// BatchUpdate a dependency update of a batch pull request
+type BatchUpdate struct {
+	// Key identifies the dependency so that later updates of the same dependency replace earlier ones
+	Key string
+	// Message the markdown describing the update
+	Message string
+}
+
+// LoadBatchConfig loads the batch configuration file
+func LoadBatchConfig(fileName string) (*BatchConfig, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	config := &BatchConfig{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	for i, g := range config.Groups {
+		if g.Name == "" {
+			return nil, errors.Errorf("group %d in %s has no name", i, fileName)
+		}
+	}
+	return config, nil
+}
+
+// FindGroup returns the group of the update of the repository from the source repository or nil if the update
+// is not batched
+func (c *BatchConfig) FindGroup(srcGitURL string, gitURL string) *BatchGroup {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Groups {
+		g := &c.Groups[i]
+		if matchesRepository(g.Dependencies, srcGitURL) && matchesRepository(g.Repositories, gitURL) {
+			return g
+		}
+	}
+	return nil
+}
+
+// Label returns the label of the pull requests of the group
+func (g *BatchGroup) Label() string {
+	return BatchLabelPrefix + g.Name
+}
+
+// BranchName returns the name of the branch of the pull requests of the group
+func (g *BatchGroup) BranchName() string {
+	return fmt.Sprintf("batch-%s-dependency-updates", g.Name)
+}
+
+// Title returns the title of a batch pull request
+func (g *BatchGroup) Title(updates []BatchUpdate, singleTitle string) string {
+	if len(updates) == 1 && singleTitle != "" {
+		return singleTitle
+	}
+	return fmt.Sprintf("chore(deps): bump %d dependencies (%s)", len(updates), g.Name)
+}
+
+// matchesRepository returns true if there are no patterns or the repository matches one of them
+// using either owner/repo or host/owner/repo
+func matchesRepository(patterns []string, gitURL string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if gitURL == "" {
+		return false
+	}
+	names := []string{gitURL}
+	gitInfo, err := gits.ParseGitURL(gitURL)
+	if err == nil {
+		names = append(names, gitInfo.Organisation+"/"+gitInfo.Name, gitInfo.Host+"/"+gitInfo.Organisation+"/"+gitInfo.Name)
+	}
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseBatchUpdates parses the updates in the body of a batch pull request
+func ParseBatchUpdates(body string) []BatchUpdate {
+	var answer []BatchUpdate
+	var current *BatchUpdate
+	for _, line := range strings.Split(body, "\n") {
+		found := batchUpdatePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if found != nil {
+			answer = append(answer, BatchUpdate{Key: found[1]})
+			current = &answer[len(answer)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if current.Message != "" {
+			current.Message += "\n"
+		}
+		current.Message += line
+	}
+	for i := range answer {
+		answer[i].Message = strings.TrimPrefix(strings.TrimSpace(answer[i].Message), "* ")
+	}
+	return answer
+}
+
+// MergeBatchUpdate adds the update to the updates replacing any update of the same dependency
+func MergeBatchUpdate(updates []BatchUpdate, update BatchUpdate) []BatchUpdate {
+	answer := make([]BatchUpdate, 0, len(updates)+1)
+	for _, u := range updates {
+		if u.Key != update.Key {
+			answer = append(answer, u)
+		}
+	}
+	answer = append(answer, update)
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Key < answer[j].Key
+	})
+	return answer
+}
+
+// BatchPullRequestBody generates the body of a batch pull request
+func BatchPullRequestBody(g *BatchGroup, updates []BatchUpdate) string {
+	var buffer strings.Builder
+	buffer.WriteString(fmt.Sprintf("Update %d dependencies of the batch %s\n", len(updates), g.Name))
+	for _, u := range updates {
+		buffer.WriteString("\n")
+		buffer.WriteString(fmt.Sprintf(batchUpdateMarker, u.Key))
+		buffer.WriteString("\n* ")
+		buffer.WriteString(u.Message)
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
+// batchUpdateKey returns the key of the dependency of the update
+func batchUpdateKey(kind string, update *v1.DependencyUpdate) string {
+	if update == nil {
+		return kind
+	}
+	key := fmt.Sprintf("%s/%s/%s", update.Host, update.Owner, update.Repo)
+	if update.Component != "" {
+		key += ":" + update.Component
+	}
+	return key
+}
+
+// createBatchPullRequest makes the update on the branch of the batch group and creates the pull request of the group
+// or updates the open one
+func (o *PullRequestOperation) createBatchPullRequest(gitURL string, kind string, group *BatchGroup, update ChangeFilesFn) (*gits.PullRequestInfo, error) {
+	dir, err := ioutil.TempDir("", "create-pr")
+	if err != nil {
+		return nil, err
+	}
+	tempDir := dir
+	defer func() {
+		// the clone is kept on a dry run so that the commit can be inspected
+		if !o.DryRun {
+			os.RemoveAll(tempDir)
+		}
+	}()
+	provider, _, err := o.CreateGitProviderForURLWithoutKind(gitURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating git provider for directory %s", dir)
+	}
+	dir, _, upstreamInfo, forkInfo, err := gits.ForkAndPullRepo(gitURL, dir, o.Base, o.BranchName, provider, o.Git(), "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fork and pull %s", gitURL)
+	}
+	gitInfo := upstreamInfo
+	username := upstreamInfo.Organisation
+	cloneURL := upstreamInfo.CloneURL
+	if forkInfo != nil {
+		gitInfo = forkInfo
+		username = forkInfo.Organisation
+		cloneURL = forkInfo.CloneURL
+	}
+	headPrefix := ""
+	if upstreamInfo.Organisation != username {
+		headPrefix = username + ":"
+	}
+
+	existingPr, err := o.findBatchPullRequest(provider, upstreamInfo, group, username)
+	if err != nil {
+		return nil, err
+	}
+	branchName := group.BranchName()
+	var updates []BatchUpdate
+	if existingPr != nil {
+		// lets add the update to the head branch of the open pull request which is on the repository we push to
+		headRef := util.DereferenceString(existingPr.HeadRef)
+		if headRef == "" {
+			headRef = branchName
+		}
+		fetchRefSpec := fmt.Sprintf("%s:%s", headRef, branchName)
+		err = o.Git().FetchBranch(dir, "origin", fetchRefSpec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching %s", fetchRefSpec)
+		}
+		updates = ParseBatchUpdates(existingPr.Body)
+	} else {
+		err = o.Git().CreateBranch(dir, branchName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating branch %s", branchName)
+		}
+	}
+	err = o.Git().Checkout(dir, branchName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checking out branch %s", branchName)
+	}
+
+	commitMessage, details, updateDependency, err := o.updateAndGenerateMessagesAndDependencyMatrix(dir, kind, upstreamInfo.Host, gitInfo, update)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !o.SkipCommit {
+		err = o.Git().Add(dir, "-A")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		changed, err := o.Git().HasChanges(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !changed {
+			log.Logger().Warnf("No changes made to the source code in %s. Code must be up to date!", dir)
+			return nil, nil
+		}
+		err = o.Git().CommitDir(dir, commitMessage)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	message := strings.TrimSpace(strings.SplitN(details.Message, "\n\nCommand run was", 2)[0])
+	updates = MergeBatchUpdate(updates, BatchUpdate{
+		Key:     batchUpdateKey(kind, updateDependency),
+		Message: message,
+	})
+	labels := []string{group.Label()}
+	if !o.SkipAutoMerge {
+		labels = append(labels, "updatebot")
+	}
+	labels = append(labels, group.Labels...)
+	labels = append(labels, o.Labels...)
+	gha := &gits.GitPullRequestArguments{
+		GitRepository: upstreamInfo,
+		Title:         group.Title(updates, details.Title),
+		Body:          BatchPullRequestBody(group, updates),
+		Base:          o.Base,
+		Head:          headPrefix + branchName,
+		Labels:        labels,
+	}
+	if o.DryRun {
+		log.Logger().Infof("Commit created but not pushed; would have created or updated the batch pull request with %s. Please manually delete %s when you are done", gha.String(), util.ColorInfo(dir))
+		return nil, nil
+	}
+
+	userAuth := provider.UserAuth()
+	pushURL, err := o.Git().CreateAuthenticatedURL(cloneURL, &userAuth)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating push URL for %s", cloneURL)
+	}
+	err = o.Git().Push(dir, pushURL, true, fmt.Sprintf("HEAD:%s", branchName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "pushing branch %s", branchName)
+	}
+
+	var pr *gits.GitPullRequest
+	if existingPr != nil {
+		pr, err = provider.UpdatePullRequest(gha, *existingPr.Number)
+		if err != nil {
+			return nil, errors.Wrapf(err, "updating pull request %s", existingPr.URL)
+		}
+		log.Logger().Infof("Updated batch Pull Request: %s", util.ColorInfo(pr.URL))
+	} else {
+		pr, err = provider.CreatePullRequest(gha)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating pull request with arguments %v", gha.String())
+		}
+		log.Logger().Infof("Created batch Pull Request: %s", util.ColorInfo(pr.URL))
+	}
+	err = provider.AddLabelsToIssue(pr.Owner, pr.Repo, *pr.Number, labels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to add labels %+v to PR %s", labels, pr.URL)
+	}
+	return &gits.PullRequestInfo{
+		GitProvider:          provider,
+		PullRequest:          pr,
+		PullRequestArguments: gha,
+	}, nil
+}
+
+// findBatchPullRequest finds the open pull request of the group which we can push to
+func (o *PullRequestOperation) findBatchPullRequest(provider gits.GitProvider, upstreamInfo *gits.GitRepository, group *BatchGroup, username string) (*gits.GitPullRequest, error) {
+	filter := gits.PullRequestFilter{
+		Labels: []string{group.Label()},
+	}
+	prs, err := gits.FilterOpenPullRequests(provider, upstreamInfo.Organisation, upstreamInfo.Name, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding existing PRs using filter %s on repo %s/%s", filter.String(), upstreamInfo.Organisation, upstreamInfo.Name)
+	}
+	sort.SliceStable(prs, func(i, j int) bool {
+		// sort in descending order of PR numbers so we update the latest one
+		return util.DereferenceInt(prs[j].Number) < util.DereferenceInt(prs[i].Number)
+	})
+	for _, pr := range prs {
+		if pr.Number == nil {
+			continue
+		}
+		headOwner := util.DereferenceString(pr.HeadOwner)
+		if headOwner != "" && headOwner != username {
+			// we can only push to the branches we own
+			continue
+		}
+		return pr, nil
+	}
+	return nil, nil
+}
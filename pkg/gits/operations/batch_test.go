@@ -0,0 +1,107 @@
+// +build unit
+
+package operations_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/gits/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBatchConfig(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-batch-config-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "batch.yaml")
+	err = ioutil.WriteFile(fileName, []byte(`groups:
+- name: platform
+  dependencies:
+  - jenkins-x/*
+  repositories:
+  - acme/*
+  labels:
+  - platform
+- name: everything-else
+`), 0600)
+	require.NoError(t, err)
+
+	config, err := operations.LoadBatchConfig(fileName)
+	require.NoError(t, err)
+	require.Len(t, config.Groups, 2)
+
+	group := config.FindGroup("https://github.com/jenkins-x/jx.git", "https://github.com/acme/app.git")
+	require.NotNil(t, group)
+	assert.Equal(t, "platform", group.Name)
+	assert.Equal(t, "batch/platform", group.Label())
+	assert.Equal(t, "batch-platform-dependency-updates", group.BranchName())
+
+	group = config.FindGroup("https://github.com/jenkins-x/jx.git", "https://github.com/other/app.git")
+	require.NotNil(t, group)
+	assert.Equal(t, "everything-else", group.Name)
+
+	var noConfig *operations.BatchConfig
+	assert.Nil(t, noConfig.FindGroup("https://github.com/jenkins-x/jx.git", "https://github.com/acme/app.git"))
+}
+
+func TestBatchPullRequestBody(t *testing.T) {
+	t.Parallel()
+
+	group := &operations.BatchGroup{Name: "platform"}
+	updates := operations.MergeBatchUpdate(nil, operations.BatchUpdate{Key: "github.com/jenkins-x/jx", Message: "Update jx to 2.0.0"})
+	updates = operations.MergeBatchUpdate(updates, operations.BatchUpdate{Key: "github.com/jenkins-x/exposecontroller", Message: "Update exposecontroller to 1.0.0"})
+	body := operations.BatchPullRequestBody(group, updates)
+
+	parsed := operations.ParseBatchUpdates(body)
+	assert.Equal(t, updates, parsed)
+	assert.Equal(t, "chore(deps): bump 2 dependencies (platform)", group.Title(parsed, "chore(deps): bump jx"))
+
+	parsed = operations.MergeBatchUpdate(parsed, operations.BatchUpdate{Key: "github.com/jenkins-x/jx", Message: "Update jx to 2.1.0"})
+	assert.Equal(t, []operations.BatchUpdate{
+		{Key: "github.com/jenkins-x/exposecontroller", Message: "Update exposecontroller to 1.0.0"},
+		{Key: "github.com/jenkins-x/jx", Message: "Update jx to 2.1.0"},
+	}, parsed)
+}
+
+func TestCreateBatchPullRequest(t *testing.T) {
+	prOpts := setupTestPullRequestOperation(t)
+
+	prOpts.GitURLs = []string{"testowner/testrepo"}
+	prOpts.SrcGitURL = "testowner/testrepo"
+	prOpts.Version = "2.0.0"
+	prOpts.BatchConfig = &operations.BatchConfig{
+		Groups: []operations.BatchGroup{
+			{
+				Name: "platform",
+			},
+		},
+	}
+	update := func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
+		return []string{"1.0.0"}, nil
+	}
+
+	results, err := prOpts.CreatePullRequest("test", update)
+	require.NoError(t, err)
+	require.NotNil(t, results)
+	assert.Equal(t, 1, *results.PullRequest.Number)
+	assert.Equal(t, "batch-platform-dependency-updates", results.PullRequestArguments.Head)
+	assert.Contains(t, results.PullRequestArguments.Labels, "batch/platform")
+
+	prOpts.Version = "3.0.0"
+	results, err = prOpts.CreatePullRequest("test", update)
+	require.NoError(t, err)
+	require.NotNil(t, results)
+	assert.Equal(t, 1, *results.PullRequest.Number, "the open batch PR should be updated")
+
+	updates := operations.ParseBatchUpdates(results.PullRequest.Body)
+	require.Len(t, updates, 1, "the update of the same dependency should be replaced")
+	assert.Contains(t, updates[0].Message, "to 3.0.0")
+}
@@ -55,6 +55,8 @@ type PullRequestOperation struct {
 	AuthorEmail   string
 	SkipAutoMerge bool
 	Labels        []string
+	// BatchConfig groups the updates into batch pull requests if specified
+	BatchConfig *BatchConfig
 }
 
 // ChangeFilesFn is the function called to create the pull request
@@ -62,9 +64,20 @@ type ChangeFilesFn func(dir string, gitInfo *gits.GitRepository) ([]string, erro
 
 // CreatePullRequest will fork (if needed) and pull a git repo, then perform the update, and finally create or update a
 // PR for the change. Any open PR on the repo with the `updatebot` label will be updated.
+// If the update belongs to a group of the batch configuration the update is added to the open PR of the group instead
 func (o *PullRequestOperation) CreatePullRequest(kind string, update ChangeFilesFn) (*gits.PullRequestInfo, error) {
 	var result *gits.PullRequestInfo
 	for _, gitURL := range o.GitURLs {
+		group := o.BatchConfig.FindGroup(o.SrcGitURL, gitURL)
+		if group != nil {
+			log.Logger().Infof("adding the update of %s to the batch %s", util.ColorInfo(gitURL), util.ColorInfo(group.Name))
+			var err error
+			result, err = o.createBatchPullRequest(gitURL, kind, group, update)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create batch PR %s for %s", group.Name, gitURL)
+			}
+			continue
+		}
 		dir, err := ioutil.TempDir("", "create-pr")
 		if err != nil {
 			return nil, err
@@ -83,7 +96,7 @@ func (o *PullRequestOperation) CreatePullRequest(kind string, update ChangeFiles
 		if forkInfo != nil {
 			gitInfo = forkInfo
 		}
-		commitMessage, details, err := o.updateAndGenerateMessagesAndDependencyMatrix(dir, kind, upstreamInfo.Host, gitInfo, update)
+		commitMessage, details, _, err := o.updateAndGenerateMessagesAndDependencyMatrix(dir, kind, upstreamInfo.Host, gitInfo, update)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -112,7 +125,7 @@ func (o *PullRequestOperation) CreatePullRequest(kind string, update ChangeFiles
 // to batch e.g. in a single PR push/creation
 func (o *PullRequestOperation) WrapChangeFilesWithCommitFn(kind string, fn ChangeFilesFn) ChangeFilesFn {
 	return func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
-		commitMessage, prDetails, err := o.updateAndGenerateMessagesAndDependencyMatrix(dir, kind, gitInfo.Host, gitInfo, fn)
+		commitMessage, prDetails, _, err := o.updateAndGenerateMessagesAndDependencyMatrix(dir, kind, gitInfo.Host, gitInfo, fn)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -139,11 +152,11 @@ func (o *PullRequestOperation) WrapChangeFilesWithCommitFn(kind string, fn Chang
 	}
 }
 
-func (o *PullRequestOperation) updateAndGenerateMessagesAndDependencyMatrix(dir string, kind string, destHost string, gitInfo *gits.GitRepository, update ChangeFilesFn) (string, *gits.PullRequestDetails, error) {
+func (o *PullRequestOperation) updateAndGenerateMessagesAndDependencyMatrix(dir string, kind string, destHost string, gitInfo *gits.GitRepository, update ChangeFilesFn) (string, *gits.PullRequestDetails, *v1.DependencyUpdate, error) {
 
 	oldVersions, err := update(dir, gitInfo)
 	if err != nil {
-		return "", nil, errors.WithStack(err)
+		return "", nil, nil, errors.WithStack(err)
 	}
 	nonSemantic := make([]string, 0)
 	semantic := make([]semver.Version, 0)
@@ -187,7 +200,7 @@ func (o *PullRequestOperation) updateAndGenerateMessagesAndDependencyMatrix(dir
 	version := strings.TrimPrefix(o.Version, "v")
 	commitMessage, details, updateDependency, assets, err := o.CreateDependencyUpdatePRDetails(kind, o.SrcGitURL, destHost, oldVersionsStr, version, o.Component)
 	if err != nil {
-		return "", nil, errors.WithStack(err)
+		return "", nil, nil, errors.WithStack(err)
 	}
 
 	var upstreamDependencyAsset *gits.GitReleaseAsset
@@ -203,7 +216,7 @@ func (o *PullRequestOperation) updateAndGenerateMessagesAndDependencyMatrix(dir
 	if updateDependency != nil {
 		err = dependencymatrix.UpdateDependencyMatrix(dir, updateDependency)
 		if err != nil {
-			return "", nil, errors.WithStack(err)
+			return "", nil, nil, errors.WithStack(err)
 		}
 	}
 
@@ -215,12 +228,12 @@ func (o *PullRequestOperation) updateAndGenerateMessagesAndDependencyMatrix(dir
 			for _, d := range updatedPaths {
 				err = dependencymatrix.UpdateDependencyMatrix(dir, d)
 				if err != nil {
-					return "", nil, errors.Wrapf(err, "updating dependency matrix with upstream dependency %+v", d)
+					return "", nil, nil, errors.Wrapf(err, "updating dependency matrix with upstream dependency %+v", d)
 				}
 			}
 		}
 	}
-	return commitMessage, details, nil
+	return commitMessage, details, updateDependency, nil
 }
 
 // AddDependencyMatrixUpdatePaths retrieves the upstreamDependencyAsset and converts it to a slice of DependencyUpdates, prepending the updateDependency to the path
@@ -78,12 +78,13 @@ type Label struct {
 }
 
 type GitCommit struct {
-	SHA       string
-	Message   string
-	Author    *GitUser
-	URL       string
-	Branch    string
-	Committer *GitUser
+	SHA         string
+	Message     string
+	Author      *GitUser
+	URL         string
+	Branch      string
+	Committer   *GitUser
+	CommittedAt *time.Time
 }
 
 type ListCommitsArguments struct {
@@ -135,6 +136,7 @@ type GitRelease struct {
 	HTMLURL       string
 	DownloadCount int
 	Assets        *[]GitReleaseAsset
+	PublishedAt   *time.Time
 }
 
 // GitReleaseAsset represents a release stored in Git
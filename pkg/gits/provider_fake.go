@@ -297,7 +297,21 @@ func (f *FakeProvider) CreatePullRequest(data *GitPullRequestArguments) (*GitPul
 
 // UpdatePullRequest updates the pull request number with the new data
 func (f *FakeProvider) UpdatePullRequest(data *GitPullRequestArguments, number int) (*GitPullRequest, error) {
-	return nil, errors.Errorf("Not yet implemented for fake")
+	org := data.GitRepository.Organisation
+	repoName := data.GitRepository.Name
+	for _, r := range f.Repositories[org] {
+		if r.GitRepo.Name == repoName {
+			fakePr, ok := r.PullRequests[number]
+			if !ok {
+				return nil, fmt.Errorf("pull request with id '%d' not found", number)
+			}
+			pr := fakePr.PullRequest
+			pr.Title = data.Title
+			pr.Body = data.Body
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("repository '%s/%s' not found", org, repoName)
 }
 
 func (f *FakeProvider) UpdatePullRequestStatus(pr *GitPullRequest) error {
@@ -27,6 +27,11 @@ type HelmState struct {
 	Templates map[string]TemplateSpec `json:"templates,omitempty"`
 
 	Env Environment `json:"-"`
+
+	// loaded is the YAML the helmfile was loaded from and marshalled the YAML of the state when it was loaded so that
+	// only the changes made to the state are saved
+	loaded     []byte
+	marshalled []byte
 }
 
 // SubHelmfileSpec defines the subhelmfile path and options
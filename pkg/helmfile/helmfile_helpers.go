@@ -0,0 +1,296 @@
+package helmfile
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/config"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/yamlmerge"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HelmfileFileName the name of the helmfile
+	HelmfileFileName = "helmfile.yaml"
+)
+
+// ErrTemplatedHelmfile is the cause of the error returned when loading a helmfile which contains Go templates and so is
+// not valid YAML until it is rendered by helmfile
+var ErrTemplatedHelmfile = errors.New("the helmfile contains templates which are rendered by helmfile")
+
+// helmfileDirs the directories of an environment repository which are searched for a helmfile in order
+var helmfileDirs = []string{"", "env"}
+
+// phaseDirs the directories of the helmfiles generated for each phase of the jx-apps.yml by 'jx step create helmfile'
+var phaseDirs = []string{string(config.PhaseSystem), string(config.PhaseApps)}
+
+// FindHelmfile returns the top level helmfile in the environment repository or chart directory or an empty string if
+// there is no helmfile and the directory uses a requirements.yaml instead
+func FindHelmfile(dir string) (string, error) {
+	for _, d := range helmfileDirs {
+		fileName := filepath.Join(dir, d, HelmfileFileName)
+		exists, err := util.FileExists(fileName)
+		if err != nil {
+			return "", errors.Wrapf(err, "checking if file %s exists", fileName)
+		}
+		if exists {
+			return fileName, nil
+		}
+	}
+	return "", nil
+}
+
+// LoadHelmfiles loads all the helmfiles of the environment repository, i.e. the top level helmfile, the helmfiles it
+// references and the helmfiles generated for each phase of the jx-apps.yml. Helmfiles which contain templates are
+// skipped. An empty list is returned if the directory uses a requirements.yaml instead
+func LoadHelmfiles(dir string) ([]*HelmState, error) {
+	var fileNames []string
+	for _, d := range append(helmfileDirs, phaseDirs...) {
+		fileNames = append(fileNames, filepath.Join(dir, d, HelmfileFileName))
+	}
+
+	var answer []*HelmState
+	loaded := map[string]bool{}
+	for i := 0; i < len(fileNames); i++ {
+		fileName := filepath.Clean(fileNames[i])
+		if loaded[fileName] {
+			continue
+		}
+		loaded[fileName] = true
+		exists, err := util.FileExists(fileName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking if file %s exists", fileName)
+		}
+		if !exists {
+			continue
+		}
+		state, err := LoadHelmfile(fileName)
+		if errors.Cause(err) == ErrTemplatedHelmfile {
+			log.Logger().Warnf("skipping helmfile %s as it contains templates", fileName)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, state)
+
+		for _, sub := range state.Helmfiles {
+			if sub.Path == "" {
+				continue
+			}
+			matches, err := filepath.Glob(filepath.Join(filepath.Dir(fileName), sub.Path))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid helmfile path %s in file %s", sub.Path, fileName)
+			}
+			fileNames = append(fileNames, matches...)
+		}
+	}
+	return answer, nil
+}
+
+// LoadHelmfile loads the helmfile with the given file name. If the helmfile cannot be parsed as it contains templates
+// the cause of the error is ErrTemplatedHelmfile
+func LoadHelmfile(fileName string) (*HelmState, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	state := &HelmState{}
+	err = yaml.Unmarshal(data, state)
+	if err != nil {
+		if bytes.Contains(data, []byte("{{")) {
+			return nil, errors.Wrapf(ErrTemplatedHelmfile, "failed to unmarshal YAML file %s: %s", fileName, err)
+		}
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	state.FilePath = fileName
+	state.loaded = data
+	state.marshalled, err = state.marshal()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal helmfile %s", fileName)
+	}
+	return state, nil
+}
+
+// SaveHelmfile saves the helmfile to the given file name. If the helmfile was loaded from a file only the changes made
+// since it was loaded are applied to the original YAML so that its comments and formatting are kept
+func SaveHelmfile(fileName string, state *HelmState) error {
+	data, err := state.marshal()
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal helmfile %s", fileName)
+	}
+	if state.loaded != nil {
+		var conflicts []yamlmerge.Conflict
+		data, conflicts, err = yamlmerge.Merge(state.marshalled, state.loaded, data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply the changes to helmfile %s", fileName)
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("failed to apply the changes to helmfile %s as %s was changed", fileName, conflicts[0].Path)
+		}
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// Modified returns true if the state has been changed since it was loaded
+func (s *HelmState) Modified() (bool, error) {
+	data, err := s.marshal()
+	if err != nil {
+		return false, err
+	}
+	return s.loaded == nil || !bytes.Equal(data, s.marshalled), nil
+}
+
+func (s *HelmState) marshal() ([]byte, error) {
+	filePath := s.FilePath
+	s.FilePath = ""
+	defer func() {
+		s.FilePath = filePath
+	}()
+	return yaml.Marshal(s)
+}
+
+// ReleaseHelmfile returns the helmfile which releases the app or, if none of them do, the helmfile a new release of
+// the app should be added to, preferring the helmfile of the apps phase
+func ReleaseHelmfile(states []*HelmState, app string) *HelmState {
+	for _, state := range states {
+		if state.FindRelease(app) != nil {
+			return state
+		}
+	}
+	for _, state := range states {
+		if filepath.Base(filepath.Dir(state.FilePath)) == string(config.PhaseApps) {
+			return state
+		}
+	}
+	for _, state := range states {
+		if len(state.Releases) > 0 {
+			return state
+		}
+	}
+	if len(states) > 0 {
+		return states[0]
+	}
+	return nil
+}
+
+// ChartName returns the name of the chart of the release without the repository prefix
+func (r *ReleaseSpec) ChartName() string {
+	idx := strings.LastIndex(r.Chart, "/")
+	return r.Chart[idx+1:]
+}
+
+// RepositoryName returns the name of the repository prefix of the chart of the release or an empty string if the
+// chart is a local directory
+func (r *ReleaseSpec) RepositoryName() string {
+	if strings.HasPrefix(r.Chart, ".") || strings.HasPrefix(r.Chart, "/") {
+		return ""
+	}
+	idx := strings.Index(r.Chart, "/")
+	if idx < 0 {
+		return ""
+	}
+	return r.Chart[:idx]
+}
+
+// FindRelease returns the release for the app which is either the name of the release or its chart or nil if the
+// app is not released by this helmfile
+func (s *HelmState) FindRelease(app string) *ReleaseSpec {
+	for i := range s.Releases {
+		if s.Releases[i].Name == app {
+			return &s.Releases[i]
+		}
+	}
+	for i := range s.Releases {
+		if s.Releases[i].ChartName() == app {
+			return &s.Releases[i]
+		}
+	}
+	return nil
+}
+
+// FindRepository returns the repository with the given name or nil if there is none
+func (s *HelmState) FindRepository(name string) *RepositorySpec {
+	for i := range s.Repositories {
+		if s.Repositories[i].Name == name {
+			return &s.Repositories[i]
+		}
+	}
+	return nil
+}
+
+// RepositoryURL returns the URL of the repository of the chart of the release or an empty string if it is not known
+func (s *HelmState) RepositoryURL(release *ReleaseSpec) string {
+	repo := s.FindRepository(release.RepositoryName())
+	if repo == nil {
+		return ""
+	}
+	return repo.URL
+}
+
+// SetReleaseVersion sets the version of the release of the app, adding a release of the chart from the repository URL
+// if the app is not released yet. A repository is added for the URL using the given name if there is no
+// repository for the URL already. The alias is the name of the release if it differs from the name of the chart
+func (s *HelmState) SetReleaseVersion(app string, version string, repositoryURL string, repositoryName string, alias string, namespace string) {
+	name := app
+	if alias != "" {
+		name = alias
+	}
+	release := s.FindRelease(name)
+	if release != nil {
+		release.Version = version
+		return
+	}
+
+	repoName := ""
+	for _, repo := range s.Repositories {
+		if repositoryURL != "" && repo.URL == repositoryURL {
+			repoName = repo.Name
+			break
+		}
+	}
+	if repoName == "" && repositoryURL == "" {
+		repoName = repositoryName
+	} else if repoName == "" {
+		repoName = repositoryName
+		// lets avoid clashing with an existing repository with a different URL
+		for i := 2; s.FindRepository(repoName) != nil; i++ {
+			repoName = fmt.Sprintf("%s-%d", repositoryName, i)
+		}
+		s.Repositories = append(s.Repositories, RepositorySpec{
+			Name: repoName,
+			URL:  repositoryURL,
+		})
+	}
+	s.Releases = append(s.Releases, ReleaseSpec{
+		Name:      name,
+		Namespace: namespace,
+		Chart:     repoName + "/" + app,
+		Version:   version,
+	})
+}
+
+// RemoveRelease removes the release of the app. Returns true if a release was removed
+func (s *HelmState) RemoveRelease(app string) bool {
+	release := s.FindRelease(app)
+	if release == nil {
+		return false
+	}
+	for i := range s.Releases {
+		if &s.Releases[i] == release {
+			s.Releases = append(s.Releases[:i], s.Releases[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
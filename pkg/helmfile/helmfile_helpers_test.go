@@ -0,0 +1,165 @@
+// +build unit
+
+package helmfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/helmfile"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetReleaseVersion(t *testing.T) {
+	t.Parallel()
+
+	state, err := helmfile.LoadHelmfile(filepath.Join("test_data", helmfile.HelmfileFileName))
+	require.NoError(t, err)
+
+	// existing release by name and by chart name
+	state.SetReleaseVersion("myapp", "0.0.2", "http://jenkins-x-chartmuseum:8080", "releases", "", "jx-staging")
+	state.SetReleaseVersion("exposecontroller", "2.3.119", "", "jenkins-x", "expose", "jx-staging")
+	require.Len(t, state.Releases, 2)
+	assert.Equal(t, "0.0.2", state.FindRelease("myapp").Version)
+	assert.Equal(t, "2.3.119", state.FindRelease("expose").Version)
+	assert.Equal(t, "2.3.119", state.FindRelease("exposecontroller").Version)
+
+	// new release from an existing repository
+	state.SetReleaseVersion("other", "1.0.0", "http://jenkins-x-chartmuseum:8080", "releases", "", "jx-staging")
+	release := state.FindRelease("other")
+	require.NotNil(t, release)
+	assert.Equal(t, "releases/other", release.Chart)
+	assert.Equal(t, "jx-staging", release.Namespace)
+	assert.Equal(t, "http://jenkins-x-chartmuseum:8080", state.RepositoryURL(release))
+	assert.Len(t, state.Repositories, 2)
+
+	// new release from a new repository whose name clashes with an existing one
+	state.SetReleaseVersion("cheese", "1.2.3", "https://acme.com/charts", "releases", "", "jx-staging")
+	release = state.FindRelease("cheese")
+	require.NotNil(t, release)
+	assert.Equal(t, "releases-2/cheese", release.Chart)
+	assert.Equal(t, "https://acme.com/charts", state.RepositoryURL(release))
+
+	assert.True(t, state.RemoveRelease("cheese"))
+	assert.False(t, state.RemoveRelease("cheese"))
+	assert.Len(t, state.Releases, 3)
+}
+
+func TestFindHelmfile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-find-helmfile-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName, err := helmfile.FindHelmfile(dir)
+	require.NoError(t, err)
+	assert.Empty(t, fileName)
+
+	err = os.MkdirAll(filepath.Join(dir, "env"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	expected := filepath.Join(dir, "env", helmfile.HelmfileFileName)
+	err = helmfile.SaveHelmfile(expected, &helmfile.HelmState{
+		Releases: []helmfile.ReleaseSpec{{Name: "myapp", Chart: "./myapp"}},
+	})
+	require.NoError(t, err)
+
+	fileName, err = helmfile.FindHelmfile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, fileName)
+
+	state, err := helmfile.LoadHelmfile(fileName)
+	require.NoError(t, err)
+	require.Len(t, state.Releases, 1)
+	assert.Equal(t, "", state.Releases[0].RepositoryName())
+	assert.Equal(t, "myapp", state.Releases[0].ChartName())
+}
+
+func TestLoadHelmfilesOfPhases(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-load-helmfiles-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		helmfile.HelmfileFileName: "helmfiles:\n- path: system/helmfile.yaml\n- path: apps/helmfile.yaml\n- path: extra/*.yaml\n",
+		filepath.Join("system", helmfile.HelmfileFileName): "releases:\n- chart: jenkins-x/exposecontroller\n  name: expose\n",
+		filepath.Join("apps", helmfile.HelmfileFileName):   "releases:\n- chart: releases/myapp\n  name: myapp\n",
+		filepath.Join("extra", "more.yaml"):                 "releases:\n- chart: releases/other\n  name: other\n",
+		filepath.Join("extra", "templated.yaml"):            "releases:\n{{ range .Values.apps }}\n- name: {{ . }}\n{{ end }}\n",
+	}
+	for name, text := range files {
+		fileName := filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(fileName, []byte(text), util.DefaultWritePermissions)
+		require.NoError(t, err)
+	}
+
+	states, err := helmfile.LoadHelmfiles(dir)
+	require.NoError(t, err)
+	var releases []string
+	for _, state := range states {
+		for _, release := range state.Releases {
+			releases = append(releases, release.Name)
+		}
+	}
+	assert.Equal(t, []string{"expose", "myapp", "other"}, releases)
+
+	assert.Equal(t, filepath.Join(dir, "system", helmfile.HelmfileFileName), helmfile.ReleaseHelmfile(states, "expose").FilePath)
+	assert.Equal(t, filepath.Join(dir, "apps", helmfile.HelmfileFileName), helmfile.ReleaseHelmfile(states, "cheese").FilePath)
+
+	_, err = helmfile.LoadHelmfile(filepath.Join(dir, "extra", "templated.yaml"))
+	assert.Equal(t, helmfile.ErrTemplatedHelmfile, errors.Cause(err))
+}
+
+func TestSaveHelmfileKeepsComments(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-save-helmfile-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, helmfile.HelmfileFileName)
+	err = ioutil.WriteFile(fileName, []byte(`# the staging environment
+releases:
+- chart: releases/myapp
+  name: myapp
+  # promoted by the pipeline
+  version: 0.0.1
+  values:
+  - '{{ .Values.myapp | toYaml }}'
+`), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	state, err := helmfile.LoadHelmfile(fileName)
+	require.NoError(t, err)
+	modified, err := state.Modified()
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	state.SetReleaseVersion("myapp", "0.0.2", "", "releases", "", "")
+	modified, err = state.Modified()
+	require.NoError(t, err)
+	assert.True(t, modified)
+	err = helmfile.SaveHelmfile(fileName, state)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, `# the staging environment
+releases:
+- chart: releases/myapp
+  name: myapp
+  # promoted by the pipeline
+  version: 0.0.2
+  values:
+  - '{{ .Values.myapp | toYaml }}'
+`, string(data))
+}
@@ -0,0 +1,141 @@
+package junit
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultMaxRuns the default number of test runs kept in the history of a repository
+	DefaultMaxRuns = 50
+
+	// DefaultFlakyWindow the default number of recent runs used to detect flaky tests
+	DefaultFlakyWindow = 20
+)
+
+// TestHistory stores the recent test results of a repository
+type TestHistory struct {
+	Owner      string     `json:"owner,omitempty"`
+	Repository string     `json:"repository,omitempty"`
+	Runs       []*TestRun `json:"runs,omitempty"`
+}
+
+// TestRun stores the results of all the test cases of a single build
+type TestRun struct {
+	Build     string                `json:"build,omitempty"`
+	Branch    string                `json:"branch,omitempty"`
+	Commit    string                `json:"commit,omitempty"`
+	Timestamp time.Time             `json:"timestamp,omitempty"`
+	Results   map[string]TestStatus `json:"results,omitempty"`
+}
+
+// FlakyTest describes a test which has flipped between passing and failing
+type FlakyTest struct {
+	Name            string `json:"name"`
+	Passes          int    `json:"passes"`
+	Failures        int    `json:"failures"`
+	Flips           int    `json:"flips"`
+	SameCommit      bool   `json:"sameCommit,omitempty"`
+	LastFailedBuild string `json:"lastFailedBuild,omitempty"`
+	LastFailedRef   string `json:"lastFailedRef,omitempty"`
+}
+
+// AddRun adds the given run to the history replacing any previous run of the same branch and build
+// and only keeping the most recent maxRuns runs
+func (h *TestHistory) AddRun(run *TestRun, maxRuns int) {
+	runs := []*TestRun{}
+	for _, r := range h.Runs {
+		if r.Build == run.Build && r.Branch == run.Branch && run.Build != "" {
+			continue
+		}
+		runs = append(runs, r)
+	}
+	runs = append(runs, run)
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Timestamp.Before(runs[j].Timestamp)
+	})
+	if maxRuns > 0 && len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+	h.Runs = runs
+}
+
+// RecentRuns returns the most recent window runs in the order they ran
+func (h *TestHistory) RecentRuns(window int) []*TestRun {
+	if window <= 0 || window >= len(h.Runs) {
+		return h.Runs
+	}
+	return h.Runs[len(h.Runs)-window:]
+}
+
+// FlakyTests returns the tests which have flipped between passing and failing in the recent window runs.
+//
+// A test is flaky if it both passed and failed on the same commit or if, within the builds of a single branch,
+// it went from passing to failing and back again (or vice versa)
+func (h *TestHistory) FlakyTests(window int) []*FlakyTest {
+	runs := h.RecentRuns(window)
+	flakes := map[string]*FlakyTest{}
+	commitStatuses := map[string]map[string]TestStatus{}
+	lastBranchStatuses := map[string]map[string]TestStatus{}
+
+	getFlaky := func(name string) *FlakyTest {
+		f := flakes[name]
+		if f == nil {
+			f = &FlakyTest{Name: name}
+			flakes[name] = f
+		}
+		return f
+	}
+
+	for _, run := range runs {
+		if lastBranchStatuses[run.Branch] == nil {
+			lastBranchStatuses[run.Branch] = map[string]TestStatus{}
+		}
+		lastStatuses := lastBranchStatuses[run.Branch]
+		for name, status := range run.Results {
+			if status == TestStatusSkipped {
+				continue
+			}
+			f := getFlaky(name)
+			if status == TestStatusFailed {
+				f.Failures++
+				f.LastFailedBuild = run.Build
+				f.LastFailedRef = run.Branch
+			} else {
+				f.Passes++
+			}
+
+			if run.Commit != "" {
+				key := run.Commit
+				if commitStatuses[key] == nil {
+					commitStatuses[key] = map[string]TestStatus{}
+				}
+				previous, ok := commitStatuses[key][name]
+				if ok && previous != status {
+					f.SameCommit = true
+				}
+				commitStatuses[key][name] = status
+			}
+
+			previous, ok := lastStatuses[name]
+			if ok && previous != status {
+				f.Flips++
+			}
+			lastStatuses[name] = status
+		}
+	}
+
+	answer := []*FlakyTest{}
+	for _, f := range flakes {
+		if f.SameCommit || f.Flips > 1 {
+			answer = append(answer, f)
+		}
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		if answer[i].Flips != answer[j].Flips {
+			return answer[i].Flips > answer[j].Flips
+		}
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
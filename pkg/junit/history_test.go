@@ -0,0 +1,102 @@
+// +build unit
+
+package junit_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pass = junit.TestStatusPassed
+	fail = junit.TestStatusFailed
+)
+
+func TestFlakyTestsAcrossBuilds(t *testing.T) {
+	t.Parallel()
+
+	history := createHistory("master", []map[string]junit.TestStatus{
+		{"TestStable": pass, "TestFlaky": pass, "TestBroken": pass},
+		{"TestStable": pass, "TestFlaky": fail, "TestBroken": pass},
+		{"TestStable": pass, "TestFlaky": pass, "TestBroken": fail},
+		{"TestStable": pass, "TestFlaky": fail, "TestBroken": fail},
+	})
+
+	flakes := history.FlakyTests(junit.DefaultFlakyWindow)
+	require.Len(t, flakes, 1)
+	assert.Equal(t, "TestFlaky", flakes[0].Name)
+	assert.Equal(t, 3, flakes[0].Flips)
+	assert.Equal(t, 2, flakes[0].Passes)
+	assert.Equal(t, 2, flakes[0].Failures)
+	assert.Equal(t, "4", flakes[0].LastFailedBuild)
+
+	flakes = history.FlakyTests(2)
+	assert.Empty(t, flakes, "a single flip within the window is not flaky")
+}
+
+func TestFlakyTestsOnSameCommit(t *testing.T) {
+	t.Parallel()
+
+	history := &junit.TestHistory{}
+	now := time.Now()
+	history.AddRun(&junit.TestRun{Build: "1", Branch: "PR-1", Commit: "abc", Timestamp: now, Results: map[string]junit.TestStatus{"TestRetried": fail}}, 0)
+	history.AddRun(&junit.TestRun{Build: "2", Branch: "PR-1", Commit: "abc", Timestamp: now.Add(time.Minute), Results: map[string]junit.TestStatus{"TestRetried": pass}}, 0)
+
+	flakes := history.FlakyTests(junit.DefaultFlakyWindow)
+	require.Len(t, flakes, 1)
+	assert.True(t, flakes[0].SameCommit)
+
+	markdown := junit.FlakyTestsMarkdown(flakes, junit.DefaultFlakyWindow)
+	assert.Contains(t, markdown, "| `TestRetried` | 1 | 1 | 1 | yes |")
+}
+
+func TestFlakyTestsIgnoresOtherBranches(t *testing.T) {
+	t.Parallel()
+
+	history := createHistory("master", []map[string]junit.TestStatus{
+		{"TestFoo": pass},
+		{"TestFoo": pass},
+	})
+	history.AddRun(&junit.TestRun{Build: "1", Branch: "PR-2", Commit: "other", Timestamp: time.Now().Add(time.Hour), Results: map[string]junit.TestStatus{"TestFoo": fail}}, 0)
+	history.AddRun(&junit.TestRun{Build: "9", Branch: "master", Commit: "last", Timestamp: time.Now().Add(2 * time.Hour), Results: map[string]junit.TestStatus{"TestFoo": pass}}, 0)
+
+	assert.Empty(t, history.FlakyTests(junit.DefaultFlakyWindow))
+}
+
+func TestAddRunTrimsHistory(t *testing.T) {
+	t.Parallel()
+
+	history := &junit.TestHistory{}
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		history.AddRun(&junit.TestRun{Build: strconv.Itoa(i), Branch: "master", Timestamp: now.Add(time.Duration(i) * time.Minute)}, 3)
+	}
+	// re-running a build replaces the previous results
+	history.AddRun(&junit.TestRun{Build: "5", Branch: "master", Timestamp: now.Add(10 * time.Minute)}, 3)
+
+	require.Len(t, history.Runs, 3)
+	assert.Equal(t, "3", history.Runs[0].Build)
+	assert.Equal(t, "4", history.Runs[1].Build)
+	assert.Equal(t, "5", history.Runs[2].Build)
+}
+
+func createHistory(branch string, results []map[string]junit.TestStatus) *junit.TestHistory {
+	history := &junit.TestHistory{}
+	now := time.Now()
+	for i, r := range results {
+		build := strconv.Itoa(i + 1)
+		history.AddRun(&junit.TestRun{
+			Build:     build,
+			Branch:    branch,
+			Commit:    "sha" + build,
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+			Results:   r,
+		}, junit.DefaultMaxRuns)
+	}
+	return history
+}
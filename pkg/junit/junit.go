@@ -0,0 +1,152 @@
+package junit
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// TestStatus represents the outcome of a single test case
+type TestStatus string
+
+const (
+	// TestStatusPassed the test case passed
+	TestStatusPassed TestStatus = "passed"
+
+	// TestStatusFailed the test case failed or errored
+	TestStatusFailed TestStatus = "failed"
+
+	// TestStatusSkipped the test case was skipped
+	TestStatusSkipped TestStatus = "skipped"
+)
+
+// TestSuites is the representation of the root of a *.junit.xml xml file
+type TestSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Text       string      `xml:",chardata"`
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is the representation of a <testsuite> of a *.junit.xml xml file
+type TestSuite struct {
+	XMLName  xml.Name   `xml:"testsuite"`
+	Text     string     `xml:",chardata"`
+	Name     string     `xml:"name,attr"`
+	Tests    string     `xml:"tests,attr"`
+	Failures string     `xml:"failures,attr"`
+	Errors   string     `xml:"errors,attr"`
+	Time     string     `xml:"time,attr"`
+	TestCase []TestCase `xml:"testcase"`
+}
+
+// TestCase is the representation of an individual test case within a TestSuite in a *.junit.xml xml file
+type TestCase struct {
+	XMLName   xml.Name `xml:"testcase"`
+	Text      string   `xml:",chardata"`
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Error     *Failure `xml:"error,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out"`
+}
+
+// Failure is the representation of a Failure or Error that can be present in a TestCase within a TestSuite in a *.junit.xml xml file
+type Failure struct {
+	Text    string `xml:",chardata"`
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Skipped is the representation of a skipped TestCase
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Status returns the outcome of the test case
+func (t *TestCase) Status() TestStatus {
+	if t.Failure != nil || t.Error != nil {
+		return TestStatusFailed
+	}
+	if t.Skipped != nil {
+		return TestStatusSkipped
+	}
+	return TestStatusPassed
+}
+
+// FullName returns the unique name of the test case within the given suite using the class name if present
+func (t *TestCase) FullName(suite *TestSuite) string {
+	prefix := t.Classname
+	if prefix == "" && suite != nil {
+		prefix = suite.Name
+	}
+	if prefix == "" {
+		return t.Name
+	}
+	return prefix + "." + t.Name
+}
+
+// ParseFile parses the given junit file which can either have a <testsuites> or a <testsuite> root element
+func ParseFile(fileName string) ([]TestSuite, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read junit file %s", fileName)
+	}
+	suites, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse junit file %s", fileName)
+	}
+	return suites, nil
+}
+
+// ParseFiles parses all the given junit files returning all of the test suites
+func ParseFiles(fileNames []string) ([]TestSuite, error) {
+	var answer []TestSuite
+	for _, f := range fileNames {
+		suites, err := ParseFile(f)
+		if err != nil {
+			return answer, err
+		}
+		answer = append(answer, suites...)
+	}
+	return answer, nil
+}
+
+// Parse parses the junit XML data which can either have a <testsuites> or a <testsuite> root element
+func Parse(data []byte) ([]TestSuite, error) {
+	var testSuites TestSuites
+	err := xml.Unmarshal(data, &testSuites)
+	if err == nil {
+		return testSuites.TestSuites, nil
+	}
+	var testSuite TestSuite
+	err = xml.Unmarshal(data, &testSuite)
+	if err != nil {
+		return nil, err
+	}
+	return []TestSuite{testSuite}, nil
+}
+
+// Results returns the status of each test case in the suites indexed by the full test name.
+// If the same test is reported more than once then any failure wins
+func Results(suites []TestSuite) map[string]TestStatus {
+	answer := map[string]TestStatus{}
+	for i := range suites {
+		suite := &suites[i]
+		for j := range suite.TestCase {
+			tc := &suite.TestCase[j]
+			name := tc.FullName(suite)
+			status := tc.Status()
+			if answer[name] == TestStatusFailed {
+				continue
+			}
+			if status == TestStatusSkipped && answer[name] == TestStatusPassed {
+				continue
+			}
+			answer[name] = status
+		}
+	}
+	return answer
+}
@@ -0,0 +1,69 @@
+// +build unit
+
+package junit_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFiles(t *testing.T) {
+	t.Parallel()
+
+	suites, err := junit.ParseFiles([]string{
+		filepath.Join("test_data", "suites.junit.xml"),
+		filepath.Join("test_data", "suite.junit.xml"),
+	})
+	require.NoError(t, err)
+	require.Len(t, suites, 3)
+
+	results := junit.Results(suites)
+	assert.Equal(t, map[string]junit.TestStatus{
+		"cart.TestAddItem":    junit.TestStatusPassed,
+		"cart.TestRemoveItem": junit.TestStatusFailed,
+		"cart.TestDiscount":   junit.TestStatusSkipped,
+		"payments.TestCharge": junit.TestStatusFailed,
+		"auth.TestLogin":      junit.TestStatusPassed,
+	}, results)
+}
+
+func TestParseInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := junit.Parse([]byte("this is not xml"))
+	assert.Error(t, err)
+}
+
+func TestSaveAndLoadHistory(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-history")
+	require.NoError(t, err)
+	bucketURL := "file://" + dir
+
+	history, err := junit.LoadHistory(bucketURL, "myorg", "myrepo")
+	require.NoError(t, err)
+	assert.Empty(t, history.Runs, "should have no runs when nothing is stored")
+
+	history.AddRun(&junit.TestRun{
+		Build:     "1",
+		Branch:    "master",
+		Commit:    "abc",
+		Timestamp: time.Now(),
+		Results:   map[string]junit.TestStatus{"TestFoo": junit.TestStatusPassed},
+	}, junit.DefaultMaxRuns)
+	err = junit.SaveHistory(bucketURL, history)
+	require.NoError(t, err)
+
+	loaded, err := junit.LoadHistory(bucketURL, "myorg", "myrepo")
+	require.NoError(t, err)
+	require.Len(t, loaded.Runs, 1)
+	assert.Equal(t, junit.TestStatusPassed, loaded.Runs[0].Results["TestFoo"])
+	assert.FileExists(t, filepath.Join(dir, "jenkins-x", "testhistory", "myorg", "myrepo", junit.HistoryFileName))
+}
@@ -0,0 +1,25 @@
+package junit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlakyTestsMarkdown generates a markdown summary of the flaky tests suitable for a Pull Request comment
+func FlakyTestsMarkdown(flakes []*FlakyTest, window int) string {
+	if len(flakes) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	buffer.WriteString(fmt.Sprintf(":warning: **%d flaky test(s)** detected in the last %d builds:\n\n", len(flakes), window))
+	buffer.WriteString("| Test | Passed | Failed | Flips | Same Commit |\n")
+	buffer.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, f := range flakes {
+		sameCommit := ""
+		if f.SameCommit {
+			sameCommit = "yes"
+		}
+		buffer.WriteString(fmt.Sprintf("| `%s` | %d | %d | %d | %s |\n", f.Name, f.Passes, f.Failures, f.Flips, sameCommit))
+	}
+	return buffer.String()
+}
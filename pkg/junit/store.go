@@ -0,0 +1,55 @@
+package junit
+
+import (
+	"bytes"
+	"path"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/cloud/buckets"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// HistoryFileName the name of the file used to store the test history of a repository
+	HistoryFileName = "history.yaml"
+
+	defaultTimeout = time.Second * 20
+)
+
+// HistoryKey returns the key in the storage bucket of the test history for the given repository
+func HistoryKey(owner string, repository string) string {
+	return path.Join("jenkins-x", "testhistory", owner, repository, HistoryFileName)
+}
+
+// LoadHistory loads the test history of the repository from the given bucket URL.
+// If there is no history stored yet an empty history is returned
+func LoadHistory(bucketURL string, owner string, repository string) (*TestHistory, error) {
+	history := &TestHistory{
+		Owner:      owner,
+		Repository: repository,
+	}
+	key := HistoryKey(owner, repository)
+	data, err := buckets.ReadBucket(bucketURL, key, defaultTimeout)
+	if err != nil {
+		return history, err
+	}
+	if len(data) == 0 {
+		return history, nil
+	}
+	err = yaml.Unmarshal(data, history)
+	if err != nil {
+		return history, errors.Wrapf(err, "failed to unmarshal test history %s in bucket %s", key, bucketURL)
+	}
+	return history, nil
+}
+
+// SaveHistory stores the test history in the given bucket URL
+func SaveHistory(bucketURL string, history *TestHistory) error {
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal test history to YAML")
+	}
+	key := HistoryKey(history.Owner, history.Repository)
+	return buckets.WriteBucket(bucketURL, key, bytes.NewReader(data), defaultTimeout)
+}
@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 type NodeStatus struct {
@@ -38,9 +39,15 @@ type ClusterStatus struct {
 	totalUsedCpu           resource.Quantity
 	totalAllocatableMemory resource.Quantity
 	totalAllocatableCpu    resource.Quantity
+	totalActualMemory      resource.Quantity
+	totalActualCpu         resource.Quantity
+	hasActualUsage         bool
 }
 
-func GetClusterStatus(client kubernetes.Interface, namespace string, verbose bool) (ClusterStatus, error) {
+// GetClusterStatus returns the allocatable resources of the nodes of the cluster and the resources requested by the
+// pods on them. If a metrics client is specified the actual usage of the nodes is also queried from the
+// metrics.k8s.io API
+func GetClusterStatus(client kubernetes.Interface, metricsClient metricsclient.Interface, namespace string, verbose bool) (ClusterStatus, error) {
 
 	clusterStatus := ClusterStatus{
 		totalAllocatableCpu:    resource.Quantity{},
@@ -91,6 +98,18 @@ func GetClusterStatus(client kubernetes.Interface, namespace string, verbose boo
 		clusterStatus.totalUsedCpu.Add(nodeStatus.CpuRequests)
 	}
 
+	if metricsClient != nil {
+		nodeMetrics, err := metricsClient.MetricsV1beta1().NodeMetricses().List(metav1.ListOptions{})
+		if err != nil {
+			log.Logger().Debugf("Unable to query node metrics from the metrics.k8s.io API: %s", err)
+		} else {
+			for _, m := range nodeMetrics.Items {
+				clusterStatus.totalActualCpu.Add(*m.Usage.Cpu())
+				clusterStatus.totalActualMemory.Add(*m.Usage.Memory())
+			}
+			clusterStatus.hasActualUsage = len(nodeMetrics.Items) > 0
+		}
+	}
 	return clusterStatus, nil
 }
 
@@ -106,6 +125,21 @@ func (clusterStatus *ClusterStatus) AverageMemPercent() int {
 	return int((clusterStatus.totalUsedMemory.Value() * 100) / clusterStatus.totalAllocatableMemory.Value())
 }
 
+// ActualCpuPercent returns the percentage of the allocatable CPU of the cluster which is actually being used
+func (clusterStatus *ClusterStatus) ActualCpuPercent() int {
+	return int((clusterStatus.totalActualCpu.MilliValue() * 100) / clusterStatus.totalAllocatableCpu.MilliValue())
+}
+
+// ActualMemPercent returns the percentage of the allocatable memory of the cluster which is actually being used
+func (clusterStatus *ClusterStatus) ActualMemPercent() int {
+	return int((clusterStatus.totalActualMemory.Value() * 100) / clusterStatus.totalAllocatableMemory.Value())
+}
+
+// HasActualUsage returns true if the actual usage of the cluster was queried from the metrics.k8s.io API
+func (clusterStatus *ClusterStatus) HasActualUsage() bool {
+	return clusterStatus.hasActualUsage
+}
+
 func (clusterStatus *ClusterStatus) NodeCount() int {
 	return clusterStatus.nodeCount
 }
@@ -129,6 +163,9 @@ func (clusterStatus *ClusterStatus) Info() string {
 		clusterStatus.totalAllocatableMemory.String(),
 		clusterStatus.AverageCpuPercent(),
 		clusterStatus.totalAllocatableCpu.String())
+	if clusterStatus.hasActualUsage {
+		str += fmt.Sprintf(", actual usage memory %d%% cpu %d%%", clusterStatus.ActualMemPercent(), clusterStatus.ActualCpuPercent())
+	}
 	return str
 }
 
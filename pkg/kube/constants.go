@@ -194,6 +194,18 @@ const (
 	// ValueKindPodTemplate a PodTemplate in a ConfigMap
 	ValueKindPodTemplate = "podTemplate"
 
+	// ValueKindDevPodTemplate a DevPod template in a ConfigMap
+	ValueKindDevPodTemplate = "devpodTemplate"
+
+	// ValueKindSuspendedDevPod a ConfigMap which stores a suspended DevPod
+	ValueKindSuspendedDevPod = "suspendedDevPod"
+
+	// ValueKindTeamPolicy a ConfigMap which stores the policy of a team
+	ValueKindTeamPolicy = "teamPolicy"
+
+	// ValueKindAudit an Event which records a jx command in the audit log
+	ValueKindAudit = "audit"
+
 	// ValueKindPodTemplateXML a PodTemplate XML in a ConfigMap
 	ValueKindPodTemplateXML = "podTemplateXml"
 
@@ -221,6 +233,9 @@ const (
 	// LabelDevPodUsername the user name owner of the DeVPod
 	LabelDevPodUsername = "jenkins.io/devpod_user"
 
+	// LabelDevPodTemplate the name of the DevPod template used to create a DevPod
+	LabelDevPodTemplate = "jenkins.io/devpod_template"
+
 	// LabelDevPodGitPrefix used to label a devpod with the repository host, owner, repo
 	LabelDevPodGitPrefix = "jenkins.io/repo"
 
@@ -282,6 +297,12 @@ const (
 	AnnotationLocalDir = "jenkins.io/local-dir"
 	// AnnotationGitURLs the newline separated list of git URLs of the DevPods
 	AnnotationGitURLs = "jenkins.io/git-urls"
+	// AnnotationDevPodLastActive the time a DevPod was last seen using CPU
+	AnnotationDevPodLastActive = "jenkins.io/devpod-last-active"
+	// AnnotationDevPodIdleTimeout the duration a DevPod can be idle before it is automatically suspended
+	AnnotationDevPodIdleTimeout = "jenkins.io/devpod-idle-timeout"
+	// AnnotationDevPodSuspended the time a DevPod was suspended
+	AnnotationDevPodSuspended = "jenkins.io/devpod-suspended"
 	// AnnotationGitReportState used to annotate what state has been reported to git
 	AnnotationGitReportState = "jenkins.io/git-report-state"
 	// AnnotationGitReportRunningStages used to annotate what stages were last reported to git as running
@@ -0,0 +1,383 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DevPodTemplateConfigMapPrefix the prefix of the names of the ConfigMaps which store DevPod templates
+	DevPodTemplateConfigMapPrefix = "jenkins-x-devpod-template-"
+
+	// DevPodTemplateKey the key of the DevPod template YAML in its ConfigMap
+	DevPodTemplateKey = "template"
+
+	// SuspendedDevPodSuffix the suffix of the name of the ConfigMap which stores a suspended DevPod
+	SuspendedDevPodSuffix = "-suspended"
+
+	// SuspendedDevPodKey the key of the pod YAML in the ConfigMap of a suspended DevPod
+	SuspendedDevPodKey = "pod"
+
+	serviceAccountMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// DevPodTemplate a named template of a team for creating DevPods. It is stored in a ConfigMap in the dev namespace
+// of the team labelled with jenkins.io/kind=devpodTemplate
+type DevPodTemplate struct {
+	// Name the name of the template
+	Name string `json:"-"`
+	// PodTemplate the label of the pod template the DevPod is based on
+	PodTemplate string `json:"podTemplate,omitempty"`
+	// Containers additional containers of the DevPod, such as databases
+	Containers []corev1.Container `json:"containers,omitempty"`
+	// Resources the resource requirements of the devpod container
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// DotfilesRepo the URL of a git repository of dotfiles which is cloned into the home directory of the DevPod
+	DotfilesRepo string `json:"dotfilesRepo,omitempty"`
+	// Persist stores the workspace of the DevPod in a PersistentVolumeClaim so that the DevPod can be suspended
+	Persist bool `json:"persist,omitempty"`
+	// IdleTimeout the duration the DevPod can be idle before it is automatically suspended, such as 2h
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+}
+
+// ApplyTo applies the template to the pod of a new DevPod whose first container is the devpod container
+func (t *DevPodTemplate) ApplyTo(pod *corev1.Pod) error {
+	if len(pod.Spec.Containers) == 0 {
+		return fmt.Errorf("no containers in the pod of DevPod template %s", t.Name)
+	}
+	if t.IdleTimeout != "" {
+		_, err := time.ParseDuration(t.IdleTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "invalid idleTimeout %s in DevPod template %s", t.IdleTimeout, t.Name)
+		}
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Labels[LabelDevPodTemplate] = t.Name
+	if t.IdleTimeout != "" {
+		pod.Annotations[AnnotationDevPodIdleTimeout] = t.IdleTimeout
+	}
+	if t.Resources != nil {
+		resources := &pod.Spec.Containers[0].Resources
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		for k, v := range t.Resources.Requests {
+			resources.Requests[k] = v
+		}
+		for k, v := range t.Resources.Limits {
+			resources.Limits[k] = v
+		}
+	}
+	pod.Spec.Containers = append(pod.Spec.Containers, t.Containers...)
+	return nil
+}
+
+// LoadDevPodTemplates loads the DevPod templates of the team from the given namespace
+func LoadDevPodTemplates(kubeClient kubernetes.Interface, ns string) (map[string]*DevPodTemplate, error) {
+	answer := map[string]*DevPodTemplate{}
+	list, err := kubeClient.CoreV1().ConfigMaps(ns).List(metav1.ListOptions{
+		LabelSelector: LabelKind + "=" + ValueKindDevPodTemplate,
+	})
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to list the DevPod template ConfigMaps in namespace %s", ns)
+	}
+	for _, cm := range list.Items {
+		text := cm.Data[DevPodTemplateKey]
+		if text == "" {
+			log.Logger().Warnf("ConfigMap %s does not contain a %s key", cm.Name, DevPodTemplateKey)
+			continue
+		}
+		template := &DevPodTemplate{}
+		err = yaml.Unmarshal([]byte(text), template)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to parse the DevPod template in ConfigMap %s", cm.Name)
+		}
+		template.Name = strings.TrimPrefix(cm.Name, DevPodTemplateConfigMapPrefix)
+		answer[template.Name] = template
+	}
+	return answer, nil
+}
+
+// SuspendedDevPodName returns the name of the ConfigMap which stores the suspended DevPod
+func SuspendedDevPodName(name string) string {
+	return name + SuspendedDevPodSuffix
+}
+
+// GetSuspendedDevPods returns the ConfigMaps of the suspended DevPods of the user indexed by the name of the DevPod.
+// If username is blank the suspended DevPods of all users are returned
+func GetSuspendedDevPods(client kubernetes.Interface, ns string, username string) ([]string, map[string]*corev1.ConfigMap, error) {
+	selector := LabelKind + "=" + ValueKindSuspendedDevPod
+	if username != "" {
+		selector += "," + LabelDevPodUsername + "=" + username
+	}
+	list, err := client.CoreV1().ConfigMaps(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list the suspended DevPods in namespace %s", ns)
+	}
+	names := []string{}
+	m := map[string]*corev1.ConfigMap{}
+	for i := range list.Items {
+		cm := &list.Items[i]
+		name := cm.Labels[LabelDevPodName]
+		if name == "" {
+			continue
+		}
+		m[name] = cm
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, m, nil
+}
+
+// DevPodWorkspaceClaims returns the names of the PersistentVolumeClaims mounted by the DevPod
+func DevPodWorkspaceClaims(pod *corev1.Pod) []string {
+	var answer []string
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			answer = append(answer, v.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return answer
+}
+
+// SuspendDevPod suspends the DevPod by deleting its pod while keeping its workspace PersistentVolumeClaim and services.
+// The pod is stored in a ConfigMap which owns the PersistentVolumeClaim and services until the DevPod is resumed.
+// Returns the ConfigMap
+func SuspendDevPod(client kubernetes.Interface, ns string, name string) (*corev1.ConfigMap, error) {
+	pod, err := client.CoreV1().Pods(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get DevPod %s in namespace %s", name, ns)
+	}
+	if pod.Labels[LabelDevPodName] == "" {
+		return nil, fmt.Errorf("pod %s is not a DevPod", name)
+	}
+	if len(DevPodWorkspaceClaims(pod)) == 0 {
+		return nil, fmt.Errorf("DevPod %s cannot be suspended without losing its workspace as it has no PersistentVolumeClaim. Create the DevPod with --persist", name)
+	}
+
+	data, err := yaml.Marshal(devPodForResume(pod))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal DevPod %s to YAML", name)
+	}
+	labels := map[string]string{
+		LabelKind:       ValueKindSuspendedDevPod,
+		LabelDevPodName: name,
+	}
+	for _, k := range []string{LabelDevPodUsername, LabelPodTemplate, LabelDevPodTemplate} {
+		if v := pod.Labels[k]; v != "" {
+			labels[k] = v
+		}
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   SuspendedDevPodName(name),
+			Labels: labels,
+			Annotations: map[string]string{
+				AnnotationDevPodSuspended: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string]string{
+			SuspendedDevPodKey: string(data),
+		},
+	}
+	cm, err = client.CoreV1().ConfigMaps(ns).Create(cm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create ConfigMap %s for the suspended DevPod", SuspendedDevPodName(name))
+	}
+
+	err = transferOwnership(client, ns, pod.UID, ConfigMapOwnerRef(cm))
+	if err != nil {
+		return cm, err
+	}
+	err = client.CoreV1().Pods(ns).Delete(name, &metav1.DeleteOptions{})
+	if err != nil {
+		return cm, errors.Wrapf(err, "failed to delete the pod of DevPod %s", name)
+	}
+	return cm, nil
+}
+
+// ResumeDevPod recreates the pod of a suspended DevPod which then owns the workspace PersistentVolumeClaim and services
+// again. Returns the new pod
+func ResumeDevPod(client kubernetes.Interface, ns string, name string) (*corev1.Pod, error) {
+	cmName := SuspendedDevPodName(name)
+	cm, err := client.CoreV1().ConfigMaps(ns).Get(cmName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find suspended DevPod %s in namespace %s", name, ns)
+	}
+	pod := &corev1.Pod{}
+	err = yaml.Unmarshal([]byte(cm.Data[SuspendedDevPodKey]), pod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the pod of suspended DevPod %s", name)
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[AnnotationDevPodLastActive] = time.Now().UTC().Format(time.RFC3339)
+	pod, err = client.CoreV1().Pods(ns).Create(pod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the pod of DevPod %s", name)
+	}
+	err = transferOwnership(client, ns, cm.UID, PodOwnerRef(pod))
+	if err != nil {
+		return pod, err
+	}
+	err = client.CoreV1().ConfigMaps(ns).Delete(cmName, &metav1.DeleteOptions{})
+	if err != nil {
+		return pod, errors.Wrapf(err, "failed to delete ConfigMap %s of the suspended DevPod", cmName)
+	}
+	return pod, nil
+}
+
+// DevPodLastActive returns the time the DevPod was last active, defaulting to when it started
+func DevPodLastActive(pod *corev1.Pod) time.Time {
+	if text := pod.Annotations[AnnotationDevPodLastActive]; text != "" {
+		t, err := time.Parse(time.RFC3339, text)
+		if err == nil {
+			return t
+		}
+	}
+	if pod.Status.StartTime != nil {
+		return pod.Status.StartTime.Time
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// DevPodIdleTimeout returns the idle timeout of the DevPod, defaulting to the given timeout if it has none
+func DevPodIdleTimeout(pod *corev1.Pod, defaultTimeout time.Duration) time.Duration {
+	if text := pod.Annotations[AnnotationDevPodIdleTimeout]; text != "" {
+		d, err := time.ParseDuration(text)
+		if err == nil {
+			return d
+		}
+		log.Logger().Warnf("ignoring invalid idle timeout %s of DevPod %s: %s", text, pod.Name, err)
+	}
+	return defaultTimeout
+}
+
+// IsDevPodIdle returns true if the DevPod has not been active for longer than its idle timeout. A zero timeout means
+// the DevPod is never idle
+func IsDevPodIdle(pod *corev1.Pod, now time.Time, defaultTimeout time.Duration) bool {
+	timeout := DevPodIdleTimeout(pod, defaultTimeout)
+	if timeout <= 0 {
+		return false
+	}
+	return now.Sub(DevPodLastActive(pod)) > timeout
+}
+
+// MarkDevPodActive records that the DevPod was active at the given time
+func MarkDevPodActive(client kubernetes.Interface, ns string, pod *corev1.Pod, now time.Time) (*corev1.Pod, error) {
+	copy := pod.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[AnnotationDevPodLastActive] = now.UTC().Format(time.RFC3339)
+	answer, err := client.CoreV1().Pods(ns).Update(copy)
+	if err != nil {
+		return pod, errors.Wrapf(err, "failed to annotate DevPod %s as active", pod.Name)
+	}
+	return answer, nil
+}
+
+// devPodForResume returns a copy of the pod of the DevPod which can be created again
+func devPodForResume(pod *corev1.Pod) *corev1.Pod {
+	copy := pod.DeepCopy()
+	copy.ObjectMeta = metav1.ObjectMeta{
+		Name:        pod.Name,
+		Labels:      util.MergeMaps(pod.Labels),
+		Annotations: util.MergeMaps(pod.Annotations),
+	}
+	copy.Status = corev1.PodStatus{}
+	copy.Spec.NodeName = ""
+
+	// the service account token volume is added again when the pod is created
+	tokenVolumes := map[string]bool{}
+	for i := range copy.Spec.Containers {
+		c := &copy.Spec.Containers[i]
+		var mounts []corev1.VolumeMount
+		for _, m := range c.VolumeMounts {
+			if m.MountPath == serviceAccountMountPath {
+				tokenVolumes[m.Name] = true
+				continue
+			}
+			mounts = append(mounts, m)
+		}
+		c.VolumeMounts = mounts
+	}
+	var volumes []corev1.Volume
+	for _, v := range copy.Spec.Volumes {
+		if !tokenVolumes[v.Name] {
+			volumes = append(volumes, v)
+		}
+	}
+	copy.Spec.Volumes = volumes
+	return copy
+}
+
+// transferOwnership replaces the owner with the given UID of the PersistentVolumeClaims and Services in the namespace
+// with the new owner
+func transferOwnership(client kubernetes.Interface, ns string, uid types.UID, owner metav1.OwnerReference) error {
+	replace := func(refs []metav1.OwnerReference) ([]metav1.OwnerReference, bool) {
+		for i, ref := range refs {
+			if ref.UID == uid {
+				answer := append([]metav1.OwnerReference{}, refs...)
+				answer[i] = owner
+				return answer, true
+			}
+		}
+		return refs, false
+	}
+
+	pvcs, err := client.CoreV1().PersistentVolumeClaims(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PersistentVolumeClaims in namespace %s", ns)
+	}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		refs, changed := replace(pvc.OwnerReferences)
+		if !changed {
+			continue
+		}
+		pvc.OwnerReferences = refs
+		_, err = client.CoreV1().PersistentVolumeClaims(ns).Update(pvc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to change the owner of PersistentVolumeClaim %s to %s %s", pvc.Name, owner.Kind, owner.Name)
+		}
+	}
+
+	services, err := client.CoreV1().Services(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list Services in namespace %s", ns)
+	}
+	for i := range services.Items {
+		svc := &services.Items[i]
+		refs, changed := replace(svc.OwnerReferences)
+		if !changed {
+			continue
+		}
+		svc.OwnerReferences = refs
+		_, err = client.CoreV1().Services(ns).Update(svc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to change the owner of Service %s to %s %s", svc.Name, owner.Kind, owner.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// +build unit
+
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const devPodNs = "jx"
+
+func newDevPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: devPodNs,
+			UID:       "pod-uid",
+			Labels: map[string]string{
+				kube.LabelDevPodName:     name,
+				kube.LabelDevPodUsername: "jstrachan",
+				kube.LabelPodTemplate:    "maven",
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{
+				{
+					Name:  "devpod",
+					Image: "maven",
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "ws-volume", MountPath: "/workspace"},
+						{Name: "default-token-abc", MountPath: "/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "ws-volume",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name + "-pvc"},
+					},
+				},
+				{
+					Name: "default-token-abc",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{SecretName: "default-token-abc"},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestLoadDevPodTemplates(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kube.DevPodTemplateConfigMapPrefix + "backend",
+			Namespace: devPodNs,
+			Labels:    map[string]string{kube.LabelKind: kube.ValueKindDevPodTemplate},
+		},
+		Data: map[string]string{
+			kube.DevPodTemplateKey: `podTemplate: maven
+persist: true
+idleTimeout: 30m
+dotfilesRepo: https://github.com/jstrachan/dotfiles.git
+resources:
+  requests:
+    cpu: "2"
+  limits:
+    memory: 4Gi
+containers:
+- name: postgres
+  image: postgres:12
+`,
+		},
+	})
+
+	templates, err := kube.LoadDevPodTemplates(client, devPodNs)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	template := templates["backend"]
+	require.NotNil(t, template)
+	assert.Equal(t, "backend", template.Name)
+	assert.Equal(t, "maven", template.PodTemplate)
+	assert.True(t, template.Persist)
+	assert.Equal(t, "https://github.com/jstrachan/dotfiles.git", template.DotfilesRepo)
+
+	pod := newDevPod("jstrachan-maven")
+	pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+	err = template.ApplyTo(pod)
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.Containers, 2)
+	assert.Equal(t, "postgres", pod.Spec.Containers[1].Name)
+	resources := pod.Spec.Containers[0].Resources
+	assert.Equal(t, "2", resources.Requests.Cpu().String())
+	assert.Equal(t, "512Mi", resources.Requests.Memory().String())
+	assert.Equal(t, "4Gi", resources.Limits.Memory().String())
+	assert.Equal(t, "backend", pod.Labels[kube.LabelDevPodTemplate])
+	assert.Equal(t, "30m", pod.Annotations[kube.AnnotationDevPodIdleTimeout])
+}
+
+func TestSuspendAndResumeDevPod(t *testing.T) {
+	t.Parallel()
+
+	name := "jstrachan-maven"
+	pod := newDevPod(name)
+	owner := []metav1.OwnerReference{kube.PodOwnerRef(pod)}
+	client := fake.NewSimpleClientset(
+		pod,
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-pvc", Namespace: devPodNs, OwnerReferences: owner},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-ide", Namespace: devPodNs, OwnerReferences: owner},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: devPodNs},
+		},
+	)
+
+	cm, err := kube.SuspendDevPod(client, devPodNs, name)
+	require.NoError(t, err)
+	assert.Equal(t, kube.SuspendedDevPodName(name), cm.Name)
+
+	_, err = client.CoreV1().Pods(devPodNs).Get(name, metav1.GetOptions{})
+	assert.Error(t, err, "the pod should be deleted")
+
+	pvc, err := client.CoreV1().PersistentVolumeClaims(devPodNs).Get(name+"-pvc", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, pvc.OwnerReferences, 1)
+	assert.Equal(t, "ConfigMap", pvc.OwnerReferences[0].Kind)
+	svc, err := client.CoreV1().Services(devPodNs).Get(name+"-ide", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ConfigMap", svc.OwnerReferences[0].Kind)
+	other, err := client.CoreV1().Services(devPodNs).Get("other", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, other.OwnerReferences)
+
+	names, suspended, err := kube.GetSuspendedDevPods(client, devPodNs, "jstrachan")
+	require.NoError(t, err)
+	assert.Equal(t, []string{name}, names)
+	assert.Equal(t, "maven", suspended[name].Labels[kube.LabelPodTemplate])
+
+	resumed, err := kube.ResumeDevPod(client, devPodNs, name)
+	require.NoError(t, err)
+	assert.Equal(t, name, resumed.Name)
+	assert.Equal(t, "", resumed.Spec.NodeName)
+	assert.Equal(t, []string{name + "-pvc"}, kube.DevPodWorkspaceClaims(resumed))
+	assert.Len(t, resumed.Spec.Volumes, 1, "the service account token volume should be removed")
+	assert.Len(t, resumed.Spec.Containers[0].VolumeMounts, 1)
+	assert.NotEmpty(t, resumed.Annotations[kube.AnnotationDevPodLastActive])
+
+	pvc, err = client.CoreV1().PersistentVolumeClaims(devPodNs).Get(name+"-pvc", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Pod", pvc.OwnerReferences[0].Kind)
+	_, err = client.CoreV1().ConfigMaps(devPodNs).Get(kube.SuspendedDevPodName(name), metav1.GetOptions{})
+	assert.Error(t, err, "the ConfigMap of the suspended DevPod should be deleted")
+}
+
+func TestSuspendDevPodWithoutPersistentWorkspace(t *testing.T) {
+	t.Parallel()
+
+	pod := newDevPod("jstrachan-go")
+	pod.Spec.Volumes = nil
+	client := fake.NewSimpleClientset(pod)
+
+	_, err := kube.SuspendDevPod(client, devPodNs, pod.Name)
+	assert.Error(t, err)
+
+	_, err = client.CoreV1().Pods(devPodNs).Get(pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err, "the pod should not be deleted")
+}
+
+func TestIsDevPodIdle(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	pod := newDevPod("jstrachan-maven")
+	pod.Status.StartTime = &metav1.Time{Time: now.Add(-3 * time.Hour)}
+
+	assert.True(t, kube.IsDevPodIdle(pod, now, 2*time.Hour))
+	assert.False(t, kube.IsDevPodIdle(pod, now, 0), "a zero timeout should never be idle")
+
+	pod.Annotations = map[string]string{kube.AnnotationDevPodLastActive: now.Add(-time.Hour).Format(time.RFC3339)}
+	assert.False(t, kube.IsDevPodIdle(pod, now, 2*time.Hour))
+
+	pod.Annotations[kube.AnnotationDevPodIdleTimeout] = "30m"
+	assert.True(t, kube.IsDevPodIdle(pod, now, 2*time.Hour))
+	assert.True(t, kube.IsDevPodIdle(pod, now, 0), "the idle timeout of the DevPod template should be used")
+}
@@ -67,6 +67,16 @@ func (m *LogMasker) LoadSecret(secret *corev1.Secret) {
 	}
 }
 
+// LoadWord adds a word such as the value of a password flag to be masked out
+func (m *LogMasker) LoadWord(word string) {
+	if m.ReplaceWords == nil {
+		m.ReplaceWords = map[string]string{}
+	}
+	if word != "" {
+		m.ReplaceWords[word] = m.replaceValue(word)
+	}
+}
+
 // MaskLog returns the text with all of the secrets masked out
 func (m *LogMasker) MaskLog(text string) string {
 	answer := text
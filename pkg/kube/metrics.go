@@ -1,91 +1,315 @@
 package kube
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
 	"github.com/jenkins-x/jx/v2/pkg/util"
-	"k8s.io/client-go/kubernetes"
-
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
-type HeapterConfig struct {
-	KubeClient        kubernetes.Interface
-	HeapsterNamespace string
-	HeapsterScheme    string
-	HeapsterPort      string
-	HeapsterService   string
-	checkedForService bool
-}
-
-func (q *HeapterConfig) GetPodMetrics(ns string, pod string, selector string, metric string, start string, end string) ([]byte, error) {
-	kubeClient := q.KubeClient
-	heapsterNamespace := util.FirstNotEmptyString(q.HeapsterNamespace, "kube-system")
-	heapsterScheme := util.FirstNotEmptyString(q.HeapsterScheme, "http")
-	heapsterService := util.FirstNotEmptyString(q.HeapsterService, "heapster")
-	heapsterPort := util.FirstNotEmptyString(q.HeapsterPort, "80")
-
-	if !q.checkedForService {
-		svc, err := kubeClient.CoreV1().Services(heapsterNamespace).Get(heapsterService, metav1.GetOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("Could not find heapster service %s in namespace %s: %s", heapsterService, heapsterNamespace, err)
+const (
+	// MetricsSourceMetricsServer the metrics were sampled from the metrics.k8s.io API
+	MetricsSourceMetricsServer = "metrics-server"
+	// MetricsSourcePrometheus the metrics were queried from Prometheus
+	MetricsSourcePrometheus = "prometheus"
+)
+
+// MetricsSeries the values of a metric sampled at regular intervals
+type MetricsSeries struct {
+	Values []float64
+}
+
+// Min returns the minimum value of the series
+func (s *MetricsSeries) Min() float64 {
+	return s.reduce(math.Min)
+}
+
+// Max returns the maximum value of the series
+func (s *MetricsSeries) Max() float64 {
+	return s.reduce(math.Max)
+}
+
+// Average returns the mean value of the series
+func (s *MetricsSeries) Average() float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range s.Values {
+		total += v
+	}
+	return total / float64(len(s.Values))
+}
+
+// Percentile returns the value of the series which the given percentage of values are less than or equal to
+func (s *MetricsSeries) Percentile(percent float64) float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	values := append([]float64{}, s.Values...)
+	sort.Float64s(values)
+	idx := int(math.Ceil(percent/100*float64(len(values)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return values[idx]
+}
+
+func (s *MetricsSeries) reduce(fn func(float64, float64) float64) float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	answer := s.Values[0]
+	for _, v := range s.Values[1:] {
+		answer = fn(answer, v)
+	}
+	return answer
+}
+
+// DeploymentMetrics the resource usage of the pods of a Deployment over a period of time
+type DeploymentMetrics struct {
+	Name      string
+	Namespace string
+	Source    string
+
+	// CPU the CPU usage in cores of the busiest pod
+	CPU MetricsSeries
+	// Memory the working set memory in bytes of the busiest pod
+	Memory MetricsSeries
+	// Restarts the total number of container restarts of the pods
+	Restarts MetricsSeries
+
+	// Requests the resource requests of each pod
+	Requests v1.ResourceList
+	// Limits the resource limits of each pod
+	Limits v1.ResourceList
+}
+
+// RecommendedRequests returns right sized CPU and memory requests for the pods of the Deployment. The CPU request is
+// based on the 95th percentile of the CPU usage and the memory request on the maximum memory usage, both plus the
+// given percentage of headroom
+func (m *DeploymentMetrics) RecommendedRequests(headroomPercent int) v1.ResourceList {
+	factor := 1 + float64(headroomPercent)/100
+	answer := v1.ResourceList{}
+	if len(m.CPU.Values) > 0 {
+		millis := int64(math.Ceil(m.CPU.Percentile(95) * 1000 * factor))
+		if millis < 10 {
+			millis = 10
 		}
-		q.checkedForService = true
-		// lets check the port is OK?
-		found := false
-		ports := []string{}
-		if svc.Spec.Ports != nil {
-			for _, p := range svc.Spec.Ports {
-				i := p.Port
-				if i > 0 {
-					t := util.Int32ToA(i)
-					ports = append(ports, t)
-					if !found {
-						if q.HeapsterPort == "" {
-							q.HeapsterPort = t
-							found = true
-						}
-						if q.HeapsterPort == t {
-							found = true
-						}
-					}
-				}
-			}
+		answer[v1.ResourceCPU] = resource.MustParse(fmt.Sprintf("%dm", millis))
+	}
+	if len(m.Memory.Values) > 0 {
+		mebibytes := int64(math.Ceil(m.Memory.Max() * factor / (1024 * 1024)))
+		if mebibytes < 16 {
+			mebibytes = 16
 		}
-		if !found {
-			if q.HeapsterPort == "" {
-				return nil, fmt.Errorf("Heapster service %s in namespace %s is not listing on a port", heapsterService, heapsterNamespace)
-			} else {
-				return nil, util.InvalidOption("heapster-port", q.HeapsterPort, ports)
+		answer[v1.ResourceMemory] = resource.MustParse(fmt.Sprintf("%dMi", mebibytes))
+	}
+	return answer
+}
+
+// GetDeploymentMetrics returns the metrics of the pods of the Deployment over the given duration. If Prometheus is
+// available it is queried for the duration at the given step. Otherwise, as the metrics.k8s.io API only has the
+// current usage, a duration is an error. A zero duration returns the current metrics
+func GetDeploymentMetrics(kubeClient kubernetes.Interface, metricsClient metricsclient.Interface, prometheus *PrometheusConfig, ns string, name string, duration time.Duration, step time.Duration) (*DeploymentMetrics, error) {
+	d, err := kubeClient.AppsV1().Deployments(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get Deployment %s in namespace %s", name, ns)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the selector of Deployment %s", name)
+	}
+	requests, limits := PodRequestsAndLimits(&v1.Pod{Spec: d.Spec.Template.Spec})
+	answer := &DeploymentMetrics{
+		Name:      name,
+		Namespace: ns,
+		Requests:  requests,
+		Limits:    limits,
+	}
+
+	if prometheus != nil && duration > 0 && prometheus.IsAvailable() {
+		answer.Source = MetricsSourcePrometheus
+		end := time.Now()
+		start := end.Add(-duration)
+		podRegex := strings.Replace(name, ".", `\\.`, -1) + "-[a-z0-9]+-[a-z0-9]+"
+		filter := fmt.Sprintf(`namespace="%s",pod=~"%s"`, ns, podRegex)
+		queries := map[*MetricsSeries]string{
+			&answer.CPU:      fmt.Sprintf(`max(sum by (pod) (rate(container_cpu_usage_seconds_total{%s,container!="",container!="POD"}[5m])))`, filter),
+			&answer.Memory:   fmt.Sprintf(`max(sum by (pod) (container_memory_working_set_bytes{%s,container!="",container!="POD"}))`, filter),
+			&answer.Restarts: fmt.Sprintf(`sum(kube_pod_container_status_restarts_total{%s})`, filter),
+		}
+		for series, query := range queries {
+			series.Values, err = prometheus.QueryRange(query, start, end, step)
+			if err != nil {
+				return nil, err
 			}
 		}
+		return answer, nil
 	}
 
-	path := util.UrlJoin("/apis/metrics/v1alpha1/namespaces/", ns, "/pods")
-	if pod != "" {
-		path = util.UrlJoin(path, pod)
-		if metric != "" {
-			path = util.UrlJoin(path, "metrics", metric)
-		}
+	if duration > 0 {
+		return nil, fmt.Errorf("the metrics of Deployment %s over %s require Prometheus as metrics-server only has the current usage, install the Prometheus addon or omit the duration", name, duration.String())
 	}
-	params := map[string]string{}
-	if selector != "" {
-		params["labelSelector"] = selector
+	answer.Source = MetricsSourceMetricsServer
+	cpu, memory, err := PodMetricsUsage(metricsClient, ns, selector.String())
+	if err != nil {
+		return nil, err
 	}
-	if start != "" {
-		params["start"] = start
+	pods, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list pods of Deployment %s", name)
 	}
-	if end != "" {
-		params["end"] = end
+	answer.CPU.Values = []float64{cpu}
+	answer.Memory.Values = []float64{memory}
+	answer.Restarts.Values = []float64{float64(PodRestarts(pods.Items))}
+	return answer, nil
+}
+
+// PodMetricsUsage returns the CPU usage in cores and memory usage in bytes of the busiest pod matching the selector
+// from the metrics.k8s.io API
+func PodMetricsUsage(metricsClient metricsclient.Interface, ns string, selector string) (float64, float64, error) {
+	list, err := metricsClient.MetricsV1beta1().PodMetricses(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to query the metrics.k8s.io API for pods in namespace %s. Is metrics-server installed?", ns)
+	}
+	cpu, memory := 0.0, 0.0
+	for _, podMetrics := range list.Items {
+		var podMillis, podBytes int64
+		for _, c := range podMetrics.Containers {
+			podMillis += c.Usage.Cpu().MilliValue()
+			podBytes += c.Usage.Memory().Value()
+		}
+		cpu = math.Max(cpu, float64(podMillis)/1000)
+		memory = math.Max(memory, float64(podBytes))
+	}
+	return cpu, memory, nil
+}
+
+// PodRestarts returns the total number of container restarts of the pods
+func PodRestarts(pods []v1.Pod) int32 {
+	var answer int32
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			answer += status.RestartCount
+		}
 	}
-	log.Logger().Infof("Querying %s using query parameters: %#v", path, params)
-	resp := kubeClient.CoreV1().Services(heapsterNamespace).ProxyGet(heapsterScheme, heapsterService, heapsterPort, path, params)
-	return resp.DoRaw()
+	return answer
 }
 
-func GetPodMetrics(client *metricsclient.Clientset, ns string) (*metricsv1beta1.PodMetricsList, error) {
+// GetPodMetrics returns the metrics of the pods in the namespace from the metrics.k8s.io API
+func GetPodMetrics(client metricsclient.Interface, ns string) (*metricsv1beta1.PodMetricsList, error) {
 	return client.MetricsV1beta1().PodMetricses(ns).List(metav1.ListOptions{})
 }
+
+// PrometheusConfig the location of the Prometheus server installed by the Prometheus addon, which is queried via the
+// service proxy of the Kubernetes API server
+type PrometheusConfig struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+	Scheme     string
+	Service    string
+	Port       string
+}
+
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+	ErrorType string `json:"errorType"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// IsAvailable returns true if the Prometheus service exists
+func (p *PrometheusConfig) IsAvailable() bool {
+	_, err := p.KubeClient.CoreV1().Services(p.namespace()).Get(p.service(), metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Debugf("Prometheus service %s not found in namespace %s: %s", p.service(), p.namespace(), err)
+		return false
+	}
+	return true
+}
+
+// QueryRange evaluates the PromQL query over the time range at the given step and returns the values of the first
+// resulting series
+func (p *PrometheusConfig) QueryRange(query string, start time.Time, end time.Time, step time.Duration) ([]float64, error) {
+	if step < time.Second {
+		step = time.Second
+	}
+	params := map[string]string{
+		"query": query,
+		"start": strconv.FormatInt(start.Unix(), 10),
+		"end":   strconv.FormatInt(end.Unix(), 10),
+		"step":  fmt.Sprintf("%ds", int64(step/time.Second)),
+	}
+	log.Logger().Debugf("Querying Prometheus with %s", query)
+	data, err := p.KubeClient.CoreV1().Services(p.namespace()).ProxyGet(p.scheme(), p.service(), p.port(), "/api/v1/query_range", params).DoRaw()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query Prometheus service %s in namespace %s", p.service(), p.namespace())
+	}
+	return parsePrometheusRange(data)
+}
+
+func parsePrometheusRange(data []byte) ([]float64, error) {
+	response := prometheusResponse{}
+	err := json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the Prometheus response")
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("Prometheus query failed with %s: %s", response.ErrorType, response.Error)
+	}
+	if len(response.Data.Result) == 0 {
+		return nil, nil
+	}
+	var answer []float64
+	for _, pair := range response.Data.Result[0].Values {
+		if len(pair) != 2 {
+			continue
+		}
+		text, ok := pair[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+		answer = append(answer, value)
+	}
+	return answer, nil
+}
+
+func (p *PrometheusConfig) namespace() string {
+	return util.FirstNotEmptyString(p.Namespace, DefaultNamespace)
+}
+
+func (p *PrometheusConfig) scheme() string {
+	return util.FirstNotEmptyString(p.Scheme, "http")
+}
+
+func (p *PrometheusConfig) service() string {
+	return util.FirstNotEmptyString(p.Service, "prometheus-server")
+}
+
+func (p *PrometheusConfig) port() string {
+	return util.FirstNotEmptyString(p.Port, "80")
+}
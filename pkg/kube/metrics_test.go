@@ -0,0 +1,105 @@
+// +build unit
+
+package kube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestParsePrometheusRange(t *testing.T) {
+	data := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1435781430.781,"0.5"],[1435781445.781,"NaN"],[1435781460.781,"1.5"]]}]}}`
+	values, err := parsePrometheusRange([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 1.5}, values)
+
+	values, err = parsePrometheusRange([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = parsePrometheusRange([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	assert.Error(t, err)
+}
+
+func TestMetricsSeries(t *testing.T) {
+	series := MetricsSeries{Values: []float64{4, 1, 3, 2, 10}}
+	assert.Equal(t, 1.0, series.Min())
+	assert.Equal(t, 10.0, series.Max())
+	assert.Equal(t, 4.0, series.Average())
+	assert.Equal(t, 4.0, series.Percentile(80))
+	assert.Equal(t, 10.0, series.Percentile(95))
+	assert.Equal(t, 0.0, (&MetricsSeries{}).Max())
+}
+
+func TestRecommendedRequests(t *testing.T) {
+	metrics := &DeploymentMetrics{
+		CPU:    MetricsSeries{Values: []float64{0.1, 0.2, 0.25}},
+		Memory: MetricsSeries{Values: []float64{100 * 1024 * 1024, 200 * 1024 * 1024}},
+	}
+	requests := metrics.RecommendedRequests(20)
+	cpu := requests[v1.ResourceCPU]
+	memory := requests[v1.ResourceMemory]
+	assert.Equal(t, "300m", cpu.String())
+	assert.Equal(t, "240Mi", memory.String())
+
+	assert.Empty(t, (&DeploymentMetrics{}).RecommendedRequests(20))
+}
+
+func TestPodMetricsUsage(t *testing.T) {
+	podMetrics := func(name string, cpu string, memory string) metricsv1beta1.PodMetrics {
+		return metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx", Labels: map[string]string{"app": "myapp"}},
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "app", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)}},
+				{Name: "sidecar", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("10m"), v1.ResourceMemory: resource.MustParse("10Mi")}},
+			},
+		}
+	}
+	metricsClient := metricsfake.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &metricsv1beta1.PodMetricsList{
+			Items: []metricsv1beta1.PodMetrics{
+				podMetrics("myapp-1", "90m", "50Mi"),
+				podMetrics("myapp-2", "40m", "100Mi"),
+			},
+		}, nil
+	})
+
+	cpu, memory, err := PodMetricsUsage(metricsClient, "jx", "app=myapp")
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, cpu)
+	assert.Equal(t, float64(110*1024*1024), memory)
+}
+
+func TestGetDeploymentMetricsWithoutPrometheus(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "jx"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "myapp"}},
+		},
+	}
+	kubeClient := kubefake.NewSimpleClientset(deployment)
+	metricsClient := metricsfake.NewSimpleClientset()
+	prometheus := &PrometheusConfig{KubeClient: kubeClient}
+
+	_, err := GetDeploymentMetrics(kubeClient, metricsClient, prometheus, "jx", "myapp", 24*time.Hour, time.Hour)
+	require.Error(t, err, "a duration should fail fast rather than sample for the duration")
+	assert.Contains(t, err.Error(), "Prometheus")
+
+	metrics, err := GetDeploymentMetrics(kubeClient, metricsClient, prometheus, "jx", "myapp", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, MetricsSourceMetricsServer, metrics.Source)
+	assert.Len(t, metrics.CPU.Values, 1)
+}
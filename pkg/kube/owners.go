@@ -28,6 +28,18 @@ func PodOwnerRef(pod *corev1.Pod) metav1.OwnerReference {
 	}
 }
 
+// ConfigMapOwnerRef returns an owner reference to the ConfigMap
+func ConfigMapOwnerRef(cm *corev1.ConfigMap) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       cm.Name,
+		UID:        cm.UID,
+		Controller: &controller,
+	}
+}
+
 func ExtensionOwnerRef(ext *jenkinsv1.Extension) metav1.OwnerReference {
 	controller := true
 	return metav1.OwnerReference{
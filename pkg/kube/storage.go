@@ -14,12 +14,21 @@ const (
 
 	// ClassificationReports stores test results, coverage & quality reports
 	ClassificationReports = "reports"
+
+	// ClassificationCaches stores the build caches of pipelines
+	ClassificationCaches = "caches"
+
+	// ClassificationDevPods stores the workspace snapshots of DevPods
+	ClassificationDevPods = "devpods"
+
+	// ClassificationAudit stores the audit log of jx commands
+	ClassificationAudit = "audit"
 )
 
 var (
 	// Classifications the common classification names
 	Classifications = []string{
-		ClassificationCoverage, ClassificationTests, ClassificationLogs, ClassificationReports,
+		ClassificationCoverage, ClassificationTests, ClassificationLogs, ClassificationReports, ClassificationCaches, ClassificationDevPods, ClassificationAudit,
 	}
 
 	// ClassificationValues the classification values as a string
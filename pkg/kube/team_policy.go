@@ -0,0 +1,352 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-api/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// TeamPolicyConfigMapPrefix the prefix of the names of the ConfigMaps in the admin namespace which store the
+	// policy of a team
+	TeamPolicyConfigMapPrefix = "jenkins-x-team-policy-"
+
+	// DefaultTeamPolicyConfigMap the name of the ConfigMap in the admin namespace which stores the policy of the teams
+	// which do not have their own policy
+	DefaultTeamPolicyConfigMap = "jenkins-x-team-policy"
+
+	// TeamPolicyKey the key of the team policy YAML in its ConfigMap
+	TeamPolicyKey = "policy"
+
+	// TeamResourceQuotaName the name of the ResourceQuota created in the namespace of a team from its policy
+	TeamResourceQuotaName = "jx-team-quota"
+
+	// TeamLimitRangeName the name of the LimitRange created in the namespace of a team from its policy
+	TeamLimitRangeName = "jx-team-limits"
+)
+
+const (
+	// TeamPolicyRuleMaxConcurrentBuilds the rule for the maximum number of running builds
+	TeamPolicyRuleMaxConcurrentBuilds = "maxConcurrentBuilds"
+	// TeamPolicyRuleMaxPreviewEnvironments the rule for the maximum number of preview environments
+	TeamPolicyRuleMaxPreviewEnvironments = "maxPreviewEnvironments"
+	// TeamPolicyRuleAllowedImageRegistries the rule for the registries of the images of build pods
+	TeamPolicyRuleAllowedImageRegistries = "allowedImageRegistries"
+	// TeamPolicyRuleRequiredPodLabels the rule for the labels of build pods
+	TeamPolicyRuleRequiredPodLabels = "requiredPodLabels"
+)
+
+// TeamPolicy the governance policy of a team. It is stored in a ConfigMap in the admin namespace, is applied to the
+// namespace of the team and enforced on its builds by the team controller and can be verified in pipelines via
+// 'jx step verify team-policy'
+type TeamPolicy struct {
+	// ResourceQuota the spec of the ResourceQuota of the namespace of the team
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	// LimitRange the spec of the LimitRange of the namespace of the team
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// MaxConcurrentBuilds the maximum number of builds of the team which can run at the same time. Pipelines which are
+	// triggered once the maximum are running are queued until a build completes. Meta pipelines are not counted
+	MaxConcurrentBuilds int `json:"maxConcurrentBuilds,omitempty"`
+	// MaxPreviewEnvironments the maximum number of preview environments of the team
+	MaxPreviewEnvironments int `json:"maxPreviewEnvironments,omitempty"`
+	// AllowedImageRegistries the registries, optionally followed by a repository prefix, which the images of build
+	// pods must come from such as gcr.io/jenkinsxio. The team controller deletes build pods using other images
+	AllowedImageRegistries []string `json:"allowedImageRegistries,omitempty"`
+	// RequiredPodLabels the labels which build pods must have. The team controller deletes build pods without them
+	RequiredPodLabels []string `json:"requiredPodLabels,omitempty"`
+}
+
+// TeamPolicyViolation a violation of a rule of a team policy
+type TeamPolicyViolation struct {
+	Rule    string
+	Message string
+}
+
+func (v TeamPolicyViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// ParseTeamPolicy parses the YAML of a team policy
+func ParseTeamPolicy(data []byte) (*TeamPolicy, error) {
+	policy := &TeamPolicy{}
+	err := yaml.Unmarshal(data, policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the team policy")
+	}
+	return policy, nil
+}
+
+// LoadTeamPolicy loads the policy of the team from the admin namespace falling back to the default team policy.
+// Returns nil if there is no policy
+func LoadTeamPolicy(kubeClient kubernetes.Interface, adminNs string, team string) (*TeamPolicy, error) {
+	for _, name := range []string{TeamPolicyConfigMapPrefix + team, DefaultTeamPolicyConfigMap} {
+		cm, err := kubeClient.CoreV1().ConfigMaps(adminNs).Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load ConfigMap %s in namespace %s", name, adminNs)
+		}
+		policy, err := ParseTeamPolicy([]byte(cm.Data[TeamPolicyKey]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid ConfigMap %s in namespace %s", name, adminNs)
+		}
+		return policy, nil
+	}
+	return nil, nil
+}
+
+// SaveTeamPolicy saves the policy of the team in the admin namespace
+func SaveTeamPolicy(kubeClient kubernetes.Interface, adminNs string, team string, policy *TeamPolicy) error {
+	data, err := yaml.Marshal(policy)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the policy of team %s", team)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   TeamPolicyConfigMapPrefix + team,
+			Labels: map[string]string{LabelKind: ValueKindTeamPolicy, LabelTeam: team},
+		},
+		Data: map[string]string{TeamPolicyKey: string(data)},
+	}
+	_, err = DefaultModifyConfigMap(kubeClient, adminNs, cm.Name, func(existing *corev1.ConfigMap) error {
+		existing.Labels = cm.Labels
+		existing.Data = cm.Data
+		return nil
+	}, cm)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the policy of team %s", team)
+	}
+	return nil
+}
+
+// ApplyTeamPolicy creates or updates the ResourceQuota and LimitRange of the namespace of the team from the policy,
+// deleting them if they are no longer in the policy
+func ApplyTeamPolicy(kubeClient kubernetes.Interface, teamNs string, policy *TeamPolicy) error {
+	quotas := kubeClient.CoreV1().ResourceQuotas(teamNs)
+	existingQuota, err := quotas.Get(TeamResourceQuotaName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get ResourceQuota %s in namespace %s", TeamResourceQuotaName, teamNs)
+	}
+	if apierrors.IsNotFound(err) {
+		existingQuota = nil
+	}
+	switch {
+	case policy.ResourceQuota == nil && existingQuota != nil:
+		err = quotas.Delete(TeamResourceQuotaName, &metav1.DeleteOptions{})
+	case policy.ResourceQuota != nil && existingQuota == nil:
+		_, err = quotas.Create(&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: TeamResourceQuotaName, Labels: map[string]string{LabelKind: ValueKindTeamPolicy}},
+			Spec:       *policy.ResourceQuota,
+		})
+	case policy.ResourceQuota != nil:
+		existingQuota.Spec = *policy.ResourceQuota
+		_, err = quotas.Update(existingQuota)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply ResourceQuota %s in namespace %s", TeamResourceQuotaName, teamNs)
+	}
+
+	limitRanges := kubeClient.CoreV1().LimitRanges(teamNs)
+	existingLimits, err := limitRanges.Get(TeamLimitRangeName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get LimitRange %s in namespace %s", TeamLimitRangeName, teamNs)
+	}
+	if apierrors.IsNotFound(err) {
+		existingLimits = nil
+	}
+	switch {
+	case policy.LimitRange == nil && existingLimits != nil:
+		err = limitRanges.Delete(TeamLimitRangeName, &metav1.DeleteOptions{})
+	case policy.LimitRange != nil && existingLimits == nil:
+		_, err = limitRanges.Create(&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: TeamLimitRangeName, Labels: map[string]string{LabelKind: ValueKindTeamPolicy}},
+			Spec:       *policy.LimitRange,
+		})
+	case policy.LimitRange != nil:
+		existingLimits.Spec = *policy.LimitRange
+		_, err = limitRanges.Update(existingLimits)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply LimitRange %s in namespace %s", TeamLimitRangeName, teamNs)
+	}
+	return nil
+}
+
+// CheckTeamPolicy returns the violations of the policy in the namespace of the team. The image and label rules apply
+// to the build pods of the team which have not completed
+func CheckTeamPolicy(kubeClient kubernetes.Interface, jxClient versioned.Interface, teamNs string, policy *TeamPolicy) ([]TeamPolicyViolation, error) {
+	var answer []TeamPolicyViolation
+	if policy.MaxConcurrentBuilds > 0 {
+		activities, err := jxClient.JenkinsV1().PipelineActivities(teamNs).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the PipelineActivities in namespace %s", teamNs)
+		}
+		running := 0
+		for _, a := range activities.Items {
+			if a.Spec.Status == v1.ActivityStatusTypeRunning {
+				running++
+			}
+		}
+		if running > policy.MaxConcurrentBuilds {
+			answer = append(answer, TeamPolicyViolation{
+				Rule:    TeamPolicyRuleMaxConcurrentBuilds,
+				Message: fmt.Sprintf("%d builds are running but the maximum is %d", running, policy.MaxConcurrentBuilds),
+			})
+		}
+	}
+	if policy.MaxPreviewEnvironments > 0 {
+		envs, err := jxClient.JenkinsV1().Environments(teamNs).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the Environments in namespace %s", teamNs)
+		}
+		previews := 0
+		for i := range envs.Items {
+			if IsPreviewEnvironment(&envs.Items[i]) {
+				previews++
+			}
+		}
+		if previews > policy.MaxPreviewEnvironments {
+			answer = append(answer, TeamPolicyViolation{
+				Rule:    TeamPolicyRuleMaxPreviewEnvironments,
+				Message: fmt.Sprintf("there are %d preview environments but the maximum is %d", previews, policy.MaxPreviewEnvironments),
+			})
+		}
+	}
+	pods, err := activeBuildPods(kubeClient, teamNs, policy)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		answer = append(answer, checkTeamPolicyPod(pod, policy)...)
+	}
+	return answer, nil
+}
+
+// EnforceTeamPolicyPods deletes the build pods of the team which violate the image or label rules of the policy so that
+// their builds fail. Returns the violations of the deleted pods
+func EnforceTeamPolicyPods(kubeClient kubernetes.Interface, teamNs string, policy *TeamPolicy) ([]TeamPolicyViolation, error) {
+	pods, err := activeBuildPods(kubeClient, teamNs, policy)
+	if err != nil {
+		return nil, err
+	}
+	var answer []TeamPolicyViolation
+	for _, pod := range pods {
+		violations, err := EnforceTeamPolicyPod(kubeClient, pod, policy)
+		answer = append(answer, violations...)
+		if err != nil {
+			return answer, err
+		}
+	}
+	return answer, nil
+}
+
+// EnforceTeamPolicyPod deletes the build pod if it has not completed and violates the image or label rules of the
+// policy so that its build fails. Returns the violations of the pod
+func EnforceTeamPolicyPod(kubeClient kubernetes.Interface, pod *corev1.Pod, policy *TeamPolicy) ([]TeamPolicyViolation, error) {
+	if !isActivePod(pod) {
+		return nil, nil
+	}
+	violations := checkTeamPolicyPod(pod, policy)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+	err := kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return violations, errors.Wrapf(err, "failed to delete pod %s in namespace %s which violates the team policy", pod.Name, pod.Namespace)
+	}
+	return violations, nil
+}
+
+// activeBuildPods returns the build pods of the team which have not completed, sorted by name, if the policy has any
+// image or label rules
+func activeBuildPods(kubeClient kubernetes.Interface, teamNs string, policy *TeamPolicy) ([]*corev1.Pod, error) {
+	if len(policy.AllowedImageRegistries) == 0 && len(policy.RequiredPodLabels) == 0 {
+		return nil, nil
+	}
+	pods, err := kubeClient.CoreV1().Pods(teamNs).List(metav1.ListOptions{LabelSelector: v1.LabelBuild})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the build pods in namespace %s", teamNs)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	var answer []*corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if isActivePod(pod) {
+			answer = append(answer, pod)
+		}
+	}
+	return answer, nil
+}
+
+func isActivePod(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+func checkTeamPolicyPod(pod *corev1.Pod, policy *TeamPolicy) []TeamPolicyViolation {
+	var answer []TeamPolicyViolation
+	if len(policy.AllowedImageRegistries) > 0 {
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, c := range containers {
+			if !IsImageAllowed(c.Image, policy.AllowedImageRegistries) {
+				answer = append(answer, TeamPolicyViolation{
+					Rule:    TeamPolicyRuleAllowedImageRegistries,
+					Message: fmt.Sprintf("container %s of pod %s uses image %s which is not from an allowed registry", c.Name, pod.Name, c.Image),
+				})
+			}
+		}
+	}
+	var missing []string
+	for _, label := range policy.RequiredPodLabels {
+		if pod.Labels[label] == "" {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		answer = append(answer, TeamPolicyViolation{
+			Rule:    TeamPolicyRuleRequiredPodLabels,
+			Message: fmt.Sprintf("pod %s is missing the labels %s", pod.Name, strings.Join(missing, ", ")),
+		})
+	}
+	return answer
+}
+
+// IsImageAllowed returns true if the image comes from one of the allowed registries. Images without a registry are
+// treated as coming from docker.io
+func IsImageAllowed(image string, allowedRegistries []string) bool {
+	image = FullyQualifiedImage(image)
+	for _, allowed := range allowedRegistries {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if allowed == "" {
+			continue
+		}
+		if image == allowed || strings.HasPrefix(image, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FullyQualifiedImage returns the image name including the docker.io registry and library repository for images
+// which do not specify them
+func FullyQualifiedImage(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 {
+		return "docker.io/library/" + image
+	}
+	if !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost" {
+		return "docker.io/" + image
+	}
+	return image
+}
@@ -0,0 +1,162 @@
+// +build unit
+
+package kube_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const teamPolicyYaml = `resourceQuota:
+  hard:
+    requests.cpu: "10"
+    pods: "50"
+limitRange:
+  limits:
+  - type: Container
+    defaultRequest:
+      cpu: 100m
+maxConcurrentBuilds: 1
+maxPreviewEnvironments: 1
+allowedImageRegistries:
+- gcr.io/jenkinsxio
+- docker.io/library
+requiredPodLabels:
+- cost-center
+`
+
+func TestLoadAndApplyTeamPolicy(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kube.TeamPolicyConfigMapPrefix + "beta", Namespace: "jx"},
+		Data:       map[string]string{kube.TeamPolicyKey: teamPolicyYaml},
+	})
+
+	policy, err := kube.LoadTeamPolicy(client, "jx", "alpha")
+	require.NoError(t, err)
+	assert.Nil(t, policy, "team alpha has no policy")
+
+	policy, err = kube.LoadTeamPolicy(client, "jx", "beta")
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	assert.Equal(t, 1, policy.MaxConcurrentBuilds)
+
+	err = kube.ApplyTeamPolicy(client, "beta", policy)
+	require.NoError(t, err)
+	quota, err := client.CoreV1().ResourceQuotas("beta").Get(kube.TeamResourceQuotaName, metav1.GetOptions{})
+	require.NoError(t, err)
+	cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]
+	assert.Equal(t, "10", cpu.String())
+	limits, err := client.CoreV1().LimitRanges("beta").Get(kube.TeamLimitRangeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, limits.Spec.Limits, 1)
+
+	policy.LimitRange = nil
+	err = kube.ApplyTeamPolicy(client, "beta", policy)
+	require.NoError(t, err)
+	_, err = client.CoreV1().LimitRanges("beta").Get(kube.TeamLimitRangeName, metav1.GetOptions{})
+	assert.Error(t, err, "the LimitRange should be deleted when it is removed from the policy")
+}
+
+func TestCheckTeamPolicy(t *testing.T) {
+	t.Parallel()
+
+	ns := "beta"
+	policy, err := kube.ParseTeamPolicy([]byte(teamPolicyYaml))
+	require.NoError(t, err)
+
+	buildPod := func(name string, image string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+		labels[v1.LabelBuild] = "1"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "step-build", Image: image}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		buildPod("allowed", "gcr.io/jenkinsxio/builder-go:2.0", map[string]string{"cost-center": "dev"}, corev1.PodRunning),
+		buildPod("library", "maven:3", map[string]string{"cost-center": "dev"}, corev1.PodRunning),
+		buildPod("bad", "quay.io/evil/miner", map[string]string{}, corev1.PodRunning),
+		buildPod("completed", "quay.io/evil/miner", map[string]string{}, corev1.PodSucceeded),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "not-a-build", Namespace: ns}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "quay.io/other/app"}}}},
+	)
+	activity := func(name string, status v1.ActivityStatusType) *v1.PipelineActivity {
+		return &v1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}, Spec: v1.PipelineActivitySpec{Status: status}}
+	}
+	preview := kube.NewPreviewEnvironment("pr-1")
+	preview.Namespace = ns
+	jxClient := jxfake.NewSimpleClientset(
+		activity("a-1", v1.ActivityStatusTypeRunning),
+		activity("a-2", v1.ActivityStatusTypeRunning),
+		activity("a-3", v1.ActivityStatusTypeSucceeded),
+		preview,
+	)
+
+	violations, err := kube.CheckTeamPolicy(kubeClient, jxClient, ns, policy)
+	require.NoError(t, err)
+
+	var rules []string
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	assert.Equal(t, []string{kube.TeamPolicyRuleMaxConcurrentBuilds, kube.TeamPolicyRuleAllowedImageRegistries, kube.TeamPolicyRuleRequiredPodLabels}, rules)
+	assert.Contains(t, violations[1].Message, "quay.io/evil/miner")
+	assert.Contains(t, violations[2].Message, "pod bad")
+}
+
+func TestEnforceTeamPolicyPods(t *testing.T) {
+	t.Parallel()
+
+	ns := "beta"
+	policy, err := kube.ParseTeamPolicy([]byte(teamPolicyYaml))
+	require.NoError(t, err)
+
+	buildPod := func(name string, image string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+		labels[v1.LabelBuild] = "1"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "step-build", Image: image}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		buildPod("allowed", "gcr.io/jenkinsxio/builder-go:2.0", map[string]string{"cost-center": "dev"}, corev1.PodRunning),
+		buildPod("bad-image", "quay.io/evil/miner", map[string]string{"cost-center": "dev"}, corev1.PodPending),
+		buildPod("no-labels", "maven:3", map[string]string{}, corev1.PodRunning),
+		buildPod("completed", "quay.io/evil/miner", map[string]string{}, corev1.PodSucceeded),
+	)
+
+	violations, err := kube.EnforceTeamPolicyPods(kubeClient, ns, policy)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	assert.Equal(t, kube.TeamPolicyRuleAllowedImageRegistries, violations[0].Rule)
+	assert.Equal(t, kube.TeamPolicyRuleRequiredPodLabels, violations[1].Rule)
+
+	pods, err := kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	assert.ElementsMatch(t, []string{"allowed", "completed"}, names, "the pods violating the policy should be deleted")
+}
+
+func TestIsImageAllowed(t *testing.T) {
+	t.Parallel()
+
+	allowed := []string{"gcr.io/jenkinsxio/", "docker.io/library", "localhost:5000"}
+	assert.True(t, kube.IsImageAllowed("gcr.io/jenkinsxio/builder-go:2.0", allowed))
+	assert.True(t, kube.IsImageAllowed("golang:1.14", allowed))
+	assert.True(t, kube.IsImageAllowed("localhost:5000/app", allowed))
+	assert.False(t, kube.IsImageAllowed("gcr.io/jenkinsxio-evil/builder", allowed))
+	assert.False(t, kube.IsImageAllowed("jenkinsxio/builder-go", allowed))
+}
@@ -0,0 +1,263 @@
+package kustomize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// KustomizationFileName the name of the kustomize configuration file
+	KustomizationFileName = "kustomization.yaml"
+	// ProjectDir the directory of a project containing its kustomize base and overlays
+	ProjectDir = "kustomize"
+	// BaseDir the directory of the base resources within the ProjectDir
+	BaseDir = "base"
+	// OverlaysDir the directory of the overlays of each environment within the ProjectDir
+	OverlaysDir = "overlays"
+	// PreviewOverlay the name of the overlay used for preview environments
+	PreviewOverlay = "preview"
+)
+
+// Kustomization is the kustomize configuration of a directory. Only the fields used by Jenkins X are defined, any
+// other fields such as patches, generators or vars are kept in Extra so that saving a loaded file does not drop them
+type Kustomization struct {
+	APIVersion            string            `json:"apiVersion,omitempty"`
+	Kind                  string            `json:"kind,omitempty"`
+	Namespace             string            `json:"namespace,omitempty"`
+	NamePrefix            string            `json:"namePrefix,omitempty"`
+	CommonLabels          map[string]string `json:"commonLabels,omitempty"`
+	Resources             []string          `json:"resources,omitempty"`
+	PatchesStrategicMerge []string          `json:"patchesStrategicMerge,omitempty"`
+	Images                []Image           `json:"images,omitempty"`
+
+	// Extra the fields of the file which are not defined above
+	Extra map[string]interface{} `json:"-"`
+}
+
+// kustomizationFields has the fields of a Kustomization without its JSON methods
+type kustomizationFields Kustomization
+
+// UnmarshalJSON unmarshals the defined fields and keeps any other fields in Extra
+func (k *Kustomization) UnmarshalJSON(data []byte) error {
+	fields := kustomizationFields{}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+	for _, name := range definedFieldNames() {
+		delete(values, name)
+	}
+	*k = Kustomization(fields)
+	if len(values) > 0 {
+		k.Extra = values
+	}
+	return nil
+}
+
+// MarshalJSON marshals the defined fields along with the fields in Extra
+func (k Kustomization) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(kustomizationFields(k))
+	if err != nil || len(k.Extra) == 0 {
+		return data, err
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+	defined := definedFieldNames()
+	for name, value := range k.Extra {
+		if util.StringArrayIndex(defined, name) < 0 {
+			values[name] = value
+		}
+	}
+	return json.Marshal(values)
+}
+
+// definedFieldNames returns the JSON names of the fields defined by Kustomization
+func definedFieldNames() []string {
+	var answer []string
+	t := reflect.TypeOf(kustomizationFields{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			answer = append(answer, name)
+		}
+	}
+	return answer
+}
+
+// Image overrides the name and tag of an image used by the resources
+type Image struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+// NewKustomization creates a new kustomization with the given resources
+func NewKustomization(resources ...string) *Kustomization {
+	return &Kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	}
+}
+
+// OverlayDir returns the directory of the overlay of the environment of the project in the given dir
+func OverlayDir(dir string, environment string) string {
+	return filepath.Join(dir, ProjectDir, OverlaysDir, environment)
+}
+
+// IsKustomizeProject returns true if the project in the given dir is deployed via kustomize
+func IsKustomizeProject(dir string) (bool, error) {
+	return util.FileExists(filepath.Join(dir, ProjectDir, BaseDir, KustomizationFileName))
+}
+
+// LoadKustomization loads the kustomization.yaml file in the given dir
+func LoadKustomization(dir string) (*Kustomization, error) {
+	fileName := filepath.Join(dir, KustomizationFileName)
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", fileName)
+	}
+	k := &Kustomization{}
+	err = yaml.Unmarshal(data, k)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML file %s", fileName)
+	}
+	return k, nil
+}
+
+// SaveKustomization saves the kustomization.yaml file in the given dir
+func SaveKustomization(dir string, k *Kustomization) error {
+	fileName := filepath.Join(dir, KustomizationFileName)
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
+
+// SetImage overrides the image with the given name. The new name is only changed if it is not empty
+func (k *Kustomization) SetImage(name string, newName string, newTag string) {
+	for i := range k.Images {
+		image := &k.Images[i]
+		if image.Name == name {
+			if newName != "" {
+				image.NewName = newName
+			}
+			image.NewTag = newTag
+			image.Digest = ""
+			return
+		}
+	}
+	k.Images = append(k.Images, Image{
+		Name:    name,
+		NewName: newName,
+		NewTag:  newTag,
+	})
+}
+
+// AddResource adds the resource if it is not already present. Returns true if it was added
+func (k *Kustomization) AddResource(resource string) bool {
+	if util.StringArrayIndex(k.Resources, resource) >= 0 {
+		return false
+	}
+	k.Resources = append(k.Resources, resource)
+	return true
+}
+
+// RemoveResource removes the resource. Returns true if it was removed
+func (k *Kustomization) RemoveResource(resource string) bool {
+	idx := util.StringArrayIndex(k.Resources, resource)
+	if idx < 0 {
+		return false
+	}
+	k.Resources = append(k.Resources[:idx], k.Resources[idx+1:]...)
+	return true
+}
+
+// SetEnvironmentAppVersion sets the version of the app in an environment repository whose kustomization.yaml lists
+// a directory for each app. The directory of the app contains a kustomization.yaml which uses the overlay of the
+// environment from the app repository at the release tag of the version and replaces the image named after the app in
+// the base with the full image name, such as 'gcr.io/myorg/myapp', tagged with the version. The sourceURL of the app
+// repository is only required if the app is not in the environment yet
+func SetEnvironmentAppVersion(envDir string, envName string, app string, image string, version string, sourceURL string) error {
+	ref := "v" + version
+	appDir := filepath.Join(envDir, app)
+	exists, err := util.FileExists(filepath.Join(appDir, KustomizationFileName))
+	if err != nil {
+		return err
+	}
+	var k *Kustomization
+	if exists {
+		k, err = LoadKustomization(appDir)
+		if err != nil {
+			return err
+		}
+		for i, resource := range k.Resources {
+			idx := strings.Index(resource, "?ref=")
+			if idx > 0 {
+				k.Resources[i] = resource[:idx] + "?ref=" + ref
+			}
+		}
+	} else {
+		if sourceURL == "" {
+			return fmt.Errorf("cannot add app %s to the environment %s as the URL of its git repository is not known", app, envName)
+		}
+		k = NewKustomization(fmt.Sprintf("%s//%s/%s/%s?ref=%s", strings.TrimSuffix(sourceURL, ".git"), ProjectDir, OverlaysDir, envName, ref))
+		err = os.MkdirAll(appDir, util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create directory %s", appDir)
+		}
+	}
+	k.SetImage(app, image, version)
+	err = SaveKustomization(appDir, k)
+	if err != nil {
+		return err
+	}
+
+	env, err := LoadKustomization(envDir)
+	if err != nil {
+		return err
+	}
+	if env.AddResource(app) {
+		return SaveKustomization(envDir, env)
+	}
+	return nil
+}
+
+// RemoveEnvironmentApp removes the app from an environment repository. Returns true if the app was removed
+func RemoveEnvironmentApp(envDir string, app string) (bool, error) {
+	env, err := LoadKustomization(envDir)
+	if err != nil {
+		return false, err
+	}
+	if !env.RemoveResource(app) {
+		return false, nil
+	}
+	err = os.RemoveAll(filepath.Join(envDir, app))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to remove the directory of app %s", app)
+	}
+	return true, SaveKustomization(envDir, env)
+}
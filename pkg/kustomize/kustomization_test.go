@@ -0,0 +1,149 @@
+// +build unit
+
+package kustomize_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jenkins-x/jx/v2/pkg/kustomize"
+)
+
+func TestGenerateLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-kustomize-layout-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = kustomize.GenerateLayout(dir, kustomize.LayoutOptions{
+		AppName:      "myapp",
+		Environments: []string{"staging", "production"},
+	})
+	require.NoError(t, err)
+
+	isProject, err := kustomize.IsKustomizeProject(dir)
+	require.NoError(t, err)
+	assert.True(t, isProject, "should be a kustomize project")
+
+	base, err := kustomize.LoadKustomization(filepath.Join(dir, kustomize.ProjectDir, kustomize.BaseDir))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployment.yaml", "service.yaml"}, base.Resources)
+
+	for _, env := range []string{"staging", "production", kustomize.PreviewOverlay} {
+		overlay, err := kustomize.LoadKustomization(kustomize.OverlayDir(dir, env))
+		require.NoError(t, err, "loading overlay %s", env)
+		assert.Equal(t, []string{"../../base"}, overlay.Resources, "resources of overlay %s", env)
+		assert.Equal(t, env, overlay.CommonLabels["env"], "env label of overlay %s", env)
+	}
+
+	// lets check existing files are not overwritten
+	deploymentFile := filepath.Join(dir, kustomize.ProjectDir, kustomize.BaseDir, "deployment.yaml")
+	err = ioutil.WriteFile(deploymentFile, []byte("custom"), 0600)
+	require.NoError(t, err)
+	err = kustomize.GenerateLayout(dir, kustomize.LayoutOptions{AppName: "myapp"})
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(deploymentFile)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(data))
+}
+
+func TestKustomizationSetImage(t *testing.T) {
+	k := kustomize.NewKustomization()
+	k.SetImage("myapp", "gcr.io/myorg/myapp", "0.0.1")
+	k.SetImage("myapp", "", "0.0.2")
+	k.SetImage("other", "", "1.0.0")
+
+	assert.Equal(t, []kustomize.Image{
+		{Name: "myapp", NewName: "gcr.io/myorg/myapp", NewTag: "0.0.2"},
+		{Name: "other", NewTag: "1.0.0"},
+	}, k.Images)
+}
+
+func TestSetEnvironmentAppVersion(t *testing.T) {
+	envDir, err := ioutil.TempDir("", "test-kustomize-env-")
+	require.NoError(t, err)
+	defer os.RemoveAll(envDir)
+
+	err = kustomize.SaveKustomization(envDir, kustomize.NewKustomization())
+	require.NoError(t, err)
+
+	err = kustomize.SetEnvironmentAppVersion(envDir, "staging", "myapp", "gcr.io/myorg/myapp", "1.0.0", "")
+	assert.Error(t, err, "should fail to add an app without a source URL")
+
+	err = kustomize.SetEnvironmentAppVersion(envDir, "staging", "myapp", "gcr.io/myorg/myapp", "1.0.0", "https://github.com/myorg/myapp.git")
+	require.NoError(t, err)
+	err = kustomize.SetEnvironmentAppVersion(envDir, "staging", "myapp", "gcr.io/myorg/myapp", "1.0.1", "")
+	require.NoError(t, err)
+
+	env, err := kustomize.LoadKustomization(envDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myapp"}, env.Resources)
+
+	app, err := kustomize.LoadKustomization(filepath.Join(envDir, "myapp"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://github.com/myorg/myapp//kustomize/overlays/staging?ref=v1.0.1"}, app.Resources)
+	assert.Equal(t, []kustomize.Image{{Name: "myapp", NewName: "gcr.io/myorg/myapp", NewTag: "1.0.1"}}, app.Images)
+
+	removed, err := kustomize.RemoveEnvironmentApp(envDir, "myapp")
+	require.NoError(t, err)
+	assert.True(t, removed, "should have removed the app")
+	env, err = kustomize.LoadKustomization(envDir)
+	require.NoError(t, err)
+	assert.Empty(t, env.Resources)
+}
+
+func TestSaveKustomizationKeepsUnknownFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-kustomize-fields-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	text := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: myconfig
+  literals:
+  - FOO=bar
+patches:
+- path: patch.yaml
+  target:
+    kind: Deployment
+vars:
+- name: MY_SERVICE
+  objref:
+    kind: Service
+    name: myapp
+    apiVersion: v1
+`
+	fileName := filepath.Join(dir, kustomize.KustomizationFileName)
+	err = ioutil.WriteFile(fileName, []byte(text), 0600)
+	require.NoError(t, err)
+
+	k, err := kustomize.LoadKustomization(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployment.yaml"}, k.Resources)
+	assert.Contains(t, k.Extra, "configMapGenerator")
+	assert.NotContains(t, k.Extra, "resources")
+
+	k.AddResource("service.yaml")
+	k.SetImage("myapp", "gcr.io/myorg/myapp", "1.0.0")
+	err = kustomize.SaveKustomization(dir, k)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	saved := string(data)
+	for _, expected := range []string{"configMapGenerator:", "- FOO=bar", "patches:", "path: patch.yaml", "vars:", "name: MY_SERVICE", "- service.yaml", "newName: gcr.io/myorg/myapp"} {
+		assert.Contains(t, saved, expected)
+	}
+
+	k, err = kustomize.LoadKustomization(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployment.yaml", "service.yaml"}, k.Resources)
+	assert.Len(t, k.Extra, 3)
+}
@@ -40,6 +40,11 @@ func (k *KustomizeCLI) Version(extraArgs ...string) (string, error) {
 	return extractSemanticVersion(version)
 }
 
+// Build executes the Kustomize build command for the given dir and returns the generated resources
+func (k *KustomizeCLI) Build(dir string) (string, error) {
+	return k.runKustomizeWithOutput("build", dir)
+}
+
 func (k *KustomizeCLI) runKustomizeWithOutput(args ...string) (string, error) {
 	k.Runner.SetArgs(args)
 	return k.Runner.RunWithoutRetry()
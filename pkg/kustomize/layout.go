@@ -0,0 +1,143 @@
+package kustomize
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort the default port of the container of a generated layout
+	DefaultPort = 8080
+
+	deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %[1]s
+  labels:
+    app: %[1]s
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: %[1]s
+  template:
+    metadata:
+      labels:
+        app: %[1]s
+    spec:
+      containers:
+      - name: %[1]s
+        image: %[1]s
+        ports:
+        - containerPort: %[2]d
+        readinessProbe:
+          httpGet:
+            path: /
+            port: %[2]d
+`
+
+	serviceTemplate = `apiVersion: v1
+kind: Service
+metadata:
+  name: %[1]s
+  labels:
+    app: %[1]s
+  annotations:
+    fabric8.io/expose: "true"
+spec:
+  type: ClusterIP
+  selector:
+    app: %[1]s
+  ports:
+  - name: http
+    port: 80
+    targetPort: %[2]d
+`
+)
+
+// LayoutOptions the options for generating the kustomize layout of a project
+type LayoutOptions struct {
+	// AppName the name of the app which is also used as the name of its image in the base
+	AppName string
+	// Port the port of the container
+	Port int
+	// Environments the names of the environments to generate overlays for. An overlay for previews is always generated
+	Environments []string
+}
+
+// GenerateLayout generates the base resources of the app and an overlay for each environment in the kustomize
+// directory of the project. Existing files are not overwritten so that a layout provided by a build pack is kept
+func GenerateLayout(dir string, options LayoutOptions) error {
+	if options.AppName == "" {
+		return errors.New("no app name specified")
+	}
+	port := options.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	baseDir := filepath.Join(dir, ProjectDir, BaseDir)
+	files := map[string]string{
+		"deployment.yaml": fmt.Sprintf(deploymentTemplate, options.AppName, port),
+		"service.yaml":    fmt.Sprintf(serviceTemplate, options.AppName, port),
+	}
+	for name, text := range files {
+		err := writeFileIfMissing(filepath.Join(baseDir, name), []byte(text))
+		if err != nil {
+			return err
+		}
+	}
+	err := saveKustomizationIfMissing(baseDir, NewKustomization("deployment.yaml", "service.yaml"))
+	if err != nil {
+		return err
+	}
+
+	environments := append([]string{}, options.Environments...)
+	if util.StringArrayIndex(environments, PreviewOverlay) < 0 {
+		environments = append(environments, PreviewOverlay)
+	}
+	for _, env := range environments {
+		overlay := NewKustomization(filepath.Join("..", "..", BaseDir))
+		overlay.CommonLabels = map[string]string{
+			"env": env,
+		}
+		err = saveKustomizationIfMissing(OverlayDir(dir, env), overlay)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveKustomizationIfMissing(dir string, k *Kustomization) error {
+	exists, err := util.FileExists(filepath.Join(dir, KustomizationFileName))
+	if err != nil || exists {
+		return err
+	}
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+	return SaveKustomization(dir, k)
+}
+
+func writeFileIfMissing(fileName string, data []byte) error {
+	exists, err := util.FileExists(fileName)
+	if err != nil || exists {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", filepath.Dir(fileName))
+	}
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+	return nil
+}
@@ -32,6 +32,16 @@ type ProjectReport struct {
 	NewContributorMetrics CountMetrics `json:"newContributorMetrics,omitempty"`
 	DeveloperChatMetrics  CountMetrics `json:"developerChatMetrics,omitempty"`
 	UserChatMetrics       CountMetrics `json:"userChatMetrics,omitempty"`
+	DORAMetrics           *DORAMetrics `json:"doraMetrics,omitempty"`
+}
+
+// DORAMetrics the DORA metrics of a team for the period of a report
+type DORAMetrics struct {
+	Deployments          int     `json:"deployments,omitempty"`
+	DeploymentsPerDay    float64 `json:"deploymentsPerDay,omitempty"`
+	LeadTimeMinutes      float64 `json:"leadTimeMinutes,omitempty"`
+	ChangeFailureRate    float64 `json:"changeFailureRate,omitempty"`
+	TimeToRestoreMinutes float64 `json:"timeToRestoreMinutes,omitempty"`
 }
 
 func (h *ProjectHistory) GetOrCreateReport(reportDate string) *ProjectReport {
@@ -131,6 +141,13 @@ func (h *ProjectHistory) UserChatMetrics(reportDate string, total int) *ProjectR
 	return report
 }
 
+// UpdateDORAMetrics stores the DORA metrics in the report for the given date
+func (h *ProjectHistory) UpdateDORAMetrics(reportDate string, metrics DORAMetrics) *ProjectReport {
+	report := h.GetOrCreateReport(reportDate)
+	report.DORAMetrics = &metrics
+	return report
+}
+
 // addMetricCount adds a new metric value, such as number of commits in a release
 func addMetricCount(current *CountMetrics, previous *CountMetrics, total int) {
 	current.Count = total
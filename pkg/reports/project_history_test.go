@@ -37,4 +37,7 @@ func TestProjectHistory(t *testing.T) {
 	assert.Equal(t, 20, report.IssueMetrics.Count, "report.IssueMetrics.Count")
 	assert.Equal(t, 32, report.IssueMetrics.Total, "report.IssueMetrics.Total")
 
+	report = history.UpdateDORAMetrics(reportDate, reports.DORAMetrics{Deployments: 12, ChangeFailureRate: 25})
+	assert.Equal(t, 12, report.DORAMetrics.Deployments, "report.DORAMetrics.Deployments")
+	assert.Equal(t, 2, len(history.Reports), "len(history.Reports)")
 }
@@ -0,0 +1,136 @@
+package semrel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/pkg/errors"
+)
+
+// GetNewChannelVersion calculates the next version of the component for a release channel such as beta or rc.
+// The version is incremented from the latest final release using the conventional commits up to endSha
+// and is raised to the version of any pre-release which has been merged into endSha so that merging a
+// release branch promotes its pre-releases. If the channel is empty the final version is returned,
+// otherwise the next pre-release of the channel, e.g. 1.3.0-rc.5.
+// The previous release of the channel is returned along with the new version, it is nil if there is none
+func GetNewChannelVersion(dir string, endSha string, gitter gits.Gitter, component *Component, channel string) (*semver.Version, *ComponentRelease, error) {
+	releases, err := FindComponentReleases(dir, gitter, component)
+	if err != nil {
+		return nil, nil, err
+	}
+	latest := LatestFinalRelease(releases, nil)
+	base := release{}
+	if latest != nil {
+		base.SHA = latest.SHA
+		base.Version = latest.Version
+	} else {
+		base.SHA, err = gitter.GetFirstCommitSha(dir)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "getting the first commit")
+		}
+		base.Version, _ = semver.NewVersion("0.0.0")
+	}
+
+	rawCommits, err := ComponentCommits(dir, gitter, component, base.SHA, endSha)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Logger().Debugf("got %d commits for component %s since %s", len(rawCommits), component.Name, base.Version)
+	commits := make([]*conventionalCommit, 0)
+	for _, c := range rawCommits {
+		commit := c
+		commits = append(commits, parseCommit(&commit))
+	}
+	next := applyChange(base.Version, calculateChange(commits, &base))
+
+	// lets include the pre-releases which have been merged
+	merged, err := gitter.GetCommits(dir, base.SHA, endSha)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "getting commits in range %s..%s", base.SHA, endSha)
+	}
+	reachable := map[string]bool{endSha: true}
+	for _, c := range merged {
+		reachable[c.SHA] = true
+	}
+	for _, r := range releases {
+		if r.Version.Prerelease() == "" || !reachable[r.SHA] {
+			continue
+		}
+		v := FinalVersion(r.Version)
+		if v.GreaterThan(next) {
+			log.Logger().Debugf("raising the version from %s to %s as pre-release %s has been merged", next, v, r.Tag)
+			next = v
+		}
+	}
+
+	if channel == "" {
+		return next, latest, nil
+	}
+
+	previous := latest
+	number := 0
+	for _, r := range releases {
+		n, ok := channelNumber(r.Version, next, channel)
+		if ok && n >= number {
+			number = n
+			previous = r
+		}
+	}
+	answer, err := next.SetPrerelease(fmt.Sprintf("%s.%d", channel, number+1))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid release channel %s", channel)
+	}
+	return &answer, previous, nil
+}
+
+// LatestFinalRelease returns the latest release without a pre-release before the version or
+// the latest of all the final releases if the version is nil. The releases must be ordered by version
+func LatestFinalRelease(releases []*ComponentRelease, before *semver.Version) *ComponentRelease {
+	for i := len(releases) - 1; i >= 0; i-- {
+		r := releases[i]
+		if r.Version.Prerelease() != "" {
+			continue
+		}
+		if before == nil || r.Version.LessThan(before) {
+			return r
+		}
+	}
+	return nil
+}
+
+// FindPreReleases returns the pre-releases of a final version from the releases
+func FindPreReleases(releases []*ComponentRelease, version *semver.Version) []*ComponentRelease {
+	var answer []*ComponentRelease
+	for _, r := range releases {
+		if r.Version.Prerelease() != "" && FinalVersion(r.Version).Equal(version) {
+			answer = append(answer, r)
+		}
+	}
+	return answer
+}
+
+// FinalVersion returns the version without its pre-release and metadata, e.g. 1.3.0 for 1.3.0-rc.4
+func FinalVersion(version *semver.Version) *semver.Version {
+	return semver.MustParse(fmt.Sprintf("%d.%d.%d", version.Major(), version.Minor(), version.Patch()))
+}
+
+// channelNumber returns the number of a pre-release of the channel for the final version, e.g. 4 for 1.3.0-rc.4
+func channelNumber(version *semver.Version, final *semver.Version, channel string) (int, bool) {
+	if !FinalVersion(version).Equal(final) {
+		return 0, false
+	}
+	prefix := channel + "."
+	pre := version.Prerelease()
+	if !strings.HasPrefix(pre, prefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(pre, prefix))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
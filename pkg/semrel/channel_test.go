@@ -0,0 +1,122 @@
+// +build unit
+
+package semrel_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/semrel"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// channelStep commits a change, if there is a commit message, then asserts the next version of the channel and
+// the previous release before creating any tag
+type channelStep struct {
+	commit           string
+	channel          string
+	expectedVersion  string
+	expectedPrevious string
+	tag              string
+}
+
+func TestGetNewChannelVersion(t *testing.T) {
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+		orig, found := os.LookupEnv(env)
+		_ = os.Setenv(env, "test@acme.com")
+		defer func(env string, orig string, found bool) {
+			if found {
+				_ = os.Setenv(env, orig)
+			} else {
+				_ = os.Unsetenv(env)
+			}
+		}(env, orig, found)
+	}
+
+	tests := []struct {
+		name               string
+		steps              []channelStep
+		expectedPreRelease []string
+	}{
+		{
+			name: "pre-releases are numbered per channel and promoted by the final release",
+			steps: []channelStep{
+				{commit: "chore: initial commit"},
+				{commit: "feat: add main", tag: "v1.2.0"},
+				{commit: "feat: add a flag", channel: "rc", expectedVersion: "1.3.0-rc.1", expectedPrevious: "v1.2.0", tag: "v1.3.0-rc.1"},
+				{commit: "fix: the flag", channel: "rc", expectedVersion: "1.3.0-rc.2", expectedPrevious: "v1.3.0-rc.1"},
+				{channel: "beta", expectedVersion: "1.3.0-beta.1", expectedPrevious: "v1.2.0"},
+				{expectedVersion: "1.3.0", expectedPrevious: "v1.2.0"},
+			},
+			expectedPreRelease: []string{"v1.3.0-rc.1"},
+		},
+		{
+			name: "a merged pre-release raises the version of the commits",
+			steps: []channelStep{
+				{commit: "feat: add main", tag: "v1.0.0"},
+				// an rc of 2.0.0 was cut manually from a fix so the commits alone only give a patch release
+				{commit: "fix: a bug", tag: "v2.0.0-rc.3"},
+				{expectedVersion: "2.0.0", expectedPrevious: "v1.0.0"},
+				{channel: "rc", expectedVersion: "2.0.0-rc.4", expectedPrevious: "v2.0.0-rc.3"},
+			},
+			expectedPreRelease: []string{"v2.0.0-rc.3"},
+		},
+		{
+			name: "the first pre-release of a repository without releases",
+			steps: []channelStep{
+				{commit: "feat: add main", channel: "beta", expectedVersion: "1.0.0-beta.1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "test-semrel-channel-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			gitter := gits.NewGitCLI()
+			require.NoError(t, gitter.Init(dir))
+			root := semrel.NewComponent("")
+
+			head := ""
+			var version string
+			for i, step := range tt.steps {
+				if step.commit != "" {
+					path := filepath.Join(dir, "main.go")
+					require.NoError(t, ioutil.WriteFile(path, []byte(step.commit), util.DefaultFileWritePermissions))
+					require.NoError(t, gitter.Add(dir, "."))
+					require.NoError(t, gitter.CommitDir(dir, step.commit))
+					head, err = gitter.RevParse(dir, "HEAD")
+					require.NoError(t, err)
+				}
+				if step.expectedVersion != "" {
+					newVersion, previous, err := semrel.GetNewChannelVersion(dir, head, gitter, root, step.channel)
+					require.NoError(t, err, "step %d", i)
+					version = newVersion.String()
+					assert.Equal(t, step.expectedVersion, version, "step %d", i)
+					if step.expectedPrevious == "" {
+						assert.Nil(t, previous, "step %d", i)
+					} else if assert.NotNil(t, previous, "step %d", i) {
+						assert.Equal(t, step.expectedPrevious, previous.Tag, "step %d", i)
+					}
+				}
+				if step.tag != "" {
+					require.NoError(t, gitter.CreateTag(dir, step.tag, "release "+step.tag))
+				}
+			}
+
+			releases, err := semrel.FindComponentReleases(dir, gitter, root)
+			require.NoError(t, err)
+			var preReleases []string
+			for _, r := range semrel.FindPreReleases(releases, semrel.FinalVersion(root.ParseTag(version))) {
+				preReleases = append(preReleases, r.Tag)
+			}
+			assert.Equal(t, tt.expectedPreRelease, preReleases)
+		})
+	}
+}
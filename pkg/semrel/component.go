@@ -0,0 +1,184 @@
+package semrel
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/pkg/errors"
+)
+
+// Component a component of a monorepo which is versioned and released independently of the other components
+// using tags prefixed with its name, e.g. svc-a/v1.4.0. A component without a name is the whole repository
+// which uses tags such as v1.4.0
+type Component struct {
+	// Name the name of the component which prefixes its tags and matches the scope of its conventional commits
+	Name string
+	// Path the path of the component relative to the root of the repository
+	Path string
+}
+
+// ComponentRelease a tagged release of a component
+type ComponentRelease struct {
+	Tag     string
+	SHA     string
+	Version *semver.Version
+}
+
+// NewComponent creates a component for the path in the repository which is named after the last element of the path.
+// An empty path creates a component for the whole repository
+func NewComponent(componentPath string) *Component {
+	p := strings.Trim(path.Clean(filepath.ToSlash(componentPath)), "/")
+	if p == "." || p == "" {
+		return &Component{}
+	}
+	return &Component{
+		Name: path.Base(p),
+		Path: p,
+	}
+}
+
+// TagPrefix returns the prefix of the tags of the component
+func (c *Component) TagPrefix() string {
+	if c.Name == "" {
+		return "v"
+	}
+	return c.Name + "/v"
+}
+
+// Tag returns the tag of a version of the component
+func (c *Component) Tag(version string) string {
+	return c.TagPrefix() + strings.TrimPrefix(version, "v")
+}
+
+// ParseTag returns the version of a tag of the component or nil if the tag is not a version of the component
+func (c *Component) ParseTag(tag string) *semver.Version {
+	if c.Name == "" && !strings.HasPrefix(tag, c.TagPrefix()) {
+		// lets support repository tags without the v prefix
+		tag = c.TagPrefix() + tag
+	}
+	if !strings.HasPrefix(tag, c.TagPrefix()) {
+		return nil
+	}
+	v, err := semver.NewVersion(strings.TrimPrefix(tag, c.TagPrefix()))
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// MatchesScope returns true if the scope of the conventional commit message contains the name of the component
+func (c *Component) MatchesScope(message string) bool {
+	if c.Name == "" {
+		return false
+	}
+	firstLine := strings.SplitN(message, "\n", 2)[0]
+	found := commitPattern.FindStringSubmatch(firstLine)
+	if len(found) < 3 {
+		return false
+	}
+	for _, scope := range strings.Split(found[2], ",") {
+		if strings.EqualFold(strings.TrimSpace(scope), c.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindComponentReleases returns the releases of the component ordered from the oldest to the latest version
+func FindComponentReleases(dir string, gitter gits.Gitter, component *Component) ([]*ComponentRelease, error) {
+	pattern := component.TagPrefix() + "*"
+	if component.Name == "" {
+		pattern = ""
+	}
+	tags, err := gitter.FilterTags(dir, pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing tags of component %s", component.Name)
+	}
+	var answer []*ComponentRelease
+	for _, tag := range tags {
+		version := component.ParseTag(tag)
+		if version == nil {
+			continue
+		}
+		sha, err := gitter.GetCommitPointedToByTag(dir, tag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting commit pointed to by tag %s", tag)
+		}
+		answer = append(answer, &ComponentRelease{
+			Tag:     tag,
+			SHA:     strings.TrimSpace(sha),
+			Version: version,
+		})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Version.LessThan(answer[j].Version)
+	})
+	return answer, nil
+}
+
+// ComponentCommits returns the commits in the range startSha..endSha which change files of the component
+// or have the component as their scope
+func ComponentCommits(dir string, gitter gits.Gitter, component *Component, startSha string, endSha string) ([]gits.GitCommit, error) {
+	commits, err := gitter.GetCommits(dir, startSha, endSha)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting commits in range %s..%s", startSha, endSha)
+	}
+	if component.Path == "" {
+		return commits, nil
+	}
+	pathCommits, err := gitter.GetCommitsInPath(dir, startSha, endSha, component.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting commits in range %s..%s changing %s", startSha, endSha, component.Path)
+	}
+	changed := map[string]bool{}
+	for _, c := range pathCommits {
+		changed[c.SHA] = true
+	}
+	answer := make([]gits.GitCommit, 0)
+	for _, c := range commits {
+		if changed[c.SHA] || component.MatchesScope(c.Message) {
+			answer = append(answer, c)
+		}
+	}
+	return answer, nil
+}
+
+// GetNewComponentVersion uses the conventional commits of the component since its latest release up to endSha
+// to increment its version. If the component has not been released yet all of its commits are used.
+// The latest release is returned along with the new version, it is nil if there is no release
+func GetNewComponentVersion(dir string, endSha string, gitter gits.Gitter, component *Component) (*semver.Version, *ComponentRelease, error) {
+	releases, err := FindComponentReleases(dir, gitter, component)
+	if err != nil {
+		return nil, nil, err
+	}
+	var latest *ComponentRelease
+	latestRelease := release{}
+	if len(releases) > 0 {
+		latest = releases[len(releases)-1]
+		latestRelease.SHA = latest.SHA
+		latestRelease.Version = latest.Version
+	} else {
+		latestRelease.SHA, err = gitter.GetFirstCommitSha(dir)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "getting the first commit")
+		}
+		latestRelease.Version, _ = semver.NewVersion("0.0.0")
+	}
+
+	rawCommits, err := ComponentCommits(dir, gitter, component, latestRelease.SHA, endSha)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Logger().Debugf("got %d commits for component %s", len(rawCommits), component.Name)
+	commits := make([]*conventionalCommit, 0)
+	for _, c := range rawCommits {
+		commit := c
+		commits = append(commits, parseCommit(&commit))
+	}
+	return applyChange(latestRelease.Version, calculateChange(commits, &latestRelease)), latest, nil
+}
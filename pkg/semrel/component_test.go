@@ -0,0 +1,113 @@
+// +build unit
+
+package semrel_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/semrel"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentTags(t *testing.T) {
+	t.Parallel()
+
+	component := semrel.NewComponent("./services/svc-a/")
+	assert.Equal(t, "svc-a", component.Name)
+	assert.Equal(t, "services/svc-a", component.Path)
+	assert.Equal(t, "svc-a/v1.4.0", component.Tag("1.4.0"))
+	assert.Equal(t, "svc-a/v1.4.0", component.Tag("v1.4.0"))
+
+	v := component.ParseTag("svc-a/v1.4.0")
+	require.NotNil(t, v)
+	assert.Equal(t, "1.4.0", v.String())
+	assert.Nil(t, component.ParseTag("svc-b/v1.4.0"))
+	assert.Nil(t, component.ParseTag("v1.4.0"))
+
+	assert.True(t, component.MatchesScope("feat(svc-a): add an endpoint"))
+	assert.True(t, component.MatchesScope("fix(svc-b, svc-a): shared bug\n\nmore details"))
+	assert.False(t, component.MatchesScope("feat(svc-b): add an endpoint"))
+	assert.False(t, component.MatchesScope("feat: add an endpoint"))
+
+	root := semrel.NewComponent(".")
+	assert.Equal(t, "", root.Name)
+	assert.Equal(t, "v1.4.0", root.Tag("1.4.0"))
+	assert.Equal(t, "1.4.0-rc.1", root.ParseTag("v1.4.0-rc.1").String())
+	assert.Equal(t, "1.4.0", root.ParseTag("1.4.0").String())
+	assert.Nil(t, root.ParseTag("svc-a/v1.4.0"))
+}
+
+func TestGetNewComponentVersion(t *testing.T) {
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+		orig, found := os.LookupEnv(env)
+		_ = os.Setenv(env, "test@acme.com")
+		defer func(env string, orig string, found bool) {
+			if found {
+				_ = os.Setenv(env, orig)
+			} else {
+				_ = os.Unsetenv(env)
+			}
+		}(env, orig, found)
+	}
+
+	dir, err := ioutil.TempDir("", "test-semrel-component-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	gitter := gits.NewGitCLI()
+	require.NoError(t, gitter.Init(dir))
+
+	commit := func(file string, message string) {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), util.DefaultWritePermissions))
+		require.NoError(t, ioutil.WriteFile(path, []byte(message), util.DefaultFileWritePermissions))
+		require.NoError(t, gitter.Add(dir, "."))
+		require.NoError(t, gitter.CommitDir(dir, message))
+	}
+
+	commit("README.md", "chore: initial commit")
+	commit("services/svc-a/main.go", "feat: add svc-a")
+	commit("services/svc-b/main.go", "feat: add svc-b")
+	require.NoError(t, gitter.CreateTag(dir, "svc-a/v1.0.0", "release svc-a 1.0.0"))
+	require.NoError(t, gitter.CreateTag(dir, "svc-b/v1.0.0", "release svc-b 1.0.0"))
+	commit("services/svc-b/main.go", "fix: bug in svc-b")
+	commit("docs/api.md", "feat(svc-a): document a new endpoint")
+	commit("services/svc-a/main.go", "fix: typo")
+
+	head, err := gitter.RevParse(dir, "HEAD")
+	require.NoError(t, err)
+
+	svcA := semrel.NewComponent("services/svc-a")
+	releases, err := semrel.FindComponentReleases(dir, gitter, svcA)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "svc-a/v1.0.0", releases[0].Tag)
+
+	commits, err := semrel.ComponentCommits(dir, gitter, svcA, releases[0].SHA, head)
+	require.NoError(t, err)
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	assert.Equal(t, []string{"fix: typo", "feat(svc-a): document a new endpoint"}, messages)
+
+	version, latest, err := semrel.GetNewComponentVersion(dir, head, gitter, svcA)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "1.1.0", version.String(), "svc-a has a feature")
+
+	version, _, err = semrel.GetNewComponentVersion(dir, head, gitter, semrel.NewComponent("services/svc-b"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.1", version.String(), "svc-b has a fix")
+
+	version, latest, err = semrel.GetNewComponentVersion(dir, head, gitter, semrel.NewComponent("services/svc-c"))
+	require.NoError(t, err)
+	assert.Nil(t, latest, "svc-c has not been released")
+	assert.Equal(t, "1.0.0", version.String())
+}
@@ -0,0 +1,313 @@
+package tekton
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-api/pkg/client/clientset/versioned"
+	typev1 "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ActivityStatusTypeQueued is the status of a PipelineActivity whose pipeline is waiting for other pipelines of its
+// concurrency group to complete
+const ActivityStatusTypeQueued v1.ActivityStatusType = "Queued"
+
+// concurrencyClaimTTL how long a slot claimed by a pipeline whose PipelineRun has not been created is reserved
+const concurrencyClaimTTL = 5 * time.Minute
+
+// ConcurrencyGroupLabelValue returns the value of the LabelConcurrencyGroup label for the given concurrency group
+func ConcurrencyGroupLabelValue(group string) string {
+	return naming.ToValidNameTruncated(group, 63)
+}
+
+// ActiveConcurrencyGroupRuns returns the PipelineRuns of the concurrency group which have not completed or been cancelled
+func ActiveConcurrencyGroupRuns(tektonClient tektonclient.Interface, ns string, group string) ([]*pipelineapi.PipelineRun, error) {
+	return activePipelineRuns(tektonClient, ns, fmt.Sprintf("%s=%s", LabelConcurrencyGroup, ConcurrencyGroupLabelValue(group)))
+}
+
+// ActiveBuildPipelineRuns returns the PipelineRuns of build pipelines which have not completed or been cancelled. The
+// PipelineRuns of meta pipelines are not included
+func ActiveBuildPipelineRuns(tektonClient tektonclient.Interface, ns string) ([]*pipelineapi.PipelineRun, error) {
+	return activePipelineRuns(tektonClient, ns, fmt.Sprintf("%s=%s", LabelType, BuildPipeline.String()))
+}
+
+func activePipelineRuns(tektonClient tektonclient.Interface, ns string, labelSelector string) ([]*pipelineapi.PipelineRun, error) {
+	prList, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list PipelineRuns in namespace %s with selector %s", ns, labelSelector)
+	}
+	var answer []*pipelineapi.PipelineRun
+	for i := range prList.Items {
+		pr := &prList.Items[i]
+		if !(pr.IsDone() || pr.IsCancelled()) {
+			answer = append(answer, pr)
+		}
+	}
+	return answer, nil
+}
+
+// CancelConcurrencyGroupRuns cancels the PipelineRuns of the concurrency group which are still in progress as they are
+// superseded by a new pipeline. Returns the names of the cancelled PipelineRuns
+func CancelConcurrencyGroupRuns(tektonClient tektonclient.Interface, ns string, group string) ([]string, error) {
+	prs, err := ActiveConcurrencyGroupRuns(tektonClient, ns, group)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pr := range prs {
+		err = CancelPipelineRun(tektonClient, ns, pr)
+		if err != nil {
+			return names, errors.Wrapf(err, "failed to cancel superseded PipelineRun %s", pr.Name)
+		}
+		log.Logger().Infof("cancelled PipelineRun %s of concurrency group %s as it is superseded", util.ColorInfo(pr.Name), util.ColorInfo(group))
+		names = append(names, pr.Name)
+	}
+	return names, nil
+}
+
+// WaitForConcurrencyGroup waits until fewer than maxParallel pipelines of the concurrency group are running and no
+// pipeline of the group has been queued for longer, then claims a slot of the group for the pipeline. While waiting the
+// PipelineActivity of the pipeline is Queued. Returns an error if the context is done before a slot is claimed.
+//
+// The claimed slots are stored in a ConfigMap which is updated conditionally so that two pipelines cannot claim the
+// same slot. The returned function releases the slot and should be invoked once the PipelineRun of the pipeline has been
+// created, from then on the PipelineRun counts towards the running pipelines of the group
+func WaitForConcurrencyGroup(ctx context.Context, kubeClient kubernetes.Interface, jxClient versioned.Interface, tektonClient tektonclient.Interface, ns string, activityKey *kube.PromoteStepActivityKey, group string, maxParallel int, pollInterval time.Duration) (func() error, error) {
+	labelValue := ConcurrencyGroupLabelValue(group)
+	queue := &concurrencyQueue{
+		description:   "the concurrency group " + group,
+		configMapName: ConcurrencyGroupConfigMapName(group),
+		label:         LabelConcurrencyGroup,
+		labelValue:    labelValue,
+		activeRuns: func() ([]*pipelineapi.PipelineRun, error) {
+			return ActiveConcurrencyGroupRuns(tektonClient, ns, group)
+		},
+		maxParallel: maxParallel,
+	}
+	return queue.wait(ctx, kubeClient, jxClient, ns, activityKey, pollInterval)
+}
+
+// WaitForMaxConcurrentBuilds waits until fewer than maxBuilds build pipelines are running in the namespace and no
+// pipeline has been queued for longer, then claims a slot for the pipeline, e.g. to enforce the maximum concurrent
+// builds of a team. The PipelineRuns of meta pipelines do not count towards the running builds. Otherwise it behaves
+// like WaitForConcurrencyGroup
+func WaitForMaxConcurrentBuilds(ctx context.Context, kubeClient kubernetes.Interface, jxClient versioned.Interface, tektonClient tektonclient.Interface, ns string, activityKey *kube.PromoteStepActivityKey, maxBuilds int, pollInterval time.Duration) (func() error, error) {
+	queue := &concurrencyQueue{
+		description:   "the maximum concurrent builds",
+		configMapName: MaxConcurrentBuildsConfigMapName,
+		label:         LabelBuildQueue,
+		labelValue:    "true",
+		activeRuns: func() ([]*pipelineapi.PipelineRun, error) {
+			return ActiveBuildPipelineRuns(tektonClient, ns)
+		},
+		maxParallel: maxBuilds,
+	}
+	return queue.wait(ctx, kubeClient, jxClient, ns, activityKey, pollInterval)
+}
+
+// concurrencyQueue a queue of the pipelines which wait until fewer than maxParallel of the PipelineRuns it limits
+// are running
+type concurrencyQueue struct {
+	// description describes the queue in log and error messages
+	description string
+	// configMapName the name of the ConfigMap which stores the claimed slots
+	configMapName string
+	// label and labelValue mark the PipelineActivities of the queue
+	label      string
+	labelValue string
+	// activeRuns returns the PipelineRuns which count towards the running pipelines
+	activeRuns  func() ([]*pipelineapi.PipelineRun, error)
+	maxParallel int
+}
+
+func (q *concurrencyQueue) wait(ctx context.Context, kubeClient kubernetes.Interface, jxClient versioned.Interface, ns string, activityKey *kube.PromoteStepActivityKey, pollInterval time.Duration) (func() error, error) {
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	activity, _, err := activityKey.GetOrCreate(jxClient, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get PipelineActivity %s", activityKey.Name)
+	}
+	queued := false
+	for {
+		claimed, err := q.claimSlot(kubeClient, jxClient, ns, activity)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			break
+		}
+		if !queued {
+			log.Logger().Infof("queueing PipelineActivity %s as %s has no capacity", util.ColorInfo(activity.Name), util.ColorInfo(q.description))
+			if activity.Labels == nil {
+				activity.Labels = map[string]string{}
+			}
+			activity.Labels[q.label] = q.labelValue
+			activity.Spec.Status = ActivityStatusTypeQueued
+			activity, err = activities.PatchUpdate(activity)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to mark PipelineActivity %s as queued", activityKey.Name)
+			}
+			queued = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "PipelineActivity %s was queued for %s", activityKey.Name, q.description)
+		case <-time.After(pollInterval):
+		}
+	}
+	release := func() error {
+		return q.releaseSlot(kubeClient, ns, activity.Name)
+	}
+	if !queued {
+		return release, nil
+	}
+	err = markActivityPending(activities, activity.Name)
+	if err != nil {
+		releaseErr := release()
+		if releaseErr != nil {
+			log.Logger().Warnf("failed to release the slot of %s: %s", q.description, releaseErr)
+		}
+		return nil, err
+	}
+	log.Logger().Infof("PipelineActivity %s is no longer queued", util.ColorInfo(activity.Name))
+	return release, nil
+}
+
+func markActivityPending(activities typev1.PipelineActivityInterface, name string) error {
+	activity, err := activities.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get PipelineActivity %s", name)
+	}
+	activity.Spec.Status = v1.ActivityStatusTypePending
+	_, err = activities.PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to mark PipelineActivity %s as pending", name)
+	}
+	return nil
+}
+
+// ConcurrencyGroupConfigMapName returns the name of the ConfigMap which stores the claimed slots of the concurrency group
+func ConcurrencyGroupConfigMapName(group string) string {
+	return "jx-concurrency-" + ConcurrencyGroupLabelValue(group)
+}
+
+// claimSlot claims a slot of the queue for the activity if it has capacity. The ConfigMap of the queue is created or
+// updated conditionally on its resource version and the claim is retried if another pipeline changed it first
+func (q *concurrencyQueue) claimSlot(kubeClient kubernetes.Interface, jxClient versioned.Interface, ns string, activity *v1.PipelineActivity) (bool, error) {
+	configMaps := kubeClient.CoreV1().ConfigMaps(ns)
+	name := q.configMapName
+	for {
+		cm, err := configMaps.Get(name, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{q.label: q.labelValue},
+				},
+			}
+		} else if err != nil {
+			return false, errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", name, ns)
+		}
+		prs, err := q.activeRuns()
+		if err != nil {
+			return false, err
+		}
+		ahead, err := q.queuedActivitiesAhead(jxClient, ns, activity)
+		if err != nil {
+			return false, err
+		}
+		claims := liveConcurrencyClaims(cm, activity.Name)
+		if len(prs)+len(claims)+ahead >= q.maxParallel {
+			log.Logger().Debugf("%s has %d running, %d starting and %d queued pipelines", q.description, len(prs), len(claims), ahead)
+			return false, nil
+		}
+		claims[activity.Name] = time.Now().UTC().Format(time.RFC3339)
+		cm.Data = claims
+		if create {
+			_, err = configMaps.Create(cm)
+		} else {
+			_, err = configMaps.Update(cm)
+		}
+		if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+			log.Logger().Debugf("retrying the claim of a slot of %s as it was changed by another pipeline", q.description)
+			continue
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to claim a slot of %s", q.description)
+		}
+		return true, nil
+	}
+}
+
+// releaseSlot removes the claim of the activity from the ConfigMap of the queue
+func (q *concurrencyQueue) releaseSlot(kubeClient kubernetes.Interface, ns string, activityName string) error {
+	configMaps := kubeClient.CoreV1().ConfigMaps(ns)
+	name := q.configMapName
+	for {
+		cm, err := configMaps.Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ConfigMap %s in namespace %s", name, ns)
+		}
+		if _, ok := cm.Data[activityName]; !ok {
+			return nil
+		}
+		cm.Data = liveConcurrencyClaims(cm, activityName)
+		_, err = configMaps.Update(cm)
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to release the slot of %s", q.description)
+		}
+		return nil
+	}
+}
+
+// liveConcurrencyClaims returns the claims of the ConfigMap of a queue other than the one of the activity, dropping
+// the claims which have expired as their pipelines failed to start
+func liveConcurrencyClaims(cm *corev1.ConfigMap, activityName string) map[string]string {
+	answer := map[string]string{}
+	for name, value := range cm.Data {
+		claimed, err := time.Parse(time.RFC3339, value)
+		if name == activityName || err != nil || time.Since(claimed) > concurrencyClaimTTL {
+			continue
+		}
+		answer[name] = value
+	}
+	return answer
+}
+
+// queuedActivitiesAhead returns the number of queued PipelineActivities of the queue created before the activity
+func (q *concurrencyQueue) queuedActivitiesAhead(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity) (int, error) {
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", q.label, q.labelValue),
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to list PipelineActivities in namespace %s", ns)
+	}
+	count := 0
+	for _, a := range list.Items {
+		if a.Name != activity.Name && a.Spec.Status == ActivityStatusTypeQueued && a.CreationTimestamp.Before(&activity.CreationTimestamp) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,260 @@
+// +build unit
+
+package tekton_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+)
+
+const concurrencyTestNamespace = "jx"
+
+func concurrencyGroupRun(name string, group string, done bool) *tektonv1alpha1.PipelineRun {
+	run := &tektonv1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: concurrencyTestNamespace,
+			Labels: map[string]string{
+				tekton.LabelConcurrencyGroup: tekton.ConcurrencyGroupLabelValue(group),
+			},
+		},
+	}
+	if done {
+		run.Status.SetCondition(&apis.Condition{
+			Type:   apis.ConditionSucceeded,
+			Status: corev1.ConditionTrue,
+		})
+	}
+	return run
+}
+
+func TestCancelConcurrencyGroupRuns(t *testing.T) {
+	tektonClient := tektonfake.NewSimpleClientset(
+		concurrencyGroupRun("running", "deploy-master", false),
+		concurrencyGroupRun("done", "deploy-master", true),
+		concurrencyGroupRun("other", "deploy-feature", false),
+	)
+
+	active, err := tekton.ActiveConcurrencyGroupRuns(tektonClient, concurrencyTestNamespace, "deploy-master")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "running", active[0].Name)
+
+	cancelled, err := tekton.CancelConcurrencyGroupRuns(tektonClient, concurrencyTestNamespace, "deploy-master")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"running"}, cancelled)
+
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(concurrencyTestNamespace).Get("running", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, tektonv1alpha1.PipelineRunSpecStatusCancelled, string(pr.Spec.Status))
+
+	pr, err = tektonClient.TektonV1alpha1().PipelineRuns(concurrencyTestNamespace).Get("other", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pr.Spec.Status, "PipelineRun of another group should not be cancelled")
+}
+
+func TestWaitForMaxConcurrentBuildsCountsOnlyBuildPipelines(t *testing.T) {
+	run := func(name string, pipelineType tekton.PipelineType, done bool) *tektonv1alpha1.PipelineRun {
+		pr := concurrencyGroupRun(name, "", done)
+		pr.Labels = map[string]string{tekton.LabelType: pipelineType.String()}
+		return pr
+	}
+	tektonClient := tektonfake.NewSimpleClientset(
+		run("build", tekton.BuildPipeline, false),
+		run("done", tekton.BuildPipeline, true),
+		run("meta", tekton.MetaPipeline, false),
+	)
+	jxClient := jxfake.NewSimpleClientset()
+	kubeClient := kubefake.NewSimpleClientset()
+
+	active, err := tekton.ActiveBuildPipelineRuns(tektonClient, concurrencyTestNamespace)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "build", active[0].Name)
+
+	release, err := tekton.WaitForMaxConcurrentBuilds(context.Background(), kubeClient, jxClient, tektonClient, concurrencyTestNamespace, concurrencyActivityKey("2"), 2, time.Millisecond)
+	require.NoError(t, err, "the running meta pipeline should not count towards the maximum concurrent builds")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tekton.WaitForMaxConcurrentBuilds(ctx, kubeClient, jxClient, tektonClient, concurrencyTestNamespace, concurrencyActivityKey("3"), 2, time.Millisecond)
+	require.Error(t, err, "the pipeline should be queued while the maximum concurrent builds are running or starting")
+
+	activities := jxClient.JenkinsV1().PipelineActivities(concurrencyTestNamespace)
+	activity, err := activities.Get("myorg-myapp-master-3", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, tekton.ActivityStatusTypeQueued, activity.Spec.Status)
+	assert.Equal(t, "true", activity.Labels[tekton.LabelBuildQueue])
+
+	pr, err := tektonClient.TektonV1alpha1().PipelineRuns(concurrencyTestNamespace).Get("build", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pr.Spec.Status, "the running build should not be cancelled")
+
+	require.NoError(t, release())
+	cm, err := kubeClient.CoreV1().ConfigMaps(concurrencyTestNamespace).Get(tekton.MaxConcurrentBuildsConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cm.Data, "the slot should be released")
+}
+
+func TestWaitForConcurrencyGroupWithCapacity(t *testing.T) {
+	tektonClient := tektonfake.NewSimpleClientset(
+		concurrencyGroupRun("running", "deploy-master", false),
+	)
+	jxClient := jxfake.NewSimpleClientset()
+
+	activityKey := &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "myorg-myapp-master-2",
+			Pipeline: "myorg/myapp/master",
+			Build:    "2",
+			GitInfo: &gits.GitRepository{
+				Name:         "myapp",
+				Organisation: "myorg",
+			},
+		},
+	}
+	kubeClient := kubefake.NewSimpleClientset()
+	release, err := tekton.WaitForConcurrencyGroup(context.Background(), kubeClient, jxClient, tektonClient, concurrencyTestNamespace, activityKey, "deploy-master", 2, time.Millisecond)
+	require.NoError(t, err)
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities(concurrencyTestNamespace).Get("myorg-myapp-master-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, tekton.ActivityStatusTypeQueued, activity.Spec.Status)
+	assert.NotEqual(t, v1.ActivityStatusTypeRunning, activity.Spec.Status)
+
+	configMaps := kubeClient.CoreV1().ConfigMaps(concurrencyTestNamespace)
+	cm, err := configMaps.Get(tekton.ConcurrencyGroupConfigMapName("deploy-master"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, cm.Data, "myorg-myapp-master-2", "the slot should be claimed")
+
+	require.NoError(t, release())
+	cm, err = configMaps.Get(tekton.ConcurrencyGroupConfigMapName("deploy-master"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cm.Data, "the slot should be released")
+}
+
+func concurrencyActivityKey(build string) *kube.PromoteStepActivityKey {
+	return &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "myorg-myapp-master-" + build,
+			Pipeline: "myorg/myapp/master",
+			Build:    build,
+			GitInfo: &gits.GitRepository{
+				Name:         "myapp",
+				Organisation: "myorg",
+			},
+		},
+	}
+}
+
+func TestWaitForConcurrencyGroupClaimsSlotsAtomically(t *testing.T) {
+	tektonClient := tektonfake.NewSimpleClientset()
+	jxClient := jxfake.NewSimpleClientset()
+	kubeClient := kubefake.NewSimpleClientset()
+
+	// lets simulate another pipeline claiming the last slot between our read and update of the ConfigMap
+	conflicted := false
+	kubeClient.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		cm := action.(k8stesting.UpdateAction).GetObject().(*corev1.ConfigMap).DeepCopy()
+		cm.Data = map[string]string{"myorg-myapp-master-1": time.Now().UTC().Format(time.RFC3339)}
+		err := kubeClient.Tracker().Update(corev1.SchemeGroupVersion.WithResource("configmaps"), cm, concurrencyTestNamespace)
+		require.NoError(t, err)
+		return true, nil, apierrors.NewConflict(corev1.Resource("configmaps"), cm.Name, errors.New("the object has been modified"))
+	})
+	configMaps := kubeClient.CoreV1().ConfigMaps(concurrencyTestNamespace)
+	_, err := configMaps.Create(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: tekton.ConcurrencyGroupConfigMapName("deploy-master")}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tekton.WaitForConcurrencyGroup(ctx, kubeClient, jxClient, tektonClient, concurrencyTestNamespace, concurrencyActivityKey("2"), "deploy-master", 1, time.Millisecond)
+	require.Error(t, err, "the slot claimed by the other pipeline should not be claimed again")
+	assert.True(t, conflicted)
+
+	activity, err := jxClient.JenkinsV1().PipelineActivities(concurrencyTestNamespace).Get("myorg-myapp-master-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, tekton.ActivityStatusTypeQueued, activity.Spec.Status)
+
+	cm, err := configMaps.Get(tekton.ConcurrencyGroupConfigMapName("deploy-master"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"myorg-myapp-master-1"}, keys(cm.Data))
+}
+
+func keys(m map[string]string) []string {
+	var answer []string
+	for k := range m {
+		answer = append(answer, k)
+	}
+	return answer
+}
+
+func TestWaitForConcurrencyGroupQueues(t *testing.T) {
+	tektonClient := tektonfake.NewSimpleClientset(
+		concurrencyGroupRun("running", "deploy-master", false),
+	)
+	jxClient := jxfake.NewSimpleClientset()
+	activities := jxClient.JenkinsV1().PipelineActivities(concurrencyTestNamespace)
+
+	activityKey := &kube.PromoteStepActivityKey{
+		PipelineActivityKey: kube.PipelineActivityKey{
+			Name:     "myorg-myapp-master-2",
+			Pipeline: "myorg/myapp/master",
+			Build:    "2",
+			GitInfo: &gits.GitRepository{
+				Name:         "myapp",
+				Organisation: "myorg",
+			},
+		},
+	}
+	result := make(chan error)
+	go func() {
+		_, err := tekton.WaitForConcurrencyGroup(context.Background(), kubefake.NewSimpleClientset(), jxClient, tektonClient, concurrencyTestNamespace, activityKey, "deploy-master", 1, time.Millisecond)
+		result <- err
+	}()
+
+	queued := false
+	for i := 0; i < 1000 && !queued; i++ {
+		activity, err := activities.Get("myorg-myapp-master-2", metav1.GetOptions{})
+		queued = err == nil && activity.Spec.Status == tekton.ActivityStatusTypeQueued
+		time.Sleep(time.Millisecond)
+	}
+	require.True(t, queued, "the PipelineActivity should be queued")
+
+	_, err := tektonClient.TektonV1alpha1().PipelineRuns(concurrencyTestNamespace).Update(concurrencyGroupRun("running", "deploy-master", true))
+	require.NoError(t, err)
+
+	select {
+	case err = <-result:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the concurrency group")
+	}
+
+	activity, err := activities.Get("myorg-myapp-master-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ActivityStatusTypePending, activity.Spec.Status)
+	assert.Equal(t, "deploy-master", activity.Labels[tekton.LabelConcurrencyGroup])
+}
@@ -26,6 +26,16 @@ const (
 	// LabelType is the label added to Tekton CRDs for the type of pipeline.
 	LabelType = "jenkins.io/pipelineType"
 
+	// LabelConcurrencyGroup is the label added to Tekton CRDs and PipelineActivities for the concurrency group of the pipeline.
+	LabelConcurrencyGroup = "jenkins.io/concurrency-group"
+
+	// LabelBuildQueue is the label added to PipelineActivities queued for the maximum concurrent builds of the namespace.
+	LabelBuildQueue = "jenkins.io/build-queue"
+
+	// MaxConcurrentBuildsConfigMapName is the name of the ConfigMap which stores the slots claimed by the pipelines
+	// starting when the namespace limits its concurrent builds.
+	MaxConcurrentBuildsConfigMapName = "jx-max-concurrent-builds"
+
 	// DefaultPipelineSA is the default service account used for pipelines
 	DefaultPipelineSA = "tekton-bot"
 )
@@ -0,0 +1,143 @@
+package tekton
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FailureClass classifies why a step of a pipeline failed
+type FailureClass string
+
+const (
+	// FailureClassNone the step has not failed
+	FailureClassNone FailureClass = ""
+	// FailureClassInfrastructure the step failed due to the cluster, e.g. the pod was evicted or ran out of memory,
+	// so running the step again may succeed
+	FailureClassInfrastructure FailureClass = "infrastructure"
+	// FailureClassUser the step failed due to the commands of the step returning a non-zero exit code
+	FailureClassUser FailureClass = "user"
+
+	// AnnotationFailureClass is the annotation added to PipelineActivities for the failure class of a failed pipeline
+	AnnotationFailureClass = "jenkins.io/failure-class"
+
+	// AnnotationStepFailureClasses is the annotation added to PipelineActivities for the failure classes of its
+	// failed steps, as a JSON object keyed by the name of the stage and the name of the step
+	AnnotationStepFailureClasses = "jenkins.io/step-failure-classes"
+
+	// AnnotationRetriedBy is the annotation added to a PipelineActivity whose PipelineRun has been retried
+	// automatically for the name of the PipelineRun which retries it
+	AnnotationRetriedBy = "jenkins.io/retried-by"
+)
+
+// infrastructurePodReasons the reasons of a failed pod which are caused by the cluster rather than the pipeline
+var infrastructurePodReasons = map[string]bool{
+	"Evicted":                  true,
+	"NodeLost":                 true,
+	"NodeAffinity":             true,
+	"Shutdown":                 true,
+	"Terminated":               true,
+	"UnexpectedAdmissionError": true,
+	"OutOfcpu":                 true,
+	"OutOfmemory":              true,
+}
+
+// infrastructureContainerReasons the reasons of a waiting or terminated container which are caused by the cluster
+// rather than the commands of the step
+var infrastructureContainerReasons = map[string]bool{
+	"OOMKilled":              true,
+	"ContainerCannotRun":     true,
+	"ErrImagePull":           true,
+	"ImagePullBackOff":       true,
+	"ImageInspectError":      true,
+	"ErrImageNeverPull":      true,
+	"RegistryUnavailable":    true,
+	"CreateContainerError":   true,
+	"RunContainerError":      true,
+	"PreCreateHookError":     true,
+	"PostStartHookError":     true,
+	"ContainerStatusUnknown": true,
+}
+
+// ClassifyStepFailure classifies the failure of the step container with the given status in the pod. Returns
+// FailureClassNone and an empty reason if the step has not failed
+func ClassifyStepFailure(pod *corev1.Pod, status corev1.ContainerStatus) (FailureClass, string) {
+	if waiting := status.State.Waiting; waiting != nil && infrastructureContainerReasons[waiting.Reason] {
+		return FailureClassInfrastructure, waiting.Reason
+	}
+	terminated := status.State.Terminated
+	if terminated == nil || terminated.ExitCode == 0 {
+		return FailureClassNone, ""
+	}
+	if pod != nil && pod.Status.Phase == corev1.PodFailed && infrastructurePodReasons[pod.Status.Reason] {
+		return FailureClassInfrastructure, pod.Status.Reason
+	}
+	if infrastructureContainerReasons[terminated.Reason] {
+		return FailureClassInfrastructure, terminated.Reason
+	}
+	return FailureClassUser, fmt.Sprintf("exit code %d", terminated.ExitCode)
+}
+
+// StepFailureClasses returns the failure classes of the failed steps of the PipelineActivity keyed by
+// StepFailureKey
+func StepFailureClasses(activity *v1.PipelineActivity) map[string]FailureClass {
+	answer := map[string]FailureClass{}
+	text := activity.Annotations[AnnotationStepFailureClasses]
+	if text == "" {
+		return answer
+	}
+	err := json.Unmarshal([]byte(text), &answer)
+	if err != nil {
+		log.Logger().Warnf("failed to unmarshal the %s annotation of PipelineActivity %s: %s", AnnotationStepFailureClasses, activity.Name, err)
+	}
+	return answer
+}
+
+// SetStepFailureClass records the failure class of the step of the stage of the PipelineActivity
+func SetStepFailureClass(activity *v1.PipelineActivity, stage string, step string, class FailureClass) {
+	classes := StepFailureClasses(activity)
+	key := StepFailureKey(stage, step)
+	if classes[key] == class {
+		return
+	}
+	classes[key] = class
+	data, err := json.Marshal(classes)
+	if err != nil {
+		log.Logger().Warnf("failed to marshal the failure classes of PipelineActivity %s: %s", activity.Name, err)
+		return
+	}
+	if activity.Annotations == nil {
+		activity.Annotations = map[string]string{}
+	}
+	activity.Annotations[AnnotationStepFailureClasses] = string(data)
+}
+
+// StepFailureKey returns the key of the failure class of the step of the stage in StepFailureClasses
+func StepFailureKey(stage string, step string) string {
+	return stage + "/" + step
+}
+
+// ClassifyActivityFailure classifies the failure of the PipelineActivity from the failure classes recorded on its
+// failed steps. The failure is only classed as infrastructure if no step failed due to the user
+func ClassifyActivityFailure(activity *v1.PipelineActivity) FailureClass {
+	classes := StepFailureClasses(activity)
+	answer := FailureClassNone
+	for _, step := range activity.Spec.Steps {
+		if step.Stage == nil {
+			continue
+		}
+		for _, s := range step.Stage.Steps {
+			if s.Status != v1.ActivityStatusTypeFailed {
+				continue
+			}
+			if classes[StepFailureKey(step.Stage.Name, s.Name)] != FailureClassInfrastructure {
+				return FailureClassUser
+			}
+			answer = FailureClassInfrastructure
+		}
+	}
+	return answer
+}
@@ -0,0 +1,85 @@
+// +build unit
+
+package tekton_test
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestClassifyStepFailure(t *testing.T) {
+	terminated := func(exitCode int32, reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: reason},
+			},
+		}
+	}
+	evicted := &corev1.Pod{
+		Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+	}
+
+	testCases := []struct {
+		name           string
+		pod            *corev1.Pod
+		status         corev1.ContainerStatus
+		expectedClass  tekton.FailureClass
+		expectedReason string
+	}{
+		{name: "succeeded", status: terminated(0, "Completed"), expectedClass: tekton.FailureClassNone},
+		{name: "running", status: corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}, expectedClass: tekton.FailureClassNone},
+		{name: "exit code", status: terminated(1, "Error"), expectedClass: tekton.FailureClassUser, expectedReason: "exit code 1"},
+		{name: "oom killed", status: terminated(137, "OOMKilled"), expectedClass: tekton.FailureClassInfrastructure, expectedReason: "OOMKilled"},
+		{name: "deadline exceeded", status: terminated(137, "DeadlineExceeded"), expectedClass: tekton.FailureClassUser, expectedReason: "exit code 137"},
+		{name: "evicted", pod: evicted, status: terminated(137, "Error"), expectedClass: tekton.FailureClassInfrastructure, expectedReason: "Evicted"},
+		{
+			name: "image pull",
+			status: corev1.ContainerStatus{
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			},
+			expectedClass:  tekton.FailureClassInfrastructure,
+			expectedReason: "ImagePullBackOff",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			class, reason := tekton.ClassifyStepFailure(tt.pod, tt.status)
+			assert.Equal(t, tt.expectedClass, class)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestClassifyActivityFailure(t *testing.T) {
+	activity := func(classes ...tekton.FailureClass) *v1.PipelineActivity {
+		stage := &v1.StageActivityStep{CoreActivityStep: v1.CoreActivityStep{Name: "build"}}
+		a := &v1.PipelineActivity{
+			Spec: v1.PipelineActivitySpec{
+				Steps: []v1.PipelineActivityStep{{Kind: v1.ActivityStepKindTypeStage, Stage: stage}},
+			},
+		}
+		for i, class := range classes {
+			name := fmt.Sprintf("step%d", i)
+			stage.Steps = append(stage.Steps, v1.CoreActivityStep{Name: name, Status: v1.ActivityStatusTypeFailed})
+			if class != tekton.FailureClassNone {
+				tekton.SetStepFailureClass(a, stage.Name, name, class)
+			}
+		}
+		stage.Steps = append(stage.Steps, v1.CoreActivityStep{Name: "ok", Status: v1.ActivityStatusTypeSucceeded})
+		return a
+	}
+
+	assert.Equal(t, tekton.FailureClassNone, tekton.ClassifyActivityFailure(activity()))
+	assert.Equal(t, tekton.FailureClassInfrastructure, tekton.ClassifyActivityFailure(activity(tekton.FailureClassInfrastructure)))
+	assert.Equal(t, tekton.FailureClassUser, tekton.ClassifyActivityFailure(activity(tekton.FailureClassInfrastructure, tekton.FailureClassUser)))
+	assert.Equal(t, tekton.FailureClassUser, tekton.ClassifyActivityFailure(activity(tekton.FailureClassNone)))
+
+	a := activity(tekton.FailureClassInfrastructure)
+	assert.Equal(t, `{"build/step0":"infrastructure"}`, a.Annotations[tekton.AnnotationStepFailureClasses])
+	assert.Equal(t, map[string]tekton.FailureClass{"build/step0": tekton.FailureClassInfrastructure}, tekton.StepFailureClasses(a))
+}
@@ -0,0 +1,425 @@
+package tekton
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-api/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelineapi "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LabelRetryAttempt is the label added to a PipelineRun which retries a failed PipelineRun for the number of the attempt
+	LabelRetryAttempt = "jenkins.io/retry-attempt"
+
+	// LabelRetryOf is the label added to a PipelineRun which retries a failed PipelineRun for the name of the original PipelineRun
+	LabelRetryOf = "jenkins.io/retry-of"
+
+	// AnnotationTaskOutputs is the annotation added to a PipelineRun which retries a pipeline from a stage for the
+	// names of the PipelineRuns which stored the outputs of the tasks it did not run again, as a JSON object
+	AnnotationTaskOutputs = "jenkins.io/task-outputs"
+
+	// ConfigMapArtifactBucket is the ConfigMap which configures Tekton to store the outputs of tasks in a bucket
+	ConfigMapArtifactBucket = "config-artifact-bucket"
+
+	artifactBucketLocationKey   = "location"
+	artifactBucketSecretNameKey = "bucket.service.account.secret.name"
+	artifactBucketSecretKeyKey  = "bucket.service.account.secret.key"
+	artifactBucketFieldNameKey  = "bucket.service.account.field.name"
+
+	// artifactCopyImage the image used to copy the outputs of a task from the artifact bucket, the same as Tekton's
+	artifactCopyImage = "google/cloud-sdk"
+	// artifactSecretDir the directory the secret of the artifact bucket is mounted in
+	artifactSecretDir = "/var/bucketsecret"
+)
+
+// ArtifactBucket is the bucket Tekton stores the outputs of tasks in, so that tasks which take their inputs from
+// earlier tasks can copy them. Unlike the default PVC, which is deleted once the PipelineRun completes, the outputs
+// in a bucket can be used by a later PipelineRun
+type ArtifactBucket struct {
+	Location   string
+	SecretName string
+	SecretKey  string
+	FieldName  string
+}
+
+// LoadArtifactBucket loads the artifact bucket configured for Tekton in the given namespace. Returns nil if Tekton
+// stores the outputs of tasks on a PVC
+func LoadArtifactBucket(kubeClient kubernetes.Interface, ns string) (*ArtifactBucket, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(ns).Get(ConfigMapArtifactBucket, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to load ConfigMap %s in namespace %s", ConfigMapArtifactBucket, ns)
+	}
+	data := cm.Data
+	if data[artifactBucketLocationKey] == "" {
+		return nil, nil
+	}
+	return &ArtifactBucket{
+		Location:   data[artifactBucketLocationKey],
+		SecretName: data[artifactBucketSecretNameKey],
+		SecretKey:  data[artifactBucketSecretKeyKey],
+		FieldName:  data[artifactBucketFieldNameKey],
+	}, nil
+}
+
+// RestoredInput is an input of a task in a Pipeline which starts at a later stage than the Pipeline it was copied
+// from, whose resource came from tasks which are no longer run and so has to be restored from their outputs
+type RestoredInput struct {
+	// Name the name of the input of the task
+	Name string
+	// From the names of the tasks of the original Pipeline whose outputs are restored
+	From []string
+}
+
+// RetryAttempt returns the number of times the pipeline of the PipelineRun has been retried
+func RetryAttempt(pr *pipelineapi.PipelineRun) int {
+	attempt, err := strconv.Atoi(pr.Labels[LabelRetryAttempt])
+	if err != nil {
+		return 0
+	}
+	return attempt
+}
+
+// RetryPipelineRunName returns the name of the PipelineRun which retries the given PipelineRun
+func RetryPipelineRunName(pr *pipelineapi.PipelineRun) string {
+	original := pr.Labels[LabelRetryOf]
+	if original == "" {
+		original = pr.Name
+	}
+	return fmt.Sprintf("%s-retry-%d", original, RetryAttempt(pr)+1)
+}
+
+// RetryPipelineRun creates a new PipelineRun which runs the pipeline of the given PipelineRun again using the same
+// PipelineResources and parameters. If fromStage is specified the stages before it are not run again, so the new
+// PipelineRun uses a copy of the Pipeline and PipelineStructure without them. The first stages it runs start from the
+// outputs of the earlier stages stored in Tekton's artifact bucket, rather than cloning the source again. If the
+// PipelineRun is still running it is cancelled first. Returns the new PipelineRun
+func RetryPipelineRun(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, jxClient versioned.Interface, ns string, pr *pipelineapi.PipelineRun, fromStage string) (*pipelineapi.PipelineRun, error) {
+	if pr.Spec.PipelineRef == nil || pr.Spec.PipelineRef.Name == "" {
+		return nil, fmt.Errorf("PipelineRun %s does not reference a Pipeline", pr.Name)
+	}
+	original := pr.Labels[LabelRetryOf]
+	if original == "" {
+		original = pr.Name
+	}
+	attempt := RetryAttempt(pr) + 1
+	name := RetryPipelineRunName(pr)
+
+	run := &pipelineapi.PipelineRun{
+		TypeMeta: pr.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       pr.Namespace,
+			Labels:          util.MergeMaps(pr.Labels),
+			Annotations:     util.MergeMaps(pr.Annotations),
+			OwnerReferences: pr.OwnerReferences,
+		},
+		Spec: *pr.Spec.DeepCopy(),
+	}
+	run.Spec.Status = ""
+	run.Labels[LabelRetryAttempt] = strconv.Itoa(attempt)
+	run.Labels[LabelRetryOf] = original
+
+	if fromStage != "" {
+		p, err := tektonClient.TektonV1alpha1().Pipelines(ns).Get(pr.Spec.PipelineRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting Pipeline %s of PipelineRun %s", pr.Spec.PipelineRef.Name, pr.Name)
+		}
+		structure, err := StructureForPipelineRun(jxClient, ns, pr)
+		if err != nil {
+			return nil, err
+		}
+		p, structure, restored, err := PipelineFromStage(p, structure, fromStage, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(restored) > 0 {
+			outputs, err := restoreTaskOutputs(kubeClient, tektonClient, ns, pr, p, restored)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(outputs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to marshal the PipelineRuns of the task outputs")
+			}
+			run.Annotations[AnnotationTaskOutputs] = string(data)
+		}
+		_, err = CreateOrUpdatePipeline(tektonClient, ns, p)
+		if err != nil {
+			return nil, err
+		}
+		structure.PipelineRunRef = &name
+		_, err = jxClient.JenkinsV1().PipelineStructures(ns).Create(structure)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create the PipelineStructure %s in namespace %s", structure.Name, ns)
+		}
+		run.Spec.PipelineRef.Name = p.Name
+		run.Labels[pipeline.GroupName+pipeline.PipelineLabelKey] = p.Name
+	}
+
+	if !PipelineRunIsComplete(pr) && !pr.IsCancelled() {
+		err := CancelPipelineRun(tektonClient, ns, pr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ApplyPipelineRun(tektonClient, ns, run)
+}
+
+// taskOutputRuns returns the names of the PipelineRuns which stored the outputs of the tasks of the given PipelineRun
+// which it did not run itself as it retried an earlier PipelineRun from a later stage
+func taskOutputRuns(pr *pipelineapi.PipelineRun) (map[string]string, error) {
+	answer := map[string]string{}
+	text := pr.Annotations[AnnotationTaskOutputs]
+	if text == "" {
+		return answer, nil
+	}
+	err := json.Unmarshal([]byte(text), &answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the %s annotation of PipelineRun %s", AnnotationTaskOutputs, pr.Name)
+	}
+	return answer, nil
+}
+
+// restoreTaskOutputs replaces the Tasks of the Pipeline whose inputs have to be restored with copies which copy the
+// outputs of the tasks the inputs came from out of the artifact bucket. Returns the names of the PipelineRuns which
+// stored the outputs of the tasks which are not run again, including those not run by the given PipelineRun
+func restoreTaskOutputs(kubeClient kubernetes.Interface, tektonClient tektonclient.Interface, ns string, pr *pipelineapi.PipelineRun, p *pipelineapi.Pipeline, restored map[string][]RestoredInput) (map[string]string, error) {
+	bucket, err := LoadArtifactBucket(kubeClient, ns)
+	if err != nil {
+		return nil, err
+	}
+	if bucket == nil {
+		return nil, fmt.Errorf("cannot retry PipelineRun %s from a later stage as Tekton stores the outputs of its stages on a PVC which is deleted once it completes. Configure a bucket in the ConfigMap %s in namespace %s or retry the whole pipeline", pr.Name, ConfigMapArtifactBucket, ns)
+	}
+	outputs, err := taskOutputRuns(pr)
+	if err != nil {
+		return nil, err
+	}
+	answer := util.MergeMaps(outputs)
+	for i := range p.Spec.Tasks {
+		pt := &p.Spec.Tasks[i]
+		inputs := restored[pt.Name]
+		if len(inputs) == 0 || pt.TaskRef == nil {
+			continue
+		}
+		task, err := tektonClient.TektonV1alpha1().Tasks(ns).Get(pt.TaskRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting Task %s of PipelineRun %s", pt.TaskRef.Name, pr.Name)
+		}
+		copied := task.DeepCopy()
+		copied.ObjectMeta = metav1.ObjectMeta{
+			Name:            p.Name + "-" + pt.Name,
+			Namespace:       task.Namespace,
+			Labels:          util.MergeMaps(task.Labels),
+			Annotations:     util.MergeMaps(task.Annotations),
+			OwnerReferences: task.OwnerReferences,
+		}
+		var steps []pipelineapi.Step
+		for _, input := range inputs {
+			var runs []string
+			for _, from := range input.From {
+				run := outputs[from]
+				if run == "" {
+					run = pr.Name
+				}
+				answer[from] = run
+				runs = append(runs, run)
+			}
+			step, err := bucket.restoreStep(copied, input, runs, ns)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		}
+		copied.Spec.Steps = append(steps, copied.Spec.Steps...)
+		if bucket.SecretName != "" {
+			copied.Spec.Volumes = append(copied.Spec.Volumes, bucket.secretVolume())
+		}
+		_, err = CreateOrUpdateTask(tektonClient, ns, copied)
+		if err != nil {
+			return nil, err
+		}
+		pt.TaskRef.Name = copied.Name
+	}
+	return answer, nil
+}
+
+// restoreStep returns the step which copies the outputs of the tasks of the given PipelineRuns which the input came
+// from into the directory of the input of the task. The input is made optional so that its resource is not fetched
+// again, e.g. by cloning the git repository
+func (b *ArtifactBucket) restoreStep(task *pipelineapi.Task, input RestoredInput, runs []string, ns string) (pipelineapi.Step, error) {
+	targetPath := ""
+	found := false
+	if task.Spec.Inputs != nil {
+		for i := range task.Spec.Inputs.Resources {
+			resource := &task.Spec.Inputs.Resources[i]
+			if resource.Name == input.Name {
+				resource.Optional = true
+				targetPath = resource.TargetPath
+				found = true
+			}
+		}
+	}
+	if !found {
+		return pipelineapi.Step{}, fmt.Errorf("Task %s has no input %s", task.Name, input.Name)
+	}
+	if targetPath == "" {
+		targetPath = input.Name
+	}
+	dir := path.Join("/workspace", targetPath)
+	commands := []string{fmt.Sprintf("mkdir -p %s", dir)}
+	for i, from := range input.From {
+		// the path Tekton stores the outputs of a task in the bucket
+		source := fmt.Sprintf("%s/%s-%s-bucket/%s/%s", b.Location, runs[i], ns, from, input.Name)
+		commands = append(commands, fmt.Sprintf("gsutil cp -P -r %s/* %s", source, dir))
+	}
+	step := pipelineapi.Step{
+		Container: corev1.Container{
+			Name:    "restore-" + input.Name,
+			Image:   artifactCopyImage,
+			Command: []string{"/bin/sh", "-c"},
+			Args:    []string{strings.Join(commands, " && ")},
+		},
+	}
+	if b.SecretName != "" {
+		mountPath := path.Join(artifactSecretDir, b.SecretName)
+		fieldName := b.FieldName
+		if fieldName == "" {
+			fieldName = "GOOGLE_APPLICATION_CREDENTIALS"
+		}
+		step.Env = []corev1.EnvVar{{Name: fieldName, Value: path.Join(mountPath, b.SecretKey)}}
+		step.VolumeMounts = []corev1.VolumeMount{{Name: b.secretVolume().Name, MountPath: mountPath}}
+	}
+	return step, nil
+}
+
+func (b *ArtifactBucket) secretVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: "volume-bucket-" + b.SecretName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: b.SecretName},
+		},
+	}
+}
+
+// PipelineFromStage returns copies of the Pipeline and its PipelineStructure with the given name which start at the
+// stage with the given name. The tasks of the stages before it are removed. The inputs the remaining tasks took
+// from them are removed from the tasks and returned, keyed by the name of the task, so that they can be restored
+// from the outputs of the removed tasks
+func PipelineFromStage(p *pipelineapi.Pipeline, structure *v1.PipelineStructure, stageName string, name string) (*pipelineapi.Pipeline, *v1.PipelineStructure, map[string][]RestoredInput, error) {
+	index := -1
+	var stageNames []string
+	for i, stage := range structure.Stages {
+		if stage.TaskRef == nil {
+			continue
+		}
+		stageNames = append(stageNames, stage.Name)
+		if strings.EqualFold(stage.Name, stageName) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, nil, nil, fmt.Errorf("no stage named %s in the pipeline %s. Available stages: %s", stageName, p.Name, strings.Join(stageNames, ", "))
+	}
+
+	skippedTasks := map[string]bool{}
+	skippedStages := map[string]bool{}
+	for _, stage := range structure.Stages[:index] {
+		if stage.TaskRef != nil {
+			skippedTasks[*stage.TaskRef] = true
+			skippedStages[stage.Name] = true
+		}
+	}
+
+	answer := p.DeepCopy()
+	answer.ObjectMeta = metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       p.Namespace,
+		Labels:          util.MergeMaps(p.Labels),
+		Annotations:     util.MergeMaps(p.Annotations),
+		OwnerReferences: p.OwnerReferences,
+	}
+	skippedPipelineTasks := map[string]bool{}
+	var tasks []pipelineapi.PipelineTask
+	for _, task := range answer.Spec.Tasks {
+		if task.TaskRef != nil && skippedTasks[task.TaskRef.Name] {
+			skippedPipelineTasks[task.Name] = true
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	restored := map[string][]RestoredInput{}
+	for i := range tasks {
+		task := &tasks[i]
+		task.RunAfter = removeNames(task.RunAfter, skippedPipelineTasks)
+		if task.Resources == nil {
+			continue
+		}
+		var inputs []pipelineapi.PipelineTaskInputResource
+		for _, input := range task.Resources.Inputs {
+			var from []string
+			for _, f := range input.From {
+				if skippedPipelineTasks[f] {
+					from = append(from, f)
+				}
+			}
+			if len(from) > 0 && len(from) == len(input.From) {
+				restored[task.Name] = append(restored[task.Name], RestoredInput{Name: input.Name, From: from})
+				continue
+			}
+			input.From = removeNames(input.From, skippedPipelineTasks)
+			inputs = append(inputs, input)
+		}
+		task.Resources.Inputs = inputs
+	}
+	answer.Spec.Tasks = tasks
+
+	newStructure := structure.DeepCopy()
+	newStructure.ObjectMeta = metav1.ObjectMeta{
+		Name:      name,
+		Namespace: structure.Namespace,
+		Labels:    util.MergeMaps(structure.Labels),
+	}
+	newStructure.PipelineRef = &name
+	var stages []v1.PipelineStructureStage
+	for _, stage := range newStructure.Stages {
+		if skippedStages[stage.Name] {
+			continue
+		}
+		stage.TaskRunRef = nil
+		if stage.Previous != nil && skippedStages[*stage.Previous] {
+			stage.Previous = nil
+		}
+		stage.Stages = removeNames(stage.Stages, skippedStages)
+		stage.Parallel = removeNames(stage.Parallel, skippedStages)
+		stages = append(stages, stage)
+	}
+	newStructure.Stages = stages
+	return answer, newStructure, restored, nil
+}
+
+func removeNames(names []string, removed map[string]bool) []string {
+	var answer []string
+	for _, n := range names {
+		if !removed[n] {
+			answer = append(answer, n)
+		}
+	}
+	return answer
+}
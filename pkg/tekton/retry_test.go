@@ -0,0 +1,181 @@
+// +build unit
+
+package tekton_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+const retryTestNamespace = "jx"
+
+func retryTestPipeline() (*tektonv1alpha1.Pipeline, *v1.PipelineStructure, *tektonv1alpha1.PipelineRun, *tektonv1alpha1.Task) {
+	name := "myorg-myapp-master-1"
+	p := &tektonv1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: retryTestNamespace},
+		Spec: tektonv1alpha1.PipelineSpec{
+			Tasks: []tektonv1alpha1.PipelineTask{
+				{Name: "build", TaskRef: &tektonv1alpha1.TaskRef{Name: name + "-build"}},
+				{
+					Name:     "test",
+					TaskRef:  &tektonv1alpha1.TaskRef{Name: name + "-test"},
+					RunAfter: []string{"build"},
+					Resources: &tektonv1alpha1.PipelineTaskResources{
+						Inputs: []tektonv1alpha1.PipelineTaskInputResource{{Name: "workspace", Resource: name, From: []string{"build"}}},
+					},
+				},
+				{Name: "deploy", TaskRef: &tektonv1alpha1.TaskRef{Name: name + "-deploy"}, RunAfter: []string{"test"}},
+			},
+		},
+	}
+	stage := func(stageName string, previous string) v1.PipelineStructureStage {
+		taskRef := name + "-" + stageName
+		s := v1.PipelineStructureStage{Name: stageName, TaskRef: &taskRef}
+		if previous != "" {
+			s.Previous = &previous
+		}
+		return s
+	}
+	structure := &v1.PipelineStructure{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Namespace: retryTestNamespace},
+		PipelineRef: &name,
+		Stages:      []v1.PipelineStructureStage{stage("build", ""), stage("test", "build"), stage("deploy", "test")},
+	}
+	pr := &tektonv1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: retryTestNamespace,
+			Labels: map[string]string{
+				pipeline.GroupName + pipeline.PipelineLabelKey: name,
+				"build": "1",
+			},
+		},
+		Spec: tektonv1alpha1.PipelineRunSpec{
+			PipelineRef: &tektonv1alpha1.PipelineRef{Name: name},
+		},
+	}
+	task := &tektonv1alpha1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: name + "-test", Namespace: retryTestNamespace},
+		Spec: tektonv1alpha1.TaskSpec{
+			Inputs: &tektonv1alpha1.Inputs{
+				Resources: []tektonv1alpha1.TaskResource{{ResourceDeclaration: tektonv1alpha1.ResourceDeclaration{Name: "workspace", Type: tektonv1alpha1.PipelineResourceTypeGit, TargetPath: "source"}}},
+			},
+		},
+	}
+	task.Spec.Steps = []tektonv1alpha1.Step{{Container: corev1.Container{Name: "test", Image: "maven", Command: []string{"mvn", "test"}}}}
+	return p, structure, pr, task
+}
+
+func TestPipelineFromStage(t *testing.T) {
+	p, structure, _, _ := retryTestPipeline()
+
+	answer, newStructure, restored, err := tekton.PipelineFromStage(p, structure, "test", "retried")
+	require.NoError(t, err)
+	assert.Equal(t, "retried", answer.Name)
+	require.Len(t, answer.Spec.Tasks, 2)
+	assert.Equal(t, "test", answer.Spec.Tasks[0].Name)
+	assert.Empty(t, answer.Spec.Tasks[0].RunAfter)
+	assert.Empty(t, answer.Spec.Tasks[0].Resources.Inputs, "the input from the skipped task should be restored rather than fetched")
+	assert.Equal(t, map[string][]tekton.RestoredInput{"test": {{Name: "workspace", From: []string{"build"}}}}, restored)
+	assert.Equal(t, []string{"test"}, answer.Spec.Tasks[1].RunAfter)
+
+	require.Len(t, newStructure.Stages, 2)
+	assert.Equal(t, "retried", *newStructure.PipelineRef)
+	assert.Nil(t, newStructure.Stages[0].Previous)
+	assert.Equal(t, "test", *newStructure.Stages[1].Previous)
+	assert.Len(t, p.Spec.Tasks, 3, "the original Pipeline should not be modified")
+
+	_, _, _, err = tekton.PipelineFromStage(p, structure, "release", "retried")
+	assert.Error(t, err)
+}
+
+func TestRetryPipelineRun(t *testing.T) {
+	p, structure, pr, task := retryTestPipeline()
+	tektonClient := tektonfake.NewSimpleClientset(p, pr, task)
+	jxClient := jxfake.NewSimpleClientset(structure)
+	kubeClient := kubefake.NewSimpleClientset()
+
+	retry, err := tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, retryTestNamespace, pr, "")
+	require.NoError(t, err)
+	assert.Equal(t, "myorg-myapp-master-1-retry-1", retry.Name)
+	assert.Equal(t, 1, tekton.RetryAttempt(retry))
+	assert.Equal(t, "1", retry.Labels["build"])
+	assert.Equal(t, p.Name, retry.Spec.PipelineRef.Name)
+
+	retry, err = tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, retryTestNamespace, retry, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "myorg-myapp-master-1-retry-2", retry.Name)
+	assert.Equal(t, 2, tekton.RetryAttempt(retry))
+	assert.Equal(t, retry.Name, retry.Spec.PipelineRef.Name)
+
+	newPipeline, err := tektonClient.TektonV1alpha1().Pipelines(retryTestNamespace).Get(retry.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, newPipeline.Spec.Tasks, 1)
+	assert.Equal(t, "deploy", newPipeline.Spec.Tasks[0].Name)
+
+	newStructure, err := tekton.StructureForPipelineRun(jxClient, retryTestNamespace, retry)
+	require.NoError(t, err)
+	require.Len(t, newStructure.Stages, 1)
+	assert.Equal(t, retry.Name, *newStructure.PipelineRunRef)
+}
+
+func TestRetryPipelineRunRestoresOutputsOfSkippedStages(t *testing.T) {
+	p, structure, pr, task := retryTestPipeline()
+	tektonClient := tektonfake.NewSimpleClientset(p, pr, task)
+	jxClient := jxfake.NewSimpleClientset(structure)
+	kubeClient := kubefake.NewSimpleClientset()
+
+	_, err := tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, retryTestNamespace, pr, "test")
+	assert.Error(t, err, "the outputs on the PVC of a completed PipelineRun cannot be restored")
+
+	_, err = kubeClient.CoreV1().ConfigMaps(retryTestNamespace).Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: tekton.ConfigMapArtifactBucket, Namespace: retryTestNamespace},
+		Data: map[string]string{
+			"location":                           "gs://my-artifacts",
+			"bucket.service.account.secret.name": "bucket-sa",
+			"bucket.service.account.secret.key":  "service_account.json",
+		},
+	})
+	require.NoError(t, err)
+
+	retry, err := tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, retryTestNamespace, pr, "test")
+	require.NoError(t, err)
+	assert.Equal(t, `{"build":"myorg-myapp-master-1"}`, retry.Annotations[tekton.AnnotationTaskOutputs])
+
+	newPipeline, err := tektonClient.TektonV1alpha1().Pipelines(retryTestNamespace).Get(retry.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, newPipeline.Spec.Tasks, 2)
+	assert.Equal(t, retry.Name+"-test", newPipeline.Spec.Tasks[0].TaskRef.Name)
+
+	restoreTask, err := tektonClient.TektonV1alpha1().Tasks(retryTestNamespace).Get(retry.Name+"-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, restoreTask.Spec.Inputs.Resources[0].Optional, "the workspace should not be cloned again")
+	require.Len(t, restoreTask.Spec.Steps, 2)
+	restore := restoreTask.Spec.Steps[0]
+	assert.Equal(t, []string{"mkdir -p /workspace/source && gsutil cp -P -r gs://my-artifacts/myorg-myapp-master-1-jx-bucket/build/workspace/* /workspace/source"}, restore.Args)
+	assert.Equal(t, []corev1.EnvVar{{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: "/var/bucketsecret/bucket-sa/service_account.json"}}, restore.Env)
+	assert.Equal(t, "test", restoreTask.Spec.Steps[1].Name)
+	require.Len(t, restoreTask.Spec.Volumes, 1)
+	assert.Equal(t, "bucket-sa", restoreTask.Spec.Volumes[0].Secret.SecretName)
+
+	original, err := tektonClient.TektonV1alpha1().Tasks(retryTestNamespace).Get(task.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, original.Spec.Steps, 1, "the original Task should not be modified")
+
+	// retrying the retry should restore the outputs stored by the original PipelineRun
+	retry, err = tekton.RetryPipelineRun(kubeClient, tektonClient, jxClient, retryTestNamespace, retry, "test")
+	require.NoError(t, err)
+	assert.Equal(t, "myorg-myapp-master-1-retry-2", retry.Name)
+	assert.Equal(t, `{"build":"myorg-myapp-master-1"}`, retry.Annotations[tekton.AnnotationTaskOutputs])
+}
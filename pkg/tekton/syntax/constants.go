@@ -15,4 +15,14 @@ const (
 
 	// DefaultContainerImage - the default image used for pipelines if none is specified.
 	DefaultContainerImage = "gcr.io/jenkinsxio/builder-maven"
+
+	// CacheVolumeName - the name of the volume for the PersistentVolumeClaim of a shared build cache.
+	CacheVolumeName = "build-cache"
+
+	// CacheMountPath - the path the PersistentVolumeClaim of a shared build cache is mounted at.
+	CacheMountPath = "/build-cache"
+
+	// CacheKeyFile - the file the cache key is rendered into before the steps of a stage run, so the same key is used
+	// to save the cache after the steps even if they change the files the key is a checksum of.
+	CacheKeyFile = "/workspace/build-cache-key"
 )
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -132,6 +133,19 @@ type RootOptions struct {
 	DistributeParallelAcrossNodes bool                `json:"distributeParallelAcrossNodes,omitempty"`
 	Tolerations                   []corev1.Toleration `json:"tolerations,omitempty"`
 	PodLabels                     map[string]string   `json:"podLabels,omitempty"`
+	Cache                         *Cache              `json:"cache,omitempty"`
+}
+
+// Cache defines directories which are restored before the steps of a stage run and saved afterwards, so that
+// dependencies do not need to be downloaded on every build
+type Cache struct {
+	// Key is a template evaluated in the workspace to give the name of the cache entry,
+	// e.g. 'maven-{{ checksum "pom.xml" }}'
+	Key   string   `json:"key"`
+	Paths []string `json:"paths"`
+	// ClaimName is the name of an optional PersistentVolumeClaim shared by builds to store the cache entries in
+	// rather than the team's storage location
+	ClaimName string `json:"claimName,omitempty"`
 }
 
 // Stash defines files to be saved for use in a later stage, marked with a name
@@ -456,7 +470,9 @@ func (a *Agent) GetImage() string {
 // MangleToRfc1035Label - Task/Step names need to be RFC 1035/1123 compliant DNS labels, so we mangle
 // them to make them compliant. Results should match the following regex and be
 // no more than 63 characters long:
-//     [a-z]([-a-z0-9]*[a-z0-9])?
+//
+//	[a-z]([-a-z0-9]*[a-z0-9])?
+//
 // cf. https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#names
 // body is assumed to have at least one ASCII letter.
 // suffix is assumed to be alphanumeric and non-empty.
@@ -814,6 +830,10 @@ func validateRootOptions(o *RootOptions, volumes []*corev1.Volume, kubeClient ku
 			}
 		}
 
+		if err := validateCache(o.Cache); err != nil {
+			return err.ViaField("cache")
+		}
+
 		return validateContainerOptions(o.ContainerOptions, volumes).ViaField("containerOptions")
 	}
 
@@ -1015,6 +1035,33 @@ func validateStash(s *Stash) *apis.FieldError {
 	return nil
 }
 
+func validateCache(c *Cache) *apis.FieldError {
+	if c != nil {
+		if c.Key == "" {
+			return &apis.FieldError{
+				Message: "The cache key must be provided",
+				Paths:   []string{"key"},
+			}
+		}
+		if len(c.Paths) == 0 {
+			return &apis.FieldError{
+				Message: "paths to cache must be provided",
+				Paths:   []string{"paths"},
+			}
+		}
+		for i, p := range c.Paths {
+			if p == "" {
+				return &apis.FieldError{
+					Message: "cache paths cannot be empty",
+					Paths:   []string{fmt.Sprintf("paths[%d]", i)},
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 func validateWorkspace(w string) *apis.FieldError {
 	if w == "" {
 		return &apis.FieldError{
@@ -1413,6 +1460,7 @@ type stageToTaskParams struct {
 	parentWorkspace      string
 	parentContainer      *corev1.Container
 	parentVolumes        []*corev1.Volume
+	parentCache          *Cache
 	depth                int8
 	enclosingStage       *transformedStage
 	previousSiblingStage *transformedStage
@@ -1425,6 +1473,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 
 	stageContainer := &corev1.Container{}
 	var stageVolumes []*corev1.Volume
+	stageCache := params.parentCache
 
 	if params.stage.Options != nil {
 		o := params.stage.Options
@@ -1438,6 +1487,9 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				stageContainer = o.ContainerOptions
 			}
 			stageVolumes = o.Volumes
+			if o.Cache != nil {
+				stageCache = o.Cache
+			}
 		}
 		if o.Stash != nil {
 			return nil, errors.New("Stash on stage not yet supported")
@@ -1499,6 +1551,19 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 		t.Spec.Steps = append(prependedSteps, t.Spec.Steps...)
 		t.SetDefaults(context.Background())
 
+		var saveCacheSteps []tektonv1alpha1.Step
+		var cachePathMounts []corev1.VolumeMount
+		if stageCache != nil {
+			restoreStep, saveStep, cacheVolumes, pathMounts, err := cacheSteps(stageCache, env, stageContainer, params.parentParams.DefaultImage, params.parentParams.VersionsDir)
+			if err != nil {
+				return nil, err
+			}
+			t.Spec.Steps = append(t.Spec.Steps, restoreStep)
+			saveCacheSteps = append(saveCacheSteps, saveStep)
+			stageVolumes = append(stageVolumes, cacheVolumes...)
+			cachePathMounts = pathMounts
+		}
+
 		ws := &tektonv1alpha1.TaskResource{
 			ResourceDeclaration: tektonv1alpha1.ResourceDeclaration{
 				Name:       "workspace",
@@ -1542,6 +1607,10 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				volumes[k] = v
 			}
 		}
+		t.Spec.Steps = append(t.Spec.Steps, saveCacheSteps...)
+		for i := range t.Spec.Steps {
+			addVolumeMounts(&t.Spec.Steps[i].Container, cachePathMounts)
+		}
 
 		// Avoid nondeterministic results by sorting the keys and appending volumes in that order.
 		var volNames []string
@@ -1577,6 +1646,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				parentWorkspace:      *ts.Stage.Options.Workspace,
 				parentContainer:      stageContainer,
 				parentVolumes:        stageVolumes,
+				parentCache:          stageCache,
 				depth:                params.depth + 1,
 				enclosingStage:       &ts,
 				previousSiblingStage: nestedPreviousSibling,
@@ -1606,6 +1676,7 @@ func stageToTask(params stageToTaskParams) (*transformedStage, error) {
 				parentWorkspace: *ts.Stage.Options.Workspace,
 				parentContainer: stageContainer,
 				parentVolumes:   stageVolumes,
+				parentCache:     stageCache,
 				depth:           params.depth + 1,
 				enclosingStage:  &ts,
 			})
@@ -1882,6 +1953,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 
 	var parentContainer *corev1.Container
 	var parentVolumes []*corev1.Volume
+	var parentCache *Cache
 
 	baseWorkingDir := j.WorkingDir
 
@@ -1892,6 +1964,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 		}
 		parentContainer = o.ContainerOptions
 		parentVolumes = o.Volumes
+		parentCache = o.Cache
 	}
 
 	p := &tektonv1alpha1.Pipeline{
@@ -1944,6 +2017,7 @@ func (j *ParsedPipeline) GenerateCRDs(params CRDsFromPipelineParams) (*tektonv1a
 			parentWorkspace:      "default",
 			parentContainer:      parentContainer,
 			parentVolumes:        parentVolumes,
+			parentCache:          parentCache,
 			depth:                0,
 			previousSiblingStage: previousStage,
 		})
@@ -2185,16 +2259,9 @@ func validateStageNames(j *ParsedPipeline) (err *apis.FieldError) {
 }
 
 func builderHomeStep(envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string, versionsDir string) ([]tektonv1alpha1.Step, error) {
-	var err error
-	image := defaultImage
-	if image == "" {
-		image = os.Getenv("BUILDER_JX_IMAGE")
-		if image == "" {
-			image, err = versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
-			if err != nil {
-				return []tektonv1alpha1.Step{}, err
-			}
-		}
+	image, err := builderJxImage(defaultImage, versionsDir)
+	if err != nil {
+		return []tektonv1alpha1.Step{}, err
 	}
 
 	builderHomeContainer := &corev1.Container{
@@ -2219,6 +2286,114 @@ func builderHomeStep(envs []corev1.EnvVar, parentContainer *corev1.Container, de
 	}}, nil
 }
 
+// builderJxImage returns the image containing the jx binary used for the steps added to each stage
+func builderJxImage(defaultImage string, versionsDir string) (string, error) {
+	if defaultImage != "" {
+		return defaultImage, nil
+	}
+	image := os.Getenv("BUILDER_JX_IMAGE")
+	if image != "" {
+		return image, nil
+	}
+	return versionstream.ResolveDockerImage(versionsDir, GitMergeImage)
+}
+
+// cacheSteps returns the steps which restore the cache before the steps of a stage and save it afterwards, along with
+// the volumes they need. The restore step renders the key into the CacheKeyFile so that the save step uses the same key.
+//
+// Steps only share the workspace, the home directory and the volumes they mount, so each cache path outside of them
+// gets an emptyDir volume which has to be mounted at the path in every step of the stage; those mounts are returned
+func cacheSteps(cache *Cache, envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string, versionsDir string) (tektonv1alpha1.Step, tektonv1alpha1.Step, []*corev1.Volume, []corev1.VolumeMount, error) {
+	image, err := builderJxImage(defaultImage, versionsDir)
+	if err != nil {
+		return tektonv1alpha1.Step{}, tektonv1alpha1.Step{}, nil, nil, err
+	}
+
+	args := []string{"--key", cache.Key, "--key-file", CacheKeyFile}
+	var volumes []*corev1.Volume
+	var pathMounts []corev1.VolumeMount
+	for i, p := range cache.Paths {
+		args = append(args, "--path", p)
+		if !cachePathNeedsVolume(p) {
+			continue
+		}
+		name := fmt.Sprintf("%s-path-%d", CacheVolumeName, i)
+		volumes = append(volumes, &corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		pathMounts = append(pathMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Clean(p),
+		})
+	}
+	var volumeMounts []corev1.VolumeMount
+	if cache.ClaimName != "" {
+		args = append(args, "--dir", CacheMountPath)
+		volumes = append(volumes, &corev1.Volume{
+			Name: CacheVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: cache.ClaimName,
+				},
+			},
+		})
+		volumeMounts = []corev1.VolumeMount{{
+			Name:      CacheVolumeName,
+			MountPath: CacheMountPath,
+		}}
+	}
+
+	var steps []tektonv1alpha1.Step
+	for _, action := range []string{"restore", "save"} {
+		c := &corev1.Container{
+			Name:         action + "-cache",
+			Image:        image,
+			Command:      []string{"jx"},
+			Args:         append([]string{"step", "cache", action}, args...),
+			WorkingDir:   "/workspace/source",
+			Env:          envs,
+			VolumeMounts: volumeMounts,
+		}
+		if parentContainer != nil {
+			c, err = MergeContainers(parentContainer, c)
+			if err != nil {
+				return tektonv1alpha1.Step{}, tektonv1alpha1.Step{}, nil, nil, err
+			}
+		}
+		steps = append(steps, tektonv1alpha1.Step{Container: *c})
+	}
+	return steps[0], steps[1], volumes, pathMounts, nil
+}
+
+// cachePathNeedsVolume returns true if the cache path is not in the workspace or the home directory which are shared
+// by the steps of a stage already
+func cachePathNeedsVolume(p string) bool {
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	p = path.Clean(p)
+	return p != "/workspace" && !strings.HasPrefix(p, "/workspace/")
+}
+
+// addVolumeMounts adds the volume mounts to the container unless it already mounts something at their paths
+func addVolumeMounts(c *corev1.Container, mounts []corev1.VolumeMount) {
+	for _, m := range mounts {
+		mounted := false
+		for _, existing := range c.VolumeMounts {
+			if existing.MountPath == m.MountPath {
+				mounted = true
+				break
+			}
+		}
+		if !mounted {
+			c.VolumeMounts = append(c.VolumeMounts, m)
+		}
+	}
+}
+
 // todo JR lets remove this when we switch tekton to using git merge type pipelineresources
 func getDefaultTaskSpec(envs []corev1.EnvVar, parentContainer *corev1.Container, defaultImage string, versionsDir string) (tektonv1alpha1.TaskSpec, error) {
 	var err error
@@ -11,6 +11,7 @@ import (
 	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/v2/pkg/versionstream"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/google/go-cmp/cmp"
@@ -2375,3 +2376,106 @@ func TestParsedPipelineHelpers(t *testing.T) {
 		t.Fatalf("ParsedPipeline diff -want, +got: %v", d)
 	}
 }
+
+func TestGenerateCRDsWithCache(t *testing.T) {
+	ctx := context.Background()
+	testVersionsDir := filepath.Join("test_data", "stable_versions")
+
+	parsed := &syntax.ParsedPipeline{
+		Agent: &syntax.Agent{
+			Image: "some-image",
+		},
+		Options: &syntax.RootOptions{
+			Cache: &syntax.Cache{
+				Key:   `maven-{{ checksum "pom.xml" }}`,
+				Paths: []string{"/root/.mvnrepository"},
+			},
+		},
+		Stages: []syntax.Stage{{
+			Name: "build",
+			Steps: []syntax.Step{{
+				Command:   "mvn",
+				Arguments: []string{"install"},
+			}},
+		}, {
+			Name: "shared volume",
+			Options: &syntax.StageOptions{
+				RootOptions: &syntax.RootOptions{
+					Cache: &syntax.Cache{
+						Key:       "node",
+						Paths:     []string{"node_modules"},
+						ClaimName: "build-cache-pvc",
+					},
+				},
+			},
+			Steps: []syntax.Step{{
+				Command:   "npm",
+				Arguments: []string{"install"},
+			}},
+		}},
+	}
+	assert.Nil(t, parsed.Validate(ctx))
+
+	crdParams := syntax.CRDsFromPipelineParams{
+		PipelineIdentifier: "somepipeline",
+		BuildIdentifier:    "1",
+		Namespace:          "jx",
+		VersionsDir:        testVersionsDir,
+		SourceDir:          "source",
+	}
+	_, tasks, _, err := parsed.GenerateCRDs(crdParams)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	stepNames := func(task *tektonv1alpha1.Task) []string {
+		var names []string
+		for _, s := range task.Spec.Steps {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+	findStep := func(task *tektonv1alpha1.Task, name string) tektonv1alpha1.Step {
+		for _, s := range task.Spec.Steps {
+			if s.Name == name {
+				return s
+			}
+		}
+		t.Fatalf("no step %s in task %s", name, task.Name)
+		return tektonv1alpha1.Step{}
+	}
+
+	assert.Equal(t, []string{"setup-builder-home", "git-merge", "restore-cache", "step2", "save-cache"}, stepNames(tasks[0]))
+	restore := findStep(tasks[0], "restore-cache")
+	assert.Equal(t, []string{"jx"}, restore.Command)
+	assert.Equal(t, []string{"step", "cache", "restore", "--key", `maven-{{ checksum "pom.xml" }}`, "--key-file", syntax.CacheKeyFile, "--path", "/root/.mvnrepository"}, restore.Args)
+	pathMount := corev1.VolumeMount{Name: syntax.CacheVolumeName + "-path-0", MountPath: "/root/.mvnrepository"}
+	for _, s := range tasks[0].Spec.Steps {
+		assert.Contains(t, s.VolumeMounts, pathMount, "the cache path should be shared with step %s", s.Name)
+	}
+	var pathVolume *corev1.Volume
+	for i, v := range tasks[0].Spec.Volumes {
+		if v.Name == pathMount.Name {
+			pathVolume = &tasks[0].Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, pathVolume)
+	assert.NotNil(t, pathVolume.EmptyDir)
+
+	assert.Equal(t, []string{"setup-builder-home", "restore-cache", "step2", "save-cache"}, stepNames(tasks[1]))
+	save := findStep(tasks[1], "save-cache")
+	assert.Equal(t, []string{"step", "cache", "save", "--key", "node", "--key-file", syntax.CacheKeyFile, "--path", "node_modules", "--dir", syntax.CacheMountPath}, save.Args)
+	for _, s := range tasks[1].Spec.Steps {
+		for _, m := range s.VolumeMounts {
+			assert.NotEqual(t, syntax.CacheVolumeName+"-path-0", m.Name, "a cache path in the workspace does not need a volume")
+		}
+	}
+	assert.Contains(t, save.VolumeMounts, corev1.VolumeMount{Name: syntax.CacheVolumeName, MountPath: syntax.CacheMountPath})
+
+	var claimName string
+	for _, v := range tasks[1].Spec.Volumes {
+		if v.Name == syntax.CacheVolumeName && v.PersistentVolumeClaim != nil {
+			claimName = v.PersistentVolumeClaim.ClaimName
+		}
+	}
+	assert.Equal(t, "build-cache-pvc", claimName)
+}
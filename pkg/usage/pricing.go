@@ -0,0 +1,61 @@
+package usage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// PriceSheet the prices used to turn resource usage into money
+type PriceSheet struct {
+	Currency        string  `json:"currency,omitempty"`
+	CPUCoreHour     float64 `json:"cpuCoreHour"`
+	MemoryGBHour    float64 `json:"memoryGBHour"`
+	PreviewDiscount float64 `json:"previewDiscount,omitempty"`
+}
+
+// LoadPriceSheet loads the price sheet from the given YAML file
+func LoadPriceSheet(fileName string) (*PriceSheet, error) {
+	exists, err := util.FileExists(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", fileName)
+	}
+	if !exists {
+		return nil, fmt.Errorf("price sheet file %s does not exist", fileName)
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load price sheet %s", fileName)
+	}
+	sheet := &PriceSheet{}
+	err = yaml.Unmarshal(data, sheet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal YAML price sheet %s", fileName)
+	}
+	return sheet, nil
+}
+
+// Apply calculates the cost of each of the usages.
+//
+// The optional preview discount is a percentage taken off the cost of previews, e.g. when they run on preemptible nodes
+func (p *PriceSheet) Apply(usages []*Usage) {
+	for _, u := range usages {
+		u.Cost = u.CPUCoreHours*p.CPUCoreHour + u.MemoryGBHours*p.MemoryGBHour
+		if u.Kind == KindPreview && p.PreviewDiscount > 0 {
+			u.Cost = u.Cost * (100 - p.PreviewDiscount) / 100
+		}
+	}
+}
+
+// FormatCost formats the cost using the currency of the price sheet
+func (p *PriceSheet) FormatCost(cost float64) string {
+	currency := strings.TrimSpace(p.Currency)
+	if currency == "" {
+		return fmt.Sprintf("%.2f", cost)
+	}
+	return fmt.Sprintf("%.2f %s", cost, currency)
+}
@@ -0,0 +1,45 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+)
+
+// SummaryText returns a plain text summary of the usages suitable for posting to a chat channel,
+// listing the repositories which used the most resources first
+func SummaryText(team string, usages []*Usage, sheet *PriceSheet, since time.Time, until time.Time, top int) string {
+	summary := Summarize(usages)
+	sort.SliceStable(summary, func(i, j int) bool {
+		if summary[i].Cost != summary[j].Cost {
+			return summary[i].Cost > summary[j].Cost
+		}
+		return summary[i].CPUCoreHours > summary[j].CPUCoreHours
+	})
+	total := Total(team, usages)
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("*Build and preview usage of team %s from %s to %s*\n", team, util.FormatDate(since), util.FormatDate(until)))
+	buf.WriteString(fmt.Sprintf("Total: %d runs, %.1f CPU core hours, %.1f GB memory hours%s\n", total.Runs, total.CPUCoreHours, total.MemoryGBHours, costText(sheet, total.Cost)))
+	for i, u := range summary {
+		if top > 0 && i >= top {
+			break
+		}
+		name := u.Repository
+		if u.Owner != "" {
+			name = u.Owner + "/" + u.Repository
+		}
+		buf.WriteString(fmt.Sprintf("• %s %s: %d runs, %.1f CPU core hours, %.1f GB memory hours%s\n", name, u.Kind, u.Runs, u.CPUCoreHours, u.MemoryGBHours, costText(sheet, u.Cost)))
+	}
+	return buf.String()
+}
+
+func costText(sheet *PriceSheet, cost float64) string {
+	if sheet == nil {
+		return ""
+	}
+	return ", " + sheet.FormatCost(cost)
+}
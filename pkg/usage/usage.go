@@ -0,0 +1,295 @@
+package usage
+
+import (
+	"sort"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KindBuild the usage of pipeline build pods
+	KindBuild = "build"
+
+	// KindPreview the usage of preview environments
+	KindPreview = "preview"
+
+	bytesPerGB = 1024 * 1024 * 1024
+)
+
+// Requests the CPU and memory requested by a pod
+type Requests struct {
+	CPU      float64 `json:"cpu"`
+	MemoryGB float64 `json:"memoryGB"`
+}
+
+// Add adds the other requests to these requests
+func (r Requests) Add(other Requests) Requests {
+	return Requests{
+		CPU:      r.CPU + other.CPU,
+		MemoryGB: r.MemoryGB + other.MemoryGB,
+	}
+}
+
+// Max returns the maximum CPU and memory of these requests and the other requests
+func (r Requests) Max(other Requests) Requests {
+	answer := r
+	if other.CPU > answer.CPU {
+		answer.CPU = other.CPU
+	}
+	if other.MemoryGB > answer.MemoryGB {
+		answer.MemoryGB = other.MemoryGB
+	}
+	return answer
+}
+
+// ContainerRequests returns the requests of the container
+func ContainerRequests(container *corev1.Container) Requests {
+	requests := container.Resources.Requests
+	answer := Requests{}
+	if q, ok := requests[corev1.ResourceCPU]; ok {
+		answer.CPU = float64(q.MilliValue()) / 1000
+	}
+	if q, ok := requests[corev1.ResourceMemory]; ok {
+		answer.MemoryGB = float64(q.Value()) / bytesPerGB
+	}
+	return answer
+}
+
+// PodRequests returns the total requests of the containers of the pod
+func PodRequests(pod *corev1.Pod) Requests {
+	answer := Requests{}
+	for i := range pod.Spec.Containers {
+		answer = answer.Add(ContainerRequests(&pod.Spec.Containers[i]))
+	}
+	return answer
+}
+
+// TaskRequests returns the requests of the pod of a Task generated by GenerateCRDs.
+//
+// As the steps run one after the other the pod requests the largest CPU and memory of any step,
+// defaulting to the step template
+func TaskRequests(task *tektonv1alpha1.Task) Requests {
+	answer := Requests{}
+	if task.Spec.StepTemplate != nil {
+		answer = ContainerRequests(task.Spec.StepTemplate)
+	}
+	for i := range task.Spec.Steps {
+		answer = answer.Max(ContainerRequests(&task.Spec.Steps[i].Container))
+	}
+	return answer
+}
+
+// Usage the resources consumed by a build or preview over time
+type Usage struct {
+	Team           string  `json:"team,omitempty"`
+	Kind           string  `json:"kind"`
+	Owner          string  `json:"owner,omitempty"`
+	Repository     string  `json:"repository"`
+	Branch         string  `json:"branch,omitempty"`
+	Runs           int     `json:"runs"`
+	CPUCoreHours   float64 `json:"cpuCoreHours"`
+	MemoryGBHours  float64 `json:"memoryGBHours"`
+	Cost           float64 `json:"cost,omitempty"`
+	MissingRequest int     `json:"missingRequests,omitempty"`
+}
+
+// Key returns the key used to aggregate usage
+func (u *Usage) Key() string {
+	return u.Team + "/" + u.Kind + "/" + u.Owner + "/" + u.Repository + "/" + u.Branch
+}
+
+// AddRequests adds the requests used for the given duration
+func (u *Usage) AddRequests(requests Requests, duration time.Duration) {
+	hours := duration.Hours()
+	u.CPUCoreHours += requests.CPU * hours
+	u.MemoryGBHours += requests.MemoryGB * hours
+}
+
+// TaskIndex indexes the Tasks generated for pipeline builds by the stage they were generated for
+type TaskIndex map[string]*tektonv1alpha1.Task
+
+// NewTaskIndex creates an index of the given Tasks
+func NewTaskIndex(tasks []tektonv1alpha1.Task) TaskIndex {
+	answer := TaskIndex{}
+	for i := range tasks {
+		task := &tasks[i]
+		labels := task.Labels
+		answer[taskKey(labels[tekton.LabelOwner], labels[tekton.LabelRepo], labels[tekton.LabelBranch], labels[tekton.LabelBuild], labels[syntax.LabelStageName])] = task
+	}
+	return answer
+}
+
+// Find finds the Task of the stage of the activity or nil if it cannot be found
+func (i TaskIndex) Find(activity *v1.PipelineActivity, stage string) *tektonv1alpha1.Task {
+	return i[taskKey(activity.RepositoryOwner(), activity.RepositoryName(), activity.BranchName(), activity.Spec.Build, syntax.MangleToRfc1035Label(stage, ""))]
+}
+
+func taskKey(owner, repo, branch, build, stage string) string {
+	return owner + "/" + repo + "/" + branch + "/" + build + "/" + stage
+}
+
+// BuildUsage calculates the usage of the stages of the activities which started within the time window.
+//
+// The requests of each stage are taken from the Task generated for it. If the Task has been removed the default
+// requests are used and the usage records a missing request
+func BuildUsage(team string, activities []v1.PipelineActivity, tasks TaskIndex, defaultRequests Requests, since time.Time, until time.Time) []*Usage {
+	m := map[string]*Usage{}
+	for i := range activities {
+		a := &activities[i]
+		started := a.Spec.StartedTimestamp
+		if started == nil || started.Time.Before(since) || started.Time.After(until) {
+			continue
+		}
+		u := &Usage{
+			Team:       team,
+			Kind:       KindBuild,
+			Owner:      a.RepositoryOwner(),
+			Repository: a.RepositoryName(),
+			Branch:     a.BranchName(),
+		}
+		if existing := m[u.Key()]; existing != nil {
+			u = existing
+		} else {
+			m[u.Key()] = u
+		}
+		u.Runs++
+		for _, step := range a.Spec.Steps {
+			stage := step.Stage
+			if step.Kind != v1.ActivityStepKindTypeStage || stage == nil {
+				continue
+			}
+			duration := stepDuration(&stage.CoreActivityStep, until)
+			if duration <= 0 {
+				continue
+			}
+			requests := defaultRequests
+			task := tasks.Find(a, stage.Name)
+			if task != nil {
+				requests = TaskRequests(task)
+			} else {
+				u.MissingRequest++
+			}
+			u.AddRequests(requests, duration)
+		}
+	}
+	return sortUsages(m)
+}
+
+// PreviewUsage calculates the usage of the pods in the namespaces of the preview environments over the time window
+func PreviewUsage(team string, previews []v1.Environment, podsByNamespace map[string][]corev1.Pod, since time.Time, until time.Time) []*Usage {
+	m := map[string]*Usage{}
+	for i := range previews {
+		env := &previews[i]
+		if env.Spec.Kind != v1.EnvironmentKindTypePreview {
+			continue
+		}
+		u := &Usage{
+			Team:       team,
+			Kind:       KindPreview,
+			Repository: env.Spec.PreviewGitSpec.ApplicationName,
+		}
+		if env.Spec.PreviewGitSpec.Name != "" {
+			u.Branch = "PR-" + env.Spec.PreviewGitSpec.Name
+		}
+		gitInfo, err := gits.ParseGitURL(env.Spec.Source.URL)
+		if err == nil {
+			u.Owner = gitInfo.Organisation
+			u.Repository = gitInfo.Name
+		}
+		if u.Repository == "" {
+			u.Repository = env.Name
+		}
+		if existing := m[u.Key()]; existing != nil {
+			u = existing
+		} else {
+			m[u.Key()] = u
+		}
+		u.Runs++
+		for j := range podsByNamespace[env.Spec.Namespace] {
+			pod := &podsByNamespace[env.Spec.Namespace][j]
+			start := pod.CreationTimestamp.Time
+			if pod.Status.StartTime != nil {
+				start = pod.Status.StartTime.Time
+			}
+			if start.Before(since) {
+				start = since
+			}
+			end := until
+			if pod.DeletionTimestamp != nil && pod.DeletionTimestamp.Time.Before(end) {
+				end = pod.DeletionTimestamp.Time
+			}
+			if end.After(start) {
+				u.AddRequests(PodRequests(pod), end.Sub(start))
+			}
+		}
+	}
+	return sortUsages(m)
+}
+
+// Summarize aggregates the usages by team, kind and repository removing the branches
+func Summarize(usages []*Usage) []*Usage {
+	m := map[string]*Usage{}
+	for _, u := range usages {
+		key := &Usage{
+			Team:       u.Team,
+			Kind:       u.Kind,
+			Owner:      u.Owner,
+			Repository: u.Repository,
+		}
+		total := m[key.Key()]
+		if total == nil {
+			total = key
+			m[key.Key()] = total
+		}
+		total.Runs += u.Runs
+		total.CPUCoreHours += u.CPUCoreHours
+		total.MemoryGBHours += u.MemoryGBHours
+		total.Cost += u.Cost
+		total.MissingRequest += u.MissingRequest
+	}
+	return sortUsages(m)
+}
+
+// Total returns the total of the usages
+func Total(team string, usages []*Usage) *Usage {
+	answer := &Usage{
+		Team:       team,
+		Repository: "TOTAL",
+	}
+	for _, u := range usages {
+		answer.Runs += u.Runs
+		answer.CPUCoreHours += u.CPUCoreHours
+		answer.MemoryGBHours += u.MemoryGBHours
+		answer.Cost += u.Cost
+		answer.MissingRequest += u.MissingRequest
+	}
+	return answer
+}
+
+func stepDuration(step *v1.CoreActivityStep, until time.Time) time.Duration {
+	if step.StartedTimestamp == nil {
+		return 0
+	}
+	end := until
+	if step.CompletedTimestamp != nil {
+		end = step.CompletedTimestamp.Time
+	}
+	return end.Sub(step.StartedTimestamp.Time)
+}
+
+func sortUsages(m map[string]*Usage) []*Usage {
+	answer := []*Usage{}
+	for _, u := range m {
+		answer = append(answer, u)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Key() < answer[j].Key()
+	})
+	return answer
+}
@@ -0,0 +1,205 @@
+// +build unit
+
+package usage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/syntax"
+	"github.com/jenkins-x/jx/v2/pkg/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonv1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildUsage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	started := now.Add(-2 * time.Hour)
+
+	activities := []v1.PipelineActivity{
+		createActivity("1", "master", started, time.Hour),
+		createActivity("2", "master", started, 30*time.Minute),
+		createActivity("1", "PR-1", started, time.Hour),
+		// outside of the time window
+		createActivity("old", "master", since.Add(-time.Hour), time.Hour),
+	}
+	tasks := []tektonv1alpha1.Task{
+		createTask("1", "master", "2", "4Gi", "500m", "1Gi"),
+		createTask("2", "master", "1", "2Gi", "", ""),
+	}
+	defaultRequests := usage.Requests{CPU: 1, MemoryGB: 1}
+
+	usages := usage.BuildUsage("jx", activities, usage.NewTaskIndex(tasks), defaultRequests, since, now)
+	require.Len(t, usages, 2)
+
+	master := usages[1]
+	assert.Equal(t, "master", master.Branch)
+	assert.Equal(t, 2, master.Runs)
+	assert.Equal(t, 2.5, master.CPUCoreHours, "2 cores for an hour and 1 core for half an hour")
+	assert.Equal(t, 5.0, master.MemoryGBHours)
+	assert.Equal(t, 0, master.MissingRequest)
+
+	pr := usages[0]
+	assert.Equal(t, "PR-1", pr.Branch)
+	assert.Equal(t, 1.0, pr.CPUCoreHours, "the default requests should be used")
+	assert.Equal(t, 1, pr.MissingRequest)
+
+	summary := usage.Summarize(usages)
+	require.Len(t, summary, 1)
+	assert.Equal(t, "myrepo", summary[0].Repository)
+	assert.Equal(t, 3, summary[0].Runs)
+	assert.Equal(t, 3.5, summary[0].CPUCoreHours)
+}
+
+func TestPreviewUsage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	env := v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myorg-myrepo-pr-3",
+		},
+		Spec: v1.EnvironmentSpec{
+			Kind:      v1.EnvironmentKindTypePreview,
+			Namespace: "jx-myorg-myrepo-pr-3",
+			Source: v1.EnvironmentRepository{
+				URL: "https://github.com/myorg/myrepo.git",
+			},
+			PreviewGitSpec: v1.PreviewGitSpec{
+				Name: "3",
+			},
+		},
+	}
+	// this pod started before the time window so only the last 24 hours count
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.Time{Time: now.Add(-48 * time.Hour)},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				createContainer("250m", "512Mi"),
+				createContainer("250m", "512Mi"),
+			},
+		},
+	}
+	usages := usage.PreviewUsage("jx", []v1.Environment{env}, map[string][]corev1.Pod{env.Spec.Namespace: {pod}}, since, now)
+	require.Len(t, usages, 1)
+	u := usages[0]
+	assert.Equal(t, usage.KindPreview, u.Kind)
+	assert.Equal(t, "myorg", u.Owner)
+	assert.Equal(t, "myrepo", u.Repository)
+	assert.Equal(t, "PR-3", u.Branch)
+	assert.InDelta(t, 12.0, u.CPUCoreHours, 0.001)
+	assert.InDelta(t, 24.0, u.MemoryGBHours, 0.001)
+}
+
+func TestPriceSheet(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "usage-prices")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "prices.yaml")
+	err = ioutil.WriteFile(fileName, []byte("currency: USD\ncpuCoreHour: 0.5\nmemoryGBHour: 0.1\npreviewDiscount: 50\n"), 0600)
+	require.NoError(t, err)
+
+	sheet, err := usage.LoadPriceSheet(fileName)
+	require.NoError(t, err)
+
+	usages := []*usage.Usage{
+		{Kind: usage.KindBuild, Repository: "myrepo", CPUCoreHours: 10, MemoryGBHours: 20},
+		{Kind: usage.KindPreview, Repository: "myrepo", CPUCoreHours: 10, MemoryGBHours: 20},
+	}
+	sheet.Apply(usages)
+	assert.InDelta(t, 7.0, usages[0].Cost, 0.001)
+	assert.InDelta(t, 3.5, usages[1].Cost, 0.001)
+	assert.Equal(t, "7.00 USD", sheet.FormatCost(usages[0].Cost))
+
+	text := usage.SummaryText("jx", usages, sheet, time.Now().Add(-7*24*time.Hour), time.Now(), 1)
+	assert.Contains(t, text, "Total: 0 runs, 20.0 CPU core hours, 40.0 GB memory hours, 10.50 USD")
+	assert.Contains(t, text, "myrepo build")
+	assert.NotContains(t, text, "myrepo preview", "only the top repository should be listed")
+
+	_, err = usage.LoadPriceSheet(filepath.Join(dir, "does-not-exist.yaml"))
+	assert.Error(t, err)
+}
+
+func createActivity(build string, branch string, started time.Time, duration time.Duration) v1.PipelineActivity {
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myorg-myrepo-" + branch + "-" + build,
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:         "myorg/myrepo/" + branch,
+			Build:            build,
+			GitOwner:         "myorg",
+			GitRepository:    "myrepo",
+			GitBranch:        branch,
+			StartedTimestamp: &metav1.Time{Time: started},
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypeStage,
+					Stage: &v1.StageActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Name:               "from build pack",
+							StartedTimestamp:   &metav1.Time{Time: started},
+							CompletedTimestamp: &metav1.Time{Time: started.Add(duration)},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createTask(build string, branch string, templateCPU string, templateMemory string, stepCPU string, stepMemory string) tektonv1alpha1.Task {
+	template := createContainer(templateCPU, templateMemory)
+	return tektonv1alpha1.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				tekton.LabelOwner:     "myorg",
+				tekton.LabelRepo:      "myrepo",
+				tekton.LabelBranch:    branch,
+				tekton.LabelBuild:     build,
+				syntax.LabelStageName: "from-build-pack",
+			},
+		},
+		Spec: tektonv1alpha1.TaskSpec{
+			TaskSpec: tektonv1beta1.TaskSpec{
+				StepTemplate: &template,
+				Steps: []tektonv1alpha1.Step{
+					{Container: createContainer(stepCPU, stepMemory)},
+				},
+			},
+		},
+	}
+}
+
+func createContainer(cpu string, memory string) corev1.Container {
+	requests := corev1.ResourceList{}
+	if cpu != "" {
+		requests[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		requests[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return corev1.Container{
+		Resources: corev1.ResourceRequirements{
+			Requests: requests,
+		},
+	}
+}
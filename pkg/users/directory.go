@@ -0,0 +1,22 @@
+package users
+
+// DirectoryUser a user of a directory such as an OIDC provider or LDAP directory
+type DirectoryUser struct {
+	Login  string
+	Name   string
+	Email  string
+	Groups []string
+}
+
+// Directory a source of users and their group membership which Users are synchronised from
+type Directory interface {
+	// ProviderKey the key of the account references and labels of the Users synchronised from the directory
+	ProviderKey() string
+
+	// Users returns the users of the directory
+	Users() ([]DirectoryUser, error)
+
+	// ListsAllUsers returns true if Users returns all the users of the directory so that users which are not
+	// returned have been removed from the directory
+	ListsAllUsers() bool
+}
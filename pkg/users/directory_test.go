@@ -0,0 +1,189 @@
+// +build unit
+
+package users_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ldapEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+var ldapEntries = []ldapEntry{
+	{"uid=jstrachan,ou=people,dc=example,dc=com", map[string][]string{
+		"uid":      {"jstrachan"},
+		"cn":       {"James Strachan"},
+		"mail":     {"james@example.com"},
+		"memberOf": {"cn=developers,ou=groups,dc=example,dc=com"},
+	}},
+	{"uid=rawlingsj,ou=people,dc=example,dc=com", map[string][]string{
+		"UID":  {"rawlingsj"},
+		"CN":   {"James Rawlings"},
+		"mail": {"rawlingsj@example.com"},
+	}},
+	{"cn=no-login,ou=people,dc=example,dc=com", map[string][]string{
+		"cn": {"No Login"},
+	}},
+	{"cn=everyone,ou=groups,dc=example,dc=com", map[string][]string{
+		"cn":     {"everyone"},
+		"member": {"uid=jstrachan,ou=people,dc=example,dc=com", "UID=rawlingsj, ou=people, dc=example, dc=com"},
+	}},
+}
+
+// startLDAPServer starts an LDAP server which accepts binds with the password and returns the entries below the
+// base DN of a search ignoring its filter. The base DNs of the searches are sent to the returned channel
+func startLDAPServer(t *testing.T, bindDN string, password string, entries []ldapEntry) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	searches := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveLDAP(conn, bindDN, password, entries, searches)
+		}
+	}()
+	return "ldap://" + listener.Addr().String(), searches
+}
+
+func serveLDAP(conn net.Conn, bindDN string, password string, entries []ldapEntry, searches chan<- string) {
+	defer conn.Close()
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		messageID := request.Children[0].Value.(int64)
+		op := request.Children[1]
+		reply := func(response *ber.Packet) {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(response)
+			conn.Write(envelope.Bytes())
+		}
+		result := func(tag ber.Tag, code int64) *ber.Packet {
+			response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+			response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+			response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+			response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+			return response
+		}
+		switch op.Tag {
+		case 0: // bind
+			code := int64(0)
+			if op.Children[1].Value.(string) != bindDN || op.Children[2].Data.String() != password {
+				code = 49 // invalid credentials
+			}
+			reply(result(1, code))
+		case 2: // unbind
+			return
+		case 3: // search
+			baseDN := op.Children[0].Value.(string)
+			searches <- baseDN
+			for _, entry := range entries {
+				if !strings.HasSuffix(entry.dn, ","+baseDN) {
+					continue
+				}
+				response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, 4, nil, "Search Result Entry")
+				response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"))
+				attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+				for name, values := range entry.attributes {
+					attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+					attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+					for _, value := range values {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+					}
+					attribute.AppendChild(set)
+					attributes.AppendChild(attribute)
+				}
+				response.AppendChild(attributes)
+				reply(response)
+			}
+			reply(result(5, 0))
+		default:
+			return
+		}
+	}
+}
+
+func TestLDAPDirectoryUsers(t *testing.T) {
+	url, searches := startLDAPServer(t, "cn=admin,dc=example,dc=com", "secret", ldapEntries)
+
+	directory := users.NewLDAPDirectory(url, "cn=admin,dc=example,dc=com", "secret", "ou=people,dc=example,dc=com")
+	directory.GroupBaseDN = "ou=groups,dc=example,dc=com"
+
+	result, err := directory.Users()
+	require.NoError(t, err)
+	assert.Equal(t, []users.DirectoryUser{
+		{Login: "jstrachan", Name: "James Strachan", Email: "james@example.com", Groups: []string{"developers", "everyone"}},
+		{Login: "rawlingsj", Name: "James Rawlings", Email: "rawlingsj@example.com", Groups: []string{"everyone"}},
+	}, result)
+	assert.Equal(t, "ou=people,dc=example,dc=com", <-searches)
+	assert.Equal(t, "ou=groups,dc=example,dc=com", <-searches)
+
+	directory.Password = "wrong"
+	_, err = directory.Users()
+	assert.Error(t, err)
+}
+
+func TestOIDCDirectoryUsers(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			body = map[string]string{"token_endpoint": server.URL + "/token"}
+		case "/users":
+			if r.Header.Get("Authorization") != "Bearer my-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body = []map[string]interface{}{
+				{"sub": "123", "preferred_username": "jstrachan", "name": "James Strachan", "groups": []string{"developers", "/everyone"}},
+				{"sub": "456", "email": "rawlingsj@example.com", "groups": "everyone"},
+				{"name": "no login"},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	directory := &users.OIDCDirectory{IssuerURL: server.URL, Token: "my-token"}
+	_, err := directory.Users()
+	assert.Error(t, err, "the users URL is required")
+
+	directory.UsersURL = server.URL + "/users"
+	directory.Token = "wrong"
+	_, err = directory.Users()
+	assert.Error(t, err)
+
+	directory.Token = "my-token"
+	assert.True(t, directory.ListsAllUsers())
+	result, err := directory.Users()
+	require.NoError(t, err)
+	assert.Equal(t, []users.DirectoryUser{
+		{Login: "jstrachan", Name: "James Strachan", Groups: []string{"developers", "everyone"}},
+		{Login: "rawlingsj@example.com", Email: "rawlingsj@example.com", Groups: []string{"everyone"}},
+	}, result)
+}
@@ -0,0 +1,188 @@
+package users
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LDAPProviderKey the provider key of users synchronised from an LDAP directory
+	LDAPProviderKey = "jenkins.io/ldap-userid"
+
+	defaultLDAPUserFilter  = "(objectClass=inetOrgPerson)"
+	defaultLDAPGroupFilter = "(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames))"
+	defaultLDAPLogin       = "uid"
+
+	ldapPageSize = 500
+)
+
+// LDAPDirectory reads users and their groups from an LDAP directory.
+// Groups are read from the memberOf attribute of users and, if GroupBaseDN is set, from the members of the groups
+type LDAPDirectory struct {
+	URL            string
+	BindDN         string
+	Password       string
+	UserBaseDN     string
+	UserFilter     string
+	LoginAttribute string
+	GroupBaseDN    string
+	GroupFilter    string
+}
+
+// verify we implement the interface
+var _ Directory = &LDAPDirectory{}
+
+// NewLDAPDirectory creates a directory for the LDAP server
+func NewLDAPDirectory(url string, bindDN string, password string, userBaseDN string) *LDAPDirectory {
+	return &LDAPDirectory{
+		URL:        url,
+		BindDN:     bindDN,
+		Password:   password,
+		UserBaseDN: userBaseDN,
+	}
+}
+
+// ProviderKey the key of the account references of the users of the directory
+func (d *LDAPDirectory) ProviderKey() string {
+	return LDAPProviderKey
+}
+
+// ListsAllUsers returns true as all the users matching the user filter are returned
+func (d *LDAPDirectory) ListsAllUsers() bool {
+	return true
+}
+
+// Users returns the users of the directory
+func (d *LDAPDirectory) Users() ([]DirectoryUser, error) {
+	loginAttribute := d.LoginAttribute
+	if loginAttribute == "" {
+		loginAttribute = defaultLDAPLogin
+	}
+	userFilter := d.UserFilter
+	if userFilter == "" {
+		userFilter = defaultLDAPUserFilter
+	}
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entries, err := d.search(conn, d.UserBaseDN, userFilter, loginAttribute, "cn", "mail", "memberOf")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to search for users in %s", d.UserBaseDN)
+	}
+	groupsByDN := map[string][]string{}
+	if d.GroupBaseDN != "" {
+		groupFilter := d.GroupFilter
+		if groupFilter == "" {
+			groupFilter = defaultLDAPGroupFilter
+		}
+		groups, err := d.search(conn, d.GroupBaseDN, groupFilter, "cn", "member", "uniqueMember")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to search for groups in %s", d.GroupBaseDN)
+		}
+		for _, group := range groups {
+			name := firstValue(group, "cn")
+			if name == "" {
+				name = rdnValue(group.DN)
+			}
+			for _, member := range append(attributeValues(group, "member"), attributeValues(group, "uniqueMember")...) {
+				key := normalizeDN(member)
+				groupsByDN[key] = append(groupsByDN[key], name)
+			}
+		}
+	}
+
+	var answer []DirectoryUser
+	for _, entry := range entries {
+		login := firstValue(entry, loginAttribute)
+		if login == "" {
+			continue
+		}
+		groups := map[string]bool{}
+		for _, dn := range attributeValues(entry, "memberOf") {
+			groups[rdnValue(dn)] = true
+		}
+		for _, group := range groupsByDN[normalizeDN(entry.DN)] {
+			groups[group] = true
+		}
+		user := DirectoryUser{
+			Login: login,
+			Name:  firstValue(entry, "cn"),
+			Email: firstValue(entry, "mail"),
+		}
+		for group := range groups {
+			user.Groups = append(user.Groups, group)
+		}
+		sort.Strings(user.Groups)
+		answer = append(answer, user)
+	}
+	return answer, nil
+}
+
+// connect connects to the LDAP server and binds as the BindDN if there is one
+func (d *LDAPDirectory) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(d.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to the LDAP server %s", d.URL)
+	}
+	if d.BindDN != "" {
+		err = conn.Bind(d.BindDN, d.Password)
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrapf(err, "failed to bind to the LDAP server %s as %s", d.URL, d.BindDN)
+		}
+	}
+	return conn, nil
+}
+
+// search searches the subtree of the base DN a page at a time so that the size limit of the server is not exceeded
+func (d *LDAPDirectory) search(conn *ldap.Conn, baseDN string, filter string, attributes ...string) ([]*ldap.Entry, error) {
+	request := ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, filter, attributes, nil)
+	result, err := conn.SearchWithPaging(request, ldapPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// attributeValues returns the values of the attribute of the entry ignoring the case of the attribute name
+func attributeValues(entry *ldap.Entry, attribute string) []string {
+	var answer []string
+	for _, a := range entry.Attributes {
+		if strings.EqualFold(a.Name, attribute) {
+			answer = append(answer, a.Values...)
+		}
+	}
+	return answer
+}
+
+func firstValue(entry *ldap.Entry, attribute string) string {
+	values := attributeValues(entry, attribute)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// rdnValue returns the value of the first relative distinguished name of the DN such as devs for cn=devs,ou=groups
+func rdnValue(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	idx := strings.Index(rdn, "=")
+	if idx < 0 {
+		return strings.TrimSpace(rdn)
+	}
+	return strings.TrimSpace(rdn[idx+1:])
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ",")
+}
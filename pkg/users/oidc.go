@@ -0,0 +1,181 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// OIDCProviderKey the provider key of users synchronised from an OIDC provider
+	OIDCProviderKey = "jenkins.io/oidc-userid"
+
+	defaultOIDCLoginClaim  = "preferred_username"
+	defaultOIDCGroupsClaim = "groups"
+)
+
+// OIDCDirectory reads users and their groups from an OIDC provider.
+//
+// OIDC has no standard endpoint which lists users so UsersURL must return a JSON array of the claims of all the users
+// of the provider. The access token is either Token or is requested via the client credentials flow using ClientID
+// and ClientSecret
+type OIDCDirectory struct {
+	IssuerURL    string
+	UsersURL     string
+	Token        string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	LoginClaim   string
+	GroupsClaim  string
+
+	// HTTPClient the client used when there is no Token or client credentials
+	HTTPClient *http.Client
+}
+
+// verify we implement the interface
+var _ Directory = &OIDCDirectory{}
+
+// oidcConfiguration the parts of the OpenID provider metadata we use
+type oidcConfiguration struct {
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// ProviderKey the key of the account references of the users of the provider
+func (d *OIDCDirectory) ProviderKey() string {
+	return OIDCProviderKey
+}
+
+// ListsAllUsers returns true as all the users are read from the UsersURL
+func (d *OIDCDirectory) ListsAllUsers() bool {
+	return true
+}
+
+// Users returns the users of the provider
+func (d *OIDCDirectory) Users() ([]DirectoryUser, error) {
+	if d.UsersURL == "" {
+		return nil, errors.New("no users URL of the OIDC provider is configured")
+	}
+	config, err := d.configuration()
+	if err != nil {
+		return nil, err
+	}
+	client := d.client(config)
+
+	var list []map[string]interface{}
+	err = getJSON(client, d.UsersURL, &list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the users from %s", d.UsersURL)
+	}
+	var answer []DirectoryUser
+	for _, claims := range list {
+		user, ok := d.claimsToUser(claims)
+		if ok {
+			answer = append(answer, user)
+		}
+	}
+	return answer, nil
+}
+
+func (d *OIDCDirectory) configuration() (*oidcConfiguration, error) {
+	u := strings.TrimSuffix(d.IssuerURL, "/") + "/.well-known/openid-configuration"
+	config := &oidcConfiguration{}
+	err := getJSON(d.httpClient(), u, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to discover the OIDC provider %s", d.IssuerURL)
+	}
+	return config, nil
+}
+
+func (d *OIDCDirectory) client(config *oidcConfiguration) *http.Client {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, d.httpClient())
+	if d.Token != "" {
+		return oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: d.Token}))
+	}
+	if d.ClientID != "" {
+		cc := &clientcredentials.Config{
+			ClientID:     d.ClientID,
+			ClientSecret: d.ClientSecret,
+			TokenURL:     config.TokenEndpoint,
+			Scopes:       d.Scopes,
+		}
+		return cc.Client(ctx)
+	}
+	return d.httpClient()
+}
+
+func (d *OIDCDirectory) httpClient() *http.Client {
+	if d.HTTPClient != nil {
+		return d.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (d *OIDCDirectory) loginClaim() string {
+	if d.LoginClaim != "" {
+		return d.LoginClaim
+	}
+	return defaultOIDCLoginClaim
+}
+
+func (d *OIDCDirectory) claimsToUser(claims map[string]interface{}) (DirectoryUser, bool) {
+	user := DirectoryUser{
+		Name:  claimString(claims, "name"),
+		Email: claimString(claims, "email"),
+	}
+	for _, claim := range []string{d.loginClaim(), "email", "sub"} {
+		user.Login = claimString(claims, claim)
+		if user.Login != "" {
+			break
+		}
+	}
+	groupsClaim := d.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultOIDCGroupsClaim
+	}
+	switch groups := claims[groupsClaim].(type) {
+	case string:
+		user.Groups = []string{groups}
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				user.Groups = append(user.Groups, strings.TrimPrefix(s, "/"))
+			}
+		}
+	}
+	sort.Strings(user.Groups)
+	return user, user.Login != ""
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+func getJSON(client *http.Client, u string, result interface{}) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the response of %s", u)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GET %s returned status %d: %s", u, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the response of %s", u)
+	}
+	return nil
+}
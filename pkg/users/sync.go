@@ -0,0 +1,232 @@
+package users
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-api/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/kube/naming"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LabelUserDisabled the label of a User which has been removed from the directory it was synchronised from
+const LabelUserDisabled = "jenkins.io/user-disabled"
+
+// UserChangeType the type of a change made to a User by a UserSync
+type UserChangeType string
+
+const (
+	// UserChangeCreate a User is created for a new user of the directory
+	UserChangeCreate UserChangeType = "create"
+	// UserChangeUpdate the details or roles of a User are updated
+	UserChangeUpdate UserChangeType = "update"
+	// UserChangeDisable a User removed from the directory is disabled by removing its roles
+	UserChangeDisable UserChangeType = "disable"
+)
+
+// UserChange a change made to a User by a UserSync
+type UserChange struct {
+	Type    UserChangeType
+	Login   string
+	Changes []string
+}
+
+func (c UserChange) String() string {
+	if len(c.Changes) == 0 {
+		return fmt.Sprintf("%s %s", c.Type, c.Login)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Type, c.Login, strings.Join(c.Changes, ", "))
+}
+
+// UserSync synchronises the Users and their roles from a directory
+type UserSync struct {
+	JXClient   versioned.Interface
+	KubeClient kubernetes.Interface
+	// Namespace the namespace of the Users which is the admin namespace
+	Namespace string
+	// TeamNamespace the namespace of the roles of the team
+	TeamNamespace string
+	// ProviderKey the key of the account references and labels of the synchronised Users
+	ProviderKey string
+	// GroupRoles the roles of the team to give the members of each group of the directory. If empty the roles of the
+	// Users are not changed
+	GroupRoles map[string][]string
+	// DryRun only returns the changes without making them
+	DryRun bool
+}
+
+// Sync creates or updates a User for each directory user giving them the roles of their groups. If disableMissing is
+// true the Users previously synchronised which are no longer in the directory are disabled.
+// Returns the changes which are made, or would be made in dry run mode
+func (s *UserSync) Sync(directoryUsers []DirectoryUser, disableMissing bool) ([]UserChange, error) {
+	existing, _, err := GetUsers(s.JXClient, s.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the users in namespace %s", s.Namespace)
+	}
+	roles, roleNames, err := kube.GetTeamRoles(s.KubeClient, s.TeamNamespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the roles of team %s", s.TeamNamespace)
+	}
+	for group, groupRoles := range s.GroupRoles {
+		for _, role := range groupRoles {
+			if util.StringArrayIndex(roleNames, role) < 0 {
+				return nil, fmt.Errorf("group %s is mapped to role %s which is not a role of team %s: %s", group, role, s.TeamNamespace, strings.Join(roleNames, ", "))
+			}
+		}
+	}
+
+	sort.Slice(directoryUsers, func(i, j int) bool {
+		return directoryUsers[i].Login < directoryUsers[j].Login
+	})
+	var answer []UserChange
+	found := map[string]bool{}
+	for _, du := range directoryUsers {
+		found[du.Login] = true
+		user := existing[du.Login]
+		change := UserChange{Type: UserChangeUpdate, Login: du.Login}
+		if user == nil {
+			change.Type = UserChangeCreate
+			user = CreateUser(s.Namespace, du.Login, du.Name, du.Email)
+		} else {
+			change.Changes = s.updateDetails(user, du)
+		}
+		if user.Labels[s.ProviderKey] == "" {
+			user = AddAccountReference(user, s.ProviderKey, naming.ToValidValue(du.Login))
+			if change.Type == UserChangeUpdate {
+				change.Changes = append(change.Changes, "account "+s.ProviderKey)
+			}
+		}
+		if user.Labels[LabelUserDisabled] != "" {
+			delete(user.Labels, LabelUserDisabled)
+			change.Changes = append(change.Changes, "enabled")
+		}
+		if change.Type == UserChangeCreate || len(change.Changes) > 0 {
+			err = s.saveUser(user, change.Type == UserChangeCreate)
+			if err != nil {
+				return answer, err
+			}
+		}
+
+		if len(s.GroupRoles) > 0 {
+			roleChanges, err := s.updateRoles(user, s.groupRoles(du.Groups), roles, change.Type == UserChangeCreate)
+			if err != nil {
+				return answer, err
+			}
+			change.Changes = append(change.Changes, roleChanges...)
+		}
+		if change.Type == UserChangeCreate || len(change.Changes) > 0 {
+			answer = append(answer, change)
+		}
+	}
+
+	if !disableMissing {
+		return answer, nil
+	}
+	var logins []string
+	for login := range existing {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	for _, login := range logins {
+		user := existing[login]
+		if found[login] || user.Labels[s.ProviderKey] == "" || user.Labels[LabelUserDisabled] != "" {
+			continue
+		}
+		change := UserChange{Type: UserChangeDisable, Login: login}
+		roleChanges, err := s.updateRoles(user, nil, roles, false)
+		if err != nil {
+			return answer, err
+		}
+		change.Changes = roleChanges
+		user.Labels[LabelUserDisabled] = "true"
+		err = s.saveUser(user, false)
+		if err != nil {
+			return answer, err
+		}
+		answer = append(answer, change)
+	}
+	return answer, nil
+}
+
+func (s *UserSync) updateDetails(user *jenkinsv1.User, du DirectoryUser) []string {
+	var answer []string
+	if du.Name != "" && user.Spec.Name != du.Name {
+		user.Spec.Name = du.Name
+		answer = append(answer, "name "+du.Name)
+	}
+	if du.Email != "" && user.Spec.Email != du.Email {
+		user.Spec.Email = du.Email
+		answer = append(answer, "email "+du.Email)
+	}
+	return answer
+}
+
+func (s *UserSync) saveUser(user *jenkinsv1.User, create bool) error {
+	if s.DryRun {
+		return nil
+	}
+	var err error
+	if create {
+		_, err = s.JXClient.JenkinsV1().Users(s.Namespace).Create(user)
+	} else {
+		_, err = s.JXClient.JenkinsV1().Users(s.Namespace).Update(user)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to save user %s", user.Spec.Login)
+	}
+	return nil
+}
+
+// updateRoles updates the roles of the user returning the roles which are added and removed
+func (s *UserSync) updateRoles(user *jenkinsv1.User, userRoles []string, roles map[string]*rbacv1.Role, created bool) ([]string, error) {
+	var currentRoles []string
+	if !created {
+		var err error
+		currentRoles, err = kube.GetUserRoles(s.KubeClient, s.JXClient, s.TeamNamespace, user.SubjectKind(), user.Spec.Login)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the roles of user %s", user.Spec.Login)
+		}
+	}
+	removed, added := util.DiffSlices(currentRoles, userRoles)
+	var answer []string
+	sort.Strings(added)
+	sort.Strings(removed)
+	for _, r := range added {
+		answer = append(answer, "+role "+r)
+	}
+	for _, r := range removed {
+		answer = append(answer, "-role "+r)
+	}
+	if len(answer) == 0 || s.DryRun {
+		return answer, nil
+	}
+	err := kube.UpdateUserRoles(s.KubeClient, s.JXClient, s.TeamNamespace, user.SubjectKind(), user.Spec.Login, userRoles, roles)
+	if err != nil {
+		return answer, errors.Wrapf(err, "failed to update the roles of user %s", user.Spec.Login)
+	}
+	log.Logger().Debugf("updated the roles of user %s: %s", user.Spec.Login, strings.Join(answer, ", "))
+	return answer, nil
+}
+
+// groupRoles returns the sorted roles of the groups
+func (s *UserSync) groupRoles(groups []string) []string {
+	set := map[string]bool{}
+	for _, group := range groups {
+		for _, role := range s.GroupRoles[group] {
+			set[role] = true
+		}
+	}
+	answer := []string{}
+	for role := range set {
+		answer = append(answer, role)
+	}
+	sort.Strings(answer)
+	return answer
+}
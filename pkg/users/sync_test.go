@@ -0,0 +1,129 @@
+// +build unit
+
+package users_test
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/kube"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newUserSync(t *testing.T, existing ...*jenkinsv1.User) *users.UserSync {
+	ns := "jx"
+	role := func(name string) *rbacv1.Role {
+		return &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{kube.LabelKind: kube.ValueKindEnvironmentRole}},
+		}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}},
+		role("committer"),
+		role("viewer"),
+		role("owner"),
+	)
+	jxClient := jxfake.NewSimpleClientset()
+	for _, u := range existing {
+		_, err := jxClient.JenkinsV1().Users(ns).Create(u)
+		require.NoError(t, err)
+	}
+	return &users.UserSync{
+		JXClient:      jxClient,
+		KubeClient:    kubeClient,
+		Namespace:     ns,
+		TeamNamespace: ns,
+		ProviderKey:   users.LDAPProviderKey,
+		GroupRoles: map[string][]string{
+			"developers": {"committer"},
+			"everyone":   {"viewer"},
+		},
+	}
+}
+
+func userRoles(t *testing.T, s *users.UserSync, login string) []string {
+	roles, err := kube.GetUserRoles(s.KubeClient, s.JXClient, s.TeamNamespace, jenkinsv1.UserTypeLocal, login)
+	require.NoError(t, err)
+	return roles
+}
+
+func TestUserSyncCreatesUpdatesAndDisablesUsers(t *testing.T) {
+	manual := users.CreateUser("jx", "manual", "Manual User", "manual@example.com")
+	s := newUserSync(t, manual)
+
+	directory := []users.DirectoryUser{
+		{Login: "jstrachan", Name: "James Strachan", Email: "james@example.com", Groups: []string{"developers", "everyone"}},
+		{Login: "rawlingsj", Name: "James Rawlings", Email: "rawlingsj@example.com", Groups: []string{"everyone"}},
+	}
+	changes, err := s.Sync(directory, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "create jstrachan: +role committer, +role viewer", changes[0].String())
+	assert.Equal(t, "create rawlingsj: +role viewer", changes[1].String())
+
+	user, err := s.JXClient.JenkinsV1().Users("jx").Get("jstrachan", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "james@example.com", user.Spec.Email)
+	assert.Equal(t, "jstrachan", user.Labels[users.LDAPProviderKey])
+	assert.Equal(t, []jenkinsv1.AccountReference{{Provider: users.LDAPProviderKey, ID: "jstrachan"}}, user.Spec.Accounts)
+	assert.ElementsMatch(t, []string{"committer", "viewer"}, userRoles(t, s, "jstrachan"))
+
+	changes, err = s.Sync(directory, true)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "nothing should change when the directory has not changed")
+
+	// jstrachan leaves the developers group, rawlingsj changes email and is then removed
+	directory = []users.DirectoryUser{
+		{Login: "jstrachan", Name: "James Strachan", Email: "james@example.com", Groups: []string{"everyone"}},
+	}
+	changes, err = s.Sync(directory, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "update jstrachan: -role committer", changes[0].String())
+	assert.Equal(t, "disable rawlingsj: -role viewer", changes[1].String())
+	assert.Equal(t, []string{"viewer"}, userRoles(t, s, "jstrachan"))
+	assert.Empty(t, userRoles(t, s, "rawlingsj"))
+
+	disabled, err := s.JXClient.JenkinsV1().Users("jx").Get("rawlingsj", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", disabled.Labels[users.LabelUserDisabled])
+	_, err = s.JXClient.JenkinsV1().Users("jx").Get("manual", metav1.GetOptions{})
+	assert.NoError(t, err, "users which were not synchronised should not be disabled")
+}
+
+func TestUserSyncDryRun(t *testing.T) {
+	existing := users.CreateUser("jx", "jstrachan", "James", "old@example.com")
+	s := newUserSync(t, existing)
+	s.DryRun = true
+
+	changes, err := s.Sync([]users.DirectoryUser{
+		{Login: "jstrachan", Name: "James", Email: "james@example.com", Groups: []string{"developers"}},
+		{Login: "new", Groups: []string{"everyone"}},
+	}, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "update jstrachan: email james@example.com, account jenkins.io/ldap-userid, +role committer", changes[0].String())
+	assert.Equal(t, "create new: +role viewer", changes[1].String())
+
+	user, err := s.JXClient.JenkinsV1().Users("jx").Get("jstrachan", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", user.Spec.Email, "a dry run should not change the user")
+	_, err = s.JXClient.JenkinsV1().Users("jx").Get("new", metav1.GetOptions{})
+	assert.Error(t, err, "a dry run should not create users")
+	assert.Empty(t, userRoles(t, s, "jstrachan"))
+}
+
+func TestUserSyncUnknownRole(t *testing.T) {
+	s := newUserSync(t)
+	s.GroupRoles["admins"] = []string{"cluster-admin"}
+
+	_, err := s.Sync(nil, false)
+	assert.Error(t, err)
+}
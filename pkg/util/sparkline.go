@@ -0,0 +1,48 @@
+package util
+
+import (
+	"math"
+	"strings"
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the values as a line of block characters scaled between the minimum and maximum value.
+// At most width values are rendered, averaging adjacent values if there are more
+func Sparkline(values []float64, width int) string {
+	values = downsample(values, width)
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		idx := 0
+		if max > min {
+			idx = int(math.Round((v - min) / (max - min) * float64(len(sparkTicks)-1)))
+		}
+		sb.WriteRune(sparkTicks[idx])
+	}
+	return sb.String()
+}
+
+func downsample(values []float64, width int) []float64 {
+	if width <= 0 || len(values) <= width {
+		return values
+	}
+	answer := make([]float64, width)
+	for i := range answer {
+		start := i * len(values) / width
+		end := (i + 1) * len(values) / width
+		total := 0.0
+		for _, v := range values[start:end] {
+			total += v
+		}
+		answer[i] = total / float64(end-start)
+	}
+	return answer
+}
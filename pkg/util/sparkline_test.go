@@ -0,0 +1,17 @@
+// +build unit
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "", util.Sparkline(nil, 10))
+	assert.Equal(t, "▁▁▁", util.Sparkline([]float64{2, 2, 2}, 10))
+	assert.Equal(t, "▁▅█", util.Sparkline([]float64{0, 0.6, 1}, 10))
+	assert.Equal(t, "▁█", util.Sparkline([]float64{0, 0, 1, 1}, 2))
+}
@@ -1,8 +1,13 @@
 package util
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	schemagen "github.com/alecthomas/jsonschema"
 	"github.com/xeipuuv/gojsonschema"
+	yamlv3 "gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -44,3 +49,117 @@ func ValidateYaml(target interface{}, data []byte) ([]string, error) {
 
 	return nil, nil
 }
+
+// UnknownYamlKey is a key in a YAML document which does not match a field of the target struct type
+type UnknownYamlKey struct {
+	// Path is the path of the key, e.g. cluster.zonee
+	Path   string
+	Line   int
+	Column int
+}
+
+func (k UnknownYamlKey) String() string {
+	return fmt.Sprintf("line %d column %d: unknown key %s", k.Line, k.Column, k.Path)
+}
+
+// FindUnknownYamlKeys returns the keys in the YAML which do not match the JSON names of the fields of the given struct
+// type along with their line numbers. Keys of maps and values of interface fields are not checked
+func FindUnknownYamlKeys(target interface{}, data []byte) ([]UnknownYamlKey, error) {
+	node := &yamlv3.Node{}
+	err := yamlv3.Unmarshal(data, node)
+	if err != nil {
+		return nil, err
+	}
+	var answer []UnknownYamlKey
+	findUnknownYamlKeys(node, reflect.TypeOf(target), "", &answer)
+	return answer, nil
+}
+
+func findUnknownYamlKeys(node *yamlv3.Node, t reflect.Type, path string, answer *[]UnknownYamlKey) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			findUnknownYamlKeys(child, t, path, answer)
+		}
+		return
+	case yamlv3.AliasNode:
+		if node.Alias != nil {
+			findUnknownYamlKeys(node.Alias, t, path, answer)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		addJSONFields(t, fields)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				*answer = append(*answer, UnknownYamlKey{Path: keyPath, Line: key.Line, Column: key.Column})
+				continue
+			}
+			findUnknownYamlKeys(node.Content[i+1], fieldType, keyPath, answer)
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := node.Content[i].Value
+			if path != "" {
+				keyPath = path + "." + keyPath
+			}
+			findUnknownYamlKeys(node.Content[i+1], t.Elem(), keyPath, answer)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yamlv3.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			findUnknownYamlKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), answer)
+		}
+	}
+}
+
+// addJSONFields adds the JSON names of the fields of the struct type including the fields of embedded structs
+func addJSONFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		values := strings.Split(f.Tag.Get("json"), ",")
+		name := values[0]
+		if name == "-" {
+			continue
+		}
+		inline := StringArrayIndex(values[1:], "inline") >= 0
+		if f.Anonymous && (name == "" || inline) {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addJSONFields(embedded, fields)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+}
@@ -0,0 +1,53 @@
+// +build unit
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validationTestConfig struct {
+	Name                 string                          `json:"name"`
+	Children             []validationTestChild           `json:"children,omitempty"`
+	Labels               map[string]string               `json:"labels,omitempty"`
+	Nested               map[string]*validationTestChild `json:"nested,omitempty"`
+	validationTestInline `json:",inline"`
+}
+
+type validationTestChild struct {
+	Key string `json:"key"`
+}
+
+type validationTestInline struct {
+	Enabled bool `json:"enabled"`
+}
+
+func TestFindUnknownYamlKeys(t *testing.T) {
+	t.Parallel()
+
+	data := `name: cheese
+enabled: true
+labels:
+  anything: goes
+children:
+- key: a
+- kye: b
+nested:
+  foo:
+    key: c
+    value: d
+nmae: typo
+`
+	keys, err := util.FindUnknownYamlKeys(&validationTestConfig{}, []byte(data))
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.Equal(t, "line 7 column 3: unknown key children[1].kye", keys[0].String())
+	assert.Equal(t, "nested.foo.value", keys[1].Path)
+	assert.Equal(t, 11, keys[1].Line)
+	assert.Equal(t, "nmae", keys[2].Path)
+	assert.Equal(t, 12, keys[2].Line)
+}
@@ -0,0 +1,185 @@
+package versionfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// textRange the location of a version in the contents of a file
+type textRange struct {
+	start int
+	end   int
+	value string
+}
+
+// replace returns the data with the range replaced by the value
+func (r *textRange) replace(data []byte, value string) []byte {
+	answer := make([]byte, 0, len(data)-(r.end-r.start)+len(value))
+	answer = append(answer, data[:r.start]...)
+	answer = append(answer, value...)
+	return append(answer, data[r.end:]...)
+}
+
+// regexHandler finds the version using a regular expression. The version is the first sub match which matched
+type regexHandler struct {
+	name     string
+	patterns []string
+	regex    *regexp.Regexp
+}
+
+// Name returns the name of the handler
+func (h *regexHandler) Name() string {
+	return h.name
+}
+
+// Patterns returns the file name patterns
+func (h *regexHandler) Patterns() []string {
+	return h.patterns
+}
+
+// GetVersion returns the version in the file contents
+func (h *regexHandler) GetVersion(data []byte) (string, error) {
+	r := h.find(data)
+	if r == nil {
+		return "", nil
+	}
+	return r.value, nil
+}
+
+// SetVersion returns the file contents with the version replaced
+func (h *regexHandler) SetVersion(data []byte, version string) ([]byte, error) {
+	r := h.find(data)
+	if r == nil {
+		return nil, fmt.Errorf("no version found")
+	}
+	return r.replace(data, version), nil
+}
+
+func (h *regexHandler) find(data []byte) *textRange {
+	idx := h.regex.FindSubmatchIndex(data)
+	for i := 2; i+1 < len(idx); i += 2 {
+		if idx[i] >= 0 {
+			return &textRange{
+				start: idx[i],
+				end:   idx[i+1],
+				value: string(data[idx[i]:idx[i+1]]),
+			}
+		}
+	}
+	return nil
+}
+
+// NewMakefileHandler creates a handler for the VERSION variable of a Makefile
+func NewMakefileHandler() Handler {
+	return &regexHandler{
+		name:     "make",
+		patterns: []string{"Makefile"},
+		regex:    regexp.MustCompile(`(?m)^VERSION[ \t]*(?:::=|:=|\?=|=|:)[ \t]*([^\s#]+)`),
+	}
+}
+
+// NewGradlePropertiesHandler creates a handler for the version property of a gradle.properties file
+func NewGradlePropertiesHandler() Handler {
+	return &regexHandler{
+		name:     "gradle-properties",
+		patterns: []string{"gradle.properties"},
+		regex:    regexp.MustCompile(`(?m)^[ \t]*version[ \t]*[=:][ \t]*([^\s#!]+)`),
+	}
+}
+
+// NewGradleHandler creates a handler for the project version of a Groovy or Kotlin Gradle build script
+func NewGradleHandler() Handler {
+	return &regexHandler{
+		name:     "gradle",
+		patterns: []string{"build.gradle", "build.gradle.kts"},
+		regex:    regexp.MustCompile(`(?m)^[ \t]*(?:project\.)?version[ \t]*=?[ \t]*(?:"([^"\n]*)"|'([^'\n]*)')`),
+	}
+}
+
+// NewGoHandler creates a handler for a Version constant or variable in a version.go file
+func NewGoHandler() Handler {
+	return &regexHandler{
+		name:     "go",
+		patterns: []string{"version.go"},
+		regex:    regexp.MustCompile(`(?m)^[ \t]*(?:(?:const|var)[ \t]+)?Version[ \t]*(?:string[ \t]*)?=[ \t]*"([^"\n]*)"`),
+	}
+}
+
+// chartHandler handles the version of a helm Chart.yaml
+type chartHandler struct {
+	regexHandler
+}
+
+// NewChartHandler creates a handler for the version of a helm Chart.yaml
+func NewChartHandler() Handler {
+	return &chartHandler{
+		regexHandler: regexHandler{
+			name:     "helm",
+			patterns: []string{"Chart.yaml"},
+			regex:    regexp.MustCompile(`(?m)^version:[ \t]*(?:"([^"\n]*)"|'([^'\n]*)'|([^\s#'"]+))`),
+		},
+	}
+}
+
+// GetVersion returns the version in the chart
+func (h *chartHandler) GetVersion(data []byte) (string, error) {
+	chart := struct {
+		Version string `json:"version"`
+	}{}
+	err := yaml.Unmarshal(data, &chart)
+	if err != nil {
+		return "", err
+	}
+	return chart.Version, nil
+}
+
+// npmHandler handles the version of a package.json
+type npmHandler struct {
+	regexHandler
+}
+
+// NewNpmHandler creates a handler for the version of a package.json
+func NewNpmHandler() Handler {
+	return &npmHandler{
+		regexHandler: regexHandler{
+			name:     "npm",
+			patterns: []string{"package.json"},
+			regex:    regexp.MustCompile(`"version"[ \t]*:[ \t]*"([^"\n]*)"`),
+		},
+	}
+}
+
+// GetVersion returns the version of the package
+func (h *npmHandler) GetVersion(data []byte) (string, error) {
+	pkg := struct {
+		Version string `json:"version"`
+	}{}
+	err := json.Unmarshal(data, &pkg)
+	if err != nil {
+		return "", err
+	}
+	return pkg.Version, nil
+}
+
+// SetVersion returns the package.json with the top level version replaced
+func (h *npmHandler) SetVersion(data []byte, version string) ([]byte, error) {
+	current, err := h.GetVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if current == "" {
+		return nil, fmt.Errorf("no version found")
+	}
+	// dependencies can also have a version property so lets replace the first one with the value of the package
+	for _, idx := range h.regex.FindAllSubmatchIndex(data, -1) {
+		r := &textRange{start: idx[2], end: idx[3], value: string(data[idx[2]:idx[3]])}
+		if strings.TrimSpace(r.value) == current {
+			return r.replace(data, version), nil
+		}
+	}
+	return nil, fmt.Errorf("no version found")
+}
@@ -0,0 +1,155 @@
+package versionfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sectionHandler handles the version key in a section of a TOML or INI file
+type sectionHandler struct {
+	name     string
+	patterns []string
+	// sections the sections which can contain the version in order of precedence
+	sections []string
+	// separators the characters which can separate a key from its value
+	separators string
+}
+
+// NewCargoHandler creates a handler for the package version of a rust Cargo.toml
+func NewCargoHandler() Handler {
+	return &sectionHandler{
+		name:       "cargo",
+		patterns:   []string{"Cargo.toml"},
+		sections:   []string{"package", "workspace.package"},
+		separators: "=",
+	}
+}
+
+// NewPyProjectHandler creates a handler for the project version of a python pyproject.toml
+// using either the standard project table or poetry
+func NewPyProjectHandler() Handler {
+	return &sectionHandler{
+		name:       "pyproject",
+		patterns:   []string{"pyproject.toml"},
+		sections:   []string{"project", "tool.poetry"},
+		separators: "=",
+	}
+}
+
+// NewSetupCfgHandler creates a handler for the metadata version of a python setup.cfg
+func NewSetupCfgHandler() Handler {
+	return &sectionHandler{
+		name:       "setuptools",
+		patterns:   []string{"setup.cfg"},
+		sections:   []string{"metadata"},
+		separators: "=:",
+	}
+}
+
+// Name returns the name of the handler
+func (h *sectionHandler) Name() string {
+	return h.name
+}
+
+// Patterns returns the file name patterns
+func (h *sectionHandler) Patterns() []string {
+	return h.patterns
+}
+
+// GetVersion returns the version in the file contents
+func (h *sectionHandler) GetVersion(data []byte) (string, error) {
+	r := h.find(data)
+	if r == nil {
+		return "", nil
+	}
+	return r.value, nil
+}
+
+// SetVersion returns the file contents with the version replaced
+func (h *sectionHandler) SetVersion(data []byte, version string) ([]byte, error) {
+	r := h.find(data)
+	if r == nil {
+		return nil, fmt.Errorf("no version found in the %s sections", strings.Join(h.sections, ", "))
+	}
+	return r.replace(data, version), nil
+}
+
+// find finds the version key in the first of the sections which has one
+func (h *sectionHandler) find(data []byte) *textRange {
+	found := map[string]*textRange{}
+	section := ""
+	offset := 0
+	text := string(data)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineStart := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.Index(trimmed, "]")
+			if end > 0 {
+				section = strings.TrimSpace(strings.Trim(trimmed[:end], "["))
+			}
+			continue
+		}
+		if found[section] != nil || !h.isVersionSection(section) {
+			continue
+		}
+		r := h.parseVersion(line)
+		if r != nil {
+			r.start += lineStart
+			r.end += lineStart
+			found[section] = r
+		}
+	}
+	for _, s := range h.sections {
+		if r := found[s]; r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func (h *sectionHandler) isVersionSection(section string) bool {
+	for _, s := range h.sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersion returns the location of the value in the line if it is the version key
+func (h *sectionHandler) parseVersion(line string) *textRange {
+	const key = "version"
+	i := len(line) - len(strings.TrimLeft(line, " \t"))
+	if !strings.HasPrefix(line[i:], key) {
+		return nil
+	}
+	i += len(key)
+	i += len(line[i:]) - len(strings.TrimLeft(line[i:], " \t"))
+	if i >= len(line) || !strings.ContainsRune(h.separators, rune(line[i])) {
+		return nil
+	}
+	i++
+	i += len(line[i:]) - len(strings.TrimLeft(line[i:], " \t"))
+	rest := line[i:]
+	if rest == "" {
+		return nil
+	}
+
+	if quote := rest[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(rest[1:], quote)
+		if end < 0 {
+			return nil
+		}
+		return &textRange{start: i + 1, end: i + 1 + end, value: rest[1 : end+1]}
+	}
+
+	value := strings.TrimRight(rest, " \t\r\n")
+	if strings.HasPrefix(value, "attr:") || strings.HasPrefix(value, "file:") || strings.HasPrefix(value, "{") {
+		// the version is read from a module, a file or a workspace which we cannot update
+		return nil
+	}
+	return &textRange{start: i, end: i + len(value), value: value}
+}
@@ -0,0 +1,166 @@
+package versionfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Handler reads and writes the version of a project in a kind of version file such as a pom.xml or Cargo.toml
+type Handler interface {
+	// Name returns the name of the handler, e.g. "maven"
+	Name() string
+
+	// Patterns returns the file name patterns of the files the handler supports, e.g. "pom.xml" or "*.csproj"
+	Patterns() []string
+
+	// GetVersion returns the version in the file contents or an empty string if there is no version
+	GetVersion(data []byte) (string, error)
+
+	// SetVersion returns the file contents with the version replaced
+	SetVersion(data []byte, version string) ([]byte, error)
+}
+
+var (
+	handlersLock sync.RWMutex
+	handlers     []Handler
+)
+
+// Register registers a handler. Handlers registered earlier take precedence when detecting the version file of a project
+func Register(handler Handler) {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	handlers = append(handlers, handler)
+}
+
+// Handlers returns the registered handlers in order of precedence
+func Handlers() []Handler {
+	handlersLock.RLock()
+	defer handlersLock.RUnlock()
+	return append([]Handler{}, handlers...)
+}
+
+// FindHandler returns the handler for the file name or nil if there is no handler for it
+func FindHandler(filename string) Handler {
+	name := filepath.Base(filename)
+	for _, h := range Handlers() {
+		for _, pattern := range h.Patterns() {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// SupportedFiles returns the file name patterns of all the registered handlers
+func SupportedFiles() []string {
+	var answer []string
+	for _, h := range Handlers() {
+		answer = append(answer, h.Patterns()...)
+	}
+	return answer
+}
+
+// Detect finds the version file in the directory, returning its path relative to the directory
+// and the handler for it. The first file with a version in order of handler precedence is used.
+// If no version file is found an empty path is returned
+func Detect(dir string) (string, Handler, error) {
+	for _, h := range Handlers() {
+		for _, pattern := range h.Patterns() {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "invalid version file pattern %s", pattern)
+			}
+			sort.Strings(matches)
+			for _, path := range matches {
+				exists, err := util.FileExists(path)
+				if err != nil || !exists {
+					continue
+				}
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return "", nil, errors.Wrapf(err, "failed to read %s", path)
+				}
+				version, err := h.GetVersion(data)
+				if err != nil || version == "" {
+					continue
+				}
+				return filepath.Base(path), h, nil
+			}
+		}
+	}
+	return "", nil, nil
+}
+
+// GetVersion returns the version in the file of the directory
+func GetVersion(dir string, filename string) (string, error) {
+	handler := FindHandler(filename)
+	if handler == nil {
+		return "", unsupportedFile(filename)
+	}
+	path := filepath.Join(dir, filename)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", path)
+	}
+	version, err := handler.GetVersion(data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s", path)
+	}
+	if version == "" {
+		return "", fmt.Errorf("cannot find version for file %s", filename)
+	}
+	return version, nil
+}
+
+// SetVersion replaces the version in the file of the directory
+func SetVersion(dir string, filename string, version string) error {
+	handler := FindHandler(filename)
+	if handler == nil {
+		return unsupportedFile(filename)
+	}
+	path := filepath.Join(dir, filename)
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find %s", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	data, err = handler.SetVersion(data, version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the version in %s", path)
+	}
+	err = ioutil.WriteFile(path, data, info.Mode())
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", path)
+	}
+	return nil
+}
+
+func unsupportedFile(filename string) error {
+	return fmt.Errorf("unrecognised filename %s, supported files are %s", filename, strings.Join(SupportedFiles(), ", "))
+}
+
+func init() {
+	Register(NewMavenHandler())
+	Register(NewGradlePropertiesHandler())
+	Register(NewGradleHandler())
+	Register(NewNpmHandler())
+	Register(NewCargoHandler())
+	Register(NewPyProjectHandler())
+	Register(NewSetupCfgHandler())
+	Register(NewCSProjHandler())
+	Register(NewChartHandler())
+	Register(NewMakefileHandler())
+	Register(NewGoHandler())
+}
@@ -0,0 +1,206 @@
+// +build unit
+
+package versionfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		filename string
+		handler  string
+		source   string
+		version  string
+		expected string
+	}{
+		{
+			filename: "pom.xml",
+			handler:  "maven",
+			source: `<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <version>9</version>
+  </parent>
+  <artifactId>demo</artifactId>
+  <version>1.0-SNAPSHOT</version>
+  <dependencies>
+    <dependency><artifactId>lib</artifactId><version>2.0</version></dependency>
+  </dependencies>
+</project>
+`,
+			version: "1.0-SNAPSHOT",
+			expected: `<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <version>9</version>
+  </parent>
+  <artifactId>demo</artifactId>
+  <version>1.2.3</version>
+  <dependencies>
+    <dependency><artifactId>lib</artifactId><version>2.0</version></dependency>
+  </dependencies>
+</project>
+`,
+		},
+		{
+			filename: "gradle.properties",
+			handler:  "gradle-properties",
+			source:   "group=org.example\nversion = 0.1.0\nkotlin.code.style=official\n",
+			version:  "0.1.0",
+			expected: "group=org.example\nversion = 1.2.3\nkotlin.code.style=official\n",
+		},
+		{
+			filename: "build.gradle",
+			handler:  "gradle",
+			source:   "plugins {\n    id 'java'\n}\n\ngroup 'org.example'\nversion '0.1.0'\n",
+			version:  "0.1.0",
+			expected: "plugins {\n    id 'java'\n}\n\ngroup 'org.example'\nversion '1.2.3'\n",
+		},
+		{
+			filename: "build.gradle.kts",
+			handler:  "gradle",
+			source:   "group = \"org.example\"\nversion = \"0.1.0\"\n",
+			version:  "0.1.0",
+			expected: "group = \"org.example\"\nversion = \"1.2.3\"\n",
+		},
+		{
+			filename: "package.json",
+			handler:  "npm",
+			source:   "{\n  \"name\": \"demo\",\n  \"engines\": {\"version\": \"12\"},\n  \"version\": \"0.0.1\"\n}\n",
+			version:  "0.0.1",
+			expected: "{\n  \"name\": \"demo\",\n  \"engines\": {\"version\": \"12\"},\n  \"version\": \"1.2.3\"\n}\n",
+		},
+		{
+			filename: "Cargo.toml",
+			handler:  "cargo",
+			source:   "[package]\nname = \"demo\"\nversion = \"0.1.0\" # the crate version\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+			version:  "0.1.0",
+			expected: "[package]\nname = \"demo\"\nversion = \"1.2.3\" # the crate version\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+		},
+		{
+			filename: "pyproject.toml",
+			handler:  "pyproject",
+			source:   "[build-system]\nrequires = [\"poetry-core\"]\n\n[tool.poetry]\nname = \"demo\"\nversion = '0.1.0'\n",
+			version:  "0.1.0",
+			expected: "[build-system]\nrequires = [\"poetry-core\"]\n\n[tool.poetry]\nname = \"demo\"\nversion = '1.2.3'\n",
+		},
+		{
+			filename: "setup.cfg",
+			handler:  "setuptools",
+			source:   "[metadata]\nname = demo\nversion = 0.1.0\n\n[options]\npackages = find:\n",
+			version:  "0.1.0",
+			expected: "[metadata]\nname = demo\nversion = 1.2.3\n\n[options]\npackages = find:\n",
+		},
+		{
+			filename: "Demo.csproj",
+			handler:  "dotnet",
+			source:   "<Project Sdk=\"Microsoft.NET.Sdk\">\n  <PropertyGroup>\n    <VersionPrefix>0.9.0</VersionPrefix>\n    <Version>0.1.0</Version>\n  </PropertyGroup>\n</Project>\n",
+			version:  "0.1.0",
+			expected: "<Project Sdk=\"Microsoft.NET.Sdk\">\n  <PropertyGroup>\n    <VersionPrefix>0.9.0</VersionPrefix>\n    <Version>1.2.3</Version>\n  </PropertyGroup>\n</Project>\n",
+		},
+		{
+			filename: "Chart.yaml",
+			handler:  "helm",
+			source:   "apiVersion: v1\nappVersion: 0.0.1\nname: demo\nversion: \"0.0.1\"\n",
+			version:  "0.0.1",
+			expected: "apiVersion: v1\nappVersion: 0.0.1\nname: demo\nversion: \"1.2.3\"\n",
+		},
+		{
+			filename: "Makefile",
+			handler:  "make",
+			source:   "NAME := demo\nVERSION ?= 0.1.0\n\nbuild:\n\tgo build\n",
+			version:  "0.1.0",
+			expected: "NAME := demo\nVERSION ?= 1.2.3\n\nbuild:\n\tgo build\n",
+		},
+		{
+			filename: "version.go",
+			handler:  "go",
+			source:   "package version\n\n// Version the version of the binary\nconst Version = \"0.1.0\"\n",
+			version:  "0.1.0",
+			expected: "package version\n\n// Version the version of the binary\nconst Version = \"1.2.3\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		handler := versionfile.FindHandler(tc.filename)
+		require.NotNil(t, handler, "no handler for %s", tc.filename)
+		assert.Equal(t, tc.handler, handler.Name(), "handler for %s", tc.filename)
+
+		version, err := handler.GetVersion([]byte(tc.source))
+		require.NoError(t, err, "failed to get version of %s", tc.filename)
+		assert.Equal(t, tc.version, version, "version of %s", tc.filename)
+
+		data, err := handler.SetVersion([]byte(tc.source), "1.2.3")
+		require.NoError(t, err, "failed to set version of %s", tc.filename)
+		assert.Equal(t, tc.expected, string(data), "updated %s", tc.filename)
+	}
+}
+
+func TestHandlersWithoutVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"pom.xml":        "<project><parent><version>9</version></parent></project>",
+		"Cargo.toml":     "[package]\nname = \"demo\"\nversion.workspace = true\n",
+		"setup.cfg":      "[metadata]\nname = demo\nversion = attr: demo.__version__\n",
+		"pyproject.toml": "[project]\nname = \"demo\"\ndynamic = [\"version\"]\n",
+		"Makefile":       "NAME := demo\n",
+	}
+	for filename, source := range testCases {
+		handler := versionfile.FindHandler(filename)
+		require.NotNil(t, handler, "no handler for %s", filename)
+
+		version, err := handler.GetVersion([]byte(source))
+		require.NoError(t, err, "failed to get version of %s", filename)
+		assert.Empty(t, version, "version of %s", filename)
+
+		_, err = handler.SetVersion([]byte(source), "1.2.3")
+		assert.Error(t, err, "should fail to set version of %s", filename)
+	}
+
+	assert.Nil(t, versionfile.FindHandler("README.md"))
+}
+
+func TestDetectAndSetVersion(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-versionfile-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename, handler, err := versionfile.Detect(dir)
+	require.NoError(t, err)
+	assert.Empty(t, filename)
+	assert.Nil(t, handler)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "Makefile"), []byte("build:\n\tcargo build\n"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\nname = \"demo\"\nversion = \"0.1.0\"\n"), util.DefaultFileWritePermissions)
+	require.NoError(t, err)
+
+	filename, handler, err = versionfile.Detect(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Cargo.toml", filename, "should ignore a Makefile without a version")
+	require.NotNil(t, handler)
+	assert.Equal(t, "cargo", handler.Name())
+
+	err = versionfile.SetVersion(dir, filename, "0.2.0")
+	require.NoError(t, err)
+	version, err := versionfile.GetVersion(dir, filename)
+	require.NoError(t, err)
+	assert.Equal(t, "0.2.0", version)
+
+	err = versionfile.SetVersion(dir, "README.md", "0.2.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package versionfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlHandler handles the version in an element of an XML file
+type xmlHandler struct {
+	name     string
+	patterns []string
+	// elements the paths of the elements which can contain the version in order of precedence
+	elements [][]string
+}
+
+// NewMavenHandler creates a handler for the project version of a maven pom.xml
+func NewMavenHandler() Handler {
+	return &xmlHandler{
+		name:     "maven",
+		patterns: []string{"pom.xml"},
+		elements: [][]string{{"project", "version"}},
+	}
+}
+
+// NewCSProjHandler creates a handler for the version of a .NET SDK project file
+func NewCSProjHandler() Handler {
+	return &xmlHandler{
+		name:     "dotnet",
+		patterns: []string{"*.csproj"},
+		elements: [][]string{
+			{"Project", "PropertyGroup", "Version"},
+			{"Project", "PropertyGroup", "VersionPrefix"},
+		},
+	}
+}
+
+// Name returns the name of the handler
+func (h *xmlHandler) Name() string {
+	return h.name
+}
+
+// Patterns returns the file name patterns
+func (h *xmlHandler) Patterns() []string {
+	return h.patterns
+}
+
+// GetVersion returns the version in the file contents
+func (h *xmlHandler) GetVersion(data []byte) (string, error) {
+	r, err := h.find(data)
+	if err != nil || r == nil {
+		return "", err
+	}
+	return strings.TrimSpace(r.value), nil
+}
+
+// SetVersion returns the file contents with the version replaced
+func (h *xmlHandler) SetVersion(data []byte, version string) ([]byte, error) {
+	r, err := h.find(data)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || bytes.HasSuffix(data[:r.start], []byte("/>")) {
+		return nil, fmt.Errorf("no version found")
+	}
+	return r.replace(data, version), nil
+}
+
+// find finds the text of the first element in order of precedence
+func (h *xmlHandler) find(data []byte) (*textRange, error) {
+	found := make([]*textRange, len(h.elements))
+	var current *textRange
+	var stack []string
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			current = nil
+			for i, path := range h.elements {
+				if found[i] == nil && equalPaths(stack, path) {
+					offset := int(decoder.InputOffset())
+					current = &textRange{start: offset, end: offset}
+					found[i] = current
+				}
+			}
+		case xml.CharData:
+			if current != nil {
+				current.end = int(decoder.InputOffset())
+				current.value += string(t)
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			current = nil
+		}
+	}
+	for _, r := range found {
+		if r != nil {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func equalPaths(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
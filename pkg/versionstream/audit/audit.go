@@ -0,0 +1,277 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/cve"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+)
+
+// Available the available versions of a dependency
+type Available struct {
+	// Latest the latest version
+	Latest string
+	// Released the times the versions were released if they are known
+	Released map[string]time.Time
+}
+
+// Finder finds the available versions of the dependency of a stable version
+type Finder interface {
+	// FindAvailable returns the available versions or nil if they cannot be found
+	FindAvailable(name string, version *versionstream.StableVersion) (*Available, error)
+}
+
+// FinderFunc adapts a function to a Finder
+type FinderFunc func(name string, version *versionstream.StableVersion) (*Available, error)
+
+// FindAvailable returns the available versions
+func (f FinderFunc) FindAvailable(name string, version *versionstream.StableVersion) (*Available, error) {
+	return f(name, version)
+}
+
+// Auditor audits the stable versions of a version stream against the latest available versions
+type Auditor struct {
+	// Finders the finders of the available versions by kind. Kinds without a finder are skipped
+	Finders map[versionstream.VersionKind]Finder
+	// CVEProvider checks the docker images for vulnerabilities if specified
+	CVEProvider cve.CVEProvider
+	// Includes the name patterns to include, all names are included if empty
+	Includes []string
+	// Excludes the name patterns to exclude
+	Excludes []string
+	// Now returns the current time, defaults to time.Now
+	Now func() time.Time
+}
+
+// Report the result of auditing a version stream
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Results   []*Result `json:"results"`
+}
+
+// Result the result of auditing a stable version
+type Result struct {
+	Kind            versionstream.VersionKind `json:"kind"`
+	Name            string                    `json:"name"`
+	Version         string                    `json:"version"`
+	LatestVersion   string                    `json:"latestVersion,omitempty"`
+	Distance        *Distance                 `json:"distance,omitempty"`
+	DaysBehind      *int                      `json:"daysBehind,omitempty"`
+	Vulnerabilities []cve.Vulnerability       `json:"vulnerabilities,omitempty"`
+	Error           string                    `json:"error,omitempty"`
+}
+
+// Distance the semantic version distance from a version to the latest version
+type Distance struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// Audit audits the stable versions in the version stream directory
+func (a *Auditor) Audit(dir string) (*Report, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	report := &Report{
+		Generated: now(),
+	}
+	err := versionstream.ForEachVersion(dir, func(kind versionstream.VersionKind, name string, version *versionstream.StableVersion) (bool, error) {
+		finder := a.Finders[kind]
+		if finder == nil || version.Version == "" || !util.StringMatchesAny(name, a.Includes, a.Excludes) {
+			return true, nil
+		}
+		report.Results = append(report.Results, a.auditVersion(finder, kind, name, version))
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "auditing the version stream in %s", dir)
+	}
+	return report, nil
+}
+
+func (a *Auditor) auditVersion(finder Finder, kind versionstream.VersionKind, name string, version *versionstream.StableVersion) *Result {
+	result := &Result{
+		Kind:    kind,
+		Name:    name,
+		Version: version.Version,
+	}
+	available, err := finder.FindAvailable(name, version)
+	if err != nil {
+		log.Logger().Warnf("failed to find the latest version of %s %s: %s", kind, util.ColorInfo(name), err)
+		result.Error = err.Error()
+	} else if available != nil && available.Latest != "" {
+		result.LatestVersion = available.Latest
+		result.Distance = VersionDistance(version.Version, available.Latest)
+		result.DaysBehind = daysBehind(available, version.Version)
+	}
+
+	if kind == versionstream.KindDocker && a.CVEProvider != nil {
+		vulnerabilities, err := a.CVEProvider.GetImageVulnerabilities(cve.CVEQuery{
+			ImageName: name,
+			Vesion:    version.Version,
+		})
+		if err != nil {
+			log.Logger().Warnf("failed to find the vulnerabilities of image %s:%s: %s", util.ColorInfo(name), version.Version, err)
+		}
+		result.Vulnerabilities = vulnerabilities
+	}
+	return result
+}
+
+// VersionDistance returns the distance from the version to the latest version or nil if either is not a semantic
+// version. Only the most significant difference is counted, e.g. 1.2.3 to 2.0.1 is 1 major version
+func VersionDistance(version string, latest string) *Distance {
+	from, err := semver.ParseTolerant(version)
+	if err != nil {
+		return nil
+	}
+	to, err := semver.ParseTolerant(latest)
+	if err != nil {
+		return nil
+	}
+	answer := &Distance{}
+	switch {
+	case !to.GT(from):
+	case to.Major != from.Major:
+		answer.Major = int(to.Major - from.Major)
+	case to.Minor != from.Minor:
+		answer.Minor = int(to.Minor - from.Minor)
+	default:
+		answer.Patch = int(to.Patch - from.Patch)
+	}
+	return answer
+}
+
+// IsZero returns true if the version is the latest version
+func (d *Distance) IsZero() bool {
+	return d.Major == 0 && d.Minor == 0 && d.Patch == 0
+}
+
+// String returns a description of the distance
+func (d *Distance) String() string {
+	switch {
+	case d.Major > 0:
+		return fmt.Sprintf("%d major", d.Major)
+	case d.Minor > 0:
+		return fmt.Sprintf("%d minor", d.Minor)
+	case d.Patch > 0:
+		return fmt.Sprintf("%d patch", d.Patch)
+	}
+	return "up to date"
+}
+
+// daysBehind returns the number of days between the release of the version and the latest version if they are known
+func daysBehind(available *Available, version string) *int {
+	current, ok := available.Released[version]
+	if !ok {
+		return nil
+	}
+	latest, ok := available.Released[available.Latest]
+	if !ok {
+		return nil
+	}
+	days := 0
+	if latest.After(current) {
+		days = int(latest.Sub(current).Hours() / 24)
+	}
+	return &days
+}
+
+// IsOutdated returns true if there is a newer version
+func (r *Result) IsOutdated() bool {
+	if r.Distance != nil {
+		return !r.Distance.IsZero()
+	}
+	return r.LatestVersion != "" && r.LatestVersion != r.Version
+}
+
+// Outdated returns the results which have a newer version
+func (r *Report) Outdated() []*Result {
+	var answer []*Result
+	for _, result := range r.Results {
+		if result.IsOutdated() {
+			answer = append(answer, result)
+		}
+	}
+	return answer
+}
+
+// Vulnerable returns the results which have vulnerabilities
+func (r *Report) Vulnerable() []*Result {
+	var answer []*Result
+	for _, result := range r.Results {
+		if len(result.Vulnerabilities) > 0 {
+			answer = append(answer, result)
+		}
+	}
+	return answer
+}
+
+// Markdown returns the report as markdown
+func (r *Report) Markdown() string {
+	var buffer strings.Builder
+	outdated := r.Outdated()
+	vulnerable := r.Vulnerable()
+	buffer.WriteString("# Version Stream Audit\n\n")
+	buffer.WriteString(fmt.Sprintf("Audited %d versions on %s: %d outdated and %d vulnerable\n", len(r.Results), r.Generated.Format("2006-01-02"), len(outdated), len(vulnerable)))
+
+	if len(outdated) > 0 {
+		sorted := append([]*Result{}, outdated...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Kind < sorted[j].Kind || (sorted[i].Kind == sorted[j].Kind && sorted[i].Name < sorted[j].Name)
+		})
+		buffer.WriteString("\n## Outdated\n\n")
+		buffer.WriteString("| Kind | Name | Version | Latest | Distance | Days Behind |\n")
+		buffer.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, result := range sorted {
+			distance := ""
+			if result.Distance != nil {
+				distance = result.Distance.String()
+			}
+			days := ""
+			if result.DaysBehind != nil {
+				days = fmt.Sprintf("%d", *result.DaysBehind)
+			}
+			buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n", result.Kind, result.Name, result.Version, result.LatestVersion, distance, days))
+		}
+	}
+
+	if len(vulnerable) > 0 {
+		buffer.WriteString("\n## Vulnerable\n\n")
+		buffer.WriteString("| Image | Version | Severity | Vulnerability | Package | Fix |\n")
+		buffer.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, result := range vulnerable {
+			for _, v := range result.Vulnerabilities {
+				vuln := v.Vuln
+				if v.URL != "" {
+					vuln = fmt.Sprintf("[%s](%s)", v.Vuln, v.URL)
+				}
+				buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n", result.Name, result.Version, v.Severity, vuln, v.Package, v.Fix))
+			}
+		}
+	}
+
+	var failed []*Result
+	for _, result := range r.Results {
+		if result.Error != "" {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) > 0 {
+		buffer.WriteString("\n## Unknown\n\n")
+		buffer.WriteString("The latest versions of these could not be found:\n\n")
+		for _, result := range failed {
+			buffer.WriteString(fmt.Sprintf("* %s %s: %s\n", result.Kind, result.Name, result.Error))
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,186 @@
+// +build unit
+
+package audit_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-version-audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"charts/repositories.yml":                 "repositories: []\n",
+		"charts/jenkins-x/prow.yml":               "version: 0.0.176\n",
+		"charts/stable/nginx-ingress.yml":         "version: 1.2.3\n",
+		"docker/gcr.io/jenkinsxio/builder-jx.yml": "version: 1.0.0\n",
+		"packages/helm.yml":                       "version: 2.12.2\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), util.DefaultWritePermissions))
+		require.NoError(t, ioutil.WriteFile(path, []byte(text), util.DefaultWritePermissions))
+	}
+
+	released := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	auditor := &audit.Auditor{
+		Finders: map[versionstream.VersionKind]audit.Finder{
+			versionstream.KindChart: audit.FinderFunc(func(name string, version *versionstream.StableVersion) (*audit.Available, error) {
+				if name == "stable/nginx-ingress" {
+					return nil, fmt.Errorf("chart not found")
+				}
+				return &audit.Available{Latest: "0.1.2"}, nil
+			}),
+			versionstream.KindDocker: audit.FinderFunc(func(name string, version *versionstream.StableVersion) (*audit.Available, error) {
+				return &audit.Available{
+					Latest: "1.0.0",
+					Released: map[string]time.Time{
+						"1.0.0": released,
+					},
+				}, nil
+			}),
+			versionstream.KindPackage: audit.FinderFunc(func(name string, version *versionstream.StableVersion) (*audit.Available, error) {
+				return &audit.Available{
+					Latest: "2.14.0",
+					Released: map[string]time.Time{
+						"2.12.2": released,
+						"2.14.0": released.Add(45 * 24 * time.Hour),
+					},
+				}, nil
+			}),
+		},
+		Excludes: []string{"jenkins-x/*"},
+		Now: func() time.Time {
+			return released
+		},
+	}
+
+	report, err := auditor.Audit(dir)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 3)
+
+	results := map[string]*audit.Result{}
+	for _, r := range report.Results {
+		results[r.Name] = r
+	}
+	assert.NotContains(t, results, "jenkins-x/prow")
+
+	chart := results["stable/nginx-ingress"]
+	require.NotNil(t, chart)
+	assert.Equal(t, "chart not found", chart.Error)
+	assert.False(t, chart.IsOutdated())
+
+	image := results["gcr.io/jenkinsxio/builder-jx"]
+	require.NotNil(t, image)
+	assert.False(t, image.IsOutdated())
+	require.NotNil(t, image.DaysBehind)
+	assert.Equal(t, 0, *image.DaysBehind)
+
+	helm := results["helm"]
+	require.NotNil(t, helm)
+	assert.True(t, helm.IsOutdated())
+	assert.Equal(t, "2.14.0", helm.LatestVersion)
+	assert.Equal(t, "2 minor", helm.Distance.String())
+	require.NotNil(t, helm.DaysBehind)
+	assert.Equal(t, 45, *helm.DaysBehind)
+
+	assert.Len(t, report.Outdated(), 1)
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "| packages | helm | 2.12.2 | 2.14.0 | 2 minor | 45 |")
+	assert.Contains(t, markdown, "* charts stable/nginx-ingress: chart not found")
+}
+
+func TestVersionDistance(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		version  string
+		latest   string
+		expected string
+	}{
+		{"1.2.3", "1.2.3", "up to date"},
+		{"1.2.3", "1.2.5", "2 patch"},
+		{"v1.2.3", "1.4.0", "2 minor"},
+		{"1.2.3", "3.0.1", "2 major"},
+		{"1.2.3", "1.0.0", "up to date"},
+	}
+	for _, tc := range testCases {
+		d := audit.VersionDistance(tc.version, tc.latest)
+		require.NotNil(t, d, "distance from %s to %s", tc.version, tc.latest)
+		assert.Equal(t, tc.expected, d.String(), "distance from %s to %s", tc.version, tc.latest)
+	}
+	assert.Nil(t, audit.VersionDistance("latest", "1.0.0"))
+}
+
+func TestDockerFinder(t *testing.T) {
+	var server *httptest.Server
+	olderPages := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/jenkinsxio/builder-jx/tags/list":
+			fmt.Fprint(w, `{"tags": ["1.0.0", "1.1.0", "2.0.0-rc.1", "latest"],
+				"manifest": {"sha256:abc": {"tag": ["1.1.0"], "timeCreatedMs": "1577836800000"}}}`)
+		case "/hub/library/nginx/tags":
+			fmt.Fprint(w, `{"results": [
+				{"name": "1.17.0", "last_updated": "2019-06-01T00:00:00Z"},
+				{"name": "1.17.9", "last_updated": "2020-03-01T00:00:00Z"},
+				{"name": "mainline", "last_updated": "2020-03-02T00:00:00Z"}]}`)
+		case "/hub/library/redis/tags":
+			if r.URL.Query().Get("page") == "2" {
+				olderPages++
+				fmt.Fprint(w, `{"results": [{"name": "5.0.0", "last_updated": "2019-01-01T00:00:00Z"}]}`)
+				return
+			}
+			assert.Equal(t, "last_updated", r.URL.Query().Get("ordering"))
+			fmt.Fprintf(w, `{"next": "%s/hub/library/redis/tags?page=2", "results": [
+				{"name": "6.0.1", "last_updated": "2020-05-01T00:00:00Z"},
+				{"name": "latest", "last_updated": "2020-05-01T00:00:00Z"}]}`, server.URL)
+		case "/hub/library/redis/tags/5.0.0":
+			fmt.Fprint(w, `{"name": "5.0.0", "last_updated": "2019-01-01T00:00:00Z"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	finder := &audit.DockerFinder{
+		DockerHubURL:   server.URL + "/hub",
+		RegistryScheme: "http",
+	}
+
+	available, err := finder.FindAvailable(host+"/jenkinsxio/builder-jx", &versionstream.StableVersion{Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", available.Latest)
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), available.Released["1.1.0"])
+
+	available, err = finder.FindAvailable("nginx", &versionstream.StableVersion{Version: "1.17.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.17.9", available.Latest)
+	assert.Len(t, available.Released, 3)
+
+	available, err = finder.FindAvailable("redis", &versionstream.StableVersion{Version: "5.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "6.0.1", available.Latest)
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), available.Released["5.0.0"])
+	assert.Equal(t, 0, olderPages, "should stop paging once a newer version is found")
+
+	_, err = finder.FindAvailable(host+"/jenkinsxio/missing", &versionstream.StableVersion{Version: "1.0.0"})
+	assert.Error(t, err)
+}
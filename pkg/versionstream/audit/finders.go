@@ -0,0 +1,298 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/helm"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/jx/v2/pkg/versionstream"
+	"github.com/pkg/errors"
+)
+
+const dockerHubAPI = "https://hub.docker.com/v2/repositories"
+
+// ChartFinder finds the available versions of charts using helm
+type ChartFinder struct {
+	Helmer helm.Helmer
+	// VersionsDir the version stream directory which contains the chart repository prefixes
+	VersionsDir string
+
+	prefixes *versionstream.RepositoryPrefixes
+	repos    map[string]string
+}
+
+// FindAvailable returns the latest version of the chart
+func (f *ChartFinder) FindAvailable(name string, version *versionstream.StableVersion) (*Available, error) {
+	searchName, err := f.searchName(name)
+	if err != nil {
+		return nil, err
+	}
+	charts, err := f.Helmer.SearchCharts(searchName, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "searching for chart %s", searchName)
+	}
+	var versions []string
+	for _, c := range charts {
+		if c.Name == searchName {
+			versions = append(versions, c.ChartVersion)
+		}
+	}
+	latest := LatestVersion(versions)
+	if latest == "" {
+		return nil, fmt.Errorf("no version found for chart %s", name)
+	}
+	return &Available{Latest: latest}, nil
+}
+
+// searchName adds the chart repository of the prefix of the chart name if it is missing and returns the name to search
+func (f *ChartFinder) searchName(name string) (string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 {
+		return name, nil
+	}
+	if f.repos == nil {
+		f.repos = map[string]string{}
+	}
+	prefix := parts[0]
+	repoName, ok := f.repos[prefix]
+	if !ok {
+		if f.prefixes == nil {
+			prefixes, err := versionstream.GetRepositoryPrefixes(f.VersionsDir)
+			if err != nil {
+				return "", errors.Wrapf(err, "getting repository prefixes")
+			}
+			f.prefixes = prefixes
+		}
+		repoName = prefix
+		urls := f.prefixes.URLsForPrefix(prefix)
+		if len(urls) > 0 {
+			var err error
+			repoName, err = helm.AddHelmRepoIfMissing(urls[0], prefix, "", "", f.Helmer, nil, util.IOFileHandles{})
+			if err != nil {
+				return "", errors.Wrapf(err, "adding repository %s with url %s", prefix, urls[0])
+			}
+		}
+		f.repos[prefix] = repoName
+	}
+	return repoName + "/" + parts[1], nil
+}
+
+// GitFinder finds the available versions of git repositories and packages from the releases of their git repository
+type GitFinder struct {
+	// CreateProvider creates the git provider for a git URL
+	CreateProvider func(gitURL string) (gits.GitProvider, *gits.GitRepository, error)
+}
+
+// FindAvailable returns the latest release of the git repository
+func (f *GitFinder) FindAvailable(name string, version *versionstream.StableVersion) (*Available, error) {
+	gitURL := version.GitURL
+	if gitURL == "" {
+		if !strings.Contains(name, "/") {
+			// a package without a git repository
+			return nil, nil
+		}
+		gitURL = "https://" + name
+	}
+	provider, gitInfo, err := f.CreateProvider(gitURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating git provider for %s", gitURL)
+	}
+	releases, err := provider.ListReleases(gitInfo.Organisation, gitInfo.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing releases of %s", gitURL)
+	}
+	answer := &Available{
+		Released: map[string]time.Time{},
+	}
+	var versions []string
+	for _, r := range releases {
+		v := r.TagName
+		if v == "" {
+			v = r.Name
+		}
+		v = strings.TrimPrefix(v, "v")
+		versions = append(versions, v)
+		if r.PublishedAt != nil {
+			answer.Released[v] = *r.PublishedAt
+		}
+	}
+	answer.Latest = LatestVersion(versions)
+	if answer.Latest == "" {
+		return nil, fmt.Errorf("no releases found for %s", gitURL)
+	}
+	// the stable version may use a v prefix
+	if t, ok := answer.Released[strings.TrimPrefix(version.Version, "v")]; ok {
+		answer.Released[version.Version] = t
+	}
+	return answer, nil
+}
+
+// DockerFinder finds the available versions of docker images from the tags in their registry
+type DockerFinder struct {
+	Client *http.Client
+	// DockerHubURL the URL of the Docker Hub API, defaults to https://hub.docker.com/v2/repositories
+	DockerHubURL string
+	// RegistryScheme the scheme used to access other registries, defaults to https
+	RegistryScheme string
+}
+
+type dockerHubTags struct {
+	Next    string         `json:"next"`
+	Results []dockerHubTag `json:"results"`
+}
+
+type dockerHubTag struct {
+	Name        string    `json:"name"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+type registryTags struct {
+	Tags     []string `json:"tags"`
+	Manifest map[string]struct {
+		Tag           []string `json:"tag"`
+		TimeCreatedMs string   `json:"timeCreatedMs"`
+	} `json:"manifest"`
+}
+
+// FindAvailable returns the latest tag of the image
+func (f *DockerFinder) FindAvailable(name string, version *versionstream.StableVersion) (*Available, error) {
+	host, repo := splitImage(name)
+	answer := &Available{
+		Released: map[string]time.Time{},
+	}
+	var versions []string
+	if host == "" {
+		u := f.DockerHubURL
+		if u == "" {
+			u = dockerHubAPI
+		}
+		u = fmt.Sprintf("%s/%s/tags", strings.TrimSuffix(u, "/"), repo)
+		// the newest tags come first so we can stop paging once a newer version than the pinned one is found
+		next := u + "?page_size=100&ordering=last_updated"
+		for next != "" {
+			tags := &dockerHubTags{}
+			err := f.get(next, tags)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range tags.Results {
+				versions = append(versions, t.Name)
+				answer.Released[t.Name] = t.LastUpdated
+			}
+			next = tags.Next
+			if next != "" && isNewerVersion(LatestVersion(versions), version.Version) {
+				break
+			}
+		}
+		if _, ok := answer.Released[version.Version]; !ok && version.Version != "" {
+			// the pinned tag was on a page we did not fetch so look up when it was pushed
+			tag := &dockerHubTag{}
+			err := f.get(u+"/"+version.Version, tag)
+			if err == nil {
+				answer.Released[version.Version] = tag.LastUpdated
+			}
+		}
+	} else {
+		scheme := f.RegistryScheme
+		if scheme == "" {
+			scheme = "https"
+		}
+		tags := &registryTags{}
+		err := f.get(fmt.Sprintf("%s://%s/v2/%s/tags/list", scheme, host, repo), tags)
+		if err != nil {
+			return nil, err
+		}
+		versions = tags.Tags
+		// some registries such as gcr.io include the creation time of the manifests
+		for _, m := range tags.Manifest {
+			ms, err := strconv.ParseInt(m.TimeCreatedMs, 10, 64)
+			if err != nil {
+				continue
+			}
+			for _, t := range m.Tag {
+				answer.Released[t] = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+			}
+		}
+	}
+	answer.Latest = LatestVersion(versions)
+	if answer.Latest == "" {
+		return nil, fmt.Errorf("no version tags found for image %s", name)
+	}
+	return answer, nil
+}
+
+func (f *DockerFinder) get(u string, result interface{}) error {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(u)
+	if err != nil {
+		return errors.Wrapf(err, "getting %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error response getting %s: %s", u, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return errors.Wrapf(err, "parsing the response of %s", u)
+	}
+	return nil
+}
+
+// splitImage splits an image name into its registry host and repository. The host is empty for Docker Hub images
+func splitImage(name string) (string, string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		if parts[0] != "docker.io" {
+			return parts[0], parts[1]
+		}
+		name = parts[1]
+	}
+	if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return "", name
+}
+
+// isNewerVersion returns true if the latest version is a newer semantic version than the pinned version
+func isNewerVersion(latest string, pinned string) bool {
+	if latest == "" {
+		return false
+	}
+	lv, err := semver.ParseTolerant(latest)
+	if err != nil {
+		return false
+	}
+	pv, err := semver.ParseTolerant(pinned)
+	if err != nil {
+		return false
+	}
+	return lv.GT(pv)
+}
+
+// LatestVersion returns the latest of the semantic versions ignoring pre-releases and tags which are
+// not semantic versions such as latest
+func LatestVersion(versions []string) string {
+	latest := ""
+	var latestVersion semver.Version
+	for _, v := range versions {
+		sv, err := semver.ParseTolerant(v)
+		if err != nil || len(sv.Pre) > 0 {
+			continue
+		}
+		if latest == "" || sv.GT(latestVersion) {
+			latest = v
+			latestVersion = sv
+		}
+	}
+	return latest
+}
@@ -491,6 +491,59 @@ func (p *RepositoryPrefixes) URLsForPrefix(prefix string) []string {
 	return p.prefixToURLs[prefix]
 }
 
+// ForEachVersion invokes the callback for each stable version of all the kinds in the version stream directory
+func ForEachVersion(dir string, callback Callback) error {
+	for _, kind := range Kinds {
+		carryOn, err := forEachKindVersion(dir, kind, callback)
+		if err != nil || !carryOn {
+			return err
+		}
+	}
+	return nil
+}
+
+func forEachKindVersion(dir string, kind VersionKind, callback Callback) (bool, error) {
+	kindDir := filepath.Join(dir, string(kind))
+	exists, err := util.DirExists(kindDir)
+	if err != nil || !exists {
+		return true, err
+	}
+	var paths []string
+	err = filepath.Walk(kindDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".yml" {
+			return nil
+		}
+		if kind == KindChart && filepath.Dir(path) == kindDir && info.Name() == "repositories.yml" {
+			// the chart repository prefixes are not a stable version
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to find the versions in %s", kindDir)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name, err := NameFromPath(kindDir, path)
+		if err != nil {
+			return false, err
+		}
+		version, err := LoadStableVersionFile(path)
+		if err != nil {
+			return false, err
+		}
+		carryOn, err := callback(kind, filepath.ToSlash(name), version)
+		if err != nil || !carryOn {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 // NameFromPath converts a path into a name for use with stable versions
 func NameFromPath(basepath string, path string) (string, error) {
 	name, err := filepath.Rel(basepath, path)
@@ -0,0 +1,327 @@
+package yamlmerge
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Resolution is how a conflict is resolved
+type Resolution int
+
+const (
+	// Unresolved the conflict is not resolved, our value is kept and the conflict is returned
+	Unresolved Resolution = iota
+	// Ours the conflict is resolved by keeping our value
+	Ours
+	// Theirs the conflict is resolved by using their value
+	Theirs
+)
+
+// Conflict is a value which has been changed differently by both sides of a merge
+type Conflict struct {
+	// Path is the path of the value, e.g. cluster.provider or environments[staging]
+	Path string `json:"path"`
+	// Base is the YAML of the common ancestor value, empty if it did not exist
+	Base string `json:"base,omitempty"`
+	// Ours is the YAML of our value, empty if we deleted it
+	Ours string `json:"ours,omitempty"`
+	// Theirs is the YAML of their value, empty if they deleted it
+	Theirs string `json:"theirs,omitempty"`
+}
+
+// Merger performs three-way merges of YAML documents using the structure of the documents rather than their text
+type Merger struct {
+	// Resolve is invoked for each conflict if it is specified, e.g. to ask the user how to resolve it
+	Resolve func(conflict Conflict) (Resolution, error)
+}
+
+// Merge does a three-way merge of the YAML documents using the default Merger. Conflicts are left unresolved
+func Merge(base []byte, ours []byte, theirs []byte) ([]byte, []Conflict, error) {
+	m := &Merger{}
+	return m.Merge(base, ours, theirs)
+}
+
+// Merge does a three-way merge of our and their changes to the base YAML document. Changes which do not overlap are
+// merged automatically, e.g. a key added by them and another key changed by us. Our values, including their comments,
+// are kept for the unresolved conflicts which are returned. Our document is returned byte for byte if the merge does
+// not change its values, e.g. because they made no changes, so that it is not reformatted
+func (m *Merger) Merge(base []byte, ours []byte, theirs []byte) ([]byte, []Conflict, error) {
+	baseNode, err := parse(base)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the base YAML")
+	}
+	ourNode, err := parse(ours)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing our YAML")
+	}
+	theirNode, err := parse(theirs)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing their YAML")
+	}
+
+	if equal(baseNode, theirNode) || equal(ourNode, theirNode) {
+		return ours, nil, nil
+	}
+
+	var conflicts []Conflict
+	result, err := m.merge("", baseNode, ourNode, theirNode, &conflicts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if equal(result, ourNode) {
+		return ours, conflicts, nil
+	}
+	if result == nil {
+		return []byte{}, conflicts, nil
+	}
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err = encoder.Encode(result)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling the merged YAML")
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling the merged YAML")
+	}
+	return buffer.Bytes(), conflicts, nil
+}
+
+func (m *Merger) merge(path string, base *yaml.Node, ours *yaml.Node, theirs *yaml.Node, conflicts *[]Conflict) (*yaml.Node, error) {
+	switch {
+	case equal(ours, theirs), equal(base, theirs):
+		return ours, nil
+	case isMapping(ours) && isMapping(theirs) && (base == nil || isMapping(base)):
+		return m.mergeMappings(path, base, ours, theirs, conflicts)
+	case isSequence(ours) && isSequence(theirs) && (base == nil || isSequence(base)):
+		if field := identityField(base, ours, theirs); field != "" {
+			return m.mergeSequences(path, field, base, ours, theirs, conflicts)
+		}
+	}
+	// only they changed the value so lets take theirs. This is checked after merging the mappings and sequences
+	// so that our comments and order are kept in the values which only they changed
+	if equal(base, ours) {
+		return theirs, nil
+	}
+
+	if path == "" {
+		path = "."
+	}
+	conflict := Conflict{
+		Path:   path,
+		Base:   toYAML(base),
+		Ours:   toYAML(ours),
+		Theirs: toYAML(theirs),
+	}
+	resolution := Unresolved
+	if m.Resolve != nil {
+		var err error
+		resolution, err = m.Resolve(conflict)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resolving the conflict at %s", path)
+		}
+	}
+	switch resolution {
+	case Theirs:
+		return theirs, nil
+	case Unresolved:
+		*conflicts = append(*conflicts, conflict)
+	}
+	return ours, nil
+}
+
+// mergeMappings merges the keys of the mappings keeping the order of our keys followed by any keys they added
+func (m *Merger) mergeMappings(path string, base *yaml.Node, ours *yaml.Node, theirs *yaml.Node, conflicts *[]Conflict) (*yaml.Node, error) {
+	result := *ours
+	result.Content = nil
+
+	keys := []*yaml.Node{}
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		keys = append(keys, ours.Content[i])
+	}
+	for i := 0; i+1 < len(theirs.Content); i += 2 {
+		if _, value := lookup(ours, theirs.Content[i].Value); value == nil {
+			keys = append(keys, theirs.Content[i])
+		}
+	}
+	for _, key := range keys {
+		_, baseValue := lookup(base, key.Value)
+		_, ourValue := lookup(ours, key.Value)
+		theirKey, theirValue := lookup(theirs, key.Value)
+		if ourValue == nil {
+			key = theirKey
+		}
+		value, err := m.merge(childPath(path, key.Value), baseValue, ourValue, theirValue, conflicts)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			result.Content = append(result.Content, key, value)
+		}
+	}
+	return &result, nil
+}
+
+// mergeSequences merges the items of sequences of mappings which are identified by the value of a field such as key or
+// name, keeping the order of our items followed by any items they added
+func (m *Merger) mergeSequences(path string, field string, base *yaml.Node, ours *yaml.Node, theirs *yaml.Node, conflicts *[]Conflict) (*yaml.Node, error) {
+	result := *ours
+	result.Content = nil
+
+	ids := []string{}
+	for _, item := range ours.Content {
+		_, id := lookup(item, field)
+		ids = append(ids, id.Value)
+	}
+	for _, item := range theirs.Content {
+		_, id := lookup(item, field)
+		if findItem(ours, field, id.Value) == nil {
+			ids = append(ids, id.Value)
+		}
+	}
+	for _, id := range ids {
+		value, err := m.merge(fmt.Sprintf("%s[%s]", path, id), findItem(base, field, id), findItem(ours, field, id), findItem(theirs, field, id), conflicts)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			result.Content = append(result.Content, value)
+		}
+	}
+	return &result, nil
+}
+
+// identityField returns the field which identifies the items of the sequences or an empty string if the items are
+// not mappings with a unique key or name
+func identityField(sequences ...*yaml.Node) string {
+	for _, field := range []string{"key", "name"} {
+		valid := true
+		for _, sequence := range sequences {
+			if sequence == nil {
+				continue
+			}
+			seen := map[string]bool{}
+			for _, item := range sequence.Content {
+				_, id := lookup(item, field)
+				if !isMapping(item) || id == nil || id.Kind != yaml.ScalarNode || seen[id.Value] {
+					valid = false
+					break
+				}
+				seen[id.Value] = true
+			}
+			if !valid {
+				break
+			}
+		}
+		if valid {
+			return field
+		}
+	}
+	return ""
+}
+
+// findItem returns the item of the sequence with the value of the identity field or nil if it is not present
+func findItem(sequence *yaml.Node, field string, id string) *yaml.Node {
+	if sequence == nil {
+		return nil
+	}
+	for _, item := range sequence.Content {
+		if _, value := lookup(item, field); value != nil && value.Value == id {
+			return item
+		}
+	}
+	return nil
+}
+
+func parse(data []byte) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	err := yaml.Unmarshal(data, node)
+	if err != nil {
+		return nil, err
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0], nil
+	}
+	return nil, nil
+}
+
+// lookup returns the key and value of a key in a mapping or nil if it is not present
+func lookup(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if !isMapping(mapping) {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func isMapping(node *yaml.Node) bool {
+	return node != nil && node.Kind == yaml.MappingNode
+}
+
+func isSequence(node *yaml.Node) bool {
+	return node != nil && node.Kind == yaml.SequenceNode
+}
+
+// equal compares the values of the nodes ignoring comments and formatting
+func equal(a *yaml.Node, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func toYAML(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(node)
+	if err != nil {
+		return fmt.Sprintf("%v", err)
+	}
+	encoder.Close()
+	return strings.TrimSpace(buffer.String())
+}
+
+func childPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Markdown returns a markdown report of the conflicts in a file
+func Markdown(file string, conflicts []Conflict) string {
+	var buffer strings.Builder
+	buffer.WriteString(fmt.Sprintf("#### `%s`\n\n", file))
+	buffer.WriteString("| Path | Base | Ours | Theirs |\n")
+	buffer.WriteString("| --- | --- | --- | --- |\n")
+	for _, c := range conflicts {
+		buffer.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", c.Path, markdownValue(c.Base), markdownValue(c.Ours), markdownValue(c.Theirs)))
+	}
+	return buffer.String()
+}
+
+func markdownValue(value string) string {
+	if value == "" {
+		return "_none_"
+	}
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return "<code>" + strings.ReplaceAll(value, "\n", "<br>") + "</code>"
+}
@@ -0,0 +1,245 @@
+// +build unit
+
+package yamlmerge_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/yamlmerge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseRequirements = `cluster:
+  clusterName: mycluster
+  provider: gke
+  zone: europe-west1-b
+environments:
+- key: dev
+  owner: jenkins-x
+- key: staging
+- key: production
+storage:
+  logs:
+    enabled: false
+`
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	ours := `# my customised cluster
+cluster:
+  clusterName: mycluster
+  provider: gke
+  # we moved zone
+  zone: us-east1-b
+environments:
+- key: dev
+  owner: acme
+- key: staging
+- key: production
+  ingress:
+    domain: acme.com
+storage:
+  logs:
+    enabled: true
+    url: gs://my-logs
+`
+	theirs := `cluster:
+  clusterName: mycluster
+  provider: gke
+  zone: europe-west1-b
+  registry: gcr.io
+environments:
+- key: dev
+  owner: jenkins-x
+  repository: environment-dev
+- key: staging
+- key: production
+storage:
+  logs:
+    enabled: false
+    url: ""
+webhook: lighthouse
+`
+	expected := `# my customised cluster
+cluster:
+  clusterName: mycluster
+  provider: gke
+  # we moved zone
+  zone: us-east1-b
+  registry: gcr.io
+environments:
+- key: dev
+  owner: acme
+  repository: environment-dev
+- key: staging
+- key: production
+  ingress:
+    domain: acme.com
+storage:
+  logs:
+    enabled: true
+    url: gs://my-logs
+webhook: lighthouse
+`
+	merged, conflicts, err := yamlmerge.Merge([]byte(baseRequirements), []byte(ours), []byte(theirs))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(merged))
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, yamlmerge.Conflict{
+		Path:   "storage.logs.url",
+		Ours:   "gs://my-logs",
+		Theirs: `""`,
+	}, conflicts[0])
+}
+
+func TestMergeKeepsOursWhenUnchanged(t *testing.T) {
+	t.Parallel()
+
+	ours := `cluster: {clusterName: mycluster, provider: gke, zone: us-east1-b}
+environments:
+    - key: dev
+      owner: jenkins-x   # the owner
+    - key: staging
+    - key: production
+storage:
+    logs:
+        enabled: false
+`
+	// their document has the same values as the base but is formatted differently
+	theirs := "cluster: {clusterName: mycluster, provider: gke, zone: europe-west1-b}\n" + baseRequirements[strings.Index(baseRequirements, "environments:"):]
+	merged, conflicts, err := yamlmerge.Merge([]byte(baseRequirements), []byte(ours), []byte(theirs))
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, ours, string(merged))
+
+	// they changed a value to the one we already have
+	theirs = strings.Replace(baseRequirements, "europe-west1-b", "us-east1-b", 1)
+	merged, conflicts, err = yamlmerge.Merge([]byte(baseRequirements), []byte(ours), []byte(theirs))
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, ours, string(merged))
+}
+
+func TestMergeKeepsOurCommentsWhenOnlyTheyChanged(t *testing.T) {
+	t.Parallel()
+
+	ours := `# the cluster
+cluster:
+  # the name of the cluster
+  clusterName: mycluster
+  zone: europe-west1-b
+  provider: gke
+environments:
+- key: dev
+  # the owner
+  owner: jenkins-x
+- key: staging
+- key: production
+storage:
+  logs:
+    enabled: false
+`
+	theirs := `cluster:
+  clusterName: mycluster
+  provider: gke
+  zone: us-east1-b
+environments:
+- key: dev
+  owner: acme
+- key: staging
+- key: production
+storage:
+  logs:
+    enabled: false
+`
+	expected := `# the cluster
+cluster:
+  # the name of the cluster
+  clusterName: mycluster
+  zone: us-east1-b
+  provider: gke
+environments:
+- key: dev
+  # the owner
+  owner: acme
+- key: staging
+- key: production
+storage:
+  logs:
+    enabled: false
+`
+	merged, conflicts, err := yamlmerge.Merge([]byte(baseRequirements), []byte(ours), []byte(theirs))
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, expected, string(merged))
+}
+
+func TestMergeResolve(t *testing.T) {
+	t.Parallel()
+
+	ours := `cluster:
+  clusterName: mycluster
+  provider: gke
+  zone: us-east1-b
+environments:
+- key: dev
+  owner: jenkins-x
+storage:
+  logs:
+    enabled: false
+`
+	theirs := `cluster:
+  clusterName: mycluster
+  provider: gke
+  zone: europe-west2-a
+environments:
+- key: dev
+  owner: jenkins-x
+- key: staging
+  namespace: jx-staging
+- key: production
+storage:
+  logs:
+    enabled: true
+`
+	var paths []string
+	m := &yamlmerge.Merger{
+		Resolve: func(conflict yamlmerge.Conflict) (yamlmerge.Resolution, error) {
+			paths = append(paths, conflict.Path)
+			return yamlmerge.Theirs, nil
+		},
+	}
+	merged, conflicts, err := m.Merge([]byte(baseRequirements), []byte(ours), []byte(theirs))
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, []string{"cluster.zone", "environments[staging]"}, paths)
+	assert.Contains(t, string(merged), "zone: europe-west2-a")
+	assert.Contains(t, string(merged), "enabled: true")
+	assert.Contains(t, string(merged), "- key: staging\n  namespace: jx-staging\n")
+	assert.NotContains(t, string(merged), "production")
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	md := yamlmerge.Markdown("jx-requirements.yml", []yamlmerge.Conflict{
+		{
+			Path:   "cluster.zone",
+			Base:   "europe-west1-b",
+			Ours:   "us-east1-b",
+			Theirs: "europe-west2-a",
+		},
+		{
+			Path: "webhook",
+			Ours: "prow",
+		},
+	})
+	assert.Equal(t, "#### `jx-requirements.yml`\n\n"+
+		"| Path | Base | Ours | Theirs |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| `cluster.zone` | <code>europe-west1-b</code> | <code>us-east1-b</code> | <code>europe-west2-a</code> |\n"+
+		"| `webhook` | _none_ | <code>prow</code> | _none_ |\n", md)
+}